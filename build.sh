@@ -19,6 +19,7 @@ echo "Building Go Binary..."
 SHORT_SHA=$(git rev-parse --short HEAD 2>/dev/null || echo 'unknown')
 RELEASE_VERSION=$(grep -oE '[0-9]+\.[0-9]+\.[0-9]+' .release-please-manifest.json 2>/dev/null | head -1 || echo "0.0.0")
 VERSION="${RELEASE_VERSION}-${SHORT_SHA}"
-go build -ldflags="-X main.Version=$VERSION" ./cmd/streamnzb/
+BUILD_TIME=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+go build -ldflags="-X main.Version=$VERSION -X main.BuildTime=$BUILD_TIME" ./cmd/streamnzb/
 
 echo "Build Complete!"
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/initialization"
+	"streamnzb/pkg/media/loader"
+	"streamnzb/pkg/media/nzb"
+	"streamnzb/pkg/media/unpack"
+)
+
+// runDownload implements `streamnzb download --nzb <url|file> --out <path>`.
+// It resolves the release exactly like /play does (same RAR/7z/direct detection
+// via unpack.GetMediaStream) and copies the STORE-mode content file to disk.
+func runDownload(args []string) error {
+	fs := flag.NewFlagSet("download", flag.ContinueOnError)
+	nzbArg := fs.String("nzb", "", "NZB URL or local file path")
+	outPath := fs.String("out", "", "output file (defaults to the media filename inside the release)")
+	force := fs.Bool("force", false, "overwrite the output file if it exists")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: streamnzb download --nzb <url|file> [--out movie.mkv] [--force]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *nzbArg == "" {
+		fs.Usage()
+		return errors.New("missing --nzb")
+	}
+
+	cfg := loadConfig()
+
+	comp, err := initialization.BuildComponents(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to build components: %w", err)
+	}
+	if len(comp.StreamingPools) == 0 {
+		return errors.New("no usable NNTP providers configured")
+	}
+	defer func() {
+		for _, p := range comp.StreamingPools {
+			p.Shutdown()
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	data, err := readNZBSource(ctx, comp.Indexer, *nzbArg)
+	if err != nil {
+		return err
+	}
+	parsed, err := nzb.Parse(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse NZB: %w", err)
+	}
+	contentFiles := parsed.GetContentFiles()
+	if len(contentFiles) == 0 {
+		return errors.New("no content files found in NZB")
+	}
+
+	estimator := loader.NewSegmentSizeEstimator()
+	files := make([]*loader.File, 0, len(contentFiles))
+	for _, info := range contentFiles {
+		files = append(files, loader.NewFile(ctx, info.File, comp.StreamingPools, estimator))
+	}
+
+	stream, name, size, _, err := unpack.GetMediaStream(ctx, files, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open media stream: %w", err)
+	}
+	defer stream.Close()
+
+	dest := *outPath
+	if dest == "" {
+		// The name comes from the NZB or archive: never let it point outside the working dir
+		if dest, err = outputName(name); err != nil {
+			return err
+		}
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	out, err := os.OpenFile(dest, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	logger.Info("Downloading release", "file", name, "size", size, "out", dest)
+	bar := newProgressBar(os.Stderr, size)
+	written, err := io.Copy(io.MultiWriter(out, bar), stream)
+	bar.Finish()
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest)
+		return fmt.Errorf("download failed after %d bytes: %w", written, err)
+	}
+	if err := checkZeroFills(files); err != nil {
+		if errors.Is(err, loader.ErrTooManyZeroFills) {
+			os.Remove(dest)
+			return err
+		}
+		return fmt.Errorf("%w (output kept at %s)", err, dest)
+	}
+	logger.Info("Download complete", "out", dest, "bytes", written)
+	return nil
+}
+
+// checkZeroFills fails a download whose files had segments zero-filled: the loader fills
+// a few missing or timed-out segments to keep playback going, which leaves gaps on disk.
+func checkZeroFills(files []*loader.File) error {
+	var missing, slow int
+	for _, f := range files {
+		if f.IsFailed() {
+			return fmt.Errorf("download incomplete: %w", loader.ErrTooManyZeroFills)
+		}
+		m, s := f.ZeroFills()
+		missing += m
+		slow += s
+	}
+	if missing+slow > 0 {
+		return fmt.Errorf("download has gaps: %d missing and %d timed-out segments were zero-filled", missing, slow)
+	}
+	return nil
+}
+
+// outputName returns the base name of the release file name, rejecting names
+// that do not name a file.
+func outputName(name string) (string, error) {
+	base := filepath.Base(filepath.Clean(strings.ReplaceAll(name, "\\", "/")))
+	if base == "." || base == ".." || base == string(filepath.Separator) || base == "/" {
+		return "", fmt.Errorf("release file name %q is not usable, pass --out", name)
+	}
+	return base, nil
+}
+
+// readNZBSource reads an NZB from a local path, or downloads it via the
+// configured indexers with a plain HTTP fallback (same order as /debug/play).
+func readNZBSource(ctx context.Context, idx indexer.Indexer, src string) ([]byte, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read NZB file: %w", err)
+		}
+		return data, nil
+	}
+
+	dlCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+	if idx != nil {
+		if data, err := idx.DownloadNZB(dlCtx, src); err == nil {
+			return data, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(dlCtx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download NZB: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download NZB (HTTP %d)", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// progressBar is an io.Writer that renders a single-line progress bar.
+type progressBar struct {
+	w        io.Writer
+	total    int64
+	written  int64
+	start    time.Time
+	lastDraw time.Time
+}
+
+func newProgressBar(w io.Writer, total int64) *progressBar {
+	return &progressBar{w: w, total: total, start: time.Now()}
+}
+
+func (p *progressBar) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if time.Since(p.lastDraw) >= 200*time.Millisecond {
+		p.draw()
+	}
+	return len(b), nil
+}
+
+// Finish draws the final state and terminates the line.
+func (p *progressBar) Finish() {
+	p.draw()
+	fmt.Fprintln(p.w)
+}
+
+func (p *progressBar) draw() {
+	p.lastDraw = time.Now()
+	const width = 30
+	ratio := 0.0
+	if p.total > 0 {
+		ratio = float64(p.written) / float64(p.total)
+		if ratio > 1 {
+			ratio = 1
+		}
+	}
+	filled := int(ratio * width)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	speed := 0.0
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		speed = float64(p.written) / elapsed
+	}
+	fmt.Fprintf(p.w, "\r[%s] %5.1f%%  %s / %s  %s/s   ",
+		bar, ratio*100, formatBytes(p.written), formatBytes(p.total), formatBytes(int64(speed)))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
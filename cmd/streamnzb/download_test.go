@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/loader"
+	"streamnzb/pkg/media/nzb"
+	"streamnzb/pkg/usenet/nntp"
+)
+
+func TestOutputName(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"Movie.2001.mkv", "Movie.2001.mkv", true},
+		{"../../etc/cron.d/x", "x", true},
+		{"/abs/path/Movie.mkv", "Movie.mkv", true},
+		{`dir\..\Movie.mkv`, "Movie.mkv", true},
+		{"", "", false},
+		{".", "", false},
+		{"..", "", false},
+		{"a/..", "", false},
+		{"/", "", false},
+	}
+	for _, tt := range tests {
+		got, err := outputName(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("outputName(%q) = %q, %v; want %q, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+// missingArticlePool is a pool whose provider has none of the articles.
+func missingArticlePool(t *testing.T) *nntp.ClientPool {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tp := textproto.NewConn(conn)
+				tp.PrintfLine("200 ready")
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					if strings.HasPrefix(line, "BODY") {
+						tp.PrintfLine("430 no such article")
+					} else {
+						tp.PrintfLine("500 unsupported")
+					}
+				}
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	pool := nntp.NewClientPool(addr.IP.String(), addr.Port, false, "", "", 1)
+	t.Cleanup(pool.Shutdown)
+	return pool
+}
+
+func TestCheckZeroFills(t *testing.T) {
+	logger.Init("DEBUG")
+	pool := missingArticlePool(t)
+	newFile := func(segments int) *loader.File {
+		nf := &nzb.File{Subject: `"movie.mkv" yEnc (1/1)`}
+		for i := 1; i <= segments; i++ {
+			nf.Segments = append(nf.Segments, nzb.Segment{Bytes: 100, Number: i, ID: fmt.Sprintf("gap-%d@test", i)})
+		}
+		f := loader.NewFile(context.Background(), nf, []*nntp.ClientPool{pool}, nil)
+		f.SetSegmentTimeout(time.Second)
+		return f
+	}
+
+	complete := newFile(1)
+	if err := checkZeroFills([]*loader.File{complete}); err != nil {
+		t.Errorf("no segment filled: %v", err)
+	}
+
+	gap := newFile(2)
+	if _, err := gap.DownloadSegment(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	err := checkZeroFills([]*loader.File{complete, gap})
+	if err == nil || !strings.Contains(err.Error(), "1 missing") || errors.Is(err, loader.ErrTooManyZeroFills) {
+		t.Errorf("one missing segment: err = %v; want a gap error", err)
+	}
+
+	failed := newFile(loader.MaxZeroFills)
+	for i := 0; i < loader.MaxZeroFills; i++ {
+		failed.DownloadSegment(context.Background(), i)
+	}
+	if err := checkZeroFills([]*loader.File{failed}); !errors.Is(err, loader.ErrTooManyZeroFills) {
+		t.Errorf("failed file: err = %v; want ErrTooManyZeroFills", err)
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"streamnzb/pkg/auth"
@@ -14,6 +18,9 @@ import (
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/core/persistence"
 	"streamnzb/pkg/initialization"
+	"streamnzb/pkg/media/loader"
+	"streamnzb/pkg/media/unpack"
+	"streamnzb/pkg/search/triage"
 	"streamnzb/pkg/server/api"
 	"streamnzb/pkg/server/stremio"
 	"streamnzb/pkg/server/web"
@@ -21,6 +28,7 @@ import (
 	"streamnzb/pkg/usenet/nntp/proxy"
 
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 var (
@@ -35,9 +43,13 @@ var (
 
 	// Version set at build time via -ldflags (from release-please tag, e.g. v1.0.0)
 	Version = "dev"
+	// BuildTime set at build time via -ldflags (RFC 3339, e.g. 2024-05-01T12:00:00Z)
+	BuildTime = ""
 )
 
-func main() {
+// loadConfig loads .env, initializes the logger and loads config.json.
+// Shared by the server and the CLI subcommands.
+func loadConfig() *config.Config {
 	// Load environment variables for logger and bootstrap
 	if err := godotenv.Load(); err != nil {
 		fmt.Println("No .env file found, using environment variables")
@@ -53,7 +65,24 @@ func main() {
 	if err != nil {
 		initialization.WaitForInputAndExit(fmt.Errorf("configuration error: %w", err))
 	}
+	logger.SetFormat(cfg.LogFormat)
 	logger.SetLevel(cfg.LogLevel)
+	if err := logger.SetSubsystemLevels(cfg.LogLevels); err != nil {
+		logger.Warn("Ignoring log_levels", "err", err)
+	}
+	return cfg
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "download" {
+		if err := runDownload(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "download:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg := loadConfig()
 
 	availNZBUrl := os.Getenv(env.AvailNZBURL)
 	if availNZBUrl == "" {
@@ -117,20 +146,64 @@ func main() {
 	}
 
 	sessionManager := session.NewManager(comp.StreamingPools, 30*time.Minute)
+	sessionManager.SetReadAheadSegments(cfg.ReadAheadSegments)
+	sessionManager.SetPlaybackSegmentTimeout(time.Duration(cfg.PlaybackSegmentTimeoutMs) * time.Millisecond)
+	sessionManager.SetPauseRelease(time.Duration(cfg.PauseReleaseSeconds) * time.Second)
+	sessionManager.SetSweepInterval(time.Duration(cfg.SessionSweepIntervalSeconds) * time.Second)
+	sessionManager.SetIdleTimeout(time.Duration(cfg.SessionIdleTimeoutMinutes) * time.Minute)
 	logger.Info("Session manager initialized", "ttl", 30*time.Minute)
 
+	if cfg.BlueprintCacheMaxEntries > 0 {
+		maxAge := time.Duration(cfg.BlueprintCacheMaxAgeHours) * time.Hour
+		bpCache, err := unpack.NewBlueprintCache(filepath.Join(dataDir, "blueprints"), cfg.BlueprintCacheMaxEntries, maxAge)
+		if err != nil {
+			logger.Warn("Blueprint disk cache disabled", "err", err)
+		} else {
+			sessionManager.SetBlueprintCache(bpCache)
+		}
+	}
+
+	segDir := cfg.SegmentCacheDir
+	if segDir != "" && !filepath.IsAbs(segDir) {
+		segDir = filepath.Join(dataDir, segDir)
+	}
+	if err := loader.ConfigureSegmentCache(int64(cfg.SegmentCacheMemoryMB)<<20, segDir, int64(cfg.SegmentCacheDiskMB)<<20); err != nil {
+		logger.Warn("Segment disk cache disabled", "dir", segDir, "err", err)
+		loader.ConfigureSegmentCache(int64(cfg.SegmentCacheMemoryMB)<<20, "", 0)
+	} else if segDir != "" {
+		logger.Info("Segment disk cache enabled", "dir", segDir, "max_mb", cfg.SegmentCacheDiskMB)
+	}
+
+	if spoolDir := cfg.SegmentSpoolDir; spoolDir != "" {
+		if !filepath.IsAbs(spoolDir) {
+			spoolDir = filepath.Join(dataDir, spoolDir)
+		}
+		if err := loader.ConfigureSegmentSpool(spoolDir, int64(cfg.SegmentSpoolMaxMB)<<20); err != nil {
+			logger.Warn("Segment spool disabled", "dir", spoolDir, "err", err)
+		} else {
+			logger.Info("Segment spool enabled", "dir", spoolDir, "max_mb", cfg.SegmentSpoolMaxMB)
+		}
+	}
+
 	deviceManager, err := auth.GetDeviceManager(dataDir)
 	if err != nil {
 		initialization.WaitForInputAndExit(fmt.Errorf("failed to initialize device manager: %v", err))
 	}
 
-	stremioServer, err := stremio.NewServer(comp.Config, comp.Config.AddonBaseURL, comp.Config.AddonPort, comp.Indexer, comp.Validator,
+	stremioServer, err := stremio.NewServer(comp.Config, comp.Config.BaseURL(), comp.Config.AddonPort, comp.Indexer, comp.Validator,
 		sessionManager, comp.Triage, comp.AvailClient, comp.AvailNZBIndexerHosts, comp.TMDBClient, comp.TVDBClient, deviceManager, Version)
 	if err != nil {
 		initialization.WaitForInputAndExit(fmt.Errorf("failed to initialize Stremio server: %v", err))
 	}
 
+	stateMgr, err := persistence.GetManager(dataDir)
+	if err != nil {
+		logger.Warn("Release blacklist is not persisted", "err", err)
+	}
+	stremioServer.SetBlacklist(triage.NewBlacklist(stateMgr))
+
 	apiServer := api.NewServerWithApp(comp.Config, comp.ProviderPools, sessionManager, stremioServer, comp.Indexer, deviceManager, application, availNZBUrl, availNZBAPIKey, tmdbKey, tvdbKey)
+	apiServer.SetBuildTime(BuildTime)
 
 	// Set embedded web handler
 	stremioServer.SetWebHandler(web.Handler())
@@ -153,6 +226,7 @@ func main() {
 			initialization.WaitForInputAndExit(fmt.Errorf("failed to initialize NNTP proxy: %v", err))
 		}
 
+		proxyServer.SetPostPool(comp.ProxyPostPool())
 		apiServer.SetProxyServer(proxyServer)
 
 		go func() {
@@ -166,10 +240,53 @@ func main() {
 	// Start Stremio server
 	addr := fmt.Sprintf(":%d", comp.Config.AddonPort)
 
-	logger.Info("Stremio addon server starting", "base_url", comp.Config.AddonBaseURL, "port", comp.Config.AddonPort)
+	logger.Info("Stremio addon server starting", "base_url", comp.Config.BaseURL(), "port", comp.Config.AddonPort)
 	logger.Info("Note: Access requires device authentication tokens")
 
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	// Stop background work and drain requests on Ctrl+C / SIGTERM
+	srv := &http.Server{Addr: addr, Handler: mux}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		logger.Info("Shutting down")
+		apiServer.Close()
+		stremioServer.Close()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := listenAndServe(comp.Config, srv, dataDir); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		initialization.WaitForInputAndExit(fmt.Errorf("server failed: %w", err))
 	}
 }
+
+// shutdownTimeout bounds how long in-flight requests may run after a shutdown signal.
+const shutdownTimeout = 10 * time.Second
+
+// listenAndServe serves the addon over plain HTTP, or over HTTPS when a certificate/key
+// pair or an ACME domain is configured. Let's Encrypt certificates are cached in
+// <dataDir>/acme; challenges are answered on the TLS port (when it is 443) and on port 80.
+func listenAndServe(cfg *config.Config, srv *http.Server, dataDir string) error {
+	if domains := cfg.ACMEDomains(); len(domains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(filepath.Join(dataDir, "acme")),
+		}
+		srv.TLSConfig = m.TLSConfig()
+		go func() {
+			if err := http.ListenAndServe(":80", m.HTTPHandler(nil)); err != nil {
+				logger.Warn("ACME HTTP challenge listener on port 80 unavailable; relying on TLS-ALPN on the addon port", "err", err)
+			}
+		}()
+		logger.Info("Serving HTTPS with Let's Encrypt certificates", "domains", domains)
+		return srv.ListenAndServeTLS("", "")
+	}
+	if cfg.TLSEnabled() {
+		logger.Info("Serving HTTPS", "cert", cfg.TLSCertFile)
+		return srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	return srv.ListenAndServe()
+}
@@ -15,7 +15,7 @@ import {
 import { Area, AreaChart, ComposedChart, Line, Legend, ResponsiveContainer, XAxis, YAxis } from "recharts"
 import { 
   Activity, Server, Zap, Globe, Settings as SettingsIcon, AlertCircle, 
-  Sun, Moon, Monitor, X, Loader2, Tv, Clipboard, Check, ChevronDown, ChevronUp, MonitorPlay, Menu, LogOut
+  Sun, Moon, Monitor, X, Loader2, Tv, Clipboard, Check, ChevronDown, ChevronUp, MonitorPlay, Menu, LogOut, Ban
 } from "lucide-react"
 import {
   DropdownMenu,
@@ -73,6 +73,7 @@ function App() {
   const authCheckTimeoutRef = useRef(null)
   
   const [logs, setLogs] = useState([])
+  const [blacklist, setBlacklist] = useState([])
   const logsEndRef = useRef(null)
   const [logsCollapsed, setLogsCollapsed] = useState(true)
 
@@ -204,6 +205,7 @@ function App() {
         setWs(socket);
         window.ws = socket; // Make available globally for DeviceManagement
         setLogs([]); // Clear logs on reconnect
+        socket.send(JSON.stringify({ type: 'get_blacklist' }));
       };
 
       socket.onmessage = (event) => {
@@ -282,6 +284,11 @@ function App() {
             }
             break;
           }
+          case 'blacklist_response': {
+            setBlacklist(msg.payload.entries || []);
+            if (msg.payload.error) console.warn('Blacklist:', msg.payload.error);
+            break;
+          }
           case 'users_response': {
             // Handle devices list response - dispatch to DeviceManagement if callback exists
             if (window.deviceManagementCallback) {
@@ -296,6 +303,24 @@ function App() {
             }
             break;
           }
+          case 'validate_provider_response': {
+            // Result of a provider connection test - dispatch to ProviderSettings
+            if (window.validateProviderCallback) {
+              window.validateProviderCallback(msg.payload);
+            }
+            break;
+          }
+          case 'rotate_admin_token_response': {
+            // New admin token: reconnect with it so the install URL and session stay valid
+            if (msg.payload.token) {
+              localStorage.setItem('auth_token', msg.payload.token);
+              setAuthToken(msg.payload.token);
+            }
+            if (window.rotateAdminTokenCallback) {
+              window.rotateAdminTokenCallback(msg.payload);
+            }
+            break;
+          }
           case 'user_action_response': {
             // Handle device action responses (create, delete, regenerate, update password)
             if (window.deviceActionCallback) {
@@ -310,7 +335,12 @@ function App() {
         }
       };
 
-      socket.onclose = () => {
+      socket.onclose = (event) => {
+        if (event.code === 4001) {
+          // Admin token rotated from another dashboard: the stored token no longer works
+          handleLogout();
+          return;
+        }
         setWsStatus('disconnected');
         setWs(null);
         window.ws = null; // Clear global reference
@@ -750,9 +780,24 @@ function App() {
           </div>
           <div className="grid gap-2 md:grid-cols-2 lg:grid-cols-3">
             {stats.active_sessions.map(sess => (
-              <div key={sess.id} className="group relative min-w-0 bg-card/80 border border-border/80 rounded-lg p-3 pr-10 shadow-sm">
+              <div key={sess.id} className="group relative min-w-0 bg-card/80 border border-border/80 rounded-lg p-3 pr-20 shadow-sm">
                 <div className="text-sm font-medium truncate pr-2 min-w-0" title={sess.title}>{sess.title}</div>
                 <div className="text-xs text-muted-foreground truncate min-w-0">{sess.clients.join(', ')}</div>
+                {!sess.id.startsWith('proxy-') && (
+                  <Button
+                    variant="ghost"
+                    size="icon"
+                    className="absolute right-10 top-1/2 -translate-y-1/2 h-7 w-7 text-muted-foreground hover:text-red-400 hover:bg-red-500/15 transition-colors"
+                    onClick={() => {
+                      if (window.confirm(`Hide "${sess.title}" from all future search results?`)) {
+                        sendCommand('blacklist_release', { session_id: sess.id })
+                      }
+                    }}
+                    title="Blacklist release"
+                  >
+                    <Ban className="h-4 w-4" />
+                  </Button>
+                )}
                 <Button
                   variant="ghost"
                   size="icon"
@@ -768,6 +813,36 @@ function App() {
         </div>
       )}
 
+      {/* Blacklisted releases (when any) */}
+      {blacklist.length > 0 && (
+        <div className="mb-6">
+          <div className="flex items-center gap-2 mb-2">
+            <Ban className="h-4 w-4 text-muted-foreground" />
+            <h2 className="text-sm font-semibold">Blacklisted releases</h2>
+            <Badge variant="outline" className="text-[10px] py-0 h-4">{blacklist.length}</Badge>
+          </div>
+          <div className="grid gap-2 md:grid-cols-2 lg:grid-cols-3">
+            {blacklist.map(entry => (
+              <div key={`${entry.title}|${entry.details_url}`} className="group relative min-w-0 bg-card/80 border border-border/80 rounded-lg p-3 pr-10 shadow-sm">
+                <div className="text-sm font-medium truncate pr-2 min-w-0" title={entry.title || entry.details_url}>{entry.title || entry.details_url}</div>
+                <div className="text-xs text-muted-foreground truncate min-w-0">
+                  {entry.added_by ? `${entry.added_by} · ` : ''}{new Date(entry.added_at).toLocaleDateString()}
+                </div>
+                <Button
+                  variant="ghost"
+                  size="icon"
+                  className="absolute right-2 top-1/2 -translate-y-1/2 h-7 w-7 text-muted-foreground hover:text-foreground transition-colors"
+                  onClick={() => sendCommand('unblacklist_release', { title: entry.title, details_url: entry.details_url })}
+                  title="Remove from blacklist"
+                >
+                  <X className="h-4 w-4" />
+                </Button>
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
+
       {/* Bottom row: Providers (left) | Indexers (right) */}
       <div className="grid grid-cols-1 lg:grid-cols-2 gap-6 mb-8">
         {/* Left: Usenet Providers */}
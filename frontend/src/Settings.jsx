@@ -45,6 +45,8 @@ function Settings({ initialConfig, sendCommand, saveStatus, isSaving, onClose, a
   const [initialFormValues, setInitialFormValues] = useState(null)
   const deviceManagementRef = useRef(null)
   const pendingSaveAfterPasswordRef = useRef(null)
+  const [rotatingToken, setRotatingToken] = useState(false)
+  const [rotateTokenStatus, setRotateTokenStatus] = useState(null)
 
   const form = useForm({
     defaultValues: {
@@ -328,6 +330,21 @@ function Settings({ initialConfig, sendCommand, saveStatus, isSaving, onClose, a
       }
   }, [saveStatus.errors, setError]);
 
+  // Replace the admin token; Stremio installs using the old admin URL stop working
+  const rotateAdminToken = () => {
+    if (!window.confirm('Rotate the admin token? Stremio installs using the current admin URL will stop working until you reinstall the addon.')) return
+    setRotatingToken(true)
+    setRotateTokenStatus(null)
+    window.rotateAdminTokenCallback = (payload) => {
+      window.rotateAdminTokenCallback = null
+      setRotatingToken(false)
+      setRotateTokenStatus(payload.error
+        ? { type: 'error', msg: payload.error }
+        : { type: 'success', msg: 'Admin token rotated. Reinstall the addon in Stremio with the new install URL.' })
+    }
+    sendCommand('rotate_admin_token', {})
+  }
+
   const onSubmit = async (data) => {
     try {
       // Save device configs via WebSocket (they're stored in state.json, not config.json)
@@ -575,6 +592,22 @@ function Settings({ initialConfig, sendCommand, saveStatus, isSaving, onClose, a
                                                     )}
                                                 />
                                             </div>
+                                            {adminToken && (
+                                                <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-2 pt-2 border-t border-border/60">
+                                                    <p className="text-xs text-muted-foreground">
+                                                        The admin token is part of your Stremio install URL. Rotate it if the URL leaked; other dashboards using it are logged out.
+                                                    </p>
+                                                    <Button type="button" variant="outline" size="sm" onClick={rotateAdminToken} disabled={rotatingToken}>
+                                                        {rotatingToken ? <Loader2 className="h-4 w-4 mr-2 animate-spin" /> : <Key className="h-4 w-4 mr-2" />}
+                                                        Rotate admin token
+                                                    </Button>
+                                                </div>
+                                            )}
+                                            {rotateTokenStatus && (
+                                                <p className={`text-xs ${rotateTokenStatus.type === 'error' ? 'text-destructive' : 'text-green-600 dark:text-green-500'}`}>
+                                                    {rotateTokenStatus.msg}
+                                                </p>
+                                            )}
                                         </div>
                                     </CardContent>
                                 </Card>
@@ -861,6 +894,7 @@ function Settings({ initialConfig, sendCommand, saveStatus, isSaving, onClose, a
                                     append={append}
                                     remove={remove}
                                     watch={watch}
+                                    sendCommand={sendCommand}
                                 />
                             </TabsContent>
 
@@ -35,7 +35,7 @@ export default function Login({ onLogin, version }) {
         }
         onLogin(data.user, data.token, data.must_change_password || false)
       } else {
-        setError(data.error || 'Login failed')
+        setError(data.error?.message || 'Login failed')
       }
     } catch (err) {
       setError('Failed to connect to server')
@@ -1,13 +1,58 @@
-import React, { useMemo } from 'react'
+import React, { useMemo, useState, useEffect } from 'react'
 import { Card, CardContent, CardHeader, CardTitle, CardDescription } from "@/components/ui/card"
 import { Button } from "@/components/ui/button"
 import { Input } from "@/components/ui/input"
 import { Checkbox } from "@/components/ui/checkbox"
 import { FormField, FormItem, FormLabel, FormControl, FormMessage } from "@/components/ui/form"
 import { PasswordInput } from "@/components/ui/password-input"
-import { Trash2, Plus } from "lucide-react"
+import { Trash2, Plus, Loader2, Check, AlertCircle } from "lucide-react"
+
+// Connection test result: latency, and the test group's article count when one is configured
+function ProviderTestResult({ result }) {
+  if (!result) return null
+  if (result.error || !result.ok) {
+    return (
+      <p className="text-xs text-destructive flex items-start gap-1">
+        <AlertCircle className="h-3.5 w-3.5 shrink-0 mt-0.5" />
+        {result.error || 'Connection failed'}
+      </p>
+    )
+  }
+  return (
+    <p className="text-xs text-green-600 dark:text-green-500 flex items-start gap-1">
+      <Check className="h-3.5 w-3.5 shrink-0 mt-0.5" />
+      <span>
+        Connected in {result.latency_ms} ms
+        {result.test_group && (
+          <> · {result.test_group}: {(result.article_count || 0).toLocaleString()} articles</>
+        )}
+      </span>
+    </p>
+  )
+}
+
+export function ProviderSettings({ control, fields, append, remove, watch, sendCommand }) {
+  // Connection tests run one at a time: responses don't say which provider they belong to
+  const [testingId, setTestingId] = useState(null)
+  const [testResults, setTestResults] = useState({})
+
+  useEffect(() => () => { window.validateProviderCallback = null }, [])
+
+  const testProvider = (fieldId, index) => {
+    setTestingId(fieldId)
+    setTestResults(prev => ({ ...prev, [fieldId]: null }))
+    const done = (payload) => {
+      clearTimeout(timer)
+      window.validateProviderCallback = null
+      setTestingId(null)
+      setTestResults(prev => ({ ...prev, [fieldId]: payload }))
+    }
+    // No reply when the connection to the server dropped
+    const timer = setTimeout(() => done({ error: 'No response from the server' }), 30000)
+    window.validateProviderCallback = done
+    sendCommand('validate_provider', watch(`providers.${index}`))
+  }
 
-export function ProviderSettings({ control, fields, append, remove, watch }) {
   // Sort providers by priority (lower number = higher priority)
   const sortedFields = useMemo(() => {
     return [...fields].sort((a, b) => {
@@ -167,6 +212,22 @@ export function ProviderSettings({ control, fields, append, remove, watch }) {
                                 )}
                             />
                         </div>
+                        {sendCommand && (
+                            <div className="space-y-2">
+                                <Button
+                                    type="button"
+                                    variant="outline"
+                                    size="sm"
+                                    className="h-8 text-xs"
+                                    disabled={testingId !== null || !watch(`providers.${index}.host`)}
+                                    onClick={() => testProvider(field.id, index)}
+                                >
+                                    {testingId === field.id && <Loader2 className="mr-2 h-3.5 w-3.5 animate-spin" />}
+                                    Test connection
+                                </Button>
+                                <ProviderTestResult result={testResults[field.id]} />
+                            </div>
+                        )}
                     </CardContent>
                 </Card>
               )
@@ -23,6 +23,8 @@ require golang.org/x/net v0.50.0
 
 require github.com/gorilla/websocket v1.5.3
 
+require golang.org/x/crypto v0.48.0
+
 require (
 	github.com/javi11/sevenzip v1.6.2-0.20251026160715-ca961b7f1239
 	golang.org/x/sync v0.19.0 // indirect
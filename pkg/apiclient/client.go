@@ -0,0 +1,429 @@
+// Package apiclient is a Go client for the StreamNZB WebSocket admin API (/api/ws),
+// for scripting configuration and device management.
+//
+// The protocol is message based: every frame is a WSMessage with a type and a JSON
+// payload. Commands (get_config, save_config, get_users, create_user, ...) are
+// answered by a message of a matching response type (config, save_status,
+// users_response, user_action_response); stats are pushed once per second.
+// Responses carry no request ID, so the client runs one command at a time.
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/server/api"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// ErrDisconnected is returned for commands that were pending when the connection dropped.
+var ErrDisconnected = errors.New("apiclient: disconnected")
+
+// ErrClosed is returned after Close.
+var ErrClosed = errors.New("apiclient: client closed")
+
+// WSMessage is a single WebSocket frame of the admin API.
+type WSMessage = api.WSMessage
+
+// Stats is the payload of the "stats" message pushed every second.
+type Stats = api.SystemStats
+
+// Device is a device account as returned by ListDevices and CreateDevice.
+type Device struct {
+	Username string              `json:"username"`
+	Token    string              `json:"token"`
+	Filters  config.FilterConfig `json:"filters"`
+	Sorting  config.SortConfig   `json:"sorting"`
+}
+
+// SaveError is returned by SaveConfig when the server rejects the configuration.
+type SaveError struct {
+	Message string
+	Errors  map[string]string // field -> message, when validation failed
+}
+
+func (e *SaveError) Error() string {
+	if len(e.Errors) == 0 {
+		return "save failed: " + e.Message
+	}
+	parts := make([]string, 0, len(e.Errors))
+	for field, msg := range e.Errors {
+		parts = append(parts, field+": "+msg)
+	}
+	return fmt.Sprintf("save failed: %s (%s)", e.Message, strings.Join(parts, "; "))
+}
+
+// Client is a connection to the admin WebSocket API. It reconnects automatically
+// with backoff until Close is called; commands issued while disconnected wait for
+// the next connection or their context.
+type Client struct {
+	wsURL  string
+	header http.Header
+	dialer *websocket.Dialer
+
+	callMu  sync.Mutex // one command in flight (responses are not correlated)
+	writeMu sync.Mutex
+
+	mu        sync.Mutex
+	conn      *websocket.Conn
+	connected chan struct{} // closed while a connection is up
+	waiters   map[string]chan WSMessage
+	statsSubs map[chan Stats]struct{}
+	closed    bool
+	done      chan struct{}
+}
+
+// Dial connects to the admin API at baseURL (e.g. "http://localhost:7000") using a
+// device or admin token, and keeps the connection alive in the background.
+func Dial(ctx context.Context, baseURL, token string) (*Client, error) {
+	wsURL, err := websocketURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		wsURL:     wsURL,
+		header:    http.Header{"Authorization": []string{"Bearer " + token}},
+		dialer:    websocket.DefaultDialer,
+		connected: make(chan struct{}),
+		waiters:   make(map[string]chan WSMessage),
+		statsSubs: make(map[chan Stats]struct{}),
+		done:      make(chan struct{}),
+	}
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.setConn(conn)
+	go c.run(conn)
+	return c, nil
+}
+
+func websocketURL(baseURL string) (string, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "ws":
+		u.Scheme = "ws"
+	case "https", "wss":
+		u.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("invalid base URL scheme: %q", u.Scheme)
+	}
+	u.Path += "/api/ws"
+	return u.String(), nil
+}
+
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	conn, resp, err := c.dialer.DialContext(ctx, c.wsURL, c.header)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("apiclient: unauthorized (check the token)")
+		}
+		return nil, fmt.Errorf("apiclient: dial %s: %w", c.wsURL, err)
+	}
+	return conn, nil
+}
+
+func (c *Client) setConn(conn *websocket.Conn) {
+	c.mu.Lock()
+	c.conn = conn
+	close(c.connected)
+	c.mu.Unlock()
+}
+
+// run reads messages until the connection drops, then reconnects with backoff.
+func (c *Client) run(conn *websocket.Conn) {
+	for {
+		c.readLoop(conn)
+
+		c.mu.Lock()
+		c.conn = nil
+		c.connected = make(chan struct{})
+		for typ, ch := range c.waiters {
+			close(ch)
+			delete(c.waiters, typ)
+		}
+		closed := c.closed
+		c.mu.Unlock()
+		if closed {
+			return
+		}
+
+		delay := minReconnectDelay
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-time.After(delay):
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			next, err := c.dial(ctx)
+			cancel()
+			if err == nil {
+				conn = next
+				break
+			}
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+		}
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c.mu.Unlock()
+		c.setConn(conn)
+	}
+}
+
+func (c *Client) readLoop(conn *websocket.Conn) {
+	defer conn.Close()
+	for {
+		var msg WSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Type == "stats" {
+			c.publishStats(msg.Payload)
+		}
+		c.mu.Lock()
+		ch, ok := c.waiters[msg.Type]
+		if ok {
+			delete(c.waiters, msg.Type)
+		}
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+}
+
+func (c *Client) publishStats(payload json.RawMessage) {
+	var stats Stats
+	if err := json.Unmarshal(payload, &stats); err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.statsSubs {
+		select {
+		case ch <- stats:
+		default: // slow subscriber: drop this sample
+		}
+	}
+}
+
+// Close stops reconnecting and closes the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	conn := c.conn
+	c.mu.Unlock()
+	if conn != nil {
+		c.writeMu.Lock()
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		c.writeMu.Unlock()
+		return conn.Close()
+	}
+	return nil
+}
+
+// waitConnected blocks until a connection is up and returns it.
+func (c *Client) waitConnected(ctx context.Context) (*websocket.Conn, error) {
+	for {
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return nil, ErrClosed
+		}
+		conn, connected := c.conn, c.connected
+		c.mu.Unlock()
+		if conn != nil {
+			return conn, nil
+		}
+		select {
+		case <-connected:
+		case <-c.done:
+			return nil, ErrClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// call sends a command and waits for the message of respType.
+func (c *Client) call(ctx context.Context, cmd string, payload interface{}, respType string) (json.RawMessage, error) {
+	c.callMu.Lock()
+	defer c.callMu.Unlock()
+
+	conn, err := c.waitConnected(ctx)
+	if err != nil {
+		return nil, err
+	}
+	msg := WSMessage{Type: cmd}
+	if payload != nil {
+		if msg.Payload, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	ch := make(chan WSMessage, 1)
+	c.mu.Lock()
+	c.waiters[respType] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.waiters[respType] == ch {
+			delete(c.waiters, respType)
+		}
+		c.mu.Unlock()
+	}()
+
+	c.writeMu.Lock()
+	err = conn.WriteJSON(msg)
+	c.writeMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("apiclient: send %s: %w", cmd, err)
+	}
+
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, ErrDisconnected
+		}
+		return resp.Payload, nil
+	case <-c.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// responseError extracts {"error": "..."} from a response payload.
+func responseError(payload json.RawMessage) error {
+	var e struct {
+		Error string `json:"error"`
+	}
+	if len(payload) > 0 && payload[0] == '{' && json.Unmarshal(payload, &e) == nil && e.Error != "" {
+		return errors.New(e.Error)
+	}
+	return nil
+}
+
+// GetConfig returns the current configuration (the global config for the admin,
+// the effective merged config for other devices). Secrets such as the admin
+// password hash and token are never included.
+func (c *Client) GetConfig(ctx context.Context) (*config.Config, error) {
+	payload, err := c.call(ctx, "get_config", nil, "config")
+	if err != nil {
+		return nil, err
+	}
+	var cfg config.Config
+	if err := json.Unmarshal(payload, &cfg); err != nil {
+		return nil, fmt.Errorf("apiclient: decode config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// SaveConfig saves and applies a full configuration (admin only). Start from
+// GetConfig and modify it; fields missing from cfg are reset to their zero value.
+// Returns a *SaveError when the server rejects the configuration.
+func (c *Client) SaveConfig(ctx context.Context, cfg *config.Config) error {
+	payload, err := c.call(ctx, "save_config", cfg, "save_status")
+	if err != nil {
+		return err
+	}
+	var status struct {
+		Status  string            `json:"status"`
+		Message string            `json:"message"`
+		Errors  map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(payload, &status); err != nil {
+		return fmt.Errorf("apiclient: decode save status: %w", err)
+	}
+	if status.Status != "success" {
+		return &SaveError{Message: status.Message, Errors: status.Errors}
+	}
+	return nil
+}
+
+// ListDevices returns all device accounts (admin only).
+func (c *Client) ListDevices(ctx context.Context) ([]Device, error) {
+	payload, err := c.call(ctx, "get_users", nil, "users_response")
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(payload); err != nil {
+		return nil, err
+	}
+	var devices []Device
+	if err := json.Unmarshal(payload, &devices); err != nil {
+		return nil, fmt.Errorf("apiclient: decode devices: %w", err)
+	}
+	return devices, nil
+}
+
+// CreateDevice creates a device account and returns it with its new token (admin only).
+func (c *Client) CreateDevice(ctx context.Context, username string) (*Device, error) {
+	req := map[string]string{"username": username}
+	payload, err := c.call(ctx, "create_user", req, "user_action_response")
+	if err != nil {
+		return nil, err
+	}
+	if err := responseError(payload); err != nil {
+		return nil, err
+	}
+	var resp struct {
+		User Device `json:"user"`
+	}
+	if err := json.Unmarshal(payload, &resp); err != nil {
+		return nil, fmt.Errorf("apiclient: decode device: %w", err)
+	}
+	return &resp.User, nil
+}
+
+// SubscribeStats delivers the stats pushed by the server (about once per second)
+// until ctx is done; the channel is then closed. Delivery continues across
+// reconnects. Samples are dropped when the receiver falls behind.
+func (c *Client) SubscribeStats(ctx context.Context) <-chan Stats {
+	ch := make(chan Stats, 8)
+	c.mu.Lock()
+	c.statsSubs[ch] = struct{}{}
+	c.mu.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-c.done:
+		}
+		c.mu.Lock()
+		delete(c.statsSubs, ch)
+		c.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
@@ -0,0 +1,107 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeAdminAPI answers get_users and create_user and pushes one stats message,
+// closing the first connection after create_user to exercise reconnection.
+func fakeAdminAPI(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	connects := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/ws" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		connects++
+		first := connects == 1
+		conn.WriteJSON(WSMessage{Type: "stats", Payload: json.RawMessage(`{"active_streams":2}`)})
+		for {
+			var msg WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			switch msg.Type {
+			case "get_users":
+				conn.WriteJSON(WSMessage{Type: "users_response", Payload: json.RawMessage(`[{"username":"tv","token":"abc"}]`)})
+			case "create_user":
+				var req struct {
+					Username string `json:"username"`
+				}
+				json.Unmarshal(msg.Payload, &req)
+				if req.Username == "tv" {
+					conn.WriteJSON(WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"device already exists"}`)})
+					continue
+				}
+				conn.WriteJSON(WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"success":true,"user":{"username":"` + req.Username + `","token":"def"}}`)})
+				if first {
+					return
+				}
+			}
+		}
+	}))
+}
+
+func TestClientCommandsAndReconnect(t *testing.T) {
+	srv := fakeAdminAPI(t)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := Dial(ctx, srv.URL, "wrong"); err == nil {
+		t.Fatal("expected dial with a bad token to fail")
+	}
+	c, err := Dial(ctx, srv.URL, "secret")
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Close()
+
+	stats := c.SubscribeStats(ctx)
+
+	devices, err := c.ListDevices(ctx)
+	if err != nil || len(devices) != 1 || devices[0].Username != "tv" {
+		t.Fatalf("ListDevices = %+v, %v", devices, err)
+	}
+	if _, err := c.CreateDevice(ctx, "tv"); err == nil {
+		t.Fatal("expected error for duplicate device")
+	}
+	dev, err := c.CreateDevice(ctx, "phone")
+	if err != nil || dev.Token != "def" {
+		t.Fatalf("CreateDevice = %+v, %v", dev, err)
+	}
+
+	// The server dropped the connection; commands run again once reconnected
+	for {
+		devices, err = c.ListDevices(ctx)
+		if !errors.Is(err, ErrDisconnected) {
+			break
+		}
+	}
+	if err != nil || len(devices) != 1 {
+		t.Fatalf("ListDevices after reconnect = %+v, %v", devices, err)
+	}
+
+	select {
+	case s := <-stats:
+		if s.ActiveStreams != 2 {
+			t.Errorf("stats active_streams = %d, want 2", s.ActiveStreams)
+		}
+	case <-ctx.Done():
+		t.Fatal("no stats received")
+	}
+}
@@ -11,14 +11,62 @@ import (
 	"sync"
 )
 
+// Role controls what a device may do in the dashboard and admin API.
+type Role string
+
+const (
+	// RoleAdmin has full access: configuration, providers, indexers and devices.
+	RoleAdmin Role = "admin"
+	// RoleManager can manage devices but not the global configuration.
+	RoleManager Role = "manager"
+	// RoleUser can only stream and read its own effective configuration.
+	RoleUser Role = "user"
+)
+
+// ParseRole validates a role name.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(s); r {
+	case RoleAdmin, RoleManager, RoleUser:
+		return r, nil
+	}
+	return "", fmt.Errorf("invalid role %q (admin, manager or user)", s)
+}
+
 // Device represents a device account
 type Device struct {
 	Username string              `json:"username"`
 	Token    string              `json:"token"` // SHA256 token for API access
+	Role     Role                `json:"role,omitempty"`
 	Filters  config.FilterConfig `json:"filters"`
 	Sorting  config.SortConfig   `json:"sorting"`
-	// PasswordHash and MustChangePassword are not stored for regular devices
-	// They are only used for admin (stored separately in AdminCredentials)
+	// MaxConcurrentPlaybacks limits simultaneous streams for this device (0 = use global default)
+	MaxConcurrentPlaybacks int `json:"max_concurrent_playbacks,omitempty"`
+	// AvailNZBReport overrides the global AvailNZB reporting setting for this device (nil = use global)
+	AvailNZBReport *bool `json:"availnzb_report,omitempty"`
+	// MonthlyQuotaGB is a soft cap on data streamed per calendar month (0 = unlimited)
+	MonthlyQuotaGB float64 `json:"monthly_quota_gb,omitempty"`
+	// PasswordHash allows dashboard login for admin and manager devices (empty = token only).
+	// The config admin's password is stored in config, not here.
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// EffectiveRole returns the device's role; devices created before roles existed are users.
+func (d *Device) EffectiveRole() Role {
+	if d == nil || d.Role == "" {
+		return RoleUser
+	}
+	return d.Role
+}
+
+// IsAdmin reports whether the device has full admin rights.
+func (d *Device) IsAdmin() bool {
+	return d.EffectiveRole() == RoleAdmin
+}
+
+// CanManageDevices reports whether the device may create, edit and delete devices.
+func (d *Device) CanManageDevices() bool {
+	r := d.EffectiveRole()
+	return r == RoleAdmin || r == RoleManager
 }
 
 // DeviceManager handles device storage and authentication.
@@ -26,6 +74,7 @@ type Device struct {
 type DeviceManager struct {
 	mu      sync.RWMutex
 	devices map[string]*Device // username -> Device (excludes admin)
+	usage   map[string]*DeviceUsage
 	manager *persistence.StateManager
 }
 
@@ -90,6 +139,18 @@ func (dm *DeviceManager) load() error {
 			dm.saveLocked()
 			logger.Info("Removed legacy admin from devices (admin is in config)")
 		}
+		// Devices created before roles existed are regular users
+		migrated := false
+		for _, device := range dm.devices {
+			if device.Role == "" {
+				device.Role = RoleUser
+				migrated = true
+			}
+		}
+		if migrated {
+			dm.saveLocked()
+			logger.Info("Assigned user role to existing devices")
+		}
 	} else {
 		dm.devices = make(map[string]*Device)
 	}
@@ -137,12 +198,23 @@ func (dm *DeviceManager) Authenticate(loginUsername, password, adminUsername, ad
 		return &Device{
 			Username: adminUsername,
 			Token:    adminToken,
+			Role:     RoleAdmin,
 			Filters:  config.FilterConfig{},
 			Sorting:  config.SortConfig{},
 		}, nil
 	}
 
-	return nil, fmt.Errorf("invalid credentials")
+	// Admin and manager devices with a password can log in to the dashboard too
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+	device, exists := dm.devices[loginUsername]
+	if !exists || device.PasswordHash == "" || !device.CanManageDevices() {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+	if HashPassword(password) != device.PasswordHash {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+	return device, nil
 }
 
 // AuthenticateToken validates a token and returns the device.
@@ -156,6 +228,7 @@ func (dm *DeviceManager) AuthenticateToken(token string, adminUsername, adminTok
 		return &Device{
 			Username: adminUsername,
 			Token:    adminToken,
+			Role:     RoleAdmin,
 			Filters:  config.FilterConfig{},
 			Sorting:  config.SortConfig{},
 		}, nil
@@ -210,10 +283,14 @@ func (dm *DeviceManager) GetAllDevices() []Device {
 		}
 		// Return copy (Device struct no longer has PasswordHash or MustChangePassword)
 		devices = append(devices, Device{
-			Username: device.Username,
-			Token:    device.Token,
-			Filters:  device.Filters,
-			Sorting:  device.Sorting,
+			Username:               device.Username,
+			Token:                  device.Token,
+			Filters:                device.Filters,
+			Sorting:                device.Sorting,
+			Role:                   device.EffectiveRole(),
+			MaxConcurrentPlaybacks: device.MaxConcurrentPlaybacks,
+			AvailNZBReport:         device.AvailNZBReport,
+			MonthlyQuotaGB:         device.MonthlyQuotaGB,
 		})
 	}
 
@@ -226,6 +303,7 @@ func (dm *DeviceManager) GetAllUsers() []Device {
 }
 
 // CreateDevice creates a new device (password is optional). adminUsername is the dashboard admin name; cannot create a device with that name.
+// The device gets the user role; see UpdateDeviceRole.
 func (dm *DeviceManager) CreateDevice(username, password string, adminUsername string) (*Device, error) {
 	dm.mu.Lock()
 	defer dm.mu.Unlock()
@@ -249,9 +327,13 @@ func (dm *DeviceManager) CreateDevice(username, password string, adminUsername s
 	device := &Device{
 		Username: username,
 		Token:    token,
+		Role:     RoleUser,
 		Filters:  config.DefaultFilterConfig(),
 		Sorting:  config.DefaultSortConfig(),
 	}
+	if password != "" {
+		device.PasswordHash = HashPassword(password)
+	}
 
 	dm.devices[username] = device
 
@@ -316,6 +398,10 @@ func (dm *DeviceManager) DeleteDevice(username string) error {
 	}
 
 	delete(dm.devices, username)
+	if _, ok := dm.usage[username]; ok {
+		delete(dm.usage, username)
+		dm.manager.Set(usageStateKey, dm.usage)
+	}
 
 	if err := dm.saveLocked(); err != nil {
 		return fmt.Errorf("failed to save device: %w", err)
@@ -378,6 +464,92 @@ func (dm *DeviceManager) UpdateUserSorting(username string, sorting config.SortC
 	return dm.UpdateDeviceSorting(username, sorting)
 }
 
+// UpdateDevicePlaybackLimit sets a device's concurrent playback limit (0 = use global default)
+func (dm *DeviceManager) UpdateDevicePlaybackLimit(username string, limit int) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	device, exists := dm.devices[username]
+	if !exists {
+		return fmt.Errorf("device not found")
+	}
+	if limit < 0 {
+		limit = 0
+	}
+
+	device.MaxConcurrentPlaybacks = limit
+
+	if err := dm.saveLocked(); err != nil {
+		return fmt.Errorf("failed to save device playback limit: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateDeviceAvailReport sets a device's AvailNZB reporting override (nil = use global setting)
+func (dm *DeviceManager) UpdateDeviceAvailReport(username string, enabled *bool) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	device, exists := dm.devices[username]
+	if !exists {
+		return fmt.Errorf("device not found")
+	}
+
+	device.AvailNZBReport = enabled
+
+	if err := dm.saveLocked(); err != nil {
+		return fmt.Errorf("failed to save device AvailNZB reporting: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateDeviceRole changes a device's role.
+func (dm *DeviceManager) UpdateDeviceRole(username string, role Role) error {
+	if _, err := ParseRole(string(role)); err != nil {
+		return err
+	}
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	device, exists := dm.devices[username]
+	if !exists {
+		return fmt.Errorf("device not found")
+	}
+
+	device.Role = role
+
+	if err := dm.saveLocked(); err != nil {
+		return fmt.Errorf("failed to save device role: %w", err)
+	}
+
+	logger.Info("Updated device role", "username", username, "role", role)
+	return nil
+}
+
+// UpdateDevicePassword sets the dashboard password of a device (empty = token only).
+func (dm *DeviceManager) UpdateDevicePassword(username, password string) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	device, exists := dm.devices[username]
+	if !exists {
+		return fmt.Errorf("device not found")
+	}
+
+	device.PasswordHash = ""
+	if password != "" {
+		device.PasswordHash = HashPassword(password)
+	}
+
+	if err := dm.saveLocked(); err != nil {
+		return fmt.Errorf("failed to save device password: %w", err)
+	}
+
+	return nil
+}
+
 // GetDeviceConfig returns a device's filter and sorting config
 func (dm *DeviceManager) GetDeviceConfig(username string) (config.FilterConfig, config.SortConfig, error) {
 	dm.mu.RLock()
@@ -395,3 +567,56 @@ func (dm *DeviceManager) GetDeviceConfig(username string) (config.FilterConfig,
 func (dm *DeviceManager) GetUserConfig(username string) (config.FilterConfig, config.SortConfig, error) {
 	return dm.GetDeviceConfig(username)
 }
+
+// ExportDevices returns full copies of all devices, including password hashes and
+// tokens, for a configuration backup.
+func (dm *DeviceManager) ExportDevices() map[string]*Device {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	out := make(map[string]*Device, len(dm.devices))
+	for username, device := range dm.devices {
+		d := *device
+		out[username] = &d
+	}
+	return out
+}
+
+// ImportDevices replaces all devices with the ones from a configuration backup.
+// Devices without a token get a new one; the dashboard admin name is rejected.
+func (dm *DeviceManager) ImportDevices(devices map[string]*Device, adminUsername string) error {
+	imported := make(map[string]*Device, len(devices))
+	for username, device := range devices {
+		if device == nil || username == "" {
+			return fmt.Errorf("invalid device entry %q", username)
+		}
+		if username == adminUsername || username == "admin" {
+			return fmt.Errorf("device name %q is reserved for the admin", username)
+		}
+		d := *device
+		d.Username = username
+		if d.Role == "" {
+			d.Role = RoleUser
+		}
+		if _, err := ParseRole(string(d.Role)); err != nil {
+			return fmt.Errorf("device %s: %w", username, err)
+		}
+		if d.Token == "" {
+			token, err := GenerateToken()
+			if err != nil {
+				return fmt.Errorf("failed to generate token: %w", err)
+			}
+			d.Token = token
+		}
+		imported[username] = &d
+	}
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.devices = imported
+	if err := dm.saveLocked(); err != nil {
+		return fmt.Errorf("failed to save devices: %w", err)
+	}
+	logger.Info("Imported devices", "count", len(imported))
+	return nil
+}
@@ -75,11 +75,11 @@ func AuthMiddleware(deviceManager *DeviceManager, getAdminUsername, getAdminToke
 				}
 			}
 
-			// No valid authentication found
+			// No valid authentication found (same error envelope as the API handlers)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "Unauthorized",
+			json.NewEncoder(w).Encode(map[string]map[string]string{
+				"error": {"code": "unauthorized", "message": "Unauthorized"},
 			})
 		})
 	}
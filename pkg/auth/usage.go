@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+const usageStateKey = "device_usage"
+
+// DeviceUsage is the data served to a device in the current calendar month.
+type DeviceUsage struct {
+	Month       string `json:"month"` // "2006-01"; counters restart when it changes
+	BytesServed int64  `json:"bytes_served"`
+}
+
+// currentUsageLocked returns the usage entry for username, starting a new month when
+// needed (caller must hold the write lock).
+func (dm *DeviceManager) currentUsageLocked(username string) *DeviceUsage {
+	if dm.usage == nil {
+		dm.usage = make(map[string]*DeviceUsage)
+		if _, err := dm.manager.Get(usageStateKey, &dm.usage); err != nil {
+			logger.Warn("Failed to load device usage", "err", err)
+		}
+	}
+	month := time.Now().Format("2006-01")
+	u, ok := dm.usage[username]
+	if !ok || u == nil {
+		u = &DeviceUsage{Month: month}
+		dm.usage[username] = u
+	} else if u.Month != month {
+		u.Month = month
+		u.BytesServed = 0
+	}
+	return u
+}
+
+// AddBytesServed adds n bytes streamed to the device's monthly usage.
+func (dm *DeviceManager) AddBytesServed(username string, n int64) {
+	if n <= 0 || username == "" {
+		return
+	}
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.currentUsageLocked(username).BytesServed += n
+	if err := dm.manager.Set(usageStateKey, dm.usage); err != nil {
+		logger.Error("Failed to save device usage", "err", err)
+	}
+}
+
+// MonthlyBytesServed returns how many bytes the device was served this month.
+func (dm *DeviceManager) MonthlyBytesServed(username string) int64 {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	return dm.currentUsageLocked(username).BytesServed
+}
+
+// QuotaExceeded reports whether the device has used up its MonthlyQuotaGB.
+func (dm *DeviceManager) QuotaExceeded(device *Device) bool {
+	if device == nil || device.MonthlyQuotaGB <= 0 {
+		return false
+	}
+	return dm.MonthlyBytesServed(device.Username) >= int64(device.MonthlyQuotaGB*(1<<30))
+}
+
+// UpdateDeviceQuota sets a device's monthly streaming quota in GB (0 = unlimited).
+func (dm *DeviceManager) UpdateDeviceQuota(username string, quotaGB float64) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	device, exists := dm.devices[username]
+	if !exists {
+		return fmt.Errorf("device not found")
+	}
+	if quotaGB < 0 {
+		quotaGB = 0
+	}
+
+	device.MonthlyQuotaGB = quotaGB
+
+	if err := dm.saveLocked(); err != nil {
+		return fmt.Errorf("failed to save device quota: %w", err)
+	}
+
+	return nil
+}
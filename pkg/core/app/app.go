@@ -9,6 +9,7 @@ import (
 	"streamnzb/pkg/core/config"
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/indexer/easynews"
 	"streamnzb/pkg/initialization"
 	"streamnzb/pkg/search/triage"
 	"streamnzb/pkg/services/availnzb"
@@ -43,6 +44,20 @@ type Components struct {
 	TVDBClient           *tvdb.Client
 }
 
+// ProxyPostPool returns the pool of the provider that receives articles posted
+// through the NNTP proxy, or nil when posting is disabled or the provider is unknown.
+func (c *Components) ProxyPostPool() *nntp.ClientPool {
+	if !c.Config.ProxyPostEnabled {
+		return nil
+	}
+	pool, ok := c.ProviderPools[c.Config.ProxyPostProvider]
+	if !ok {
+		logger.Warn("NNTP proxy posting enabled but upload provider not found", "provider", c.Config.ProxyPostProvider)
+		return nil
+	}
+	return pool
+}
+
 // App centralizes service construction and granular reload
 type App struct {
 	mu         sync.RWMutex
@@ -78,14 +93,22 @@ func (a *App) buildFull(cfg *config.Config, opts BuildOpts) (*Components, error)
 
 	cacheTTL := time.Duration(cfg.CacheTTLSeconds) * time.Second
 	validator := validation.NewChecker(
-		base.ProviderPools,
+		base.ValidationPools,
 		base.ProviderOrder,
 		cacheTTL,
 		cfg.ValidationSampleSize,
 		6,
+		cfg.MinAvailabilityRatio,
 	)
+	validator.SetTailDepth(cfg.ValidateTailDepth)
+	validator.SetSegmentTimeout(time.Duration(cfg.ValidationSegmentTimeoutMs) * time.Millisecond)
+	validator.SetVerifyCRC(cfg.ValidationVerifyCRC)
+	validator.SetProviderWeights(base.ProviderWeights)
 	triageSvc := triage.NewService(&cfg.Filters, cfg.Sorting)
+	triageSvc.AllowUnknownSize = cfg.ZeroSizePolicy != "skip"
+	triageSvc.SkipObfuscated = cfg.ObfuscatedPolicy == "skip"
 	availClient := availnzb.NewClient(opts.AvailNZBURL, opts.AvailNZBAPIKey)
+	availClient.SetTimeout(time.Duration(cfg.AvailNZBTimeoutMs) * time.Millisecond)
 	dataDir := opts.DataDir
 	if dataDir == "" {
 		dataDir = filepath.Dir(cfg.LoadedPath)
@@ -95,6 +118,14 @@ func (a *App) buildFull(cfg *config.Config, opts BuildOpts) (*Components, error)
 	}
 	tmdbClient := tmdb.NewClient(opts.TMDBAPIKey)
 	tvdbClient := tvdb.NewClient(opts.TVDBAPIKey, dataDir)
+	// Easynews title-year/title-only query strategies resolve titles through TMDB
+	if agg, ok := base.Indexer.(*indexer.Aggregator); ok {
+		for _, idx := range agg.GetIndexers() {
+			if en, ok := idx.(*easynews.Client); ok {
+				en.SetTitleResolver(tmdbClient)
+			}
+		}
+	}
 
 	return &Components{
 		Config:               base.Config,
@@ -128,15 +159,24 @@ func ConfigChanged(old, new_ *config.Config) ReloadScope {
 		return ReloadFull
 	}
 
-	indexersChanged := !reflect.DeepEqual(old.Indexers, new_.Indexers)
-	providersChanged := !reflect.DeepEqual(old.Providers, new_.Providers)
+	indexersChanged := !reflect.DeepEqual(old.Indexers, new_.Indexers) ||
+		old.MaxRawResultsPerIndexer != new_.MaxRawResultsPerIndexer
+	providersChanged := !reflect.DeepEqual(old.Providers, new_.Providers) ||
+		old.NNTPIdleTimeoutSeconds != new_.NNTPIdleTimeoutSeconds ||
+		old.NNTPKeepaliveSeconds != new_.NNTPKeepaliveSeconds
 	proxyChanged := old.ProxyEnabled != new_.ProxyEnabled ||
 		old.ProxyHost != new_.ProxyHost ||
 		old.ProxyPort != new_.ProxyPort ||
 		old.ProxyAuthUser != new_.ProxyAuthUser ||
-		old.ProxyAuthPass != new_.ProxyAuthPass
+		old.ProxyAuthPass != new_.ProxyAuthPass ||
+		old.ProxyPostEnabled != new_.ProxyPostEnabled ||
+		old.ProxyPostProvider != new_.ProxyPostProvider
 	validationChanged := old.CacheTTLSeconds != new_.CacheTTLSeconds ||
-		old.ValidationSampleSize != new_.ValidationSampleSize
+		old.ValidationSampleSize != new_.ValidationSampleSize ||
+		old.MinAvailabilityRatio != new_.MinAvailabilityRatio ||
+		old.ValidateTailDepth != new_.ValidateTailDepth ||
+		old.ValidationSegmentTimeoutMs != new_.ValidationSegmentTimeoutMs ||
+		old.ValidationVerifyCRC != new_.ValidationVerifyCRC
 
 	if providersChanged || indexersChanged {
 		return ReloadFull
@@ -162,9 +202,14 @@ func (a *App) Reload(newCfg *config.Config) (*Components, bool, error) {
 		// No pool/indexer rebuild - just config + triage
 		logger.Info("Reload: config-only (filters, sorting, limits) - no NNTP/indexer restart")
 		triageSvc := triage.NewService(&newCfg.Filters, newCfg.Sorting)
+		triageSvc.AllowUnknownSize = newCfg.ZeroSizePolicy != "skip"
+		triageSvc.SkipObfuscated = newCfg.ObfuscatedPolicy == "skip"
 		comp := *old
 		comp.Config = newCfg
 		comp.Triage = triageSvc
+		if comp.AvailClient != nil {
+			comp.AvailClient.SetTimeout(time.Duration(newCfg.AvailNZBTimeoutMs) * time.Millisecond)
+		}
 		a.components = &comp
 		return &comp, false, nil
 
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+func TestReloadAppliesAvailNZBTimeout(t *testing.T) {
+	logger.Init("DEBUG")
+	// AvailNZB that answers only after 2s
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	a := New()
+	comp, err := a.Build(&config.Config{AvailNZBTimeoutMs: 5000, LoadedPath: filepath.Join(dir, "config.json")}, BuildOpts{AvailNZBURL: srv.URL, DataDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(comp.AvailClient.Close)
+
+	newCfg := *comp.Config
+	newCfg.AvailNZBTimeoutMs = 100
+	if scope := ConfigChanged(comp.Config, &newCfg); scope != ReloadConfigOnly {
+		t.Fatalf("scope = %v; want a config-only reload", scope)
+	}
+	reloaded, full, err := a.Reload(&newCfg)
+	if err != nil || full {
+		t.Fatalf("Reload: full=%v err=%v", full, err)
+	}
+
+	start := time.Now()
+	if err := reloaded.AvailClient.Ping(context.Background()); err == nil {
+		t.Fatal("Ping succeeded against a server slower than the timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Ping took %v; the 100ms timeout was not applied", elapsed)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"streamnzb/pkg/core/env"
 	"streamnzb/pkg/core/logger"
@@ -27,6 +30,50 @@ type Provider struct {
 	UseSSL      bool   `json:"use_ssl"`
 	Priority    *int   `json:"priority,omitempty"` // Lower number = higher priority (1 = first, 2 = backup, etc.). nil = not set (old config)
 	Enabled     *bool  `json:"enabled,omitempty"`  // Whether this provider is enabled. nil = not set (old config)
+	Compression bool   `json:"compression"`        // Negotiate NNTP COMPRESS DEFLATE when the server supports it
+	// Connections validation and cache warming never use, so playback is not starved (0 = none)
+	PlaybackReservedConnections int `json:"playback_reserved_connections"`
+	// Role limits what the provider is used for: "all" (default), "validate" (STAT/validation
+	// only, e.g. a block account) or "download" (playback only)
+	Role string `json:"role,omitempty"`
+	// Weight prefers this provider for playback among providers that validated a release
+	// equally well; higher wins (0 = no preference)
+	Weight int `json:"weight,omitempty"`
+}
+
+// Provider roles
+const (
+	ProviderRoleAll      = "all"
+	ProviderRoleValidate = "validate"
+	ProviderRoleDownload = "download"
+)
+
+// ValidProviderRole reports whether role is a known provider role ("" = all).
+func ValidProviderRole(role string) bool {
+	switch role {
+	case "", ProviderRoleAll, ProviderRoleValidate, ProviderRoleDownload:
+		return true
+	}
+	return false
+}
+
+// ValidZeroSizePolicy reports whether policy is a known zero_size_policy ("" = defer).
+func ValidZeroSizePolicy(policy string) bool {
+	switch policy {
+	case "", "defer", "resolve", "skip":
+		return true
+	}
+	return false
+}
+
+// UsedForValidation reports whether validation may use this provider.
+func (p Provider) UsedForValidation() bool {
+	return p.Role != ProviderRoleDownload
+}
+
+// UsedForDownload reports whether playback (and the NNTP proxy) may use this provider.
+func (p Provider) UsedForDownload() bool {
+	return p.Role != ProviderRoleValidate
 }
 
 // FilterConfig holds user filtering preferences for PTT-based release filtering
@@ -38,6 +85,8 @@ type FilterConfig struct {
 	// Resolution filters
 	MinResolution string `json:"min_resolution"` // e.g., "720p"
 	MaxResolution string `json:"max_resolution"` // e.g., "2160p"
+	// AllowedResolutionGroups keeps only these groups ("4k", "1080p", "720p", "sd"); empty allows all
+	AllowedResolutionGroups []string `json:"allowed_resolution_groups"` // e.g., ["1080p"]
 
 	// Codec filters
 	AllowedCodecs []string `json:"allowed_codecs"` // e.g., ["HEVC", "AVC"]
@@ -70,8 +119,19 @@ type FilterConfig struct {
 	MinSizeGB float64 `json:"min_size_gb"`
 	MaxSizeGB float64 `json:"max_size_gb"`
 
+	// Grab filters: drop releases the indexer reports fewer than MinGrabs grabs for.
+	// Releases without grab data are kept unless RequireGrabs is set.
+	MinGrabs     int  `json:"min_grabs"`
+	RequireGrabs bool `json:"require_grabs"`
+
 	// Group filters (blocking only)
 	BlockedGroups []string `json:"blocked_groups"`
+
+	// Title patterns: case-insensitive regular expressions matched against the release title.
+	// Releases matching a blocked pattern are dropped; when required patterns are set,
+	// releases must match at least one.
+	BlockedTitlePatterns  []string `json:"blocked_title_patterns"`
+	RequiredTitlePatterns []string `json:"required_title_patterns"`
 }
 
 // DefaultFilterConfig returns built-in filter defaults for fresh devices.
@@ -94,7 +154,19 @@ type SortConfig struct {
 
 	// Preference boosts (prioritization, not filtering)
 	PreferredGroups    []string `json:"preferred_groups"`    // e.g., ["FLUX", "NTb"]
-	PreferredLanguages []string `json:"preferred_languages"` // e.g., ["en", "multi"]
+	PreferredLanguages []string `json:"preferred_languages"` // e.g., ["en", "multi"], most preferred first
+	// Boost for the first preferred language; later ones get proportionally less, and releases
+	// tagged only with other languages lose this much (0 = default 2000)
+	LanguageWeight int `json:"language_weight"`
+	// Visual tags the display handles, best first (e.g. ["HDR10+", "HDR", "SDR"] for a TV
+	// without Dolby Vision). Within a resolution, releases are ranked by the first listed tag
+	// they carry; releases with none of them come last.
+	PreferredVisualTags []string `json:"preferred_visual_tags"`
+	// Release origins to favor, best first (e.g. ["US", "UK"]), detected from country tags
+	// and regional network tags in the title. Other releases are not penalized.
+	PreferredRegions []string `json:"preferred_regions"`
+	// Boost for the first preferred region; later ones get proportionally less (0 = default 1000)
+	RegionWeight int `json:"region_weight"`
 }
 
 // DefaultSortConfig returns built-in sort weights used when config has empty values.
@@ -154,6 +226,21 @@ type IndexerConfig struct {
 	// Easynews-specific fields
 	Username string `json:"username"` // Easynews username
 	Password string `json:"password"` // Easynews password
+	// CacheNZB keeps Easynews dl-nzb responses in memory so repeated plays skip the dl-nzb call
+	CacheNZB bool `json:"cache_nzb,omitempty"`
+	// QueryStrategy builds Easynews ID searches from the IMDb number ("imdb-number", the
+	// default), the TMDB title and year ("title-year") or the title alone ("title-only")
+	QueryStrategy string `json:"query_strategy,omitempty"`
+	// Categories overrides the newznab cat parameter per content type,
+	// e.g. {"movie": "2000,2040", "series": "5000,5070"}. Unset keys use the standard categories.
+	Categories map[string]string `json:"categories,omitempty"`
+	// TimeoutSeconds cuts off this indexer's searches and NZB downloads (0 = default:
+	// 30s for newznab, 15s search / 30s download for Easynews)
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// UserAgent and Headers are sent with every request to this newznab indexer (e.g. a UA
+	// allowlist or a Cloudflare cookie). UserAgent overrides the INDEXER_*_HEADER env UA.
+	UserAgent string            `json:"user_agent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
 }
 
 // Config holds application configuration
@@ -165,6 +252,21 @@ type Config struct {
 	AddonPort    int    `json:"addon_port"`
 	AddonBaseURL string `json:"addon_base_url"`
 	LogLevel     string `json:"log_level"`
+	LogFormat    string `json:"log_format"` // "text" or "json" (stdout and log file)
+	// Level overrides per subsystem (unpack, validation, loader, nntp, availnzb), e.g. {"unpack": "DEBUG"}
+	LogLevels map[string]string `json:"log_levels"`
+	// HTTPS served by the addon itself (empty = plain HTTP, e.g. behind a reverse proxy):
+	// a certificate/key pair, or Let's Encrypt certificates for ACMEDomain (comma-separated)
+	TLSCertFile string `json:"tls_cert_file"`
+	TLSKeyFile  string `json:"tls_key_file"`
+	ACMEDomain  string `json:"acme_domain"`
+	// Reverse proxies (CIDRs or IPs) whose X-Forwarded-For / X-Real-IP headers are trusted for client IPs
+	TrustedProxies []string `json:"trusted_proxies"`
+	// Branding for public instances; empty values keep the built-in name, logo and error video.
+	// CustomErrorVideoURL is where failed playbacks are redirected instead of /error/failure.mp4.
+	AddonName           string `json:"addon_name"`
+	AddonLogoURL        string `json:"addon_logo_url"`
+	CustomErrorVideoURL string `json:"custom_error_video_url"`
 
 	// Dashboard admin: stored in config.json (never send hash/token to frontend)
 	AdminUsername           string `json:"admin_username"`
@@ -177,9 +279,116 @@ type Config struct {
 	ValidationSampleSize    int `json:"validation_sample_size"`
 	MaxStreams              int `json:"max_streams"`                // Max successful streams to return per search
 	MaxStreamsPerResolution int `json:"max_streams_per_resolution"` // Max streams per resolution (0 = disabled, use MaxStreams behavior)
+	// Indexer candidates validated per search: MaxStreams * ValidationAttemptMultiplier, at least
+	// MinValidationAttempts. Raise them to dig deeper on content where most releases are dead.
+	ValidationAttemptMultiplier int `json:"validation_attempt_multiplier"`
+	MinValidationAttempts       int `json:"min_validation_attempts"`
+	// Candidates validated at the same time per search (default 6); capped by the connections
+	// validation can use across the enabled providers
+	ValidationConcurrency int `json:"validation_concurrency"`
+	// Memory bounds for large searches (0 = unlimited): results kept per indexer response,
+	// and indexer candidates kept after triage (highest scored first)
+	MaxRawResultsPerIndexer  int `json:"max_raw_results_per_indexer"`
+	MaxCandidatesAfterTriage int `json:"max_candidates_after_triage"`
+
+	// Read-only WebDAV view of validated sessions at /{token}/dav/ (default false)
+	WebDAVEnabled bool `json:"webdav_enabled"`
+	// Return only releases AvailNZB already confirms available (instant, nothing downloaded
+	// for validation). When that is not enough, indexer candidates are still validated unless
+	// PreferCachedStrict is set.
+	PreferCachedOnly   bool `json:"prefer_cached_only"`
+	PreferCachedStrict bool `json:"prefer_cached_strict"`
+	// Minimum fraction of sampled articles a provider must have (1.0 = all). Streams accepted
+	// below 100% are marked with their completion percentage.
+	MinAvailabilityRatio float64 `json:"min_availability_ratio"`
+	// Dedupe validated streams by NZB content (first article Message-ID) instead of only by
+	// normalized title: re-uploads under different names merge, distinct uploads sharing a title stay
+	DedupeByContentHash bool `json:"dedupe_by_content_hash"`
+	// Stream RAR/7z releases (default true). When false only direct-file releases are
+	// returned and archives are skipped before scanning, which helps on constrained hardware.
+	AllowArchiveStreaming bool `json:"allow_archive_streaming"`
+	// Also check the last N percent of segments (0 = only the final one); providers with an
+	// intact tail are preferred since players need the file index stored there
+	ValidateTailDepth float64 `json:"validate_tail_depth"`
+	// Read the header of validated direct MKV releases and correct the resolution and codec
+	// parsed from the title when the video track disagrees (one extra segment per release)
+	VerifyMediaMetadata bool `json:"verify_media_metadata"`
+	// Reject providers whose probed articles fail the yEnc CRC32 check (default true);
+	// such providers are reported as serving corrupt data rather than missing articles
+	ValidationVerifyCRC bool `json:"validation_verify_crc"`
+	// Releases whose files are not recognizable by name: "heuristic" (probe when playing),
+	// "skip" (drop random titles at triage, others once the NZB is inspected) or "probe"
+	// (check the first segment's magic bytes)
+	ObfuscatedPolicy string `json:"obfuscated_policy"`
+	// Releases AvailNZB reports healthy but the indexer lists without a size: "defer"
+	// (deferred session without Content-Length until played), "resolve" (download and
+	// validate the NZB to get the real size) or "skip"
+	ZeroSizePolicy string `json:"zero_size_policy"`
+	// Absolute episode matching for anime: "auto" (series with TVDB's Anime genre), "always" or "off"
+	AnimeAbsoluteNumbering string `json:"anime_absolute_numbering"`
+
+	// Default limit on simultaneous playbacks per device (0 = unlimited). Devices can override.
+	MaxConcurrentPlaybacks int `json:"max_concurrent_playbacks"`
+	// Go text/template for the stream description (see stremio.StreamTemplateData); empty = built-in default
+	StreamTitleTemplate string `json:"stream_title_template"`
+	// Link the release NFO (newznab t=getnfo) in stream descriptions; fetched on demand, costs an API hit
+	FetchNFO bool `json:"fetch_nfo"`
+	// Seconds a device's /stream result is reused for repeated requests of the same content (0 = disabled)
+	StreamCacheTTLSeconds int `json:"stream_cache_ttl_seconds"`
+	// Search+validate runs per device and minute for /stream (0 = unlimited); beyond it the
+	// device gets its previous result for the content
+	StreamRateLimitPerMinute int `json:"stream_rate_limit_per_minute"`
+
+	// Segments prefetched ahead of playback and after a seek (0 = one per provider connection, max 20)
+	ReadAheadSegments int `json:"read_ahead_segments"`
+	// How long one provider may take to deliver a segment before the next is tried. Validation
+	// fails fast; playback waits longer so slow providers don't cause zero-filled segments.
+	ValidationSegmentTimeoutMs int `json:"validation_segment_timeout_ms"`
+	PlaybackSegmentTimeoutMs   int `json:"playback_segment_timeout_ms"`
+	// Seconds without reads (player paused) before a stream stops prefetching and frees its
+	// provider connections for other streams; resumes on the next read (0 = never)
+	PauseReleaseSeconds int `json:"pause_release_seconds"`
+
+	// How often expired sessions are swept (default 300). Played sessions without active
+	// playback are also closed after SessionIdleTimeoutMinutes (0 = only the 30 minute TTL).
+	SessionSweepIntervalSeconds int `json:"session_sweep_interval_seconds"`
+	SessionIdleTimeoutMinutes   int `json:"session_idle_timeout_minutes"`
+
+	// On-disk archive blueprint cache (data dir "blueprints"); 0 entries disables it
+	BlueprintCacheMaxEntries  int `json:"blueprint_cache_max_entries"`
+	BlueprintCacheMaxAgeHours int `json:"blueprint_cache_max_age_hours"`
+
+	// Decoded segment cache shared by all sessions. Memory is capped at SegmentCacheMemoryMB;
+	// with SegmentCacheDir set (relative paths are under the data dir), segments evicted
+	// from memory are kept on disk up to SegmentCacheDiskMB. Applied at startup.
+	SegmentCacheMemoryMB int    `json:"segment_cache_memory_mb"`
+	SegmentCacheDir      string `json:"segment_cache_dir"`
+	SegmentCacheDiskMB   int    `json:"segment_cache_disk_mb"`
+
+	// Persistent segment spool (relative paths are under the data dir), checked by
+	// Message-ID before fetching over NNTP and filled with fetched segments. Unlike the
+	// segment cache it survives restarts; the least recently used segments beyond
+	// SegmentSpoolMaxMB are evicted (0 = no limit). Applied at startup.
+	SegmentSpoolDir   string `json:"segment_spool_dir"`
+	SegmentSpoolMaxMB int    `json:"segment_spool_max_mb"`
+
+	// Browser origins allowed to use the admin API and WebSocket (e.g. "https://admin.example.com").
+	// Empty = any origin. Stremio addon endpoints always allow any origin.
+	AllowedOrigins []string `json:"allowed_origins"`
 
 	// NNTP Providers
 	Providers []Provider `json:"providers"`
+	// Keep the connection opened while validating each provider at startup idle and ready,
+	// instead of letting it time out, so the first stream does not pay dial+TLS+auth latency
+	WarmConnectionsOnStart bool `json:"warm_connections_on_start"`
+	// Idle NNTP connections are closed after NNTPIdleTimeoutSeconds (default 30); the ones
+	// kept open are pinged every NNTPKeepaliveSeconds (default 60, 0 = off) so a provider
+	// dropping idle connections doesn't fail the first command after a pause
+	NNTPIdleTimeoutSeconds int `json:"nntp_idle_timeout_seconds"`
+	NNTPKeepaliveSeconds   int `json:"nntp_keepalive_seconds"`
+	// Group selected when a provider is checked (startup, dashboard validation) to confirm
+	// the account serves binary groups; the reported article count is shown ("" = skip)
+	ProviderTestGroup string `json:"provider_test_group"`
 
 	// NNTP Proxy
 	ProxyEnabled  bool   `json:"proxy_enabled"`
@@ -187,10 +396,20 @@ type Config struct {
 	ProxyHost     string `json:"proxy_host"`
 	ProxyAuthUser string `json:"proxy_auth_user"`
 	ProxyAuthPass string `json:"proxy_auth_pass"`
+	// ProxyPostEnabled allows authenticated proxy clients to POST/IHAVE articles,
+	// forwarded to ProxyPostProvider (provider name). Requires proxy credentials.
+	ProxyPostEnabled  bool   `json:"proxy_post_enabled"`
+	ProxyPostProvider string `json:"proxy_post_provider"`
 
 	// AvailNZB (Internal/Community)
 	AvailNZBURL    string `json:"-"`
 	AvailNZBAPIKey string `json:"-"`
+	// Send availability reports to AvailNZB (lookups stay active when disabled). Devices can override.
+	AvailNZBReportEnabled bool `json:"availnzb_report_enabled"`
+	// Timeout of each AvailNZB request; lookups are skipped for a while after repeated failures
+	AvailNZBTimeoutMs int `json:"availnzb_timeout_ms"`
+	// Releases AvailNZB reported unhealthy within this many hours are skipped (0 = always validate)
+	UnhealthyGracePeriodHours int `json:"unhealthy_grace_period_hours"`
 
 	// TMDB Settings
 	TMDBAPIKey string `json:"-"`
@@ -216,6 +435,41 @@ func (c *Config) GetAdminUsername() string {
 	return "admin"
 }
 
+// TLSEnabled reports whether the addon server listens on HTTPS itself.
+func (c *Config) TLSEnabled() bool {
+	return c != nil && (len(c.ACMEDomains()) > 0 || (c.TLSCertFile != "" && c.TLSKeyFile != ""))
+}
+
+// ACMEDomains returns the domains to obtain Let's Encrypt certificates for.
+func (c *Config) ACMEDomains() []string {
+	var domains []string
+	for _, d := range strings.Split(c.ACMEDomain, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+// BaseURL returns the public addon URL used in manifests and stream links: AddonBaseURL,
+// switched to https:// when TLS is enabled. With ACME, a localhost base URL becomes the
+// first ACME domain.
+func (c *Config) BaseURL() string {
+	if !c.TLSEnabled() {
+		return c.AddonBaseURL
+	}
+	if domains := c.ACMEDomains(); len(domains) > 0 {
+		u, err := url.Parse(c.AddonBaseURL)
+		if c.AddonBaseURL == "" || (err == nil && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1")) {
+			return "https://" + domains[0]
+		}
+	}
+	if rest, ok := strings.CutPrefix(c.AddonBaseURL, "http://"); ok {
+		return "https://" + rest
+	}
+	return c.AddonBaseURL
+}
+
 // Load is intended for startup only. It loads configuration from config.json,
 // applies environment variable overrides once, then saves the merged config.
 // Environment variables are not read again after startup; subsequent reloads
@@ -237,16 +491,42 @@ func Load() (*Config, error) {
 	// 2. Load config.json (or create with defaults if it doesn't exist)
 	cfg := &Config{
 		// Set defaults
-		AddonPort:               7000,
-		AddonBaseURL:            "http://localhost:7000",
-		LogLevel:                "INFO",
-		AdminUsername:           "admin",
-		CacheTTLSeconds:         300,
-		ValidationSampleSize:    5,
-		MaxStreams:              6,
-		MaxStreamsPerResolution: 0, // 0 = disabled
-		ProxyPort:               119,
-		ProxyHost:               "0.0.0.0",
+		AddonPort:                   7000,
+		AddonBaseURL:                "http://localhost:7000",
+		LogLevel:                    "INFO",
+		AdminUsername:               "admin",
+		CacheTTLSeconds:             300,
+		ValidationSampleSize:        5,
+		MaxStreams:                  6,
+		MaxStreamsPerResolution:     0, // 0 = disabled
+		ValidationAttemptMultiplier: 2,
+		MinValidationAttempts:       6,
+		ValidationConcurrency:       6,
+		MinAvailabilityRatio:        1.0,
+		StreamCacheTTLSeconds:       30,
+		StreamRateLimitPerMinute:    20,
+		AvailNZBReportEnabled:       true,
+		AvailNZBTimeoutMs:           5000,
+		UnhealthyGracePeriodHours:   24,
+		AnimeAbsoluteNumbering:      "auto",
+		ObfuscatedPolicy:            "heuristic",
+		ZeroSizePolicy:              "defer",
+		AllowArchiveStreaming:       true,
+		ValidationVerifyCRC:         true,
+		BlueprintCacheMaxEntries:    500,
+		BlueprintCacheMaxAgeHours:   168,
+		SegmentCacheMemoryMB:        512,
+		SegmentCacheDiskMB:          2048,
+		SegmentSpoolMaxMB:           20480,
+		NNTPIdleTimeoutSeconds:      30,
+		NNTPKeepaliveSeconds:        60,
+		ProviderTestGroup:           "alt.binaries.test",
+		ValidationSegmentTimeoutMs:  10000,
+		PlaybackSegmentTimeoutMs:    60000,
+		PauseReleaseSeconds:         15,
+		SessionSweepIntervalSeconds: 300,
+		ProxyPort:                   119,
+		ProxyHost:                   "0.0.0.0",
 		Sorting: SortConfig{
 			ResolutionWeights: map[string]int{
 				"4k":    4000000,
@@ -306,6 +586,10 @@ func Load() (*Config, error) {
 	overrides, keys := env.ReadConfigOverrides()
 	ApplyEnvOverrides(cfg, overrides, keys)
 
+	if !ValidZeroSizePolicy(cfg.ZeroSizePolicy) {
+		return nil, fmt.Errorf("invalid zero_size_policy %q: must be defer, resolve or skip", cfg.ZeroSizePolicy)
+	}
+
 	// 4. Migrate legacy indexers
 	cfg.MigrateLegacyIndexers()
 
@@ -431,6 +715,9 @@ func ApplyEnvOverrides(cfg *Config, o env.ConfigOverrides, keys []string) {
 	if keySet(keys, env.KeyLogLevel) {
 		cfg.LogLevel = o.LogLevel
 	}
+	if keySet(keys, env.KeyLogFormat) {
+		cfg.LogFormat = o.LogFormat
+	}
 	if keySet(keys, env.KeyCacheTTL) {
 		cfg.CacheTTLSeconds = o.CacheTTLSeconds
 	}
@@ -467,15 +754,19 @@ func ApplyEnvOverrides(cfg *Config, o env.ConfigOverrides, keys []string) {
 				enabled = p.Enabled
 			}
 			cfg.Providers[i] = Provider{
-				Name:        p.Name,
-				Host:        p.Host,
-				Port:        p.Port,
-				Username:    p.Username,
-				Password:    p.Password,
-				Connections: p.Connections,
-				UseSSL:      p.UseSSL,
-				Priority:    priority,
-				Enabled:     enabled,
+				Name:                        p.Name,
+				Host:                        p.Host,
+				Port:                        p.Port,
+				Username:                    p.Username,
+				Password:                    p.Password,
+				Connections:                 p.Connections,
+				UseSSL:                      p.UseSSL,
+				Priority:                    priority,
+				Enabled:                     enabled,
+				Compression:                 p.Compression,
+				PlaybackReservedConnections: p.PlaybackReservedConnections,
+				Role:                        p.Role,
+				Weight:                      p.Weight,
 			}
 		}
 	}
@@ -525,6 +816,8 @@ func CopyEnvOverridesFrom(src, dst *Config) {
 			dst.AddonBaseURL = src.AddonBaseURL
 		case env.KeyLogLevel:
 			dst.LogLevel = src.LogLevel
+		case env.KeyLogFormat:
+			dst.LogFormat = src.LogFormat
 		case env.KeyCacheTTL:
 			dst.CacheTTLSeconds = src.CacheTTLSeconds
 		case env.KeyValidationSize:
@@ -555,15 +848,19 @@ func CopyEnvOverridesFrom(src, dst *Config) {
 					enabled = &enabledVal
 				}
 				dst.Providers[i] = Provider{
-					Name:        p.Name,
-					Host:        p.Host,
-					Port:        p.Port,
-					Username:    p.Username,
-					Password:    p.Password,
-					Connections: p.Connections,
-					UseSSL:      p.UseSSL,
-					Priority:    priority,
-					Enabled:     enabled,
+					Name:                        p.Name,
+					Host:                        p.Host,
+					Port:                        p.Port,
+					Username:                    p.Username,
+					Password:                    p.Password,
+					Connections:                 p.Connections,
+					UseSSL:                      p.UseSSL,
+					Priority:                    priority,
+					Enabled:                     enabled,
+					Compression:                 p.Compression,
+					PlaybackReservedConnections: p.PlaybackReservedConnections,
+					Role:                        p.Role,
+					Weight:                      p.Weight,
 				}
 			}
 		case env.KeyIndexers:
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestACMEDomains(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{" , ", nil},
+		{"a.example.com", []string{"a.example.com"}},
+		{" a.example.com , b.example.com,", []string{"a.example.com", "b.example.com"}},
+	}
+	for _, tt := range tests {
+		c := &Config{ACMEDomain: tt.in}
+		if got := c.ACMEDomains(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ACMEDomains(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"no TLS", Config{AddonBaseURL: "http://localhost:7000"}, "http://localhost:7000"},
+		{"cert pair", Config{AddonBaseURL: "http://nzb.example.com:7000", TLSCertFile: "c.pem", TLSKeyFile: "k.pem"}, "https://nzb.example.com:7000"},
+		{"cert without key", Config{AddonBaseURL: "http://nzb.example.com:7000", TLSCertFile: "c.pem"}, "http://nzb.example.com:7000"},
+		{"ACME localhost", Config{AddonBaseURL: "http://localhost:7000", ACMEDomain: "a.example.com,b.example.com"}, "https://a.example.com"},
+		{"ACME loopback", Config{AddonBaseURL: "http://127.0.0.1:7000", ACMEDomain: "a.example.com"}, "https://a.example.com"},
+		{"ACME empty base", Config{ACMEDomain: "a.example.com"}, "https://a.example.com"},
+		{"ACME public base", Config{AddonBaseURL: "http://nzb.example.com", ACMEDomain: "a.example.com"}, "https://nzb.example.com"},
+		{"already https", Config{AddonBaseURL: "https://nzb.example.com", ACMEDomain: "a.example.com"}, "https://nzb.example.com"},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.BaseURL(); got != tt.want {
+			t.Errorf("%s: BaseURL() = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidZeroSizePolicy(t *testing.T) {
+	for _, p := range []string{"", "defer", "resolve", "skip"} {
+		if !ValidZeroSizePolicy(p) {
+			t.Errorf("ValidZeroSizePolicy(%q) = false", p)
+		}
+	}
+	for _, p := range []string{"Defer", "drop", "0"} {
+		if ValidZeroSizePolicy(p) {
+			t.Errorf("ValidZeroSizePolicy(%q) = true", p)
+		}
+	}
+}
+
+func TestProviderRoles(t *testing.T) {
+	tests := []struct {
+		role               string
+		valid              bool
+		validate, download bool
+	}{
+		{"", true, true, true},
+		{ProviderRoleAll, true, true, true},
+		{ProviderRoleValidate, true, true, false},
+		{ProviderRoleDownload, true, false, true},
+		{"Validate", false, true, true},
+	}
+	for _, tt := range tests {
+		p := Provider{Role: tt.role}
+		if got := ValidProviderRole(tt.role); got != tt.valid {
+			t.Errorf("ValidProviderRole(%q) = %v", tt.role, got)
+		}
+		if p.UsedForValidation() != tt.validate || p.UsedForDownload() != tt.download {
+			t.Errorf("role %q: validation %v, download %v; want %v, %v", tt.role, p.UsedForValidation(), p.UsedForDownload(), tt.validate, tt.download)
+		}
+	}
+}
@@ -14,6 +14,7 @@ const (
 	ADDONPort             = "ADDON_PORT"
 	ADDONBaseURL          = "ADDON_BASE_URL"
 	LOGLevel              = "LOG_LEVEL"
+	LOGFormat             = "LOG_FORMAT"
 	CacheTTLSeconds       = "CACHE_TTL_SECONDS"
 	ValidationSampleSize  = "VALIDATION_SAMPLE_SIZE"
 	AvailNZBURL           = "AVAILNZB_URL"
@@ -38,6 +39,7 @@ const (
 	KeyAddonPort      = "addon_port"
 	KeyAddonBaseURL   = "addon_base_url"
 	KeyLogLevel       = "log_level"
+	KeyLogFormat      = "log_format"
 	KeyCacheTTL       = "cache_ttl_seconds"
 	KeyValidationSize = "validation_sample_size"
 	KeyProxyEnabled   = "proxy_enabled"
@@ -84,6 +86,14 @@ func LogLevel() string {
 	return "INFO"
 }
 
+// LogFormat returns LOG_FORMAT ("text" or "json") with default "text" (for early logger init before config).
+func LogFormat() string {
+	if v := os.Getenv(LOGFormat); v != "" {
+		return v
+	}
+	return "text"
+}
+
 // Provider and Indexer mirror config types so this package does not depend on config.
 type Provider struct {
 	Name        string
@@ -95,6 +105,11 @@ type Provider struct {
 	UseSSL      bool
 	Priority    *int
 	Enabled     *bool
+	Compression bool
+	// Connections kept free of validation/cache warming
+	PlaybackReservedConnections int
+	Role                        string
+	Weight                      int
 }
 
 type Indexer struct {
@@ -109,6 +124,7 @@ type ConfigOverrides struct {
 	AddonPort            int
 	AddonBaseURL         string
 	LogLevel             string
+	LogFormat            string
 	CacheTTLSeconds      int
 	ValidationSampleSize int
 	AvailNZBURL          string
@@ -146,6 +162,10 @@ func ReadConfigOverrides() (ConfigOverrides, []string) {
 		o.LogLevel = v
 		keys = append(keys, KeyLogLevel)
 	}
+	if v := os.Getenv(LOGFormat); v != "" {
+		o.LogFormat = v
+		keys = append(keys, KeyLogFormat)
+	}
 	if v := os.Getenv(CacheTTLSeconds); v != "" {
 		if ttl, err := strconv.Atoi(v); err == nil {
 			o.CacheTTLSeconds = ttl
@@ -217,15 +237,19 @@ func readProvidersFromEnv() []Provider {
 		priority := getEnvInt(prefix+"PRIORITY", i)   // Default priority matches provider number
 		enabled := getEnvBool(prefix+"ENABLED", true) // Default to enabled
 		list = append(list, Provider{
-			Name:        getEnv(prefix+"NAME", fmt.Sprintf("Provider %d", i)),
-			Host:        host,
-			Port:        getEnvInt(prefix+"PORT", 563),
-			Username:    os.Getenv(prefix + "USERNAME"),
-			Password:    os.Getenv(prefix + "PASSWORD"),
-			Connections: getEnvInt(prefix+"CONNECTIONS", 10),
-			UseSSL:      getEnvBool(prefix+"SSL", true),
-			Priority:    &priority,
-			Enabled:     &enabled,
+			Name:                        getEnv(prefix+"NAME", fmt.Sprintf("Provider %d", i)),
+			Host:                        host,
+			Port:                        getEnvInt(prefix+"PORT", 563),
+			Username:                    os.Getenv(prefix + "USERNAME"),
+			Password:                    os.Getenv(prefix + "PASSWORD"),
+			Connections:                 getEnvInt(prefix+"CONNECTIONS", 10),
+			UseSSL:                      getEnvBool(prefix+"SSL", true),
+			Priority:                    &priority,
+			Enabled:                     &enabled,
+			Compression:                 getEnvBool(prefix+"COMPRESSION", false),
+			PlaybackReservedConnections: getEnvInt(prefix+"RESERVED_CONNECTIONS", 0),
+			Role:                        os.Getenv(prefix + "ROLE"),
+			Weight:                      getEnvInt(prefix+"WEIGHT", 0),
 		})
 	}
 	return list
@@ -70,21 +70,60 @@ func SetBroadcast(ch chan<- string) {
 	// But Init creates a NEW logger.
 }
 
+// Output formats for stdout and the log file (see SetFormat)
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var (
+	logFormat   string // empty until SetFormat; falls back to LOG_FORMAT
+	logLevelStr string
+	settingsMu  sync.Mutex
+)
+
+// SetFormat switches stdout and log file output between "text" and "json" lines,
+// keeping the current level. The dashboard history is always kept in text form.
+func SetFormat(format string) {
+	format = normalizeFormat(format)
+	settingsMu.Lock()
+	current := logFormat
+	if current == "" {
+		current = normalizeFormat(env.LogFormat())
+	}
+	logFormat = format
+	level := logLevelStr
+	settingsMu.Unlock()
+	if format != current {
+		Init(level)
+	}
+}
+
+func normalizeFormat(format string) string {
+	if strings.EqualFold(strings.TrimSpace(format), FormatJSON) {
+		return FormatJSON
+	}
+	return FormatText
+}
+
 // Init initializes the global logger
 func Init(levelStr string) {
-	var level slog.Level
-	switch strings.ToUpper(levelStr) {
-	case "TRACE":
-		level = slog.LevelDebug - 1 // More verbose than Debug
-	case "DEBUG":
-		level = slog.LevelDebug
-	case "WARN":
-		level = slog.LevelWarn
-	case "ERROR":
-		level = slog.LevelError
-	default:
+	settingsMu.Lock()
+	logLevelStr = levelStr
+	format := logFormat
+	settingsMu.Unlock()
+	if format == "" {
+		format = normalizeFormat(env.LogFormat())
+	}
+
+	level, err := ParseLevel(levelStr)
+	if err != nil {
 		level = slog.LevelInfo
 	}
+	levelsMu.Lock()
+	globalLevel = level
+	updateMinLevelLocked()
+	levelsMu.Unlock()
 
 	// Load timezone from TZ environment variable (single source: pkg/env)
 	tzEnv := env.TZ()
@@ -132,8 +171,10 @@ func Init(levelStr string) {
 	// Create handler options with ReplaceAttr to use configured timezone
 	// Capture the location in the closure
 	tzLoc := loc
+	// The handlers accept every level; GlobalBroadcastHandler filters by the global and
+	// subsystem levels (see SetSubsystemLevels)
 	opts := &slog.HandlerOptions{
-		Level: level,
+		Level: LevelTrace,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
 			// Replace time attribute with time in configured timezone
 			if a.Key == slog.TimeKey {
@@ -141,17 +182,28 @@ func Init(levelStr string) {
 				t := a.Value.Time().In(tzLoc)
 				return slog.String("time", t.Format("2006-01-02T15:04:05.000-07:00"))
 			}
+			if a.Key == slog.LevelKey && a.Value.Any() == LevelTrace {
+				return slog.String(slog.LevelKey, "TRACE")
+			}
 			return a
 		},
 	}
 
 	// Create handler that writes to stdout only (log file is written separately in GlobalBroadcastHandler)
-	baseHandler := slog.NewTextHandler(os.Stdout, opts)
+	var baseHandler slog.Handler
+	var fileHandler slog.Handler
+	if format == FormatJSON {
+		baseHandler = slog.NewJSONHandler(os.Stdout, opts)
+		fileHandler = slog.NewJSONHandler(logFileWriter{}, opts)
+	} else {
+		baseHandler = slog.NewTextHandler(os.Stdout, opts)
+	}
 
 	// Always wrap with our broadcaster
 	// We use the global broadcastCh so we can set it anytime
 	handler := &GlobalBroadcastHandler{
-		Handler: baseHandler,
+		Handler:     baseHandler,
+		fileHandler: fileHandler,
 	}
 
 	Log = slog.New(handler)
@@ -170,6 +222,19 @@ func Init(levelStr string) {
 // GlobalBroadcastHandler uses the package-level broadcastCh
 type GlobalBroadcastHandler struct {
 	slog.Handler
+	fileHandler slog.Handler // JSON mode only; nil writes the text line to the log file
+}
+
+// logFileWriter writes to the current log file (no-op when none is open).
+type logFileWriter struct{}
+
+func (logFileWriter) Write(p []byte) (int, error) {
+	logFileMu.Lock()
+	defer logFileMu.Unlock()
+	if logFile == nil {
+		return len(p), nil
+	}
+	return logFile.Write(p)
 }
 
 var (
@@ -182,7 +247,16 @@ var (
 	locationMu  sync.RWMutex
 )
 
+// Enabled lets through every level some subsystem logs at; Handle drops the rest.
+func (h *GlobalBroadcastHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.Level(minLevel.Load())
+}
+
 func (h *GlobalBroadcastHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < recordLevel(r) {
+		return nil
+	}
+
 	// Use configured timezone (from TZ environment variable)
 	locationMu.RLock()
 	loc := logLocation
@@ -214,12 +288,16 @@ func (h *GlobalBroadcastHandler) Handle(ctx context.Context, r slog.Record) erro
 	// 2. Write to base handler (stdout only)
 	err := h.Handler.Handle(ctx, r)
 
-	// 3. Write to log file with custom format
-	logFileMu.Lock()
-	if logFile != nil {
-		fmt.Fprintln(logFile, msg)
+	// 3. Write to log file with custom format (or JSON lines)
+	if h.fileHandler != nil {
+		_ = h.fileHandler.Handle(ctx, r)
+	} else {
+		logFileMu.Lock()
+		if logFile != nil {
+			fmt.Fprintln(logFile, msg)
+		}
+		logFileMu.Unlock()
 	}
-	logFileMu.Unlock()
 
 	// 4. Broadcast
 	if broadcastCh != nil {
@@ -259,7 +337,7 @@ func Close() {
 // Helper functions for easy access
 // Trace is for verbose debugging; set LOG_LEVEL=TRACE to see these
 func Trace(msg string, args ...any) {
-	Log.Log(context.TODO(), slog.LevelDebug-1, msg, args...)
+	Log.Log(context.TODO(), LevelTrace, msg, args...)
 }
 
 func Debug(msg string, args ...any) {
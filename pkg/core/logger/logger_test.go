@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useLogFile points the log file at a temp file for the test and returns its path.
+func useLogFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFileMu.Lock()
+	prev := logFile
+	logFile = f
+	logFileMu.Unlock()
+	t.Cleanup(func() {
+		logFileMu.Lock()
+		logFile = prev
+		logFileMu.Unlock()
+		f.Close()
+	})
+	return path
+}
+
+func TestSetFormatJSON(t *testing.T) {
+	Init("TRACE")
+	SetFormat(" JSON ")
+	path := useLogFile(t)
+	ch := make(chan string, 10)
+	SetBroadcast(ch)
+	t.Cleanup(func() {
+		SetBroadcast(nil)
+		SetFormat(FormatText)
+		Init("INFO")
+	})
+
+	Trace("json trace", "provider", "news.test")
+	Info("json info", "count", 3)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log file has %d lines; want 2:\n%s", len(lines), data)
+	}
+	var trace, info map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &trace); err != nil {
+		t.Fatalf("trace line is not JSON: %v: %s", err, lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &info); err != nil {
+		t.Fatalf("info line is not JSON: %v: %s", err, lines[1])
+	}
+	if trace["level"] != "TRACE" || trace["msg"] != "json trace" || trace["provider"] != "news.test" {
+		t.Errorf("trace line = %v", trace)
+	}
+	if info["level"] != "INFO" || info["count"] != float64(3) {
+		t.Errorf("info line = %v", info)
+	}
+
+	// The dashboard keeps text lines whatever the output format
+	for _, msg := range drain(ch) {
+		if strings.HasPrefix(msg, "{") || !strings.Contains(msg, "msg=") {
+			t.Errorf("broadcast line is not text: %s", msg)
+		}
+	}
+}
+
+func TestSetFormatTextLogFile(t *testing.T) {
+	Init("INFO")
+	SetFormat("unknown") // anything but json is text
+	path := useLogFile(t)
+
+	Info("text line", "count", 3)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); !strings.Contains(got, `level=INFO msg="text line" count=3`) {
+		t.Errorf("log file = %q", got)
+	}
+}
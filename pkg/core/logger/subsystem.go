@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelTrace is more verbose than Debug; shown as TRACE.
+const LevelTrace = slog.LevelDebug - 1
+
+// SubsystemKey is the attribute that tags messages of a scoped logger (see For).
+const SubsystemKey = "subsystem"
+
+var (
+	levelsMu        sync.RWMutex
+	globalLevel     = slog.LevelInfo
+	subsystemLevels map[string]slog.Level
+	// subsystems are the names registered by For, the valid keys of SetSubsystemLevels
+	subsystems = make(map[string]bool)
+	// minLevel is the lowest of the global and subsystem levels, for Enabled
+	minLevel atomic.Int64
+)
+
+// ParseLevel parses TRACE, DEBUG, INFO, WARN or ERROR (case-insensitive).
+func ParseLevel(levelStr string) (slog.Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(levelStr)) {
+	case "TRACE":
+		return LevelTrace, nil
+	case "DEBUG":
+		return slog.LevelDebug, nil
+	case "INFO", "":
+		return slog.LevelInfo, nil
+	case "WARN", "WARNING":
+		return slog.LevelWarn, nil
+	case "ERROR":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", levelStr)
+}
+
+// SetSubsystemLevels replaces the per-subsystem level overrides, e.g.
+// {"unpack": "DEBUG", "validation": "WARN"}. Messages of other subsystems and untagged
+// messages use the global level. Nothing is changed when a subsystem is unknown (see
+// Subsystems) or a level is invalid.
+func SetSubsystemLevels(levels map[string]string) error {
+	parsed := make(map[string]slog.Level, len(levels))
+	for name, levelStr := range levels {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if !isSubsystem(key) {
+			return fmt.Errorf("unknown subsystem %q (known: %s)", name, strings.Join(Subsystems(), ", "))
+		}
+		level, err := ParseLevel(levelStr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		parsed[key] = level
+	}
+	levelsMu.Lock()
+	subsystemLevels = parsed
+	updateMinLevelLocked()
+	levelsMu.Unlock()
+	return nil
+}
+
+// SubsystemLevels returns the current per-subsystem overrides.
+func SubsystemLevels() map[string]string {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	out := make(map[string]string, len(subsystemLevels))
+	for name, level := range subsystemLevels {
+		out[name] = levelName(level)
+	}
+	return out
+}
+
+// Subsystems returns the sorted names of the subsystems whose level can be set.
+func Subsystems() []string {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return slices.Sorted(maps.Keys(subsystems))
+}
+
+func isSubsystem(name string) bool {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return subsystems[name]
+}
+
+func updateMinLevelLocked() {
+	lowest := globalLevel
+	for level := range maps.Values(subsystemLevels) {
+		lowest = min(lowest, level)
+	}
+	minLevel.Store(int64(lowest))
+}
+
+// recordLevel returns the level a record must reach: its subsystem's override, else the
+// global level.
+func recordLevel(r slog.Record) slog.Level {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	level := globalLevel
+	if len(subsystemLevels) == 0 {
+		return level
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key != SubsystemKey {
+			return true
+		}
+		if override, ok := subsystemLevels[a.Value.String()]; ok {
+			level = override
+		}
+		return false
+	})
+	return level
+}
+
+func levelName(level slog.Level) string {
+	if level == LevelTrace {
+		return "TRACE"
+	}
+	return level.String()
+}
+
+// Scoped tags its messages with a subsystem so their level can be set separately.
+type Scoped struct {
+	subsystem string
+}
+
+// For returns a logger for a subsystem (e.g. "unpack"); its messages carry
+// subsystem=<name> and follow that subsystem's level override when one is set.
+func For(subsystem string) *Scoped {
+	levelsMu.Lock()
+	subsystems[subsystem] = true
+	levelsMu.Unlock()
+	return &Scoped{subsystem: subsystem}
+}
+
+func (s *Scoped) log(level slog.Level, msg string, args []any) {
+	if !Log.Enabled(context.TODO(), level) {
+		return
+	}
+	Log.Log(context.TODO(), level, msg, append([]any{SubsystemKey, s.subsystem}, args...)...)
+}
+
+func (s *Scoped) Trace(msg string, args ...any) { s.log(LevelTrace, msg, args) }
+func (s *Scoped) Debug(msg string, args ...any) { s.log(slog.LevelDebug, msg, args) }
+func (s *Scoped) Info(msg string, args ...any)  { s.log(slog.LevelInfo, msg, args) }
+func (s *Scoped) Warn(msg string, args ...any)  { s.log(slog.LevelWarn, msg, args) }
+func (s *Scoped) Error(msg string, args ...any) { s.log(slog.LevelError, msg, args) }
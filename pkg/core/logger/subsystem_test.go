@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// drain returns the messages broadcast so far.
+func drain(ch chan string) []string {
+	var msgs []string
+	for {
+		select {
+		case msg := <-ch:
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestSubsystemLevels(t *testing.T) {
+	Init("INFO")
+	ch := make(chan string, 100)
+	SetBroadcast(ch)
+	t.Cleanup(func() {
+		SetBroadcast(nil)
+		SetSubsystemLevels(nil)
+	})
+	verbose, quiet := For("testverbose"), For("testquiet")
+	if err := SetSubsystemLevels(map[string]string{" TestVerbose ": "debug", "testquiet": "ERROR"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := SubsystemLevels(); got["testverbose"] != "DEBUG" || got["testquiet"] != "ERROR" {
+		t.Fatalf("SubsystemLevels = %v", got)
+	}
+	drain(ch)
+
+	verbose.Debug("verbose debug")
+	quiet.Warn("quiet warn")
+	quiet.Error("quiet error")
+	Debug("untagged debug")
+	Info("untagged info")
+
+	var got []string
+	for _, msg := range drain(ch) {
+		for _, want := range []string{"verbose debug", "quiet warn", "quiet error", "untagged debug", "untagged info"} {
+			if strings.Contains(msg, `msg="`+want+`"`) {
+				got = append(got, want)
+			}
+		}
+	}
+	if want := []string{"verbose debug", "quiet error", "untagged info"}; !slices.Equal(got, want) {
+		t.Errorf("logged %v; want %v", got, want)
+	}
+}
+
+func TestSetSubsystemLevelsValidates(t *testing.T) {
+	Init("INFO")
+	t.Cleanup(func() { SetSubsystemLevels(nil) })
+	For("testvalid")
+	if !slices.Contains(Subsystems(), "testvalid") {
+		t.Fatalf("Subsystems() = %v; want testvalid registered", Subsystems())
+	}
+	if err := SetSubsystemLevels(map[string]string{"testvalid": "WARN"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, levels := range []map[string]string{
+		{"testvalid": "DEBUG", "nosuchsubsystem": "DEBUG"},
+		{"testvalid": "LOUD"},
+	} {
+		if err := SetSubsystemLevels(levels); err == nil {
+			t.Errorf("SetSubsystemLevels(%v) accepted", levels)
+		}
+		if got := SubsystemLevels(); len(got) != 1 || got["testvalid"] != "WARN" {
+			t.Errorf("levels changed by a rejected update: %v", got)
+		}
+	}
+	if err := SetSubsystemLevels(map[string]string{"nosuchsubsystem": "DEBUG"}); err == nil || !strings.Contains(err.Error(), "testvalid") {
+		t.Errorf("error should list the known subsystems: %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package indexer
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"net/url"
 	"sort"
@@ -15,6 +16,8 @@ import (
 // Aggregator combines multiple indexers into one
 type Aggregator struct {
 	Indexers []Indexer
+	// MaxResultsPerIndexer caps the results requested from and kept per indexer (0 = no cap)
+	MaxResultsPerIndexer int
 }
 
 // Name returns the name of the aggregator
@@ -131,6 +134,16 @@ func (a *Aggregator) Search(req SearchRequest) (*SearchResponse, error) {
 	resultsChan := make(chan []Item, len(a.Indexers))
 	var wg sync.WaitGroup
 
+	maxResults := a.MaxResultsPerIndexer
+	if maxResults > 0 && (req.Limit <= 0 || req.Limit > maxResults) {
+		req.Limit = maxResults
+	}
+
+	// A failing indexer only loses its own results; the search fails when all of them do
+	var failedMu sync.Mutex
+	var failed []string
+	var errs []error
+
 	// Launch parallel searches
 	for _, idx := range a.Indexers {
 		wg.Add(1)
@@ -139,15 +152,21 @@ func (a *Aggregator) Search(req SearchRequest) (*SearchResponse, error) {
 
 			resp, err := indexer.Search(req)
 			if err != nil {
-				// Log error but don't fail entire search?
-				// For now we just return empty result for this indexer
 				logger.Warn("Indexer search failed", "indexer", indexer.Name(), "err", err)
+				failedMu.Lock()
+				failed = append(failed, indexer.Name())
+				errs = append(errs, fmt.Errorf("%s: %w", indexer.Name(), err))
+				failedMu.Unlock()
 				resultsChan <- []Item{}
 				return
 			}
 
 			if resp != nil {
-				resultsChan <- resp.Channel.Items
+				items := resp.Channel.Items
+				if maxResults > 0 && len(items) > maxResults {
+					items = items[:maxResults]
+				}
+				resultsChan <- items
 			}
 		}(idx)
 	}
@@ -156,6 +175,13 @@ func (a *Aggregator) Search(req SearchRequest) (*SearchResponse, error) {
 	wg.Wait()
 	close(resultsChan)
 
+	if len(failed) > 0 {
+		if len(failed) == len(a.Indexers) {
+			return nil, fmt.Errorf("all indexers failed: %w", errors.Join(errs...))
+		}
+		logger.Debug("Search continuing with partial results", "failed", strings.Join(failed, ", "), "succeeded", len(a.Indexers)-len(failed))
+	}
+
 	// Collect results
 	var allItems []Item
 	for items := range resultsChan {
@@ -223,6 +249,7 @@ func (a *Aggregator) Search(req SearchRequest) (*SearchResponse, error) {
 		Channel: Channel{
 			Items: uniqueItems,
 		},
+		Partial: len(failed) > 0,
 	}
 	NormalizeSearchResponse(resp)
 	return resp, nil
@@ -0,0 +1,93 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+)
+
+type fakeIndexer struct {
+	name  string
+	items []Item
+	err   error
+	limit int // Limit of the last search request
+}
+
+func (f *fakeIndexer) Search(req SearchRequest) (*SearchResponse, error) {
+	f.limit = req.Limit
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &SearchResponse{Channel: Channel{Items: append([]Item(nil), f.items...)}}, nil
+}
+func (f *fakeIndexer) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeIndexer) Ping() error     { return nil }
+func (f *fakeIndexer) Name() string    { return f.name }
+func (f *fakeIndexer) GetUsage() Usage { return Usage{} }
+
+func TestAggregatorSearchPartial(t *testing.T) {
+	logger.Init("DEBUG")
+	ok := &fakeIndexer{name: "ok", items: []Item{{Title: "Movie.2001.1080p", GUID: "g1", Link: "http://ok/1", Size: 1}}}
+	down := &fakeIndexer{name: "down", err: errors.New("timeout")}
+
+	resp, err := NewAggregator(ok, down).Search(SearchRequest{Query: "movie"})
+	if err != nil {
+		t.Fatalf("one failed indexer failed the search: %v", err)
+	}
+	if !resp.Partial {
+		t.Error("response with a failed indexer not marked partial")
+	}
+	if len(resp.Releases) != 1 || resp.Releases[0].Title != "Movie.2001.1080p" {
+		t.Errorf("releases = %v; want the working indexer's result", resp.Releases)
+	}
+
+	resp, err = NewAggregator(ok).Search(SearchRequest{Query: "movie"})
+	if err != nil || resp.Partial {
+		t.Errorf("all indexers succeeded: partial=%v err=%v", resp != nil && resp.Partial, err)
+	}
+
+	if _, err := NewAggregator(down, &fakeIndexer{name: "down2", err: errors.New("503")}).Search(SearchRequest{Query: "movie"}); err == nil {
+		t.Error("search succeeded although every indexer failed")
+	}
+}
+
+func TestAggregatorMaxResultsPerIndexer(t *testing.T) {
+	logger.Init("DEBUG")
+	items := func(prefix string, n int) []Item {
+		var out []Item
+		for i := 0; i < n; i++ {
+			out = append(out, Item{Title: fmt.Sprintf("%s.Movie.2001.Part%d.1080p", prefix, i), GUID: fmt.Sprintf("%s%d", prefix, i), Link: fmt.Sprintf("http://%s/%d", prefix, i), Size: 1})
+		}
+		return out
+	}
+	a := &fakeIndexer{name: "a", items: items("a", 5)}
+	b := &fakeIndexer{name: "b", items: items("b", 2)}
+	agg := NewAggregator(a, b)
+	agg.MaxResultsPerIndexer = 3
+
+	resp, err := agg.Search(SearchRequest{Query: "movie"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Releases) != 5 {
+		t.Errorf("got %d releases; want 3 from a and 2 from b", len(resp.Releases))
+	}
+	if a.limit != 3 || b.limit != 3 {
+		t.Errorf("requested limits %d/%d; want the cap of 3", a.limit, b.limit)
+	}
+
+	// A smaller request limit is kept; no cap leaves the request alone
+	agg.Search(SearchRequest{Query: "movie", Limit: 2})
+	if a.limit != 2 {
+		t.Errorf("limit 2 under a cap of 3 became %d", a.limit)
+	}
+	agg.MaxResultsPerIndexer = 0
+	if resp, _ := agg.Search(SearchRequest{Query: "movie"}); len(resp.Releases) != 7 || a.limit != 0 {
+		t.Errorf("without a cap: %d releases, limit %d; want 7 and 0", len(resp.Releases), a.limit)
+	}
+}
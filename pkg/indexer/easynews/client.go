@@ -20,8 +20,9 @@ import (
 const (
 	easynewsBaseURL   = "https://members.easynews.com"
 	maxResultsPerPage = 250
-	searchTimeout     = 15 * time.Second
-	downloadTimeout   = 30 * time.Second
+	// Defaults; see SetTimeout
+	defaultSearchTimeout   = 15 * time.Second
+	defaultDownloadTimeout = 30 * time.Second
 )
 
 // Client represents an Easynews API client
@@ -32,6 +33,9 @@ type Client struct {
 	client       *http.Client
 	downloadBase string // Base URL for NZB download proxying
 
+	searchTimeout   time.Duration
+	downloadTimeout time.Duration
+
 	// Usage tracking
 	apiLimit          int
 	apiUsed           int
@@ -41,11 +45,28 @@ type Client struct {
 	downloadRemaining int
 	usageManager      *indexer.UsageManager
 	mu                sync.RWMutex
+
+	// dl-nzb responses by file hash; nil when disabled (see SetCacheNZB)
+	nzbCache *nzbCache
+
+	// Query construction of ID searches (see SetQueryStrategy)
+	queryStrategy string
+	titles        TitleResolver
 }
 
 // Ensure Client implements indexer.Indexer at compile time.
 var _ indexer.Indexer = (*Client)(nil)
 
+// SetTimeout overrides the search and NZB download timeouts (0 = defaults).
+func (c *Client) SetTimeout(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.searchTimeout = d
+	c.downloadTimeout = d
+	c.client.Timeout = d
+}
+
 // NewClient creates a new Easynews client
 func NewClient(username, password, name string, downloadBase string, apiLimit, downloadLimit int, um *indexer.UsageManager) (*Client, error) {
 	if username == "" || password == "" {
@@ -71,8 +92,10 @@ func NewClient(username, password, name string, downloadBase string, apiLimit, d
 		downloadLimit:     downloadLimit,
 		downloadUsed:      0,
 		downloadRemaining: downloadLimit,
+		searchTimeout:     defaultSearchTimeout,
+		downloadTimeout:   defaultDownloadTimeout,
 		client: &http.Client{
-			Timeout:   searchTimeout,
+			Timeout:   defaultSearchTimeout,
 			Transport: transport,
 		},
 	}
@@ -143,16 +166,7 @@ func (c *Client) Search(req indexer.SearchRequest) (*indexer.SearchResponse, err
 		return nil, err
 	}
 
-	// Build query from request
-	query := req.Query
-	if req.IMDbID != "" {
-		// Remove 'tt' prefix if present
-		imdbID := strings.TrimPrefix(req.IMDbID, "tt")
-		query = fmt.Sprintf("%s %s", query, imdbID)
-	}
-	if req.TMDBID != "" {
-		query = fmt.Sprintf("%s %s", query, req.TMDBID)
-	}
+	query := c.buildQuery(req)
 
 	season := req.Season
 	episode := req.Episode
@@ -200,10 +214,6 @@ func (c *Client) Search(req indexer.SearchRequest) (*indexer.SearchResponse, err
 // DownloadNZB downloads an NZB file.
 // ctx is used for timeout; use 60s for resolve/lazy load, 5s for validation.
 func (c *Client) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error) {
-	if err := c.checkDownloadLimit(); err != nil {
-		return nil, err
-	}
-
 	// Easynews URLs are proxied through our server
 	// Format: {downloadBase}/easynews/nzb?payload={token}
 	// We need to extract the payload and download from Easynews
@@ -223,11 +233,22 @@ func (c *Client) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error)
 		return nil, fmt.Errorf("invalid payload token: %w", err)
 	}
 
+	// Cached from an earlier play: no dl-nzb round trip, no download quota used
+	hash, _ := payload["hash"].(string)
+	if data, ok := c.cachedNZB(hash); ok {
+		return data, nil
+	}
+
+	if err := c.checkDownloadLimit(); err != nil {
+		return nil, err
+	}
+
 	// Build NZB download request
 	nzbData, err := c.downloadNZBInternal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download NZB: %w", err)
 	}
+	c.rememberNZB(hash, nzbData)
 
 	// Increment download usage
 	c.mu.Lock()
@@ -268,9 +289,9 @@ func (c *Client) searchInternal(query, season, episode, category string, strictM
 	params.Add("fty[]", "VIDEO")
 
 	// Add category filters if specified
-	if category == "2000" {
+	if category == indexer.CategoryMovie {
 		// Movies - could add specific filters
-	} else if category == "5000" {
+	} else if category == indexer.CategorySeries {
 		// TV - add season/episode to query if provided
 		if season != "" && episode != "" {
 			params.Set("gps", fmt.Sprintf("%s S%sE%s", query, season, episode))
@@ -279,7 +300,7 @@ func (c *Client) searchInternal(query, season, episode, category string, strictM
 
 	searchURL := fmt.Sprintf("%s/2.0/search/solr-search/?%s", easynewsBaseURL, params.Encode())
 
-	ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.searchTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
@@ -341,7 +362,7 @@ func (c *Client) downloadNZBInternal(payload map[string]interface{}) ([]byte, er
 		form.Set(key, value)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.downloadTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", easynewsBaseURL+"/2.0/api/dl-nzb", strings.NewReader(form.Encode()))
@@ -361,14 +382,17 @@ func (c *Client) downloadNZBInternal(payload map[string]interface{}) ([]byte, er
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("easynews NZB download failed with status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, indexer.CheckNZBResponse(c.Name(), resp.StatusCode, body)
 	}
 
 	nzbData, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read NZB data: %w", err)
 	}
+	if err := indexer.CheckNZBResponse(c.Name(), resp.StatusCode, nzbData); err != nil {
+		return nil, err
+	}
 
 	return nzbData, nil
 }
@@ -491,6 +515,12 @@ func (c *Client) filterAndMapResults(data easynewsSearchResponse, query, season,
 			if sig, ok := obj["sig"].(string); ok {
 				item.Sig = sig
 			}
+			if poster, ok := obj["poster"].(string); ok {
+				item.Poster = poster
+			}
+			if posted, ok := obj["posted"].(string); ok {
+				item.Posted = posted
+			}
 		}
 
 		if item.Hash == "" {
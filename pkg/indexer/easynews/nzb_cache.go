@@ -0,0 +1,103 @@
+package easynews
+
+import (
+	"container/list"
+)
+
+// maxCachedNZBBytes bounds the dl-nzb responses kept in memory (see SetCacheNZB).
+const maxCachedNZBBytes = 64 << 20
+
+// nzbCache keeps dl-nzb responses keyed by Easynews file hash so repeated plays of
+// the same release skip the dl-nzb POST. Memory is bounded with LRU eviction.
+//
+// Easynews search results carry no article message IDs, so NZBs cannot be built
+// from search metadata; caching the first dl-nzb response is the only way to
+// avoid the round trip.
+type nzbCache struct {
+	maxBytes int64
+	bytes    int64
+	entries  map[string]*list.Element
+	lru      *list.List // front = most recently used
+}
+
+type nzbCacheEntry struct {
+	hash string
+	data []byte
+}
+
+func newNZBCache(maxBytes int64) *nzbCache {
+	return &nzbCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (c *nzbCache) get(hash string) ([]byte, bool) {
+	el, ok := c.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*nzbCacheEntry).data, true
+}
+
+func (c *nzbCache) put(hash string, data []byte) {
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return
+	}
+	if el, ok := c.entries[hash]; ok {
+		entry := el.Value.(*nzbCacheEntry)
+		c.bytes += size - int64(len(entry.data))
+		entry.data = data
+		c.lru.MoveToFront(el)
+	} else {
+		c.entries[hash] = c.lru.PushFront(&nzbCacheEntry{hash: hash, data: data})
+		c.bytes += size
+	}
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*nzbCacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.hash)
+		c.bytes -= int64(len(entry.data))
+	}
+}
+
+// SetCacheNZB enables keeping dl-nzb responses in memory so later plays of the
+// same file skip the dl-nzb POST and its download quota. Disabling drops the cache.
+func (c *Client) SetCacheNZB(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !enabled {
+		c.nzbCache = nil
+	} else if c.nzbCache == nil {
+		c.nzbCache = newNZBCache(maxCachedNZBBytes)
+	}
+}
+
+// cachedNZB returns the cached dl-nzb response for hash, if any.
+func (c *Client) cachedNZB(hash string) ([]byte, bool) {
+	if hash == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nzbCache == nil {
+		return nil, false
+	}
+	return c.nzbCache.get(hash)
+}
+
+// rememberNZB caches a dl-nzb response for hash when caching is enabled.
+func (c *Client) rememberNZB(hash string, data []byte) {
+	if hash == "" || len(data) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nzbCache != nil {
+		c.nzbCache.put(hash, data)
+	}
+}
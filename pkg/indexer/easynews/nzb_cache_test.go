@@ -0,0 +1,116 @@
+package easynews
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+const testNZBBody = `<?xml version="1.0" encoding="UTF-8"?>
+<nzb xmlns="http://www.newzbin.com/DTD/2003/nzb"><file subject="x"><segments><segment bytes="1" number="1">a@b</segment></segments></file></nzb>`
+
+// dlNZBClient returns a client whose dl-nzb POSTs are answered locally and counted in calls.
+func dlNZBClient(t *testing.T, calls *int) *Client {
+	t.Helper()
+	c, err := NewClient("user", "pass", "Easynews", "http://localhost:7000", 0, 10, nil)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	c.client.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if !strings.HasSuffix(r.URL.Path, "/dl-nzb") {
+			return nil, fmt.Errorf("unexpected request %s", r.URL)
+		}
+		*calls++
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(testNZBBody))}, nil
+	})
+	return c
+}
+
+func nzbURL(hash string) string {
+	return "http://localhost:7000/easynews/nzb?payload=" + encodePayload(map[string]interface{}{
+		"hash": hash, "filename": "Show.S01E01", "ext": "mkv", "sig": "sig", "title": "Show S01E01",
+	})
+}
+
+func TestCachedNZBSkipsDLNZB(t *testing.T) {
+	logger.Init("DEBUG")
+	var calls int
+	c := dlNZBClient(t, &calls)
+	c.SetCacheNZB(true)
+
+	for i := 0; i < 3; i++ {
+		data, err := c.DownloadNZB(context.Background(), nzbURL("abc123"))
+		if err != nil {
+			t.Fatalf("DownloadNZB failed: %v", err)
+		}
+		if string(data) != testNZBBody {
+			t.Fatalf("unexpected NZB %q", data)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 dl-nzb call, got %d", calls)
+	}
+	if u := c.GetUsage(); u.DownloadsUsed != 1 {
+		t.Errorf("cached NZBs should not use download quota, used=%d", u.DownloadsUsed)
+	}
+
+	if _, err := c.DownloadNZB(context.Background(), nzbURL("def456")); err != nil {
+		t.Fatalf("DownloadNZB failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("a different file should call dl-nzb, got %d calls", calls)
+	}
+}
+
+func TestCachedNZBDisabledByDefault(t *testing.T) {
+	logger.Init("DEBUG")
+	var calls int
+	c := dlNZBClient(t, &calls)
+	for i := 0; i < 2; i++ {
+		if _, err := c.DownloadNZB(context.Background(), nzbURL("abc123")); err != nil {
+			t.Fatalf("DownloadNZB failed: %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected every play to call dl-nzb without caching, got %d calls", calls)
+	}
+}
+
+func TestNZBCacheLRUEviction(t *testing.T) {
+	c := newNZBCache(10)
+	c.put("a", []byte("aaaa"))
+	c.put("b", []byte("bbbb"))
+	if _, ok := c.get("a"); !ok { // a is now most recently used
+		t.Fatal("a missing")
+	}
+	c.put("c", []byte("cccc")) // over 10 bytes: evicts b, not the whole cache
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.get(k); !ok {
+			t.Errorf("%s evicted", k)
+		}
+	}
+	if c.bytes != 8 || len(c.entries) != 2 || c.lru.Len() != 2 {
+		t.Errorf("bytes=%d entries=%d lru=%d", c.bytes, len(c.entries), c.lru.Len())
+	}
+
+	c.put("a", []byte("aaaaaa")) // replace: size accounted once
+	if c.bytes != 10 {
+		t.Errorf("bytes after replace = %d; want 10", c.bytes)
+	}
+	c.put("huge", make([]byte, 11)) // larger than the cache: not stored, nothing evicted
+	if _, ok := c.get("huge"); ok || len(c.entries) != 2 {
+		t.Errorf("oversized entry handling: entries=%d", len(c.entries))
+	}
+}
@@ -0,0 +1,85 @@
+package easynews
+
+import (
+	"fmt"
+	"strings"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+)
+
+// Query strategies of ID searches (see SetQueryStrategy).
+const (
+	QueryIMDbNumber = "imdb-number" // IMDb number without "tt" (default)
+	QueryTitleYear  = "title-year"  // TMDB title and release year; series get the title only
+	QueryTitleOnly  = "title-only"  // TMDB title
+)
+
+// ValidQueryStrategy reports whether s is a known query strategy ("" is the default).
+func ValidQueryStrategy(s string) bool {
+	switch s {
+	case "", QueryIMDbNumber, QueryTitleYear, QueryTitleOnly:
+		return true
+	}
+	return false
+}
+
+// TitleResolver looks up the title and release year of a movie or show (TMDB).
+// mediaType is "movie" or "series".
+type TitleResolver interface {
+	GetTitleAndYear(mediaType, imdbID, tmdbID string) (string, string, error)
+}
+
+// SetQueryStrategy sets how ID searches are turned into an Easynews query. The IMDb
+// number finds posts that mention it, which few do; the title strategies raise recall
+// and leave precision to result filtering.
+func (c *Client) SetQueryStrategy(strategy string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queryStrategy = strategy
+}
+
+// SetTitleResolver sets the TMDB lookup used by the title-year and title-only strategies.
+func (c *Client) SetTitleResolver(r TitleResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.titles = r
+}
+
+// buildQuery returns the Easynews query of req. Text searches keep their query. ID
+// searches follow the query strategy; when the title cannot be resolved they fall back
+// to the IMDb number.
+func (c *Client) buildQuery(req indexer.SearchRequest) string {
+	c.mu.RLock()
+	strategy, titles := c.queryStrategy, c.titles
+	c.mu.RUnlock()
+
+	if req.Query == "" && (strategy == QueryTitleYear || strategy == QueryTitleOnly) &&
+		(req.IMDbID != "" || req.TMDBID != "") {
+		mediaType := "movie"
+		if req.Cat == indexer.CategorySeries || req.Season != "" {
+			mediaType = "series"
+		}
+		if titles != nil {
+			title, year, err := titles.GetTitleAndYear(mediaType, req.IMDbID, req.TMDBID)
+			if err == nil && title != "" {
+				if strategy == QueryTitleYear && mediaType == "movie" && year != "" {
+					return title + " " + year
+				}
+				return title
+			}
+			logger.Debug("Easynews title lookup failed, searching by IMDb number", "imdb", req.IMDbID, "tmdb", req.TMDBID, "err", err)
+		}
+	}
+
+	query := req.Query
+	if req.IMDbID != "" {
+		// Remove 'tt' prefix if present
+		imdbID := strings.TrimPrefix(req.IMDbID, "tt")
+		query = fmt.Sprintf("%s %s", query, imdbID)
+	}
+	if req.TMDBID != "" {
+		query = fmt.Sprintf("%s %s", query, req.TMDBID)
+	}
+	return query
+}
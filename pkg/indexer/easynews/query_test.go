@@ -0,0 +1,68 @@
+package easynews
+
+import (
+	"errors"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+)
+
+type stubTitles struct {
+	title, year string
+	err         error
+	mediaType   string
+}
+
+func (s *stubTitles) GetTitleAndYear(mediaType, imdbID, tmdbID string) (string, string, error) {
+	s.mediaType = mediaType
+	return s.title, s.year, s.err
+}
+
+func TestBuildQuery(t *testing.T) {
+	logger.Init("DEBUG")
+	movie := indexer.SearchRequest{IMDbID: "tt1160419", Cat: indexer.CategoryMovie}
+	series := indexer.SearchRequest{IMDbID: "tt0903747", Cat: indexer.CategorySeries, Season: "1", Episode: "2"}
+	tests := []struct {
+		name     string
+		strategy string
+		titles   *stubTitles
+		req      indexer.SearchRequest
+		want     string
+	}{
+		{"default", "", &stubTitles{title: "Dune", year: "2021"}, movie, " 1160419"},
+		{"imdb-number", QueryIMDbNumber, &stubTitles{title: "Dune", year: "2021"}, movie, " 1160419"},
+		{"title-year", QueryTitleYear, &stubTitles{title: "Dune", year: "2021"}, movie, "Dune 2021"},
+		{"title-year series", QueryTitleYear, &stubTitles{title: "Breaking Bad", year: "2008"}, series, "Breaking Bad"},
+		{"title-only", QueryTitleOnly, &stubTitles{title: "Dune", year: "2021"}, movie, "Dune"},
+		{"title lookup failed", QueryTitleYear, &stubTitles{err: errors.New("not found")}, movie, " 1160419"},
+		{"text search", QueryTitleOnly, &stubTitles{title: "Dune"}, indexer.SearchRequest{Query: "dune part two"}, "dune part two"},
+	}
+	for _, tt := range tests {
+		c, err := NewClient("user", "pass", "Easynews", "", 0, 0, nil)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		c.SetQueryStrategy(tt.strategy)
+		c.SetTitleResolver(tt.titles)
+		if got := c.buildQuery(tt.req); got != tt.want {
+			t.Errorf("%s: buildQuery = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	titles := &stubTitles{title: "Breaking Bad"}
+	c, _ := NewClient("user", "pass", "Easynews", "", 0, 0, nil)
+	c.SetQueryStrategy(QueryTitleOnly)
+	c.SetTitleResolver(titles)
+	c.buildQuery(series)
+	if titles.mediaType != "series" {
+		t.Errorf("series lookup used media type %q", titles.mediaType)
+	}
+
+	// Without TMDB the title strategies keep the IMDb number
+	c, _ = NewClient("user", "pass", "Easynews", "", 0, 0, nil)
+	c.SetQueryStrategy(QueryTitleYear)
+	if got := c.buildQuery(movie); got != " 1160419" {
+		t.Errorf("no resolver: buildQuery = %q, want %q", got, " 1160419")
+	}
+}
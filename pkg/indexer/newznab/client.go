@@ -16,8 +16,16 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/net/http/httpguts"
 )
 
+// defaultTimeout applies to searches and NZB downloads when the indexer sets no timeout_seconds.
+const defaultTimeout = 30 * time.Second
+
+// maxSearchPages caps the requests one search makes when the indexer pages its results.
+const maxSearchPages = 10
+
 // Client represents a Newznab API client for a single indexer
 type Client struct {
 	baseURL string
@@ -26,6 +34,10 @@ type Client struct {
 	name    string
 	client  *http.Client
 
+	categories map[string]string // content type ("movie"/"series") -> cat param override
+	userAgent  string            // overrides the env User-Agent
+	headers    map[string]string // extra headers on every request
+
 	// Usage tracking
 	apiLimit          int
 	apiUsed           int
@@ -98,13 +110,19 @@ func NewClient(cfg config.IndexerConfig, um *indexer.UsageManager) *Client {
 		apiPath = "/" + apiPath
 	}
 
+	// Per-indexer timeout for searches and NZB downloads
+	timeout := defaultTimeout
+	if cfg.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+	}
+
 	c := &Client{
 		name:    cfg.Name,
 		baseURL: strings.TrimRight(cfg.URL, "/"),
 		apiPath: apiPath,
 		apiKey:  cfg.APIKey,
 		client: &http.Client{
-			Timeout:   30 * time.Second,
+			Timeout:   timeout,
 			Transport: transport,
 		},
 		apiLimit:          cfg.APIHitsDay,
@@ -114,6 +132,9 @@ func NewClient(cfg config.IndexerConfig, um *indexer.UsageManager) *Client {
 		downloadUsed:      0,
 		downloadRemaining: cfg.DownloadsDay,
 		usageManager:      um,
+		categories:        cfg.Categories,
+		userAgent:         cfg.UserAgent,
+		headers:           cfg.Headers,
 	}
 
 	// Load initial usage if manager is provided
@@ -137,6 +158,24 @@ func NewClient(cfg config.IndexerConfig, um *indexer.UsageManager) *Client {
 	return c
 }
 
+// categoryParam returns the cat parameter for a logical category, applying the
+// indexer's configured override for movies/series when present.
+func (c *Client) categoryParam(cat string) string {
+	var key string
+	switch cat {
+	case indexer.CategoryMovie:
+		key = "movie"
+	case indexer.CategorySeries:
+		key = "series"
+	default:
+		return cat
+	}
+	if override := strings.TrimSpace(c.categories[key]); override != "" {
+		return override
+	}
+	return cat
+}
+
 // checkAPILimit returns error if API limit is reached
 func (c *Client) checkAPILimit() error {
 	c.mu.RLock()
@@ -216,10 +255,40 @@ func (c *Client) updateUsageFromHeaders(h http.Header) {
 	}
 }
 
+// ValidateHeaders checks custom indexer header names and values.
+func ValidateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value for header %q", name)
+		}
+	}
+	return nil
+}
+
+// setHeaders applies the configured User-Agent (else envUA) and custom headers to req.
+func (c *Client) setHeaders(req *http.Request, envUA string) {
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	} else if envUA != "" {
+		req.Header.Set("User-Agent", envUA)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+}
+
 // Ping checks if the indexer is reachable
 func (c *Client) Ping() error {
 	apiURL := fmt.Sprintf("%s%s?t=caps&apikey=%s", c.baseURL, c.apiPath, c.apiKey)
-	resp, err := c.client.Get(apiURL)
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req, env.IndexerQueryHeader())
+	resp, err := c.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -272,12 +341,11 @@ func (c *Client) Search(req indexer.SearchRequest) (*indexer.SearchResponse, err
 	params.Set("apikey", c.apiKey)
 	params.Set("o", "xml")
 	params.Set("limit", fmt.Sprintf("%d", limit))
-	params.Set("offset", "0") // Start from beginning
 
 	// Map categories to Newznab search types
-	if req.Cat == "2000" {
+	if req.Cat == indexer.CategoryMovie {
 		params.Set("t", "movie")
-	} else if req.Cat == "5000" {
+	} else if req.Cat == indexer.CategorySeries {
 		params.Set("t", "tvsearch")
 	} else {
 		params.Set("t", "search")
@@ -296,8 +364,8 @@ func (c *Client) Search(req indexer.SearchRequest) (*indexer.SearchResponse, err
 	if req.TVDBID != "" {
 		params.Set("tvdbid", req.TVDBID)
 	}
-	if req.Cat != "" {
-		params.Set("cat", req.Cat)
+	if cat := c.categoryParam(req.Cat); cat != "" {
+		params.Set("cat", cat)
 	}
 	if req.Season != "" {
 		params.Set("season", req.Season)
@@ -306,16 +374,54 @@ func (c *Client) Search(req indexer.SearchRequest) (*indexer.SearchResponse, err
 		params.Set("ep", req.Episode)
 	}
 
+	result, err := c.searchPage(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Indexers that cap a page below the requested limit (e.g. 100) report the full result
+	// count in newznab:response; fetch the following pages until limit or total is reached.
+	// Each page is an API hit. Without a reported total we can't tell, so stay on one page.
+	for page := 1; page < maxSearchPages; page++ {
+		offset := len(result.Channel.Items)
+		total := result.Channel.Response.Total
+		if offset >= limit || offset >= total {
+			break
+		}
+		if err := c.checkAPILimit(); err != nil {
+			logger.Debug("Newznab paging stopped", "indexer", c.Name(), "offset", offset, "total", total, "err", err)
+			break
+		}
+		next, err := c.searchPage(params, offset)
+		if err != nil {
+			logger.Warn("Newznab page request failed, keeping earlier pages", "indexer", c.Name(), "offset", offset, "err", err)
+			break
+		}
+		if len(next.Channel.Items) == 0 {
+			break
+		}
+		result.Channel.Items = append(result.Channel.Items, next.Channel.Items...)
+	}
+
+	// Truncate to requested limit if indexer returned more
+	if len(result.Channel.Items) > limit {
+		result.Channel.Items = result.Channel.Items[:limit]
+	}
+
+	return result, nil
+}
+
+// searchPage runs one search request at the given result offset.
+func (c *Client) searchPage(params url.Values, offset int) (*indexer.SearchResponse, error) {
+	params.Set("offset", strconv.Itoa(offset))
 	apiURL := fmt.Sprintf("%s%s?%s", c.baseURL, c.apiPath, params.Encode())
-	logger.Debug("Newznab search request", "indexer", c.Name(), "url", apiURL, "limit", limit)
+	logger.Debug("Newznab search request", "indexer", c.Name(), "url", apiURL, "limit", params.Get("limit"), "offset", offset)
 
 	httpReq, err := http.NewRequestWithContext(context.Background(), "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	if ua := env.IndexerQueryHeader(); ua != "" {
-		httpReq.Header.Set("User-Agent", ua)
-	}
+	c.setHeaders(httpReq, env.IndexerQueryHeader())
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query %s: %w", c.Name(), err)
@@ -371,11 +477,6 @@ func (c *Client) Search(req indexer.SearchRequest) (*indexer.SearchResponse, err
 		}
 	}
 
-	// Truncate to requested limit if indexer returned more
-	if len(result.Channel.Items) > limit {
-		result.Channel.Items = result.Channel.Items[:limit]
-	}
-
 	return &result, nil
 }
 
@@ -392,9 +493,7 @@ func (c *Client) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	if ua := env.IndexerGrabHeader(); ua != "" {
-		req.Header.Set("User-Agent", ua)
-	}
+	c.setHeaders(req, env.IndexerGrabHeader())
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download NZB from %s: %w", c.Name(), err)
@@ -416,13 +515,17 @@ func (c *Client) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error)
 	c.updateUsageFromHeaders(resp.Header)
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("%s NZB download returned status %d", c.Name(), resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, indexer.CheckNZBResponse(c.Name(), resp.StatusCode, body)
 	}
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read NZB data from %s: %w", c.Name(), err)
 	}
+	if err := indexer.CheckNZBResponse(c.Name(), resp.StatusCode, data); err != nil {
+		return nil, err
+	}
 
 	return data, nil
 }
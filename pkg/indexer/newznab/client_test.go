@@ -1,13 +1,19 @@
 package newznab
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"streamnzb/pkg/core/config"
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/release"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewznabSearch(t *testing.T) {
@@ -131,6 +137,61 @@ func TestNewznabPagination(t *testing.T) {
 	}
 }
 
+func TestNewznabOffsetPaging(t *testing.T) {
+	logger.Init("DEBUG")
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		start, _ := strconv.Atoi(offset)
+
+		// Indexer caps pages at 2 items out of 5
+		var items strings.Builder
+		for i := start; i < min(start+2, 5); i++ {
+			fmt.Fprintf(&items, `<item><title>Item %d</title><newznab:attr name="size" value="100"/></item>`, i+1)
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel>
+<newznab:response offset="%d" total="5"/>
+%s
+</channel>
+</rss>`, start, items.String())
+	}))
+	defer server.Close()
+
+	client := NewClient(config.IndexerConfig{
+		Name:   "MockIndexer",
+		URL:    server.URL,
+		APIKey: "test-api-key",
+	}, nil)
+
+	resp, err := client.Search(indexer.SearchRequest{Limit: 100})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Channel.Items) != 5 || resp.Channel.Items[4].Title != "Item 5" {
+		t.Fatalf("Expected all 5 items across pages, got %d", len(resp.Channel.Items))
+	}
+	if got := strings.Join(offsets, ","); got != "0,2,4" {
+		t.Errorf("Expected offsets 0,2,4, got %s", got)
+	}
+	if used := client.GetUsage().APIHitsUsed; used != 3 {
+		t.Errorf("Expected 3 API hits for 3 pages, got %d", used)
+	}
+
+	// The requested limit stops paging early
+	offsets = nil
+	resp, err = client.Search(indexer.SearchRequest{Limit: 3})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(resp.Channel.Items) != 3 || len(offsets) != 2 {
+		t.Errorf("Expected 3 items from 2 pages, got %d items from %d pages", len(resp.Channel.Items), len(offsets))
+	}
+}
+
 func TestNewznabPing(t *testing.T) {
 	logger.Init("DEBUG")
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -152,3 +213,180 @@ func TestNewznabPing(t *testing.T) {
 		t.Errorf("Ping failed: %v", err)
 	}
 }
+
+func TestNewznabTimeout(t *testing.T) {
+	logger.Init("DEBUG")
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock // Slow indexer
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client := NewClient(config.IndexerConfig{
+		Name:           "SlowIndexer",
+		URL:            server.URL,
+		APIKey:         "test-api-key",
+		TimeoutSeconds: 1,
+	}, nil)
+	start := time.Now()
+	if _, err := client.Search(indexer.SearchRequest{Query: "test"}); err == nil {
+		t.Fatal("expected the search to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("search took %v, want about 1s", elapsed)
+	}
+	if _, err := client.DownloadNZB(context.Background(), server.URL+"/getnzb"); err == nil {
+		t.Fatal("expected the download to time out")
+	}
+}
+
+func TestNewznabCustomHeaders(t *testing.T) {
+	logger.Init("DEBUG")
+	var gotUA, gotCookie string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA, gotCookie = r.Header.Get("User-Agent"), r.Header.Get("Cookie")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(config.IndexerConfig{
+		Name:      "MockIndexer",
+		URL:       server.URL,
+		APIKey:    "test-api-key",
+		UserAgent: "CustomUA/1.0",
+		Headers:   map[string]string{"Cookie": "cf_clearance=abc"},
+	}, nil)
+	if err := client.Ping(); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if gotUA != "CustomUA/1.0" || gotCookie != "cf_clearance=abc" {
+		t.Errorf("got User-Agent %q, Cookie %q", gotUA, gotCookie)
+	}
+
+	if err := ValidateHeaders(map[string]string{"X-Api-Token": "ok"}); err != nil {
+		t.Errorf("valid header rejected: %v", err)
+	}
+	if err := ValidateHeaders(map[string]string{"Bad Header": "x"}); err == nil {
+		t.Error("expected invalid header name to be rejected")
+	}
+	if err := ValidateHeaders(map[string]string{"X-Test": "a\r\nInjected: 1"}); err == nil {
+		t.Error("expected header value with CRLF to be rejected")
+	}
+}
+
+func TestNewznabCategoryOverride(t *testing.T) {
+	logger.Init("DEBUG")
+	var gotT, gotCat string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotT = r.URL.Query().Get("t")
+		gotCat = r.URL.Query().Get("cat")
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel></channel></rss>`)
+	}))
+	defer server.Close()
+
+	client := NewClient(config.IndexerConfig{
+		Name:       "MockIndexer",
+		URL:        server.URL,
+		APIKey:     "test-api-key",
+		Categories: map[string]string{"series": "5000,5070"},
+	}, nil)
+
+	if _, err := client.Search(indexer.SearchRequest{Cat: indexer.CategorySeries, TVDBID: "123"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if gotT != "tvsearch" || gotCat != "5000,5070" {
+		t.Errorf("series search: got t=%q cat=%q, want t=tvsearch cat=5000,5070", gotT, gotCat)
+	}
+
+	// No movie override configured: falls back to the standard category
+	if _, err := client.Search(indexer.SearchRequest{Cat: indexer.CategoryMovie, IMDbID: "tt1234567"}); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if gotT != "movie" || gotCat != "2000" {
+		t.Errorf("movie search: got t=%q cat=%q, want t=movie cat=2000", gotT, gotCat)
+	}
+}
+
+func TestNewznabGetNFO(t *testing.T) {
+	logger.Init("DEBUG")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("t") != "getnfo" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch q.Get("id") {
+		case "abc123":
+			fmt.Fprint(w, "Source: BluRay\nEncoder notes: none")
+		case "rss1":
+			fmt.Fprint(w, `<?xml version="1.0"?><rss><channel><item><description>RSS NFO</description></item></channel></rss>`)
+		default:
+			fmt.Fprint(w, `<?xml version="1.0"?><error code="300" description="No such item"/>`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(config.IndexerConfig{Name: "MockIndexer", URL: server.URL, APIKey: "key"}, nil)
+
+	// ID from the t=get link
+	nfo, err := client.GetNFO(context.Background(), &release.Release{Link: server.URL + "/api?t=get&id=abc123"})
+	if err != nil || nfo != "Source: BluRay\nEncoder notes: none" {
+		t.Fatalf("GetNFO raw: got %q, err %v", nfo, err)
+	}
+	// ID from a details-URL GUID, RSS response
+	nfo, err = client.GetNFO(context.Background(), &release.Release{GUID: "https://indexer.example/details/rss1"})
+	if err != nil || nfo != "RSS NFO" {
+		t.Fatalf("GetNFO rss: got %q, err %v", nfo, err)
+	}
+	if _, err := client.GetNFO(context.Background(), &release.Release{GUID: "missing"}); err == nil {
+		t.Error("expected error for missing NFO")
+	}
+	if u := client.GetUsage(); u.APIHitsUsed != 3 {
+		t.Errorf("expected 3 API hits, got %d", u.APIHitsUsed)
+	}
+}
+
+func TestNewznabDownloadNotNZB(t *testing.T) {
+	logger.Init("DEBUG")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/html":
+			fmt.Fprint(w, "<!DOCTYPE html>\n<html><head><title>Just a moment...</title></head><body>captcha</body></html>")
+		case "/error":
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><error code="429" description="Request limit reached"/>`)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, "<html>Forbidden</html>")
+		default:
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE nzb><nzb xmlns="http://www.newzbin.com/DTD/2003/nzb"></nzb>`)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(config.IndexerConfig{Name: "MockIndexer", URL: server.URL, APIKey: "test-api-key"}, nil)
+
+	if _, err := client.DownloadNZB(context.Background(), server.URL+"/getnzb"); err != nil {
+		t.Fatalf("valid NZB rejected: %v", err)
+	}
+	tests := []struct {
+		path, snippet string
+		status        int
+	}{
+		{"/html", "<title>Just a moment...</title>", http.StatusOK},
+		{"/error", "Request limit reached", http.StatusOK},
+		{"/forbidden", "Forbidden", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		_, err := client.DownloadNZB(context.Background(), server.URL+tt.path)
+		var notNZB *indexer.NotNZBError
+		if !errors.Is(err, indexer.ErrNotNZB) || !errors.As(err, &notNZB) {
+			t.Errorf("%s: got %v, want ErrNotNZB", tt.path, err)
+			continue
+		}
+		if notNZB.StatusCode != tt.status || !strings.Contains(notNZB.Snippet, tt.snippet) {
+			t.Errorf("%s: status %d snippet %q", tt.path, notNZB.StatusCode, notNZB.Snippet)
+		}
+	}
+}
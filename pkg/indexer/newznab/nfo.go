@@ -0,0 +1,117 @@
+package newznab
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"streamnzb/pkg/core/env"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/release"
+)
+
+// Ensure Client supports NFO lookups at compile time.
+var _ indexer.IndexerWithNFO = (*Client)(nil)
+
+// maxNFOSize caps NFO responses; real NFOs are a few KB.
+const maxNFOSize = 256 * 1024
+
+// nfoResponse is the RSS form of t=getnfo for indexers that ignore raw=1.
+type nfoResponse struct {
+	Channel struct {
+		Items []struct {
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// GetNFO fetches the release NFO via t=getnfo. Costs one API hit.
+func (c *Client) GetNFO(ctx context.Context, rel *release.Release) (string, error) {
+	id := releaseID(rel)
+	if id == "" {
+		return "", errors.New("release has no indexer ID")
+	}
+	if err := c.checkAPILimit(); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("t", "getnfo")
+	params.Set("id", id)
+	params.Set("raw", "1")
+	params.Set("apikey", c.apiKey)
+	apiURL := fmt.Sprintf("%s%s?%s", c.baseURL, c.apiPath, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req, env.IndexerQueryHeader())
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch NFO from %s: %w", c.Name(), err)
+	}
+	defer resp.Body.Close()
+
+	c.mu.Lock()
+	c.apiUsed++
+	if c.apiRemaining > 0 {
+		c.apiRemaining--
+	}
+	c.mu.Unlock()
+	c.updateUsageFromHeaders(resp.Header)
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxNFOSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s NFO: %w", c.Name(), err)
+	}
+	if err := c.checkNewznabError(body); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s NFO request returned status %d", c.Name(), resp.StatusCode)
+	}
+
+	text := string(body)
+	if trimmed := strings.TrimSpace(text); strings.HasPrefix(trimmed, "<?xml") || strings.HasPrefix(trimmed, "<rss") {
+		var rss nfoResponse
+		if err := xml.Unmarshal(body, &rss); err != nil || len(rss.Channel.Items) == 0 {
+			return "", fmt.Errorf("%s returned no NFO", c.Name())
+		}
+		text = rss.Channel.Items[0].Description
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("%s returned no NFO", c.Name())
+	}
+	return text, nil
+}
+
+// releaseID returns the newznab release ID: the "id"/"guid" of the t=get link, else the
+// last path element of the GUID (indexers often use the details URL as GUID).
+func releaseID(rel *release.Release) string {
+	if rel == nil {
+		return ""
+	}
+	if u, err := url.Parse(rel.Link); err == nil {
+		q := u.Query()
+		if id := q.Get("id"); id != "" {
+			return id
+		}
+		if id := q.Get("guid"); id != "" {
+			return id
+		}
+	}
+	guid := strings.TrimSpace(rel.GUID)
+	if strings.Contains(guid, "://") {
+		if u, err := url.Parse(guid); err == nil {
+			return path.Base(strings.TrimSuffix(u.Path, "/"))
+		}
+	}
+	return guid
+}
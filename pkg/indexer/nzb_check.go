@@ -0,0 +1,68 @@
+package indexer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNotNZB is matched (errors.Is) by download errors where the indexer answered
+// with something other than an NZB: an HTML login/captcha page, a newznab XML
+// error, a rate-limit notice, etc.
+var ErrNotNZB = errors.New("indexer response is not an NZB")
+
+// NotNZBError describes a non-NZB download response.
+type NotNZBError struct {
+	Indexer    string
+	StatusCode int
+	Snippet    string // start of the body (or the newznab error description)
+}
+
+func (e *NotNZBError) Error() string {
+	return fmt.Sprintf("%s returned a non-NZB response (status %d): %s", e.Indexer, e.StatusCode, e.Snippet)
+}
+
+func (e *NotNZBError) Is(target error) bool {
+	return target == ErrNotNZB
+}
+
+const notNZBSnippetLen = 200
+
+// sniffLen bounds how far into the body we look for the <nzb root element;
+// it follows the XML declaration, DOCTYPE and possibly a comment.
+const sniffLen = 4096
+
+var newznabErrorRe = regexp.MustCompile(`(?i)<error[^>]*description="([^"]*)"`)
+
+// CheckNZBResponse returns a *NotNZBError unless status is 200 and data looks
+// like an NZB document.
+func CheckNZBResponse(indexerName string, status int, data []byte) error {
+	if status == 200 && looksLikeNZB(data) {
+		return nil
+	}
+	return &NotNZBError{Indexer: indexerName, StatusCode: status, Snippet: responseSnippet(data)}
+}
+
+func looksLikeNZB(data []byte) bool {
+	head := data
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	return bytes.Contains(bytes.ToLower(head), []byte("<nzb"))
+}
+
+func responseSnippet(data []byte) string {
+	if m := newznabErrorRe.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	s := strings.Join(strings.Fields(string(data)), " ")
+	if len(s) > notNZBSnippetLen {
+		s = s[:notNZBSnippetLen] + "..."
+	}
+	if s == "" {
+		return "(empty body)"
+	}
+	return s
+}
@@ -26,6 +26,12 @@ type IndexerWithResolve interface {
 	ResolveDownloadURL(ctx context.Context, directURL, title string, size int64, cat string) (resolvedURL string, err error)
 }
 
+// IndexerWithNFO is an optional interface for indexers that can return a release NFO
+// (newznab t=getnfo). Indexers without NFO support simply don't implement it.
+type IndexerWithNFO interface {
+	GetNFO(ctx context.Context, rel *release.Release) (string, error)
+}
+
 // Usage represents the current API and download usage for an indexer
 type Usage struct {
 	APIHitsLimit         int
@@ -38,6 +44,13 @@ type Usage struct {
 	AllTimeDownloadsUsed int
 }
 
+// Standard Newznab parent categories. SearchRequest.Cat carries one of these as the
+// logical search type; indexers may map it to their own category list.
+const (
+	CategoryMovie  = "2000"
+	CategorySeries = "5000"
+)
+
 // SearchRequest represents a search query
 type SearchRequest struct {
 	Query   string // Search query
@@ -48,6 +61,9 @@ type SearchRequest struct {
 	Limit   int    // Max results
 	Season  string // Season number for TV searches
 	Episode string // Episode number for TV searches
+	// AbsoluteEpisode is the anime absolute episode number for Season/Episode (0 = unknown).
+	// Not sent to indexers; used for an extra text search and title matching.
+	AbsoluteEpisode int
 }
 
 // SearchResponse represents the Newznab XML response. After aggregation, items are normalized
@@ -57,6 +73,8 @@ type SearchResponse struct {
 	XMLName  xml.Name           `xml:"rss"`
 	Channel  Channel            `xml:"channel"`
 	Releases []*release.Release `xml:"-"` // Populated by NormalizeSearchResponse
+	// Partial is set by the Aggregator when some indexers failed; such responses are not cached
+	Partial bool `xml:"-"`
 }
 
 // NewznabResponse contains metadata about the results
@@ -126,10 +144,10 @@ func (i *Item) ToRelease() *release.Release {
 	if i == nil {
 		return nil
 	}
-	grabs := 0
+	grabs, hasGrabs := 0, false
 	if s := i.GetAttribute("grabs"); s != "" {
 		if n, err := strconv.Atoi(s); err == nil {
-			grabs = n
+			grabs, hasGrabs = n, true
 		}
 	}
 	return &release.Release{
@@ -143,6 +161,7 @@ func (i *Item) ToRelease() *release.Release {
 		GUID:          i.GUID,
 		QuerySource:   i.QuerySource,
 		Grabs:         grabs,
+		HasGrabs:      hasGrabs,
 	}
 }
 
@@ -14,16 +14,19 @@ import (
 	"streamnzb/pkg/indexer/newznab"
 	"streamnzb/pkg/usenet/nntp"
 	"strings"
+	"time"
 )
 
 // InitializedComponents holds all the components initialized during bootstrap
 type InitializedComponents struct {
 	Config               *config.Config
 	Indexer              indexer.Indexer
-	ProviderPools        map[string]*nntp.ClientPool
-	ProviderOrder        []string // Provider names in priority order (for single-provider validation)
-	StreamingPools       []*nntp.ClientPool
-	AvailNZBIndexerHosts []string // Underlying indexer hostnames for AvailNZB GetReleases filter (e.g. nzbgeek.info)
+	ProviderPools        map[string]*nntp.ClientPool // All providers (stats, usage)
+	ValidationPools      map[string]*nntp.ClientPool // Providers with the all/validate role
+	ProviderOrder        []string                    // ValidationPools names in priority order (for single-provider validation)
+	ProviderWeights      map[string]int              // Provider.Weight by ValidationPools name (playback preference)
+	StreamingPools       []*nntp.ClientPool          // Providers with the all/download role, for playback and the proxy
+	AvailNZBIndexerHosts []string                    // Underlying indexer hostnames for AvailNZB GetReleases filter (e.g. nzbgeek.info)
 }
 
 // WaitForInputAndExit prints an error and waits for user input before exiting
@@ -94,7 +97,7 @@ func BuildComponents(cfg *config.Config) (*InitializedComponents, error) {
 		switch indexerType {
 		case "easynews":
 			// Determine download base URL (for proxying NZB downloads)
-			downloadBase := cfg.AddonBaseURL
+			downloadBase := cfg.BaseURL()
 			if downloadBase == "" {
 				downloadBase = "http://127.0.0.1:7000"
 			}
@@ -107,6 +110,9 @@ func BuildComponents(cfg *config.Config) (*InitializedComponents, error) {
 			if err != nil {
 				logger.Error("Failed to initialize Easynews from indexer list", "name", idxCfg.Name, "err", err)
 			} else {
+				easynewsClient.SetCacheNZB(idxCfg.CacheNZB)
+				easynewsClient.SetQueryStrategy(idxCfg.QueryStrategy)
+				easynewsClient.SetTimeout(time.Duration(idxCfg.TimeoutSeconds) * time.Second)
 				indexers = append(indexers, easynewsClient)
 				logger.Info("Initialized Easynews indexer", "name", idxCfg.Name)
 			}
@@ -130,9 +136,11 @@ func BuildComponents(cfg *config.Config) (*InitializedComponents, error) {
 	}
 
 	aggregator := indexer.NewAggregator(indexers...)
+	aggregator.MaxResultsPerIndexer = cfg.MaxRawResultsPerIndexer
 
 	// 3. Initialize NNTP provider pools
 	providerPools := make(map[string]*nntp.ClientPool)
+	validationPools := make(map[string]*nntp.ClientPool)
 	var streamingPools []*nntp.ClientPool
 
 	// Initialize provider usage manager (may be nil if stateMgr failed)
@@ -168,6 +176,8 @@ func BuildComponents(cfg *config.Config) (*InitializedComponents, error) {
 	})
 
 	providerOrder := make([]string, 0, len(providers))
+	providerWeights := make(map[string]int)
+	warmFailed := 0
 	for _, provider := range providers {
 		logger.Info("Initializing NNTP pool", "provider", provider.Name, "host", provider.Host, "conns", provider.Connections)
 
@@ -179,12 +189,30 @@ func BuildComponents(cfg *config.Config) (*InitializedComponents, error) {
 			provider.Password,
 			provider.Connections,
 		)
+		pool.SetCompression(provider.Compression)
+		pool.SetPlaybackReserve(provider.PlaybackReservedConnections)
+		pool.SetIdleTimeout(time.Duration(cfg.NNTPIdleTimeoutSeconds) * time.Second)
+		pool.SetKeepalive(time.Duration(cfg.NNTPKeepaliveSeconds) * time.Second)
 
-		// Validate credentials/connectivity (502 auth check)
+		// Validate credentials/connectivity (502 auth check). The connection it opens
+		// stays idle; with WarmConnectionsOnStart it is kept ready for the first request.
+		start := time.Now()
 		if err := pool.Validate(); err != nil {
 			logger.Error("Failed to initialize provider", "name", provider.Name, "host", provider.Host, "err", err)
+			warmFailed++
 			continue
 		}
+		if cfg.ProviderTestGroup != "" {
+			if articles, err := pool.ProbeGroup(cfg.ProviderTestGroup); err != nil {
+				logger.Warn("Provider does not serve the test group; check that the account includes binary groups", "name", provider.Name, "host", provider.Host, "group", cfg.ProviderTestGroup, "err", err)
+			} else {
+				logger.Debug("Provider test group", "name", provider.Name, "group", cfg.ProviderTestGroup, "articles", articles)
+			}
+		}
+		if cfg.WarmConnectionsOnStart {
+			pool.SetKeepWarm(1)
+			logger.Info("Provider connection warmed", "provider", provider.Name, "host", provider.Host, "latency", time.Since(start).Round(time.Millisecond))
+		}
 
 		// Use Host as fallback if Name is empty (common for UI-added providers)
 		poolName := provider.Name
@@ -201,19 +229,32 @@ func BuildComponents(cfg *config.Config) (*InitializedComponents, error) {
 		}
 
 		providerPools[poolName] = pool
-		providerOrder = append(providerOrder, poolName)
-		streamingPools = append(streamingPools, pool)
+		if provider.UsedForValidation() {
+			validationPools[poolName] = pool
+			providerOrder = append(providerOrder, poolName)
+			if provider.Weight != 0 {
+				providerWeights[poolName] = provider.Weight
+			}
+		}
+		if provider.UsedForDownload() {
+			streamingPools = append(streamingPools, pool)
+		}
 	}
 
 	if len(providerPools) == 0 {
 		logger.Warn("!! No valid NNTP providers initialized. Check your credentials in the web UI !!")
 	}
+	if cfg.WarmConnectionsOnStart && len(providers) > 0 {
+		logger.Info("Provider warmup complete", "warm", len(providerPools), "failed", warmFailed)
+	}
 
 	return &InitializedComponents{
 		Config:               cfg,
 		Indexer:              aggregator,
 		ProviderPools:        providerPools,
+		ValidationPools:      validationPools,
 		ProviderOrder:        providerOrder,
+		ProviderWeights:      providerWeights,
 		StreamingPools:       streamingPools,
 		AvailNZBIndexerHosts: availNzbHosts,
 	}, nil
@@ -67,11 +67,21 @@ type Frame struct {
 	FileName string
 }
 
+// ErrCRCMismatch is matched (errors.Is) when the decoded data does not match the
+// crc32/pcrc32 value of the =yend trailer. Unlike a missing article, this means the
+// provider serves corrupt data.
+var ErrCRCMismatch = rapidyenc.ErrCrcMismatch
+
 // DecodeToBytes decodes the reader into a byte slice.
+// When the trailer carries a CRC32 that does not match, the error matches ErrCRCMismatch
+// and the (fully decoded) frame is returned as well, so callers can decide to accept it.
 func DecodeToBytes(r io.Reader) (*Frame, error) {
 	dec := rapidyenc.NewDecoder(normalizeCRLF(r))
 	buf := new(bytes.Buffer)
 	_, err := io.Copy(buf, dec)
+	if errors.Is(err, ErrCRCMismatch) {
+		return &Frame{Data: buf.Bytes(), FileName: dec.Meta.FileName}, err
+	}
 	if err != nil && !errors.Is(err, io.EOF) {
 		return nil, err
 	}
@@ -0,0 +1,36 @@
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+// yencArticle builds a single-part yEnc article body with the given pcrc32 trailer.
+func yencArticle(data string, crc uint32) string {
+	enc := make([]byte, len(data))
+	for i := range data {
+		enc[i] = data[i] + 42 // no critical characters in the test data
+	}
+	return fmt.Sprintf("=ybegin part=1 line=128 size=%d name=a.bin\r\n=ypart begin=1 end=%d\r\n%s\r\n=yend size=%d part=1 pcrc32=%08x\r\n.\r\n",
+		len(data), len(data), enc, len(data), crc)
+}
+
+func TestDecodeToBytesCRC(t *testing.T) {
+	data := "hello world"
+
+	frame, err := DecodeToBytes(strings.NewReader(yencArticle(data, crc32.ChecksumIEEE([]byte(data)))))
+	if err != nil || string(frame.Data) != data || frame.FileName != "a.bin" {
+		t.Fatalf("valid article: frame %+v, err %v", frame, err)
+	}
+
+	frame, err = DecodeToBytes(strings.NewReader(yencArticle(data, 0xdeadbeef)))
+	if !errors.Is(err, ErrCRCMismatch) {
+		t.Fatalf("corrupt article: err = %v, want ErrCRCMismatch", err)
+	}
+	if frame == nil || string(frame.Data) != data {
+		t.Errorf("decoded data not returned with the CRC error: %+v", frame)
+	}
+}
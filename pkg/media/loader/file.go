@@ -15,6 +15,9 @@ import (
 	"streamnzb/pkg/usenet/nntp"
 )
 
+// log tags this package's messages; set "loader" in log_levels to change its level.
+var log = logger.For("loader")
+
 // MaxZeroFills is the maximum number of segments we zero-fill before returning
 // an error. Beyond this, playback would be too corrupted to be useful.
 const MaxZeroFills = 10
@@ -23,12 +26,91 @@ const MaxZeroFills = 10
 // more than MaxZeroFills times. Callers can use errors.Is to detect and redirect.
 var ErrTooManyZeroFills = errors.New("too many failed segments")
 
+// MaxSlowFills is the maximum number of segments zero-filled because every provider
+// timed out. They are retried on a later read, so the bound is looser than MaxZeroFills,
+// but a file whose providers keep timing out fails with ErrTooManyZeroFills as well.
+const MaxSlowFills = 3 * MaxZeroFills
+
 // IsFailed returns true when this file has accumulated too many segment
 // download failures and further read attempts would immediately error.
 func (f *File) IsFailed() bool {
 	f.zeroFillMu.Lock()
 	defer f.zeroFillMu.Unlock()
-	return f.zeroFillCount >= MaxZeroFills
+	return f.zeroFillCount >= MaxZeroFills || f.slowFillCount >= MaxSlowFills
+}
+
+// ZeroFills returns how many segments were zero-filled so far: missing on every provider,
+// and timed out on every provider. Below the limits the file is not failed, but the data
+// read has gaps.
+func (f *File) ZeroFills() (missing, slow int) {
+	f.zeroFillMu.Lock()
+	defer f.zeroFillMu.Unlock()
+	return f.zeroFillCount, f.slowFillCount
+}
+
+// ProviderCount returns the number of provider pools this file downloads from.
+func (f *File) ProviderCount() int { return len(f.pools) }
+
+// ProviderHost returns the host of the provider pool at index, for logging.
+func (f *File) ProviderHost(index int) string {
+	if index < 0 || index >= len(f.pools) {
+		return ""
+	}
+	return f.pools[index].Host()
+}
+
+// WithProvider returns a scan-only copy of f that downloads exclusively from the
+// provider pool at index. The copy has its own zero-filled segments and failure
+// count, so a header scan can be retried against one provider without touching f
+// (successfully downloaded segments land in the shared cache either way).
+func (f *File) WithProvider(index int) (*File, bool) {
+	if index < 0 || index >= len(f.pools) {
+		return nil, false
+	}
+	alt := NewFile(f.ctx, f.nzbFile, []*nntp.ClientPool{f.pools[index]}, f.estimator)
+	alt.SetSegmentTimeout(f.SegmentTimeout())
+	return alt, true
+}
+
+// AdoptFrom takes over the local segments and segment map of alt (a
+// WithProvider copy of f) and clears f's failure count. Used after a retry scan
+// succeeded so f can stream again across all providers.
+func (f *File) AdoptFrom(alt *File) {
+	alt.segCacheMu.RLock()
+	cache := make(map[int][]byte, len(alt.segCache))
+	for idx, data := range alt.segCache {
+		cache[idx] = data
+	}
+	held := make(map[int]struct{}, len(alt.held))
+	for idx := range alt.held {
+		held[idx] = struct{}{}
+	}
+	alt.segCacheMu.RUnlock()
+
+	f.segCacheMu.Lock()
+	f.segCache = cache
+	for idx := range held {
+		f.held[idx] = struct{}{}
+	}
+	f.segCacheMu.Unlock()
+
+	alt.mu.Lock()
+	detected := alt.detected
+	var segSize int64
+	if detected && len(alt.segments) > 0 {
+		segSize = alt.segments[0].EndOffset - alt.segments[0].StartOffset
+	}
+	alt.mu.Unlock()
+	if detected && segSize > 0 {
+		f.mu.Lock()
+		f.applySegmentSize(segSize)
+		f.mu.Unlock()
+	}
+
+	f.zeroFillMu.Lock()
+	f.zeroFillCount = 0
+	f.slowFillCount = 0
+	f.zeroFillMu.Unlock()
 }
 
 type Segment struct {
@@ -47,11 +129,20 @@ type File struct {
 	ctx       context.Context
 	mu        sync.Mutex
 
+	// segCache holds segments that must not be shared across files: zero-fills
+	// (specific to this file's providers) and segments without a Message-ID.
+	// held tracks indices this file put in the shared cache, for eviction.
 	segCache   map[int][]byte
+	held       map[int]struct{}
 	segCacheMu sync.RWMutex
 
 	zeroFillMu    sync.Mutex
 	zeroFillCount int
+	slowFillCount int // timeout-only zero-fills, bounded by MaxSlowFills
+
+	readAhead      int           // segments SegmentReader prefetches; 0 = one per connection
+	segmentTimeout time.Duration // per-provider deadline for one segment; 0 = nntp default
+	pauseRelease   time.Duration // readers idle this long stop prefetching; 0 = never
 }
 
 func NewFile(ctx context.Context, f *nzb.File, pools []*nntp.ClientPool, estimator *SegmentSizeEstimator) *File {
@@ -73,6 +164,7 @@ func NewFile(ctx context.Context, f *nzb.File, pools []*nntp.ClientPool, estimat
 		totalSize: offset,
 		ctx:       ctx,
 		segCache:  make(map[int][]byte),
+		held:      make(map[int]struct{}),
 	}
 }
 
@@ -86,6 +178,52 @@ func (f *File) Size() int64 {
 
 func (f *File) SegmentCount() int { return len(f.segments) }
 
+// SetReadAhead sets how many segments readers prefetch ahead of the read
+// position (and after a seek). 0 uses one per provider connection.
+func (f *File) SetReadAhead(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.readAhead = n
+}
+
+// SetSegmentTimeout sets how long one provider may take to deliver a segment
+// before the next provider is tried (0 = the NNTP client's default).
+func (f *File) SetSegmentTimeout(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.segmentTimeout = d
+}
+
+// SegmentTimeout returns the per-provider segment download deadline.
+func (f *File) SegmentTimeout() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.segmentTimeout
+}
+
+// SetPauseRelease sets how long a reader may go without a Read (the player paused) before
+// it cancels its prefetches, so they stop holding or waiting for NNTP connections that
+// other streams could use. Prefetching resumes with the next Read. 0 disables it.
+func (f *File) SetPauseRelease(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pauseRelease = d
+}
+
+// PauseRelease returns the idle time after which readers release their prefetches.
+func (f *File) PauseRelease() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pauseRelease
+}
+
+// ReadAhead returns the configured read-ahead depth in segments.
+func (f *File) ReadAhead() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.readAhead
+}
+
 func (f *File) TotalConnections() int {
 	total := 0
 	for _, p := range f.pools {
@@ -130,7 +268,7 @@ func (f *File) detectSegmentSize() error {
 				f.mu.Unlock()
 				return nil
 			}
-			logger.Debug("Using estimated segment size", "name", f.Name(), "size", decoded)
+			log.Debug("Using estimated segment size", "name", f.Name(), "size", decoded)
 			f.applySegmentSize(decoded)
 			f.mu.Unlock()
 			return nil
@@ -152,7 +290,7 @@ func (f *File) detectSegmentSize() error {
 	}
 
 	segSize := int64(len(data))
-	logger.Debug("Detected segment size", "name", f.Name(), "size", segSize, "nzb_size", f.segments[0].Bytes)
+	log.Debug("Detected segment size", "name", f.Name(), "size", segSize, "nzb_size", f.segments[0].Bytes)
 	if f.estimator != nil {
 		f.estimator.Set(f.segments[0].Bytes, segSize)
 	}
@@ -176,7 +314,7 @@ func (f *File) applySegmentSize(segSize int64) {
 	}
 	f.totalSize = offset
 	f.detected = true
-	logger.Debug("Recalculated total decoded size", "size", f.totalSize)
+	log.Debug("Recalculated total decoded size", "size", f.totalSize)
 }
 
 // --- Segment lookup (binary search) ---
@@ -193,27 +331,80 @@ func (f *File) FindSegmentIndex(offset int64) int {
 
 // --- Shared segment cache ---
 
+// sharedKey returns the shared cache key (the article Message-ID) for a segment,
+// or "" when the segment must be cached locally.
+func (f *File) sharedKey(index int) string {
+	if index < 0 || index >= len(f.segments) {
+		return ""
+	}
+	return f.segments[index].ID
+}
+
 func (f *File) GetCachedSegment(index int) ([]byte, bool) {
 	f.segCacheMu.RLock()
 	data, ok := f.segCache[index]
 	f.segCacheMu.RUnlock()
-	return data, ok
+	if ok {
+		return data, true
+	}
+	if key := f.sharedKey(index); key != "" {
+		return sharedSegments.get(key)
+	}
+	return nil, false
+}
+
+// HasCachedSegment reports whether a segment is cached without loading it from disk.
+func (f *File) HasCachedSegment(index int) bool {
+	f.segCacheMu.RLock()
+	_, ok := f.segCache[index]
+	f.segCacheMu.RUnlock()
+	if ok {
+		return true
+	}
+	key := f.sharedKey(index)
+	return key != "" && sharedSegments.contains(key)
 }
 
 func (f *File) PutCachedSegment(index int, data []byte) {
+	key := f.sharedKey(index)
+	f.segCacheMu.Lock()
+	if key == "" {
+		f.segCache[index] = data
+	} else {
+		f.held[index] = struct{}{}
+	}
+	f.segCacheMu.Unlock()
+	if key != "" {
+		sharedSegments.put(key, data)
+	}
+}
+
+func (f *File) putZeroFill(index int, data []byte) {
 	f.segCacheMu.Lock()
 	f.segCache[index] = data
 	f.segCacheMu.Unlock()
 }
 
+// EvictCachedSegmentsBefore drops segments behind the reader: local ones are
+// discarded, shared ones are moved out of memory (to disk when enabled).
 func (f *File) EvictCachedSegmentsBefore(minIndex int) {
+	var demote []string
 	f.segCacheMu.Lock()
 	for idx := range f.segCache {
 		if idx < minIndex {
 			delete(f.segCache, idx)
 		}
 	}
+	for idx := range f.held {
+		if idx < minIndex {
+			delete(f.held, idx)
+			demote = append(demote, f.segments[idx].ID)
+		}
+	}
 	f.segCacheMu.Unlock()
+	for _, key := range demote {
+		sharedSegments.demote(key)
+	}
 }
 
 // PrewarmSegment downloads a segment by index in the background.
@@ -223,7 +414,7 @@ func (f *File) PrewarmSegment(index int) {
 	if index < 0 || index >= len(f.segments) {
 		return
 	}
-	if _, ok := f.GetCachedSegment(index); ok {
+	if f.HasCachedSegment(index) {
 		return
 	}
 	go f.DownloadSegment(f.ctx, index)
@@ -235,7 +426,7 @@ func (f *File) PrewarmSegment(index int) {
 // Used for prefetch; callers typically ignore the return value.
 func (f *File) StartDownloadSegment(ctx context.Context, index int) <-chan struct{} {
 	done := make(chan struct{})
-	if _, ok := f.GetCachedSegment(index); ok {
+	if f.HasCachedSegment(index) {
 		close(done)
 		return done
 	}
@@ -260,13 +451,86 @@ func (f *File) doDownloadSegment(ctx context.Context, index int) ([]byte, error)
 	defer cancel()
 
 	seg := f.segments[index]
-	tried := make([]bool, len(f.pools))
+	spool := sharedSpool
+	if spool != nil {
+		if data, ok := spool.get(seg.ID); ok {
+			f.PutCachedSegment(index, data)
+			return data, nil
+		}
+	}
 	var lastErr error
+	slow := false
+
+	// A provider that only timed out is slow, not missing the article: give every
+	// provider a second chance before treating the segment as failed.
+	for round := 0; round < 2; round++ {
+		data, timedOut, err := f.downloadFromProviders(downloadCtx, index)
+		if data != nil {
+			f.PutCachedSegment(index, data)
+			if spool != nil {
+				spool.put(seg.ID, data)
+			}
+			return data, nil
+		}
+		if ctxErr := downloadCtx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		lastErr = err
+		slow = timedOut
+		if !slow {
+			break
+		}
+		log.Debug("Segment timed out on all providers, retrying", "index", index, "round", round+1, "err", err)
+	}
+
+	size := int(seg.EndOffset - seg.StartOffset)
+	if size < 0 {
+		size = 0
+	}
+	if slow {
+		// Not cached and not counted against MaxZeroFills: a later read retries it
+		f.zeroFillMu.Lock()
+		count := f.slowFillCount
+		if count >= MaxSlowFills {
+			f.zeroFillMu.Unlock()
+			return nil, fmt.Errorf("too many slow segments (%d/%d): %w", count+1, MaxSlowFills, errors.Join(ErrTooManyZeroFills, lastErr))
+		}
+		f.slowFillCount++
+		f.zeroFillMu.Unlock()
+		log.Debug("Segment too slow on all providers, zero-filling without caching", "index", index, "count", count+1, "max", MaxSlowFills, "err", lastErr)
+		return make([]byte, size), nil
+	}
+
+	f.zeroFillMu.Lock()
+	count := f.zeroFillCount
+	if count >= MaxZeroFills {
+		f.zeroFillMu.Unlock()
+		return nil, fmt.Errorf("too many failed segments (%d/%d): %w", count+1, MaxZeroFills, errors.Join(ErrTooManyZeroFills, lastErr))
+	}
+	f.zeroFillCount++
+	f.zeroFillMu.Unlock()
+
+	log.Debug("Segment failed on all providers, zero-filling", "index", index, "count", count+1, "max", MaxZeroFills, "err", lastErr)
+	zeroData := make([]byte, size)
+	f.putZeroFill(index, zeroData)
+	return zeroData, nil
+}
+
+// downloadFromProviders tries each provider once. On failure, timedOut reports
+// whether every provider that was reached failed only by timing out.
+func (f *File) downloadFromProviders(downloadCtx context.Context, index int) (data []byte, timedOut bool, lastErr error) {
+	seg := f.segments[index]
+	tried := make([]bool, len(f.pools))
+	timeout := f.SegmentTimeout()
+	timeouts, failures := 0, 0
 
 	for attempt := 0; attempt < len(f.pools); attempt++ {
 		select {
 		case <-downloadCtx.Done():
-			return nil, downloadCtx.Err()
+			return nil, false, downloadCtx.Err()
 		default:
 		}
 
@@ -294,7 +558,7 @@ func (f *File) doDownloadSegment(ctx context.Context, index int) ([]byte, error)
 						tried[i] = true
 						lastErr = err
 						if errors.Is(err, context.Canceled) {
-							return nil, err
+							return nil, false, err
 						}
 						continue
 					}
@@ -313,11 +577,17 @@ func (f *File) doDownloadSegment(ctx context.Context, index int) ([]byte, error)
 			client.Group(f.nzbFile.Groups[0])
 		}
 
-		r, err := client.Body(seg.ID)
+		r, err := client.BodyTimeout(seg.ID, timeout)
 		if err != nil {
-			pool.Put(client)
 			tried[poolIdx] = true
 			lastErr = err
+			failures++
+			if nntp.IsTimeout(err) {
+				timeouts++
+				pool.Discard(client)
+			} else {
+				pool.Put(client)
+			}
 			continue
 		}
 
@@ -334,37 +604,25 @@ func (f *File) doDownloadSegment(ctx context.Context, index int) ([]byte, error)
 		select {
 		case <-downloadCtx.Done():
 			pool.Discard(client)
-			return nil, downloadCtx.Err()
+			return nil, false, downloadCtx.Err()
 		case res := <-done:
 			if res.err != nil {
-				pool.Put(client)
 				tried[poolIdx] = true
 				lastErr = res.err
+				failures++
+				if nntp.IsTimeout(res.err) {
+					timeouts++
+					pool.Discard(client)
+				} else {
+					pool.Put(client)
+				}
 				continue
 			}
 			pool.Put(client)
-			f.PutCachedSegment(index, res.frame.Data)
-			return res.frame.Data, nil
+			return res.frame.Data, false, nil
 		}
 	}
-
-	f.zeroFillMu.Lock()
-	count := f.zeroFillCount
-	if count >= MaxZeroFills {
-		f.zeroFillMu.Unlock()
-		return nil, fmt.Errorf("too many failed segments (%d/%d): %w", count+1, MaxZeroFills, errors.Join(ErrTooManyZeroFills, lastErr))
-	}
-	f.zeroFillCount++
-	f.zeroFillMu.Unlock()
-
-	logger.Debug("Segment failed on all providers, zero-filling", "index", index, "count", count+1, "max", MaxZeroFills, "err", lastErr)
-	size := int(seg.EndOffset - seg.StartOffset)
-	if size < 0 {
-		size = 0
-	}
-	zeroData := make([]byte, size)
-	f.PutCachedSegment(index, zeroData)
-	return zeroData, nil
+	return nil, failures > 0 && timeouts == failures, lastErr
 }
 
 // --- Random access (for archive header scanning) ---
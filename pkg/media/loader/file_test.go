@@ -0,0 +1,151 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/nzb"
+	"streamnzb/pkg/usenet/nntp"
+)
+
+// fakeBodyServer answers BODY with 430 (missing) or not at all (stall).
+func fakeBodyServer(t *testing.T, stall bool) *nntp.ClientPool {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tp := textproto.NewConn(conn)
+				tp.PrintfLine("200 ready")
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					switch {
+					case strings.HasPrefix(line, "AUTHINFO"):
+						tp.PrintfLine("281 accepted")
+					case strings.HasPrefix(line, "BODY") && stall:
+						// never answer
+					case strings.HasPrefix(line, "BODY"):
+						tp.PrintfLine("430 no such article")
+					default:
+						tp.PrintfLine("500 unsupported")
+					}
+				}
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	pool := nntp.NewClientPool(addr.IP.String(), addr.Port, false, "", "", 2)
+	t.Cleanup(pool.Shutdown)
+	return pool
+}
+
+func testFile(pool *nntp.ClientPool) *File {
+	nf := &nzb.File{Subject: `"movie.mkv" yEnc (1/1)`, Segments: []nzb.Segment{{Bytes: 100, Number: 1, ID: "slow-or-missing@test"}}}
+	return NewFile(context.Background(), nf, []*nntp.ClientPool{pool}, nil)
+}
+
+func TestSlowSegmentNotCountedAsMissing(t *testing.T) {
+	logger.Init("DEBUG")
+	f := testFile(fakeBodyServer(t, true))
+	f.SetSegmentTimeout(100 * time.Millisecond)
+
+	data, err := f.DownloadSegment(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("DownloadSegment: %v", err)
+	}
+	if len(data) != 100 {
+		t.Errorf("got %d bytes, want 100 zero bytes", len(data))
+	}
+	if f.zeroFillCount != 0 {
+		t.Errorf("slow segment counted as zero-fill (%d)", f.zeroFillCount)
+	}
+	if f.HasCachedSegment(0) {
+		t.Error("slow segment was cached; a later read should retry it")
+	}
+}
+
+func TestTooManySlowSegmentsFails(t *testing.T) {
+	logger.Init("DEBUG")
+	f := testFile(fakeBodyServer(t, true))
+	f.SetSegmentTimeout(50 * time.Millisecond)
+	f.slowFillCount = MaxSlowFills - 1
+
+	if _, err := f.DownloadSegment(context.Background(), 0); err != nil {
+		t.Fatalf("DownloadSegment under the limit: %v", err)
+	}
+	if !f.IsFailed() {
+		t.Error("file not failed after MaxSlowFills slow segments")
+	}
+	if _, err := f.DownloadSegment(context.Background(), 0); !errors.Is(err, ErrTooManyZeroFills) {
+		t.Errorf("DownloadSegment beyond the limit: err = %v; want ErrTooManyZeroFills", err)
+	}
+}
+
+func TestMissingSegmentCountedAsZeroFill(t *testing.T) {
+	logger.Init("DEBUG")
+	f := testFile(fakeBodyServer(t, false))
+	f.SetSegmentTimeout(time.Second)
+
+	if _, err := f.DownloadSegment(context.Background(), 0); err != nil {
+		t.Fatalf("DownloadSegment: %v", err)
+	}
+	if f.zeroFillCount != 1 {
+		t.Errorf("zeroFillCount = %d, want 1", f.zeroFillCount)
+	}
+	if missing, slow := f.ZeroFills(); missing != 1 || slow != 0 {
+		t.Errorf("ZeroFills = %d, %d; want 1 missing", missing, slow)
+	}
+}
+
+func TestWithProviderAndAdoptFrom(t *testing.T) {
+	logger.Init("DEBUG")
+	nf := &nzb.File{Subject: `"movie.part01.rar" yEnc (1/1)`, Segments: []nzb.Segment{{Bytes: 100, Number: 1, ID: "rescan@test"}}}
+	f := NewFile(context.Background(), nf, []*nntp.ClientPool{fakeBodyServer(t, false), fakeBodyServer(t, false)}, nil)
+	f.SetSegmentTimeout(time.Second)
+	if f.ProviderCount() != 2 || f.ProviderHost(2) != "" {
+		t.Fatalf("ProviderCount = %d, ProviderHost(2) = %q", f.ProviderCount(), f.ProviderHost(2))
+	}
+	if _, ok := f.WithProvider(2); ok {
+		t.Error("WithProvider accepted an index past the last provider")
+	}
+	f.zeroFillCount = MaxZeroFills
+
+	alt, ok := f.WithProvider(1)
+	if !ok || alt.ProviderCount() != 1 || alt.SegmentTimeout() != time.Second {
+		t.Fatalf("WithProvider(1) = %v providers, timeout %v", alt.ProviderCount(), alt.SegmentTimeout())
+	}
+	if alt.IsFailed() {
+		t.Error("copy inherited the failure count")
+	}
+	if _, err := alt.DownloadSegment(context.Background(), 0); err != nil {
+		t.Fatalf("DownloadSegment: %v", err)
+	}
+	if !alt.HasCachedSegment(0) || f.HasCachedSegment(0) {
+		t.Error("the copy's zero-fill should stay local to the copy")
+	}
+
+	f.AdoptFrom(alt)
+	if f.IsFailed() {
+		t.Error("AdoptFrom did not clear the failure count")
+	}
+	if !f.HasCachedSegment(0) {
+		t.Error("AdoptFrom did not take over the copy's segments")
+	}
+}
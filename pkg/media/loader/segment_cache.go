@@ -0,0 +1,291 @@
+package loader
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultSegmentCacheMemory bounds decoded segments held in memory across all files
+// and sessions until ConfigureSegmentCache is called.
+const DefaultSegmentCacheMemory = 512 << 20
+
+// segmentCache is the package-level cache of decoded segments, keyed by article
+// Message-ID so sessions playing the same release share downloads. Memory is
+// bounded with LRU eviction; with a disk tier, evicted segments are spilled to
+// disk (also LRU-bounded) and promoted back to memory when read again.
+type segmentCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	bytes    int64
+	entries  map[string]*list.Element
+	lru      *list.List // front = most recently used
+	disk     *diskSegmentCache
+}
+
+type segmentCacheEntry struct {
+	key  string
+	data []byte
+}
+
+var sharedSegments = newSegmentCache(DefaultSegmentCacheMemory, nil)
+
+func newSegmentCache(maxBytes int64, disk *diskSegmentCache) *segmentCache {
+	return &segmentCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		disk:     disk,
+	}
+}
+
+// ConfigureSegmentCache replaces the shared segment cache: memoryBytes caps decoded
+// segments in memory (<= 0 uses DefaultSegmentCacheMemory). When dir is set and
+// diskBytes > 0, segments evicted from memory are kept on disk in dir up to diskBytes.
+// Existing cache files in dir are removed. Call at startup, before sessions exist.
+func ConfigureSegmentCache(memoryBytes int64, dir string, diskBytes int64) error {
+	if memoryBytes <= 0 {
+		memoryBytes = DefaultSegmentCacheMemory
+	}
+	var disk *diskSegmentCache
+	if dir != "" && diskBytes > 0 {
+		var err error
+		if disk, err = newDiskSegmentCache(dir, diskBytes); err != nil {
+			return err
+		}
+	}
+	sharedSegments = newSegmentCache(memoryBytes, disk)
+	return nil
+}
+
+// ClearSegmentCache drops all cached segments from memory and disk and returns
+// how many were removed.
+func ClearSegmentCache() int {
+	return sharedSegments.clear()
+}
+
+func (c *segmentCache) clear() int {
+	c.mu.Lock()
+	n := len(c.entries)
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+	c.mu.Unlock()
+	if c.disk != nil {
+		n += c.disk.clear()
+	}
+	return n
+}
+
+// get returns the segment from memory, or from disk (moving it back to memory).
+func (c *segmentCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		data := el.Value.(*segmentCacheEntry).data
+		c.mu.Unlock()
+		return data, true
+	}
+	c.mu.Unlock()
+
+	if c.disk == nil {
+		return nil, false
+	}
+	data, ok := c.disk.get(key)
+	if !ok {
+		return nil, false
+	}
+	c.put(key, data)
+	return data, true
+}
+
+// contains reports whether the segment is in memory or on disk without reading it.
+func (c *segmentCache) contains(key string) bool {
+	c.mu.Lock()
+	_, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return true
+	}
+	return c.disk != nil && c.disk.contains(key)
+}
+
+// put stores a segment in memory, evicting the least recently used entries over
+// the limit (to disk when a disk tier exists).
+func (c *segmentCache) put(key string, data []byte) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&segmentCacheEntry{key: key, data: data})
+	c.bytes += int64(len(data))
+	var evicted []*segmentCacheEntry
+	for c.bytes > c.maxBytes && c.lru.Len() > 1 {
+		evicted = append(evicted, c.removeLocked(c.lru.Back()))
+	}
+	c.mu.Unlock()
+
+	if c.disk != nil {
+		for _, e := range evicted {
+			if !c.disk.contains(e.key) {
+				c.disk.put(e.key, e.data)
+			}
+		}
+	}
+}
+
+// demote drops a segment from memory, keeping it on disk when a disk tier exists.
+func (c *segmentCache) demote(key string) {
+	c.mu.Lock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	e := c.removeLocked(el)
+	c.mu.Unlock()
+	if c.disk != nil && !c.disk.contains(key) {
+		c.disk.put(e.key, e.data)
+	}
+}
+
+func (c *segmentCache) removeLocked(el *list.Element) *segmentCacheEntry {
+	e := c.lru.Remove(el).(*segmentCacheEntry)
+	delete(c.entries, e.key)
+	c.bytes -= int64(len(e.data))
+	return e
+}
+
+// diskSegmentCache stores segments as files named by the SHA-1 of their key,
+// evicting the least recently used files beyond maxBytes.
+type diskSegmentCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	bytes   int64
+	entries map[string]*list.Element // key -> *diskEntry
+	lru     *list.List
+}
+
+type diskEntry struct {
+	key  string
+	size int64
+}
+
+const segmentFileExt = ".seg"
+
+func newDiskSegmentCache(dir string, maxBytes int64) (*diskSegmentCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create segment cache dir: %w", err)
+	}
+	// Entries from a previous run are not indexed; start empty
+	if old, err := os.ReadDir(dir); err == nil {
+		for _, e := range old {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), segmentFileExt) {
+				os.Remove(filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	return &diskSegmentCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}, nil
+}
+
+func (d *diskSegmentCache) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+segmentFileExt)
+}
+
+func (d *diskSegmentCache) contains(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.entries[key]
+	return ok
+}
+
+func (d *diskSegmentCache) get(key string) ([]byte, bool) {
+	d.mu.Lock()
+	el, ok := d.entries[key]
+	if ok {
+		d.lru.MoveToFront(el)
+	}
+	d.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		d.remove(key)
+		return nil, false
+	}
+	return data, true
+}
+
+func (d *diskSegmentCache) put(key string, data []byte) {
+	if int64(len(data)) > d.maxBytes {
+		return
+	}
+	p := d.path(key)
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		log.Debug("Failed to write segment to disk cache", "err", err)
+		return
+	}
+
+	d.mu.Lock()
+	if el, ok := d.entries[key]; ok {
+		d.bytes -= el.Value.(*diskEntry).size
+		d.lru.Remove(el)
+	}
+	d.entries[key] = d.lru.PushFront(&diskEntry{key: key, size: int64(len(data))})
+	d.bytes += int64(len(data))
+	var evicted []string
+	for d.bytes > d.maxBytes && d.lru.Len() > 1 {
+		e := d.lru.Remove(d.lru.Back()).(*diskEntry)
+		delete(d.entries, e.key)
+		d.bytes -= e.size
+		evicted = append(evicted, e.key)
+	}
+	d.mu.Unlock()
+
+	for _, k := range evicted {
+		os.Remove(d.path(k))
+	}
+}
+
+func (d *diskSegmentCache) clear() int {
+	d.mu.Lock()
+	keys := make([]string, 0, len(d.entries))
+	for key := range d.entries {
+		keys = append(keys, key)
+	}
+	d.entries = make(map[string]*list.Element)
+	d.lru.Init()
+	d.bytes = 0
+	d.mu.Unlock()
+	for _, key := range keys {
+		os.Remove(d.path(key))
+	}
+	return len(keys)
+}
+
+func (d *diskSegmentCache) remove(key string) {
+	d.mu.Lock()
+	if el, ok := d.entries[key]; ok {
+		d.bytes -= el.Value.(*diskEntry).size
+		d.lru.Remove(el)
+		delete(d.entries, key)
+	}
+	d.mu.Unlock()
+	os.Remove(d.path(key))
+}
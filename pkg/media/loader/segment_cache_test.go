@@ -0,0 +1,117 @@
+package loader
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestSegmentCacheMemoryLRU(t *testing.T) {
+	c := newSegmentCache(20, nil)
+	c.put("a", make([]byte, 10))
+	c.put("b", make([]byte, 10))
+	c.get("a") // a is now most recently used
+	c.put("c", make([]byte, 10))
+
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used segment b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("segment %s evicted", key)
+		}
+	}
+	if c.bytes != 20 {
+		t.Errorf("bytes = %d, want 20", c.bytes)
+	}
+}
+
+func TestSegmentCacheDiskSpill(t *testing.T) {
+	logger.Init("DEBUG")
+	dir := t.TempDir()
+	stale := dir + "/old" + segmentFileExt
+	os.WriteFile(stale, []byte("x"), 0644)
+
+	disk, err := newDiskSegmentCache(dir, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale cache file from a previous run was kept")
+	}
+	c := newSegmentCache(10, disk)
+
+	a := bytes.Repeat([]byte{'a'}, 10)
+	c.put("a", a)
+	c.put("b", bytes.Repeat([]byte{'b'}, 10)) // spills a
+	if !disk.contains("a") {
+		t.Fatal("evicted segment was not written to disk")
+	}
+	if got, ok := c.get("a"); !ok || !bytes.Equal(got, a) {
+		t.Fatalf("get(a) from disk = %q, %v", got, ok)
+	}
+	if _, ok := c.entries["a"]; !ok {
+		t.Error("segment read from disk was not promoted to memory")
+	}
+
+	c.put("c", make([]byte, 10))
+	c.put("d", make([]byte, 10))
+	if disk.bytes > 25 {
+		t.Errorf("disk cache holds %d bytes, limit 25", disk.bytes)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != disk.lru.Len() {
+		t.Errorf("%d files on disk, %d indexed", len(entries), disk.lru.Len())
+	}
+}
+
+func TestFileEvictDemotesSharedSegments(t *testing.T) {
+	prev := sharedSegments
+	t.Cleanup(func() { sharedSegments = prev })
+	sharedSegments = newSegmentCache(1<<20, nil)
+
+	f := &File{
+		segments: []*Segment{{}, {}},
+		segCache: make(map[int][]byte),
+		held:     make(map[int]struct{}),
+	}
+	f.segments[0].ID = "seg0@test"
+	f.PutCachedSegment(0, []byte("shared"))
+	f.PutCachedSegment(1, []byte("local")) // no Message-ID
+
+	other := &File{segments: []*Segment{{}}, segCache: make(map[int][]byte), held: make(map[int]struct{})}
+	other.segments[0].ID = "seg0@test"
+	if data, ok := other.GetCachedSegment(0); !ok || string(data) != "shared" {
+		t.Fatalf("segment not shared across files: %q, %v", data, ok)
+	}
+	if other.HasCachedSegment(1) {
+		t.Error("segment without Message-ID leaked into another file")
+	}
+
+	f.EvictCachedSegmentsBefore(2)
+	if f.HasCachedSegment(0) || f.HasCachedSegment(1) {
+		t.Error("segments behind the reader were not evicted")
+	}
+}
+
+func TestSegmentCacheClear(t *testing.T) {
+	disk, err := newDiskSegmentCache(t.TempDir(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newSegmentCache(10, disk)
+	c.put("a", make([]byte, 10))
+	c.put("b", make([]byte, 10)) // spills a
+
+	if n := c.clear(); n != 2 {
+		t.Errorf("clear removed %d entries, want 2", n)
+	}
+	if c.contains("a") || c.contains("b") || c.bytes != 0 || disk.bytes != 0 {
+		t.Error("segments left after clear")
+	}
+	if entries, _ := os.ReadDir(disk.dir); len(entries) != 0 {
+		t.Errorf("%d cache files left on disk", len(entries))
+	}
+}
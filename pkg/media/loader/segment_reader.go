@@ -7,8 +7,6 @@ import (
 	"strings"
 	"sync"
 	"time"
-
-	"streamnzb/pkg/core/logger"
 )
 
 // SegmentReader provides linear reading with read-ahead prefetching.
@@ -25,6 +23,12 @@ type SegmentReader struct {
 	segOff int64 // byte offset within current segment
 	offset int64 // virtual offset in file
 	closed bool
+	seeked bool // next Read follows a seek: prefetch ahead while fetching the target segment
+
+	// Pause detection: pauseTimer fires when no Read happened for the file's PauseRelease
+	pauseTimer *time.Timer
+	paused     bool
+	reading    int // Reads in progress; a pause is never detected during one
 
 	// Prefetch
 	prefetchWg  sync.WaitGroup
@@ -52,6 +56,7 @@ func NewSegmentReader(parent context.Context, f *File, startOffset int64) *Segme
 	} else {
 		sr.segIdx = idx
 		sr.segOff = startOffset - f.segments[idx].StartOffset
+		sr.seeked = startOffset > 0
 	}
 	// No prefetch here - first Read() gets the segment with zero competition.
 	// startPrefetch() runs after each Read for sequential throughput.
@@ -68,10 +73,31 @@ func (r *SegmentReader) Read(p []byte) (int, error) {
 		r.mu.Unlock()
 		return 0, io.EOF
 	}
+	r.reading++
+	defer func() {
+		r.mu.Lock()
+		r.reading--
+		r.mu.Unlock()
+	}()
+	if r.paused {
+		// Resuming after a pause: prefetch again around the current position
+		r.paused = false
+		r.seeked = true
+		log.Debug("Playback resumed, prefetching again", "name", r.file.Name(), "seg", r.segIdx)
+	}
 	segIdx := r.segIdx
 	segOff := r.segOff
+	seeked := r.seeked
+	r.seeked = false
 	r.mu.Unlock()
 
+	// After a seek nothing around the new position is buffered: fetch the
+	// following segments in the background while the target segment downloads,
+	// leaving one connection free for the sync read.
+	if seeked {
+		r.startPrefetchFrom(segIdx+1, r.prefetchDepth()-1)
+	}
+
 	// Download the segment the caller actually needs FIRST, before
 	// spawning any prefetch goroutines. This guarantees the sync read
 	// gets an NNTP connection without competing against prefetch.
@@ -106,10 +132,46 @@ func (r *SegmentReader) Read(p []byte) (int, error) {
 	// Start prefetch AFTER the sync read succeeds. This way prefetch
 	// goroutines only use connections that the active read doesn't need.
 	r.startPrefetch()
+	r.armPauseTimer()
 
 	return n, nil
 }
 
+// armPauseTimer (re)starts the pause timer after a Read.
+func (r *SegmentReader) armPauseTimer() {
+	d := r.file.PauseRelease()
+	if d <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	if r.pauseTimer == nil {
+		r.pauseTimer = time.AfterFunc(d, r.releaseForPause)
+	} else {
+		r.pauseTimer.Reset(d)
+	}
+}
+
+// releaseForPause cancels the prefetches of a reader that has not been read from for a
+// while, so they neither hold connections nor queue for them while the player is paused.
+// Segments already downloaded stay cached for the resume.
+func (r *SegmentReader) releaseForPause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed || r.paused || r.reading > 0 {
+		return
+	}
+	r.paused = true
+	inFlight := len(r.prefetching)
+	r.cancel()
+	r.ctx, r.cancel = context.WithCancel(r.parent)
+	r.prefetching = make(map[int]bool)
+	log.Debug("Playback paused, released prefetches", "name", r.file.Name(), "seg", r.segIdx, "in_flight", inFlight)
+}
+
 func (r *SegmentReader) waitForSegment(index int) ([]byte, error) {
 	// Fast path: already in shared cache
 	if data, ok := r.file.GetCachedSegment(index); ok {
@@ -119,11 +181,11 @@ func (r *SegmentReader) waitForSegment(index int) ([]byte, error) {
 	return r.file.DownloadSegment(r.ctx, index)
 }
 
-func (r *SegmentReader) startPrefetch() {
-	r.mu.Lock()
-	current := r.segIdx
-	r.mu.Unlock()
-
+// prefetchDepth is how many segments to keep in flight ahead of the reader:
+// the file's read-ahead setting when set, otherwise one per connection (max 20).
+// Going beyond the connection count just queues goroutines that block on
+// pool.Get, adding contention without any throughput benefit.
+func (r *SegmentReader) prefetchDepth() int {
 	maxWorkers := r.file.TotalConnections()
 	if maxWorkers > 20 {
 		maxWorkers = 20
@@ -131,19 +193,29 @@ func (r *SegmentReader) startPrefetch() {
 	if maxWorkers < 1 {
 		maxWorkers = 1
 	}
+	if n := r.file.ReadAhead(); n > 0 && n < maxWorkers {
+		return n
+	}
+	return maxWorkers
+}
 
-	// Prefetch only as many segments as we have connections.
-	// Going beyond this just queues goroutines that block on pool.Get,
-	// adding contention without any throughput benefit.
-	ahead := maxWorkers
+func (r *SegmentReader) startPrefetch() {
+	r.mu.Lock()
+	current := r.segIdx
+	r.mu.Unlock()
+	r.startPrefetchFrom(current, r.prefetchDepth())
+}
 
+// startPrefetchFrom downloads up to ahead segments starting at start in the background.
+func (r *SegmentReader) startPrefetchFrom(start, ahead int) {
 	r.mu.Lock()
+	ctx := r.ctx
 	for i := 0; i < ahead; i++ {
-		idx := current + i
+		idx := start + i
 		if idx >= len(r.file.segments) {
 			break
 		}
-		if _, ok := r.file.GetCachedSegment(idx); ok {
+		if r.file.HasCachedSegment(idx) {
 			continue
 		}
 		if r.prefetching[idx] {
@@ -158,9 +230,9 @@ func (r *SegmentReader) startPrefetch() {
 				delete(r.prefetching, segIdx)
 				r.mu.Unlock()
 			}()
-			_, err := r.file.DownloadSegment(r.ctx, segIdx)
+			_, err := r.file.DownloadSegment(ctx, segIdx)
 			if err != nil && !isContextErr(err) {
-				logger.Error("Prefetch failed", "seg", segIdx, "err", err)
+				log.Error("Prefetch failed", "seg", segIdx, "err", err)
 			}
 		}(idx)
 	}
@@ -220,6 +292,7 @@ func (r *SegmentReader) Seek(offset int64, whence int) (int64, error) {
 	}
 
 	r.offset = target
+	r.seeked = true
 	if target >= r.file.Size() {
 		r.segIdx = len(r.file.segments)
 		r.segOff = 0
@@ -245,6 +318,9 @@ func (r *SegmentReader) Close() error {
 		return nil
 	}
 	r.closed = true
+	if r.pauseTimer != nil {
+		r.pauseTimer.Stop()
+	}
 	r.mu.Unlock()
 
 	r.cancel()
@@ -0,0 +1,105 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/nzb"
+	"streamnzb/pkg/usenet/nntp"
+)
+
+func TestReleaseForPauseCancelsPrefetch(t *testing.T) {
+	logger.Init("DEBUG")
+	f := testFile(fakeBodyServer(t, true))
+	r := NewSegmentReader(context.Background(), f, 0)
+	defer r.Close()
+
+	oldCtx := r.ctx
+	r.prefetching[0] = true
+	r.reading = 1
+	r.releaseForPause()
+	if r.paused || oldCtx.Err() != nil {
+		t.Fatal("pause detected during a Read")
+	}
+
+	r.reading = 0
+	r.releaseForPause()
+	if !r.paused {
+		t.Fatal("reader not marked paused")
+	}
+	if oldCtx.Err() == nil {
+		t.Error("prefetch context not cancelled")
+	}
+	if r.ctx.Err() != nil {
+		t.Error("new context already cancelled; resume could not download")
+	}
+	if len(r.prefetching) != 0 {
+		t.Errorf("prefetching = %v, want empty", r.prefetching)
+	}
+}
+
+// multiSegmentFile is a file of n 100-byte segments served by pool.
+func multiSegmentFile(pool *nntp.ClientPool, n int) *File {
+	nf := &nzb.File{Subject: `"movie.mkv" yEnc (1/1)`}
+	for i := 1; i <= n; i++ {
+		nf.Segments = append(nf.Segments, nzb.Segment{Bytes: 100, Number: i, ID: fmt.Sprintf("seek-%d@test", i)})
+	}
+	return NewFile(context.Background(), nf, []*nntp.ClientPool{pool}, nil)
+}
+
+func TestPrefetchDepth(t *testing.T) {
+	logger.Init("DEBUG")
+	f := multiSegmentFile(fakeBodyServer(t, true), 5) // 2 connections
+	r := NewSegmentReader(context.Background(), f, 0)
+	defer r.Close()
+	for _, tt := range []struct{ readAhead, want int }{
+		{0, 2}, // one per connection
+		{1, 1},
+		{8, 2}, // never more than the connections
+	} {
+		f.SetReadAhead(tt.readAhead)
+		if got := r.prefetchDepth(); got != tt.want {
+			t.Errorf("read-ahead %d: prefetchDepth = %d; want %d", tt.readAhead, got, tt.want)
+		}
+	}
+}
+
+func TestSeekPrefetchesAhead(t *testing.T) {
+	logger.Init("DEBUG")
+	f := multiSegmentFile(fakeBodyServer(t, true), 5)
+	f.SetSegmentTimeout(150 * time.Millisecond)
+
+	// prefetchingDuringRead reports which segments are prefetched while a Read at
+	// offset stalls on its segment.
+	prefetchingDuringRead := func(offset int64) map[int]bool {
+		r := NewSegmentReader(context.Background(), f, offset)
+		defer r.Close()
+		done := make(chan struct{})
+		go func() {
+			r.Read(make([]byte, 10))
+			close(done)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		r.mu.Lock()
+		got := make(map[int]bool, len(r.prefetching))
+		for idx := range r.prefetching {
+			got[idx] = true
+		}
+		r.mu.Unlock()
+		<-done
+		return got
+	}
+
+	// After a seek the segment following the target is fetched alongside it, leaving
+	// the other connection for the sync read
+	if got := prefetchingDuringRead(250); len(got) != 1 || !got[3] {
+		t.Errorf("prefetching after a seek to segment 2 = %v; want segment 3", got)
+	}
+	// From the start nothing is prefetched before the first read completes
+	if got := prefetchingDuringRead(0); len(got) != 0 {
+		t.Errorf("prefetching before the first read = %v; want none", got)
+	}
+}
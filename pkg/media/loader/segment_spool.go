@@ -0,0 +1,228 @@
+package loader
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/media/decode"
+)
+
+// segmentSpool is a persistent directory of segments named by their Message-ID, checked
+// before fetching a segment over NNTP and filled with every segment fetched. Unlike the
+// disk tier of the segment cache it survives restarts: files found in the directory are
+// indexed at startup, oldest modification time evicted first. Files may also be put there
+// by other tools, either decoded or as the raw yEnc article body.
+type segmentSpool struct {
+	dir      string
+	maxBytes int64 // <= 0 never evicts
+
+	mu      sync.Mutex
+	bytes   int64
+	entries map[string]*list.Element // file name -> *diskEntry
+	lru     *list.List               // front = most recently used
+}
+
+var sharedSpool *segmentSpool
+
+const spoolTempPrefix = ".spool-"
+
+// ConfigureSegmentSpool enables the persistent segment spool in dir, evicting the least
+// recently used segments beyond maxBytes (<= 0 keeps every segment). An empty dir
+// disables it. Call at startup, before sessions exist.
+func ConfigureSegmentSpool(dir string, maxBytes int64) error {
+	if dir == "" {
+		sharedSpool = nil
+		return nil
+	}
+	spool, err := newSegmentSpool(dir, maxBytes)
+	if err != nil {
+		return err
+	}
+	sharedSpool = spool
+	return nil
+}
+
+func newSegmentSpool(dir string, maxBytes int64) (*segmentSpool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create segment spool dir: %w", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read segment spool dir: %w", err)
+	}
+	type spooled struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+	var found []spooled
+	for _, e := range files {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if strings.HasPrefix(e.Name(), spoolTempPrefix) {
+			os.Remove(filepath.Join(dir, e.Name())) // left by an interrupted write
+			continue
+		}
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, spooled{e.Name(), info.Size(), info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.Before(found[j].modTime) })
+
+	s := &segmentSpool{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+	for _, f := range found {
+		s.entries[f.name] = s.lru.PushFront(&diskEntry{key: f.name, size: f.size})
+		s.bytes += f.size
+	}
+	s.mu.Lock()
+	evicted := s.evictLocked()
+	s.mu.Unlock()
+	s.removeFiles(evicted)
+	log.Debug("Segment spool indexed", "dir", dir, "segments", len(s.entries), "bytes", s.bytes)
+	return s, nil
+}
+
+// spoolFileName maps a Message-ID to a file name: angle brackets are dropped and
+// characters unsafe in a path are escaped.
+func spoolFileName(messageID string) string {
+	name := url.PathEscape(strings.TrimSuffix(strings.TrimPrefix(messageID, "<"), ">"))
+	if strings.HasPrefix(name, ".") {
+		name = "%2E" + name[1:]
+	}
+	return name
+}
+
+// get returns the decoded segment for messageID. Files added to the directory since
+// startup are picked up too.
+func (s *segmentSpool) get(messageID string) ([]byte, bool) {
+	name := spoolFileName(messageID)
+	if name == "" {
+		return nil, false
+	}
+	p := filepath.Join(s.dir, name)
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		s.forget(name)
+		return nil, false
+	}
+	data := raw
+	if bytes.HasPrefix(bytes.TrimLeft(raw, "\r\n"), []byte("=ybegin ")) {
+		frame, err := decode.DecodeToBytes(bytes.NewReader(raw))
+		if err != nil {
+			log.Debug("Ignoring undecodable spooled segment", "file", name, "err", err)
+			return nil, false
+		}
+		data = frame.Data
+	}
+
+	now := time.Now()
+	os.Chtimes(p, now, now) // keeps the eviction order across restarts
+	s.mu.Lock()
+	if el, ok := s.entries[name]; ok {
+		s.lru.MoveToFront(el)
+	} else {
+		s.entries[name] = s.lru.PushFront(&diskEntry{key: name, size: int64(len(raw))})
+		s.bytes += int64(len(raw))
+	}
+	evicted := s.evictLocked()
+	s.mu.Unlock()
+	s.removeFiles(evicted)
+	return data, true
+}
+
+// put stores a decoded segment fetched over NNTP.
+func (s *segmentSpool) put(messageID string, data []byte) {
+	name := spoolFileName(messageID)
+	if name == "" || (s.maxBytes > 0 && int64(len(data)) > s.maxBytes) {
+		return
+	}
+	s.mu.Lock()
+	_, exists := s.entries[name]
+	s.mu.Unlock()
+	if exists {
+		return
+	}
+
+	// Write under a temporary name so a crash never leaves a truncated segment
+	p := filepath.Join(s.dir, name)
+	tmp, err := os.CreateTemp(s.dir, spoolTempPrefix+"*")
+	if err != nil {
+		log.Debug("Failed to write segment to spool", "err", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Debug("Failed to write segment to spool", "err", err)
+		return
+	}
+
+	s.mu.Lock()
+	if el, ok := s.entries[name]; ok {
+		s.bytes -= el.Value.(*diskEntry).size
+		s.lru.Remove(el)
+	}
+	s.entries[name] = s.lru.PushFront(&diskEntry{key: name, size: int64(len(data))})
+	s.bytes += int64(len(data))
+	evicted := s.evictLocked()
+	s.mu.Unlock()
+	s.removeFiles(evicted)
+}
+
+// evictLocked drops the least recently used entries beyond maxBytes and returns
+// their file names.
+func (s *segmentSpool) evictLocked() []string {
+	if s.maxBytes <= 0 {
+		return nil
+	}
+	var evicted []string
+	for s.bytes > s.maxBytes && s.lru.Len() > 1 {
+		e := s.lru.Remove(s.lru.Back()).(*diskEntry)
+		delete(s.entries, e.key)
+		s.bytes -= e.size
+		evicted = append(evicted, e.key)
+	}
+	return evicted
+}
+
+func (s *segmentSpool) removeFiles(names []string) {
+	for _, name := range names {
+		os.Remove(filepath.Join(s.dir, name))
+	}
+}
+
+// forget drops an entry whose file is gone.
+func (s *segmentSpool) forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[name]; ok {
+		s.bytes -= el.Value.(*diskEntry).size
+		s.lru.Remove(el)
+		delete(s.entries, name)
+	}
+}
@@ -0,0 +1,92 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestSegmentSpoolPersistsAndEvicts(t *testing.T) {
+	logger.Init("DEBUG")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, spoolTempPrefix+"123"), []byte("partial"), 0644)
+
+	s, err := newSegmentSpool(dir, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, spoolTempPrefix+"123")); !os.IsNotExist(err) {
+		t.Error("interrupted write was kept")
+	}
+	a := bytes.Repeat([]byte{'a'}, 10)
+	s.put("<a@test>", a)
+	s.put("b/c@test", bytes.Repeat([]byte{'b'}, 10))
+	if _, err := os.Stat(filepath.Join(dir, "b%2Fc@test")); err != nil {
+		t.Errorf("Message-ID with a slash not escaped: %v", err)
+	}
+
+	// Reopened like after a restart
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "b%2Fc@test"), past, past)
+	s, err = newSegmentSpool(dir, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.get("a@test"); !ok || !bytes.Equal(got, a) {
+		t.Fatalf("get(a) after reopening = %q, %v", got, ok)
+	}
+	s.put("d@test", make([]byte, 10))
+	if _, ok := s.get("b/c@test"); ok {
+		t.Error("least recently used segment b was not evicted")
+	}
+	if s.bytes > 25 {
+		t.Errorf("spool holds %d bytes, limit 25", s.bytes)
+	}
+}
+
+func TestSegmentSpoolReadsYencArticles(t *testing.T) {
+	logger.Init("DEBUG")
+	dir := t.TempDir()
+	data := "hello world"
+	enc := make([]byte, len(data))
+	for i := range data {
+		enc[i] = data[i] + 42
+	}
+	article := fmt.Sprintf("=ybegin part=1 line=128 size=%d name=a.bin\r\n=ypart begin=1 end=%d\r\n%s\r\n=yend size=%d part=1 pcrc32=%08x\r\n",
+		len(data), len(data), enc, len(data), crc32.ChecksumIEEE([]byte(data)))
+	os.WriteFile(filepath.Join(dir, "seg0@test"), []byte(article), 0644)
+
+	s, err := newSegmentSpool(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := s.get("seg0@test"); !ok || string(got) != data {
+		t.Fatalf("get(seg0) = %q, %v; want the decoded article", got, ok)
+	}
+
+	// A File with no providers is served from the spool
+	prev, prevSpool := sharedSegments, sharedSpool
+	t.Cleanup(func() { sharedSegments, sharedSpool = prev, prevSpool })
+	sharedSegments = newSegmentCache(1<<20, nil)
+	sharedSpool = s
+	f := &File{
+		segments: []*Segment{{}},
+		segCache: make(map[int][]byte),
+		held:     make(map[int]struct{}),
+	}
+	f.segments[0].ID = "seg0@test"
+	got, err := f.DownloadSegment(context.Background(), 0)
+	if err != nil || string(got) != data {
+		t.Fatalf("DownloadSegment = %q, %v", got, err)
+	}
+	if !f.HasCachedSegment(0) {
+		t.Error("spooled segment was not put in the segment cache")
+	}
+}
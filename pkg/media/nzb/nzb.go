@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -129,6 +131,12 @@ func (n *NZB) GetFileInfo() []*FileInfo {
 	return infos
 }
 
+// IsObfuscated reports whether no file of the release is recognizable as video or archive
+// by name (random names, no extensions), so playback must probe the largest file instead.
+func (n *NZB) IsObfuscated() bool {
+	return len(n.Files) > 0 && n.GetLargestContentFile() == nil
+}
+
 // GetLargestContentFile returns the single largest content file (video or archive), excluding samples/extras.
 // Used for compression detection: the biggest file is the main content to inspect.
 func (n *NZB) GetLargestContentFile() *FileInfo {
@@ -278,6 +286,48 @@ func getFilePattern(filename string) string {
 	return strings.Trim(s, " .-_")
 }
 
+// Limits of the broken release heuristic (see BrokenReason).
+const (
+	// A release whose only content file is smaller than this is a placeholder.
+	minLoneContentSize = 16 << 20
+	// A release carrying more than this multiple of its content size in par2 volumes is
+	// a "PAR2-only" post: the real content is missing or only recoverable from parity.
+	maxParityRatio = 1
+)
+
+// ErrBrokenRelease marks releases rejected by BrokenReason.
+var ErrBrokenRelease = errors.New("broken release")
+
+// BrokenReason reports why a release cannot be streamed even though its articles
+// validate: par2 volumes that dwarf the content, or a lone content file too small to be
+// the video. It returns "" for normal releases and for releases without content files.
+func (n *NZB) BrokenReason() string {
+	// Content files include par2 and extras sharing the release name; only media counts
+	var media []*FileInfo
+	var contentSize, parSize int64
+	for _, info := range n.GetContentFiles() {
+		if !info.IsExtra && !info.IsSample {
+			media = append(media, info)
+			contentSize += info.Size
+		}
+	}
+	if len(media) == 0 {
+		return ""
+	}
+	for _, info := range n.GetFileInfo() {
+		if info.Extension == ".par2" {
+			parSize += info.Size
+		}
+	}
+	if parSize > maxParityRatio*contentSize {
+		return fmt.Sprintf("par2 volumes (%d MB) dwarf content (%d MB)", parSize>>20, contentSize>>20)
+	}
+	if len(media) == 1 && contentSize < minLoneContentSize {
+		return fmt.Sprintf("only content file %q is %d KB", media[0].Filename, contentSize>>10)
+	}
+	return ""
+}
+
 // IsRARRelease returns true if the main content of the release is RAR-based.
 func (n *NZB) IsRARRelease() bool {
 	return n.CompressionType() == "rar"
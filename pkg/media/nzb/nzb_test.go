@@ -30,3 +30,66 @@ func TestCompressionType_posterAttribute(t *testing.T) {
 		t.Error("GetContentFiles() returned empty, expected RAR parts")
 	}
 }
+
+func TestIsObfuscated(t *testing.T) {
+	logger.Init("warn")
+	build := func(names ...string) *NZB {
+		n := &NZB{}
+		for _, name := range names {
+			n.Files = append(n.Files, File{
+				Subject:  `"` + name + `" yEnc (1/1)`,
+				Segments: []Segment{{ID: name + "@test", Bytes: 700 << 20, Number: 1}},
+			})
+		}
+		return n
+	}
+	tests := []struct {
+		name  string
+		files []string
+		want  bool
+	}{
+		{"direct", []string{"Movie.2001.1080p.mkv", "Movie.2001.1080p.nfo"}, false},
+		{"rar", []string{"movie.part01.rar", "movie.part02.rar"}, false},
+		{"random names", []string{"a8f7e6d5c4b3a2f1e0d9", "b7e6d5c4b3a2f1e0d9c8", "a8f7e6d5c4b3a2f1e0d9.par2"}, true},
+		{"no files", nil, false},
+	}
+	for _, tt := range tests {
+		if got := build(tt.files...).IsObfuscated(); got != tt.want {
+			t.Errorf("%s: IsObfuscated = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBrokenReason(t *testing.T) {
+	logger.Init("warn")
+	type file struct {
+		name string
+		size int64
+	}
+	build := func(files ...file) *NZB {
+		n := &NZB{}
+		for _, f := range files {
+			n.Files = append(n.Files, File{
+				Subject:  `"` + f.name + `" yEnc (1/1)`,
+				Segments: []Segment{{ID: f.name + "@test", Bytes: f.size, Number: 1}},
+			})
+		}
+		return n
+	}
+	tests := []struct {
+		name   string
+		files  []file
+		broken bool
+	}{
+		{"normal", []file{{"Movie.mkv", 4 << 30}, {"Movie.par2", 1 << 20}, {"Movie.vol00+50.par2", 400 << 20}}, false},
+		{"par2 only", []file{{"Movie.mkv", 200 << 20}, {"Movie.vol00+50.par2", 3 << 30}}, true},
+		{"placeholder", []file{{"Movie.mkv", 2 << 20}, {"Movie.nfo", 1 << 10}}, true},
+		{"small rar volumes", []file{{"movie.part01.rar", 10 << 20}, {"movie.part02.rar", 10 << 20}}, false},
+		{"no content", []file{{"Movie.nfo", 1 << 10}}, false},
+	}
+	for _, tt := range tests {
+		if got := build(tt.files...).BrokenReason(); (got != "") != tt.broken {
+			t.Errorf("%s: BrokenReason = %q; want broken=%v", tt.name, got, tt.broken)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package unpack
 
 import (
 	"context"
+	"errors"
 	"io"
 	"strings"
 
@@ -9,6 +10,13 @@ import (
 	"streamnzb/pkg/media/loader"
 )
 
+// log tags this package's messages; set "unpack" in log_levels to change its level.
+var log = logger.For("unpack")
+
+// ErrEncryptedArchive is returned when a RAR or 7z archive needs a password.
+// Such releases can never be streamed, so callers skip them without a full scan.
+var ErrEncryptedArchive = errors.New("encrypted archive (password required)")
+
 // ReadSeekCloser combines Reader, Seeker and Closer.
 type ReadSeekCloser interface {
 	io.Reader
@@ -19,8 +27,8 @@ type ReadSeekCloser interface {
 // DirectBlueprint caches the result of direct/obfuscated file detection
 // so subsequent play requests skip the full detection pipeline.
 type DirectBlueprint struct {
-	FileName  string
-	FileIndex int
+	FileName  string `json:"file_name"`
+	FileIndex int    `json:"file_index"`
 }
 
 // FailedBlueprint is a sentinel cached after a scan failure so that
@@ -29,6 +37,60 @@ type FailedBlueprint struct {
 	Err error
 }
 
+// selectDirectVideo returns the index of the largest video file, skipping sample and
+// proof files when a larger video exists. -1 when there is no video file.
+func selectDirectVideo(names []string, sizes []int64) int {
+	largest := int64(-1)
+	for i, name := range names {
+		if IsVideoFile(name) && !IsSampleFile(name) && !IsProofFile(name) && sizes[i] > largest {
+			largest = sizes[i]
+		}
+	}
+	best := -1
+	for i, name := range names {
+		if !IsVideoFile(name) {
+			continue
+		}
+		if (IsSampleFile(name) || IsProofFile(name)) && largest > sizes[i] {
+			continue
+		}
+		if best == -1 || sizes[i] > sizes[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// largestDiscImage returns the index of the largest .iso file; -1 when there is none.
+func largestDiscImage(names []string, sizes []int64) int {
+	best := -1
+	for i, name := range names {
+		if IsDiscImage(name) && (best == -1 || sizes[i] > sizes[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// streamDiscImage opens the main title inside a disc image (see ScanISO). Encrypted
+// discs, images without video and file systems over the scan limits are returned as a
+// FailedBlueprint, so replays fail without reading the image again.
+func streamDiscImage(ctx context.Context, image *ArchiveBlueprint) (ReadSeekCloser, string, int64, interface{}, error) {
+	bp, err := ScanISO(ctx, image)
+	if err != nil {
+		log.Warn("Disc image scan failed", "image", image.MainFileName, "err", err)
+		if errors.Is(err, ErrEncryptedDisc) || errors.Is(err, errNoDiscVideo) || errors.Is(err, errDiscTooComplex) {
+			return nil, "", 0, &FailedBlueprint{Err: err}, err
+		}
+		return nil, "", 0, nil, err
+	}
+	s, name, size, err := StreamFromBlueprint(ctx, bp)
+	if err != nil {
+		return nil, "", 0, nil, err
+	}
+	return s, name, size, bp, nil
+}
+
 // GetMediaStream finds a video file inside the provided NZB files and returns
 // a seekable stream. ctx controls the lifetime of the returned stream.
 // cachedBP is an optional cached blueprint to avoid re-scanning headers.
@@ -37,11 +99,11 @@ func GetMediaStream(ctx context.Context, files []*loader.File, cachedBP interfac
 	if cachedBP != nil {
 		switch bp := cachedBP.(type) {
 		case *ArchiveBlueprint:
-			logger.Debug("Using cached RAR blueprint", "file", bp.MainFileName)
+			log.Debug("Using cached RAR blueprint", "file", bp.MainFileName)
 			s, name, size, err := StreamFromBlueprint(ctx, bp)
 			return s, name, size, bp, err
 		case *SevenZipBlueprint:
-			logger.Debug("Using cached 7z blueprint", "file", bp.MainFileName)
+			log.Debug("Using cached 7z blueprint", "file", bp.MainFileName)
 			s, n, sz, err := Open7zStreamFromBlueprint(ctx, bp)
 			return s, n, sz, bp, err
 		case *DirectBlueprint:
@@ -54,7 +116,7 @@ func GetMediaStream(ctx context.Context, files []*loader.File, cachedBP interfac
 				return stream, bp.FileName, f.Size(), bp, nil
 			}
 		case *FailedBlueprint:
-			logger.Debug("Using cached scan failure", "err", bp.Err)
+			log.Debug("Using cached scan failure", "err", bp.Err)
 			return nil, "", 0, bp, bp.Err
 		}
 	}
@@ -73,14 +135,16 @@ func GetMediaStream(ctx context.Context, files []*loader.File, cachedBP interfac
 	}
 
 	if len(rarFiles) > 0 {
-		logger.Info("Detected RAR archive", "volumes", len(rarFiles))
+		log.Info("Detected RAR archive", "volumes", len(rarFiles))
 		unpackables := make([]UnpackableFile, len(files))
 		for i, f := range files {
 			unpackables[i] = f
 		}
 		bp, err := ScanArchive(unpackables)
 		if err != nil {
-			logger.Warn("ScanArchive failed, falling back to other methods", "err", err)
+			log.Warn("ScanArchive failed, falling back to other methods", "err", err)
+		} else if IsDiscImage(bp.MainFileName) {
+			return streamDiscImage(ctx, bp)
 		} else {
 			s, name, size, err := StreamFromBlueprint(ctx, bp)
 			if err != nil {
@@ -94,7 +158,7 @@ func GetMediaStream(ctx context.Context, files []*loader.File, cachedBP interfac
 	for _, f := range files {
 		name := ExtractFilename(f.Name())
 		if strings.HasSuffix(strings.ToLower(name), Ext7z) || strings.Contains(strings.ToLower(name), ".7z.001") {
-			logger.Info("Detected 7z archive", "name", name)
+			log.Info("Detected 7z archive", "name", name)
 			newBp, err := CreateSevenZipBlueprint(files, name)
 			if err != nil {
 				return nil, "", 0, nil, err
@@ -104,54 +168,59 @@ func GetMediaStream(ctx context.Context, files []*loader.File, cachedBP interfac
 		}
 	}
 
-	// 3. Direct video files
+	// 3. Direct video files: the largest, so a sample next to the main feature is not picked.
+	// A larger disc image (4.) holds the feature, e.g. next to a sample clip.
+	names := make([]string, len(files))
+	sizes := make([]int64, len(files))
 	for i, f := range files {
-		name := ExtractFilename(f.Name())
-		if IsVideoFile(name) {
-			stream, err := f.OpenStreamCtx(ctx)
-			if err != nil {
-				return nil, "", 0, nil, err
-			}
-			bp := &DirectBlueprint{FileName: name, FileIndex: i}
-			return stream, name, f.Size(), bp, nil
+		names[i] = ExtractFilename(f.Name())
+		sizes[i] = f.Size()
+	}
+	disc := largestDiscImage(names, sizes)
+	if i := selectDirectVideo(names, sizes); i >= 0 && (disc < 0 || sizes[i] >= sizes[disc]) {
+		f := files[i]
+		stream, err := f.OpenStreamCtx(ctx)
+		if err != nil {
+			return nil, "", 0, nil, err
 		}
+		bp := &DirectBlueprint{FileName: names[i], FileIndex: i}
+		return stream, names[i], f.Size(), bp, nil
+	}
+
+	// 4. Disc images: the main title inside the largest .iso
+	if disc >= 0 {
+		return streamDiscImage(ctx, &ArchiveBlueprint{
+			MainFileName: names[disc],
+			TotalSize:    sizes[disc],
+			Parts:        []VirtualPartDef{{VirtualStart: 0, VirtualEnd: sizes[disc], VolFile: files[disc], VolOffset: 0}},
+		})
 	}
 
-	// 4. Obfuscated / unknown: find largest non-archive file
+	// 5. Obfuscated / unknown: find largest non-archive file
 	var largestFile *loader.File
-	var largestIdx int
-	for i, f := range files {
-		name := strings.ToLower(ExtractFilename(f.Name()))
-		if strings.HasSuffix(name, ExtRar) || strings.Contains(name, ".part") || IsRarPart(name) || IsSplitArchivePart(name) {
-			continue
-		}
-		if strings.HasSuffix(name, ExtPar2) || strings.HasSuffix(name, ExtNzb) || strings.HasSuffix(name, ExtNfo) {
-			continue
-		}
-		if largestFile == nil || f.Size() > largestFile.Size() {
-			largestFile = f
-			largestIdx = i
-		}
+	largestIdx := largestUnknownFile(files)
+	if largestIdx >= 0 {
+		largestFile = files[largestIdx]
 	}
 
 	if largestFile != nil && largestFile.Size() > 50*1024*1024 {
-		logger.Warn("No clear media found, probing largest file", "name", largestFile.Name(), "size", largestFile.Size())
+		log.Warn("No clear media found, probing largest file", "name", largestFile.Name(), "size", largestFile.Size())
 
 		unpackables := make([]UnpackableFile, len(files))
 		for i, f := range files {
 			unpackables[i] = f
 		}
 
-		logger.Info("Attempting heuristic RAR scan on unknown files")
+		log.Info("Attempting heuristic RAR scan on unknown files")
 		bp, err := ScanArchive(unpackables)
 		if err == nil {
-			logger.Info("Heuristic scan found RAR archive")
+			log.Info("Heuristic scan found RAR archive")
 			s, name, size, err := StreamFromBlueprint(ctx, bp)
 			if err == nil {
 				return s, name, size, bp, nil
 			}
 		} else {
-			logger.Warn("Heuristic RAR scan failed, falling back to direct stream", "err", err)
+			log.Warn("Heuristic RAR scan failed, falling back to direct stream", "err", err)
 		}
 
 		extractedName := ExtractFilename(largestFile.Name())
@@ -163,6 +232,6 @@ func GetMediaStream(ctx context.Context, files []*loader.File, cachedBP interfac
 		return stream, extractedName, largestFile.Size(), directBP, nil
 	}
 
-	logger.Warn("GetMediaStream found no suitable media", "files", len(files), "rar_candidates", len(rarFiles))
+	log.Warn("GetMediaStream found no suitable media", "files", len(files), "rar_candidates", len(rarFiles))
 	return nil, "", 0, &FailedBlueprint{Err: io.EOF}, io.EOF
 }
@@ -0,0 +1,91 @@
+package unpack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/loader"
+	"streamnzb/pkg/media/nzb"
+)
+
+// sizedFiles builds NZB files with the given names and segment counts (1000 bytes each).
+func sizedFiles(names []string, segments []int) []*loader.File {
+	files := make([]*loader.File, len(names))
+	for i, name := range names {
+		f := &nzb.File{Subject: fmt.Sprintf(`"%s" yEnc (1/%d)`, name, segments[i])}
+		for n := 1; n <= segments[i]; n++ {
+			f.Segments = append(f.Segments, nzb.Segment{Bytes: 1000, Number: n, ID: fmt.Sprintf("%s-%d", name, n)})
+		}
+		files[i] = loader.NewFile(context.Background(), f, nil, nil)
+	}
+	return files
+}
+
+func TestSelectDirectVideo(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		sizes []int64
+		want  int
+	}{
+		{"sample listed first", []string{"movie.sample.mkv", "movie.mkv", "movie.nfo"}, []int64{50, 4000, 1}, 1},
+		{"proof and sample", []string{"group-proof.mkv", "Sample/movie-sample.mkv", "movie.mkv"}, []int64{10, 60, 5000}, 2},
+		{"proof-named feature", []string{"Death.Proof.2007.1080p.mkv", "extras/trailer.mkv"}, []int64{5000, 100}, 0},
+		{"largest feature wins", []string{"movie.cd1.avi", "movie.cd2.avi"}, []int64{700, 800}, 1},
+		{"only a sample", []string{"movie.sample.mkv", "movie.par2"}, []int64{50, 10}, 0},
+		{"no video", []string{"movie.rar", "movie.nfo"}, []int64{5000, 1}, -1},
+	}
+	for _, tt := range tests {
+		if got := selectDirectVideo(tt.names, tt.sizes); got != tt.want {
+			t.Errorf("%s: selectDirectVideo = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetMediaStreamSkipsSample(t *testing.T) {
+	logger.Init("DEBUG")
+	files := sizedFiles(
+		[]string{"Movie.2020.1080p-GRP.sample.mkv", "Movie.2020.1080p-GRP.mkv", "Movie.2020.1080p-GRP.nfo"},
+		[]int{2, 20, 1},
+	)
+	stream, name, size, bp, err := GetMediaStream(context.Background(), files, nil)
+	if err != nil {
+		t.Fatalf("GetMediaStream failed: %v", err)
+	}
+	defer stream.Close()
+	if name != "Movie.2020.1080p-GRP.mkv" || size != 20000 {
+		t.Errorf("picked %q (%d bytes), want the main feature", name, size)
+	}
+	if direct, ok := bp.(*DirectBlueprint); !ok || direct.FileIndex != 1 {
+		t.Errorf("blueprint = %#v, want DirectBlueprint for file 1", bp)
+	}
+}
+
+func TestRescanNeedsAlternateProvider(t *testing.T) {
+	logger.Init("DEBUG")
+	vol := sizedFiles([]string{"movie.part01.rar"}, []int{2})[0]
+	// Without a second provider there is nothing to retry the header scan on
+	if parts, ok := rescanOnAlternateProvider(vol); ok || parts != nil {
+		t.Errorf("rescanOnAlternateProvider = %v, %v; want no retry", parts, ok)
+	}
+}
+
+func TestReplaceVolumeParts(t *testing.T) {
+	vols := sizedFiles([]string{"a.part01.rar", "b.part01.rar"}, []int{1, 1})
+	parts := []filePart{
+		{name: "a.mkv", volFile: vols[0]},
+		{name: "b.mkv", volFile: vols[1]},
+		{name: "a.nfo", volFile: vols[0]},
+	}
+	got := replaceVolumeParts(parts, vols[0], []filePart{{name: "a-rescanned.mkv", volFile: vols[0]}})
+	var names []string
+	for _, p := range got {
+		names = append(names, p.name)
+	}
+	if want := "b.mkv,a-rescanned.mkv"; strings.Join(names, ",") != want {
+		t.Errorf("parts = %v; want %s", names, want)
+	}
+}
@@ -0,0 +1,301 @@
+package unpack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/media/loader"
+)
+
+// blueprintFormatVersion is bumped whenever the on-disk layout changes; older
+// entries are ignored and rewritten on the next scan.
+const blueprintFormatVersion = 1
+
+// ErrBlueprintNotPersistable is returned for blueprints that reference files
+// outside the NZB file list (e.g. nested archives) or carry no reusable state.
+var ErrBlueprintNotPersistable = errors.New("blueprint cannot be persisted")
+
+// blueprintRecord is the on-disk form of a blueprint. Volume references are
+// stored as indices into the session's file list and resolved on load.
+type blueprintRecord struct {
+	Version     int       `json:"version"`
+	Fingerprint string    `json:"fingerprint"`
+	SavedAt     time.Time `json:"saved_at"`
+
+	RAR        *ArchiveBlueprint `json:"rar,omitempty"`
+	RARVolumes []int             `json:"rar_volumes,omitempty"` // file index per RAR part
+
+	SevenZip      *SevenZipBlueprint `json:"sevenzip,omitempty"`
+	SevenZipFiles []int              `json:"sevenzip_files,omitempty"`
+
+	Direct *DirectBlueprint `json:"direct,omitempty"`
+}
+
+// BlueprintFingerprint identifies the exact file list a blueprint was built from.
+// A cached blueprint is only reused when the fingerprint matches.
+func BlueprintFingerprint(files []*loader.File) string {
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\x00%d\n", f.Name(), f.SegmentCount())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MarshalBlueprint encodes a RAR, 7z or direct blueprint built from files.
+func MarshalBlueprint(bp interface{}, files []*loader.File) ([]byte, error) {
+	index := make(map[UnpackableFile]int, len(files))
+	for i, f := range files {
+		index[f] = i
+	}
+
+	rec := blueprintRecord{
+		Version:     blueprintFormatVersion,
+		Fingerprint: BlueprintFingerprint(files),
+		SavedAt:     time.Now(),
+	}
+	switch bp := bp.(type) {
+	case *ArchiveBlueprint:
+		rec.RAR = bp
+		rec.RARVolumes = make([]int, len(bp.Parts))
+		for i, p := range bp.Parts {
+			idx, ok := index[p.VolFile]
+			if !ok {
+				return nil, ErrBlueprintNotPersistable
+			}
+			rec.RARVolumes[i] = idx
+		}
+	case *SevenZipBlueprint:
+		rec.SevenZip = bp
+		rec.SevenZipFiles = make([]int, len(bp.Files))
+		for i, f := range bp.Files {
+			idx, ok := index[f]
+			if !ok {
+				return nil, ErrBlueprintNotPersistable
+			}
+			rec.SevenZipFiles[i] = idx
+		}
+	case *DirectBlueprint:
+		rec.Direct = bp
+	default:
+		return nil, ErrBlueprintNotPersistable
+	}
+	return json.Marshal(rec)
+}
+
+// UnmarshalBlueprint decodes a blueprint written by MarshalBlueprint and binds it
+// to files. It fails when files differ from the list the blueprint was built from.
+func UnmarshalBlueprint(data []byte, files []*loader.File) (interface{}, error) {
+	var rec blueprintRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	if rec.Version != blueprintFormatVersion {
+		return nil, fmt.Errorf("unsupported blueprint version %d", rec.Version)
+	}
+	if rec.Fingerprint != BlueprintFingerprint(files) {
+		return nil, errors.New("blueprint does not match NZB files")
+	}
+
+	fileAt := func(idx int) (*loader.File, error) {
+		if idx < 0 || idx >= len(files) {
+			return nil, fmt.Errorf("blueprint file index %d out of range", idx)
+		}
+		return files[idx], nil
+	}
+
+	switch {
+	case rec.RAR != nil:
+		if len(rec.RARVolumes) != len(rec.RAR.Parts) {
+			return nil, errors.New("blueprint volume map is incomplete")
+		}
+		for i := range rec.RAR.Parts {
+			f, err := fileAt(rec.RARVolumes[i])
+			if err != nil {
+				return nil, err
+			}
+			rec.RAR.Parts[i].VolFile = f
+		}
+		return rec.RAR, nil
+	case rec.SevenZip != nil:
+		rec.SevenZip.Files = make([]*loader.File, len(rec.SevenZipFiles))
+		for i, idx := range rec.SevenZipFiles {
+			f, err := fileAt(idx)
+			if err != nil {
+				return nil, err
+			}
+			rec.SevenZip.Files[i] = f
+		}
+		return rec.SevenZip, nil
+	case rec.Direct != nil:
+		if _, err := fileAt(rec.Direct.FileIndex); err != nil {
+			return nil, err
+		}
+		return rec.Direct, nil
+	}
+	return nil, errors.New("empty blueprint record")
+}
+
+// BlueprintCache persists blueprints on disk (one JSON file per NZB hash) so a
+// replayed release skips archive header scanning after a restart.
+type BlueprintCache struct {
+	dir        string
+	maxEntries int
+	maxAge     time.Duration
+	mu         sync.Mutex
+}
+
+// NewBlueprintCache creates the cache directory and drops expired entries.
+// maxEntries <= 0 or maxAge <= 0 disables the respective cap.
+func NewBlueprintCache(dir string, maxEntries int, maxAge time.Duration) (*BlueprintCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blueprint cache dir: %w", err)
+	}
+	c := &BlueprintCache{dir: dir, maxEntries: maxEntries, maxAge: maxAge}
+	c.mu.Lock()
+	c.pruneLocked()
+	c.mu.Unlock()
+	return c, nil
+}
+
+func (c *BlueprintCache) path(key string) (string, bool) {
+	if key == "" || strings.ContainsAny(key, `/\.`) {
+		return "", false
+	}
+	return filepath.Join(c.dir, key+".json"), true
+}
+
+// Load returns the cached blueprint for key bound to files, or nil when there is
+// no usable entry. Stale or mismatching entries are removed.
+func (c *BlueprintCache) Load(key string, files []*loader.File) interface{} {
+	p, ok := c.path(key)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil
+	}
+	if c.maxAge > 0 && time.Since(info.ModTime()) > c.maxAge {
+		os.Remove(p)
+		return nil
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	bp, err := UnmarshalBlueprint(data, files)
+	if err != nil {
+		log.Debug("Discarding cached blueprint", "key", key, "err", err)
+		os.Remove(p)
+		return nil
+	}
+	log.Debug("Loaded blueprint from disk", "key", key)
+	return bp
+}
+
+// Store writes bp for key. Blueprints that cannot be persisted are skipped silently.
+func (c *BlueprintCache) Store(key string, files []*loader.File, bp interface{}) {
+	p, ok := c.path(key)
+	if !ok {
+		return
+	}
+	data, err := MarshalBlueprint(bp, files)
+	if err != nil {
+		if !errors.Is(err, ErrBlueprintNotPersistable) {
+			log.Debug("Failed to encode blueprint", "key", key, "err", err)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Warn("Failed to write blueprint cache", "key", key, "err", err)
+		return
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		log.Warn("Failed to write blueprint cache", "key", key, "err", err)
+		return
+	}
+	c.pruneLocked()
+}
+
+// Remove deletes the entry for key, e.g. a blueprint that produced a corrupt stream.
+func (c *BlueprintCache) Remove(key string) {
+	p, ok := c.path(key)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	os.Remove(p)
+}
+
+// pruneLocked removes entries older than maxAge, then the oldest entries beyond maxEntries.
+func (c *BlueprintCache) pruneLocked() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type entry struct {
+		path    string
+		modTime time.Time
+	}
+	var kept []entry
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		p := filepath.Join(c.dir, e.Name())
+		if c.maxAge > 0 && time.Since(info.ModTime()) > c.maxAge {
+			os.Remove(p)
+			continue
+		}
+		kept = append(kept, entry{path: p, modTime: info.ModTime()})
+	}
+	if c.maxEntries <= 0 || len(kept) <= c.maxEntries {
+		return
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].modTime.Before(kept[j].modTime) })
+	for _, e := range kept[:len(kept)-c.maxEntries] {
+		os.Remove(e.path)
+	}
+}
+
+// Clear removes all cached blueprints and returns how many were removed.
+func (c *BlueprintCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if os.Remove(filepath.Join(c.dir, e.Name())) == nil {
+			n++
+		}
+	}
+	return n
+}
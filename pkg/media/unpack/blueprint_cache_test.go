@@ -0,0 +1,194 @@
+package unpack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/loader"
+	"streamnzb/pkg/media/nzb"
+)
+
+func testFiles(names ...string) []*loader.File {
+	files := make([]*loader.File, len(names))
+	for i, name := range names {
+		f := &nzb.File{
+			Subject:  fmt.Sprintf(`"%s" yEnc (1/2)`, name),
+			Segments: []nzb.Segment{{Bytes: 1000, Number: 1, ID: name + "-1"}, {Bytes: 1000, Number: 2, ID: name + "-2"}},
+		}
+		files[i] = loader.NewFile(context.Background(), f, nil, nil)
+	}
+	return files
+}
+
+func TestArchiveBlueprintRoundTrip(t *testing.T) {
+	files := testFiles("movie.part1.rar", "movie.part2.rar", "movie.par2")
+	bp := &ArchiveBlueprint{
+		MainFileName: "movie.mkv",
+		TotalSize:    1800,
+		Parts: []VirtualPartDef{
+			{VirtualStart: 0, VirtualEnd: 900, VolFile: files[0], VolOffset: 100},
+			{VirtualStart: 900, VirtualEnd: 1800, VolFile: files[1], VolOffset: 80},
+		},
+	}
+
+	data, err := MarshalBlueprint(bp, files)
+	if err != nil {
+		t.Fatalf("MarshalBlueprint failed: %v", err)
+	}
+	got, err := UnmarshalBlueprint(data, files)
+	if err != nil {
+		t.Fatalf("UnmarshalBlueprint failed: %v", err)
+	}
+	rar, ok := got.(*ArchiveBlueprint)
+	if !ok {
+		t.Fatalf("expected *ArchiveBlueprint, got %T", got)
+	}
+	if rar.MainFileName != bp.MainFileName || rar.TotalSize != bp.TotalSize || len(rar.Parts) != 2 {
+		t.Fatalf("blueprint mismatch: %+v", rar)
+	}
+	for i, p := range rar.Parts {
+		want := bp.Parts[i]
+		if p.VirtualStart != want.VirtualStart || p.VirtualEnd != want.VirtualEnd || p.VolOffset != want.VolOffset {
+			t.Errorf("part %d: got %+v, want %+v", i, p, want)
+		}
+		if p.VolFile != want.VolFile {
+			t.Errorf("part %d: volume not rebound to the same file", i)
+		}
+	}
+}
+
+func TestSevenZipAndDirectBlueprintRoundTrip(t *testing.T) {
+	files := testFiles("movie.7z.001", "movie.7z.002")
+	sz := &SevenZipBlueprint{MainFileName: "movie.mkv", TotalSize: 1900, FileOffset: 32, Files: files}
+
+	data, err := MarshalBlueprint(sz, files)
+	if err != nil {
+		t.Fatalf("MarshalBlueprint failed: %v", err)
+	}
+	got, err := UnmarshalBlueprint(data, files)
+	if err != nil {
+		t.Fatalf("UnmarshalBlueprint failed: %v", err)
+	}
+	szGot, ok := got.(*SevenZipBlueprint)
+	if !ok || szGot.FileOffset != 32 || szGot.TotalSize != 1900 || len(szGot.Files) != 2 || szGot.Files[1] != files[1] {
+		t.Fatalf("7z blueprint mismatch: %+v", got)
+	}
+
+	direct := &DirectBlueprint{FileName: "movie.mkv", FileIndex: 1}
+	data, err = MarshalBlueprint(direct, files)
+	if err != nil {
+		t.Fatalf("MarshalBlueprint failed: %v", err)
+	}
+	got, err = UnmarshalBlueprint(data, files)
+	if err != nil {
+		t.Fatalf("UnmarshalBlueprint failed: %v", err)
+	}
+	if d, ok := got.(*DirectBlueprint); !ok || *d != *direct {
+		t.Fatalf("direct blueprint mismatch: %+v", got)
+	}
+}
+
+func TestBlueprintNotPersistable(t *testing.T) {
+	files := testFiles("movie.rar")
+	nested := &ArchiveBlueprint{
+		MainFileName: "movie.mkv",
+		Parts:        []VirtualPartDef{{VolFile: NewVirtualFile("inner.rar", 10, nil)}},
+	}
+	if _, err := MarshalBlueprint(nested, files); err != ErrBlueprintNotPersistable {
+		t.Errorf("nested archive: expected ErrBlueprintNotPersistable, got %v", err)
+	}
+	if _, err := MarshalBlueprint(&FailedBlueprint{Err: os.ErrNotExist}, files); err != ErrBlueprintNotPersistable {
+		t.Errorf("failed blueprint: expected ErrBlueprintNotPersistable, got %v", err)
+	}
+}
+
+func TestBlueprintCacheInvalidation(t *testing.T) {
+	logger.Init("DEBUG")
+	dir := t.TempDir()
+	cache, err := NewBlueprintCache(dir, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("NewBlueprintCache failed: %v", err)
+	}
+
+	files := testFiles("movie.mkv")
+	cache.Store("abc123", files, &DirectBlueprint{FileName: "movie.mkv", FileIndex: 0})
+
+	if bp := cache.Load("abc123", files); bp == nil {
+		t.Fatal("expected cached blueprint")
+	}
+
+	// Same key, different file list: entry is discarded
+	other := testFiles("other.mkv")
+	if bp := cache.Load("abc123", other); bp != nil {
+		t.Fatalf("expected nil for mismatching files, got %+v", bp)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "abc123.json")); !os.IsNotExist(err) {
+		t.Errorf("expected stale entry to be removed, stat err=%v", err)
+	}
+
+	if bp := cache.Load("../escape", files); bp != nil {
+		t.Error("expected invalid key to be rejected")
+	}
+}
+
+func TestBlueprintCacheCaps(t *testing.T) {
+	logger.Init("DEBUG")
+	dir := t.TempDir()
+	cache, err := NewBlueprintCache(dir, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("NewBlueprintCache failed: %v", err)
+	}
+
+	files := testFiles("movie.mkv")
+	bp := &DirectBlueprint{FileName: "movie.mkv"}
+	for i, key := range []string{"a", "b", "c"} {
+		cache.Store(key, files, bp)
+		// Give each entry a distinct mtime so eviction order is deterministic
+		mtime := time.Now().Add(time.Duration(i-3) * time.Minute)
+		os.Chtimes(filepath.Join(dir, key+".json"), mtime, mtime)
+	}
+	cache.mu.Lock()
+	cache.pruneLocked()
+	cache.mu.Unlock()
+
+	if cache.Load("a", files) != nil {
+		t.Error("expected oldest entry to be evicted")
+	}
+	if cache.Load("b", files) == nil || cache.Load("c", files) == nil {
+		t.Error("expected newest entries to be kept")
+	}
+
+	// Expired entries are dropped on load
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(filepath.Join(dir, "c.json"), old, old)
+	if cache.Load("c", files) != nil {
+		t.Error("expected expired entry to be ignored")
+	}
+}
+
+func TestBlueprintCacheClear(t *testing.T) {
+	cache, err := NewBlueprintCache(t.TempDir(), 10, time.Hour)
+	if err != nil {
+		t.Fatalf("NewBlueprintCache failed: %v", err)
+	}
+	files := testFiles("movie.mkv")
+	cache.Store("a", files, &DirectBlueprint{FileName: "movie.mkv"})
+	cache.Store("b", files, &DirectBlueprint{FileName: "movie.mkv"})
+	cache.Store("c", files, &DirectBlueprint{FileName: "movie.mkv"})
+
+	cache.Remove("c")
+	if bp := cache.Load("c", files); bp != nil {
+		t.Error("blueprint still cached after Remove")
+	}
+	if n := cache.Clear(); n != 2 {
+		t.Errorf("Clear removed %d entries, want 2", n)
+	}
+	if bp := cache.Load("a", files); bp != nil {
+		t.Error("blueprint still cached after Clear")
+	}
+}
@@ -0,0 +1,259 @@
+package unpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"streamnzb/pkg/media/loader"
+)
+
+// Players read the seek index (MP4 moov, MKV Cues) before starting playback. When a
+// direct file stores it at the end, the first play has to fetch the tail from Usenet
+// first; probing the head lets us start that download right away.
+
+const (
+	indexProbeSize      = 64 * 1024
+	tailPrewarmSegments = 4
+)
+
+const (
+	ebmlIDHeader    = 0x1A45DFA3
+	ebmlIDSegment   = 0x18538067
+	ebmlIDSeekHead  = 0x114D9B74
+	ebmlIDSeek      = 0x4DBB
+	ebmlIDSeekID    = 0x53AB
+	ebmlIDSeekPos   = 0x53AC
+	ebmlIDCues      = 0x1C53BB6B
+	ebmlIDCluster   = 0x1F43B675
+	ebmlUnknownSize = -1
+)
+
+// indexLayout describes where a media file keeps its seek index.
+type indexLayout struct {
+	format    string // "mp4", "mkv" or "" when not recognized
+	atEnd     bool
+	tailStart int64 // offset the index starts at, when known (MKV); 0 otherwise
+}
+
+// detectIndexLayout inspects the first bytes of a file of the given size.
+// A layout with an empty format means the index position could not be determined.
+func detectIndexLayout(head []byte, size int64) indexLayout {
+	if len(head) >= 8 && string(head[4:8]) == "ftyp" {
+		return mp4IndexLayout(head)
+	}
+	if len(head) >= 4 && binary.BigEndian.Uint32(head) == ebmlIDHeader {
+		return mkvIndexLayout(head, size)
+	}
+	return indexLayout{}
+}
+
+// mp4IndexLayout walks the top-level boxes: moov before mdat is fast-start.
+func mp4IndexLayout(head []byte) indexLayout {
+	var off int64
+	for off+8 <= int64(len(head)) {
+		boxSize := int64(binary.BigEndian.Uint32(head[off:]))
+		boxType := string(head[off+4 : off+8])
+		switch boxType {
+		case "moov":
+			return indexLayout{format: "mp4"}
+		case "mdat":
+			return indexLayout{format: "mp4", atEnd: true}
+		}
+		switch boxSize {
+		case 0: // box extends to the end of the file
+			return indexLayout{}
+		case 1:
+			if off+16 > int64(len(head)) {
+				return indexLayout{}
+			}
+			boxSize = int64(binary.BigEndian.Uint64(head[off+8:]))
+		}
+		if boxSize < 8 {
+			return indexLayout{}
+		}
+		off += boxSize
+	}
+	return indexLayout{}
+}
+
+// mkvIndexLayout finds the Cues position through the SeekHead of the Segment.
+func mkvIndexLayout(head []byte, size int64) indexLayout {
+	r := bytes.NewReader(head)
+	id, n, ok := readEBMLElement(r)
+	if !ok || id != ebmlIDHeader || n == ebmlUnknownSize {
+		return indexLayout{}
+	}
+	if _, err := r.Seek(n, io.SeekCurrent); err != nil {
+		return indexLayout{}
+	}
+	id, _, ok = readEBMLElement(r)
+	if !ok || id != ebmlIDSegment {
+		return indexLayout{}
+	}
+	segmentStart := int64(len(head)) - int64(r.Len())
+
+	for {
+		id, n, ok := readEBMLElement(r)
+		if !ok || n == ebmlUnknownSize || n > int64(r.Len()) {
+			return indexLayout{}
+		}
+		switch id {
+		case ebmlIDCues:
+			return indexLayout{format: "mkv"}
+		case ebmlIDCluster:
+			return indexLayout{} // media data without an index seen so far
+		case ebmlIDSeekHead:
+			body := make([]byte, n)
+			r.Read(body)
+			pos, found := cuesSeekPosition(body)
+			if !found {
+				return indexLayout{}
+			}
+			cues := segmentStart + pos
+			return indexLayout{format: "mkv", atEnd: cues > size/2, tailStart: cues}
+		default:
+			if _, err := r.Seek(n, io.SeekCurrent); err != nil {
+				return indexLayout{}
+			}
+		}
+	}
+}
+
+// cuesSeekPosition returns the SeekPosition of the Cues entry in a SeekHead body.
+func cuesSeekPosition(seekHead []byte) (int64, bool) {
+	r := bytes.NewReader(seekHead)
+	for r.Len() > 0 {
+		id, n, ok := readEBMLElement(r)
+		if !ok || n < 0 || n > int64(r.Len()) {
+			return 0, false
+		}
+		body := make([]byte, n)
+		r.Read(body)
+		if id != ebmlIDSeek {
+			continue
+		}
+		var seekID, seekPos int64 = 0, -1
+		sr := bytes.NewReader(body)
+		for sr.Len() > 0 {
+			cid, cn, ok := readEBMLElement(sr)
+			if !ok || cn < 0 || cn > int64(sr.Len()) || cn > 8 {
+				return 0, false
+			}
+			var v int64
+			for i := int64(0); i < cn; i++ {
+				b, _ := sr.ReadByte()
+				v = v<<8 | int64(b)
+			}
+			switch cid {
+			case ebmlIDSeekID:
+				seekID = v
+			case ebmlIDSeekPos:
+				seekPos = v
+			}
+		}
+		if seekID == ebmlIDCues && seekPos >= 0 {
+			return seekPos, true
+		}
+	}
+	return 0, false
+}
+
+// readEBMLElement reads an element ID (marker bits kept) and its data size
+// (ebmlUnknownSize for the reserved all-ones value).
+func readEBMLElement(r *bytes.Reader) (id int64, size int64, ok bool) {
+	id, _, ok = readVint(r, true)
+	if !ok {
+		return 0, 0, false
+	}
+	size, unknown, ok := readVint(r, false)
+	if !ok {
+		return 0, 0, false
+	}
+	if unknown {
+		size = ebmlUnknownSize
+	}
+	return id, size, true
+}
+
+func readVint(r *bytes.Reader, keepMarker bool) (v int64, allOnes bool, ok bool) {
+	first, err := r.ReadByte()
+	if err != nil || first == 0 {
+		return 0, false, false
+	}
+	length := 1
+	for mask := byte(0x80); first&mask == 0; mask >>= 1 {
+		length++
+	}
+	if keepMarker {
+		v = int64(first)
+	} else {
+		v = int64(first & (0xFF >> length))
+	}
+	allOnes = v == int64(0xFF>>length)
+	for i := 1; i < length; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, false, false
+		}
+		allOnes = allOnes && b == 0xFF
+		v = v<<8 | int64(b)
+	}
+	return v, allOnes && !keepMarker, true
+}
+
+// tailPrewarmer is satisfied by loader.File.
+type tailPrewarmer interface {
+	segmentPrewarmer
+	io.ReaderAt
+	Size() int64
+	FindSegmentIndex(offset int64) int
+}
+
+// PrewarmTrailingIndex starts the tail prefetch of the file played through blueprint when
+// it is a direct file (see prewarmTrailingIndex). Archives are left alone. Call it for
+// playback only: listings and other opens of GetMediaStream never read the index.
+func PrewarmTrailingIndex(files []*loader.File, blueprint interface{}) {
+	bp, ok := blueprint.(*DirectBlueprint)
+	if !ok || bp.FileIndex < 0 || bp.FileIndex >= len(files) {
+		return
+	}
+	prewarmTrailingIndex(files[bp.FileIndex], bp.FileName)
+}
+
+// prewarmTrailingIndex probes a direct media file in the background and, when its seek
+// index is stored at the end, downloads the tail segments before the player asks for them.
+func prewarmTrailingIndex(f tailPrewarmer, name string) {
+	go func() {
+		size := f.Size()
+		head := make([]byte, min(int64(indexProbeSize), size))
+		n, err := f.ReadAt(head, 0)
+		if err != nil && err != io.EOF {
+			log.Debug("Media index probe failed", "file", name, "err", err)
+			return
+		}
+		layout := detectIndexLayout(head[:n], size)
+		if layout.format == "" {
+			log.Debug("Media index position unknown", "file", name)
+			return
+		}
+		if !layout.atEnd {
+			log.Debug("Media index at start of file (fast start)", "file", name, "format", layout.format)
+			return
+		}
+
+		// MP4: the moov size is unknown, fetch the last segments. MKV: start at the Cues.
+		count := f.SegmentCount()
+		first, last := max(count-tailPrewarmSegments, 0), count
+		if layout.tailStart > 0 {
+			if idx := f.FindSegmentIndex(layout.tailStart); idx >= 0 {
+				first, last = idx, min(idx+tailPrewarmSegments, count)
+			}
+		}
+		log.Debug("Media index is at the end of the file; the first play needs the tail, prefetching it",
+			"file", name, "format", layout.format, "segments", last-first)
+		for i := first; i < last; i++ {
+			f.PrewarmSegment(i)
+		}
+	}()
+}
@@ -0,0 +1,54 @@
+package unpack
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func mp4Box(typ string, payload int) []byte {
+	b := make([]byte, 8+payload)
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	copy(b[4:], typ)
+	return b
+}
+
+// ebml encodes an element with a 1-byte size (payload < 127 bytes).
+func ebml(id []byte, payload ...byte) []byte {
+	return append(append(append([]byte{}, id...), 0x80|byte(len(payload))), payload...)
+}
+
+func mkvHead(cuesPos byte) []byte {
+	head := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebml([]byte{0x42, 0x82}, 'w', 'e', 'b', 'm')...)
+	head = append(head, 0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF) // Segment, unknown size
+	head = append(head, ebml([]byte{0xEC}, 0, 0, 0)...)                                         // Void
+	seek := append(ebml([]byte{0x53, 0xAB}, 0x1C, 0x53, 0xBB, 0x6B), ebml([]byte{0x53, 0xAC}, cuesPos)...)
+	head = append(head, ebml([]byte{0x11, 0x4D, 0x9B, 0x74}, ebml([]byte{0x4D, 0xBB}, seek...)...)...)
+	return append(head, ebml([]byte{0x1F, 0x43, 0xB6, 0x75}, 0)...) // Cluster
+}
+
+func TestDetectIndexLayout(t *testing.T) {
+	fastMP4 := append(append(mp4Box("ftyp", 16), mp4Box("moov", 100)...), mp4Box("mdat", 10)...)
+	slowMP4 := append(mp4Box("ftyp", 16), mp4Box("mdat", 10)...)
+
+	tests := []struct {
+		name      string
+		head      []byte
+		size      int64
+		format    string
+		atEnd     bool
+		tailStart bool
+	}{
+		{"mp4 fast start", fastMP4, 1000, "mp4", false, false},
+		{"mp4 moov at end", slowMP4, 1000, "mp4", true, false},
+		{"mkv cues at end", mkvHead(0x70), 150, "mkv", true, true},
+		{"mkv cues near start", mkvHead(0x10), 10000, "mkv", false, true},
+		{"avi", []byte("RIFF\x00\x00\x00\x00AVI LIST"), 1000, "", false, false},
+		{"truncated mp4", fastMP4[:30], 1000, "", false, false},
+	}
+	for _, tt := range tests {
+		got := detectIndexLayout(tt.head, tt.size)
+		if got.format != tt.format || got.atEnd != tt.atEnd || (got.tailStart > 0) != tt.tailStart {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+	}
+}
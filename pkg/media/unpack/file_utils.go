@@ -91,6 +91,11 @@ func IsSampleFile(name string) bool {
 	return strings.Contains(strings.ToLower(name), "sample")
 }
 
+// IsProofFile reports proof clips some groups ship next to the release (e.g. "group-proof.mkv").
+func IsProofFile(name string) bool {
+	return strings.Contains(strings.ToLower(name), "proof")
+}
+
 // IsRarPart returns true for .rNN extensions (e.g. .r01, .r99).
 func IsRarPart(name string) bool {
 	if len(name) < 4 {
@@ -0,0 +1,689 @@
+package unpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Disc images (.iso) hold a file system, not a playable stream. Remuxes are shipped as
+// Blu-ray (UDF, BDMV/STREAM/*.m2ts) or DVD (UDF + ISO9660, VIDEO_TS/*.VOB) images; the
+// largest title inside is streamed through a virtual mapping onto the image bytes.
+
+const (
+	isoSectorSize   = 2048
+	discMaxDepth    = 8
+	discMaxFiles    = 10000
+	discMaxDirBytes = 4 << 20
+	// Budget for one scan: directories walked and bytes read for the file system
+	discMaxDirs      = 4096
+	discMaxScanBytes = 64 << 20
+	// Blu-ray AACS encrypts aligned units of 32 source packets
+	aacsUnitSize = 6144
+)
+
+// ErrEncryptedDisc is returned for disc images whose video is AACS or CSS encrypted.
+var ErrEncryptedDisc = errors.New("encrypted disc image (AACS/CSS): decrypt before posting")
+
+// errNoDiscVideo is returned when a disc image holds no .m2ts, .vob or .mkv file.
+var errNoDiscVideo = errors.New("no video file in disc image")
+
+// errDiscTooComplex is returned when a file system needs more directories or reads than
+// a real disc (or loops back on itself beyond what the visited set catches).
+var errDiscTooComplex = errors.New("disc image file system exceeds the scan limits")
+
+// discFile is a file inside a disc image and the byte extents it occupies in the image.
+type discFile struct {
+	path    string
+	size    int64
+	extents []discExtent
+}
+
+type discExtent struct {
+	offset, length int64
+}
+
+// discScan is one walk of a disc image's file system. Each directory is walked once
+// (directory records may point back at their ancestors or share an extent), and the
+// number of directories and bytes read are capped.
+type discScan struct {
+	ctx     context.Context
+	img     UnpackableFile
+	files   []discFile
+	visited map[discDir]bool
+	dirs    int
+	read    int64
+}
+
+// discDir identifies a directory: its partition reference (UDF) and location.
+type discDir struct {
+	ref uint16
+	loc int64
+}
+
+func newDiscScan(ctx context.Context, img UnpackableFile) *discScan {
+	return &discScan{ctx: ctx, img: img, visited: make(map[discDir]bool)}
+}
+
+// readAt reads n bytes at off against the scan's byte budget.
+func (s *discScan) readAt(off int64, n int) ([]byte, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.read += int64(n); s.read > discMaxScanBytes {
+		return nil, errDiscTooComplex
+	}
+	return readImage(s.img, off, n)
+}
+
+// enter reports whether the directory at loc should be walked: false when it was walked
+// already, an error once the scan has walked discMaxDirs directories.
+func (s *discScan) enter(ref uint16, loc int64) (bool, error) {
+	key := discDir{ref: ref, loc: loc}
+	if s.visited[key] {
+		return false, nil
+	}
+	s.visited[key] = true
+	if s.dirs++; s.dirs > discMaxDirs {
+		return false, errDiscTooComplex
+	}
+	return true, nil
+}
+
+// IsDiscImage reports whether name is a disc image GetMediaStream can look into.
+func IsDiscImage(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ExtIso)
+}
+
+// ScanISO locates the main title of the disc image mapped by image (a RAR blueprint, or
+// one part covering a direct .iso file) and returns a blueprint mapping it onto the same
+// volumes. UDF is read when present, else ISO9660. DVD titles split over VTS_nn_k.VOB
+// files are joined. Encrypted discs fail with ErrEncryptedDisc. ctx cancels the scan.
+func ScanISO(ctx context.Context, image *ArchiveBlueprint) (*ArchiveBlueprint, error) {
+	parts := make([]virtualPart, len(image.Parts))
+	for i, p := range image.Parts {
+		parts[i] = virtualPart(p)
+	}
+	img := NewVirtualFile(image.MainFileName, image.TotalSize, parts)
+
+	files, err := listUDF(ctx, img)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		log.Debug("No UDF file system, reading ISO9660", "image", image.MainFileName, "err", err)
+		if files, err = listISO9660(ctx, img); err != nil {
+			return nil, fmt.Errorf("disc image %s: %w", image.MainFileName, err)
+		}
+	}
+	title := selectDiscTitle(files)
+	if title == nil {
+		return nil, fmt.Errorf("disc image %s: %w", image.MainFileName, errNoDiscVideo)
+	}
+	if discEncrypted(img, title) {
+		return nil, fmt.Errorf("%w: %s", ErrEncryptedDisc, image.MainFileName)
+	}
+
+	bp := &ArchiveBlueprint{MainFileName: title.path, TotalSize: title.size}
+	var pos int64
+	for _, e := range title.extents {
+		bp.Parts = append(bp.Parts, mapImageRange(image.Parts, e.offset, e.length, pos)...)
+		pos += e.length
+	}
+	if pos != title.size {
+		return nil, fmt.Errorf("disc image %s: %s extends past the image", image.MainFileName, title.path)
+	}
+	log.Info("Found title in disc image", "image", image.MainFileName, "file", title.path, "size", title.size, "extents", len(title.extents))
+	return bp, nil
+}
+
+// mapImageRange maps length bytes at offset in the image onto the image parts, placing
+// them at virtStart in the new virtual file.
+func mapImageRange(parts []VirtualPartDef, offset, length, virtStart int64) []VirtualPartDef {
+	var mapped []VirtualPartDef
+	end := offset + length
+	for _, p := range parts {
+		lo, hi := max(offset, p.VirtualStart), min(end, p.VirtualEnd)
+		if lo >= hi {
+			continue
+		}
+		mapped = append(mapped, VirtualPartDef{
+			VirtualStart: virtStart + lo - offset,
+			VirtualEnd:   virtStart + hi - offset,
+			VolFile:      p.VolFile,
+			VolOffset:    p.VolOffset + lo - p.VirtualStart,
+		})
+	}
+	return mapped
+}
+
+// selectDiscTitle returns the largest .m2ts or .mkv file, or DVD title set (its
+// VTS_nn_1..9.VOB files joined), whichever is larger. nil when there is none.
+func selectDiscTitle(files []discFile) *discFile {
+	var best *discFile
+	titleSets := make(map[string][]discFile)
+	for i, f := range files {
+		name := strings.ToLower(path.Base(f.path))
+		switch {
+		case strings.HasSuffix(name, ExtVob):
+			// VTS_nn_0.VOB is the title set menu
+			if len(name) == len("vts_01_1.vob") && strings.HasPrefix(name, "vts_") && name[7] != '0' {
+				set := strings.ToLower(path.Dir(f.path)) + "/" + name[:6]
+				titleSets[set] = append(titleSets[set], f)
+			} else if best == nil || f.size > best.size {
+				best = &files[i]
+			}
+		case strings.HasSuffix(name, ExtM2ts) || strings.HasSuffix(name, ExtMkv):
+			if best == nil || f.size > best.size {
+				best = &files[i]
+			}
+		}
+	}
+	for _, vobs := range titleSets {
+		sort.Slice(vobs, func(i, j int) bool { return vobs[i].path < vobs[j].path })
+		joined := discFile{path: vobs[0].path}
+		for _, v := range vobs {
+			joined.size += v.size
+			joined.extents = append(joined.extents, v.extents...)
+		}
+		if best == nil || joined.size > best.size {
+			best = &joined
+		}
+	}
+	return best
+}
+
+// discEncrypted checks the start of the title: Blu-ray units with the AACS copy
+// permission bits set, or DVD packs with the PES scrambling bits set (CSS).
+func discEncrypted(img UnpackableFile, title *discFile) bool {
+	if len(title.extents) == 0 {
+		return false
+	}
+	e := title.extents[0]
+	name := strings.ToLower(title.path)
+	if strings.HasSuffix(name, ExtM2ts) {
+		buf := make([]byte, min(e.length, 4*aacsUnitSize))
+		n, _ := img.ReadAt(buf, e.offset)
+		for off := 0; off+5 <= n; off += aacsUnitSize {
+			if buf[off+4] == 0x47 && buf[off]&0xc0 != 0 {
+				return true
+			}
+		}
+		return false
+	}
+	if strings.HasSuffix(name, ExtVob) {
+		buf := make([]byte, min(e.length, 64*isoSectorSize))
+		n, _ := img.ReadAt(buf, e.offset)
+		for off := 0; off+isoSectorSize <= n; off += isoSectorSize {
+			sector := buf[off:]
+			// MPEG-2 pack header without stuffing, then a PES packet
+			if bytes.HasPrefix(sector, []byte{0, 0, 1, 0xba}) && bytes.HasPrefix(sector[14:], []byte{0, 0, 1}) &&
+				sector[20]&0x30 != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readImage reads n bytes at off, failing on short reads.
+func readImage(img UnpackableFile, off int64, n int) ([]byte, error) {
+	if off < 0 || off+int64(n) > img.Size() {
+		return nil, fmt.Errorf("read at %d+%d outside the image", off, n)
+	}
+	buf := make([]byte, n)
+	if _, err := img.ReadAt(buf, off); err != nil && !(errors.Is(err, io.EOF) && off+int64(n) == img.Size()) {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// listISO9660 lists the files of the primary volume descriptor's directory tree.
+func listISO9660(ctx context.Context, img UnpackableFile) ([]discFile, error) {
+	for sector := int64(16); sector < 32; sector++ {
+		vd, err := readImage(img, sector*isoSectorSize, isoSectorSize)
+		if err != nil {
+			return nil, err
+		}
+		if string(vd[1:6]) != "CD001" {
+			return nil, errors.New("no ISO9660 volume descriptor")
+		}
+		switch vd[0] {
+		case 1: // primary volume descriptor
+			block := int64(binary.LittleEndian.Uint16(vd[128:]))
+			if block == 0 {
+				block = isoSectorSize
+			}
+			root := vd[156 : 156+34]
+			s := newDiscScan(ctx, img)
+			err := s.walkISO9660(block, int64(binary.LittleEndian.Uint32(root[2:])), int64(binary.LittleEndian.Uint32(root[10:])), "", 0)
+			return s.files, err
+		case 255: // terminator
+			return nil, errors.New("no ISO9660 primary volume descriptor")
+		}
+	}
+	return nil, errors.New("no ISO9660 primary volume descriptor")
+}
+
+func (s *discScan) walkISO9660(block, extent, size int64, dir string, depth int) error {
+	if depth > discMaxDepth || size > discMaxDirBytes {
+		return nil
+	}
+	if ok, err := s.enter(0, extent); !ok {
+		return err
+	}
+	data, err := s.readAt(extent*block, int(size))
+	if err != nil {
+		return err
+	}
+	multi := false // previous record continues in the next one (files over 4 GiB)
+	for off := 0; off < len(data); {
+		l := int(data[off])
+		if l == 0 {
+			// Records do not cross sector boundaries
+			off = (off/isoSectorSize + 1) * isoSectorSize
+			continue
+		}
+		if l < 34 || off+l > len(data) {
+			break
+		}
+		rec := data[off : off+l]
+		off += l
+		nameLen := int(rec[32])
+		if 33+nameLen > len(rec) {
+			break
+		}
+		name := string(rec[33 : 33+nameLen])
+		if name == "\x00" || name == "\x01" { // . and ..
+			continue
+		}
+		name, _, _ = strings.Cut(name, ";")
+		name = strings.TrimSuffix(name, ".")
+		flags := rec[25]
+		loc := int64(binary.LittleEndian.Uint32(rec[2:])) + int64(rec[1])
+		length := int64(binary.LittleEndian.Uint32(rec[10:]))
+		p := path.Join(dir, name)
+		if flags&0x02 != 0 {
+			if err := s.walkISO9660(block, loc, length, p, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		ext := discExtent{offset: loc * block, length: length}
+		if n := len(s.files); multi && n > 0 && s.files[n-1].path == p {
+			s.files[n-1].size += length
+			s.files[n-1].extents = append(s.files[n-1].extents, ext)
+		} else if len(s.files) < discMaxFiles {
+			s.files = append(s.files, discFile{path: p, size: length, extents: []discExtent{ext}})
+		}
+		multi = flags&0x80 != 0
+	}
+	return nil
+}
+
+// udfVolume resolves UDF logical blocks to image offsets.
+type udfVolume struct {
+	*discScan
+	block     int64
+	partStart map[uint16]int64 // partition number -> first sector
+	maps      []udfPartitionMap
+}
+
+// udfPartitionMap is one partition map of the logical volume. A metadata partition
+// (UDF 2.50, Blu-ray) is addressed through the extents of its metadata file.
+type udfPartitionMap struct {
+	partition uint16
+	metadata  []discExtent
+}
+
+// udfFileEntry is the part of a File Entry or Extended File Entry needed here.
+type udfFileEntry struct {
+	size    int64
+	extents []discExtent
+	inline  []byte // embedded data (small directories)
+}
+
+const (
+	udfTagAVDP       = 2
+	udfTagPartition  = 5
+	udfTagLogicalVol = 6
+	udfTagTerminator = 8
+	udfTagFileSet    = 256
+	udfTagFileID     = 257
+	udfTagFileEntry  = 261
+	udfTagExtFileEnt = 266
+)
+
+// listUDF lists the files of a UDF file system (ECMA-167 / OSTA UDF up to 2.60).
+func listUDF(ctx context.Context, img UnpackableFile) ([]discFile, error) {
+	avdp, err := readImage(img, 256*isoSectorSize, isoSectorSize)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint16(avdp) != udfTagAVDP {
+		return nil, errors.New("no UDF anchor volume descriptor")
+	}
+	vdsLen := int64(binary.LittleEndian.Uint32(avdp[16:]))
+	vdsLoc := int64(binary.LittleEndian.Uint32(avdp[20:]))
+
+	v := &udfVolume{discScan: newDiscScan(ctx, img), partStart: make(map[uint16]int64)}
+	var lvd []byte
+	for i := int64(0); i < min(vdsLen/isoSectorSize, 64); i++ {
+		d, err := readImage(img, (vdsLoc+i)*isoSectorSize, isoSectorSize)
+		if err != nil {
+			return nil, err
+		}
+		tag := binary.LittleEndian.Uint16(d)
+		if tag == udfTagTerminator {
+			break
+		}
+		switch tag {
+		case udfTagPartition:
+			v.partStart[binary.LittleEndian.Uint16(d[22:])] = int64(binary.LittleEndian.Uint32(d[188:]))
+		case udfTagLogicalVol:
+			lvd = d
+		}
+	}
+	if lvd == nil || len(v.partStart) == 0 {
+		return nil, errors.New("incomplete UDF volume descriptor sequence")
+	}
+	v.block = int64(binary.LittleEndian.Uint32(lvd[212:]))
+	if v.block != isoSectorSize {
+		return nil, fmt.Errorf("unsupported UDF block size %d", v.block)
+	}
+	if err := v.readPartitionMaps(lvd); err != nil {
+		return nil, err
+	}
+
+	// File set descriptor -> root directory ICB
+	fsdLBN, fsdRef := binary.LittleEndian.Uint32(lvd[252:]), binary.LittleEndian.Uint16(lvd[256:])
+	off, err := v.offset(fsdRef, fsdLBN)
+	if err != nil {
+		return nil, err
+	}
+	fsd, err := readImage(img, off, isoSectorSize)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint16(fsd) != udfTagFileSet {
+		return nil, errors.New("no UDF file set descriptor")
+	}
+	rootLBN, rootRef := binary.LittleEndian.Uint32(fsd[404:]), binary.LittleEndian.Uint16(fsd[408:])
+	err = v.walk(rootRef, rootLBN, "", 0)
+	return v.files, err
+}
+
+func (v *udfVolume) readPartitionMaps(lvd []byte) error {
+	n := int(binary.LittleEndian.Uint32(lvd[268:]))
+	p := 440
+	for i := 0; i < n && p+2 <= len(lvd); i++ {
+		typ, l := lvd[p], int(lvd[p+1])
+		if l == 0 || p+l > len(lvd) {
+			return errors.New("corrupt UDF partition map")
+		}
+		m := lvd[p : p+l]
+		switch {
+		case typ == 1 && l >= 6:
+			v.maps = append(v.maps, udfPartitionMap{partition: binary.LittleEndian.Uint16(m[4:])})
+		case typ == 2 && l >= 64:
+			ident := string(m[5:28])
+			pm := udfPartitionMap{partition: binary.LittleEndian.Uint16(m[38:])}
+			switch {
+			case strings.HasPrefix(ident, "*UDF Metadata Partition"):
+				start, ok := v.partStart[pm.partition]
+				if !ok {
+					return errors.New("UDF metadata partition without physical partition")
+				}
+				fe, err := v.readFileEntryAt((start+int64(binary.LittleEndian.Uint32(m[40:])))*v.block, pm.partition, true)
+				if err != nil {
+					return fmt.Errorf("UDF metadata file: %w", err)
+				}
+				pm.metadata = fe.extents
+			case strings.HasPrefix(ident, "*UDF Sparable Partition"):
+				// Sparing only matters on the physical medium, not in an image
+			default:
+				return fmt.Errorf("unsupported UDF partition type %q", strings.TrimRight(ident, "\x00"))
+			}
+			v.maps = append(v.maps, pm)
+		default:
+			return fmt.Errorf("unsupported UDF partition map type %d", typ)
+		}
+		p += l
+	}
+	return nil
+}
+
+// offset returns the image offset of logical block lbn of partition map ref.
+func (v *udfVolume) offset(ref uint16, lbn uint32) (int64, error) {
+	exts, err := v.resolve(ref, lbn, v.block)
+	if err != nil {
+		return 0, err
+	}
+	return exts[0].offset, nil
+}
+
+// resolve maps length bytes from logical block lbn of partition map ref to image extents.
+func (v *udfVolume) resolve(ref uint16, lbn uint32, length int64) ([]discExtent, error) {
+	if int(ref) >= len(v.maps) {
+		return nil, fmt.Errorf("UDF partition reference %d out of range", ref)
+	}
+	m := v.maps[ref]
+	if m.metadata == nil {
+		start, ok := v.partStart[m.partition]
+		if !ok {
+			return nil, fmt.Errorf("UDF partition %d not found", m.partition)
+		}
+		return []discExtent{{offset: (start + int64(lbn)) * v.block, length: length}}, nil
+	}
+	// Metadata partition: blocks are offsets into the metadata file
+	pos := int64(lbn) * v.block
+	var out []discExtent
+	for _, e := range m.metadata {
+		if length == 0 {
+			break
+		}
+		if pos >= e.length {
+			pos -= e.length
+			continue
+		}
+		take := min(e.length-pos, length)
+		out = append(out, discExtent{offset: e.offset + pos, length: take})
+		length -= take
+		pos = 0
+	}
+	if length > 0 {
+		return nil, fmt.Errorf("UDF block %d outside the metadata partition", lbn)
+	}
+	return out, nil
+}
+
+func (v *udfVolume) readFileEntry(ref uint16, lbn uint32) (*udfFileEntry, error) {
+	off, err := v.offset(ref, lbn)
+	if err != nil {
+		return nil, err
+	}
+	return v.readFileEntryAt(off, ref, false)
+}
+
+// readFileEntryAt reads the (extended) file entry at off. Short allocation descriptors
+// are relative to ref, or to physical partition ref itself when physical is set (the
+// metadata file, read before the partition maps exist).
+func (v *udfVolume) readFileEntryAt(off int64, ref uint16, physical bool) (*udfFileEntry, error) {
+	d, err := v.readAt(off, int(v.block))
+	if err != nil {
+		return nil, err
+	}
+	var eaLen, adLen, base int
+	switch binary.LittleEndian.Uint16(d) {
+	case udfTagFileEntry:
+		eaLen, adLen, base = int(binary.LittleEndian.Uint32(d[168:])), int(binary.LittleEndian.Uint32(d[172:])), 176
+	case udfTagExtFileEnt:
+		eaLen, adLen, base = int(binary.LittleEndian.Uint32(d[208:])), int(binary.LittleEndian.Uint32(d[212:])), 216
+	default:
+		return nil, errors.New("no UDF file entry")
+	}
+	if base+eaLen+adLen > len(d) {
+		return nil, errors.New("corrupt UDF file entry")
+	}
+	fe := &udfFileEntry{size: int64(binary.LittleEndian.Uint64(d[56:]))}
+	ads := d[base+eaLen : base+eaLen+adLen]
+
+	resolve := func(ref uint16, lbn uint32, length int64) ([]discExtent, error) {
+		if physical {
+			start, ok := v.partStart[ref]
+			if !ok {
+				return nil, fmt.Errorf("UDF partition %d not found", ref)
+			}
+			return []discExtent{{offset: (start + int64(lbn)) * v.block, length: length}}, nil
+		}
+		return v.resolve(ref, lbn, length)
+	}
+	adType := binary.LittleEndian.Uint16(d[34:]) & 7
+	var adSize int
+	switch adType {
+	case 0:
+		adSize = 8
+	case 1:
+		adSize = 16
+	case 3:
+		fe.inline = ads
+		return fe, nil
+	default:
+		return nil, fmt.Errorf("unsupported UDF allocation descriptor type %d", adType)
+	}
+	for p := 0; p+adSize <= len(ads); p += adSize {
+		raw := binary.LittleEndian.Uint32(ads[p:])
+		length, kind := int64(raw&0x3fffffff), raw>>30
+		if length == 0 {
+			break
+		}
+		if kind != 0 {
+			// Unrecorded extents or a continuation of the descriptors
+			return nil, errors.New("unsupported UDF extent type")
+		}
+		lbn, partRef := binary.LittleEndian.Uint32(ads[p+4:]), ref
+		if adType == 1 {
+			partRef = binary.LittleEndian.Uint16(ads[p+8:])
+		}
+		exts, err := resolve(partRef, lbn, length)
+		if err != nil {
+			return nil, err
+		}
+		fe.extents = append(fe.extents, exts...)
+	}
+	return fe, nil
+}
+
+// data returns the contents of a small file entry (a directory).
+func (v *udfVolume) data(fe *udfFileEntry) ([]byte, error) {
+	if fe.inline != nil {
+		return fe.inline, nil
+	}
+	if fe.size > discMaxDirBytes {
+		return nil, errors.New("UDF directory too large")
+	}
+	out := make([]byte, 0, fe.size)
+	for _, e := range fe.extents {
+		b, err := v.readAt(e.offset, int(e.length))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	return out[:min(int64(len(out)), fe.size)], nil
+}
+
+func (v *udfVolume) walk(ref uint16, lbn uint32, dir string, depth int) error {
+	if depth > discMaxDepth {
+		return nil
+	}
+	if ok, err := v.enter(ref, int64(lbn)); !ok {
+		return err
+	}
+	fe, err := v.readFileEntry(ref, lbn)
+	if err != nil {
+		return err
+	}
+	d, err := v.data(fe)
+	if err != nil {
+		return err
+	}
+	for off := 0; off+38 <= len(d); {
+		if binary.LittleEndian.Uint16(d[off:]) != udfTagFileID {
+			return errors.New("corrupt UDF directory")
+		}
+		chars, nameLen := d[off+18], int(d[off+19])
+		iuLen := int(binary.LittleEndian.Uint16(d[off+36:]))
+		end := off + 38 + iuLen + nameLen
+		if end > len(d) {
+			return errors.New("corrupt UDF directory")
+		}
+		name := udfName(d[off+38+iuLen : end])
+		icbLBN, icbRef := binary.LittleEndian.Uint32(d[off+24:]), binary.LittleEndian.Uint16(d[off+28:])
+		off = (end + 3) &^ 3
+
+		if chars&0x08 != 0 || chars&0x04 != 0 || name == "" { // parent or deleted
+			continue
+		}
+		p := path.Join(dir, name)
+		if chars&0x02 != 0 {
+			if err := v.walk(icbRef, icbLBN, p, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(v.files) >= discMaxFiles {
+			return nil
+		}
+		entry, err := v.readFileEntry(icbRef, icbLBN)
+		if err != nil {
+			log.Debug("Skipping unreadable UDF file", "file", p, "err", err)
+			continue
+		}
+		v.files = append(v.files, discFile{path: p, size: entry.size, extents: trimExtents(entry.extents, entry.size)})
+	}
+	return nil
+}
+
+// trimExtents cuts extents to size bytes: the last extent is padded to a block.
+func trimExtents(extents []discExtent, size int64) []discExtent {
+	var out []discExtent
+	for _, e := range extents {
+		if size <= 0 {
+			break
+		}
+		e.length = min(e.length, size)
+		size -= e.length
+		out = append(out, e)
+	}
+	return out
+}
+
+// udfName decodes an OSTA CS0 file identifier: 8-bit or UTF-16BE after a compression ID.
+func udfName(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	switch b[0] {
+	case 8:
+		r := make([]rune, len(b)-1)
+		for i, c := range b[1:] {
+			r[i] = rune(c)
+		}
+		return string(r)
+	case 16:
+		u := make([]uint16, (len(b)-1)/2)
+		for i := range u {
+			u[i] = binary.BigEndian.Uint16(b[1+2*i:])
+		}
+		return string(utf16.Decode(u))
+	}
+	return ""
+}
@@ -0,0 +1,358 @@
+package unpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+// discImage builds a disc image sector by sector.
+type discImage []byte
+
+func newDiscImage(sectors int) discImage { return make(discImage, sectors*isoSectorSize) }
+
+func (d discImage) sector(n int) []byte { return d[n*isoSectorSize : (n+1)*isoSectorSize] }
+
+// fill writes a recognizable pattern over n bytes from sector start.
+func (d discImage) fill(start, n int, seed byte) []byte {
+	b := d[start*isoSectorSize : start*isoSectorSize+n]
+	for i := range b {
+		b[i] = seed + byte(i%251)
+	}
+	return b
+}
+
+func isoRecord(name string, loc, size uint32, flags byte) []byte {
+	rec := make([]byte, 33+len(name)+(1-len(name)%2))
+	rec[0] = byte(len(rec))
+	binary.LittleEndian.PutUint32(rec[2:], loc)
+	binary.LittleEndian.PutUint32(rec[10:], size)
+	rec[25] = flags
+	rec[32] = byte(len(name))
+	copy(rec[33:], name)
+	return rec
+}
+
+func isoDir(sector []byte, records ...[]byte) {
+	off := 0
+	for _, r := range append([][]byte{isoRecord("\x00", 0, 0, 2), isoRecord("\x01", 0, 0, 2)}, records...) {
+		off += copy(sector[off:], r)
+	}
+}
+
+func imageBlueprint(name string, img []byte) *ArchiveBlueprint {
+	f := &memFile{name: name, data: img}
+	return &ArchiveBlueprint{MainFileName: name, TotalSize: int64(len(img)),
+		Parts: []VirtualPartDef{{VirtualStart: 0, VirtualEnd: int64(len(img)), VolFile: f}}}
+}
+
+func readTitle(t *testing.T, bp *ArchiveBlueprint) []byte {
+	t.Helper()
+	s, _, _, err := StreamFromBlueprint(context.Background(), bp)
+	if err != nil {
+		t.Fatalf("StreamFromBlueprint: %v", err)
+	}
+	defer s.Close()
+	data, err := io.ReadAll(s)
+	if err != nil {
+		t.Fatalf("reading title: %v", err)
+	}
+	return data
+}
+
+func TestScanISO9660(t *testing.T) {
+	logger.Init("DEBUG")
+	img := newDiscImage(40)
+	pvd := img.sector(16)
+	pvd[0] = 1
+	copy(pvd[1:], "CD001")
+	binary.LittleEndian.PutUint16(pvd[128:], isoSectorSize)
+	copy(pvd[156:], isoRecord("\x00", 18, isoSectorSize, 2))
+	img.sector(17)[0] = 255
+	copy(img.sector(17)[1:], "CD001")
+
+	isoDir(img.sector(18), isoRecord("VIDEO_TS", 19, isoSectorSize, 2), isoRecord("EXTRA.MKV;1", 36, 3000, 0))
+	isoDir(img.sector(19),
+		isoRecord("VTS_01_0.VOB;1", 20, 2048, 0),
+		isoRecord("VTS_01_1.VOB;1", 22, 4096, 0x80), // continues in the next record
+		isoRecord("VTS_01_1.VOB;1", 30, 1000, 0),
+		isoRecord("VTS_01_2.VOB;1", 26, 2048, 0),
+	)
+	var want []byte
+	want = append(want, img.fill(22, 4096, 1)...)
+	want = append(want, img.fill(30, 1000, 2)...)
+	want = append(want, img.fill(26, 2048, 3)...)
+	img.fill(36, 3000, 4)
+
+	bp, err := ScanISO(context.Background(), imageBlueprint("Movie.iso", img))
+	if err != nil {
+		t.Fatalf("ScanISO: %v", err)
+	}
+	if bp.MainFileName != "VIDEO_TS/VTS_01_1.VOB" || bp.TotalSize != int64(len(want)) {
+		t.Fatalf("title = %s (%d bytes); want the joined VTS_01 title set (%d bytes)", bp.MainFileName, bp.TotalSize, len(want))
+	}
+	if got := readTitle(t, bp); !bytes.Equal(got, want) {
+		t.Error("title bytes do not match the VOB extents")
+	}
+
+	// CSS: PES scrambling control set in the first pack
+	sector := img.sector(22)
+	copy(sector, []byte{0, 0, 1, 0xba})
+	copy(sector[14:], []byte{0, 0, 1, 0xe0})
+	sector[20] = 0x10
+	if _, err := ScanISO(context.Background(), imageBlueprint("Movie.iso", img)); !errors.Is(err, ErrEncryptedDisc) {
+		t.Errorf("scrambled VOB: err = %v; want ErrEncryptedDisc", err)
+	}
+}
+
+// udfImage lays out a UDF volume. With metadata set, directories and file entries live
+// in a metadata partition (UDF 2.50, as on Blu-ray) mapped onto physical blocks.
+type udfImage struct {
+	discImage
+	partStart int
+	metadata  bool
+}
+
+const (
+	udfMetaFileLBN  = 40 // physical block of the metadata file entry
+	udfMetaDataLBN  = 50 // physical block where the metadata partition starts
+	udfMetaRef      = 1
+	udfPhysicalRef  = 0
+	testPartitionNo = 3
+)
+
+// block returns the logical block lbn of the partition holding metadata.
+func (u *udfImage) block(lbn int) []byte {
+	if u.metadata {
+		lbn += udfMetaDataLBN
+	}
+	return u.sector(u.partStart + lbn)
+}
+
+func (u *udfImage) metaRef() uint16 {
+	if u.metadata {
+		return udfMetaRef
+	}
+	return udfPhysicalRef
+}
+
+func udfTag(b []byte, id uint16) { binary.LittleEndian.PutUint16(b, id) }
+
+func udfFID(name string, lbn uint32, ref uint16, chars byte) []byte {
+	var ident []byte
+	if name != "" {
+		ident = append([]byte{8}, name...)
+	}
+	fid := make([]byte, (38+len(ident)+3)&^3)
+	udfTag(fid, udfTagFileID)
+	fid[18] = chars
+	fid[19] = byte(len(ident))
+	binary.LittleEndian.PutUint32(fid[20:], isoSectorSize)
+	binary.LittleEndian.PutUint32(fid[24:], lbn)
+	binary.LittleEndian.PutUint16(fid[28:], ref)
+	copy(fid[38:], ident)
+	return fid
+}
+
+// dir writes a directory file entry with embedded FIDs at metadata block lbn.
+func (u *udfImage) dir(lbn int, fids ...[]byte) {
+	fe := u.block(lbn)
+	udfTag(fe, udfTagFileEntry)
+	binary.LittleEndian.PutUint16(fe[34:], 3)
+	data := udfFID("", 0, 0, 0x0a) // parent
+	for _, f := range fids {
+		data = append(data, f...)
+	}
+	binary.LittleEndian.PutUint64(fe[56:], uint64(len(data)))
+	binary.LittleEndian.PutUint32(fe[172:], uint32(len(data)))
+	copy(fe[176:], data)
+}
+
+// file writes a file entry at metadata block lbn whose data is in physical blocks.
+func (u *udfImage) file(lbn int, size int64, extents ...[2]uint32) {
+	fe := u.block(lbn)
+	udfTag(fe, udfTagExtFileEnt)
+	binary.LittleEndian.PutUint64(fe[56:], uint64(size))
+	binary.LittleEndian.PutUint16(fe[34:], 1) // long_ad
+	for i, e := range extents {
+		ad := fe[216+16*i:]
+		binary.LittleEndian.PutUint32(ad, e[1]*isoSectorSize)
+		binary.LittleEndian.PutUint32(ad[4:], e[0])
+		binary.LittleEndian.PutUint16(ad[8:], udfPhysicalRef)
+	}
+	binary.LittleEndian.PutUint32(fe[212:], uint32(16*len(extents)))
+}
+
+func newUDFImage(metadata bool) *udfImage {
+	u := &udfImage{discImage: newDiscImage(400), partStart: 270, metadata: metadata}
+	avdp := u.sector(256)
+	udfTag(avdp, udfTagAVDP)
+	binary.LittleEndian.PutUint32(avdp[16:], 4*isoSectorSize)
+	binary.LittleEndian.PutUint32(avdp[20:], 257)
+
+	pd := u.sector(257)
+	udfTag(pd, udfTagPartition)
+	binary.LittleEndian.PutUint16(pd[22:], testPartitionNo)
+	binary.LittleEndian.PutUint32(pd[188:], uint32(u.partStart))
+
+	lvd := u.sector(258)
+	udfTag(lvd, udfTagLogicalVol)
+	binary.LittleEndian.PutUint32(lvd[212:], isoSectorSize)
+	binary.LittleEndian.PutUint32(lvd[252:], 0) // file set descriptor at block 0
+	binary.LittleEndian.PutUint16(lvd[256:], u.metaRef())
+	lvd[440], lvd[441] = 1, 6
+	binary.LittleEndian.PutUint16(lvd[444:], testPartitionNo)
+	binary.LittleEndian.PutUint32(lvd[268:], 1)
+	if metadata {
+		m := lvd[446:]
+		m[0], m[1] = 2, 64
+		copy(m[5:], "*UDF Metadata Partition")
+		binary.LittleEndian.PutUint16(m[38:], testPartitionNo)
+		binary.LittleEndian.PutUint32(m[40:], udfMetaFileLBN)
+		binary.LittleEndian.PutUint32(lvd[268:], 2)
+
+		mfe := u.sector(u.partStart + udfMetaFileLBN)
+		udfTag(mfe, udfTagFileEntry)
+		binary.LittleEndian.PutUint64(mfe[56:], 8*isoSectorSize)
+		binary.LittleEndian.PutUint32(mfe[172:], 8)
+		binary.LittleEndian.PutUint32(mfe[176:], 8*isoSectorSize) // short_ad
+		binary.LittleEndian.PutUint32(mfe[180:], udfMetaDataLBN)
+	}
+	udfTag(u.sector(259), udfTagTerminator)
+
+	fsd := u.block(0)
+	udfTag(fsd, udfTagFileSet)
+	binary.LittleEndian.PutUint32(fsd[404:], 1)
+	binary.LittleEndian.PutUint16(fsd[408:], u.metaRef())
+	return u
+}
+
+func TestScanUDF(t *testing.T) {
+	logger.Init("DEBUG")
+	for _, metadata := range []bool{false, true} {
+		u := newUDFImage(metadata)
+		ref := u.metaRef()
+		u.dir(1, udfFID("BDMV", 2, ref, 0x02), udfFID("AACS", 6, ref, 0x02))
+		u.dir(2, udfFID("STREAM", 3, ref, 0x02))
+		u.dir(3, udfFID("00000.m2ts", 4, ref, 0), udfFID("00001.m2ts", 5, ref, 0))
+		u.dir(6)
+		u.file(4, 3000, [2]uint32{10, 1}, [2]uint32{20, 1}) // second extent padded to a block
+		u.file(5, 100, [2]uint32{30, 1})
+		want := append(append([]byte(nil), u.fill(u.partStart+10, isoSectorSize, 5)...), u.fill(u.partStart+20, 952, 6)...)
+
+		bp, err := ScanISO(context.Background(), imageBlueprint("Movie.iso", u.discImage))
+		if err != nil {
+			t.Fatalf("metadata=%v: ScanISO: %v", metadata, err)
+		}
+		if bp.MainFileName != "BDMV/STREAM/00000.m2ts" || bp.TotalSize != 3000 {
+			t.Fatalf("metadata=%v: title = %s (%d bytes); want 00000.m2ts (3000 bytes)", metadata, bp.MainFileName, bp.TotalSize)
+		}
+		if got := readTitle(t, bp); !bytes.Equal(got, want) {
+			t.Errorf("metadata=%v: title bytes do not match the file extents", metadata)
+		}
+
+		// AACS: copy permission bits set in the first aligned unit
+		unit := u.sector(u.partStart + 10)
+		unit[0], unit[4] = 0xc0, 0x47
+		if _, err := ScanISO(context.Background(), imageBlueprint("Movie.iso", u.discImage)); !errors.Is(err, ErrEncryptedDisc) {
+			t.Errorf("metadata=%v: AACS unit: err = %v; want ErrEncryptedDisc", metadata, err)
+		}
+	}
+}
+
+// scanWithin runs ScanISO on img and fails the test if it takes longer than a few seconds.
+func scanWithin(t *testing.T, ctx context.Context, img []byte) (*ArchiveBlueprint, error) {
+	t.Helper()
+	type result struct {
+		bp  *ArchiveBlueprint
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		bp, err := ScanISO(ctx, imageBlueprint("Movie.iso", img))
+		done <- result{bp, err}
+	}()
+	select {
+	case r := <-done:
+		return r.bp, r.err
+	case <-time.After(5 * time.Second):
+		t.Fatal("ScanISO did not return on a self-referencing directory tree")
+		return nil, nil
+	}
+}
+
+func TestScanISODirectoryLoops(t *testing.T) {
+	logger.Init("DEBUG")
+
+	// ISO9660: 50 directories in the root all point back at the root sector
+	img := newDiscImage(40)
+	pvd := img.sector(16)
+	pvd[0] = 1
+	copy(pvd[1:], "CD001")
+	binary.LittleEndian.PutUint16(pvd[128:], isoSectorSize)
+	copy(pvd[156:], isoRecord("\x00", 18, isoSectorSize, 2))
+	img.sector(17)[0] = 255
+	copy(img.sector(17)[1:], "CD001")
+	records := [][]byte{isoRecord("VIDEO_TS", 19, isoSectorSize, 2)}
+	for i := 0; i < 50; i++ {
+		records = append(records, isoRecord(fmt.Sprintf("L%02d", i), 18, isoSectorSize, 2))
+	}
+	isoDir(img.sector(18), records...)
+	isoDir(img.sector(19), isoRecord("VTS_01_1.VOB;1", 20, 4096, 0), isoRecord("LOOP", 18, isoSectorSize, 2))
+
+	bp, err := scanWithin(t, context.Background(), img)
+	if err != nil || bp.MainFileName != "VIDEO_TS/VTS_01_1.VOB" {
+		t.Errorf("ISO9660 loop: title %v, err %v; want VIDEO_TS/VTS_01_1.VOB", bp, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := scanWithin(t, ctx, img); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled scan: err = %v; want context.Canceled", err)
+	}
+
+	// UDF: the same loop through file identifiers pointing back at the root ICB
+	for _, metadata := range []bool{false, true} {
+		u := newUDFImage(metadata)
+		ref := u.metaRef()
+		fids := [][]byte{udfFID("BDMV", 2, ref, 0x02)}
+		for i := 0; i < 30; i++ {
+			fids = append(fids, udfFID(fmt.Sprintf("L%02d", i), 1, ref, 0x02))
+		}
+		u.dir(1, fids...)
+		u.dir(2, udfFID("00000.m2ts", 3, ref, 0), udfFID("UP", 1, ref, 0x02))
+		u.file(3, 3000, [2]uint32{10, 2})
+
+		bp, err := scanWithin(t, context.Background(), u.discImage)
+		if err != nil || bp.MainFileName != "BDMV/00000.m2ts" {
+			t.Errorf("metadata=%v: UDF loop: title %v, err %v; want BDMV/00000.m2ts", metadata, bp, err)
+		}
+	}
+}
+
+func TestMapImageRange(t *testing.T) {
+	vol1, vol2 := &memFile{name: "a.rar"}, &memFile{name: "a.r00"}
+	parts := []VirtualPartDef{
+		{VirtualStart: 0, VirtualEnd: 1000, VolFile: vol1, VolOffset: 100},
+		{VirtualStart: 1000, VirtualEnd: 2000, VolFile: vol2, VolOffset: 50},
+	}
+	got := mapImageRange(parts, 900, 300, 10)
+	want := []VirtualPartDef{
+		{VirtualStart: 10, VirtualEnd: 110, VolFile: vol1, VolOffset: 1000},
+		{VirtualStart: 110, VirtualEnd: 310, VolFile: vol2, VolOffset: 50},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("mapImageRange = %+v; want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("part %d = %+v; want %+v", i, got[i], want[i])
+		}
+	}
+}
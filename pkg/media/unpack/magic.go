@@ -0,0 +1,121 @@
+package unpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"streamnzb/pkg/media/loader"
+)
+
+// Obfuscated releases (random file names, no extensions) can only be told apart by their
+// content: the magic bytes at the start of the largest file.
+
+const magicProbeSize = 512
+
+// largestUnknownFile returns the index of the largest file that is not an archive volume,
+// PAR2, NZB or NFO by name: what GetMediaStream opens when nothing is recognizable. -1 if none.
+func largestUnknownFile(files []*loader.File) int {
+	largest := -1
+	for i, f := range files {
+		name := strings.ToLower(ExtractFilename(f.Name()))
+		if strings.HasSuffix(name, ExtRar) || strings.Contains(name, ".part") || IsRarPart(name) || IsSplitArchivePart(name) {
+			continue
+		}
+		if strings.HasSuffix(name, ExtPar2) || strings.HasSuffix(name, ExtNzb) || strings.HasSuffix(name, ExtNfo) {
+			continue
+		}
+		if largest < 0 || f.Size() > files[largest].Size() {
+			largest = i
+		}
+	}
+	return largest
+}
+
+// ProbeObfuscated reads the first segment of the file GetMediaStream would fall back to
+// for an obfuscated release and returns the container its magic bytes identify ("mkv",
+// "mp4", "avi", "ts", "mpeg", "rar" or "7z"). ok is false when nothing is recognized.
+func ProbeObfuscated(files []*loader.File) (kind, name string, ok bool) {
+	i := largestUnknownFile(files)
+	if i < 0 {
+		return "", "", false
+	}
+	name = ExtractFilename(files[i].Name())
+	head := make([]byte, min(int64(magicProbeSize), files[i].Size()))
+	n, err := files[i].ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", name, false
+	}
+	kind = magicKind(head[:n])
+	return kind, name, kind != ""
+}
+
+// magicKind identifies a media container or archive by its leading bytes; "" if unknown.
+func magicKind(head []byte) string {
+	switch {
+	case len(head) >= 4 && binary.BigEndian.Uint32(head) == ebmlIDHeader:
+		return "mkv"
+	case len(head) >= 8 && isMP4Box(string(head[4:8])):
+		return "mp4"
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "AVI ":
+		return "avi"
+	case bytes.HasPrefix(head, []byte("Rar!\x1a\x07")):
+		return "rar"
+	case bytes.HasPrefix(head, []byte("7z\xbc\xaf\x27\x1c")):
+		return "7z"
+	case bytes.HasPrefix(head, []byte{0x00, 0x00, 0x01, 0xba}):
+		return "mpeg"
+	case len(head) > 188 && head[0] == 0x47 && head[188] == 0x47:
+		return "ts"
+	case len(head) > 196 && head[4] == 0x47 && head[196] == 0x47: // M2TS: 192-byte packets
+		return "ts"
+	}
+	return ""
+}
+
+// isMP4Box reports whether typ is a top-level ISO BMFF box that starts MP4/MOV files.
+func isMP4Box(typ string) bool {
+	switch typ {
+	case "ftyp", "moov", "mdat", "free", "wide", "skip":
+		return true
+	}
+	return false
+}
+
+// ErrCorruptStream is returned by CheckStreamHead when the start of a media stream is
+// unreadable, zero-filled or not the container its name says.
+var ErrCorruptStream = errors.New("corrupt media stream")
+
+// extensionKinds maps media extensions to the container magicKind must find.
+var extensionKinds = map[string]string{
+	".mkv": "mkv", ".webm": "mkv",
+	".mp4": "mp4", ".m4v": "mp4", ".mov": "mp4",
+	".avi": "avi",
+}
+
+// CheckStreamHead reads the first bytes of the media stream name and seeks back to the
+// start. A stale archive blueprint points at the wrong offsets, and missing articles are
+// zero-filled: both show up here before anything is sent to the player.
+func CheckStreamHead(s io.ReadSeeker, name string) error {
+	head := make([]byte, magicProbeSize)
+	n, err := io.ReadFull(s, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %w", ErrCorruptStream, err)
+	}
+	if _, err := s.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("%w: %w", ErrCorruptStream, err)
+	}
+	head = head[:n]
+	if n == 0 || len(bytes.Trim(head, "\x00")) == 0 {
+		return fmt.Errorf("%w: %s starts with zero-filled data", ErrCorruptStream, name)
+	}
+	want := extensionKinds[strings.ToLower(filepath.Ext(name))]
+	if kind := magicKind(head); want != "" && kind != want {
+		return fmt.Errorf("%w: %s does not start with a %s header", ErrCorruptStream, name, want)
+	}
+	return nil
+}
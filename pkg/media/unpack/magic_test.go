@@ -0,0 +1,61 @@
+package unpack
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestMagicKind(t *testing.T) {
+	ts := make([]byte, 376)
+	ts[0], ts[188] = 0x47, 0x47
+	m2ts := make([]byte, 392)
+	m2ts[4], m2ts[196] = 0x47, 0x47
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"mkv", mkvTracksHead("V_MPEGH/ISO/HEVC", 3840, 2160), "mkv"},
+		{"mp4", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), "mp4"},
+		{"avi", []byte("RIFF\x10\x00\x00\x00AVI LIST"), "avi"},
+		{"rar5", []byte("Rar!\x1a\x07\x01\x00"), "rar"},
+		{"7z", []byte("7z\xbc\xaf\x27\x1c\x00\x04"), "7z"},
+		{"mpeg-ps", []byte{0x00, 0x00, 0x01, 0xba, 0x44}, "mpeg"},
+		{"ts", ts, "ts"},
+		{"m2ts", m2ts, "ts"},
+		{"random", []byte("\x8f\x12\xaa\x03garbage data here"), ""},
+		{"short", []byte{0x1a}, ""},
+	}
+	for _, tt := range tests {
+		if got := magicKind(tt.head); got != tt.want {
+			t.Errorf("%s: magicKind = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckStreamHead(t *testing.T) {
+	mkv := append(mkvTracksHead("V_MPEG4/ISO/AVC", 1920, 1080), make([]byte, 1024)...)
+	tests := []struct {
+		name    string
+		data    []byte
+		corrupt bool
+	}{
+		{"Movie.mkv", mkv, false},
+		{"Movie.mkv", make([]byte, 4096), true},                        // zero-filled
+		{"Movie.mkv", append([]byte("garbage"), mkv...), true},         // wrong offset
+		{"Movie.ts", append([]byte("\x47 other data"), mkv...), false}, // no header expected
+		{"Movie.mkv", nil, true},
+	}
+	for _, tt := range tests {
+		r := bytes.NewReader(tt.data)
+		err := CheckStreamHead(r, tt.name)
+		if errors.Is(err, ErrCorruptStream) != tt.corrupt {
+			t.Errorf("%s (%d bytes): CheckStreamHead = %v; want corrupt %v", tt.name, len(tt.data), err, tt.corrupt)
+		}
+		if pos, _ := r.Seek(0, io.SeekCurrent); pos != 0 {
+			t.Errorf("%s: stream left at offset %d", tt.name, pos)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package unpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"streamnzb/pkg/media/loader"
+)
+
+// Release titles can be wrong about resolution and codec; the Matroska Tracks element
+// at the start of the file says what is actually inside.
+
+const (
+	ebmlIDTracks      = 0x1654AE6B
+	ebmlIDTrackEntry  = 0xAE
+	ebmlIDTrackType   = 0x83
+	ebmlIDCodecID     = 0x86
+	ebmlIDVideo       = 0xE0
+	ebmlIDPixelWidth  = 0xB0
+	ebmlIDPixelHeight = 0xBA
+
+	mkvTrackTypeVideo = 1
+)
+
+// VideoInfo describes the first video track of a media file.
+type VideoInfo struct {
+	Width  int
+	Height int
+	Codec  string // "HEVC", "AVC", "AV1", ... ("" when not recognized)
+}
+
+// Resolution returns the resolution tag of the video ("2160p", "1080p", "720p" or "480p").
+// Widths count as well as heights, so cropped scope encodes (1920x800) stay 1080p.
+func (v VideoInfo) Resolution() string {
+	switch {
+	case v.Width >= 3200 || v.Height >= 2000:
+		return "2160p"
+	case v.Width >= 1800 || v.Height >= 1000:
+		return "1080p"
+	case v.Width >= 1200 || v.Height >= 700:
+		return "720p"
+	}
+	return "480p"
+}
+
+// mkvCodecs maps Matroska CodecIDs to the codec names release titles are parsed into.
+var mkvCodecs = map[string]string{
+	"V_MPEGH/ISO/HEVC": "HEVC",
+	"V_MPEG4/ISO/AVC":  "AVC",
+	"V_AV1":            "AV1",
+	"V_VP9":            "VP9",
+	"V_VP8":            "VP8",
+	"V_MPEG2":          "MPEG-2",
+}
+
+// ProbeDirectVideo reads the header of the main video file (as GetMediaStream would pick it)
+// and returns its video track. ok is false for non-Matroska files or when the Tracks
+// element is not in the first indexProbeSize bytes.
+func ProbeDirectVideo(files []*loader.File) (info VideoInfo, name string, ok bool) {
+	names := make([]string, len(files))
+	sizes := make([]int64, len(files))
+	for i, f := range files {
+		names[i] = ExtractFilename(f.Name())
+		sizes[i] = f.Size()
+	}
+	i := selectDirectVideo(names, sizes)
+	if i < 0 {
+		return VideoInfo{}, "", false
+	}
+	head := make([]byte, min(int64(indexProbeSize), sizes[i]))
+	n, err := files[i].ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return VideoInfo{}, names[i], false
+	}
+	info, ok = mkvVideoInfo(head[:n])
+	return info, names[i], ok
+}
+
+// mkvVideoInfo finds the first video track in the Tracks element of a Matroska header.
+func mkvVideoInfo(head []byte) (VideoInfo, bool) {
+	if len(head) < 4 || binary.BigEndian.Uint32(head) != ebmlIDHeader {
+		return VideoInfo{}, false
+	}
+	r := bytes.NewReader(head)
+	id, n, ok := readEBMLElement(r)
+	if !ok || n == ebmlUnknownSize {
+		return VideoInfo{}, false
+	}
+	if _, err := r.Seek(n, io.SeekCurrent); err != nil {
+		return VideoInfo{}, false
+	}
+	id, _, ok = readEBMLElement(r)
+	if !ok || id != ebmlIDSegment {
+		return VideoInfo{}, false
+	}
+	for {
+		id, n, ok := readEBMLElement(r)
+		if !ok || n == ebmlUnknownSize || n > int64(r.Len()) || id == ebmlIDCluster {
+			return VideoInfo{}, false
+		}
+		body := make([]byte, n)
+		r.Read(body)
+		if id == ebmlIDTracks {
+			return mkvVideoTrack(body)
+		}
+	}
+}
+
+// mkvVideoTrack returns the first video TrackEntry of a Tracks body.
+func mkvVideoTrack(tracks []byte) (VideoInfo, bool) {
+	r := bytes.NewReader(tracks)
+	for r.Len() > 0 {
+		id, n, ok := readEBMLElement(r)
+		if !ok || n < 0 || n > int64(r.Len()) {
+			return VideoInfo{}, false
+		}
+		body := make([]byte, n)
+		r.Read(body)
+		if id != ebmlIDTrackEntry {
+			continue
+		}
+		var info VideoInfo
+		var trackType int64
+		er := bytes.NewReader(body)
+		for er.Len() > 0 {
+			cid, cn, ok := readEBMLElement(er)
+			if !ok || cn < 0 || cn > int64(er.Len()) {
+				break
+			}
+			value := make([]byte, cn)
+			er.Read(value)
+			switch cid {
+			case ebmlIDTrackType:
+				trackType = ebmlUint(value)
+			case ebmlIDCodecID:
+				codecID := strings.TrimRight(string(value), "\x00")
+				info.Codec = mkvCodecs[codecID]
+			case ebmlIDVideo:
+				vr := bytes.NewReader(value)
+				for vr.Len() > 0 {
+					vid, vn, ok := readEBMLElement(vr)
+					if !ok || vn < 0 || vn > int64(vr.Len()) {
+						break
+					}
+					v := make([]byte, vn)
+					vr.Read(v)
+					switch vid {
+					case ebmlIDPixelWidth:
+						info.Width = int(ebmlUint(v))
+					case ebmlIDPixelHeight:
+						info.Height = int(ebmlUint(v))
+					}
+				}
+			}
+		}
+		if trackType == mkvTrackTypeVideo && info.Width > 0 && info.Height > 0 {
+			return info, true
+		}
+	}
+	return VideoInfo{}, false
+}
+
+func ebmlUint(b []byte) int64 {
+	var v int64
+	for _, c := range b[:min(len(b), 8)] {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+package unpack
+
+import "testing"
+
+func mkvTracksHead(codecID string, width, height uint16) []byte {
+	head := ebml([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebml([]byte{0x42, 0x82}, 'w', 'e', 'b', 'm')...)
+	head = append(head, 0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)                       // Segment, unknown size
+	head = append(head, ebml([]byte{0x15, 0x49, 0xA9, 0x66}, ebml([]byte{0x2A, 0xD7, 0xB1}, 0x0F, 0x42, 0x40)...)...) // Info
+	audio := append(ebml([]byte{0x83}, 2), ebml([]byte{0x86}, []byte("A_EAC3")...)...)
+	video := append(ebml([]byte{0x83}, 1), ebml([]byte{0x86}, []byte(codecID)...)...)
+	dims := append(ebml([]byte{0xB0}, byte(width>>8), byte(width)), ebml([]byte{0xBA}, byte(height>>8), byte(height))...)
+	video = append(video, ebml([]byte{0xE0}, dims...)...)
+	tracks := append(ebml([]byte{0xAE}, audio...), ebml([]byte{0xAE}, video...)...)
+	head = append(head, ebml([]byte{0x16, 0x54, 0xAE, 0x6B}, tracks...)...)
+	return append(head, ebml([]byte{0x1F, 0x43, 0xB6, 0x75}, 0)...) // Cluster
+}
+
+func TestMKVVideoInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		head       []byte
+		ok         bool
+		codec      string
+		resolution string
+	}{
+		{"hevc 4k", mkvTracksHead("V_MPEGH/ISO/HEVC", 3840, 2160), true, "HEVC", "2160p"},
+		{"avc scope 1080p", mkvTracksHead("V_MPEG4/ISO/AVC", 1920, 800), true, "AVC", "1080p"},
+		{"av1 720p", mkvTracksHead("V_AV1", 1280, 536), true, "AV1", "720p"},
+		{"unknown codec sd", mkvTracksHead("V_THEORA", 720, 404), true, "", "480p"},
+		{"no tracks before cluster", mkvHead(0x10), false, "", ""},
+		{"mp4", append(mp4Box("ftyp", 16), mp4Box("moov", 100)...), false, "", ""},
+	}
+	for _, tt := range tests {
+		info, ok := mkvVideoInfo(tt.head)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v", tt.name, ok)
+			continue
+		}
+		if ok && (info.Codec != tt.codec || info.Resolution() != tt.resolution) {
+			t.Errorf("%s: got %+v (%s)", tt.name, info, info.Resolution())
+		}
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"sync"
 	"time"
 
-	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/media/loader"
 
 	"github.com/javi11/rardecode/v2"
@@ -18,17 +17,17 @@ import (
 
 // ArchiveBlueprint stores the verified structure of a RAR archive.
 type ArchiveBlueprint struct {
-	MainFileName string
-	TotalSize    int64
-	Parts        []VirtualPartDef
-	IsCompressed bool
+	MainFileName string           `json:"main_file_name"`
+	TotalSize    int64            `json:"total_size"`
+	Parts        []VirtualPartDef `json:"parts"`
+	IsCompressed bool             `json:"is_compressed"`
 }
 
 type VirtualPartDef struct {
-	VirtualStart int64
-	VirtualEnd   int64
-	VolFile      UnpackableFile
-	VolOffset    int64
+	VirtualStart int64          `json:"virtual_start"`
+	VirtualEnd   int64          `json:"virtual_end"`
+	VolFile      UnpackableFile `json:"-"` // persisted as a file index, see MarshalBlueprint
+	VolOffset    int64          `json:"vol_offset"`
 }
 
 // StreamFromBlueprint returns a reader over the virtual file. We do not use rardecode
@@ -58,17 +57,30 @@ func ScanArchive(files []UnpackableFile) (*ArchiveBlueprint, error) {
 
 	// Only scan first volumes to avoid "bad volume number" errors
 	firstVols := filterFirstVolumes(rarFiles)
-	logger.Debug("Scanning RAR first volumes", "count", len(firstVols), "total", len(rarFiles))
+	log.Debug("Scanning RAR first volumes", "count", len(firstVols), "total", len(rarFiles))
 
 	start := time.Now()
 	parts := scanVolumesParallel(firstVols)
 
 	// Fail fast: if any scanned volume already exceeded its failure threshold,
-	// the release is dead and there's no point building a blueprint.
+	// retry its header scan pinned to each provider in turn. Only when no
+	// provider can serve the headers is the release dead.
 	for _, f := range firstVols {
 		if fc, ok := f.(interface{ IsFailed() bool }); ok && fc.IsFailed() {
-			logger.Error("First volume failed too many segments, aborting scan", "file", f.Name())
-			return nil, fmt.Errorf("first volume unavailable: %w", loader.ErrTooManyZeroFills)
+			retried, ok := rescanOnAlternateProvider(f)
+			if !ok {
+				log.Error("First volume failed too many segments, aborting scan", "file", f.Name())
+				return nil, fmt.Errorf("first volume unavailable: %w", loader.ErrTooManyZeroFills)
+			}
+			parts = replaceVolumeParts(parts, f, retried)
+		}
+	}
+
+	// Password-protected archives can never be streamed; stop before any
+	// nested-archive scan downloads more headers.
+	for _, p := range parts {
+		if p.isEncrypted {
+			return nil, fmt.Errorf("%w (file: %s)", ErrEncryptedArchive, p.name)
 		}
 	}
 
@@ -85,17 +97,20 @@ func ScanArchive(files []UnpackableFile) (*ArchiveBlueprint, error) {
 		}
 	}
 	if !hasMedia && len(parts) > 0 && len(rarFiles) > len(firstVols) {
-		logger.Debug("No media in first volumes, running full multi-volume scan for nested archive")
+		log.Debug("No media in first volumes, running full multi-volume scan for nested archive")
 		fullParts := scanFullArchive(rarFiles)
 		if len(fullParts) > 0 {
 			parts = fullParts
 		}
 	}
 
-	logger.Info("RAR scan complete", "files", len(rarFiles), "duration", time.Since(start))
+	log.Info("RAR scan complete", "files", len(rarFiles), "duration", time.Since(start))
 
-	// Fail fast on compression
+	// Fail fast on encryption (inner archive found by the full scan) and compression
 	for _, p := range parts {
+		if p.isEncrypted {
+			return nil, fmt.Errorf("%w (file: %s)", ErrEncryptedArchive, p.name)
+		}
 		if p.isCompressed {
 			return nil, fmt.Errorf("compressed RAR archive (file: %s) -- STORE mode required for streaming", p.name)
 		}
@@ -174,10 +189,53 @@ type filePart struct {
 	volName      string
 	isMedia      bool
 	isCompressed bool
+	isEncrypted  bool
 }
 
 // --- scanning ---
 
+// rescanOnAlternateProvider retries the header scan of a failed first volume,
+// forcing one provider pool at a time. On success the original file adopts the
+// downloaded segments and the parts are returned bound to it, so streaming
+// still uses all providers.
+func rescanOnAlternateProvider(f UnpackableFile) ([]filePart, bool) {
+	lf, ok := f.(*loader.File)
+	// With a single provider the full scan already used it; nothing to fall back to
+	if !ok || lf.ProviderCount() < 2 {
+		return nil, false
+	}
+	for i := 0; i < lf.ProviderCount(); i++ {
+		alt, ok := lf.WithProvider(i)
+		if !ok {
+			continue
+		}
+		log.Warn("First volume failed, retrying header scan on single provider", "file", f.Name(), "provider", lf.ProviderHost(i))
+		parts := scanVolumesParallel([]UnpackableFile{alt})
+		if alt.IsFailed() || len(parts) == 0 {
+			continue
+		}
+		lf.AdoptFrom(alt)
+		for j := range parts {
+			parts[j].volFile = f
+			parts[j].volName = f.Name()
+		}
+		log.Info("Header scan recovered on alternate provider", "file", f.Name(), "provider", lf.ProviderHost(i))
+		return parts, true
+	}
+	return nil, false
+}
+
+// replaceVolumeParts drops the parts found in vol and appends replacement.
+func replaceVolumeParts(parts []filePart, vol UnpackableFile, replacement []filePart) []filePart {
+	kept := parts[:0]
+	for _, p := range parts {
+		if p.volFile != vol {
+			kept = append(kept, p)
+		}
+	}
+	return append(kept, replacement...)
+}
+
 func scanVolumesParallel(files []UnpackableFile) []filePart {
 	var mu sync.Mutex
 	var result []filePart
@@ -192,10 +250,18 @@ func scanVolumesParallel(files []UnpackableFile) []filePart {
 			defer func() { <-sem }()
 			defer func() {
 				if r := recover(); r != nil {
-					logger.Error("Panic scanning RAR", "file", f.Name(), "err", r)
+					log.Error("Panic scanning RAR", "file", f.Name(), "err", r)
 				}
 			}()
 
+			// RAR5 volumes with a quick-open record list their files without a header walk
+			if parts, ok := readQuickOpen(f); ok {
+				mu.Lock()
+				result = append(result, parts...)
+				mu.Unlock()
+				return
+			}
+
 			cleanName := ExtractFilename(f.Name())
 			fsys := NewNZBFSFromMap(map[string]UnpackableFile{cleanName: f})
 
@@ -205,14 +271,22 @@ func scanVolumesParallel(files []UnpackableFile) []filePart {
 				rardecode.SkipVolumeCheck,
 			)
 			if err != nil {
-				logger.Debug("Scan failure", "name", cleanName, "err", err)
+				log.Debug("Scan failure", "name", cleanName, "err", err)
+			}
+			// Encrypted headers hide the file list entirely; record the volume
+			// so the caller can reject the archive instead of treating it as corrupt.
+			if errors.Is(err, rardecode.ErrArchiveEncrypted) && len(infos) == 0 {
+				mu.Lock()
+				result = append(result, filePart{name: cleanName, volFile: f, volName: f.Name(), isEncrypted: true})
+				mu.Unlock()
+				return
 			}
 
 			for _, info := range infos {
 				if info.Name == "" {
 					continue
 				}
-				logger.Debug("Found file in archive", "vol", cleanName, "name", info.Name, "size", info.TotalUnpackedSize)
+				log.Debug("Found file in archive", "vol", cleanName, "name", info.Name, "size", info.TotalUnpackedSize)
 
 				compressed := false
 				for _, p := range info.Parts {
@@ -232,6 +306,7 @@ func scanVolumesParallel(files []UnpackableFile) []filePart {
 						volName:      f.Name(),
 						isMedia:      isMediaFile(info),
 						isCompressed: compressed,
+						isEncrypted:  info.AnyEncrypted,
 					})
 					mu.Unlock()
 				}
@@ -272,14 +347,14 @@ func scanFullArchive(rarFiles []UnpackableFile) []filePart {
 	}
 
 	fsys := NewNZBFSFromMap(fileMap)
-	logger.Debug("Full archive scan starting", "first", firstName, "volumes", len(rarFiles))
+	log.Debug("Full archive scan starting", "first", firstName, "volumes", len(rarFiles))
 
 	infos, err := rardecode.ListArchiveInfo(firstName,
 		rardecode.FileSystem(fsys),
 		rardecode.ParallelRead(true),
 	)
 	if err != nil {
-		logger.Debug("Full archive scan failed", "err", err)
+		log.Debug("Full archive scan failed", "err", err)
 		return nil
 	}
 
@@ -288,7 +363,7 @@ func scanFullArchive(rarFiles []UnpackableFile) []filePart {
 		if info.Name == "" {
 			continue
 		}
-		logger.Debug("Full scan found file", "name", info.Name, "size", info.TotalUnpackedSize, "parts", len(info.Parts))
+		log.Debug("Full scan found file", "name", info.Name, "size", info.TotalUnpackedSize, "parts", len(info.Parts))
 
 		compressed := false
 		for _, p := range info.Parts {
@@ -300,7 +375,7 @@ func scanFullArchive(rarFiles []UnpackableFile) []filePart {
 		for _, p := range info.Parts {
 			volFile := fileMap[ExtractFilename(p.Path)]
 			if volFile == nil {
-				logger.Debug("Volume not found in map", "path", p.Path)
+				log.Debug("Volume not found in map", "path", p.Path)
 				continue
 			}
 			result = append(result, filePart{
@@ -312,10 +387,11 @@ func scanFullArchive(rarFiles []UnpackableFile) []filePart {
 				volName:      volFile.Name(),
 				isMedia:      isMediaFile(info),
 				isCompressed: compressed,
+				isEncrypted:  info.AnyEncrypted,
 			})
 		}
 	}
-	logger.Debug("Full archive scan complete", "files", len(infos), "parts", len(result))
+	log.Debug("Full archive scan complete", "files", len(infos), "parts", len(result))
 	return result
 }
 
@@ -336,7 +412,7 @@ func buildBlueprint(parts []filePart, allRarFiles []UnpackableFile) (*ArchiveBlu
 			}
 		}
 		if archiveTotal > mediaTotal*2 {
-			logger.Info("Archive content outweighs direct media, trying nested archive first",
+			log.Info("Archive content outweighs direct media, trying nested archive first",
 				"media", mediaTotal, "archive", archiveTotal, "sample", bestName)
 			if bp, err := tryNestedArchive(parts); err == nil {
 				return bp, nil
@@ -348,7 +424,7 @@ func buildBlueprint(parts []filePart, allRarFiles []UnpackableFile) (*ArchiveBlu
 		return tryNestedArchive(parts)
 	}
 
-	logger.Info("Selected main media", "name", bestName)
+	log.Info("Selected main media", "name", bestName)
 
 	mainParts := collectParts(parts, bestName)
 	sortByVolume(mainParts)
@@ -384,15 +460,15 @@ func buildBlueprint(parts []filePart, allRarFiles []UnpackableFile) (*ArchiveBlu
 			VolOffset:    p.dataOffset,
 		})
 		if i < 3 || i >= len(mainParts)-2 {
-			logger.Debug("Blueprint part", "idx", i, "vStart", vOffset, "vEnd", vOffset+p.packedSize, "volOff", p.dataOffset, "packed", p.packedSize)
+			log.Debug("Blueprint part", "idx", i, "vStart", vOffset, "vEnd", vOffset+p.packedSize, "volOff", p.dataOffset, "packed", p.packedSize)
 		}
 		vOffset += p.packedSize
 	}
 
-	logger.Debug("Blueprint total", "vOffset", vOffset, "headerSize", headerSize, "parts", len(mainParts))
+	log.Debug("Blueprint total", "vOffset", vOffset, "headerSize", headerSize, "parts", len(mainParts))
 
 	if vOffset < headerSize {
-		logger.Debug("Adjusting stream size", "header", headerSize, "actual", vOffset)
+		log.Debug("Adjusting stream size", "header", headerSize, "actual", vOffset)
 		bp.TotalSize = vOffset
 	}
 
@@ -483,7 +559,7 @@ func aggregateRemainingVolumes(mainParts []filePart, allRarFiles []UnpackableFil
 	// read from the wrong byte offset and produce corrupt data.
 	probe := probeContinuation(allRarFiles, startIdx, name)
 	if probe.dataOffset > 0 {
-		logger.Debug("Probed continuation volume", "dataOffset", probe.dataOffset, "packedSize", probe.packedSize)
+		log.Debug("Probed continuation volume", "dataOffset", probe.dataOffset, "packedSize", probe.packedSize)
 	}
 
 	first := mainParts[0]
@@ -549,7 +625,7 @@ func aggregateRemainingVolumes(mainParts []filePart, allRarFiles []UnpackableFil
 		})
 		added++
 	}
-	logger.Debug("Manual volume aggregation", "added", added, "total", len(result))
+	log.Debug("Manual volume aggregation", "added", added, "total", len(result))
 	return result
 }
 
@@ -579,7 +655,7 @@ func probeContinuation(allRarFiles []UnpackableFile, startIdx int, targetName st
 		rardecode.ParallelRead(true),
 	)
 	if err != nil {
-		logger.Debug("Continuation probe failed, falling back to zero offset", "err", err)
+		log.Debug("Continuation probe failed, falling back to zero offset", "err", err)
 		return continuationProbe{}
 	}
 
@@ -641,9 +717,9 @@ func tryNestedArchive(parts []filePart) (*ArchiveBlueprint, error) {
 	}
 
 	nestedParts := sets[bestSet].parts
-	logger.Info("Detected nested archive", "set", bestSet, "size", maxSize, "volumes", len(nestedParts))
+	log.Info("Detected nested archive", "set", bestSet, "size", maxSize, "volumes", len(nestedParts))
 	for _, p := range nestedParts {
-		logger.Debug("Nested archive part", "name", p.name, "volName", p.volName, "packed", p.packedSize, "unpacked", p.unpackedSize)
+		log.Debug("Nested archive part", "name", p.name, "volName", p.volName, "packed", p.packedSize, "unpacked", p.unpackedSize)
 	}
 
 	// Build VirtualFiles for each inner archive volume
@@ -684,9 +760,9 @@ func tryNestedArchive(parts []filePart) (*ArchiveBlueprint, error) {
 	}
 
 	for _, nf := range nestedFiles {
-		logger.Debug("Nested VirtualFile", "name", nf.Name(), "size", nf.Size(), "extracted", ExtractFilename(nf.Name()))
+		log.Debug("Nested VirtualFile", "name", nf.Name(), "size", nf.Size(), "extracted", ExtractFilename(nf.Name()))
 	}
-	logger.Info("Recursively scanning nested archive", "set", bestSet, "volumes", len(nestedFiles))
+	log.Info("Recursively scanning nested archive", "set", bestSet, "volumes", len(nestedFiles))
 	return ScanArchive(nestedFiles)
 }
 
@@ -698,13 +774,13 @@ func filterRarFiles(files []UnpackableFile) []UnpackableFile {
 		name := ExtractFilename(f.Name())
 		lower := strings.ToLower(name)
 		if strings.HasSuffix(lower, ExtPar2) {
-			logger.Debug("filterRarFiles: skip par2", "name", name)
+			log.Debug("filterRarFiles: skip par2", "name", name)
 			continue
 		}
 		if strings.HasSuffix(lower, ExtRar) || strings.Contains(lower, ".part") || IsRarPart(lower) || IsSplitArchivePart(lower) {
 			result = append(result, f)
 		} else {
-			logger.Debug("filterRarFiles: skip non-rar", "name", name)
+			log.Debug("filterRarFiles: skip non-rar", "name", name)
 		}
 	}
 	return result
@@ -715,15 +791,15 @@ func filterFirstVolumes(files []UnpackableFile) []UnpackableFile {
 	for _, f := range files {
 		name := strings.ToLower(ExtractFilename(f.Name()))
 		if strings.HasSuffix(name, ExtRar) && !strings.Contains(name, ".part") && !strings.Contains(name, ".r0") {
-			logger.Debug("filterFirstVolumes: accept .rar first vol", "name", name)
+			log.Debug("filterFirstVolumes: accept .rar first vol", "name", name)
 			result = append(result, f)
 			continue
 		}
 		if IsMiddleRarVolume(name) {
-			logger.Debug("filterFirstVolumes: skip middle vol", "name", name)
+			log.Debug("filterFirstVolumes: skip middle vol", "name", name)
 			continue
 		}
-		logger.Debug("filterFirstVolumes: accept fallthrough", "name", name)
+		log.Debug("filterFirstVolumes: accept fallthrough", "name", name)
 		result = append(result, f)
 	}
 	return result
@@ -0,0 +1,387 @@
+package unpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	"github.com/javi11/rardecode/v2"
+)
+
+// RAR5 archives can carry a quick-open record: a service block near the end of the
+// volume holding copies of the file headers, located through the main header. Reading
+// it lists the volume without fetching every header spread through the volume. RAR only
+// caches headers of small files by default, so headers missing from the record are read
+// at their known position; anything unexpected falls back to the rardecode scan.
+
+var rar5Signature = []byte("Rar!\x1a\x07\x01\x00")
+
+const (
+	rar5BlockMain    = 1
+	rar5BlockFile    = 2
+	rar5BlockService = 3
+	rar5BlockEnd     = 5
+
+	rar5HasExtra     = 0x0001
+	rar5HasData      = 0x0002
+	rar5DataNotFirst = 0x0008 // data continues from the previous volume
+
+	rar5MainHasVolNumber = 0x0002
+	rar5LocatorQuickOpen = 0x0001
+	rar5ExtraLocator     = 1
+	rar5ExtraEncryption  = 1
+
+	rar5FileIsDir   = 0x0001
+	rar5FileHasTime = 0x0002
+	rar5FileHasCRC  = 0x0004
+
+	rar5MaxHeaderSize = 2 << 20
+	rar5MaxQuickOpen  = 16 << 20
+	rar5MaxBlocks     = 10000
+)
+
+var errRar5Format = errors.New("rar5: malformed header")
+
+// rar5Block is one parsed block header.
+type rar5Block struct {
+	typ      uint64
+	flags    uint64
+	dataSize uint64
+	fields   []byte // type specific fields
+	extra    []byte // extra area records
+	size     int64  // length of the whole block header
+}
+
+// readQuickOpen lists the files of a RAR5 volume using its quick-open record. It
+// returns false when the volume has no usable record and must be scanned normally.
+func readQuickOpen(f UnpackableFile) ([]filePart, bool) {
+	sig := make([]byte, len(rar5Signature))
+	if _, err := readFullAt(f, sig, 0); err != nil || string(sig) != string(rar5Signature) {
+		return nil, false
+	}
+	mainPos := int64(len(rar5Signature))
+	main, err := readRar5Block(f, mainPos)
+	if err != nil || main.typ != rar5BlockMain {
+		return nil, false // encrypted headers or not a RAR5 main header
+	}
+	qoPos, ok := main.quickOpenPos(mainPos)
+	if !ok {
+		return nil, false
+	}
+
+	cached, err := readQuickOpenHeaders(f, qoPos)
+	if err != nil {
+		log.Debug("Unusable RAR5 quick-open record", "name", f.Name(), "err", err)
+		return nil, false
+	}
+
+	// Walk the blocks between the main header and the quick-open block, taking each
+	// header from the record when cached and from the volume otherwise
+	var parts []filePart
+	pos := mainPos + main.size
+	read := 0
+	for i := 0; pos < qoPos; i++ {
+		if i >= rar5MaxBlocks {
+			return nil, false
+		}
+		var b *rar5Block
+		if raw, ok := cached[pos]; ok {
+			b, err = parseRar5Block(raw)
+		} else {
+			b, err = readRar5Block(f, pos)
+			read++
+		}
+		if err != nil || b.typ == rar5BlockEnd {
+			log.Debug("RAR5 quick-open walk failed", "name", f.Name(), "pos", pos, "err", err)
+			return nil, false
+		}
+		if b.typ == rar5BlockFile {
+			part, ok := b.filePart(f, pos)
+			if !ok {
+				return nil, false
+			}
+			if part.name != "" {
+				parts = append(parts, part)
+			}
+		}
+		pos += b.size + int64(b.dataSize)
+	}
+	if pos != qoPos {
+		return nil, false
+	}
+	log.Debug("Listed RAR5 volume from quick-open record", "name", f.Name(), "files", len(parts), "cached", len(cached), "read", read)
+	return parts, true
+}
+
+// quickOpenPos returns the position of the quick-open block from the main header's
+// locator record.
+func (b *rar5Block) quickOpenPos(mainPos int64) (int64, bool) {
+	r := rar5Reader{buf: b.fields}
+	archFlags := r.vint()
+	if archFlags&rar5MainHasVolNumber != 0 && r.vint() != 0 {
+		return 0, false // not the first volume
+	}
+	if r.err != nil {
+		return 0, false
+	}
+	for _, rec := range b.extraRecords() {
+		if rec.typ != rar5ExtraLocator {
+			continue
+		}
+		lr := rar5Reader{buf: rec.data}
+		if lr.vint()&rar5LocatorQuickOpen == 0 {
+			return 0, false
+		}
+		off := lr.vint()
+		if lr.err != nil || off == 0 {
+			return 0, false
+		}
+		return mainPos + int64(off), true
+	}
+	return 0, false
+}
+
+// readQuickOpenHeaders reads the quick-open block at pos and returns the cached
+// headers by their position in the volume.
+func readQuickOpenHeaders(f UnpackableFile, pos int64) (map[int64][]byte, error) {
+	b, err := readRar5Block(f, pos)
+	if err != nil {
+		return nil, err
+	}
+	if b.typ != rar5BlockService || b.dataSize > rar5MaxQuickOpen {
+		return nil, errRar5Format
+	}
+	name, method, _, err := b.fileFields()
+	if err != nil || name != "QO" || method != 0 {
+		return nil, errRar5Format
+	}
+	data := make([]byte, b.dataSize)
+	if _, err := readFullAt(f, data, pos+b.size); err != nil {
+		return nil, err
+	}
+
+	cached := make(map[int64][]byte)
+	r := rar5Reader{buf: data}
+	for len(r.buf) > 0 {
+		crc := r.uint32()
+		start := r.buf
+		size := r.vint()
+		body := r.bytes(size)
+		if r.err != nil {
+			return nil, r.err
+		}
+		if crc32.ChecksumIEEE(start[:len(start)-len(r.buf)]) != crc {
+			return nil, errRar5Format
+		}
+		br := rar5Reader{buf: body}
+		br.vint() // flags
+		offset := br.vint()
+		header := br.bytes(br.vint())
+		if br.err != nil || offset > uint64(pos) {
+			return nil, errRar5Format
+		}
+		cached[pos-int64(offset)] = header
+	}
+	return cached, nil
+}
+
+// filePart converts a file header at pos into the part stored in this volume.
+func (b *rar5Block) filePart(f UnpackableFile, pos int64) (filePart, bool) {
+	if b.flags&rar5DataNotFirst != 0 {
+		return filePart{}, false // not a first volume
+	}
+	name, method, unpacked, err := b.fileFields()
+	if err != nil {
+		return filePart{}, false
+	}
+	if b.isDir() {
+		return filePart{}, true
+	}
+	encrypted := false
+	for _, rec := range b.extraRecords() {
+		if rec.typ == rar5ExtraEncryption {
+			encrypted = true
+		}
+	}
+	return filePart{
+		name:         name,
+		unpackedSize: int64(unpacked),
+		dataOffset:   pos + b.size,
+		packedSize:   int64(b.dataSize),
+		volFile:      f,
+		volName:      f.Name(),
+		isMedia:      isMediaFile(rardecode.ArchiveFileInfo{Name: name, TotalUnpackedSize: int64(unpacked)}),
+		isCompressed: method != 0,
+		isEncrypted:  encrypted,
+	}, true
+}
+
+func (b *rar5Block) isDir() bool {
+	r := rar5Reader{buf: b.fields}
+	return r.vint()&rar5FileIsDir != 0
+}
+
+// fileFields parses the fields shared by file and service headers.
+func (b *rar5Block) fileFields() (name string, method, unpacked uint64, err error) {
+	r := rar5Reader{buf: b.fields}
+	fileFlags := r.vint()
+	unpacked = r.vint()
+	r.vint() // attributes
+	if fileFlags&rar5FileHasTime != 0 {
+		r.uint32()
+	}
+	if fileFlags&rar5FileHasCRC != 0 {
+		r.uint32()
+	}
+	compInfo := r.vint()
+	r.vint() // host OS
+	name = string(r.bytes(r.vint()))
+	if r.err != nil {
+		return "", 0, 0, r.err
+	}
+	return name, (compInfo >> 7) & 7, unpacked, nil
+}
+
+type rar5Extra struct {
+	typ  uint64
+	data []byte
+}
+
+func (b *rar5Block) extraRecords() []rar5Extra {
+	var recs []rar5Extra
+	r := rar5Reader{buf: b.extra}
+	for len(r.buf) > 0 {
+		rec := rar5Reader{buf: r.bytes(r.vint())}
+		typ := rec.vint()
+		if r.err != nil || rec.err != nil {
+			break
+		}
+		recs = append(recs, rar5Extra{typ: typ, data: rec.buf})
+	}
+	return recs
+}
+
+// readRar5Block reads and parses the block header at pos.
+func readRar5Block(f UnpackableFile, pos int64) (*rar5Block, error) {
+	// CRC32 plus the header size vint (at most 3 bytes for the 2 MB limit)
+	head := make([]byte, 7)
+	n, err := readFullAt(f, head, pos)
+	if n < 5 {
+		if err == nil {
+			err = errRar5Format
+		}
+		return nil, err
+	}
+	r := rar5Reader{buf: head[4:n]}
+	size := r.vint()
+	if r.err != nil || size == 0 || size > rar5MaxHeaderSize {
+		return nil, errRar5Format
+	}
+	raw := make([]byte, 4+(n-4-len(r.buf))+int(size))
+	if _, err := readFullAt(f, raw, pos); err != nil {
+		return nil, err
+	}
+	return parseRar5Block(raw)
+}
+
+// parseRar5Block parses a complete block header, verifying its CRC.
+func parseRar5Block(raw []byte) (*rar5Block, error) {
+	r := rar5Reader{buf: raw}
+	crc := r.uint32()
+	if r.err != nil || crc32.ChecksumIEEE(r.buf) != crc {
+		return nil, errRar5Format
+	}
+	body := r.bytes(r.vint())
+	if r.err != nil || len(r.buf) != 0 {
+		return nil, errRar5Format
+	}
+
+	br := rar5Reader{buf: body}
+	b := &rar5Block{size: int64(len(raw))}
+	b.typ = br.vint()
+	b.flags = br.vint()
+	var extraSize uint64
+	if b.flags&rar5HasExtra != 0 {
+		extraSize = br.vint()
+	}
+	if b.flags&rar5HasData != 0 {
+		b.dataSize = br.vint()
+	}
+	if br.err != nil || extraSize > uint64(len(br.buf)) {
+		return nil, errRar5Format
+	}
+	split := len(br.buf) - int(extraSize)
+	b.fields, b.extra = br.buf[:split], br.buf[split:]
+	return b, nil
+}
+
+// readFullAt reads len(p) bytes at off; a short read is only an error when nothing
+// was read.
+func readFullAt(f UnpackableFile, p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) {
+		m, err := f.ReadAt(p[n:], off+int64(n))
+		n += m
+		if err == io.EOF {
+			if n < len(p) {
+				return n, io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		if m == 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+	}
+	return n, nil
+}
+
+// rar5Reader decodes the little-endian integers and vints of RAR5 headers. The
+// first decoding error sticks; later reads return zero values.
+type rar5Reader struct {
+	buf []byte
+	err error
+}
+
+func (r *rar5Reader) vint() uint64 {
+	var v uint64
+	for i := 0; i < len(r.buf) && i < 10; i++ {
+		v |= uint64(r.buf[i]&0x7f) << (7 * i)
+		if r.buf[i]&0x80 == 0 {
+			r.buf = r.buf[i+1:]
+			return v
+		}
+	}
+	r.fail()
+	return 0
+}
+
+func (r *rar5Reader) uint32() uint32 {
+	if len(r.buf) < 4 {
+		r.fail()
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.buf)
+	r.buf = r.buf[4:]
+	return v
+}
+
+func (r *rar5Reader) bytes(n uint64) []byte {
+	if n > uint64(len(r.buf)) {
+		r.fail()
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *rar5Reader) fail() {
+	if r.err == nil {
+		r.err = errRar5Format
+	}
+	r.buf = nil
+}
@@ -0,0 +1,182 @@
+package unpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+
+	"github.com/javi11/rardecode/v2"
+)
+
+// memFile is an in-memory UnpackableFile.
+type memFile struct {
+	name string
+	data []byte
+}
+
+func (m *memFile) Name() string { return m.name }
+func (m *memFile) Size() int64  { return int64(len(m.data)) }
+func (m *memFile) OpenStream() (io.ReadSeekCloser, error) {
+	return nopSeekCloser{bytes.NewReader(m.data)}, nil
+}
+func (m *memFile) OpenReaderAt(_ context.Context, offset int64) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m.data[offset:])), nil
+}
+func (m *memFile) ReadAt(p []byte, off int64) (int, error) {
+	return bytes.NewReader(m.data).ReadAt(p, off)
+}
+
+type nopSeekCloser struct{ *bytes.Reader }
+
+func (nopSeekCloser) Close() error { return nil }
+
+func appendVint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// rar5Header builds a block header with its CRC and size.
+func rar5Header(typ, flags uint64, extra []byte, dataSize uint64, fields []byte) []byte {
+	var body []byte
+	body = appendVint(body, typ)
+	if len(extra) > 0 {
+		flags |= rar5HasExtra
+	}
+	if dataSize > 0 {
+		flags |= rar5HasData
+	}
+	body = appendVint(body, flags)
+	if len(extra) > 0 {
+		body = appendVint(body, uint64(len(extra)))
+	}
+	if dataSize > 0 {
+		body = appendVint(body, dataSize)
+	}
+	body = append(append(body, fields...), extra...)
+	sized := append(appendVint(nil, uint64(len(body))), body...)
+	return append(binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(sized)), sized...)
+}
+
+func rar5FileFields(name string, size uint64) []byte {
+	var f []byte
+	f = appendVint(f, 0)    // file flags
+	f = appendVint(f, size) // unpacked size
+	f = appendVint(f, 0)    // attributes
+	f = appendVint(f, 0)    // compression: stored
+	f = appendVint(f, 1)    // host OS
+	f = appendVint(f, uint64(len(name)))
+	return append(f, name...)
+}
+
+// buildRar5 builds a stored RAR5 volume. cache selects which file headers are copied
+// into the quick-open record; nil builds a volume without one.
+func buildRar5(files map[string]int, order []string, cache map[string]bool) []byte {
+	// Fixed-width locator offset so the main header size does not depend on it
+	mainHeader := func(qoOffset uint64) []byte {
+		var loc []byte
+		loc = appendVint(loc, rar5ExtraLocator)
+		loc = appendVint(loc, rar5LocatorQuickOpen)
+		loc = append(loc, byte(qoOffset)|0x80, byte(qoOffset>>7)|0x80, byte(qoOffset>>14))
+		extra := append(appendVint(nil, uint64(len(loc))), loc...)
+		return rar5Header(rar5BlockMain, 0, extra, 0, appendVint(nil, 0))
+	}
+	if cache == nil {
+		mainHeader = func(uint64) []byte { return rar5Header(rar5BlockMain, 0, nil, 0, appendVint(nil, 0)) }
+	}
+
+	out := append([]byte(nil), rar5Signature...)
+	out = append(out, mainHeader(0)...)
+	type cached struct {
+		pos    int
+		header []byte
+	}
+	var headers []cached
+	for _, name := range order {
+		size := files[name]
+		h := rar5Header(rar5BlockFile, 0, nil, uint64(size), rar5FileFields(name, uint64(size)))
+		if cache[name] {
+			headers = append(headers, cached{len(out), h})
+		}
+		out = append(out, h...)
+		out = append(out, bytes.Repeat([]byte{'x'}, size)...)
+	}
+
+	if cache != nil {
+		qoPos := len(out)
+		var data []byte
+		for _, c := range headers {
+			var rec []byte
+			rec = appendVint(rec, 0)
+			rec = appendVint(rec, uint64(qoPos-c.pos))
+			rec = appendVint(rec, uint64(len(c.header)))
+			rec = append(rec, c.header...)
+			sized := append(appendVint(nil, uint64(len(rec))), rec...)
+			data = append(data, binary.LittleEndian.AppendUint32(nil, crc32.ChecksumIEEE(sized))...)
+			data = append(data, sized...)
+		}
+		out = append(out, rar5Header(rar5BlockService, 0, nil, uint64(len(data)), rar5FileFields("QO", 0))...)
+		out = append(out, data...)
+		main := mainHeader(uint64(qoPos - len(rar5Signature)))
+		copy(out[len(rar5Signature):], main)
+	}
+	return append(out, rar5Header(rar5BlockEnd, 0, nil, 0, appendVint(nil, 0))...)
+}
+
+func TestReadQuickOpen(t *testing.T) {
+	logger.Init("DEBUG")
+	files := map[string]int{"movie.nfo": 40, "Sample/movie-sample.mkv": 300, "movie.mkv": 5000}
+	order := []string{"movie.nfo", "Sample/movie-sample.mkv", "movie.mkv"}
+
+	tests := []struct {
+		name  string
+		cache map[string]bool
+	}{
+		{"all headers cached", map[string]bool{"movie.nfo": true, "Sample/movie-sample.mkv": true, "movie.mkv": true}},
+		{"large file not cached", map[string]bool{"movie.nfo": true, "Sample/movie-sample.mkv": true}},
+		{"empty record", map[string]bool{}},
+	}
+	for _, tt := range tests {
+		vol := &memFile{name: "movie.rar", data: buildRar5(files, order, tt.cache)}
+
+		// The rardecode scan of the same volume is the reference
+		infos, err := rardecode.ListArchiveInfo("movie.rar", rardecode.FileSystem(NewNZBFSFromMap(map[string]UnpackableFile{"movie.rar": vol})), rardecode.SkipVolumeCheck)
+		if err != nil {
+			t.Fatalf("%s: rardecode: %v", tt.name, err)
+		}
+		want := make(map[string]int64)
+		for _, info := range infos {
+			want[info.Name] = info.Parts[0].DataOffset
+		}
+
+		parts, ok := readQuickOpen(vol)
+		if !ok {
+			t.Fatalf("%s: quick-open record not used", tt.name)
+		}
+		if len(parts) != len(want) {
+			t.Fatalf("%s: got %d parts, want %d", tt.name, len(parts), len(want))
+		}
+		for _, p := range parts {
+			if off, found := want[p.name]; !found || off != p.dataOffset {
+				t.Errorf("%s: %s at offset %d, rardecode says %d", tt.name, p.name, p.dataOffset, off)
+			}
+			if p.packedSize != int64(files[p.name]) || p.isCompressed || p.isEncrypted {
+				t.Errorf("%s: part %+v", tt.name, p)
+			}
+			if p.isMedia != (p.name == "movie.mkv" || p.name == "Sample/movie-sample.mkv") {
+				t.Errorf("%s: %s isMedia = %v", tt.name, p.name, p.isMedia)
+			}
+		}
+	}
+
+	if _, ok := readQuickOpen(&memFile{name: "old.rar", data: buildRar5(files, order, nil)}); ok {
+		t.Error("volume without a quick-open record was listed")
+	}
+}
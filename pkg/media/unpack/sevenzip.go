@@ -9,7 +9,6 @@ import (
 	"sort"
 	"strings"
 
-	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/media/loader"
 
 	"github.com/javi11/sevenzip"
@@ -17,10 +16,44 @@ import (
 
 // SevenZipBlueprint stores metadata about an uncompressed file inside a 7z archive.
 type SevenZipBlueprint struct {
-	MainFileName string
-	TotalSize    int64
-	FileOffset   int64
-	Files        []*loader.File
+	MainFileName string         `json:"main_file_name"`
+	TotalSize    int64          `json:"total_size"`
+	FileOffset   int64          `json:"file_offset"`
+	Files        []*loader.File `json:"-"` // persisted as file indices, see MarshalBlueprint
+}
+
+// select7zVideo returns the index of the largest stored (uncompressed, unencrypted)
+// video in a 7z listing, or -1 when there is none. Sample and proof files are skipped
+// only when a larger video exists in the listing, even one that cannot be streamed,
+// so a compressed main feature never falls back to its sample. encryptedVideo names
+// an encrypted video that was passed over.
+func select7zVideo(fileInfos []sevenzip.FileInfo) (best int, encryptedVideo string) {
+	var largest uint64
+	for _, fi := range fileInfos {
+		if IsVideoFile(fi.Name) && !IsSampleFile(fi.Name) && !IsProofFile(fi.Name) && fi.Size > largest {
+			largest = fi.Size
+		}
+	}
+	best = -1
+	for i, fi := range fileInfos {
+		if !IsVideoFile(fi.Name) {
+			continue
+		}
+		if (IsSampleFile(fi.Name) || IsProofFile(fi.Name)) && largest > fi.Size {
+			continue
+		}
+		if fi.Encrypted {
+			encryptedVideo = fi.Name
+			continue
+		}
+		if fi.Compressed {
+			continue
+		}
+		if best == -1 || fi.Size > fileInfos[best].Size {
+			best = i
+		}
+	}
+	return best, encryptedVideo
 }
 
 // CreateSevenZipBlueprint scans a 7z archive and builds a cached blueprint
@@ -32,26 +65,25 @@ func CreateSevenZipBlueprint(files []*loader.File, firstVolName string) (*SevenZ
 
 	r, err := sevenzip.NewReader(mr, mr.Size())
 	if err != nil {
+		if Is7zEncryptedError(err) {
+			return nil, fmt.Errorf("%w (7z headers): %v", ErrEncryptedArchive, err)
+		}
 		return nil, fmt.Errorf("failed to open 7z archive: %w", err)
 	}
 
 	fileInfos, err := r.ListFilesWithOffsets()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list 7z files: %w", err)
-	}
-
-	bestIdx, bestSize := -1, int64(0)
-	for i, fi := range fileInfos {
-		if !IsVideoFile(fi.Name) || fi.Compressed || IsSampleFile(fi.Name) {
-			continue
-		}
-		if int64(fi.Size) > bestSize {
-			bestIdx = i
-			bestSize = int64(fi.Size)
+		if Is7zEncryptedError(err) {
+			return nil, fmt.Errorf("%w (7z headers): %v", ErrEncryptedArchive, err)
 		}
+		return nil, fmt.Errorf("failed to list 7z files: %w", err)
 	}
 
+	bestIdx, encryptedVideo := select7zVideo(fileInfos)
 	if bestIdx == -1 {
+		if encryptedVideo != "" {
+			return nil, fmt.Errorf("%w (file: %s)", ErrEncryptedArchive, encryptedVideo)
+		}
 		return nil, errors.New("no uncompressed media found in 7z")
 	}
 
@@ -62,13 +94,12 @@ func CreateSevenZipBlueprint(files []*loader.File, firstVolName string) (*SevenZ
 		FileOffset:   fi.Offset,
 		Files:        archiveFiles,
 	}
-	logger.Debug("Created 7z blueprint", "name", bp.MainFileName, "offset", bp.FileOffset, "size", bp.TotalSize)
+	log.Debug("Created 7z blueprint", "name", bp.MainFileName, "offset", bp.FileOffset, "size", bp.TotalSize)
 
-	// Pre-warm the last volume's final segment so end-of-file seeks
-	// (MKV Cues / MP4 moov atom) are fast on first play.
-	if len(archiveFiles) > 0 {
-		lastFile := archiveFiles[len(archiveFiles)-1]
-		lastFile.PrewarmSegment(lastFile.SegmentCount() - 1)
+	// Pre-warm the media file's tail so end-of-file seeks (MKV Cues / MP4
+	// moov atom) are fast on first play.
+	if streamParts, err := mapOffsetToParts(parts, bp.FileOffset, bp.TotalSize); err == nil {
+		prewarmTail(streamParts)
 	}
 
 	return bp, nil
@@ -86,10 +117,32 @@ func Open7zStreamFromBlueprint(ctx context.Context, bp *SevenZipBlueprint) (Read
 		return nil, "", 0, err
 	}
 
+	// Blueprints restored from the session or disk cache skipped the scan;
+	// no-op when the tail is already cached.
+	prewarmTail(streamParts)
+
 	vs := NewVirtualStream(ctx, streamParts, bp.TotalSize, 0)
 	return vs, bp.MainFileName, bp.TotalSize, nil
 }
 
+// prewarmTail downloads the segment holding the last byte of the mapped file in
+// the background. For multi-volume archives that is usually in the last volume,
+// which would otherwise only be touched when the player seeks to the end.
+func prewarmTail(parts []virtualPart) {
+	if len(parts) == 0 {
+		return
+	}
+	last := parts[len(parts)-1]
+	f, ok := last.VolFile.(*loader.File)
+	if !ok || last.VirtualEnd <= last.VirtualStart {
+		return
+	}
+	tailOff := last.VolOffset + (last.VirtualEnd - last.VirtualStart) - 1
+	if idx := f.FindSegmentIndex(tailOff); idx >= 0 {
+		f.PrewarmSegment(idx)
+	}
+}
+
 // --- helpers ---
 
 func filter7zFiles(files []*loader.File) []*loader.File {
@@ -228,3 +281,14 @@ func (c *ConcatenatedReaderAt) ReadAt(p []byte, off int64) (int, error) {
 }
 
 func (c *ConcatenatedReaderAt) Size() int64 { return c.total }
+
+// Is7zEncryptedError reports whether err from the sevenzip reader was caused by
+// encryption: either a ReadError flagged as encrypted or AES-encrypted headers,
+// which fail with "no password set" before any file can be listed.
+func Is7zEncryptedError(err error) bool {
+	var re *sevenzip.ReadError
+	if errors.As(err, &re) && re.Encrypted {
+		return true
+	}
+	return err != nil && strings.Contains(err.Error(), "no password set")
+}
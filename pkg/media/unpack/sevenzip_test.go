@@ -0,0 +1,92 @@
+package unpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+
+	"github.com/javi11/sevenzip"
+)
+
+func TestSevenZipTailPrewarm(t *testing.T) {
+	logger.Init("DEBUG")
+	// Two 2000-byte volumes; the media file ends 100 bytes before the archive end,
+	// in the last segment of the second volume.
+	files := testFiles("movie.7z.001", "movie.7z.002")
+	bp := &SevenZipBlueprint{MainFileName: "movie.mkv", FileOffset: 32, TotalSize: 4000 - 32 - 100, Files: files}
+
+	stream, _, size, err := Open7zStreamFromBlueprint(context.Background(), bp)
+	if err != nil {
+		t.Fatalf("Open7zStreamFromBlueprint failed: %v", err)
+	}
+	defer stream.Close()
+
+	last := files[1]
+	tailIdx := last.FindSegmentIndex(1899)
+	if tailIdx != last.SegmentCount()-1 {
+		t.Fatalf("expected tail in last segment, got index %d", tailIdx)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := last.GetCachedSegment(tailIdx); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("tail segment was not prewarmed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := stream.Seek(size-1, io.SeekStart); err != nil {
+		t.Fatalf("seek to end failed: %v", err)
+	}
+	buf := make([]byte, 1)
+	if n, err := stream.Read(buf); n != 1 || (err != nil && err != io.EOF) {
+		t.Fatalf("read at end: n=%d err=%v", n, err)
+	}
+}
+
+func TestIs7zEncryptedError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("sevenzip: not a valid 7-zip file"), false},
+		{&sevenzip.ReadError{Encrypted: true, Err: io.ErrUnexpectedEOF}, true},
+		{fmt.Errorf("list: %w", &sevenzip.ReadError{Encrypted: true, Err: io.ErrUnexpectedEOF}), true},
+		{&sevenzip.ReadError{Err: io.ErrUnexpectedEOF}, false},
+		{errors.New("aes7z: no password set"), true},
+	}
+	for _, c := range cases {
+		if got := Is7zEncryptedError(c.err); got != c.want {
+			t.Errorf("Is7zEncryptedError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestSelect7zVideo(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         []sevenzip.FileInfo
+		want          int
+		wantEncrypted string
+	}{
+		{"proof smaller than main", []sevenzip.FileInfo{{Name: "group-proof.mkv", Size: 10}, {Name: "movie.mkv", Size: 5000}}, 1, ""},
+		{"proof-named feature", []sevenzip.FileInfo{{Name: "Death.Proof.2007.1080p.mkv", Size: 5000}, {Name: "movie.nfo", Size: 1}}, 0, ""},
+		{"only a sample", []sevenzip.FileInfo{{Name: "movie.sample.mkv", Size: 50}}, 0, ""},
+		{"compressed main keeps sample out", []sevenzip.FileInfo{{Name: "movie.sample.mkv", Size: 50}, {Name: "movie.mkv", Size: 5000, Compressed: true}}, -1, ""},
+		{"encrypted main", []sevenzip.FileInfo{{Name: "movie.mkv", Size: 5000, Encrypted: true}}, -1, "movie.mkv"},
+	}
+	for _, tt := range tests {
+		got, encrypted := select7zVideo(tt.files)
+		if got != tt.want || encrypted != tt.wantEncrypted {
+			t.Errorf("%s: select7zVideo = (%d, %q), want (%d, %q)", tt.name, got, encrypted, tt.want, tt.wantEncrypted)
+		}
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"sync"
 	"time"
 
-	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/media/loader"
 )
 
@@ -79,12 +78,12 @@ func (s *VirtualStream) readLocked(p []byte) (int, error) {
 		return 0, fmt.Errorf("offset %d not mapped in %d parts", s.offset, len(s.parts))
 	}
 
-	logger.Trace("VirtualStream.readLocked: before ensureReader", "offset", s.offset, "partIdx", partIdx, "bufSize", len(p))
+	log.Trace("VirtualStream.readLocked: before ensureReader", "offset", s.offset, "partIdx", partIdx, "bufSize", len(p))
 	if err := s.ensureReader(part, partIdx); err != nil {
-		logger.Trace("VirtualStream.readLocked: ensureReader failed", "err", err)
+		log.Trace("VirtualStream.readLocked: ensureReader failed", "err", err)
 		return 0, err
 	}
-	logger.Trace("VirtualStream.readLocked: after ensureReader", "offset", s.offset, "partIdx", partIdx)
+	log.Trace("VirtualStream.readLocked: after ensureReader", "offset", s.offset, "partIdx", partIdx)
 
 	remaining := part.VirtualEnd - s.offset
 	buf := p
@@ -92,13 +91,13 @@ func (s *VirtualStream) readLocked(p []byte) (int, error) {
 		buf = buf[:remaining]
 	}
 
-	logger.Trace("VirtualStream.readLocked: calling reader.Read", "offset", s.offset, "bufSize", len(buf))
+	log.Trace("VirtualStream.readLocked: calling reader.Read", "offset", s.offset, "bufSize", len(buf))
 	n, err := s.currentReader.Read(buf)
-	logger.Trace("VirtualStream.readLocked: reader.Read returned", "n", n, "err", err, "offset", s.offset)
+	log.Trace("VirtualStream.readLocked: reader.Read returned", "n", n, "err", err, "offset", s.offset)
 	s.offset += int64(n)
 
 	if err == io.EOF {
-		logger.Trace("VirtualStream.readLocked: EOF, closing reader", "n", n)
+		log.Trace("VirtualStream.readLocked: EOF, closing reader", "n", n)
 		s.closeReader()
 		// Advance past current part so next iteration finds the next one.
 		// This prevents an infinite loop when a reader returns (0, EOF)
@@ -143,12 +142,12 @@ func (s *VirtualStream) Seek(offset int64, whence int) (int64, error) {
 	}
 
 	if target == s.offset {
-		logger.Trace("VirtualStream.Seek: no-op", "offset", s.offset)
+		log.Trace("VirtualStream.Seek: no-op", "offset", s.offset)
 		return target, nil
 	}
 
-	logger.Debug("VirtualStream.Seek: start", "from", s.offset, "to", target, "whence", whence, "currentPart", s.currentPart)
-	logger.Trace("VirtualStream.Seek: start", "from", s.offset, "to", target, "whence", whence, "currentPart", s.currentPart)
+	log.Debug("VirtualStream.Seek: start", "from", s.offset, "to", target, "whence", whence, "currentPart", s.currentPart)
+	log.Trace("VirtualStream.Seek: start", "from", s.offset, "to", target, "whence", whence, "currentPart", s.currentPart)
 
 	// Check if we're staying in the same part - if so, reuse the reader and seek within it
 	part, partIdx := s.findPart(target)
@@ -156,18 +155,18 @@ func (s *VirtualStream) Seek(offset int64, whence int) (int64, error) {
 		// Same part - seek within the existing reader (reuse connection)
 		localOff := target - part.VirtualStart
 		volOff := part.VolOffset + localOff
-		logger.Trace("VirtualStream.Seek: same part, reusing reader", "partIdx", partIdx, "volOff", volOff)
+		log.Trace("VirtualStream.Seek: same part, reusing reader", "partIdx", partIdx, "volOff", volOff)
 
 		// Prefetch target segment even when reusing reader (SegmentReader.Seek handles this too, but
 		// doing it here ensures it starts immediately when http.ServeContent calls Seek)
 		if volFile, ok := part.VolFile.(*loader.File); ok && volOff > 0 {
 			if err := volFile.EnsureSegmentMap(); err == nil {
 				if segIdx := volFile.FindSegmentIndex(volOff); segIdx >= 0 {
-					if _, cached := volFile.GetCachedSegment(segIdx); !cached {
-						logger.Debug("VirtualStream.Seek: prefetching segment in same part", "segIdx", segIdx, "volOff", volOff)
-						logger.Trace("VirtualStream.Seek: prefetching segment in same part", "segIdx", segIdx)
+					if !volFile.HasCachedSegment(segIdx) {
+						log.Debug("VirtualStream.Seek: prefetching segment in same part", "segIdx", segIdx, "volOff", volOff)
+						log.Trace("VirtualStream.Seek: prefetching segment in same part", "segIdx", segIdx)
 						done := volFile.StartDownloadSegment(s.ctx, segIdx)
-						logger.Debug("VirtualStream.Seek: prefetch registered (same part)", "segIdx", segIdx, "hasChannel", done != nil)
+						log.Debug("VirtualStream.Seek: prefetch registered (same part)", "segIdx", segIdx, "hasChannel", done != nil)
 					}
 				}
 			}
@@ -181,31 +180,31 @@ func (s *VirtualStream) Seek(offset int64, whence int) (int64, error) {
 			if seekDelta != 0 {
 				newPos, err := seeker.Seek(seekDelta, io.SeekCurrent)
 				if err == nil {
-					logger.Trace("VirtualStream.Seek: seeked within reader", "delta", seekDelta, "newPos", newPos)
+					log.Trace("VirtualStream.Seek: seeked within reader", "delta", seekDelta, "newPos", newPos)
 					s.offset = target
 					return target, nil
 				}
-				logger.Trace("VirtualStream.Seek: seek failed, will recreate", "err", err)
+				log.Trace("VirtualStream.Seek: seek failed, will recreate", "err", err)
 				// Seek failed, fall through to close and recreate
 			} else {
 				// Already at the right position
-				logger.Trace("VirtualStream.Seek: already at position")
+				log.Trace("VirtualStream.Seek: already at position")
 				s.offset = target
 				return target, nil
 			}
 		} else {
-			logger.Trace("VirtualStream.Seek: reader not seekable, will recreate")
+			log.Trace("VirtualStream.Seek: reader not seekable, will recreate")
 		}
 	} else {
 		if part != nil {
-			logger.Trace("VirtualStream.Seek: different part", "oldPart", s.currentPart, "newPart", partIdx)
+			log.Trace("VirtualStream.Seek: different part", "oldPart", s.currentPart, "newPart", partIdx)
 		} else {
-			logger.Trace("VirtualStream.Seek: part not found for target", "target", target)
+			log.Trace("VirtualStream.Seek: part not found for target", "target", target)
 		}
 	}
 
 	// Different part or seek failed - close current reader; a new one will be opened on next Read
-	logger.Trace("VirtualStream.Seek: closing reader, will recreate on next Read", "oldPart", s.currentPart)
+	log.Trace("VirtualStream.Seek: closing reader, will recreate on next Read", "oldPart", s.currentPart)
 	s.closeReader()
 	s.offset = target
 
@@ -234,8 +233,8 @@ func (s *VirtualStream) Seek(offset int64, whence int) (int64, error) {
 		localOff := target - part.VirtualStart
 		volOff := part.VolOffset + localOff
 		if volFile, ok := part.VolFile.(*loader.File); ok && volOff > 0 {
-			logger.Debug("VirtualStream.Seek: prefetching target segment", "volOff", volOff, "partIdx", partIdx)
-			logger.Trace("VirtualStream.Seek: prefetching target segment", "volOff", volOff, "partIdx", partIdx)
+			log.Debug("VirtualStream.Seek: prefetching target segment", "volOff", volOff, "partIdx", partIdx)
+			log.Trace("VirtualStream.Seek: prefetching target segment", "volOff", volOff, "partIdx", partIdx)
 			if err := volFile.EnsureSegmentMap(); err == nil {
 				if segIdx := volFile.FindSegmentIndex(volOff); segIdx >= 0 {
 					// Always start prefetch and wait (done closes immediately if already cached).
@@ -243,24 +242,24 @@ func (s *VirtualStream) Seek(offset int64, whence int) (int64, error) {
 					// each volume has its own segment cache, so part 2's seg 0 is rarely cached until
 					// we request it; without waiting, first Read() blocks and client gets no data,
 					// then may open range=0- ("download from start").
-					logger.Debug("VirtualStream.Seek: starting prefetch", "segIdx", segIdx, "volOff", volOff, "partIdx", partIdx)
+					log.Debug("VirtualStream.Seek: starting prefetch", "segIdx", segIdx, "volOff", volOff, "partIdx", partIdx)
 					done := volFile.StartDownloadSegment(s.ctx, segIdx)
-					logger.Debug("VirtualStream.Seek: prefetch registered", "segIdx", segIdx, "hasChannel", done != nil)
+					log.Debug("VirtualStream.Seek: prefetch registered", "segIdx", segIdx, "hasChannel", done != nil)
 					select {
 					case <-done:
-						logger.Trace("VirtualStream.Seek: target segment ready", "segIdx", segIdx)
+						log.Trace("VirtualStream.Seek: target segment ready", "segIdx", segIdx)
 					case <-s.ctx.Done():
 					case <-time.After(15 * time.Second):
-						logger.Trace("VirtualStream.Seek: prefetch wait timeout", "segIdx", segIdx)
+						log.Trace("VirtualStream.Seek: prefetch wait timeout", "segIdx", segIdx)
 					}
 				} else {
-					logger.Debug("VirtualStream.Seek: segment index not found", "volOff", volOff)
+					log.Debug("VirtualStream.Seek: segment index not found", "volOff", volOff)
 				}
 			} else {
-				logger.Debug("VirtualStream.Seek: EnsureSegmentMap failed", "err", err)
+				log.Debug("VirtualStream.Seek: EnsureSegmentMap failed", "err", err)
 			}
 		} else {
-			logger.Debug("VirtualStream.Seek: not RAR volume or volOff=0", "isLoaderFile", ok, "volOff", volOff)
+			log.Debug("VirtualStream.Seek: not RAR volume or volOff=0", "isLoaderFile", ok, "volOff", volOff)
 		}
 	}
 
@@ -300,58 +299,58 @@ func (s *VirtualStream) findPart(offset int64) (*virtualPart, int) {
 
 func (s *VirtualStream) ensureReader(part *virtualPart, partIdx int) error {
 	if s.currentReader != nil && s.currentPart == partIdx {
-		logger.Trace("VirtualStream.ensureReader: reader already open", "partIdx", partIdx)
+		log.Trace("VirtualStream.ensureReader: reader already open", "partIdx", partIdx)
 		return nil
 	}
 
-	logger.Trace("VirtualStream.ensureReader: creating new reader", "partIdx", partIdx, "currentPart", s.currentPart)
+	log.Trace("VirtualStream.ensureReader: creating new reader", "partIdx", partIdx, "currentPart", s.currentPart)
 	s.closeReader()
 
 	localOff := s.offset - part.VirtualStart
 	volOff := part.VolOffset + localOff
-	logger.Trace("VirtualStream.ensureReader: calculated offsets", "localOff", localOff, "volOff", volOff)
+	log.Trace("VirtualStream.ensureReader: calculated offsets", "localOff", localOff, "volOff", volOff)
 
 	// For loader.File volumes, ensure segment map and wait for the target segment before
 	// opening the reader, so the first Read() returns quickly instead of blocking in
 	// DownloadSegment (which can cause client timeouts and range=0- retries).
 	if volFile, ok := part.VolFile.(*loader.File); ok && volOff > 0 {
-		logger.Trace("VirtualStream.ensureReader: RAR volume detected", "volOff", volOff)
+		log.Trace("VirtualStream.ensureReader: RAR volume detected", "volOff", volOff)
 		if err := volFile.EnsureSegmentMap(); err == nil {
 			if segIdx := volFile.FindSegmentIndex(volOff); segIdx >= 0 {
-				if _, cached := volFile.GetCachedSegment(segIdx); !cached {
-					logger.Trace("VirtualStream.ensureReader: starting prefetch and waiting", "segIdx", segIdx, "volOff", volOff)
+				if !volFile.HasCachedSegment(segIdx) {
+					log.Trace("VirtualStream.ensureReader: starting prefetch and waiting", "segIdx", segIdx, "volOff", volOff)
 					done := volFile.StartDownloadSegment(s.ctx, segIdx)
-					logger.Trace("VirtualStream.ensureReader: prefetch registered", "segIdx", segIdx, "hasChannel", done != nil)
+					log.Trace("VirtualStream.ensureReader: prefetch registered", "segIdx", segIdx, "hasChannel", done != nil)
 					select {
 					case <-done:
-						logger.Trace("VirtualStream.ensureReader: target segment ready", "segIdx", segIdx)
+						log.Trace("VirtualStream.ensureReader: target segment ready", "segIdx", segIdx)
 					case <-s.ctx.Done():
 					case <-time.After(15 * time.Second):
-						logger.Trace("VirtualStream.ensureReader: prefetch wait timeout", "segIdx", segIdx)
+						log.Trace("VirtualStream.ensureReader: prefetch wait timeout", "segIdx", segIdx)
 					}
 				} else {
-					logger.Trace("VirtualStream.ensureReader: segment already cached", "segIdx", segIdx)
+					log.Trace("VirtualStream.ensureReader: segment already cached", "segIdx", segIdx)
 				}
 			} else {
-				logger.Trace("VirtualStream.ensureReader: segment index not found", "volOff", volOff)
+				log.Trace("VirtualStream.ensureReader: segment index not found", "volOff", volOff)
 			}
 		} else {
-			logger.Trace("VirtualStream.ensureReader: EnsureSegmentMap failed", "err", err)
+			log.Trace("VirtualStream.ensureReader: EnsureSegmentMap failed", "err", err)
 		}
 	} else {
-		logger.Trace("VirtualStream.ensureReader: not RAR volume or volOff=0", "isLoaderFile", ok, "volOff", volOff)
+		log.Trace("VirtualStream.ensureReader: not RAR volume or volOff=0", "isLoaderFile", ok, "volOff", volOff)
 	}
 
-	logger.Trace("VirtualStream.ensureReader: opening reader", "partIdx", partIdx, "volOff", volOff)
+	log.Trace("VirtualStream.ensureReader: opening reader", "partIdx", partIdx, "volOff", volOff)
 	r, err := part.VolFile.OpenReaderAt(s.ctx, volOff)
 	if err != nil {
-		logger.Trace("VirtualStream.ensureReader: OpenReaderAt failed", "err", err, "partIdx", partIdx, "volOff", volOff)
+		log.Trace("VirtualStream.ensureReader: OpenReaderAt failed", "err", err, "partIdx", partIdx, "volOff", volOff)
 		return fmt.Errorf("open volume part %d at offset %d: %w", partIdx, volOff, err)
 	}
 
 	s.currentReader = r
 	s.currentPart = partIdx
-	logger.Trace("VirtualStream.ensureReader: reader opened successfully", "partIdx", partIdx)
+	log.Trace("VirtualStream.ensureReader: reader opened successfully", "partIdx", partIdx)
 	return nil
 }
 
@@ -41,6 +41,11 @@ type Release struct {
 	GUID        string // For session ID when skipping validation
 	QuerySource string // "id" or "text" — ID-based results prioritized
 	Grabs       int    // From newznab grabs attribute, for popularity scoring
+	HasGrabs    bool   // The indexer reported grabs (Grabs 0 is a real count)
+
+	// Same release (by normalized title) returned by other indexers; used as
+	// download fallbacks for deferred sessions
+	Alternates []*Release
 }
 
 // EqualByTitle returns true if both releases have the same normalized title.
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+)
+
+// Raw indexer responses are reused for a few minutes, so back-to-back /stream
+// requests for the same content and the AvailNZB cache warm-up don't hit the
+// indexer APIs again.
+const (
+	searchCacheTTL = 5 * time.Minute
+	searchCacheMax = 500
+)
+
+type searchCacheEntry struct {
+	resp    *indexer.SearchResponse
+	expires time.Time
+}
+
+var searchCache = struct {
+	mu      sync.Mutex
+	entries map[indexer.SearchRequest]searchCacheEntry
+}{entries: make(map[indexer.SearchRequest]searchCacheEntry)}
+
+// searchCacheKey normalizes a request so equivalent queries share an entry.
+func searchCacheKey(req indexer.SearchRequest) indexer.SearchRequest {
+	req.Query = strings.ToLower(strings.Join(strings.Fields(req.Query), " "))
+	req.AbsoluteEpisode = 0 // not sent to indexers
+	return req
+}
+
+// CachedSearch is idx.Search with a short-lived cache of successful responses. Partial
+// responses (some indexers failed) are not cached, so the next request retries them.
+// Callers get their own copy of the items and must normalize it (NormalizeSearchResponse).
+func CachedSearch(idx indexer.Indexer, req indexer.SearchRequest) (*indexer.SearchResponse, error) {
+	key := searchCacheKey(req)
+	now := time.Now()
+
+	searchCache.mu.Lock()
+	e, ok := searchCache.entries[key]
+	searchCache.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		logger.Trace("Indexer search cache hit", "query", key.Query, "imdb", key.IMDbID, "tvdb", key.TVDBID, "season", key.Season, "ep", key.Episode)
+		return copySearchResponse(e.resp), nil
+	}
+
+	resp, err := idx.Search(req)
+	if err != nil || resp == nil || resp.Partial {
+		return resp, err
+	}
+
+	searchCache.mu.Lock()
+	if len(searchCache.entries) >= searchCacheMax {
+		for k, e := range searchCache.entries {
+			if !now.Before(e.expires) {
+				delete(searchCache.entries, k)
+			}
+		}
+		if len(searchCache.entries) >= searchCacheMax {
+			searchCache.entries = make(map[indexer.SearchRequest]searchCacheEntry)
+		}
+	}
+	searchCache.entries[key] = searchCacheEntry{resp: copySearchResponse(resp), expires: now.Add(searchCacheTTL)}
+	searchCache.mu.Unlock()
+	return resp, nil
+}
+
+// copySearchResponse copies the items (normalized in place) and drops the releases
+// (rebuilt per caller), so requests never share *release.Release values.
+func copySearchResponse(resp *indexer.SearchResponse) *indexer.SearchResponse {
+	out := *resp
+	out.Channel.Items = append([]indexer.Item(nil), resp.Channel.Items...)
+	out.Releases = nil
+	return &out
+}
+
+// ClearSearchCache drops all cached indexer responses and returns how many there were.
+// Called when the configuration is reloaded (indexers may have changed).
+func ClearSearchCache() int {
+	searchCache.mu.Lock()
+	defer searchCache.mu.Unlock()
+	n := len(searchCache.entries)
+	searchCache.entries = make(map[indexer.SearchRequest]searchCacheEntry)
+	return n
+}
@@ -0,0 +1,133 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/release"
+)
+
+func cachedItems(t *testing.T, idx indexer.Indexer, req indexer.SearchRequest) []indexer.Item {
+	t.Helper()
+	resp, err := CachedSearch(idx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Channel.Items
+}
+
+func TestCachedSearchExpiry(t *testing.T) {
+	logger.Init("DEBUG")
+	ClearSearchCache()
+	t.Cleanup(func() { ClearSearchCache() })
+	idx := &fakeIndexer{idItems: []indexer.Item{{Title: "Movie.2001.1080p.BluRay.x264-GRP", GUID: "g1"}}}
+	req := indexer.SearchRequest{IMDbID: "tt0000101", Cat: "2000"}
+
+	cachedItems(t, idx, req)
+	cachedItems(t, idx, req)
+	if n := idx.searches(); n != 1 {
+		t.Fatalf("repeated search hit the indexer %d times; want 1", n)
+	}
+
+	// Equivalent text queries share an entry
+	text := indexer.SearchRequest{Query: "  Movie   2001 ", Cat: "2000"}
+	cachedItems(t, idx, text)
+	cachedItems(t, idx, indexer.SearchRequest{Query: "movie 2001", Cat: "2000", AbsoluteEpisode: 5})
+	if n := idx.searches(); n != 2 {
+		t.Fatalf("normalized query missed the cache: %d searches; want 2", n)
+	}
+
+	searchCache.mu.Lock()
+	key := searchCacheKey(req)
+	e := searchCache.entries[key]
+	e.expires = time.Now().Add(-time.Second)
+	searchCache.entries[key] = e
+	searchCache.mu.Unlock()
+	cachedItems(t, idx, req)
+	if n := idx.searches(); n != 3 {
+		t.Errorf("expired entry was served: %d searches; want 3", n)
+	}
+}
+
+func TestCachedSearchSkipsPartialAndErrors(t *testing.T) {
+	logger.Init("DEBUG")
+	ClearSearchCache()
+	t.Cleanup(func() { ClearSearchCache() })
+	idx := &fakeIndexer{idItems: []indexer.Item{{Title: "Movie.2001.1080p.BluRay.x264-GRP", GUID: "g1"}}, partial: true}
+	req := indexer.SearchRequest{IMDbID: "tt0000102", Cat: "2000"}
+
+	cachedItems(t, idx, req)
+	cachedItems(t, idx, req)
+	if n := idx.searches(); n != 2 {
+		t.Errorf("partial response was cached: %d searches; want 2", n)
+	}
+
+	idx = &fakeIndexer{idErr: fmt.Errorf("indexer down")}
+	for i := 0; i < 2; i++ {
+		if _, err := CachedSearch(idx, req); err == nil {
+			t.Fatal("expected the indexer error")
+		}
+	}
+	if n := idx.searches(); n != 2 {
+		t.Errorf("failed search was cached: %d searches; want 2", n)
+	}
+}
+
+func TestCachedSearchSizeCap(t *testing.T) {
+	logger.Init("DEBUG")
+	ClearSearchCache()
+	t.Cleanup(func() { ClearSearchCache() })
+	idx := &fakeIndexer{idItems: []indexer.Item{{Title: "Movie.2001.1080p.BluRay.x264-GRP", GUID: "g1"}}}
+
+	for i := 0; i <= searchCacheMax; i++ {
+		cachedItems(t, idx, indexer.SearchRequest{IMDbID: fmt.Sprintf("tt%07d", i), Cat: "2000"})
+		searchCache.mu.Lock()
+		n := len(searchCache.entries)
+		searchCache.mu.Unlock()
+		if n > searchCacheMax {
+			t.Fatalf("cache grew to %d entries; cap is %d", n, searchCacheMax)
+		}
+	}
+	last := indexer.SearchRequest{IMDbID: fmt.Sprintf("tt%07d", searchCacheMax), Cat: "2000"}
+	before := idx.searches()
+	cachedItems(t, idx, last)
+	if idx.searches() != before {
+		t.Error("the newest entry was not kept when the cap was reached")
+	}
+	if n := ClearSearchCache(); n == 0 {
+		t.Error("ClearSearchCache reported no entries")
+	}
+}
+
+func TestCachedSearchCopyIsolation(t *testing.T) {
+	logger.Init("DEBUG")
+	ClearSearchCache()
+	t.Cleanup(func() { ClearSearchCache() })
+	idx := &fakeIndexer{idItems: []indexer.Item{{Title: "Movie.2001.1080p.BluRay.x264-GRP", GUID: "g1"}}}
+	req := indexer.SearchRequest{IMDbID: "tt0000103", Cat: "2000"}
+
+	first, err := CachedSearch(idx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Channel.Items[0].Title = "changed by the first caller"
+	first.Releases = append(first.Releases, &release.Release{Title: "normalized"})
+
+	second, err := CachedSearch(idx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Channel.Items[0].Title != "Movie.2001.1080p.BluRay.x264-GRP" {
+		t.Errorf("caller's change leaked into the cache: %q", second.Channel.Items[0].Title)
+	}
+	if second.Releases != nil {
+		t.Errorf("cached response shares releases: %v", second.Releases)
+	}
+	second.Channel.Items[0].Title = "changed by the second caller"
+	if third := cachedItems(t, idx, req); third[0].Title != "Movie.2001.1080p.BluRay.x264-GRP" {
+		t.Errorf("cache hit shares items between callers: %q", third[0].Title)
+	}
+}
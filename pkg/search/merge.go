@@ -68,12 +68,39 @@ func FilterTextResultsByContent(releases []*release.Release, contentType, textQu
 	return out
 }
 
+// FilterAbsoluteResults keeps releases of the show numbered by absolute episode
+// (anime-style "Show - 105" or "Show Ep105") that match the requested absolute episode.
+func FilterAbsoluteResults(releases []*release.Release, showName string, absolute int) []*release.Release {
+	expectShow := release.NormalizeTitle(showName)
+	var out []*release.Release
+	for _, rel := range releases {
+		if rel == nil {
+			continue
+		}
+		parsed := parser.ParseReleaseTitle(rel.Title)
+		gotShow := release.NormalizeTitle(parsed.Title)
+		if gotShow == "" {
+			continue
+		}
+		if expectShow != "" && !strings.Contains(gotShow, expectShow) && !strings.Contains(expectShow, gotShow) {
+			continue
+		}
+		if parser.AbsoluteEpisode(rel.Title) != absolute {
+			continue
+		}
+		out = append(out, rel)
+	}
+	return out
+}
+
 // MergeAndDedupeSearchResults merges ID and text results, preferring ID-based when duplicates.
+// Dropped duplicates with a different download link are kept as Alternates of the
+// surviving release so a failed download can fall back to another indexer.
 func MergeAndDedupeSearchResults(releases []*release.Release) []*release.Release {
 	sort.SliceStable(releases, func(i, j int) bool {
 		return releases[i].QuerySource == "id" && releases[j].QuerySource != "id"
 	})
-	seenTitle := make(map[string]bool)
+	seenTitle := make(map[string]*release.Release)
 	var result []*release.Release
 	for _, rel := range releases {
 		if rel == nil {
@@ -83,11 +110,24 @@ func MergeAndDedupeSearchResults(releases []*release.Release) []*release.Release
 		if normTitle == "" {
 			continue
 		}
-		if seenTitle[normTitle] {
+		if kept := seenTitle[normTitle]; kept != nil {
+			addAlternate(kept, rel)
 			continue
 		}
-		seenTitle[normTitle] = true
+		seenTitle[normTitle] = rel
 		result = append(result, rel)
 	}
 	return result
 }
+
+func addAlternate(kept, alt *release.Release) {
+	if alt.Link == "" || alt.Link == kept.Link || alt.SourceIndexer == nil {
+		return
+	}
+	for _, existing := range kept.Alternates {
+		if existing.Link == alt.Link {
+			return
+		}
+	}
+	kept.Alternates = append(kept.Alternates, alt)
+}
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -26,6 +28,7 @@ type ParsedRelease struct {
 	// Additional metadata
 	Languages []string
 	Network   string
+	Regions   []string // release origin ("US", "UK", ...) from country tags and network hints
 	Repack    bool
 	Proper    bool
 	Extended  bool
@@ -73,6 +76,10 @@ func ParseReleaseTitle(title string) *ParsedRelease {
 		}
 	}
 
+	if isRepostTag(parsed.Group) {
+		parsed.Group = groupBeforeRepostTags(title)
+	}
+
 	// Extract season/episode if available
 	if len(info.Seasons) > 0 {
 		parsed.Season = info.Seasons[0]
@@ -81,9 +88,140 @@ func ParseReleaseTitle(title string) *ParsedRelease {
 		parsed.Episode = info.Episodes[0]
 	}
 
+	parsed.Regions = releaseRegions(title)
+
 	return parsed
 }
 
+// regionTags are country tags releases carry after the title ("The.Office.US",
+// "Ghosts.UK"). They must be upper case: "Us.2019" is a title, not a region.
+var regionTags = map[string]string{
+	"US": "US",
+	"UK": "UK",
+	"GB": "UK",
+	"AU": "AU",
+	"CA": "CA",
+	"NZ": "NZ",
+	"IE": "IE",
+}
+
+// regionNetworks maps the source tags of regional streaming services and broadcasters
+// (upper case) to the region they serve.
+var regionNetworks = map[string]string{
+	"BBC":   "UK",
+	"ITV":   "UK",
+	"ITVX":  "UK",
+	"ALL4":  "UK",
+	"MY5":   "UK",
+	"STAN":  "AU",
+	"SBS":   "AU",
+	"9NOW":  "AU",
+	"7PLUS": "AU",
+	"CBC":   "CA",
+	"CRAV":  "CA",
+	"CRAVE": "CA",
+	"HULU":  "US",
+	"PCOK":  "US",
+	"HMAX":  "US",
+	"CBS":   "US",
+	"NBC":   "US",
+	"PBS":   "US",
+}
+
+// releaseRegions returns the regions a title points to, in the order they appear. The
+// first word is skipped since it is part of the title.
+func releaseRegions(title string) []string {
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return strings.ContainsRune(" ._-()[]", r)
+	})
+	var regions []string
+	for i, word := range words {
+		if i == 0 {
+			continue
+		}
+		region, ok := regionTags[word]
+		if !ok {
+			region, ok = regionNetworks[strings.ToUpper(word)]
+		}
+		if !ok && word == "iP" { // BBC iPlayer
+			region, ok = "UK", true
+		}
+		if ok && !slices.Contains(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// repostTags are suffixes Usenet posters append after the release group
+// ("...-FLUX-Obfuscated"); PTT reports them as the group.
+var repostTags = map[string]bool{
+	"obfuscated":  true,
+	"xpost":       true,
+	"postbot":     true,
+	"asrequested": true,
+	"scrambled":   true,
+	"repost":      true,
+	"sample":      true,
+}
+
+func isRepostTag(group string) bool {
+	return repostTags[strings.ToLower(group)]
+}
+
+// groupBeforeRepostTags strips trailing repost tags from title and returns the
+// group PTT finds in the remainder ("" when none is left).
+func groupBeforeRepostTags(title string) string {
+	for {
+		idx := strings.LastIndexAny(title, "-.")
+		if idx <= 0 || !isRepostTag(title[idx+1:]) {
+			break
+		}
+		title = title[:idx]
+	}
+	if group := ptt.Parse(title).Group; !isRepostTag(group) {
+		return group
+	}
+	return ""
+}
+
+// NormalizeGroup canonicalizes a release group name for comparison: surrounding
+// spaces, a leading "-" and enclosing brackets are removed and case is folded,
+// so config entries like "-FLUX" or "[SubsPlease]" match parsed groups.
+func NormalizeGroup(group string) string {
+	g := strings.TrimSpace(group)
+	g = strings.TrimPrefix(g, "-")
+	g = strings.TrimPrefix(g, "[")
+	g = strings.TrimSuffix(g, "]")
+	return strings.ToLower(strings.TrimSpace(g))
+}
+
+var (
+	// "Show - 105", "Show - 105v2", "Show Ep105", "Show Episode 105"
+	absoluteEpisodeRe = regexp.MustCompile(`(?i)(?:\s-\s*|\bEp\.?\s?|\bEpisode\s|\bE)(\d{1,4})(?:v\d)?(?:[\s\]\)\[(._-]|$)`)
+	seasonEpisodeRe   = regexp.MustCompile(`(?i)\bS\d{1,2}\s?E\d`)
+)
+
+// AbsoluteEpisode returns the absolute episode number from anime-style titles
+// ("Show - 105", "Show Ep105"), or 0 when the title has none or uses SxxEyy numbering.
+func AbsoluteEpisode(title string) int {
+	if seasonEpisodeRe.MatchString(title) {
+		return 0
+	}
+	for _, m := range absoluteEpisodeRe.FindAllStringSubmatch(title, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n == 0 {
+			continue
+		}
+		// Four digits after a dash are usually a year ("Show - 2019")
+		if len(m[1]) == 4 && n >= 1900 && n <= 2099 {
+			continue
+		}
+		return n
+	}
+	return 0
+}
+
 // ResolutionGroup returns the resolution group (4k, 1080p, 720p, sd) from parsed metadata.
 func (p *ParsedRelease) ResolutionGroup() string {
 	if p == nil {
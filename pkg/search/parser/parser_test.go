@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAbsoluteEpisode(t *testing.T) {
+	tests := []struct {
+		title string
+		want  int
+	}{
+		{"[SubsPlease] One Piece - 1100 (1080p) [ABCD1234].mkv", 1100},
+		{"[Erai-raws] Jujutsu Kaisen - 05v2 [1080p][Multiple Subtitle]", 5},
+		{"Naruto Shippuden Ep042 720p", 42},
+		{"Bleach Episode 366 1080p WEB", 366},
+		{"Show.Name.S01E05.1080p.WEB.x264-GROUP", 0},
+		{"Some Movie - 2019 1080p BluRay", 0},
+		{"Show Name 1080p WEB-DL", 0},
+	}
+	for _, tt := range tests {
+		if got := AbsoluteEpisode(tt.title); got != tt.want {
+			t.Errorf("AbsoluteEpisode(%q) = %d, want %d", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestParseReleaseTitleGroup(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Movie.2020.1080p.WEB-DL.DDP5.1.H.264-FLUX", "FLUX"},
+		{"Movie.2020.1080p.WEB-DL.DDP5.1.H.264-FLUXCAPACITOR", "FLUXCAPACITOR"},
+		{"Fluxus.2020.1080p.WEB-DL.H.264-NTb", "NTb"},
+		{"Movie.2020.1080p.WEB-DL.H.264-FLUX[rarbg]", "FLUX"},
+		{"Movie.2020.1080p.WEB-DL.H.264-FLUX-Obfuscated", "FLUX"},
+		{"Movie.2020.1080p.BluRay.x264-FLUX-xpost-postbot", "FLUX"},
+		{"Movie 2020 1080p WEB-DL H264-FLUX.mkv", "FLUX"},
+		{"[SubsPlease] Show - 05 (1080p) [ABCD1234]", "SubsPlease"},
+	}
+	for _, tt := range tests {
+		if got := ParseReleaseTitle(tt.title).Group; got != tt.want {
+			t.Errorf("ParseReleaseTitle(%q).Group = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeGroup(t *testing.T) {
+	for in, want := range map[string]string{"FLUX": "flux", " -FLUX ": "flux", "[SubsPlease]": "subsplease", "": ""} {
+		if got := NormalizeGroup(in); got != want {
+			t.Errorf("NormalizeGroup(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReleaseRegions(t *testing.T) {
+	tests := []struct {
+		title string
+		want  []string
+	}{
+		{"The.Office.US.S01E01.1080p.WEB-DL.H.264-NTb", []string{"US"}},
+		{"Ghosts.2019.GB.S01E01.1080p.iP.WEB-DL.AAC2.0.H.264-RTN", []string{"UK"}},
+		{"Show.S01E01.1080p.STAN.WEB-DL.DDP5.1.H.264-NTb", []string{"AU"}},
+		{"Show.S02E03.1080p.HULU.WEB-DL.DDP5.1.H.264-NTb", []string{"US"}},
+		{"Us.2019.1080p.BluRay.x264-GROUP", nil},
+		{"Movie.2020.1080p.AMZN.WEB-DL.DDP5.1.H.264-FLUX", nil},
+	}
+	for _, tt := range tests {
+		if got := ParseReleaseTitle(tt.title).Regions; !slices.Equal(got, tt.want) {
+			t.Errorf("ParseReleaseTitle(%q).Regions = %v, want %v", tt.title, got, tt.want)
+		}
+	}
+}
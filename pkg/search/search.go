@@ -1,7 +1,9 @@
 package search
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"streamnzb/pkg/core/logger"
@@ -18,11 +20,14 @@ type TMDBResolver interface {
 
 // RunIndexerSearches runs ID-based and text-based searches in parallel, merges and dedupes.
 // Text search uses TMDB to resolve titles; when TMDB is unavailable, only ID search runs.
+// When req.AbsoluteEpisode is set (anime), a third text search matches absolute-numbered releases.
+// Indexer responses are cached briefly (CachedSearch).
+// A failed search only drops its own results; an error is returned when every search failed.
 func RunIndexerSearches(idx indexer.Indexer, tmdbClient TMDBResolver, req indexer.SearchRequest, contentType string, contentIDs *session.AvailReportMeta, imdbForText, tmdbForText string) ([]*release.Release, error) {
 	idReq := req
 	idReq.Query = ""
 
-	var textQuery string
+	var textQuery, showName string
 	if tmdbClient != nil {
 		if contentType == "movie" {
 			if t, err := tmdbClient.GetMovieTitle(contentIDs.ImdbID, req.TMDBID); err == nil {
@@ -30,38 +35,83 @@ func RunIndexerSearches(idx indexer.Indexer, tmdbClient TMDBResolver, req indexe
 			}
 		} else if req.Season != "" && req.Episode != "" {
 			if name, err := tmdbClient.GetTVShowName(tmdbForText, imdbForText); err == nil {
+				showName = name
 				textQuery = fmt.Sprintf("%s S%sE%s", name, req.Season, req.Episode)
 			}
 		}
 	}
 
 	var idResp *indexer.SearchResponse
-	var idErr error
+	var idErr, textErr, absErr error
 	var textReleases []*release.Release
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		idResp, idErr = idx.Search(idReq)
+		idResp, idErr = CachedSearch(idx, idReq)
 	}()
 	if textQuery != "" {
 		wg.Add(1)
 		textReq := indexer.SearchRequest{Query: textQuery, Cat: req.Cat, Limit: req.Limit, Season: req.Season, Episode: req.Episode}
 		go func() {
 			defer wg.Done()
-			if resp, err := idx.Search(textReq); err == nil {
-				indexer.NormalizeSearchResponse(resp)
-				textReleases = FilterTextResultsByContent(resp.Releases, contentType, textQuery, req.Season, req.Episode)
+			resp, err := CachedSearch(idx, textReq)
+			if err != nil {
+				textErr = err
+				return
 			}
+			indexer.NormalizeSearchResponse(resp)
+			textReleases = FilterTextResultsByContent(resp.Releases, contentType, textQuery, req.Season, req.Episode)
+		}()
+	}
+	// Anime is often released with absolute numbering only ("Show - 105"); search for that too
+	var absReleases []*release.Release
+	absQuery := showName != "" && req.AbsoluteEpisode > 0
+	if absQuery {
+		wg.Add(1)
+		absReq := indexer.SearchRequest{Query: fmt.Sprintf("%s %02d", showName, req.AbsoluteEpisode), Cat: req.Cat, Limit: req.Limit}
+		go func() {
+			defer wg.Done()
+			resp, err := CachedSearch(idx, absReq)
+			if err != nil {
+				absErr = err
+				return
+			}
+			indexer.NormalizeSearchResponse(resp)
+			absReleases = FilterAbsoluteResults(resp.Releases, showName, req.AbsoluteEpisode)
 		}()
 	}
 	wg.Wait()
 
-	if idErr != nil {
-		return nil, fmt.Errorf("indexer search failed: %w", idErr)
+	// Only a total failure is an error; otherwise serve what the other searches found
+	var failed []string
+	var errs []error
+	ran := 0
+	for _, r := range []struct {
+		name string
+		ran  bool
+		err  error
+	}{{"id", true, idErr}, {"text", textQuery != "", textErr}, {"absolute", absQuery, absErr}} {
+		if !r.ran {
+			continue
+		}
+		ran++
+		if r.err != nil {
+			failed = append(failed, r.name)
+			errs = append(errs, r.err)
+		}
+	}
+	if len(failed) == ran {
+		return nil, fmt.Errorf("indexer search failed: %w", errors.Join(errs...))
+	}
+	if len(failed) > 0 {
+		logger.Debug("Indexer search continuing with partial results", "failed", strings.Join(failed, ", "), "err", errors.Join(errs...))
+	}
+	if idResp == nil {
+		idResp = &indexer.SearchResponse{}
 	}
 	indexer.NormalizeSearchResponse(idResp)
-	idReleases := make([]*release.Release, 0, len(idResp.Releases)+len(textReleases))
+	idReleases := make([]*release.Release, 0, len(idResp.Releases)+len(textReleases)+len(absReleases))
 	for _, rel := range idResp.Releases {
 		if rel != nil {
 			rel.QuerySource = "id"
@@ -74,8 +124,14 @@ func RunIndexerSearches(idx indexer.Indexer, tmdbClient TMDBResolver, req indexe
 			idReleases = append(idReleases, rel)
 		}
 	}
-	if len(textReleases) > 0 {
-		logger.Debug("Indexer dual search", "id", len(idResp.Releases), "text", len(textReleases))
+	for _, rel := range absReleases {
+		if rel != nil {
+			rel.QuerySource = "text"
+			idReleases = append(idReleases, rel)
+		}
+	}
+	if len(textReleases) > 0 || len(absReleases) > 0 {
+		logger.Debug("Indexer dual search", "id", len(idResp.Releases), "text", len(textReleases), "absolute", len(absReleases))
 	}
 	return MergeAndDedupeSearchResults(idReleases), nil
 }
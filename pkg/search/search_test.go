@@ -0,0 +1,134 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/session"
+)
+
+// fakeIndexer answers ID searches (no Query) and text searches from separate fields.
+type fakeIndexer struct {
+	mu      sync.Mutex
+	calls   int
+	idItems []indexer.Item
+	idErr   error
+	text    []indexer.Item
+	partial bool
+}
+
+func (f *fakeIndexer) Search(req indexer.SearchRequest) (*indexer.SearchResponse, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	if req.Query == "" {
+		if f.idErr != nil {
+			return nil, f.idErr
+		}
+		return &indexer.SearchResponse{Channel: indexer.Channel{Items: append([]indexer.Item(nil), f.idItems...)}, Partial: f.partial}, nil
+	}
+	return &indexer.SearchResponse{Channel: indexer.Channel{Items: append([]indexer.Item(nil), f.text...)}, Partial: f.partial}, nil
+}
+func (f *fakeIndexer) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+func (f *fakeIndexer) Ping() error             { return nil }
+func (f *fakeIndexer) Name() string            { return "fake" }
+func (f *fakeIndexer) GetUsage() indexer.Usage { return indexer.Usage{} }
+
+func (f *fakeIndexer) searches() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+type fakeTMDB struct{}
+
+func (fakeTMDB) GetMovieTitle(imdbID, tmdbID string) (string, error) { return "Movie", nil }
+func (fakeTMDB) GetTVShowName(tmdbID, imdbID string) (string, error) {
+	return "", errors.New("no show")
+}
+
+func TestRunIndexerSearchesPartial(t *testing.T) {
+	logger.Init("DEBUG")
+	ClearSearchCache()
+	t.Cleanup(func() { ClearSearchCache() })
+	idx := &fakeIndexer{
+		idErr: errors.New("id search timed out"),
+		text:  []indexer.Item{{Title: "Movie.2001.1080p.BluRay.x264-GRP", GUID: "t1", Link: "http://idx/t1", Size: 1 << 30}},
+	}
+	ids := &session.AvailReportMeta{ImdbID: "tt0000001"}
+	req := indexer.SearchRequest{IMDbID: "tt0000001", Cat: "2000"}
+
+	rels, err := RunIndexerSearches(idx, fakeTMDB{}, req, "movie", ids, "tt0000001", "")
+	if err != nil {
+		t.Fatalf("failed ID search failed the whole search: %v", err)
+	}
+	if len(rels) != 1 || rels[0].QuerySource != "text" {
+		t.Errorf("releases = %v; want the text search result", rels)
+	}
+
+	// Without a text search the failed ID search is a total failure
+	if _, err := RunIndexerSearches(idx, nil, req, "movie", ids, "tt0000001", ""); err == nil {
+		t.Error("search succeeded although every search failed")
+	}
+}
+
+func TestCachedSearchSkipsPartial(t *testing.T) {
+	logger.Init("DEBUG")
+	ClearSearchCache()
+	t.Cleanup(func() { ClearSearchCache() })
+	idx := &fakeIndexer{idItems: []indexer.Item{{Title: "A", GUID: "a"}}, partial: true}
+	req := indexer.SearchRequest{IMDbID: "tt0000002"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := CachedSearch(idx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := idx.searches(); n != 2 {
+		t.Errorf("partial response served from cache: %d indexer searches; want 2", n)
+	}
+
+	idx.partial = false
+	for i := 0; i < 2; i++ {
+		if _, err := CachedSearch(idx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := idx.searches(); n != 3 {
+		t.Errorf("complete response not cached: %d indexer searches; want 3", n)
+	}
+}
+
+func TestMergeKeepsAlternates(t *testing.T) {
+	idxA, idxB := &fakeIndexer{}, &fakeIndexer{}
+	primary := &release.Release{Title: "Movie.2001.1080p.BluRay.x264-GRP", Link: "https://a.test/1", QuerySource: "id", SourceIndexer: idxA}
+	releases := []*release.Release{
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", Link: "https://b.test/7", QuerySource: "text", SourceIndexer: idxB},
+		primary,
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", Link: "https://a.test/1", QuerySource: "text", SourceIndexer: idxA}, // same link
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", Link: "https://b.test/7", QuerySource: "text", SourceIndexer: idxB}, // already an alternate
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", Link: "https://avail.test/3", QuerySource: "text"},                  // no indexer to download from
+		{Title: "Movie.2001.2160p.WEB-DL-GRP", Link: "https://b.test/8", QuerySource: "text", SourceIndexer: idxB},
+	}
+	merged := MergeAndDedupeSearchResults(releases)
+	if len(merged) != 2 || merged[0] != primary {
+		t.Fatalf("merged = %d releases, first %q; want the ID result first of 2", len(merged), merged[0].Title)
+	}
+	if len(primary.Alternates) != 1 || primary.Alternates[0].Link != "https://b.test/7" {
+		var links []string
+		for _, alt := range primary.Alternates {
+			links = append(links, alt.Link)
+		}
+		t.Errorf("alternates = %v; want [https://b.test/7]", links)
+	}
+	if len(merged[1].Alternates) != 0 {
+		t.Errorf("distinct release got alternates: %v", merged[1].Alternates)
+	}
+}
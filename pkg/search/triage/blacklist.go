@@ -0,0 +1,160 @@
+package triage
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/core/persistence"
+	"streamnzb/pkg/release"
+)
+
+const blacklistStateKey = "release_blacklist"
+
+// ErrEmptyBlacklistEntry rejects entries without a title or details URL.
+var ErrEmptyBlacklistEntry = errors.New("title or details_url is required")
+
+// BlacklistEntry is a release hidden from search results.
+type BlacklistEntry struct {
+	Title      string    `json:"title,omitempty"`
+	DetailsURL string    `json:"details_url,omitempty"`
+	AddedBy    string    `json:"added_by,omitempty"` // device that blacklisted it
+	AddedAt    time.Time `json:"added_at"`
+}
+
+// matches reports whether the entry names the release with this normalized title or
+// details URL.
+func (e BlacklistEntry) matches(title, detailsURL string) bool {
+	return (title != "" && release.NormalizeTitle(e.Title) == title) ||
+		(detailsURL != "" && e.DetailsURL == detailsURL)
+}
+
+// Blacklist is the persisted list of releases users hid because they fail playback
+// although they validate. A release is dropped when its normalized title or its details
+// URL is listed, so reposts of a blacklisted title are hidden on every indexer.
+type Blacklist struct {
+	state   *persistence.StateManager // nil keeps the list in memory only
+	mu      sync.RWMutex
+	entries []BlacklistEntry
+}
+
+// NewBlacklist loads the blacklist from state.
+func NewBlacklist(state *persistence.StateManager) *Blacklist {
+	b := &Blacklist{state: state}
+	if state != nil {
+		if _, err := state.Get(blacklistStateKey, &b.entries); err != nil {
+			logger.Error("Failed to load release blacklist", "err", err)
+		}
+	}
+	return b
+}
+
+// Add blacklists a release. An entry already naming the release is returned unchanged.
+func (b *Blacklist) Add(title, detailsURL, addedBy string) (BlacklistEntry, error) {
+	title, detailsURL = strings.TrimSpace(title), strings.TrimSpace(detailsURL)
+	if title == "" && detailsURL == "" {
+		return BlacklistEntry{}, ErrEmptyBlacklistEntry
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, e := range b.entries {
+		if (title == "" || release.NormalizeTitle(e.Title) == release.NormalizeTitle(title)) &&
+			(detailsURL == "" || e.DetailsURL == detailsURL) {
+			return e, nil
+		}
+	}
+	e := BlacklistEntry{Title: title, DetailsURL: detailsURL, AddedBy: addedBy, AddedAt: time.Now().UTC()}
+	b.entries = append(b.entries, e)
+	return e, b.saveLocked()
+}
+
+// Find returns the entries naming the release with this title or details URL.
+func (b *Blacklist) Find(title, detailsURL string) []BlacklistEntry {
+	title, detailsURL = release.NormalizeTitle(title), strings.TrimSpace(detailsURL)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var found []BlacklistEntry
+	for _, e := range b.entries {
+		if e.matches(title, detailsURL) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Remove un-blacklists the release with this title or details URL and returns how many
+// entries were removed.
+func (b *Blacklist) Remove(title, detailsURL string) (int, error) {
+	title, detailsURL = release.NormalizeTitle(title), strings.TrimSpace(detailsURL)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	kept := b.entries[:0]
+	for _, e := range b.entries {
+		if !e.matches(title, detailsURL) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(b.entries) - len(kept)
+	b.entries = kept
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, b.saveLocked()
+}
+
+// List returns the entries, oldest first.
+func (b *Blacklist) List() []BlacklistEntry {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]BlacklistEntry(nil), b.entries...)
+}
+
+// Contains reports whether rel is blacklisted.
+func (b *Blacklist) Contains(rel *release.Release) bool {
+	if b == nil || rel == nil {
+		return false
+	}
+	title, detailsURL := release.NormalizeTitle(rel.Title), rel.DetailsURL
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, e := range b.entries {
+		if e.matches(title, detailsURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the releases that are not blacklisted. A nil Blacklist keeps all.
+func (b *Blacklist) Filter(releases []*release.Release) []*release.Release {
+	if b == nil {
+		return releases
+	}
+	b.mu.RLock()
+	empty := len(b.entries) == 0
+	b.mu.RUnlock()
+	if empty {
+		return releases
+	}
+	kept := make([]*release.Release, 0, len(releases))
+	for _, rel := range releases {
+		if b.Contains(rel) {
+			logger.Debug("Dropping blacklisted release", "title", rel.Title)
+			continue
+		}
+		kept = append(kept, rel)
+	}
+	return kept
+}
+
+func (b *Blacklist) saveLocked() error {
+	if b.state == nil {
+		return nil
+	}
+	return b.state.Set(blacklistStateKey, b.entries)
+}
@@ -0,0 +1,63 @@
+package triage
+
+import (
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/core/persistence"
+	"streamnzb/pkg/release"
+)
+
+func TestBlacklist(t *testing.T) {
+	logger.Init("DEBUG")
+	state, err := persistence.GetManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("GetManager: %v", err)
+	}
+	defer state.Flush()
+
+	b := NewBlacklist(state)
+	if _, err := b.Add(" ", "", "alice"); err == nil {
+		t.Error("expected an empty entry to be rejected")
+	}
+	if _, err := b.Add("Movie.2020.1080p.WEB-DL.H.264-GROUP", "", "alice"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, err := b.Add("", "https://indexer.example/details/42", "bob"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	b.Add("movie.2020.1080p.web-dl.h.264-group", "", "bob") // already listed by title
+	if n := len(b.List()); n != 2 {
+		t.Fatalf("List has %d entries, want 2", n)
+	}
+
+	releases := []*release.Release{
+		{Title: "MOVIE.2020.1080p.WEB-DL.H.264-GROUP", DetailsURL: "https://other.example/details/7"}, // repost
+		{Title: "Movie.2020.2160p.WEB-DL.H.265-GROUP", DetailsURL: "https://indexer.example/details/42"},
+		{Title: "Movie.2020.1080p.BluRay.x264-GROUP", DetailsURL: "https://indexer.example/details/43"},
+	}
+	kept := b.Filter(releases)
+	if len(kept) != 1 || kept[0] != releases[2] {
+		t.Fatalf("Filter kept %v, want only the BluRay release", kept)
+	}
+
+	// The list survives a reload from state
+	if n := len(NewBlacklist(state).List()); n != 2 {
+		t.Errorf("reloaded blacklist has %d entries, want 2", n)
+	}
+
+	if found := b.Find("", "https://indexer.example/details/42"); len(found) != 1 || found[0].AddedBy != "bob" {
+		t.Errorf("Find by URL = %v", found)
+	}
+	if n, err := b.Remove("Movie.2020.1080p.WEB-DL.H.264-GROUP", ""); err != nil || n != 1 {
+		t.Errorf("Remove = %d, %v; want 1 entry removed", n, err)
+	}
+	if !b.Contains(releases[1]) || b.Contains(releases[0]) {
+		t.Error("only the URL entry should be left")
+	}
+
+	var none *Blacklist
+	if got := none.Filter(releases); len(got) != len(releases) {
+		t.Error("a nil blacklist should keep every release")
+	}
+}
@@ -1,9 +1,14 @@
 package triage
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
 
 	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/release"
 	"streamnzb/pkg/search/parser"
 )
@@ -36,8 +41,37 @@ func checkQuality(cfg *config.FilterConfig, p *parser.ParsedRelease) bool {
 	return true
 }
 
+// AllowsResolutionGroup reports whether a resolution group (as returned by
+// ParsedRelease.ResolutionGroup) passes AllowedResolutionGroups. Configured values are
+// matched case-insensitively; "2160p"/"uhd" count as "4k" and "480p"/"576p" as "sd".
+func AllowsResolutionGroup(cfg *config.FilterConfig, group string) bool {
+	if cfg == nil || len(cfg.AllowedResolutionGroups) == 0 {
+		return true
+	}
+	for _, allowed := range cfg.AllowedResolutionGroups {
+		if normalizeResolutionGroup(allowed) == group {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeResolutionGroup(group string) string {
+	switch g := strings.ToLower(strings.TrimSpace(group)); g {
+	case "2160p", "uhd":
+		return "4k"
+	case "480p", "576p":
+		return "sd"
+	default:
+		return g
+	}
+}
+
 // checkResolution validates resolution filters
 func checkResolution(cfg *config.FilterConfig, p *parser.ParsedRelease) bool {
+	if !AllowsResolutionGroup(cfg, p.ResolutionGroup()) {
+		return false
+	}
 	if p.Resolution == "" {
 		// If resolution is unknown and we have min/max filters, reject it
 		// This prevents SD/unknown content from bypassing resolution filters
@@ -428,17 +462,18 @@ func checkOther(cfg *config.FilterConfig, p *parser.ParsedRelease) bool {
 	return true
 }
 
-// checkGroup validates group filters
+// checkGroup validates group filters. The group is the one PTT parsed from the title,
+// compared whole (never as a substring) so blocking "FLUX" leaves "FLUXCAPACITOR" alone.
 func checkGroup(cfg *config.FilterConfig, p *parser.ParsedRelease) bool {
 	if p.Group == "" {
 		return true
 	}
 
-	group := strings.ToLower(p.Group)
+	group := parser.NormalizeGroup(p.Group)
 
 	// Check blocked groups
 	for _, blocked := range cfg.BlockedGroups {
-		if strings.EqualFold(group, blocked) {
+		if b := parser.NormalizeGroup(blocked); b != "" && group == b {
 			return false
 		}
 	}
@@ -450,6 +485,85 @@ func checkGroup(cfg *config.FilterConfig, p *parser.ParsedRelease) bool {
 	return true
 }
 
+// CompileTitlePatterns compiles title patterns as case-insensitive regular expressions.
+// It is used to validate BlockedTitlePatterns/RequiredTitlePatterns on save.
+func CompileTitlePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// compileTitlePatterns compiles the valid patterns; invalid ones (only possible in a
+// hand-edited config, saves are validated) are logged and skipped.
+func compileTitlePatterns(patterns []string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := CompileTitlePatterns([]string{pattern})
+		if err != nil {
+			logger.Warn("Ignoring title pattern", "err", err)
+			continue
+		}
+		compiled = append(compiled, re...)
+	}
+	return compiled
+}
+
+// checkTitlePatterns rejects titles matching a blocked pattern and, when required
+// patterns are set, titles matching none of them.
+func checkTitlePatterns(blocked, required []*regexp.Regexp, title string) bool {
+	for _, re := range blocked {
+		if re.MatchString(title) {
+			return false
+		}
+	}
+	if len(required) == 0 {
+		return true
+	}
+	for _, re := range required {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// minObfuscatedTitleLen keeps short single-word titles (e.g. "Se7en") from counting as obfuscated.
+const minObfuscatedTitleLen = 16
+
+// isObfuscatedTitle reports whether a release title is a random obfuscated name (e.g.
+// "a8f7e6d5c4b3a2f1e0d9"): one run of at least minObfuscatedTitleLen letters and digits,
+// mixing both, without the separators real release names use.
+func isObfuscatedTitle(title string) bool {
+	title = strings.TrimSpace(title)
+	if ext := strings.ToLower(path.Ext(title)); ext == ".nzb" || ext == ".mkv" || ext == ".mp4" || ext == ".avi" {
+		title = title[:len(title)-len(ext)]
+	}
+	if len(title) < minObfuscatedTitleLen {
+		return false
+	}
+	var letters, digits bool
+	for _, r := range title {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letters = true
+		default:
+			return false
+		}
+	}
+	return letters && digits
+}
+
 // checkSize validates size filters
 func checkSize(cfg *config.FilterConfig, rel *release.Release) bool {
 	if rel == nil {
@@ -475,22 +589,141 @@ func checkSize(cfg *config.FilterConfig, rel *release.Release) bool {
 	return true
 }
 
+// checkGrabs validates the minimum grabs filter. Releases without grab data (indexers
+// that don't report it, AvailNZB) pass unless RequireGrabs is set.
+func checkGrabs(cfg *config.FilterConfig, rel *release.Release) bool {
+	if cfg.MinGrabs <= 0 {
+		return true
+	}
+	if !rel.HasGrabs {
+		return !cfg.RequireGrabs
+	}
+	return rel.Grabs >= cfg.MinGrabs
+}
+
 // scoreBoost calculates score boost based on preferred attributes
 func scoreBoost(sortCfg config.SortConfig, p *parser.ParsedRelease) int {
 	boost := 0
 
 	// Boost for preferred groups
 	if p.Group != "" {
+		group := parser.NormalizeGroup(p.Group)
 		for _, preferred := range sortCfg.PreferredGroups {
-			if strings.EqualFold(p.Group, preferred) {
+			if group == parser.NormalizeGroup(preferred) {
 				boost += 1000 // Significant boost for preferred groups
 				break
 			}
 		}
 	}
 
-	// Note: Language boost would require parser to extract language info
-	// For now, preferred_languages is available in config for future use
+	boost += languageBoost(sortCfg, p.Languages)
+	boost += visualTagPreferenceBoost(sortCfg.PreferredVisualTags, p)
+	boost += regionBoost(sortCfg, p.Regions)
 
 	return boost
 }
+
+const defaultLanguageWeight = 2000
+
+const defaultRegionWeight = 1000
+
+// visualTagPreferenceStep separates the ranks of PreferredVisualTags. It outweighs the
+// attribute boosts (codec, audio, quality, visual tag weights) so the preferred variant of
+// a release wins, but stays below the gap between resolution weights.
+const visualTagPreferenceStep = 50_000
+
+// visualTagPreferenceBoost ranks a release by the first entry of prefs (best first) it
+// matches: "SDR" matches releases without HDR tags, "3D" any 3D format, "HDR10" plain HDR,
+// other entries the parsed HDR tags (DV, HDR10+, HDR). Unmatched releases get 0.
+func visualTagPreferenceBoost(prefs []string, p *parser.ParsedRelease) int {
+	for i, pref := range prefs {
+		if matchesVisualTag(pref, p) {
+			return visualTagPreferenceStep * min(len(prefs)-i, 8)
+		}
+	}
+	return 0
+}
+
+func matchesVisualTag(pref string, p *parser.ParsedRelease) bool {
+	pref = strings.ToLower(strings.TrimSpace(pref))
+	switch pref {
+	case "sdr":
+		return len(p.HDR) == 0
+	case "3d":
+		return p.ThreeD != ""
+	case "hdr10":
+		pref = "hdr"
+	}
+	for _, tag := range p.HDR {
+		if strings.ToLower(tag) == pref {
+			return true
+		}
+	}
+	return false
+}
+
+// languageBoost ranks a release by its parsed languages against PreferredLanguages (most
+// preferred first): the best match at position i of n gets LanguageWeight*(n-i)/n. Releases
+// tagged only with other languages get -LanguageWeight; untagged releases (usually the
+// original language) and multi/dual audio releases without a matching tag are left alone.
+func languageBoost(sortCfg config.SortConfig, languages []string) int {
+	prefs := sortCfg.PreferredLanguages
+	if len(prefs) == 0 || len(languages) == 0 {
+		return 0
+	}
+	weight := sortCfg.LanguageWeight
+	if weight == 0 {
+		weight = defaultLanguageWeight
+	}
+	multi := false
+	for i, pref := range prefs {
+		for _, lang := range languages {
+			if languageMatches(lang, pref) {
+				return weight * (len(prefs) - i) / len(prefs)
+			}
+			if isMultiLanguage(lang) {
+				multi = true
+			}
+		}
+	}
+	if multi {
+		return 0
+	}
+	return -weight
+}
+
+// regionBoost ranks a release by its parsed regions against PreferredRegions (best first):
+// the best match at position i of n gets RegionWeight*(n-i)/n. Unlike languages, releases
+// from other or unknown regions are left alone - most titles carry no region at all.
+func regionBoost(sortCfg config.SortConfig, regions []string) int {
+	prefs := sortCfg.PreferredRegions
+	if len(prefs) == 0 || len(regions) == 0 {
+		return 0
+	}
+	weight := sortCfg.RegionWeight
+	if weight == 0 {
+		weight = defaultRegionWeight
+	}
+	for i, pref := range prefs {
+		pref = strings.ToUpper(strings.TrimSpace(pref))
+		if pref == "GB" {
+			pref = "UK"
+		}
+		if slices.Contains(regions, pref) {
+			return weight * (len(prefs) - i) / len(prefs)
+		}
+	}
+	return 0
+}
+
+// languageMatches compares a parsed language ("en", "multi audio", "dual audio") with a
+// configured preference ("en", "multi", "dual").
+func languageMatches(lang, pref string) bool {
+	lang, pref = strings.ToLower(lang), strings.ToLower(strings.TrimSpace(pref))
+	return lang == pref || (isMultiLanguage(lang) && strings.HasPrefix(lang, pref+" "))
+}
+
+func isMultiLanguage(lang string) bool {
+	lang = strings.ToLower(lang)
+	return strings.HasPrefix(lang, "multi") || strings.HasPrefix(lang, "dual")
+}
@@ -2,7 +2,9 @@ package triage
 
 import (
 	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/release"
 	"streamnzb/pkg/search/parser"
+	"strings"
 	"testing"
 )
 
@@ -415,3 +417,127 @@ func TestCheckGroup(t *testing.T) {
 		})
 	}
 }
+
+// Blocked groups are matched against the group parsed from the title, never as a substring
+func TestCheckGroupTrickyTitles(t *testing.T) {
+	cfg := &config.FilterConfig{BlockedGroups: []string{"FLUX", "-YIFY", " [SubsPlease] "}}
+	tests := []struct {
+		title      string
+		shouldPass bool
+	}{
+		{"Movie.2020.1080p.WEB-DL.DDP5.1.H.264-FLUX", false},
+		{"Movie.2020.1080p.WEB-DL.DDP5.1.H.264-flux", false},
+		{"Movie.2020.1080p.WEB-DL.H.264-FLUX-Obfuscated", false},
+		{"Movie.2020.1080p.WEB-DL.H.264-FLUXCAPACITOR", true},
+		{"Fluxus.2020.1080p.WEB-DL.H.264-NTb", true},
+		{"Flux.Gourmet.2022.1080p.BluRay.x264-GROUP", true},
+		{"Movie.2020.720p.BluRay.x264-YIFY", false},
+		{"[SubsPlease] Show - 05 (1080p) [ABCD1234]", false},
+	}
+	for _, tt := range tests {
+		if got := checkGroup(cfg, parser.ParseReleaseTitle(tt.title)); got != tt.shouldPass {
+			t.Errorf("checkGroup(%q) = %v, want %v", tt.title, got, tt.shouldPass)
+		}
+	}
+}
+
+func TestTitlePatterns(t *testing.T) {
+	cfg := &config.FilterConfig{
+		BlockedTitlePatterns:  []string{`\bHDCAM\b`, `-BadEncoder$`},
+		RequiredTitlePatterns: []string{`\bS01E0[1-3]\b`, `2160p`},
+	}
+	s := NewService(cfg, config.SortConfig{})
+	tests := []struct {
+		title      string
+		shouldPass bool
+	}{
+		{"Show.S01E02.1080p.WEB-DL.H.264-NTb", true},
+		{"Movie.2024.2160p.WEB-DL.H.265-GROUP", true},
+		{"Movie.2024.hdcam.2160p.x264-GROUP", false},
+		{"Show.S01E02.1080p.WEB-DL.H.264-BadEncoder", false},
+		{"Show.S01E05.1080p.WEB-DL.H.264-NTb", false}, // matches no required pattern
+	}
+	for _, tt := range tests {
+		if got := checkTitlePatterns(s.blockedTitles, s.requiredTitles, tt.title); got != tt.shouldPass {
+			t.Errorf("checkTitlePatterns(%q) = %v, want %v", tt.title, got, tt.shouldPass)
+		}
+	}
+
+	if _, err := CompileTitlePatterns([]string{"ok", "(unclosed"}); err == nil {
+		t.Error("expected an invalid pattern to fail compilation")
+	}
+}
+
+func TestLanguageBoost(t *testing.T) {
+	sortCfg := config.SortConfig{PreferredLanguages: []string{"fr", "en", "multi"}, LanguageWeight: 3000}
+	tests := []struct {
+		title string
+		want  int
+	}{
+		{"Movie.2020.FRENCH.1080p.WEB.x264-GROUP", 3000},
+		{"Movie.2020.iTA.ENG.1080p.BluRay.x264-GROUP", 2000},
+		{"Movie.2020.MULTi.1080p.BluRay.x264-GROUP", 1000},
+		{"Movie.2020.German.1080p.BluRay.x264-GROUP", -3000}, // only an undesired language
+		{"Movie.2020.1080p.BluRay.x264-GROUP", 0},            // untagged
+	}
+	for _, tt := range tests {
+		if got := languageBoost(sortCfg, parser.ParseReleaseTitle(tt.title).Languages); got != tt.want {
+			t.Errorf("languageBoost(%q) = %d, want %d", tt.title, got, tt.want)
+		}
+	}
+
+	// Ranking follows the device's order: first preference above second above other languages
+	s := NewService(nil, config.SortConfig{PreferredLanguages: []string{"de", "en"}})
+	candidates := s.Filter([]*release.Release{
+		{Title: "Movie.2020.FRENCH.1080p.BluRay.x264-GROUP"},
+		{Title: "Movie.2020.ENG.1080p.BluRay.x264-GROUP"},
+		{Title: "Movie.2020.German.1080p.BluRay.x264-GROUP"},
+	})
+	var order []string
+	for _, c := range candidates {
+		order = append(order, c.Metadata.Languages[0])
+	}
+	if strings.Join(order, ",") != "de,en,fr" {
+		t.Errorf("order = %v, want [de en fr]", order)
+	}
+}
+
+func TestPreferredVisualTags(t *testing.T) {
+	// An HDR10 TV without Dolby Vision: HDR10+ first, then HDR10, then SDR
+	s := NewService(nil, config.SortConfig{
+		ResolutionWeights:   map[string]int{"4k": 4000000, "1080p": 3000000},
+		VisualTagWeights:    map[string]int{"DV": 1500, "HDR10+": 1200, "HDR": 1000},
+		PreferredVisualTags: []string{"HDR10+", "HDR10", "SDR"},
+	})
+	candidates := s.Filter([]*release.Release{
+		{Title: "Movie.2020.2160p.WEB-DL.DoVi.H265-GROUP"},
+		{Title: "Movie.2020.2160p.WEB-DL.HDR.H265-OTHER"},
+		{Title: "Movie.2020.2160p.WEB-DL.HDR10Plus.H265-THIRD"},
+		{Title: "Movie.2020.1080p.WEB-DL.H264-FOURTH"},
+	})
+	var order []string
+	for _, c := range candidates {
+		order = append(order, c.Metadata.Group)
+	}
+	if want := "THIRD,OTHER,GROUP,FOURTH"; strings.Join(order, ",") != want {
+		t.Errorf("order = %v, want %s", order, want)
+	}
+}
+
+func TestRegionBoost(t *testing.T) {
+	sortCfg := config.SortConfig{PreferredRegions: []string{"us", "GB"}, RegionWeight: 2000}
+	tests := []struct {
+		title string
+		want  int
+	}{
+		{"Show.S01E01.1080p.PCOK.WEB-DL.DDP5.1.H.264-NTb", 2000},
+		{"Show.UK.S01E01.1080p.WEB-DL.H.264-NTb", 1000},
+		{"Show.S01E01.1080p.STAN.WEB-DL.H.264-NTb", 0}, // other region, not penalized
+		{"Show.S01E01.1080p.WEB-DL.H.264-NTb", 0},
+	}
+	for _, tt := range tests {
+		if got := regionBoost(sortCfg, parser.ParseReleaseTitle(tt.title).Regions); got != tt.want {
+			t.Errorf("regionBoost(%q) = %d, want %d", tt.title, got, tt.want)
+		}
+	}
+}
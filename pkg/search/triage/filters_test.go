@@ -1,6 +1,7 @@
 package triage
 
 import (
+	"slices"
 	"testing"
 
 	"streamnzb/pkg/core/config"
@@ -84,6 +85,46 @@ func TestCheckResolution(t *testing.T) {
 			},
 			shouldPass: false,
 		},
+		{
+			name: "1080p passes with allowed groups 1080p",
+			cfg: &config.FilterConfig{
+				AllowedResolutionGroups: []string{"1080p"},
+			},
+			parsed: &parser.ParsedRelease{
+				Resolution: "1080p",
+			},
+			shouldPass: true,
+		},
+		{
+			name: "4K rejected with allowed groups 1080p",
+			cfg: &config.FilterConfig{
+				AllowedResolutionGroups: []string{"1080p"},
+			},
+			parsed: &parser.ParsedRelease{
+				Resolution: "2160p",
+			},
+			shouldPass: false,
+		},
+		{
+			name: "Unknown resolution counts as SD for allowed groups",
+			cfg: &config.FilterConfig{
+				AllowedResolutionGroups: []string{"4K", "SD"},
+			},
+			parsed: &parser.ParsedRelease{
+				Resolution: "",
+			},
+			shouldPass: true,
+		},
+		{
+			name: "2160p alias allows 4K",
+			cfg: &config.FilterConfig{
+				AllowedResolutionGroups: []string{"2160p"},
+			},
+			parsed: &parser.ParsedRelease{
+				Resolution: "4k",
+			},
+			shouldPass: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -184,6 +225,28 @@ func TestCheckCodec(t *testing.T) {
 	}
 }
 
+func TestCheckGrabs(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        *config.FilterConfig
+		rel        *release.Release
+		shouldPass bool
+	}{
+		{"no minimum", &config.FilterConfig{}, &release.Release{Grabs: 0, HasGrabs: true}, true},
+		{"below minimum", &config.FilterConfig{MinGrabs: 5}, &release.Release{Grabs: 4, HasGrabs: true}, false},
+		{"zero grabs reported", &config.FilterConfig{MinGrabs: 1}, &release.Release{Grabs: 0, HasGrabs: true}, false},
+		{"meets minimum", &config.FilterConfig{MinGrabs: 5}, &release.Release{Grabs: 5, HasGrabs: true}, true},
+		{"no grab data kept", &config.FilterConfig{MinGrabs: 5}, &release.Release{}, true},
+		{"no grab data strict", &config.FilterConfig{MinGrabs: 5, RequireGrabs: true}, &release.Release{}, false},
+		{"strict without minimum", &config.FilterConfig{RequireGrabs: true}, &release.Release{}, true},
+	}
+	for _, tt := range tests {
+		if got := checkGrabs(tt.cfg, tt.rel); got != tt.shouldPass {
+			t.Errorf("%s: checkGrabs() = %v, want %v", tt.name, got, tt.shouldPass)
+		}
+	}
+}
+
 // Test File Size Filtering
 func TestCheckSize(t *testing.T) {
 	tests := []struct {
@@ -249,3 +312,42 @@ func TestCheckSize(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterUnknownSize(t *testing.T) {
+	rels := []*release.Release{
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", Size: 0},
+		{Title: "Movie.2001.720p.BluRay.x264-GRP", Size: -1},
+	}
+	s := NewService(&config.FilterConfig{}, config.SortConfig{})
+	if got := s.Filter(rels); len(got) != 0 {
+		t.Errorf("releases without a size kept by default: %d", len(got))
+	}
+	s.AllowUnknownSize = true
+	got := s.Filter(rels)
+	if len(got) != 1 || got[0].Release.Size != 0 {
+		t.Errorf("AllowUnknownSize: kept %d releases; want only the one listed without a size", len(got))
+	}
+}
+
+func TestFilterSkipObfuscated(t *testing.T) {
+	rels := []*release.Release{
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", Size: 1 << 30},
+		{Title: "a8f7e6d5c4b3a2f1e0d9c8b7", Size: 1 << 30},
+		{Title: "Xk3Jq9Lm2Pz7Rt5Vw8Yb.nzb", Size: 1 << 30},
+		{Title: "Se7en", Size: 1 << 30},
+		{Title: "Interstellar", Size: 1 << 30},
+	}
+	s := NewService(&config.FilterConfig{}, config.SortConfig{})
+	if got := s.Filter(rels); len(got) != len(rels) {
+		t.Errorf("obfuscated releases dropped by default: kept %d of %d", len(got), len(rels))
+	}
+	s.SkipObfuscated = true
+	var kept []string
+	for _, c := range s.Filter(rels) {
+		kept = append(kept, c.Release.Title)
+	}
+	slices.Sort(kept)
+	if want := []string{"Interstellar", "Movie.2001.1080p.BluRay.x264-GRP", "Se7en"}; !slices.Equal(kept, want) {
+		t.Errorf("SkipObfuscated kept %v; want %v", kept, want)
+	}
+}
@@ -1,6 +1,7 @@
 package triage
 
 import (
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -23,14 +24,29 @@ type Candidate struct {
 type Service struct {
 	FilterConfig *config.FilterConfig
 	SortConfig   config.SortConfig
+	// AllowUnknownSize keeps releases listed without a size (0) instead of rejecting them
+	// as invalid; the caller decides how to stream them (see config ZeroSizePolicy)
+	AllowUnknownSize bool
+	// SkipObfuscated drops releases whose title looks like a random obfuscated name,
+	// before their NZB is downloaded (see config ObfuscatedPolicy "skip")
+	SkipObfuscated bool
+
+	// Compiled FilterConfig title patterns
+	blockedTitles  []*regexp.Regexp
+	requiredTitles []*regexp.Regexp
 }
 
 // NewService creates a new triage service
 func NewService(filterConfig *config.FilterConfig, sortConfig config.SortConfig) *Service {
-	return &Service{
+	s := &Service{
 		FilterConfig: filterConfig,
 		SortConfig:   sortConfig,
 	}
+	if filterConfig != nil {
+		s.blockedTitles = compileTitlePatterns(filterConfig.BlockedTitlePatterns)
+		s.requiredTitles = compileTitlePatterns(filterConfig.RequiredTitlePatterns)
+	}
+	return s
 }
 
 // Filter processes search results and returns candidates sorted by score
@@ -41,6 +57,9 @@ func (s *Service) Filter(releases []*release.Release) []Candidate {
 		if rel == nil {
 			continue
 		}
+		if s.SkipObfuscated && isObfuscatedTitle(rel.Title) {
+			continue
+		}
 		// Parse title
 		parsed := parser.ParseReleaseTitle(rel.Title)
 
@@ -92,6 +111,11 @@ func (s *Service) Filter(releases []*release.Release) []Candidate {
 func (s *Service) shouldInclude(rel *release.Release, parsed *parser.ParsedRelease) bool {
 	cfg := s.FilterConfig
 
+	// Title patterns
+	if !checkTitlePatterns(s.blockedTitles, s.requiredTitles, rel.Title) {
+		return false
+	}
+
 	// Quality filters
 	if !checkQuality(cfg, parsed) {
 		return false
@@ -133,7 +157,12 @@ func (s *Service) shouldInclude(rel *release.Release, parsed *parser.ParsedRelea
 	}
 
 	// Size filters
-	if !checkSize(cfg, rel) {
+	if !(rel.Size == 0 && s.AllowUnknownSize) && !checkSize(cfg, rel) {
+		return false
+	}
+
+	// Grab filters
+	if !checkGrabs(cfg, rel) {
 		return false
 	}
 
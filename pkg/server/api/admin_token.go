@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+
+	"github.com/gorilla/websocket"
+)
+
+// closeAdminTokenRotated is the WS close code sent to dashboards still using the old
+// admin token; the UI drops its token and shows the login screen.
+const closeAdminTokenRotated = 4001
+
+var errAdminOnly = errors.New("only admin can rotate the admin token")
+
+// currentConfig returns the running config. The config is never changed in place;
+// the writers serialized by configMu swap in a new one.
+func (s *Server) currentConfig() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// rotateAdminToken replaces the config admin token with a new one and saves the config.
+// The old token stops working right away, for the dashboard and for Stremio install URLs.
+// Dashboards connected with the old token are disconnected, except keep (the requester).
+func (s *Server) rotateAdminToken(device *auth.Device, keep *Client) (string, error) {
+	if device == nil || !device.IsAdmin() {
+		return "", errAdminOnly
+	}
+	token, err := auth.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Like applyConfig: save a copy, then swap it in, so readers never see a half-updated
+	// config; configMu keeps a concurrent save from writing the old token back
+	s.configMu.Lock()
+	oldCfg := s.currentConfig()
+	newCfg := *oldCfg
+	newCfg.AdminToken = token
+	if err := newCfg.Save(); err != nil {
+		s.configMu.Unlock()
+		return "", err
+	}
+	s.mu.Lock()
+	s.config = &newCfg
+	s.mu.Unlock()
+	if s.strmServer != nil {
+		s.strmServer.SetConfig(&newCfg)
+	}
+	s.configMu.Unlock()
+
+	logger.Info("Admin token rotated", "by", device.Username)
+	s.disconnectTokenClients(oldCfg.AdminToken, keep)
+	return token, nil
+}
+
+// withAdminCredentials returns cfg with the admin password and token of from, copying
+// cfg only when they differ.
+func withAdminCredentials(cfg, from *config.Config) *config.Config {
+	if cfg.AdminPasswordHash == from.AdminPasswordHash && cfg.AdminToken == from.AdminToken &&
+		cfg.AdminMustChangePassword == from.AdminMustChangePassword {
+		return cfg
+	}
+	c := *cfg
+	c.AdminPasswordHash = from.AdminPasswordHash
+	c.AdminToken = from.AdminToken
+	c.AdminMustChangePassword = from.AdminMustChangePassword
+	return &c
+}
+
+// disconnectTokenClients closes the WS connections authenticated with token, except keep.
+func (s *Server) disconnectTokenClients(token string, keep *Client) {
+	if token == "" {
+		return
+	}
+	closeMsg := websocket.FormatCloseMessage(closeAdminTokenRotated, "admin token rotated")
+	deadline := time.Now().Add(time.Second)
+
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+	for client := range s.clients {
+		if client == keep || client.device == nil || client.device.Token != token {
+			continue
+		}
+		// WriteControl and Close are safe alongside the client's read and write loops,
+		// which exit on the closed connection and unregister the client.
+		client.conn.WriteControl(websocket.CloseMessage, closeMsg, deadline)
+		client.conn.Close()
+	}
+}
+
+// handleRotateAdminTokenWS handles rotate_admin_token. The response carries the new token
+// so the dashboard can reconnect with it and show the new Stremio install URL.
+func (s *Server) handleRotateAdminTokenWS(client *Client) {
+	token, err := s.rotateAdminToken(client.device, client)
+	if err != nil {
+		errorPayload, _ := json.Marshal(map[string]string{"error": err.Error()})
+		trySendWS(client, WSMessage{Type: "rotate_admin_token_response", Payload: errorPayload})
+		return
+	}
+	respPayload, _ := json.Marshal(map[string]interface{}{
+		"success": true,
+		"token":   token,
+	})
+	trySendWS(client, WSMessage{Type: "rotate_admin_token_response", Payload: respPayload})
+}
+
+// handleRotateAdminToken is the REST equivalent of the rotate_admin_token WS command.
+// POST /api/admin/rotate-token (admin only). The session cookie is renewed with the new token.
+func (s *Server) handleRotateAdminToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+	token, err := s.rotateAdminToken(device, nil)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "auth_session",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   false, // Set to true in production with HTTPS
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   86400 * 7, // 7 days
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"token":   token,
+	})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/app"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/session"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRotateAdminToken(t *testing.T) {
+	logger.Init("DEBUG")
+	s := &Server{
+		config:        &config.Config{AdminUsername: "admin", AdminToken: "old-token", LoadedPath: filepath.Join(t.TempDir(), "config.json")},
+		deviceManager: testDeviceManager(t),
+		clients:       make(map[*Client]bool),
+	}
+	h := s.Handler()
+	status := func(token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/admin/rotate-token", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	if got := status("old-token"); got != http.StatusMethodNotAllowed {
+		t.Fatalf("old token before rotation: status %d; want 405 (authenticated)", got)
+	}
+
+	prev := s.config
+	token, err := s.rotateAdminToken(&auth.Device{Username: "admin", Role: auth.RoleAdmin}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev.AdminToken != "old-token" {
+		t.Error("the previous config was changed in place")
+	}
+	if got := status("old-token"); got != http.StatusUnauthorized {
+		t.Errorf("old token after rotation: status %d; want 401", got)
+	}
+	if got := status(token); got != http.StatusMethodNotAllowed {
+		t.Errorf("new token: status %d; want 405 (authenticated)", got)
+	}
+	saved, _ := os.ReadFile(s.config.LoadedPath)
+	if !strings.Contains(string(saved), token) {
+		t.Error("new token not saved to config.json")
+	}
+
+	if _, err := s.rotateAdminToken(&auth.Device{Username: "tv", Role: auth.RoleUser}, nil); err != errAdminOnly {
+		t.Errorf("rotation by a user device: err = %v; want errAdminOnly", err)
+	}
+}
+
+func TestRotateAdminTokenSaveFailure(t *testing.T) {
+	logger.Init("DEBUG")
+	blocker := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(blocker, nil, 0644)
+	cfg := &config.Config{AdminToken: "old-token", LoadedPath: filepath.Join(blocker, "config.json")}
+	s := &Server{config: cfg, clients: make(map[*Client]bool)}
+
+	if _, err := s.rotateAdminToken(&auth.Device{Username: "admin", Role: auth.RoleAdmin}, nil); err == nil {
+		t.Fatal("rotation succeeded although the config could not be saved")
+	}
+	if s.config != cfg || cfg.AdminToken != "old-token" {
+		t.Errorf("admin token changed after a failed save: %q", s.config.AdminToken)
+	}
+}
+
+func TestDisconnectTokenClients(t *testing.T) {
+	logger.Init("DEBUG")
+	s := &Server{clients: make(map[*Client]bool)}
+	serverConns := make(chan *websocket.Conn, 3)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		serverConns <- conn
+	}))
+	defer srv.Close()
+
+	dial := func(token string) (*websocket.Conn, *Client) {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		client := &Client{conn: <-serverConns, device: &auth.Device{Username: "admin", Token: token}}
+		s.clients[client] = true
+		return conn, client
+	}
+	stale, _ := dial("old-token")
+	requester, keep := dial("old-token")
+	other, _ := dial("device-token")
+
+	s.disconnectTokenClients("old-token", keep)
+
+	stale.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := stale.ReadMessage()
+	if !websocket.IsCloseError(err, closeAdminTokenRotated) {
+		t.Errorf("dashboard with the old token: err = %v; want close %d", err, closeAdminTokenRotated)
+	}
+	for name, conn := range map[string]*websocket.Conn{"requester": requester, "other device": other} {
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, _, err := conn.ReadMessage(); websocket.IsCloseError(err, closeAdminTokenRotated) {
+			t.Errorf("%s was disconnected", name)
+		}
+	}
+}
+
+func TestRotateAdminTokenDuringSaveConfig(t *testing.T) {
+	logger.Init("DEBUG")
+	cfg := &config.Config{AdminUsername: "admin", AdminToken: "old-token", LoadedPath: filepath.Join(t.TempDir(), "config.json")}
+	a := app.New()
+	if _, err := a.Build(cfg, app.BuildOpts{DataDir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{config: cfg, app: a, sessionMgr: session.NewManager(nil, time.Minute), clients: make(map[*Client]bool)}
+	admin := &auth.Device{Username: "admin", Role: auth.RoleAdmin}
+	client := &Client{send: make(chan WSMessage, 64), device: admin}
+	// What the dashboard sends: the config without admin credentials
+	payload, _ := json.Marshal(config.Config{AdminUsername: "admin"})
+
+	var wg sync.WaitGroup
+	var token string
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			tok, err := s.rotateAdminToken(admin, nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			token = tok
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			s.handleSaveConfigWS(nil, client, payload)
+			for len(client.send) > 0 {
+				if msg := <-client.send; msg.Type == "save_status" && strings.Contains(string(msg.Payload), `"error"`) {
+					t.Errorf("save_config failed: %s", msg.Payload)
+				}
+			}
+		}
+	}()
+	wg.Wait()
+
+	time.Sleep(200 * time.Millisecond) // background reloads of the last saves
+	if got := s.currentConfig().AdminToken; got != token {
+		t.Errorf("running admin token %q; want the last rotated token %q", got, token)
+	}
+	data, err := os.ReadFile(cfg.LoadedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved config.Config
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.AdminToken != token {
+		t.Errorf("saved admin token %q; want the last rotated token %q", saved.AdminToken, token)
+	}
+}
+
+func TestReloadKeepsRotatedToken(t *testing.T) {
+	logger.Init("DEBUG")
+	stale := &config.Config{AdminUsername: "admin", AdminToken: "old-token", ReadAheadSegments: 4}
+	s := &Server{config: &config.Config{AdminUsername: "admin", AdminToken: "new-token"}, sessionMgr: session.NewManager(nil, time.Minute)}
+
+	// A reload started before the rotation finishes after it
+	s.ReloadFromComponents(&app.Components{Config: stale}, false)
+	if got := s.currentConfig(); got.AdminToken != "new-token" || got.ReadAheadSegments != 4 {
+		t.Errorf("config after reload: token %q, read-ahead %d; want new-token, 4", got.AdminToken, got.ReadAheadSegments)
+	}
+	if stale.AdminToken != "old-token" {
+		t.Error("the reloaded config was changed in place")
+	}
+}
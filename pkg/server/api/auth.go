@@ -17,42 +17,29 @@ type LoginResponse struct {
 	Success            bool   `json:"success"`
 	Token              string `json:"token,omitempty"`
 	User               string `json:"user,omitempty"`
+	Role               string `json:"role,omitempty"`
 	MustChangePassword bool   `json:"must_change_password,omitempty"`
-	Error              string `json:"error,omitempty"`
 }
 
 // handleLogin handles user login
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Invalid request")
 		return
 	}
 
-	adminUsername := s.config.GetAdminUsername()
-	if req.Username != adminUsername {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(LoginResponse{
-			Success: false,
-			Error:   "Invalid credentials",
-		})
-		return
-	}
-
-	device, err := s.deviceManager.Authenticate(req.Username, req.Password, adminUsername, s.config.AdminPasswordHash, s.config.AdminToken)
+	// The config admin, or an admin/manager device with a password
+	cfg := s.currentConfig()
+	adminUsername := cfg.GetAdminUsername()
+	device, err := s.deviceManager.Authenticate(req.Username, req.Password, adminUsername, cfg.AdminPasswordHash, cfg.AdminToken)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(LoginResponse{
-			Success: false,
-			Error:   "Invalid credentials",
-		})
+		writeAPIError(w, http.StatusUnauthorized, errCodeUnauthorized, "Invalid credentials")
 		return
 	}
 
@@ -77,6 +64,7 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		Success:            true,
 		Token:              device.Token, // Empty for admin
 		User:               device.Username,
+		Role:               string(device.EffectiveRole()),
 		MustChangePassword: mustChangePassword,
 	})
 }
@@ -84,7 +72,7 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 // handleInfo returns app info (version) - public, no auth
 func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeMethodNotAllowed(w)
 		return
 	}
 	version := "dev"
@@ -102,7 +90,8 @@ func (s *Server) handleAuthCheck(w http.ResponseWriter, r *http.Request) {
 		// Try cookie
 		cookie, err := r.Cookie("auth_session")
 		if err == nil && cookie != nil {
-			device, err = s.deviceManager.AuthenticateToken(cookie.Value, s.config.GetAdminUsername(), s.config.AdminToken)
+			cfg := s.currentConfig()
+			device, err = s.deviceManager.AuthenticateToken(cookie.Value, cfg.GetAdminUsername(), cfg.AdminToken)
 			if err == nil {
 				ok = true
 			}
@@ -120,6 +109,7 @@ func (s *Server) handleAuthCheck(w http.ResponseWriter, r *http.Request) {
 			"authenticated":        true,
 			"username":             device.Username,
 			"must_change_password": mustChangePassword,
+			"role":                 device.EffectiveRole(),
 		})
 	} else {
 		w.Header().Set("Content-Type", "application/json")
@@ -142,6 +132,7 @@ func hasCustomFilters(filters config.FilterConfig) bool {
 		len(filters.BlockedQualities) > 0 ||
 		filters.MinResolution != "" ||
 		filters.MaxResolution != "" ||
+		len(filters.AllowedResolutionGroups) > 0 ||
 		len(filters.AllowedCodecs) > 0 ||
 		len(filters.BlockedCodecs) > 0 ||
 		len(filters.RequiredAudio) > 0 ||
@@ -163,7 +154,10 @@ func hasCustomFilters(filters config.FilterConfig) bool {
 		filters.MinBitDepth != "" ||
 		filters.MinSizeGB > 0 ||
 		filters.MaxSizeGB > 0 ||
-		len(filters.BlockedGroups) > 0
+		filters.MinGrabs > 0 ||
+		len(filters.BlockedGroups) > 0 ||
+		len(filters.BlockedTitlePatterns) > 0 ||
+		len(filters.RequiredTitlePatterns) > 0
 }
 
 // hasAnyOtherFilterSet checks if any filter field (except AllowRepack) is set
@@ -172,6 +166,7 @@ func hasAnyOtherFilterSet(filters config.FilterConfig) bool {
 		len(filters.BlockedQualities) > 0 ||
 		filters.MinResolution != "" ||
 		filters.MaxResolution != "" ||
+		len(filters.AllowedResolutionGroups) > 0 ||
 		len(filters.AllowedCodecs) > 0 ||
 		len(filters.BlockedCodecs) > 0 ||
 		len(filters.RequiredAudio) > 0 ||
@@ -190,7 +185,10 @@ func hasAnyOtherFilterSet(filters config.FilterConfig) bool {
 		filters.MinBitDepth != "" ||
 		filters.MinSizeGB > 0 ||
 		filters.MaxSizeGB > 0 ||
-		len(filters.BlockedGroups) > 0
+		filters.MinGrabs > 0 ||
+		len(filters.BlockedGroups) > 0 ||
+		len(filters.BlockedTitlePatterns) > 0 ||
+		len(filters.RequiredTitlePatterns) > 0
 }
 
 // hasCustomSorting checks if user has custom sorting configuration
@@ -204,7 +202,11 @@ func hasCustomSorting(sorting config.SortConfig) bool {
 		sorting.GrabWeight != 0 ||
 		sorting.AgeWeight != 0 ||
 		len(sorting.PreferredGroups) > 0 ||
-		len(sorting.PreferredLanguages) > 0
+		len(sorting.PreferredLanguages) > 0 ||
+		sorting.LanguageWeight != 0 ||
+		len(sorting.PreferredVisualTags) > 0 ||
+		len(sorting.PreferredRegions) > 0 ||
+		sorting.RegionWeight != 0
 }
 
 // REST endpoint removed - config saving now uses WebSocket
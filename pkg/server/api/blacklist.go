@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/search/triage"
+)
+
+// BlacklistRequest names a release to blacklist or un-blacklist: an active session,
+// or the release title and/or details URL.
+type BlacklistRequest struct {
+	SessionID  string `json:"session_id,omitempty"`
+	Title      string `json:"title,omitempty"`
+	DetailsURL string `json:"details_url,omitempty"`
+}
+
+// BlacklistResponse is the body of the blacklist endpoints and WS responses.
+type BlacklistResponse struct {
+	Entries []triage.BlacklistEntry `json:"entries"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+var (
+	errBlacklistUnavailable = errors.New("blacklist unavailable")
+	errBlacklistNotFound    = errors.New("release not blacklisted")
+	errBlacklistNotOwner    = errors.New("only admin or the device that blacklisted a release can remove it")
+	errSessionNotFound      = errors.New("session not found")
+)
+
+func (s *Server) releaseBlacklist() *triage.Blacklist {
+	s.mu.RLock()
+	strmServer := s.strmServer
+	s.mu.RUnlock()
+	if strmServer == nil {
+		return nil
+	}
+	return strmServer.Blacklist()
+}
+
+// resolveBlacklistRequest fills title and details URL from the session, when given.
+func (s *Server) resolveBlacklistRequest(req BlacklistRequest) (BlacklistRequest, error) {
+	if req.SessionID == "" {
+		return req, nil
+	}
+	s.mu.RLock()
+	sessionMgr := s.sessionMgr
+	s.mu.RUnlock()
+	if sessionMgr == nil {
+		return req, errSessionNotFound
+	}
+	sess, err := sessionMgr.GetSession(req.SessionID)
+	if err != nil || sess.Release == nil {
+		return req, errSessionNotFound
+	}
+	req.Title = sess.Release.Title
+	req.DetailsURL = sess.Release.DetailsURL
+	return req, nil
+}
+
+// blacklistRelease hides a release from the search results of every device.
+func (s *Server) blacklistRelease(device *auth.Device, req BlacklistRequest) error {
+	bl := s.releaseBlacklist()
+	if bl == nil {
+		return errBlacklistUnavailable
+	}
+	req, err := s.resolveBlacklistRequest(req)
+	if err != nil {
+		return err
+	}
+	entry, err := bl.Add(req.Title, req.DetailsURL, device.Username)
+	if err != nil {
+		return err
+	}
+	logger.Info("Release blacklisted", "title", entry.Title, "details_url", entry.DetailsURL, "device", device.Username)
+	s.flushStreamCache()
+	return nil
+}
+
+// unblacklistRelease removes a release from the blacklist. Devices other than admin can
+// only remove what they added.
+func (s *Server) unblacklistRelease(device *auth.Device, req BlacklistRequest) error {
+	bl := s.releaseBlacklist()
+	if bl == nil {
+		return errBlacklistUnavailable
+	}
+	req, err := s.resolveBlacklistRequest(req)
+	if err != nil {
+		return err
+	}
+	found := bl.Find(req.Title, req.DetailsURL)
+	if len(found) == 0 {
+		return errBlacklistNotFound
+	}
+	if !device.IsAdmin() {
+		for _, e := range found {
+			if e.AddedBy != device.Username {
+				return errBlacklistNotOwner
+			}
+		}
+	}
+	if _, err := bl.Remove(req.Title, req.DetailsURL); err != nil {
+		return err
+	}
+	logger.Info("Release removed from blacklist", "title", req.Title, "details_url", req.DetailsURL, "device", device.Username)
+	s.flushStreamCache()
+	return nil
+}
+
+// flushStreamCache drops cached stream lists so a blacklist change shows on the next request.
+func (s *Server) flushStreamCache() {
+	s.mu.RLock()
+	strmServer := s.strmServer
+	s.mu.RUnlock()
+	if strmServer != nil {
+		strmServer.FlushStreamCache()
+	}
+}
+
+// handleBlacklistWS handles get_blacklist, blacklist_release and unblacklist_release.
+// Every command answers with blacklist_response carrying the current list.
+func (s *Server) handleBlacklistWS(client *Client, msgType string, payload json.RawMessage) {
+	var resp BlacklistResponse
+	if msgType != "get_blacklist" {
+		var req BlacklistRequest
+		var err error
+		if err = json.Unmarshal(payload, &req); err != nil {
+			err = errors.New("invalid payload")
+		} else if msgType == "blacklist_release" {
+			err = s.blacklistRelease(client.device, req)
+		} else {
+			err = s.unblacklistRelease(client.device, req)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+	}
+	resp.Entries = s.releaseBlacklist().List()
+	respPayload, _ := json.Marshal(resp)
+	trySendWS(client, WSMessage{Type: "blacklist_response", Payload: respPayload})
+}
+
+// handleBlacklist is the REST equivalent of the blacklist WS commands:
+// GET /api/blacklist lists, POST adds and DELETE removes the release named in the JSON body.
+func (s *Server) handleBlacklist(w http.ResponseWriter, r *http.Request) {
+	device, ok := auth.DeviceFromContext(r)
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
+		return
+	}
+	if r.Method != http.MethodGet {
+		var req BlacklistRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Invalid JSON body")
+			return
+		}
+		var err error
+		switch r.Method {
+		case http.MethodPost:
+			err = s.blacklistRelease(device, req)
+		case http.MethodDelete:
+			err = s.unblacklistRelease(device, req)
+		default:
+			writeMethodNotAllowed(w)
+			return
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, errBlacklistNotOwner):
+			writeAPIError(w, http.StatusForbidden, errCodeForbidden, err.Error())
+			return
+		case errors.Is(err, errBlacklistNotFound), errors.Is(err, errSessionNotFound):
+			writeAPIError(w, http.StatusNotFound, errCodeNotFound, err.Error())
+			return
+		case errors.Is(err, errBlacklistUnavailable):
+			writeAPIError(w, http.StatusServiceUnavailable, errCodeUnavailable, err.Error())
+			return
+		case errors.Is(err, triage.ErrEmptyBlacklistEntry):
+			writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, err.Error())
+			return
+		default:
+			writeAPIError(w, http.StatusInternalServerError, errCodeInternal, err.Error())
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BlacklistResponse{Entries: s.releaseBlacklist().List()})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/loader"
+)
+
+// FlushCachesResult reports how many entries were removed per cache.
+type FlushCachesResult struct {
+	Cleared map[string]int `json:"cleared"`
+}
+
+// FlushCaches purges the stream result, metadata (TMDB/TVDB), blueprint and segment
+// caches so re-uploaded releases or changed indexer data are picked up without a restart.
+// AvailNZB results are not cached locally and always come fresh from the API.
+func (s *Server) FlushCaches() FlushCachesResult {
+	s.mu.RLock()
+	strmServer := s.strmServer
+	sessionMgr := s.sessionMgr
+	s.mu.RUnlock()
+
+	cleared := make(map[string]int)
+	if strmServer != nil {
+		for name, n := range strmServer.FlushCaches() {
+			cleared[name] = n
+		}
+	}
+	if sessionMgr != nil {
+		cleared["blueprints"] = sessionMgr.ClearBlueprintCache()
+	}
+	cleared["segments"] = loader.ClearSegmentCache()
+	logger.Info("Caches flushed", "cleared", cleared)
+	return FlushCachesResult{Cleared: cleared}
+}
+
+func (s *Server) handleFlushCachesWS(client *Client) {
+	if !client.device.IsAdmin() {
+		trySendWS(client, WSMessage{Type: "flush_caches_response", Payload: json.RawMessage(`{"error":"Only admin can flush caches"}`)})
+		return
+	}
+	respPayload, _ := json.Marshal(s.FlushCaches())
+	trySendWS(client, WSMessage{Type: "flush_caches_response", Payload: respPayload})
+}
+
+// handleFlushCaches is the REST equivalent of the flush_caches WS command.
+// POST /api/caches/flush (admin only).
+func (s *Server) handleFlushCaches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.FlushCaches())
+}
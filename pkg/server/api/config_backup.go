@@ -0,0 +1,111 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+const maxConfigImportBytes = 10 << 20
+
+// ConfigBackup is a full configuration backup: config.json and the devices from state.json.
+// Nothing is redacted; it contains provider and indexer credentials, the admin password
+// hash and token, and every device's token.
+type ConfigBackup struct {
+	ExportedAt time.Time               `json:"exported_at"`
+	Config     config.Config           `json:"config"`
+	Devices    map[string]*auth.Device `json:"devices"`
+}
+
+// handleConfigExport returns a full backup of the configuration and devices.
+// GET /api/config/export (admin only).
+func (s *Server) handleConfigExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+	s.mu.RLock()
+	backup := ConfigBackup{ExportedAt: time.Now().UTC(), Config: *s.config}
+	s.mu.RUnlock()
+	if s.deviceManager != nil {
+		backup.Devices = s.deviceManager.ExportDevices()
+	}
+
+	logger.Info("Configuration exported", "devices", len(backup.Devices))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="streamnzb-backup-%s.json"`, backup.ExportedAt.Format("20060102-150405")))
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(backup)
+}
+
+// handleConfigImport validates and applies a backup from handleConfigExport, then reloads
+// like save_config. Devices are replaced only when the backup contains a devices section.
+// POST /api/config/import (admin only).
+func (s *Server) handleConfigImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+
+	var backup ConfigBackup
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxConfigImportBytes)).Decode(&backup); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Invalid backup: "+err.Error())
+		return
+	}
+	newCfg := backup.Config
+
+	if fieldErrors := s.validateConfig(&newCfg); len(fieldErrors) > 0 {
+		writeValidationError(w, fieldErrors)
+		return
+	}
+
+	// Devices are checked and replaced before the config is applied; if applying fails
+	// the previous devices are put back, so config and devices never come from different backups.
+	var prevDevices map[string]*auth.Device
+	if backup.Devices != nil && s.deviceManager != nil {
+		prevDevices = s.deviceManager.ExportDevices()
+		if err := s.deviceManager.ImportDevices(backup.Devices, newCfg.GetAdminUsername()); err != nil {
+			writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Failed to import devices: "+err.Error())
+			return
+		}
+	}
+
+	s.mu.RLock()
+	prevAdmin := s.config.GetAdminUsername()
+	s.mu.RUnlock()
+	// A backup without admin credentials keeps the current ones.
+	if err := s.applyConfig(&newCfg, newCfg.AdminPasswordHash == "" || newCfg.AdminToken == ""); err != nil {
+		if prevDevices != nil {
+			if rbErr := s.deviceManager.ImportDevices(prevDevices, prevAdmin); rbErr != nil {
+				logger.Error("Failed to restore devices after a failed import", "err", rbErr)
+			}
+		}
+		writeAPIError(w, http.StatusInternalServerError, errCodeInternal, "Failed to save config: "+err.Error())
+		return
+	}
+
+	logger.Info("Configuration imported", "devices", len(backup.Devices))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Configuration imported and reloaded.",
+		"devices": len(backup.Devices),
+	})
+}
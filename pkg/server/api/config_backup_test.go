@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+var (
+	testDevicesOnce sync.Once
+	testDevices     *auth.DeviceManager
+)
+
+// testDeviceManager returns the process-wide device manager, backed by a temp dir
+// that outlives individual tests.
+func testDeviceManager(t *testing.T) *auth.DeviceManager {
+	t.Helper()
+	testDevicesOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "streamnzb-api-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testDevices, err = auth.GetDeviceManager(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return testDevices
+}
+
+func adminRequest(method, target string, body []byte) *http.Request {
+	r := httptest.NewRequest(method, target, bytes.NewReader(body))
+	return r.WithContext(auth.ContextWithDevice(r.Context(), &auth.Device{Username: "admin", Role: auth.RoleAdmin}))
+}
+
+func TestConfigImportKeepsDevicesOnFailure(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := testDeviceManager(t)
+	if err := dm.ImportDevices(map[string]*auth.Device{"tv": {Token: "tvtok"}}, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	os.WriteFile(blocker, nil, 0644)
+	// config.json under a regular file cannot be written
+	s := &Server{config: &config.Config{LoadedPath: filepath.Join(blocker, "config.json")}, deviceManager: dm}
+
+	doImport := func(backup ConfigBackup) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(backup)
+		w := httptest.NewRecorder()
+		s.handleConfigImport(w, adminRequest(http.MethodPost, "/api/config/import", body))
+		return w
+	}
+	devices := map[string]*auth.Device{"phone": {Token: "phonetok"}}
+
+	// Invalid config: rejected before the devices are touched
+	if w := doImport(ConfigBackup{Config: config.Config{TLSCertFile: "cert.pem"}, Devices: devices}); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid config: status %d; want 400", w.Code)
+	}
+	if _, ok := dm.ExportDevices()["tv"]; !ok {
+		t.Error("devices replaced by a backup with an invalid config")
+	}
+
+	// Saving the config fails: the devices are rolled back and the running config kept
+	prev := s.config
+	if w := doImport(ConfigBackup{Config: config.Config{AddonPort: 7001}, Devices: devices}); w.Code != http.StatusInternalServerError {
+		t.Fatalf("unsaveable config: status %d; want 500", w.Code)
+	}
+	got := dm.ExportDevices()
+	if _, ok := got["tv"]; !ok || len(got) != 1 {
+		t.Errorf("devices after a failed import = %v; want the previous devices", got)
+	}
+	if s.config != prev {
+		t.Error("running config replaced although saving failed")
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// originAllowed reports whether a browser Origin may use the admin API and WebSocket.
+// Requests without an Origin (scripts, curl) and same-origin requests are always allowed.
+// With no AllowedOrigins configured every origin is allowed, as before the setting existed.
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	s.mu.RLock()
+	allowed := s.config.AllowedOrigins
+	s.mu.RUnlock()
+	if len(allowed) == 0 {
+		return true
+	}
+	origin = strings.TrimSuffix(origin, "/")
+	for _, o := range allowed {
+		o = strings.TrimSuffix(strings.TrimSpace(o), "/")
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware applies AllowedOrigins to the admin API: listed origins get CORS
+// headers (with credentials, for the session cookie) and preflight answers; other
+// cross-origin requests are rejected. Without AllowedOrigins nothing changes.
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		s.mu.RLock()
+		configured := len(s.config.AllowedOrigins) > 0
+		s.mu.RUnlock()
+		if origin == "" || !configured {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !s.originAllowed(r) {
+			writeAPIError(w, http.StatusForbidden, errCodeForbidden, "Origin not allowed")
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
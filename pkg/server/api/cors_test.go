@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"streamnzb/pkg/core/config"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	s := &Server{config: &config.Config{AllowedOrigins: []string{"https://dash.example.com/", " http://localhost:5173 "}}}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},                            // no browser origin
+		{"http://api.example.com:7000", true}, // same origin as the request host
+		{"https://dash.example.com", true},
+		{"HTTPS://DASH.EXAMPLE.COM", true},
+		{"http://localhost:5173", true},
+		{"https://evil.example.com", false},
+		{"https://dash.example.com.evil.com", false},
+		{"http://localhost:5174", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com:7000/api/info", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := s.originAllowed(r); got != tt.want {
+			t.Errorf("originAllowed(%q) = %v; want %v", tt.origin, got, tt.want)
+		}
+	}
+
+	s.config = &config.Config{}
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com:7000/api/info", nil)
+	r.Header.Set("Origin", "https://anywhere.example.com")
+	if !s.originAllowed(r) {
+		t.Error("any origin should be allowed without AllowedOrigins")
+	}
+	s.config = &config.Config{AllowedOrigins: []string{"*"}}
+	if !s.originAllowed(r) {
+		t.Error(`"*" should allow any origin`)
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	s := &Server{config: &config.Config{AllowedOrigins: []string{"https://dash.example.com"}}}
+	var reached int
+	h := s.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached++
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "http://api.example.com/api/info", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, "https://dash.example.com")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://dash.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("allowed origin: status %d, headers %v", w.Code, w.Header())
+	}
+
+	reached = 0
+	w = serve(http.MethodOptions, "https://dash.example.com")
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") == "" || w.Header().Get("Access-Control-Allow-Headers") == "" {
+		t.Errorf("preflight: status %d, headers %v", w.Code, w.Header())
+	}
+	if reached != 0 {
+		t.Error("preflight reached the API handler")
+	}
+
+	w = serve(http.MethodOptions, "https://evil.example.com")
+	if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed preflight: status %d, headers %v", w.Code, w.Header())
+	}
+	w = serve(http.MethodGet, "https://evil.example.com")
+	if w.Code != http.StatusForbidden || reached != 0 {
+		t.Errorf("disallowed origin: status %d, handler reached %d times", w.Code, reached)
+	}
+
+	// Same-origin and non-browser requests pass without CORS headers
+	for _, origin := range []string{"", "http://api.example.com"} {
+		w = serve(http.MethodGet, origin)
+		if w.Code != http.StatusOK {
+			t.Errorf("origin %q: status %d", origin, w.Code)
+		}
+	}
+
+	s.config = &config.Config{}
+	w = serve(http.MethodGet, "https://evil.example.com")
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("without AllowedOrigins: status %d, headers %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/server/stremio"
+)
+
+// RecentErrorsResponse is the body of GET /api/diagnostics/recent-errors.
+type RecentErrorsResponse struct {
+	Errors []stremio.FailedRequest `json:"errors"`
+}
+
+// handleRecentErrors returns the last failed /stream and /play requests, newest first,
+// with the candidates each search validated and why they failed.
+// GET /api/diagnostics/recent-errors (admin only).
+func (s *Server) handleRecentErrors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+	s.mu.RLock()
+	strmServer := s.strmServer
+	s.mu.RUnlock()
+	resp := RecentErrorsResponse{Errors: []stremio.FailedRequest{}}
+	if strmServer != nil {
+		if recent := strmServer.RecentErrors(); recent != nil {
+			resp.Errors = recent
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
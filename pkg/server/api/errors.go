@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Stable error codes of the HTTP API; clients should match on these, not on messages.
+const (
+	errCodeBadRequest       = "bad_request"
+	errCodeUnauthorized     = "unauthorized"
+	errCodeForbidden        = "forbidden"
+	errCodeNotFound         = "not_found"
+	errCodeMethodNotAllowed = "method_not_allowed"
+	errCodeConflict         = "conflict"
+	errCodeValidationFailed = "validation_failed"
+	errCodeProviderError    = "provider_error"
+	errCodeUnavailable      = "unavailable"
+	errCodeInternal         = "internal_error"
+)
+
+// APIError is the body of every HTTP API error: {"error":{"code":"...","message":"..."}}.
+// Fields holds per-field messages for validation_failed.
+type APIError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// writeAPIError writes an error envelope with the given status and code.
+func writeAPIError(w http.ResponseWriter, status int, code, msg string) {
+	writeAPIErrorBody(w, status, APIError{Code: code, Message: msg})
+}
+
+// writeValidationError reports config fields that failed validation.
+func writeValidationError(w http.ResponseWriter, fields map[string]string) {
+	writeAPIErrorBody(w, http.StatusBadRequest, APIError{Code: errCodeValidationFailed, Message: "Validation failed", Fields: fields})
+}
+
+// writeMethodNotAllowed is the common error for a wrong HTTP method.
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeAPIError(w, http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "Method not allowed")
+}
+
+// writeForbidden is the common error for endpoints limited to the admin.
+func writeForbidden(w http.ResponseWriter) {
+	writeAPIError(w, http.StatusForbidden, errCodeForbidden, "Forbidden")
+}
+
+func writeAPIErrorBody(w http.ResponseWriter, status int, e APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error APIError `json:"error"`
+	}{e})
+}
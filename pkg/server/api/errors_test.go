@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+func TestAPIErrorEnvelope(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := testDeviceManager(t)
+	s := &Server{
+		config:        &config.Config{AdminUsername: "admin", AdminToken: "admin-token", LoadedPath: filepath.Join(t.TempDir(), "config.json")},
+		deviceManager: dm,
+		clients:       make(map[*Client]bool),
+	}
+	h := s.Handler()
+	user := roleDevice(t, dm, fmt.Sprintf("errors-user-%d", time.Now().UnixNano()), auth.RoleUser)
+
+	tests := []struct {
+		name, method, path, token, body string
+		status                          int
+		code                            string
+	}{
+		{"no credentials", http.MethodGet, "/api/config/export", "", "", http.StatusUnauthorized, errCodeUnauthorized},
+		{"bad login", http.MethodPost, "/api/login", "", `{"username":"admin","password":"wrong"}`, http.StatusUnauthorized, errCodeUnauthorized},
+		{"malformed login", http.MethodPost, "/api/login", "", "{", http.StatusBadRequest, errCodeBadRequest},
+		{"not admin", http.MethodGet, "/api/config/export", user.Token, "", http.StatusForbidden, errCodeForbidden},
+		{"wrong method", http.MethodPost, "/api/config/export", "admin-token", "", http.StatusMethodNotAllowed, errCodeMethodNotAllowed},
+		{"unknown endpoint", http.MethodGet, "/api/nope", "", "", http.StatusNotFound, errCodeNotFound},
+		{"invalid config", http.MethodPost, "/api/config/import", "admin-token", `{"config":{"providers":[{"name":"p","port":119}]}}`, http.StatusBadRequest, errCodeValidationFailed},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		var body struct {
+			Error APIError `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s: body is not an error envelope: %v: %s", tt.name, err, w.Body.String())
+			continue
+		}
+		if w.Code != tt.status || body.Error.Code != tt.code || body.Error.Message == "" {
+			t.Errorf("%s: status %d, error %+v; want %d %s", tt.name, w.Code, body.Error, tt.status, tt.code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: Content-Type %q", tt.name, ct)
+		}
+		if tt.code == errCodeValidationFailed && len(body.Error.Fields) == 0 {
+			t.Errorf("%s: no field errors in %+v", tt.name, body.Error)
+		}
+	}
+}
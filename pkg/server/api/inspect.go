@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streamnzb/pkg/auth"
+)
+
+// handleInspectNZB downloads and parses an NZB and reports its structure without
+// streaming it (admin only). GET /api/nzb/inspect?nzb=<url|path>[&validate=true]
+func (s *Server) handleInspectNZB(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+	if s.strmServer == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, errCodeUnavailable, "Stremio server not available")
+		return
+	}
+	nzbPath := r.URL.Query().Get("nzb")
+	if nzbPath == "" {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Missing 'nzb' query parameter (URL or file path)")
+		return
+	}
+
+	res, err := s.strmServer.InspectNZB(r.Context(), nzbPath, r.URL.Query().Get("validate") == "true")
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, errCodeProviderError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/json"
+
+	"streamnzb/pkg/core/logger"
+)
+
+// LogLevelsPayload is the body of the set_log_levels WS command and its response.
+type LogLevelsPayload struct {
+	Levels map[string]string `json:"levels"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// handleSetLogLevelsWS replaces the per-subsystem log levels until the next restart or
+// config save, e.g. {"levels": {"unpack": "DEBUG"}}; an empty map clears the overrides.
+// To keep them, set log_levels in the config instead.
+func (s *Server) handleSetLogLevelsWS(client *Client, payload json.RawMessage) {
+	if !client.device.IsAdmin() {
+		trySendWS(client, WSMessage{Type: "set_log_levels_response", Payload: json.RawMessage(`{"error":"Only admin can change log levels"}`)})
+		return
+	}
+	var req, resp LogLevelsPayload
+	if err := json.Unmarshal(payload, &req); err != nil {
+		resp.Error = "Invalid payload"
+	} else if err := logger.SetSubsystemLevels(req.Levels); err != nil {
+		resp.Error = err.Error()
+	} else {
+		logger.Info("Log levels changed", "levels", req.Levels)
+	}
+	resp.Levels = logger.SubsystemLevels()
+	respPayload, _ := json.Marshal(resp)
+	trySendWS(client, WSMessage{Type: "set_log_levels_response", Payload: respPayload})
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/server/stremio"
+)
+
+// PrevalidateRequest is the body of POST /api/prevalidate
+type PrevalidateRequest struct {
+	ID       string `json:"id"`                 // IMDb (tt123), tvdb:123 or tmdb:123
+	Season   int    `json:"season"`             // Season number
+	Episodes int    `json:"episodes,omitempty"` // Optional episode count (default: looked up on TMDB)
+}
+
+// handlePrevalidate validates every episode of a season ahead of time (admin only).
+// Progress is broadcast to admin WebSocket clients as "prevalidate_progress"; the
+// response contains the status of every episode once the run completes.
+func (s *Server) handlePrevalidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+	if s.strmServer == nil {
+		writeAPIError(w, http.StatusServiceUnavailable, errCodeUnavailable, "Stremio server not available")
+		return
+	}
+
+	var req PrevalidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Invalid request")
+		return
+	}
+
+	// One season at a time keeps indexer/provider load bounded
+	if !s.prevalidating.CompareAndSwap(false, true) {
+		writeAPIError(w, http.StatusConflict, errCodeConflict, "A prevalidation is already running")
+		return
+	}
+	defer s.prevalidating.Store(false)
+
+	results, err := s.strmServer.PrevalidateSeason(r.Context(), req.ID, req.Season, req.Episodes, func(st stremio.EpisodeStatus) {
+		payload, _ := json.Marshal(map[string]interface{}{"id": req.ID, "status": st})
+		s.broadcastToAdmins(WSMessage{Type: "prevalidate_progress", Payload: payload})
+	})
+	if err != nil && len(results) == 0 {
+		logger.Warn("Prevalidation failed", "id", req.ID, "season", req.Season, "err", err)
+		writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, err.Error())
+		return
+	}
+
+	ready := 0
+	for _, st := range results {
+		if st.Ready {
+			ready++
+		}
+	}
+	resp := map[string]interface{}{
+		"id":       req.ID,
+		"season":   req.Season,
+		"ready":    ready,
+		"episodes": results,
+	}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	logger.Info("Prevalidation finished", "id", req.ID, "season", req.Season, "ready", ready, "episodes", len(results))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
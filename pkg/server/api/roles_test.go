@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+// roleDevice creates a device with role in dm and returns it.
+func roleDevice(t *testing.T, dm *auth.DeviceManager, username string, role auth.Role) *auth.Device {
+	t.Helper()
+	d, err := dm.CreateDevice(username, "", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role != auth.RoleUser {
+		if err := dm.UpdateDeviceRole(username, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, err = dm.GetDevice(username, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestAdminEndpointsDenyOtherRoles(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := testDeviceManager(t)
+	s := &Server{
+		config:        &config.Config{AdminUsername: "admin", AdminToken: "admin-token", LoadedPath: filepath.Join(t.TempDir(), "config.json")},
+		deviceManager: dm,
+		clients:       make(map[*Client]bool),
+	}
+	h := s.Handler()
+	endpoints := []struct{ method, path string }{
+		{http.MethodGet, "/api/config/export"},
+		{http.MethodPost, "/api/config/import"},
+		{http.MethodPost, "/api/caches/flush"},
+		{http.MethodPost, "/api/admin/rotate-token"},
+		{http.MethodGet, "/api/nzb/inspect?nzb=x"},
+		{http.MethodPost, "/api/prevalidate"},
+		{http.MethodGet, "/api/stats/history"},
+		{http.MethodGet, "/api/diagnostics/recent-errors"},
+		{http.MethodPost, "/api/validate/provider"},
+		{http.MethodPost, "/api/validate/indexer"},
+	}
+	for _, d := range []*auth.Device{
+		roleDevice(t, dm, "roles-http-manager", auth.RoleManager),
+		roleDevice(t, dm, "roles-http-user", auth.RoleUser),
+	} {
+		for _, ep := range endpoints {
+			r := httptest.NewRequest(ep.method, ep.path, nil)
+			r.Header.Set("Authorization", "Bearer "+d.Token)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s %s as %s: status %d; want 403", ep.method, ep.path, d.Role, w.Code)
+			}
+		}
+	}
+	if s.config.AdminToken != "admin-token" {
+		t.Error("admin token rotated by a non-admin device")
+	}
+}
+
+// wsReply runs a WS command handler for client and returns the error of its reply.
+func wsReply(t *testing.T, client *Client, handle func()) string {
+	t.Helper()
+	handle()
+	select {
+	case msg := <-client.send:
+		var resp struct {
+			Error   string `json:"error"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(msg.Payload, &resp)
+		if resp.Status == "error" {
+			return resp.Message
+		}
+		return resp.Error
+	default:
+		t.Fatal("no reply")
+		return ""
+	}
+}
+
+func TestAdminWSCommandsDenyOtherRoles(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := testDeviceManager(t)
+	cfg := &config.Config{AdminUsername: "admin", AdminToken: "admin-token", LoadedPath: filepath.Join(t.TempDir(), "config.json")}
+	s := &Server{config: cfg, deviceManager: dm, clients: make(map[*Client]bool)}
+	levels := json.RawMessage(`{"levels":{"stremio":"DEBUG"}}`)
+
+	for _, d := range []*auth.Device{
+		roleDevice(t, dm, "roles-ws-manager", auth.RoleManager),
+		roleDevice(t, dm, "roles-ws-user", auth.RoleUser),
+	} {
+		client := &Client{send: make(chan WSMessage, 4), device: d}
+		commands := map[string]func(){
+			"save_config":        func() { s.handleSaveConfigWS(nil, client, json.RawMessage(`{}`)) },
+			"rotate_admin_token": func() { s.handleRotateAdminTokenWS(client) },
+			"flush_caches":       func() { s.handleFlushCachesWS(client) },
+			"set_log_levels":     func() { s.handleSetLogLevelsWS(client, levels) },
+			"validate_provider":  func() { s.handleValidateProviderWS(client, json.RawMessage(`{}`)) },
+			"validate_indexer":   func() { s.handleValidateIndexerWS(client, json.RawMessage(`{}`)) },
+			"close_session":      func() { s.handleCloseSessionWS(client, json.RawMessage(`{"id":"x"}`)) },
+			"restart":            func() { s.handleRestartWS(client) },
+		}
+		for name, handle := range commands {
+			if errMsg := wsReply(t, client, handle); errMsg == "" {
+				t.Errorf("%s as %s: not denied", name, d.Role)
+			}
+		}
+	}
+	if s.config != cfg || cfg.AdminToken != "admin-token" {
+		t.Error("global config changed by a non-admin device")
+	}
+}
+
+func TestDeviceCommandsByRole(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := testDeviceManager(t)
+	s := &Server{config: &config.Config{AdminUsername: "admin"}, deviceManager: dm, clients: make(map[*Client]bool)}
+	manager := &Client{send: make(chan WSMessage, 4), device: roleDevice(t, dm, "roles-dev-manager", auth.RoleManager)}
+	user := &Client{send: make(chan WSMessage, 4), device: roleDevice(t, dm, "roles-dev-user", auth.RoleUser)}
+	roleDevice(t, dm, "roles-dev-admin", auth.RoleAdmin)
+
+	create := func(c *Client, body string) string {
+		return wsReply(t, c, func() { s.handleCreateDeviceWS(c, json.RawMessage(body)) })
+	}
+	if errMsg := create(user, `{"username":"roles-dev-by-user"}`); errMsg == "" {
+		t.Error("user created a device")
+	}
+	if errMsg := create(manager, `{"username":"roles-dev-elevated","role":"admin"}`); errMsg == "" {
+		t.Error("manager granted the admin role")
+	}
+	if _, err := dm.GetDevice("roles-dev-elevated", "admin"); err == nil {
+		t.Error("device created although the role was refused")
+	}
+	if errMsg := create(manager, `{"username":"roles-dev-by-manager"}`); errMsg != "" {
+		t.Errorf("manager could not create a user device: %s", errMsg)
+	}
+
+	del := func(c *Client, username string) string {
+		return wsReply(t, c, func() { s.handleDeleteDeviceWS(c, json.RawMessage(`{"username":"`+username+`"}`)) })
+	}
+	if errMsg := del(manager, "roles-dev-admin"); errMsg == "" {
+		t.Error("manager deleted an admin device")
+	}
+	if errMsg := del(user, "roles-dev-by-manager"); errMsg == "" {
+		t.Error("user deleted a device")
+	}
+	if errMsg := del(manager, "roles-dev-by-manager"); errMsg != "" {
+		t.Errorf("manager could not delete a user device: %s", errMsg)
+	}
+}
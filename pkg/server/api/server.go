@@ -2,9 +2,10 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 
@@ -27,6 +28,7 @@ import (
 // Server handles API requests and serves the frontend
 type Server struct {
 	mu             sync.RWMutex
+	configMu       sync.Mutex // Serializes config writers: read the config, Save a modified copy, swap it in
 	config         *config.Config
 	providerPools  map[string]*nntp.ClientPool // Map for easy lookup/management
 	streamingPools []*nntp.ClientPool          // Slice for session manager/proxy (superset or same underlying pools)
@@ -41,11 +43,17 @@ type Server struct {
 	availNZBAPIKey string
 	tmdbAPIKey     string
 	tvdbAPIKey     string
+	buildTime      string
 
 	// WebSocket Client Registry
 	clients   map[*Client]bool
 	clientsMu sync.Mutex
 	logCh     chan string
+
+	prevalidating atomic.Bool // Guards POST /api/prevalidate (one run at a time)
+	statsHistory  *statsHistory
+	stop          chan struct{} // Closed by Close to end background work
+	closeOnce     sync.Once
 }
 
 type Client struct {
@@ -84,20 +92,33 @@ func NewServerWithApp(cfg *config.Config, pools map[string]*nntp.ClientPool, ses
 		tvdbAPIKey:     tvdbAPIKey,
 		clients:        make(map[*Client]bool),
 		logCh:          make(chan string, 100),
+		statsHistory:   newStatsHistory(statsHistorySize),
+		stop:           make(chan struct{}),
 	}
 
 	// Start log broadcaster
 	logger.SetBroadcast(s.logCh)
 	go s.broadcastLogs()
+	go s.sampleStats(s.stop)
 
 	return s
 }
 
+// Close stops the server's background work (stats sampling). Safe to call more than once.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.stop) })
+}
+
 // ... (SetProxyServer and Reload remain same)
 
 func (s *Server) broadcastLogs() {
 	for msgStr := range s.logCh {
-		msg := WSMessage{Type: "log_entry", Payload: json.RawMessage(fmt.Sprintf("%q", msgStr))}
+		// json.Marshal (not %q) so control characters in attrs still produce valid JSON
+		payload, err := json.Marshal(msgStr)
+		if err != nil {
+			continue
+		}
+		msg := WSMessage{Type: "log_entry", Payload: payload}
 
 		s.clientsMu.Lock()
 		for client := range s.clients {
@@ -157,10 +178,8 @@ func (s *Server) ReloadFromComponents(comp *app.Components, fullReload bool) {
 		// 3. Update pools and indexer
 		s.providerPools = comp.ProviderPools
 		s.indexer = comp.Indexer
-		s.streamingPools = make([]*nntp.ClientPool, 0, len(comp.ProviderPools))
-		for _, p := range comp.ProviderPools {
-			s.streamingPools = append(s.streamingPools, p)
-		}
+		// Providers with the download/all role, in priority order
+		s.streamingPools = comp.StreamingPools
 		s.sessionMgr.UpdatePools(s.streamingPools)
 
 		// 4. Restart Proxy if enabled
@@ -170,6 +189,7 @@ func (s *Server) ReloadFromComponents(comp *app.Components, fullReload bool) {
 			if err != nil {
 				logger.Error("Failed to create new proxy during reload", "err", err)
 			} else {
+				newProxy.SetPostPool(comp.ProxyPostPool())
 				s.proxyServer = newProxy
 				go func() {
 					if err := newProxy.Start(); err != nil {
@@ -183,11 +203,25 @@ func (s *Server) ReloadFromComponents(comp *app.Components, fullReload bool) {
 		s.cleanupProviderUsage()
 	}
 
-	// Common: always update config, triage, stremio
-	s.config = comp.Config
+	// Common: always update config, triage, stremio. A writer may have swapped in a newer
+	// config since this reload started; its admin credentials win over the reloaded ones.
+	cfg := comp.Config
+	if s.config != nil && s.config != cfg {
+		cfg = withAdminCredentials(cfg, s.config)
+	}
+	s.config = cfg
+	logger.SetFormat(comp.Config.LogFormat)
 	logger.SetLevel(comp.Config.LogLevel)
+	if err := logger.SetSubsystemLevels(comp.Config.LogLevels); err != nil {
+		logger.Warn("Ignoring log_levels", "err", err)
+	}
+	s.sessionMgr.SetReadAheadSegments(comp.Config.ReadAheadSegments)
+	s.sessionMgr.SetPlaybackSegmentTimeout(time.Duration(comp.Config.PlaybackSegmentTimeoutMs) * time.Millisecond)
+	s.sessionMgr.SetPauseRelease(time.Duration(comp.Config.PauseReleaseSeconds) * time.Second)
+	s.sessionMgr.SetSweepInterval(time.Duration(comp.Config.SessionSweepIntervalSeconds) * time.Second)
+	s.sessionMgr.SetIdleTimeout(time.Duration(comp.Config.SessionIdleTimeoutMinutes) * time.Minute)
 	if s.strmServer != nil {
-		s.strmServer.Reload(comp.Config, comp.Config.AddonBaseURL, comp.Indexer, comp.Validator, comp.Triage, comp.AvailClient, comp.AvailNZBIndexerHosts, comp.TMDBClient, comp.TVDBClient, s.deviceManager)
+		s.strmServer.Reload(cfg, cfg.BaseURL(), comp.Indexer, comp.Validator, comp.Triage, comp.AvailClient, comp.AvailNZBIndexerHosts, comp.TMDBClient, comp.TVDBClient, s.deviceManager)
 	}
 }
 
@@ -259,10 +293,25 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/api/login", s.handleLogin)
 	mux.HandleFunc("/api/auth/check", s.handleAuthCheck)
 	mux.HandleFunc("/api/info", s.handleInfo)
+	mux.HandleFunc("/api/version", s.handleVersion)
 
 	// Protected routes (require auth)
-	authMiddleware := auth.AuthMiddleware(s.deviceManager, func() string { return s.config.GetAdminUsername() }, func() string { return s.config.AdminToken })
+	authMiddleware := auth.AuthMiddleware(s.deviceManager, func() string { return s.currentConfig().GetAdminUsername() }, func() string { return s.currentConfig().AdminToken })
 	mux.Handle("/api/ws", authMiddleware(http.HandlerFunc(s.handleWebSocket)))
-
-	return mux
+	mux.Handle("/api/prevalidate", authMiddleware(http.HandlerFunc(s.handlePrevalidate)))
+	mux.Handle("/api/validate/provider", authMiddleware(http.HandlerFunc(s.handleValidateProvider)))
+	mux.Handle("/api/validate/indexer", authMiddleware(http.HandlerFunc(s.handleValidateIndexer)))
+	mux.Handle("/api/nzb/inspect", authMiddleware(http.HandlerFunc(s.handleInspectNZB)))
+	mux.Handle("/api/caches/flush", authMiddleware(http.HandlerFunc(s.handleFlushCaches)))
+	mux.Handle("/api/admin/rotate-token", authMiddleware(http.HandlerFunc(s.handleRotateAdminToken)))
+	mux.Handle("/api/blacklist", authMiddleware(http.HandlerFunc(s.handleBlacklist)))
+	mux.Handle("/api/stats/history", authMiddleware(http.HandlerFunc(s.handleStatsHistory)))
+	mux.Handle("/api/diagnostics/recent-errors", authMiddleware(http.HandlerFunc(s.handleRecentErrors)))
+	mux.Handle("/api/config/export", authMiddleware(http.HandlerFunc(s.handleConfigExport)))
+	mux.Handle("/api/config/import", authMiddleware(http.HandlerFunc(s.handleConfigImport)))
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, http.StatusNotFound, errCodeNotFound, "Unknown API endpoint")
+	})
+
+	return s.corsMiddleware(mux)
 }
@@ -8,6 +8,7 @@ import (
 
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/services/availnzb"
 	"streamnzb/pkg/session"
 )
 
@@ -21,7 +22,18 @@ type SystemStats struct {
 	TotalDownloadedMB float64                     `json:"total_downloaded_mb"` // Sum of all providers
 	Providers         []ProviderStats             `json:"providers"`
 	Indexers          []IndexerStats              `json:"indexers"`
+	Metadata          []MetadataStats             `json:"metadata"`
 	ActiveSessions    []session.ActiveSessionInfo `json:"active_sessions"`
+	SweptSessions     session.SweepStats          `json:"swept_sessions"` // removed by the session sweep since start
+	AvailNZB          *availnzb.BreakerStatus     `json:"availnzb,omitempty"`
+}
+
+// MetadataStats is the rate-limit state of a metadata API (TMDB, TVDB)
+type MetadataStats struct {
+	Name        string     `json:"name"`
+	RateLimited bool       `json:"rate_limited"`            // lookups are skipped until RetryAt
+	RetryAt     *time.Time `json:"retry_at,omitempty"`      // when lookups resume
+	RateLimits  int        `json:"rate_limits_since_start"` // 429 responses since start
 }
 
 // IndexerStats represents statistics and usage for an indexer
@@ -137,11 +149,27 @@ func (s *Server) collectStats() SystemStats {
 		}
 	}
 
+	// Metadata API rate limits
+	if s.strmServer != nil {
+		for name, st := range s.strmServer.MetadataRateLimits() {
+			m := MetadataStats{Name: name, RateLimited: st.RateLimited, RateLimits: st.Trips}
+			if st.RateLimited {
+				m.RetryAt = &st.Until
+			}
+			stats.Metadata = append(stats.Metadata, m)
+		}
+		sort.Slice(stats.Metadata, func(i, j int) bool { return stats.Metadata[i].Name < stats.Metadata[j].Name })
+		if st, ok := s.strmServer.AvailNZBBreaker(); ok {
+			stats.AvailNZB = &st
+		}
+	}
+
 	stats.ActiveConnections = totalActive
 	stats.TotalConnections = totalMax
 
 	// Active Sessions (Detailed)
 	stats.ActiveSessions = s.sessionMgr.GetActiveSessions()
+	stats.SweptSessions = s.sessionMgr.SweepStats()
 
 	// Append Proxy Sessions (Aggregated by IP)
 	s.mu.RLock() // Lock for proxyServer access
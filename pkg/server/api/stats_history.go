@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/auth"
+)
+
+const (
+	statsSampleInterval = 30 * time.Second
+	statsHistorySize    = 2880 // 24h of samples
+)
+
+// StatsSample is one point of the stats history (aggregates only, no per-session details).
+type StatsSample struct {
+	Timestamp         time.Time `json:"timestamp"`
+	ActiveStreams     int       `json:"active_streams"`
+	ActiveConnections int       `json:"active_connections"`
+	TotalSpeed        float64   `json:"total_speed_mbps"`
+	TotalDownloadedMB float64   `json:"total_downloaded_mb"`
+	// Indexer-candidate validations since the previous sample
+	ValidationsOK     int64 `json:"validations_ok"`
+	ValidationsFailed int64 `json:"validations_failed"`
+}
+
+// statsHistory is a fixed-size ring buffer of samples, oldest overwritten first.
+type statsHistory struct {
+	mu      sync.Mutex
+	samples []StatsSample
+	next    int
+	full    bool
+}
+
+func newStatsHistory(size int) *statsHistory {
+	return &statsHistory{samples: make([]StatsSample, size)}
+}
+
+func (h *statsHistory) add(sample StatsSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// since returns the samples taken at or after t, oldest first.
+func (h *statsHistory) since(t time.Time) []StatsSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ordered := h.samples[:h.next]
+	if h.full {
+		ordered = append(append([]StatsSample{}, h.samples[h.next:]...), h.samples[:h.next]...)
+	}
+	result := make([]StatsSample, 0, len(ordered))
+	for _, sample := range ordered {
+		if !sample.Timestamp.Before(t) {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+// sampleStats records a history sample every statsSampleInterval until stop is closed.
+func (s *Server) sampleStats(stop <-chan struct{}) {
+	var last validationTotals
+	ticker := time.NewTicker(statsSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.statsHistory.add(s.takeStatsSample(&last))
+		}
+	}
+}
+
+// validationTotals are the addon's cumulative validation counts at the previous sample.
+type validationTotals struct {
+	ok, failed int64
+}
+
+// takeStatsSample collects the current stats; validations are counted since last,
+// which is advanced to the current totals.
+func (s *Server) takeStatsSample(last *validationTotals) StatsSample {
+	stats := s.collectStats()
+	sample := StatsSample{
+		Timestamp:         stats.Timestamp,
+		ActiveStreams:     stats.ActiveStreams,
+		ActiveConnections: stats.ActiveConnections,
+		TotalSpeed:        stats.TotalSpeed,
+		TotalDownloadedMB: stats.TotalDownloadedMB,
+	}
+	s.mu.RLock()
+	strmServer := s.strmServer
+	s.mu.RUnlock()
+	if strmServer != nil {
+		ok, failed := strmServer.ValidationCounts()
+		if ok < last.ok || failed < last.failed { // addon server was recreated by a reload
+			*last = validationTotals{}
+		}
+		sample.ValidationsOK, sample.ValidationsFailed = ok-last.ok, failed-last.failed
+		*last = validationTotals{ok: ok, failed: failed}
+	}
+	return sample
+}
+
+// StatsHistoryResponse is the body of GET /api/stats/history.
+type StatsHistoryResponse struct {
+	IntervalSeconds int           `json:"interval_seconds"`
+	Samples         []StatsSample `json:"samples"`
+}
+
+// handleStatsHistory returns the sampled stats for the last window (default 1h, at most 24h).
+// GET /api/stats/history?window=1h (admin only).
+func (s *Server) handleStatsHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return
+	}
+	window := time.Hour
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Invalid window (use e.g. 15m, 1h, 24h)")
+			return
+		}
+		window = d
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StatsHistoryResponse{
+		IntervalSeconds: int(statsSampleInterval / time.Second),
+		Samples:         s.statsHistory.since(time.Now().Add(-window)),
+	})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/server/stremio"
+	"streamnzb/pkg/session"
+)
+
+func TestStatsHistoryRing(t *testing.T) {
+	h := newStatsHistory(3)
+	base := time.Now().Add(-time.Hour)
+	if got := h.since(base); len(got) != 0 {
+		t.Fatalf("empty history returned %d samples", len(got))
+	}
+	for i := 0; i < 5; i++ {
+		h.add(StatsSample{Timestamp: base.Add(time.Duration(i) * time.Minute), ActiveStreams: i})
+	}
+	got := h.since(base)
+	if len(got) != 3 {
+		t.Fatalf("expected the ring to keep 3 samples, got %d", len(got))
+	}
+	for i, sample := range got {
+		if sample.ActiveStreams != i+2 {
+			t.Errorf("sample %d: ActiveStreams %d; want %d (oldest first)", i, sample.ActiveStreams, i+2)
+		}
+	}
+	if got := h.since(base.Add(3 * time.Minute)); len(got) != 2 || got[0].ActiveStreams != 3 {
+		t.Errorf("since(+3m) = %+v", got)
+	}
+}
+
+func TestTakeStatsSampleValidationDeltas(t *testing.T) {
+	logger.Init("DEBUG")
+	s := &Server{strmServer: &stremio.Server{}, sessionMgr: session.NewManager(nil, time.Minute)}
+	last := validationTotals{ok: 7, failed: 3} // totals from an addon server replaced by a reload
+	sample := s.takeStatsSample(&last)
+	if sample.ValidationsOK != 0 || sample.ValidationsFailed != 0 || last != (validationTotals{}) {
+		t.Errorf("counters not reset after reload: sample %+v, last %+v", sample, last)
+	}
+	if sample.Timestamp.IsZero() {
+		t.Error("sample has no timestamp")
+	}
+}
+
+func TestSampleStatsStopsOnClose(t *testing.T) {
+	logger.Init("DEBUG")
+	s := &Server{statsHistory: newStatsHistory(4), stop: make(chan struct{}), sessionMgr: session.NewManager(nil, time.Minute)}
+	done := make(chan struct{})
+	go func() {
+		s.sampleStats(s.stop)
+		close(done)
+	}()
+	s.Close()
+	s.Close() // idempotent
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sampleStats still running after Close")
+	}
+}
+
+func TestHandleStatsHistory(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := testDeviceManager(t)
+	s := &Server{
+		config:        &config.Config{AdminUsername: "admin", AdminToken: "admin-token", LoadedPath: filepath.Join(t.TempDir(), "config.json")},
+		deviceManager: dm,
+		clients:       make(map[*Client]bool),
+		statsHistory:  newStatsHistory(8),
+	}
+	now := time.Now()
+	s.statsHistory.add(StatsSample{Timestamp: now.Add(-2 * time.Hour), ActiveStreams: 1})
+	s.statsHistory.add(StatsSample{Timestamp: now.Add(-10 * time.Minute), ActiveStreams: 2})
+	h := s.Handler()
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	tests := []struct {
+		window string
+		want   int
+	}{
+		{"", 1}, // default 1h
+		{"?window=15m", 1},
+		{"?window=3h", 2},
+	}
+	for _, tt := range tests {
+		w := get("/api/stats/history"+tt.window, "admin-token")
+		if w.Code != http.StatusOK {
+			t.Fatalf("window %q: status %d", tt.window, w.Code)
+		}
+		var resp StatsHistoryResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Samples) != tt.want || resp.IntervalSeconds != int(statsSampleInterval/time.Second) {
+			t.Errorf("window %q: %d samples, interval %d", tt.window, len(resp.Samples), resp.IntervalSeconds)
+		}
+	}
+	for _, bad := range []string{"?window=abc", "?window=-1h"} {
+		if w := get("/api/stats/history"+bad, "admin-token"); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d; want 400", bad, w.Code)
+		}
+	}
+	user := roleDevice(t, dm, "stats-history-user", auth.RoleUser)
+	if w := get("/api/stats/history", user.Token); w.Code != http.StatusForbidden {
+		t.Errorf("user device: status %d; want 403", w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+func TestSaveUserConfigsAvailNZBReport(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := testDeviceManager(t)
+	s := &Server{config: &config.Config{AdminUsername: "admin"}, deviceManager: dm, clients: make(map[*Client]bool)}
+	manager := &Client{send: make(chan WSMessage, 4), device: roleDevice(t, dm, "report-manager", auth.RoleManager)}
+	roleDevice(t, dm, "report-tv", auth.RoleUser)
+
+	report := func() *bool {
+		d, err := dm.GetDevice("report-tv", "admin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.AvailNZBReport
+	}
+	save := func(body string) string {
+		return wsReply(t, manager, func() { s.handleSaveUserConfigsWS(nil, manager, json.RawMessage(`{"report-tv":`+body+`}`)) })
+	}
+
+	if errMsg := save(`{"availnzb_report":false}`); errMsg != "" {
+		t.Fatalf("opt-out: %s", errMsg)
+	}
+	if got := report(); got == nil || *got {
+		t.Fatalf("after opting out: %v; want false", got)
+	}
+	if errMsg := save(`{}`); errMsg != "" {
+		t.Fatalf("save without the field: %s", errMsg)
+	}
+	if got := report(); got == nil || *got {
+		t.Errorf("absent field changed the override: %v", got)
+	}
+	if errMsg := save(`{"availnzb_report":"yes"}`); errMsg == "" {
+		t.Error("invalid setting accepted")
+	}
+	if errMsg := save(`{"availnzb_report":null}`); errMsg != "" {
+		t.Fatalf("reset: %s", errMsg)
+	}
+	if got := report(); got != nil {
+		t.Errorf("null did not restore the global setting: %v", *got)
+	}
+}
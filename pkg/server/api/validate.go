@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer/easynews"
+	"streamnzb/pkg/indexer/newznab"
+	"streamnzb/pkg/usenet/nntp"
+)
+
+// ComponentValidation is the result of testing a single provider or indexer.
+// Nothing is persisted; LatencyMs lets users compare providers.
+type ComponentValidation struct {
+	OK        bool   `json:"ok"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	// Providers: the probed ProviderTestGroup and its article count as reported by GROUP
+	TestGroup    string `json:"test_group,omitempty"`
+	ArticleCount int64  `json:"article_count,omitempty"`
+}
+
+func newComponentValidation(start time.Time, err error) ComponentValidation {
+	res := ComponentValidation{OK: err == nil, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// checkProvider connects and authenticates against a provider using a throwaway pool,
+// then selects testGroup (when set) and returns its article count.
+func checkProvider(provider config.Provider, testGroup string) (int64, error) {
+	if provider.Host == "" {
+		return 0, errors.New("Host is required")
+	}
+	pool := nntp.NewClientPool(provider.Host, provider.Port, provider.UseSSL, provider.Username, provider.Password, 1)
+	pool.SetCompression(provider.Compression)
+	defer pool.Shutdown()
+	if err := pool.Validate(); err != nil {
+		return 0, err
+	}
+	if testGroup == "" {
+		return 0, nil
+	}
+	return pool.ProbeGroup(testGroup)
+}
+
+// checkIndexer pings an indexer without registering it or recording usage.
+func checkIndexer(indexerCfg config.IndexerConfig) error {
+	if indexerCfg.Type == "easynews" {
+		if indexerCfg.Username == "" || indexerCfg.Password == "" {
+			return errors.New("Username and password are required")
+		}
+		client, err := easynews.NewClient(indexerCfg.Username, indexerCfg.Password, indexerCfg.Name, "", 0, 0, nil)
+		if err != nil {
+			return err
+		}
+		return client.Ping()
+	}
+	if indexerCfg.URL == "" {
+		return errors.New("URL is required")
+	}
+	return newznab.NewClient(indexerCfg, nil).Ping()
+}
+
+// ValidateProvider tests connectivity and auth for a single provider.
+func (s *Server) ValidateProvider(provider config.Provider) ComponentValidation {
+	s.mu.RLock()
+	testGroup := s.config.ProviderTestGroup
+	s.mu.RUnlock()
+
+	start := time.Now()
+	articles, err := checkProvider(provider, testGroup)
+	res := newComponentValidation(start, err)
+	res.TestGroup, res.ArticleCount = testGroup, articles
+	logger.Debug("Validated provider", "host", provider.Host, "ok", res.OK, "latency_ms", res.LatencyMs, "group", testGroup, "articles", articles)
+	return res
+}
+
+// ValidateIndexer tests connectivity and auth for a single indexer.
+func (s *Server) ValidateIndexer(indexerCfg config.IndexerConfig) ComponentValidation {
+	start := time.Now()
+	res := newComponentValidation(start, checkIndexer(indexerCfg))
+	logger.Debug("Validated indexer", "name", indexerCfg.Name, "ok", res.OK, "latency_ms", res.LatencyMs)
+	return res
+}
+
+func (s *Server) handleValidateProviderWS(client *Client, payload json.RawMessage) {
+	if !client.device.IsAdmin() {
+		trySendWS(client, WSMessage{Type: "validate_provider_response", Payload: json.RawMessage(`{"error":"Only admin can validate providers"}`)})
+		return
+	}
+	var provider config.Provider
+	if err := json.Unmarshal(payload, &provider); err != nil {
+		trySendWS(client, WSMessage{Type: "validate_provider_response", Payload: json.RawMessage(`{"error":"Invalid request"}`)})
+		return
+	}
+	// Connecting can take several seconds; don't block the read loop
+	go func() {
+		respPayload, _ := json.Marshal(s.ValidateProvider(provider))
+		trySendWS(client, WSMessage{Type: "validate_provider_response", Payload: respPayload})
+	}()
+}
+
+func (s *Server) handleValidateIndexerWS(client *Client, payload json.RawMessage) {
+	if !client.device.IsAdmin() {
+		trySendWS(client, WSMessage{Type: "validate_indexer_response", Payload: json.RawMessage(`{"error":"Only admin can validate indexers"}`)})
+		return
+	}
+	var indexerCfg config.IndexerConfig
+	if err := json.Unmarshal(payload, &indexerCfg); err != nil {
+		trySendWS(client, WSMessage{Type: "validate_indexer_response", Payload: json.RawMessage(`{"error":"Invalid request"}`)})
+		return
+	}
+	go func() {
+		respPayload, _ := json.Marshal(s.ValidateIndexer(indexerCfg))
+		trySendWS(client, WSMessage{Type: "validate_indexer_response", Payload: respPayload})
+	}()
+}
+
+// handleValidateProvider is the REST equivalent of the validate_provider WS command.
+// POST /api/validate/provider with a provider object (admin only).
+func (s *Server) handleValidateProvider(w http.ResponseWriter, r *http.Request) {
+	var provider config.Provider
+	if !s.decodeValidateRequest(w, r, &provider) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.ValidateProvider(provider))
+}
+
+// handleValidateIndexer is the REST equivalent of the validate_indexer WS command.
+// POST /api/validate/indexer with an indexer object (admin only).
+func (s *Server) handleValidateIndexer(w http.ResponseWriter, r *http.Request) {
+	var indexerCfg config.IndexerConfig
+	if !s.decodeValidateRequest(w, r, &indexerCfg) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.ValidateIndexer(indexerCfg))
+}
+
+func (s *Server) decodeValidateRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		writeMethodNotAllowed(w)
+		return false
+	}
+	device, ok := auth.DeviceFromContext(r)
+	if !ok || !device.IsAdmin() {
+		writeForbidden(w)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, errCodeBadRequest, "Invalid request")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+// fakeNNTPProvider accepts any login and serves alt.binaries.test with 42 articles.
+func fakeNNTPProvider(t *testing.T) config.Provider {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tp := textproto.NewConn(conn)
+				tp.PrintfLine("200 ready")
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					switch {
+					case strings.HasPrefix(line, "AUTHINFO USER"):
+						tp.PrintfLine("381 password required")
+					case strings.HasPrefix(line, "AUTHINFO PASS"):
+						tp.PrintfLine("281 accepted")
+					case line == "GROUP alt.binaries.test":
+						tp.PrintfLine("211 42 1 42 alt.binaries.test")
+					case strings.HasPrefix(line, "GROUP"):
+						tp.PrintfLine("411 no such group")
+					default:
+						tp.PrintfLine("500 unsupported")
+					}
+				}
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return config.Provider{Name: "fake", Host: addr.IP.String(), Port: addr.Port, Username: "u", Password: "p"}
+}
+
+func TestValidateProvider(t *testing.T) {
+	logger.Init("DEBUG")
+	provider := fakeNNTPProvider(t)
+	s := &Server{config: &config.Config{}}
+
+	if res := s.ValidateProvider(provider); !res.OK || res.Error != "" || res.TestGroup != "" {
+		t.Errorf("without a test group: %+v", res)
+	}
+	if res := s.ValidateProvider(config.Provider{Port: 119}); res.OK || res.Error != "Host is required" {
+		t.Errorf("missing host: %+v", res)
+	}
+
+	s.config = &config.Config{ProviderTestGroup: "alt.binaries.test"}
+	if res := s.ValidateProvider(provider); !res.OK || res.TestGroup != "alt.binaries.test" || res.ArticleCount != 42 {
+		t.Errorf("with a served test group: %+v", res)
+	}
+	s.config = &config.Config{ProviderTestGroup: "alt.binaries.missing"}
+	if res := s.ValidateProvider(provider); res.OK || !strings.Contains(res.Error, "does not serve alt.binaries.missing") {
+		t.Errorf("with an unserved test group: %+v", res)
+	}
+}
+
+func TestValidateIndexer(t *testing.T) {
+	logger.Init("DEBUG")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "caps" || r.URL.Query().Get("apikey") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`<caps></caps>`))
+	}))
+	t.Cleanup(srv.Close)
+	s := &Server{config: &config.Config{}}
+
+	tests := []struct {
+		name    string
+		cfg     config.IndexerConfig
+		ok      bool
+		errPart string
+	}{
+		{"reachable", config.IndexerConfig{Name: "nz", URL: srv.URL, APIKey: "good"}, true, ""},
+		{"bad key", config.IndexerConfig{Name: "nz", URL: srv.URL, APIKey: "bad"}, false, "401"},
+		{"no URL", config.IndexerConfig{Name: "nz"}, false, "URL is required"},
+		{"easynews without login", config.IndexerConfig{Name: "en", Type: "easynews"}, false, "Username and password are required"},
+	}
+	for _, tt := range tests {
+		res := s.ValidateIndexer(tt.cfg)
+		if res.OK != tt.ok || !strings.Contains(res.Error, tt.errPart) {
+			t.Errorf("%s: %+v", tt.name, res)
+		}
+	}
+}
+
+func TestHandleValidateProvider(t *testing.T) {
+	logger.Init("DEBUG")
+	s := &Server{
+		config:        &config.Config{AdminUsername: "admin", AdminToken: "admin-token", LoadedPath: filepath.Join(t.TempDir(), "config.json")},
+		deviceManager: testDeviceManager(t),
+		clients:       make(map[*Client]bool),
+	}
+	h := s.Handler()
+	post := func(method, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/validate/provider", strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer admin-token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	body, _ := json.Marshal(fakeNNTPProvider(t))
+	w := post(http.MethodPost, string(body))
+	var res ComponentValidation
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil || w.Code != http.StatusOK || !res.OK {
+		t.Errorf("status %d, result %+v, err %v", w.Code, res, err)
+	}
+	if w := post(http.MethodPost, "{"); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: status %d; want 400", w.Code)
+	}
+	if w := post(http.MethodGet, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status %d; want 405", w.Code)
+	}
+}
+
+func TestValidateConfigProviderRoles(t *testing.T) {
+	logger.Init("DEBUG")
+	s := &Server{config: &config.Config{}}
+	withRoles := func(roles ...string) *config.Config {
+		cfg := &config.Config{}
+		for _, role := range roles {
+			p := fakeNNTPProvider(t)
+			p.Role = role
+			cfg.Providers = append(cfg.Providers, p)
+		}
+		return cfg
+	}
+	off := false
+	disabledDownload := withRoles(config.ProviderRoleValidate, config.ProviderRoleDownload)
+	disabledDownload.Providers[1].Enabled = &off
+
+	tests := []struct {
+		name  string
+		cfg   *config.Config
+		field string // expected error key, "" = none of the role errors
+	}{
+		{"split accounts", withRoles(config.ProviderRoleValidate, config.ProviderRoleDownload), ""},
+		{"default role", withRoles(""), ""},
+		{"unknown role", withRoles("block"), "providers.0.role"},
+		{"nothing validates", withRoles(config.ProviderRoleDownload), "providers"},
+		{"nothing downloads", withRoles(config.ProviderRoleValidate), "providers"},
+		{"only download provider disabled", disabledDownload, "providers"},
+	}
+	for _, tt := range tests {
+		errs := s.validateConfig(tt.cfg)
+		for _, field := range []string{"providers", "providers.0.role"} {
+			if _, got := errs[field]; got != (field == tt.field) {
+				t.Errorf("%s: errors %v; want %q", tt.name, errs, tt.field)
+			}
+		}
+	}
+}
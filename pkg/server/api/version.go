@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// VersionResponse is the body of GET /api/version.
+type VersionResponse struct {
+	Version   string          `json:"version"`
+	GoVersion string          `json:"go_version"`
+	BuildTime string          `json:"build_time,omitempty"` // RFC 3339; empty when unknown
+	Commit    string          `json:"commit,omitempty"`
+	Features  VersionFeatures `json:"features"`
+}
+
+// VersionFeatures summarizes what the running instance has enabled.
+type VersionFeatures struct {
+	Proxy     bool `json:"proxy"`
+	WebDAV    bool `json:"webdav"`
+	TLS       bool `json:"tls"`
+	Providers int  `json:"providers"` // enabled providers
+	Indexers  int  `json:"indexers"`
+}
+
+// SetBuildTime records the build time set via -ldflags (main.BuildTime). Without it,
+// /api/version falls back to the VCS time Go embeds in binaries built from a checkout.
+func (s *Server) SetBuildTime(buildTime string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buildTime = buildTime
+}
+
+// handleVersion returns the version and build information - public, no auth, so
+// monitors can compare it against the release feed.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+	resp := VersionResponse{Version: "dev", GoVersion: runtime.Version()}
+	if s.strmServer != nil {
+		resp.Version = s.strmServer.Version()
+	}
+
+	var vcsTime string
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				resp.Commit = setting.Value
+			case "vcs.time":
+				vcsTime = setting.Value
+			}
+		}
+	}
+
+	s.mu.RLock()
+	resp.BuildTime = s.buildTime
+	cfg := s.config
+	s.mu.RUnlock()
+	if resp.BuildTime == "" {
+		resp.BuildTime = vcsTime
+	}
+	if cfg != nil {
+		resp.Features = VersionFeatures{
+			Proxy:    cfg.ProxyEnabled,
+			WebDAV:   cfg.WebDAVEnabled,
+			TLS:      cfg.TLSEnabled(),
+			Indexers: len(cfg.Indexers),
+		}
+		for _, p := range cfg.Providers {
+			if p.Enabled == nil || *p.Enabled {
+				resp.Features.Providers++
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
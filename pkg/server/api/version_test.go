@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+func TestHandleVersion(t *testing.T) {
+	logger.Init("DEBUG")
+	off := false
+	s := &Server{
+		config: &config.Config{
+			AdminToken:   "admin-token",
+			ProxyEnabled: true,
+			Providers:    []config.Provider{{Name: "a"}, {Name: "b", Enabled: &off}, {Name: "c"}},
+			Indexers:     []config.IndexerConfig{{Name: "nz"}},
+		},
+		deviceManager: testDeviceManager(t),
+		clients:       make(map[*Client]bool),
+	}
+	s.SetBuildTime("2026-10-01T12:00:00Z")
+	h := s.Handler()
+
+	// Public: no credentials needed
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	var resp VersionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status %d, err %v: %s", w.Code, err, w.Body.String())
+	}
+	if resp.Version != "dev" || resp.GoVersion != runtime.Version() || resp.BuildTime != "2026-10-01T12:00:00Z" {
+		t.Errorf("version = %+v", resp)
+	}
+	want := VersionFeatures{Proxy: true, Providers: 2, Indexers: 1}
+	if resp.Features != want {
+		t.Errorf("features = %+v; want %+v", resp.Features, want)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/version", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d; want 405", w.Code)
+	}
+}
@@ -15,24 +15,19 @@ import (
 	"streamnzb/pkg/core/config"
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/core/paths"
+	"streamnzb/pkg/indexer/easynews"
 	"streamnzb/pkg/indexer/newznab"
 	"streamnzb/pkg/initialization"
 	"streamnzb/pkg/search/triage"
+	"streamnzb/pkg/server/stremio"
 	"streamnzb/pkg/services/availnzb"
 	"streamnzb/pkg/services/metadata/tmdb"
 	"streamnzb/pkg/services/metadata/tvdb"
-	"streamnzb/pkg/usenet/nntp"
 	"streamnzb/pkg/usenet/validation"
 
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allowing all origins for development
-	},
-}
-
 type WSMessage struct {
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload,omitempty"`
@@ -45,9 +40,10 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		// Try cookie fallback
 		cookie, err := r.Cookie("auth_session")
 		if err == nil && cookie != nil {
-			device, err = s.deviceManager.AuthenticateToken(cookie.Value, s.config.GetAdminUsername(), s.config.AdminToken)
+			cfg := s.currentConfig()
+			device, err = s.deviceManager.AuthenticateToken(cookie.Value, cfg.GetAdminUsername(), cfg.AdminToken)
 			if err != nil {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				writeAPIError(w, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
 				return
 			}
 			ok = true
@@ -55,10 +51,11 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		writeAPIError(w, http.StatusUnauthorized, errCodeUnauthorized, "Unauthorized")
 		return
 	}
 
+	upgrader := websocket.Upgrader{CheckOrigin: s.originAllowed}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		logger.Error("WS upgrade error", "err", err)
@@ -110,6 +107,7 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			"authenticated":        true,
 			"username":             client.device.Username,
 			"must_change_password": mustChangePassword,
+			"role":                 client.device.EffectiveRole(),
 		}
 		if s.strmServer != nil {
 			authInfo["version"] = s.strmServer.Version()
@@ -162,12 +160,24 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 				s.handleDeleteDeviceWS(client, msg.Payload)
 			case "regenerate_token":
 				s.handleRegenerateTokenWS(client, msg.Payload)
+			case "rotate_admin_token":
+				s.handleRotateAdminTokenWS(client)
 			case "update_password":
 				s.handleUpdatePasswordWS(client, msg.Payload)
 			case "close_session":
-				s.handleCloseSessionWS(msg.Payload)
+				s.handleCloseSessionWS(client, msg.Payload)
 			case "restart":
-				s.handleRestartWS(conn)
+				s.handleRestartWS(client)
+			case "validate_provider":
+				s.handleValidateProviderWS(client, msg.Payload)
+			case "validate_indexer":
+				s.handleValidateIndexerWS(client, msg.Payload)
+			case "flush_caches":
+				s.handleFlushCachesWS(client)
+			case "set_log_levels":
+				s.handleSetLogLevelsWS(client, msg.Payload)
+			case "get_blacklist", "blacklist_release", "unblacklist_release":
+				s.handleBlacklistWS(client, msg.Type, msg.Payload)
 			}
 		}
 	}()
@@ -215,7 +225,7 @@ type configPayload struct {
 func (s *Server) sendConfig(client *Client) {
 	// Admin always gets global config, devices get merged config. Never send admin hash/token to client.
 	var cfg config.Config
-	if client.device != nil && client.device.Username == s.config.GetAdminUsername() {
+	if client.device.IsAdmin() {
 		cfg = s.config.RedactForAPI()
 	} else if client.device != nil {
 		cfg = *s.config
@@ -231,7 +241,7 @@ func (s *Server) sendConfig(client *Client) {
 	}
 
 	var payload []byte
-	if client.device != nil && client.device.Username == s.config.GetAdminUsername() {
+	if client.device.IsAdmin() {
 		envKeys := config.GetEnvOverrideKeys()
 		pl := configPayload{Config: cfg, EnvOverrides: envKeys}
 		payload, _ = json.Marshal(pl)
@@ -256,8 +266,8 @@ func (s *Server) handleSaveConfigWS(conn *websocket.Conn, client *Client, payloa
 		return
 	}
 
-	// Admin saves to global config, regular devices don't save via this endpoint
-	if client.device != nil && client.device.Username == s.config.GetAdminUsername() {
+	// Admin saves to global config, managers and regular devices don't save via this endpoint
+	if client.device.IsAdmin() {
 		// Validate settings before saving
 		fieldErrors := s.validateConfig(&newCfg)
 		if len(fieldErrors) > 0 {
@@ -270,110 +280,136 @@ func (s *Server) handleSaveConfigWS(conn *websocket.Conn, client *Client, payloa
 			return
 		}
 
-		// Preserve effective values for any key that has an env override, so we don't
-		// overwrite them with form data (they would be overridden on next restart anyway).
-		// Note: ldflags variables are never part of config - they're build-time constants
-		// used directly in main.go and cannot be overridden.
-		s.mu.RLock()
-		currentCfg := s.config
-		currentLoadedPath := s.config.LoadedPath
-		s.mu.RUnlock()
-		config.CopyEnvOverridesFrom(currentCfg, &newCfg)
+		if err := s.applyConfig(&newCfg, true); err != nil {
+			trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage([]byte(fmt.Sprintf(`{"status":"error","message":"Failed to save config: %s"}`, err.Error())))})
+			return
+		}
+
+		// Push updated config back to client
+		s.sendConfig(client)
+		trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage(`{"status":"success","message":"Configuration saved and reloaded."}`)})
+		return
+	}
+
+	// Regular devices cannot save via this endpoint
+	trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage(`{"status":"error","message":"Only admin can save global configuration"}`)})
+}
+
+// applyConfig saves a validated config as the global config and reloads the affected
+// components in the background (granular when App is available). Env-overridden keys keep
+// their effective values; keepAdminCredentials keeps the current admin password and token.
+func (s *Server) applyConfig(newCfg *config.Config, keepAdminCredentials bool) error {
+	// Preserve effective values for any key that has an env override, so we don't
+	// overwrite them with form data (they would be overridden on next restart anyway).
+	// Note: ldflags variables are never part of config - they're build-time constants
+	// used directly in main.go and cannot be overridden.
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	currentCfg := s.currentConfig()
+	currentLoadedPath := currentCfg.LoadedPath
+	config.CopyEnvOverridesFrom(currentCfg, newCfg)
+	if keepAdminCredentials {
 		// Admin credentials and token are never sent from the UI; preserve from current config.
 		newCfg.AdminPasswordHash = currentCfg.AdminPasswordHash
 		newCfg.AdminToken = currentCfg.AdminToken
 		newCfg.AdminMustChangePassword = currentCfg.AdminMustChangePassword
+	}
 
-		// Apply provider defaults migration (only for old configs with priority=0)
-		// This ensures old configs get migrated when saving from UI
-		newCfg.ApplyProviderDefaults()
+	// Apply provider defaults migration (only for old configs with priority=0)
+	// This ensures old configs get migrated when saving from UI
+	newCfg.ApplyProviderDefaults()
 
-		if currentLoadedPath == "" {
-			currentLoadedPath = filepath.Join(paths.GetDataDir(), "config.json")
-		}
-		newCfg.LoadedPath = currentLoadedPath
+	if currentLoadedPath == "" {
+		currentLoadedPath = filepath.Join(paths.GetDataDir(), "config.json")
+	}
+	newCfg.LoadedPath = currentLoadedPath
 
-		// Update global config
-		s.mu.Lock()
-		s.config = &newCfg
-		s.mu.Unlock()
+	// Save before swapping, so a failed save leaves the running config unchanged
+	if err := newCfg.Save(); err != nil {
+		return err
+	}
 
-		if err := s.config.Save(); err != nil {
-			trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage([]byte(fmt.Sprintf(`{"status":"error","message":"Failed to save config: %s"}`, err.Error())))})
-			return
-		}
+	// Update global config
+	s.mu.Lock()
+	s.config = newCfg
+	s.mu.Unlock()
 
-		// Reload components - granular when App is available (config-only skips NNTP/proxy restart)
-		go func() {
-			if s.app != nil {
-				comp, fullReload, err := s.app.Reload(&newCfg)
-				if err != nil {
-					logger.Error("Reload: App.Reload failed", "err", err)
-					return
-				}
-				s.ReloadFromComponents(comp, fullReload)
-				logger.Info("Reload: configuration reloaded successfully", "full", fullReload)
-				return
-			}
-			// Fallback when App not set (legacy)
-			base, err := initialization.BuildComponents(&newCfg)
+	// Reload components - granular when App is available (config-only skips NNTP/proxy restart)
+	go func() {
+		if s.app != nil {
+			comp, fullReload, err := s.app.Reload(newCfg)
 			if err != nil {
-				logger.Error("Reload: BuildComponents failed", "err", err)
+				logger.Error("Reload: App.Reload failed", "err", err)
 				return
 			}
-			cacheTTL := time.Duration(newCfg.CacheTTLSeconds) * time.Second
-			validator := validation.NewChecker(base.ProviderPools, base.ProviderOrder, cacheTTL, newCfg.ValidationSampleSize, 6)
-			triageService := triage.NewService(&base.Config.Filters, base.Config.Sorting)
-			s.mu.RLock()
-			availNZBURL := s.availNZBURL
-			availNZBAPIKey := s.availNZBAPIKey
-			tmdbAPIKey := s.tmdbAPIKey
-			tvdbAPIKey := s.tvdbAPIKey
-			s.mu.RUnlock()
-			availClient := availnzb.NewClient(availNZBURL, availNZBAPIKey)
-			tmdbClient := tmdb.NewClient(tmdbAPIKey)
-			dataDir := filepath.Dir(base.Config.LoadedPath)
-			if dataDir == "" {
-				dataDir, _ = os.Getwd()
-			}
-			tvdbClient := tvdb.NewClient(tvdbAPIKey, dataDir)
-			comp := &app.Components{
-				Config:               base.Config,
-				Indexer:              base.Indexer,
-				ProviderPools:        base.ProviderPools,
-				ProviderOrder:        base.ProviderOrder,
-				StreamingPools:       base.StreamingPools,
-				AvailNZBIndexerHosts: base.AvailNZBIndexerHosts,
-				Validator:            validator,
-				Triage:               triageService,
-				AvailClient:          availClient,
-				TMDBClient:           tmdbClient,
-				TVDBClient:           tvdbClient,
-			}
-			s.ReloadFromComponents(comp, true)
-			logger.Info("Reload: configuration reloaded successfully")
-		}()
-
-		// Push updated config back to client
-		s.sendConfig(client)
-		trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage(`{"status":"success","message":"Configuration saved and reloaded."}`)})
-		return
-	}
+			s.ReloadFromComponents(comp, fullReload)
+			logger.Info("Reload: configuration reloaded successfully", "full", fullReload)
+			return
+		}
+		// Fallback when App not set (legacy)
+		base, err := initialization.BuildComponents(newCfg)
+		if err != nil {
+			logger.Error("Reload: BuildComponents failed", "err", err)
+			return
+		}
+		cacheTTL := time.Duration(newCfg.CacheTTLSeconds) * time.Second
+		validator := validation.NewChecker(base.ValidationPools, base.ProviderOrder, cacheTTL, newCfg.ValidationSampleSize, 6, newCfg.MinAvailabilityRatio)
+		validator.SetTailDepth(newCfg.ValidateTailDepth)
+		validator.SetSegmentTimeout(time.Duration(newCfg.ValidationSegmentTimeoutMs) * time.Millisecond)
+		validator.SetVerifyCRC(newCfg.ValidationVerifyCRC)
+		validator.SetProviderWeights(base.ProviderWeights)
+		triageService := triage.NewService(&base.Config.Filters, base.Config.Sorting)
+		triageService.AllowUnknownSize = base.Config.ZeroSizePolicy != "skip"
+		triageService.SkipObfuscated = base.Config.ObfuscatedPolicy == "skip"
+		s.mu.RLock()
+		availNZBURL := s.availNZBURL
+		availNZBAPIKey := s.availNZBAPIKey
+		tmdbAPIKey := s.tmdbAPIKey
+		tvdbAPIKey := s.tvdbAPIKey
+		s.mu.RUnlock()
+		availClient := availnzb.NewClient(availNZBURL, availNZBAPIKey)
+		availClient.SetTimeout(time.Duration(newCfg.AvailNZBTimeoutMs) * time.Millisecond)
+		tmdbClient := tmdb.NewClient(tmdbAPIKey)
+		dataDir := filepath.Dir(base.Config.LoadedPath)
+		if dataDir == "" {
+			dataDir, _ = os.Getwd()
+		}
+		tvdbClient := tvdb.NewClient(tvdbAPIKey, dataDir)
+		comp := &app.Components{
+			Config:               base.Config,
+			Indexer:              base.Indexer,
+			ProviderPools:        base.ProviderPools,
+			ProviderOrder:        base.ProviderOrder,
+			StreamingPools:       base.StreamingPools,
+			AvailNZBIndexerHosts: base.AvailNZBIndexerHosts,
+			Validator:            validator,
+			Triage:               triageService,
+			AvailClient:          availClient,
+			TMDBClient:           tmdbClient,
+			TVDBClient:           tvdbClient,
+		}
+		s.ReloadFromComponents(comp, true)
+		logger.Info("Reload: configuration reloaded successfully")
+	}()
 
-	// Regular devices cannot save via this endpoint
-	trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage(`{"status":"error","message":"Only admin can save global configuration"}`)})
+	return nil
 }
 
 func (s *Server) handleSaveUserConfigsWS(conn *websocket.Conn, client *Client, payload json.RawMessage) {
-	// Only admin can save device configs
-	if client.device == nil || client.device.Username != s.config.GetAdminUsername() {
-		trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage(`{"status":"error","message":"Only admin can save device configurations"}`)})
+	// Only admins and managers can save device configs
+	if !client.device.CanManageDevices() {
+		trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage(`{"status":"error","message":"Only admins and managers can save device configurations"}`)})
 		return
 	}
 
 	var deviceConfigs map[string]struct {
-		Filters config.FilterConfig `json:"filters"`
-		Sorting config.SortConfig   `json:"sorting"`
+		Filters                config.FilterConfig `json:"filters"`
+		Sorting                config.SortConfig   `json:"sorting"`
+		MaxConcurrentPlaybacks *int                `json:"max_concurrent_playbacks,omitempty"`
+		MonthlyQuotaGB         *float64            `json:"monthly_quota_gb,omitempty"`
+		// Absent = unchanged, null = use global setting, true/false = override
+		AvailNZBReport json.RawMessage `json:"availnzb_report,omitempty"`
+		Role           string          `json:"role,omitempty"`
 	}
 	if err := json.Unmarshal(payload, &deviceConfigs); err != nil {
 		trySendWS(client, WSMessage{Type: "save_status", Payload: json.RawMessage(`{"status":"error","message":"Invalid device config data"}`)})
@@ -386,6 +422,15 @@ func (s *Server) handleSaveUserConfigsWS(conn *websocket.Conn, client *Client, p
 		if username == s.config.GetAdminUsername() {
 			continue // Skip admin
 		}
+		if !s.canManageDevice(client, username) {
+			errors = append(errors, fmt.Sprintf("Not allowed to modify %s", username))
+			continue
+		}
+
+		if err := validateTitlePatterns(deviceConfig.Filters); err != nil {
+			errors = append(errors, fmt.Sprintf("Invalid filters for %s: %v", username, err))
+			continue
+		}
 
 		if err := s.deviceManager.UpdateDeviceFilters(username, deviceConfig.Filters); err != nil {
 			errors = append(errors, fmt.Sprintf("Failed to update filters for %s: %v", username, err))
@@ -396,6 +441,39 @@ func (s *Server) handleSaveUserConfigsWS(conn *websocket.Conn, client *Client, p
 			errors = append(errors, fmt.Sprintf("Failed to update sorting for %s: %v", username, err))
 			continue
 		}
+
+		if deviceConfig.MaxConcurrentPlaybacks != nil {
+			if err := s.deviceManager.UpdateDevicePlaybackLimit(username, *deviceConfig.MaxConcurrentPlaybacks); err != nil {
+				errors = append(errors, fmt.Sprintf("Failed to update playback limit for %s: %v", username, err))
+				continue
+			}
+		}
+
+		if deviceConfig.MonthlyQuotaGB != nil {
+			if err := s.deviceManager.UpdateDeviceQuota(username, *deviceConfig.MonthlyQuotaGB); err != nil {
+				errors = append(errors, fmt.Sprintf("Failed to update monthly quota for %s: %v", username, err))
+				continue
+			}
+		}
+
+		if len(deviceConfig.AvailNZBReport) > 0 {
+			var report *bool
+			if err := json.Unmarshal(deviceConfig.AvailNZBReport, &report); err != nil {
+				errors = append(errors, fmt.Sprintf("Invalid AvailNZB reporting setting for %s", username))
+				continue
+			}
+			if err := s.deviceManager.UpdateDeviceAvailReport(username, report); err != nil {
+				errors = append(errors, fmt.Sprintf("Failed to update AvailNZB reporting for %s: %v", username, err))
+				continue
+			}
+		}
+
+		if deviceConfig.Role != "" {
+			if err := s.setDeviceRole(client, username, deviceConfig.Role); err != nil {
+				errors = append(errors, fmt.Sprintf("Failed to update role for %s: %v", username, err))
+				continue
+			}
+		}
 	}
 
 	if len(errors) > 0 {
@@ -412,9 +490,9 @@ func (s *Server) handleSaveUserConfigsWS(conn *websocket.Conn, client *Client, p
 }
 
 func (s *Server) handleGetDevicesWS(client *Client) {
-	// Only admin can get devices list
-	if client.device == nil || client.device.Username != s.config.GetAdminUsername() {
-		trySendWS(client, WSMessage{Type: "users_response", Payload: json.RawMessage(`{"error":"Only admin can access devices list"}`)})
+	// Only admins and managers can get devices list
+	if !client.device.CanManageDevices() {
+		trySendWS(client, WSMessage{Type: "users_response", Payload: json.RawMessage(`{"error":"Only admins and managers can access devices list"}`)})
 		return
 	}
 
@@ -422,13 +500,8 @@ func (s *Server) handleGetDevicesWS(client *Client) {
 
 	// Format devices for response (exclude sensitive data)
 	deviceList := make([]map[string]interface{}, 0, len(devices))
-	for _, device := range devices {
-		deviceList = append(deviceList, map[string]interface{}{
-			"username": device.Username,
-			"token":    device.Token,
-			"filters":  device.Filters,
-			"sorting":  device.Sorting,
-		})
+	for i := range devices {
+		deviceList = append(deviceList, s.deviceInfo(&devices[i]))
 	}
 
 	deviceListPayload, _ := json.Marshal(deviceList)
@@ -436,9 +509,9 @@ func (s *Server) handleGetDevicesWS(client *Client) {
 }
 
 func (s *Server) handleGetDeviceWS(client *Client, payload json.RawMessage) {
-	// Only admin can get user details
-	if client.device == nil || client.device.Username != s.config.GetAdminUsername() {
-		trySendWS(client, WSMessage{Type: "user_response", Payload: json.RawMessage(`{"error":"Only admin can access user details"}`)})
+	// Only admins and managers can get user details
+	if !client.device.CanManageDevices() {
+		trySendWS(client, WSMessage{Type: "user_response", Payload: json.RawMessage(`{"error":"Only admins and managers can access user details"}`)})
 		return
 	}
 
@@ -457,34 +530,56 @@ func (s *Server) handleGetDeviceWS(client *Client, payload json.RawMessage) {
 		return
 	}
 
-	response := map[string]interface{}{
-		"username": device.Username,
-		"token":    device.Token,
-		"filters":  device.Filters,
-		"sorting":  device.Sorting,
-	}
-
-	respPayload, _ := json.Marshal(response)
+	respPayload, _ := json.Marshal(s.deviceInfo(device))
 	trySendWS(client, WSMessage{Type: "user_response", Payload: respPayload})
 }
 
+// deviceInfo formats a device for users_response / user_response (no sensitive data),
+// including its streaming usage for the current month.
+func (s *Server) deviceInfo(device *auth.Device) map[string]interface{} {
+	return map[string]interface{}{
+		"username":             device.Username,
+		"token":                device.Token,
+		"role":                 device.EffectiveRole(),
+		"filters":              device.Filters,
+		"sorting":              device.Sorting,
+		"monthly_quota_gb":     device.MonthlyQuotaGB,
+		"monthly_bytes_served": s.deviceManager.MonthlyBytesServed(device.Username),
+	}
+}
+
 func (s *Server) handleCreateDeviceWS(client *Client, payload json.RawMessage) {
-	// Only admin can create users
-	if client.device == nil || client.device.Username != s.config.GetAdminUsername() {
-		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admin can create users"}`)})
+	// Only admins and managers can create users
+	if !client.device.CanManageDevices() {
+		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admins and managers can create users"}`)})
 		return
 	}
 
 	var req struct {
 		Username string `json:"username"`
+		Role     string `json:"role,omitempty"`     // default user
+		Password string `json:"password,omitempty"` // dashboard login for admin/manager devices
 	}
 	if err := json.Unmarshal(payload, &req); err != nil {
 		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Invalid request"}`)})
 		return
 	}
+	role := auth.RoleUser
+	if req.Role != "" {
+		var err error
+		if role, err = s.assignableRole(client, req.Role); err != nil {
+			errorPayload, _ := json.Marshal(map[string]string{"error": err.Error()})
+			trySendWS(client, WSMessage{Type: "user_action_response", Payload: errorPayload})
+			return
+		}
+	}
 
-	// Create user without password (empty string)
-	device, err := s.deviceManager.CreateDevice(req.Username, "", s.config.GetAdminUsername())
+	device, err := s.deviceManager.CreateDevice(req.Username, req.Password, s.config.GetAdminUsername())
+	if err == nil && role != auth.RoleUser {
+		if err = s.deviceManager.UpdateDeviceRole(device.Username, role); err != nil {
+			s.deviceManager.DeleteDevice(device.Username)
+		}
+	}
 	if err != nil {
 		errorPayload, _ := json.Marshal(map[string]string{"error": err.Error()})
 		trySendWS(client, WSMessage{Type: "user_action_response", Payload: errorPayload})
@@ -496,6 +591,7 @@ func (s *Server) handleCreateDeviceWS(client *Client, payload json.RawMessage) {
 		"user": map[string]interface{}{
 			"username": device.Username,
 			"token":    device.Token,
+			"role":     role,
 		},
 	}
 
@@ -507,9 +603,9 @@ func (s *Server) handleCreateDeviceWS(client *Client, payload json.RawMessage) {
 }
 
 func (s *Server) handleDeleteDeviceWS(client *Client, payload json.RawMessage) {
-	// Only admin can delete users
-	if client.device == nil || client.device.Username != s.config.GetAdminUsername() {
-		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admin can delete users"}`)})
+	// Only admins and managers can delete users; managers cannot touch admin devices
+	if !client.device.CanManageDevices() {
+		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admins and managers can delete users"}`)})
 		return
 	}
 
@@ -520,6 +616,10 @@ func (s *Server) handleDeleteDeviceWS(client *Client, payload json.RawMessage) {
 		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Invalid request"}`)})
 		return
 	}
+	if !s.canManageDevice(client, req.Username) {
+		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admins can modify admin devices"}`)})
+		return
+	}
 
 	if err := s.deviceManager.DeleteDevice(req.Username); err != nil {
 		errorPayload, _ := json.Marshal(map[string]string{"error": err.Error()})
@@ -540,9 +640,9 @@ func (s *Server) handleDeleteDeviceWS(client *Client, payload json.RawMessage) {
 }
 
 func (s *Server) handleRegenerateTokenWS(client *Client, payload json.RawMessage) {
-	// Only admin can regenerate tokens
-	if client.device == nil || client.device.Username != s.config.GetAdminUsername() {
-		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admin can regenerate tokens"}`)})
+	// Only admins and managers can regenerate tokens; managers cannot touch admin devices
+	if !client.device.CanManageDevices() {
+		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admins and managers can regenerate tokens"}`)})
 		return
 	}
 
@@ -553,6 +653,10 @@ func (s *Server) handleRegenerateTokenWS(client *Client, payload json.RawMessage
 		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Invalid request"}`)})
 		return
 	}
+	if !s.canManageDevice(client, req.Username) {
+		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admins can modify admin devices"}`)})
+		return
+	}
 
 	token, err := s.deviceManager.RegenerateToken(req.Username)
 	if err != nil {
@@ -574,12 +678,6 @@ func (s *Server) handleRegenerateTokenWS(client *Client, payload json.RawMessage
 }
 
 func (s *Server) handleUpdatePasswordWS(client *Client, payload json.RawMessage) {
-	// Only admin can update password
-	if client.device == nil || client.device.Username != s.config.GetAdminUsername() {
-		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admin can update password"}`)})
-		return
-	}
-
 	var req struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
@@ -589,21 +687,51 @@ func (s *Server) handleUpdatePasswordWS(client *Client, payload json.RawMessage)
 		return
 	}
 
-	// Note: We've already verified the client is authenticated as admin (line 592).
+	// Dashboard password of an admin/manager device: its owner or an admin may change it
+	if client.device != nil && client.device.Username != s.config.GetAdminUsername() && req.Username != s.config.GetAdminUsername() {
+		if _, err := s.deviceManager.GetDevice(req.Username, s.config.GetAdminUsername()); err == nil {
+			if !client.device.IsAdmin() && client.device.Username != req.Username {
+				trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admin can update other devices' passwords"}`)})
+				return
+			}
+			if err := s.deviceManager.UpdateDevicePassword(req.Username, req.Password); err != nil {
+				errorPayload, _ := json.Marshal(map[string]string{"error": err.Error()})
+				trySendWS(client, WSMessage{Type: "user_action_response", Payload: errorPayload})
+				return
+			}
+			trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"success":true,"message":"Password updated successfully"}`)})
+			return
+		}
+	}
+
+	// Only admin can update the admin password
+	if !client.device.IsAdmin() {
+		trySendWS(client, WSMessage{Type: "user_action_response", Payload: json.RawMessage(`{"error":"Only admin can update password"}`)})
+		return
+	}
+
+	// Note: We've already verified the client is authenticated as admin (above).
 	// The username in the request may be the new username if both username and password are being changed.
 	// Since this endpoint only updates the admin password, we allow the change regardless of the username value.
 
-	// Update admin password in config (not state)
-	newHash := auth.HashPassword(req.Password)
-	s.mu.Lock()
-	s.config.AdminPasswordHash = newHash
-	s.config.AdminMustChangePassword = false
-	s.mu.Unlock()
-	if err := s.config.Save(); err != nil {
+	// Update admin password in config (not state): save a copy, then swap it in
+	s.configMu.Lock()
+	newCfg := *s.currentConfig()
+	newCfg.AdminPasswordHash = auth.HashPassword(req.Password)
+	newCfg.AdminMustChangePassword = false
+	if err := newCfg.Save(); err != nil {
+		s.configMu.Unlock()
 		errorPayload, _ := json.Marshal(map[string]string{"error": err.Error()})
 		trySendWS(client, WSMessage{Type: "user_action_response", Payload: errorPayload})
 		return
 	}
+	s.mu.Lock()
+	s.config = &newCfg
+	s.mu.Unlock()
+	if s.strmServer != nil {
+		s.strmServer.SetConfig(&newCfg)
+	}
+	s.configMu.Unlock()
 
 	response := map[string]interface{}{
 		"success": true,
@@ -619,24 +747,56 @@ func (s *Server) broadcastUsersList() {
 
 	// Format devices for response
 	deviceList := make([]map[string]interface{}, 0, len(devices))
-	for _, device := range devices {
-		deviceList = append(deviceList, map[string]interface{}{
-			"username": device.Username,
-			"token":    device.Token,
-			"filters":  device.Filters,
-			"sorting":  device.Sorting,
-		})
+	for i := range devices {
+		deviceList = append(deviceList, s.deviceInfo(&devices[i]))
 	}
 
 	payload, _ := json.Marshal(deviceList)
+	s.broadcastToAdmins(WSMessage{Type: "users_response", Payload: payload})
+}
+
+// canManageDevice reports whether client may modify the named device:
+// admins may modify any device, managers only non-admin devices.
+func (s *Server) canManageDevice(client *Client, username string) bool {
+	if client.device.IsAdmin() {
+		return true
+	}
+	if !client.device.CanManageDevices() {
+		return false
+	}
+	target, err := s.deviceManager.GetDevice(username, s.config.GetAdminUsername())
+	return err == nil && !target.IsAdmin()
+}
+
+// assignableRole parses name and checks that client may grant it (only admins grant admin).
+func (s *Server) assignableRole(client *Client, name string) (auth.Role, error) {
+	role, err := auth.ParseRole(name)
+	if err != nil {
+		return "", err
+	}
+	if role == auth.RoleAdmin && !client.device.IsAdmin() {
+		return "", fmt.Errorf("only admins can grant the admin role")
+	}
+	return role, nil
+}
+
+// setDeviceRole changes a device's role if client may grant it.
+func (s *Server) setDeviceRole(client *Client, username, name string) error {
+	role, err := s.assignableRole(client, name)
+	if err != nil {
+		return err
+	}
+	return s.deviceManager.UpdateDeviceRole(username, role)
+}
 
-	// Send to all admin clients
+// broadcastToAdmins sends msg to every connected client that can manage devices
+func (s *Server) broadcastToAdmins(msg WSMessage) {
 	s.clientsMu.Lock()
 	defer s.clientsMu.Unlock()
 	for client := range s.clients {
-		if client.device != nil && client.device.Username == s.config.GetAdminUsername() {
+		if client.device.CanManageDevices() {
 			select {
-			case client.send <- WSMessage{Type: "users_response", Payload: payload}:
+			case client.send <- msg:
 			default:
 				// Channel full, skip
 			}
@@ -645,25 +805,87 @@ func (s *Server) broadcastUsersList() {
 }
 
 // validateConfig checks connectivity for all components and returns a map of field errors
+// validateTitlePatterns checks the blocked and required title regular expressions.
+func validateTitlePatterns(filters config.FilterConfig) error {
+	if _, err := triage.CompileTitlePatterns(filters.BlockedTitlePatterns); err != nil {
+		return fmt.Errorf("blocked title patterns: %w", err)
+	}
+	if _, err := triage.CompileTitlePatterns(filters.RequiredTitlePatterns); err != nil {
+		return fmt.Errorf("required title patterns: %w", err)
+	}
+	return nil
+}
+
 func (s *Server) validateConfig(cfg *config.Config) map[string]string {
 	errors := make(map[string]string)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
+	if err := stremio.ValidateStreamTitleTemplate(cfg.StreamTitleTemplate); err != nil {
+		errors["stream_title_template"] = err.Error()
+	}
+	for field, u := range map[string]string{"addon_logo_url": cfg.AddonLogoURL, "custom_error_video_url": cfg.CustomErrorVideoURL} {
+		if err := stremio.ValidateBrandingURL(u); err != nil {
+			errors[field] = err.Error()
+		}
+	}
+
+	if !config.ValidZeroSizePolicy(cfg.ZeroSizePolicy) {
+		errors["zero_size_policy"] = "must be defer, resolve or skip"
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		errors["tls_cert_file"] = "set both the certificate and the key file"
+	}
+	for field, path := range map[string]string{"tls_cert_file": cfg.TLSCertFile, "tls_key_file": cfg.TLSKeyFile} {
+		if path != "" {
+			if _, err := os.Stat(path); err != nil {
+				errors[field] = err.Error()
+			}
+		}
+	}
+
+	for name, level := range cfg.LogLevels {
+		if _, err := logger.ParseLevel(level); err != nil {
+			errors["log_levels"] = fmt.Sprintf("%s: %v", name, err)
+		}
+	}
+
+	for field, patterns := range map[string][]string{
+		"filters.blocked_title_patterns":  cfg.Filters.BlockedTitlePatterns,
+		"filters.required_title_patterns": cfg.Filters.RequiredTitlePatterns,
+	} {
+		if _, err := triage.CompileTitlePatterns(patterns); err != nil {
+			errors[field] = err.Error()
+		}
+	}
+
 	// 1. Validate NNTP Providers
+	var enabled, canValidate, canDownload int
+	for i, p := range cfg.Providers {
+		if !config.ValidProviderRole(p.Role) {
+			errors[fmt.Sprintf("providers.%d.role", i)] = "role must be all, validate or download"
+		}
+		if p.Enabled == nil || *p.Enabled {
+			enabled++
+			if p.UsedForValidation() {
+				canValidate++
+			}
+			if p.UsedForDownload() {
+				canDownload++
+			}
+		}
+	}
+	if enabled > 0 && canValidate == 0 {
+		errors["providers"] = "at least one enabled provider needs the all or validate role"
+	} else if enabled > 0 && canDownload == 0 {
+		errors["providers"] = "at least one enabled provider needs the all or download role"
+	}
 	for i, p := range cfg.Providers {
 		wg.Add(1)
 		go func(idx int, provider config.Provider) {
 			defer wg.Done()
-			// Basic format check
-			if provider.Host == "" {
-				mu.Lock()
-				errors[fmt.Sprintf("providers.%d.host", idx)] = "Host is required"
-				mu.Unlock()
-				return
-			}
-			pool := nntp.NewClientPool(provider.Host, provider.Port, provider.UseSSL, provider.Username, provider.Password, 1)
-			if err := pool.Validate(); err != nil {
+			if _, err := checkProvider(provider, cfg.ProviderTestGroup); err != nil {
 				mu.Lock()
 				errors[fmt.Sprintf("providers.%d.host", idx)] = err.Error()
 				mu.Unlock()
@@ -676,15 +898,19 @@ func (s *Server) validateConfig(cfg *config.Config) map[string]string {
 		wg.Add(1)
 		go func(index int, indexerCfg config.IndexerConfig) {
 			defer wg.Done()
-			if indexerCfg.URL == "" {
+			if !easynews.ValidQueryStrategy(indexerCfg.QueryStrategy) {
+				mu.Lock()
+				errors[fmt.Sprintf("indexers.%d.query_strategy", index)] = "Must be imdb-number, title-year or title-only"
+				mu.Unlock()
+				return
+			}
+			if err := newznab.ValidateHeaders(indexerCfg.Headers); err != nil {
 				mu.Lock()
-				errors[fmt.Sprintf("indexers.%d.url", index)] = "URL is required"
+				errors[fmt.Sprintf("indexers.%d.headers", index)] = err.Error()
 				mu.Unlock()
 				return
 			}
-			// Use our newznab client to ping
-			client := newznab.NewClient(indexerCfg, nil)
-			if err := client.Ping(); err != nil {
+			if err := checkIndexer(indexerCfg); err != nil {
 				mu.Lock()
 				errors[fmt.Sprintf("indexers.%d.url", index)] = err.Error()
 				mu.Unlock()
@@ -696,7 +922,11 @@ func (s *Server) validateConfig(cfg *config.Config) map[string]string {
 	return errors
 }
 
-func (s *Server) handleCloseSessionWS(payload json.RawMessage) {
+func (s *Server) handleCloseSessionWS(client *Client, payload json.RawMessage) {
+	if !client.device.IsAdmin() {
+		trySendWS(client, WSMessage{Type: "close_session_response", Payload: json.RawMessage(`{"error":"Only admin can close sessions"}`)})
+		return
+	}
 	var req struct {
 		ID string `json:"id"`
 	}
@@ -707,7 +937,11 @@ func (s *Server) handleCloseSessionWS(payload json.RawMessage) {
 	s.sessionMgr.DeleteSession(req.ID)
 }
 
-func (s *Server) handleRestartWS(conn *websocket.Conn) {
+func (s *Server) handleRestartWS(client *Client) {
+	if !client.device.IsAdmin() {
+		trySendWS(client, WSMessage{Type: "restart_response", Payload: json.RawMessage(`{"error":"Only admin can restart the server"}`)})
+		return
+	}
 	go func() {
 		time.Sleep(500 * time.Millisecond)
 		exe, _ := os.Executable()
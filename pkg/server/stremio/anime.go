@@ -0,0 +1,31 @@
+package stremio
+
+import (
+	"streamnzb/pkg/core/logger"
+)
+
+// absoluteEpisode returns the absolute episode number for a series episode when
+// absolute matching applies (AnimeAbsoluteNumbering: "always", or "auto" for series
+// TVDB tags as Anime). Returns 0 when it does not apply or TVDB has no mapping.
+func (s *Server) absoluteEpisode(tvdbID string, season, episode int) int {
+	mode := s.config.AnimeAbsoluteNumbering
+	if s.tvdbClient == nil || mode == "off" {
+		return 0
+	}
+	if mode != "always" {
+		anime, err := s.tvdbClient.IsAnime(tvdbID)
+		if err != nil {
+			logger.Debug("TVDB anime lookup failed", "tvdb", tvdbID, "err", err)
+			return 0
+		}
+		if !anime {
+			return 0
+		}
+	}
+	abs, err := s.tvdbClient.GetAbsoluteEpisode(tvdbID, season, episode)
+	if err != nil {
+		logger.Debug("TVDB absolute episode lookup failed", "tvdb", tvdbID, "season", season, "episode", episode, "err", err)
+		return 0
+	}
+	return abs
+}
@@ -0,0 +1,23 @@
+package stremio
+
+import "streamnzb/pkg/search/triage"
+
+// SetBlacklist sets the releases hidden from search results.
+func (s *Server) SetBlacklist(b *triage.Blacklist) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blacklist = b
+}
+
+// Blacklist returns the release blacklist (nil when not set).
+func (s *Server) Blacklist() *triage.Blacklist {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.blacklist
+}
+
+// FlushStreamCache drops cached stream results, e.g. after the blacklist changed, and
+// returns the number of entries cleared.
+func (s *Server) FlushStreamCache() int {
+	return s.streamCache.Clear()
+}
@@ -0,0 +1,122 @@
+package stremio
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"streamnzb/pkg/core/logger"
+)
+
+// clientIP returns the IP used for playback tracking. When the direct peer is a
+// trusted proxy (config TrustedProxies), the real client is taken from
+// X-Forwarded-For (right-most untrusted hop) or X-Real-IP.
+func (s *Server) clientIP(r *http.Request) string {
+	s.mu.RLock()
+	trusted := s.trustedProxies
+	s.mu.RUnlock()
+	return resolveClientIP(r, trusted)
+}
+
+// resolveClientIP extracts the client IP from r, honoring forwarding headers only
+// when the immediate peer is inside one of the trusted networks.
+func resolveClientIP(r *http.Request, trusted []*net.IPNet) string {
+	peer := normalizeIP(r.RemoteAddr)
+	if peer == "" {
+		peer = r.RemoteAddr
+	}
+	if len(trusted) == 0 || !ipTrusted(peer, trusted) {
+		return peer
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		var hops []string
+		for _, h := range xff {
+			for _, part := range strings.Split(h, ",") {
+				if ip := normalizeIP(part); ip != "" {
+					hops = append(hops, ip)
+				}
+			}
+		}
+		// Walk from the closest hop outward; the first untrusted address is the client.
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !ipTrusted(hops[i], trusted) {
+				return hops[i]
+			}
+		}
+		if len(hops) > 0 {
+			return hops[0]
+		}
+	}
+	if ip := normalizeIP(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return peer
+}
+
+// normalizeIP parses "1.2.3.4", "1.2.3.4:80", "::1", "[::1]" or "[::1]:80" and
+// returns the canonical IP string, or "" if the value is not an IP.
+func normalizeIP(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	if i := strings.IndexByte(addr, '%'); i != -1 {
+		addr = addr[:i] // drop IPv6 zone
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+	return ip.String()
+}
+
+// parseTrustedProxies converts CIDRs (or bare IPs) into networks, skipping invalid entries.
+func parseTrustedProxies(entries []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if !strings.Contains(e, "/") {
+			ip := net.ParseIP(strings.Trim(e, "[]"))
+			if ip == nil {
+				logger.Warn("Ignoring invalid trusted proxy", "value", e)
+				continue
+			}
+			if ip.To4() != nil {
+				e = ip.String() + "/32"
+			} else {
+				e = ip.String() + "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(e)
+		if err != nil {
+			logger.Warn("Ignoring invalid trusted proxy", "value", e, "err", err)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func ipTrusted(ipStr string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
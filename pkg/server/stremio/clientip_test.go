@@ -0,0 +1,97 @@
+package stremio
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+)
+
+func TestNormalizeIP(t *testing.T) {
+	tests := map[string]string{
+		"1.2.3.4":             "1.2.3.4",
+		" 1.2.3.4:8080 ":      "1.2.3.4",
+		"::1":                 "::1",
+		"[::1]":               "::1",
+		"[2001:db8::1]:443":   "2001:db8::1",
+		"2001:DB8:0::1":       "2001:db8::1",
+		"fe80::1%eth0":        "fe80::1",
+		"[fe80::1%eth0]:80":   "fe80::1",
+		"::ffff:192.168.1.10": "192.168.1.10",
+		"example.com":         "",
+		"unknown":             "",
+		"":                    "",
+	}
+	for in, want := range tests {
+		if got := normalizeIP(in); got != want {
+			t.Errorf("normalizeIP(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	logger.Init("DEBUG")
+	nets := parseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1 ", "[fd00::1]", "2001:db8::/32", "not-an-ip", "10.0.0.0/99", ""})
+	var got []string
+	for _, n := range nets {
+		got = append(got, n.String())
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.1/32", "fd00::1/128", "2001:db8::/32"}
+	if len(got) != len(want) {
+		t.Fatalf("parseTrustedProxies = %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseTrustedProxies = %v; want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestResolveClientIP(t *testing.T) {
+	logger.Init("DEBUG")
+	trusted := parseTrustedProxies([]string{"10.0.0.0/8", "fd00::/8"})
+	tests := []struct {
+		name, remote, xff, realIP, want string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.5:1234", "198.51.100.1", "", "203.0.113.5"},
+		{"trusted peer", "10.0.0.2:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"right-most untrusted hop", "10.0.0.2:1234", "198.51.100.1, 203.0.113.9, 10.0.0.3", "", "203.0.113.9"},
+		{"all hops trusted", "10.0.0.2:1234", "10.0.0.4, 10.0.0.3", "", "10.0.0.4"},
+		{"X-Real-IP fallback", "10.0.0.2:1234", "", "198.51.100.7", "198.51.100.7"},
+		{"IPv6 proxy", "[fd00::2]:443", "2001:db8::7", "", "2001:db8::7"},
+		{"bracketed IPv6 hop with port", "[fd00::2]:443", "[2001:db8::8]:51000", "", "2001:db8::8"},
+		{"IPv6 peer untrusted", "[2001:db8::9]:443", "198.51.100.1", "", "2001:db8::9"},
+		{"garbage hop skipped", "10.0.0.2:1234", "unknown, 198.51.100.2", "", "198.51.100.2"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.xff != "" {
+			r.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		if tt.realIP != "" {
+			r.Header.Set("X-Real-IP", tt.realIP)
+		}
+		if got := resolveClientIP(r, trusted); got != tt.want {
+			t.Errorf("%s: resolveClientIP = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClientIPFollowsConfig(t *testing.T) {
+	logger.Init("DEBUG")
+	s := &Server{}
+	s.SetConfig(&config.Config{})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.2:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := s.clientIP(r); got != "10.0.0.2" {
+		t.Errorf("without trusted proxies: %q", got)
+	}
+	s.SetConfig(&config.Config{TrustedProxies: []string{"10.0.0.0/8"}})
+	if got := s.clientIP(r); got != "198.51.100.1" {
+		t.Errorf("after trusting the proxy: %q", got)
+	}
+}
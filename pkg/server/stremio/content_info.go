@@ -0,0 +1,155 @@
+package stremio
+
+import (
+	"sync"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/services/metadata/ratelimit"
+	"streamnzb/pkg/services/metadata/tmdb"
+)
+
+const (
+	contentInfoTTL       = 24 * time.Hour
+	contentInfoFailedTTL = 10 * time.Minute // retry unresolved IDs sooner
+	contentInfoMax       = 2000
+)
+
+// contentInfo is the TMDB metadata of the requested movie or show: title and year for
+// stream descriptions, the rest for the meta resource. Zero when TMDB is not configured
+// or the lookup failed.
+type contentInfo struct {
+	Title string
+	Year  string
+	// SeriesID is the show's ID for behaviorHints.bingeGroup (empty for movies)
+	SeriesID string
+
+	TMDBID     int
+	Overview   string
+	Poster     string
+	Background string
+}
+
+type contentInfoEntry struct {
+	info    contentInfo
+	expires time.Time
+	// episodes of a show, filled on the first meta request (see resolveEpisodes)
+	episodes       []tmdb.Episode
+	episodesLoaded bool
+}
+
+// contentInfoCache keeps resolved metadata per content ID so every /stream and /meta
+// request for the same movie or show (e.g. each episode) does not query TMDB again.
+type contentInfoCache struct {
+	mu      sync.Mutex
+	entries map[string]contentInfoEntry
+}
+
+func newContentInfoCache() *contentInfoCache {
+	return &contentInfoCache{entries: make(map[string]contentInfoEntry)}
+}
+
+func contentInfoKey(contentType, imdbID, tmdbID string) string {
+	return contentType + ":" + imdbID + ":" + tmdbID
+}
+
+// resolveContentInfo returns the cached metadata for the content, looking it up
+// on TMDB on a miss. Failures degrade to an empty contentInfo.
+func (s *Server) resolveContentInfo(contentType, imdbID, tmdbID string) contentInfo {
+	if s.tmdbClient == nil || (imdbID == "" && tmdbID == "") {
+		return contentInfo{}
+	}
+	key := contentInfoKey(contentType, imdbID, tmdbID)
+	c := s.contentInfo
+
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		return e.info
+	}
+	c.mu.Unlock()
+
+	var info contentInfo
+	ttl := contentInfoTTL
+	summary, err := s.tmdbClient.GetSummary(contentType, imdbID, tmdbID)
+	if err != nil {
+		logger.Debug("TMDB title lookup failed", "type", contentType, "imdb", imdbID, "tmdb", tmdbID, "err", err)
+		ttl = contentInfoFailedTTL
+	} else {
+		info = contentInfo{
+			Title:      summary.Name,
+			Year:       summary.Year,
+			TMDBID:     summary.TMDBID,
+			Overview:   summary.Overview,
+			Poster:     summary.Poster,
+			Background: summary.Background,
+		}
+	}
+
+	c.mu.Lock()
+	if len(c.entries) >= contentInfoMax {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= contentInfoMax {
+			c.entries = make(map[string]contentInfoEntry)
+		}
+	}
+	c.entries[key] = contentInfoEntry{info: info, expires: now.Add(ttl)}
+	c.mu.Unlock()
+	return info
+}
+
+// resolveEpisodes returns the episodes of a show resolved by resolveContentInfo, listing
+// them on TMDB once per cache entry. A failed listing is retried after
+// contentInfoFailedTTL, together with the rest of the entry.
+func (s *Server) resolveEpisodes(imdbID, tmdbID string, info contentInfo) []tmdb.Episode {
+	if info.TMDBID == 0 {
+		return nil
+	}
+	key := contentInfoKey("series", imdbID, tmdbID)
+	c := s.contentInfo
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && e.episodesLoaded {
+		c.mu.Unlock()
+		return e.episodes
+	}
+	c.mu.Unlock()
+
+	episodes, err := s.tmdbClient.GetEpisodes(info.TMDBID)
+	if err != nil {
+		logger.Debug("TMDB episode lookup failed", "tmdb", info.TMDBID, "err", err)
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		e.episodes, e.episodesLoaded = episodes, true
+		if retry := time.Now().Add(contentInfoFailedTTL); err != nil && e.expires.After(retry) {
+			e.expires = retry
+		}
+		c.entries[key] = e
+	}
+	c.mu.Unlock()
+	return episodes
+}
+
+// MetadataRateLimits returns the 429 circuit breaker state of the configured TMDB and
+// TVDB clients, keyed "tmdb" and "tvdb".
+func (s *Server) MetadataRateLimits() map[string]ratelimit.Status {
+	s.mu.RLock()
+	tmdbClient, tvdbClient := s.tmdbClient, s.tvdbClient
+	s.mu.RUnlock()
+
+	out := make(map[string]ratelimit.Status)
+	if tmdbClient.Configured() {
+		out["tmdb"] = tmdbClient.RateLimit()
+	}
+	if tvdbClient != nil {
+		out["tvdb"] = tvdbClient.RateLimit()
+	}
+	return out
+}
@@ -0,0 +1,394 @@
+package stremio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/webdav"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/loader"
+	"streamnzb/pkg/media/unpack"
+	"streamnzb/pkg/session"
+)
+
+// Validated and played releases are also exposed read-only over WebDAV at /{token}/dav/,
+// so players without Stremio support (Kodi, or Plex through an rclone/davfs2 mount) can
+// browse and stream them: one folder per session, holding the session's media file.
+
+const davFolderIDLen = 8
+
+// davTree holds the WebDAV state shared between requests.
+type davTree struct {
+	locks webdav.LockSystem
+
+	mu    sync.Mutex
+	media map[string]davInfo // session ID -> media file, learned when the folder is first listed
+}
+
+func newDavTree() *davTree {
+	return &davTree{locks: webdav.NewMemLS(), media: make(map[string]davInfo)}
+}
+
+// handleDAV serves the read-only WebDAV tree (OPTIONS, PROPFIND, GET and HEAD).
+func (s *Server) handleDAV(w http.ResponseWriter, r *http.Request, device *auth.Device) {
+	if !s.config.WebDAVEnabled || device == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodOptions, http.MethodGet, http.MethodHead, "PROPFIND":
+	default:
+		w.Header().Set("Allow", "OPTIONS, PROPFIND, GET, HEAD")
+		http.Error(w, "WebDAV view is read-only", http.StatusMethodNotAllowed)
+		return
+	}
+
+	davFS := &davFS{s: s, device: device, clientIP: s.clientIP(r)}
+	if r.Method == http.MethodGet {
+		if sess, file := davFS.lookup(strings.TrimPrefix(r.URL.Path, "/dav")); sess != nil && file != "" {
+			if s.deviceManager != nil && s.deviceManager.QuotaExceeded(device) {
+				logger.Warn("Monthly quota exceeded for device", "device", device.Username, "quota_gb", device.MonthlyQuotaGB, "session", sess.ID)
+				http.Error(w, "Monthly quota exceeded", http.StatusForbidden)
+				return
+			}
+			deviceKey := "device:" + device.Token
+			limit := s.config.MaxConcurrentPlaybacks
+			if device.MaxConcurrentPlaybacks > 0 {
+				limit = device.MaxConcurrentPlaybacks
+			}
+			if !s.sessionManager.TryStartDevicePlayback(deviceKey, sess.ID, limit) {
+				w.Header().Set("Retry-After", "30")
+				http.Error(w, fmt.Sprintf("Too many streams: this device is limited to %d concurrent playbacks", limit), http.StatusTooManyRequests)
+				return
+			}
+			defer s.sessionManager.EndDevicePlayback(deviceKey, sess.ID)
+		}
+	}
+
+	// Hrefs in PROPFIND responses must keep the device token, so serve under the full path.
+	prefix := "/" + device.Token + "/dav"
+	r.URL.Path = "/" + device.Token + r.URL.Path
+	h := &webdav.Handler{
+		Prefix:     prefix,
+		FileSystem: davFS,
+		LockSystem: s.dav.locks,
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !os.IsNotExist(err) {
+				logger.Debug("WebDAV request failed", "method", r.Method, "path", strings.TrimPrefix(r.URL.Path, prefix), "err", err)
+			}
+		},
+	}
+	h.ServeHTTP(w, r)
+}
+
+// davFS is the read-only webdav.FileSystem of one request.
+type davFS struct {
+	s        *Server
+	device   *auth.Device
+	clientIP string
+}
+
+// offerSession records that the session's stream was listed for or played by device,
+// which makes it visible in the device's WebDAV tree.
+func offerSession(sess *session.Session, device *auth.Device) {
+	if sess != nil && device != nil {
+		sess.AddDevice(device.Username)
+	}
+}
+
+// visible reports whether the session is in the device's tree: admins see every session,
+// other devices only those that were offered to them.
+func (d *davFS) visible(sess *session.Session) bool {
+	return sess.NZB != nil && (d.device.IsAdmin() || sess.HasDevice(d.device.Username))
+}
+
+// lookup resolves a tree path to its session and file name ("" for the folder itself).
+// sess is nil for the root and for unknown or hidden folders.
+func (d *davFS) lookup(name string) (sess *session.Session, file string) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return nil, ""
+	}
+	folder, file, _ := strings.Cut(name, "/")
+	for _, sess := range d.s.sessionManager.Sessions() {
+		if d.visible(sess) && davFolderName(sess) == folder {
+			return sess, file
+		}
+	}
+	return nil, ""
+}
+
+// media returns the media file of a session, opening the stream once to learn its name and size.
+func (d *davFS) media(ctx context.Context, sess *session.Session) (davInfo, error) {
+	d.s.dav.mu.Lock()
+	info, ok := d.s.dav.media[sess.ID]
+	d.s.dav.mu.Unlock()
+	if ok {
+		return info, nil
+	}
+	d.s.dav.prune(d.s.sessionManager.Sessions())
+	stream, name, size, bp, err := unpack.GetMediaStream(ctx, sessionFiles(sess), sess.Blueprint)
+	if bp != nil && sess.Blueprint == nil {
+		sess.SetBlueprint(bp)
+	}
+	if err != nil {
+		return davInfo{}, err
+	}
+	stream.Close()
+	info = davInfo{name: path.Base(strings.ReplaceAll(name, "\\", "/")), size: size, modTime: sess.CreatedAt}
+	d.s.dav.mu.Lock()
+	d.s.dav.media[sess.ID] = info
+	d.s.dav.mu.Unlock()
+	return info, nil
+}
+
+func (d *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	if strings.Trim(name, "/") == "" {
+		return davInfo{name: "/", dir: true}, nil
+	}
+	sess, file := d.lookup(name)
+	if sess == nil {
+		return nil, os.ErrNotExist
+	}
+	if file == "" {
+		return davInfo{name: davFolderName(sess), dir: true, modTime: sess.CreatedAt}, nil
+	}
+	info, err := d.media(ctx, sess)
+	if err != nil || info.name != file {
+		return nil, os.ErrNotExist
+	}
+	return info, nil
+}
+
+func (d *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return nil, os.ErrPermission
+	}
+	if strings.Trim(name, "/") == "" {
+		return &davDir{info: davInfo{name: "/", dir: true}, entries: d.rootEntries()}, nil
+	}
+	sess, file := d.lookup(name)
+	if sess == nil {
+		return nil, os.ErrNotExist
+	}
+	media, err := d.media(ctx, sess)
+	if file == "" {
+		dir := &davDir{info: davInfo{name: davFolderName(sess), dir: true, modTime: sess.CreatedAt}}
+		if err != nil {
+			logger.Debug("WebDAV: failed to open session media", "session", sess.ID, "err", err)
+		} else {
+			dir.entries = []os.FileInfo{media}
+		}
+		return dir, nil
+	}
+	if err != nil || media.name != file {
+		return nil, os.ErrNotExist
+	}
+	return &davFile{ctx: ctx, fs: d, sess: sess, info: media}, nil
+}
+
+// rootEntries lists one folder per visible session with a loaded NZB and forgets the
+// media of sessions that expired.
+func (d *davFS) rootEntries() []os.FileInfo {
+	sessions := d.s.sessionManager.Sessions()
+	var entries []os.FileInfo
+	for _, sess := range sessions {
+		if d.visible(sess) {
+			entries = append(entries, davInfo{name: davFolderName(sess), dir: true, modTime: sess.CreatedAt})
+		}
+	}
+	d.s.dav.prune(sessions)
+	return entries
+}
+
+// prune forgets the media of sessions that are no longer live.
+func (t *davTree) prune(live []*session.Session) {
+	ids := make(map[string]bool, len(live))
+	for _, sess := range live {
+		ids[sess.ID] = true
+	}
+	t.mu.Lock()
+	for id := range t.media {
+		if !ids[id] {
+			delete(t.media, id)
+		}
+	}
+	t.mu.Unlock()
+}
+
+func (d *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	return os.ErrPermission
+}
+
+func (d *davFS) RemoveAll(ctx context.Context, name string) error {
+	return os.ErrPermission
+}
+
+func (d *davFS) Rename(ctx context.Context, oldName, newName string) error {
+	return os.ErrPermission
+}
+
+// davFolderName is the folder of a session: the release title plus the start of the
+// session ID, which keeps releases with the same title apart.
+func davFolderName(sess *session.Session) string {
+	title := sess.ReportReleaseName()
+	if title == "" {
+		title = "Release"
+	}
+	title = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	return fmt.Sprintf("%s [%s]", title, sess.ID[:min(len(sess.ID), davFolderIDLen)])
+}
+
+// sessionFiles returns the files GetMediaStream should open for a session.
+func sessionFiles(sess *session.Session) []*loader.File {
+	if len(sess.Files) == 0 && sess.File != nil {
+		return []*loader.File{sess.File}
+	}
+	return sess.Files
+}
+
+// davInfo is the os.FileInfo of a folder or media file in the tree.
+type davInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+}
+
+func (i davInfo) Name() string       { return i.name }
+func (i davInfo) Size() int64        { return i.size }
+func (i davInfo) ModTime() time.Time { return i.modTime }
+func (i davInfo) IsDir() bool        { return i.dir }
+func (i davInfo) Sys() any           { return nil }
+
+func (i davInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// ContentType keeps the WebDAV handler from opening the media stream to sniff it.
+func (i davInfo) ContentType(ctx context.Context) (string, error) {
+	ext := strings.ToLower(path.Ext(i.name))
+	switch ext {
+	case ".mkv":
+		return "video/x-matroska", nil
+	case ".mp4", ".m4v":
+		return "video/mp4", nil
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// davDir is an open folder.
+type davDir struct {
+	info    davInfo
+	entries []os.FileInfo
+	pos     int
+}
+
+func (d *davDir) Close() error                                 { return nil }
+func (d *davDir) Read(p []byte) (int, error)                   { return 0, fs.ErrInvalid }
+func (d *davDir) Seek(offset int64, whence int) (int64, error) { return 0, fs.ErrInvalid }
+func (d *davDir) Write(p []byte) (int, error)                  { return 0, os.ErrPermission }
+func (d *davDir) Stat() (os.FileInfo, error)                   { return d.info, nil }
+
+func (d *davDir) Readdir(count int) ([]os.FileInfo, error) {
+	rest := d.entries[d.pos:]
+	if count <= 0 {
+		d.pos = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(rest))
+	d.pos += n
+	return rest[:n], nil
+}
+
+// davFile is an open media file. The stream is opened on the first Read or Seek, since
+// PROPFIND opens files only to stat them.
+type davFile struct {
+	ctx    context.Context
+	fs     *davFS
+	sess   *session.Session
+	info   davInfo
+	stream *StreamMonitor
+}
+
+func (f *davFile) open() error {
+	if f.stream != nil {
+		return nil
+	}
+	stream, _, _, bp, err := unpack.GetMediaStream(f.ctx, sessionFiles(f.sess), f.sess.Blueprint)
+	if bp != nil && f.sess.Blueprint == nil {
+		f.sess.SetBlueprint(bp)
+	}
+	if err != nil {
+		logger.Error("WebDAV: failed to open media stream", "session", f.sess.ID, "err", err)
+		return err
+	}
+	manager := f.fs.s.sessionManager
+	manager.StartPlayback(f.sess.ID, f.fs.clientIP)
+	f.stream = &StreamMonitor{
+		ReadSeekCloser: stream,
+		sessionID:      f.sess.ID,
+		clientIP:       f.fs.clientIP,
+		manager:        manager,
+		lastUpdate:     time.Now(),
+	}
+	if f.fs.s.deviceManager != nil {
+		f.stream.usage, f.stream.username = f.fs.s.deviceManager, f.fs.device.Username
+	}
+	logger.Info("Serving media over WebDAV", "name", f.info.name, "size", f.info.size, "session", f.sess.ID)
+	return nil
+}
+
+func (f *davFile) Read(p []byte) (int, error) {
+	if err := f.open(); err != nil {
+		return 0, err
+	}
+	return f.stream.Read(p)
+}
+
+func (f *davFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.open(); err != nil {
+		return 0, err
+	}
+	return f.stream.Seek(offset, whence)
+}
+
+func (f *davFile) Close() error {
+	if f.stream == nil {
+		return nil
+	}
+	f.stream.flushUsage()
+	err := f.stream.Close()
+	f.fs.s.sessionManager.EndPlayback(f.sess.ID, f.fs.clientIP)
+	f.stream = nil
+	return err
+}
+
+func (f *davFile) Readdir(count int) ([]os.FileInfo, error) { return nil, fs.ErrInvalid }
+func (f *davFile) Write(p []byte) (int, error)              { return 0, os.ErrPermission }
+func (f *davFile) Stat() (os.FileInfo, error)               { return f.info, nil }
@@ -0,0 +1,78 @@
+package stremio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/session"
+)
+
+func TestDAVListing(t *testing.T) {
+	logger.Init("DEBUG")
+	mgr := session.NewManager(nil, time.Hour)
+	sess, err := mgr.CreateSession("0123456789abcdef", testNZB(map[string]int64{"Movie.2001.1080p.mkv": 4 << 30}),
+		&release.Release{Title: "Movie 2001/1080p"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := davFolderName(sess), "Movie 2001_1080p [01234567]"; got != want {
+		t.Errorf("davFolderName = %q; want %q", got, want)
+	}
+
+	s := &Server{config: &config.Config{}, sessionManager: mgr, dav: newDavTree()}
+	device := &auth.Device{Username: "tv", Token: "tok"}
+	offerSession(sess, device)
+	serveAs := func(device *auth.Device, method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.Header.Set("Depth", "1")
+		w := httptest.NewRecorder()
+		s.handleDAV(w, r, device)
+		return w
+	}
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		return serveAs(device, method, path)
+	}
+
+	if w := serve("PROPFIND", "/dav/"); w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status %d; want 404", w.Code)
+	}
+	s.config.WebDAVEnabled = true
+
+	w := serve("PROPFIND", "/dav/")
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND status %d; want 207", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "/tok/dav/Movie%202001_1080p%20%5B01234567%5D/") {
+		t.Errorf("listing lacks the session folder under the token prefix:\n%s", body)
+	}
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete, "MKCOL", "MOVE"} {
+		if w := serve(method, "/dav/x"); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: status %d; want 405", method, w.Code)
+		}
+	}
+	if w := serve("PROPFIND", "/dav/Unknown%20%5Bffffffff%5D/"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown folder: status %d; want 404", w.Code)
+	}
+
+	// Another device does not see the session offered to tv; the admin does
+	other := &auth.Device{Username: "phone", Token: "tok2"}
+	if body := serveAs(other, "PROPFIND", "/dav/").Body.String(); strings.Contains(body, "Movie%202001") {
+		t.Errorf("device phone lists tv's session:\n%s", body)
+	}
+	if w := serveAs(other, "PROPFIND", "/dav/Movie%202001_1080p%20%5B01234567%5D/"); w.Code != http.StatusNotFound {
+		t.Errorf("device phone opening tv's folder: status %d; want 404", w.Code)
+	}
+	admin := &auth.Device{Username: "admin", Token: "tok3", Role: auth.RoleAdmin}
+	if body := serveAs(admin, "PROPFIND", "/dav/").Body.String(); !strings.Contains(body, "Movie%202001") {
+		t.Errorf("admin listing lacks the session:\n%s", body)
+	}
+}
@@ -0,0 +1,85 @@
+package stremio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"streamnzb/pkg/media/nzb"
+)
+
+const maxDebugNZBBytes = 64 << 20
+
+// debugSegments is the JSON body of POST /debug/play-segments: files described by their
+// article message IDs, for playing something that only exists as a list of segments.
+type debugSegments struct {
+	Files []struct {
+		Name     string   `json:"name"`
+		Groups   []string `json:"groups,omitempty"`
+		Segments []struct {
+			ID    string `json:"id"`
+			Bytes int64  `json:"bytes"`
+		} `json:"segments"`
+	} `json:"files"`
+}
+
+// toNZB builds the NZB equivalent of the segment list; subjects carry the quoted file
+// name the way posters do, so file detection works as for a downloaded NZB.
+func (d *debugSegments) toNZB() (*nzb.NZB, error) {
+	if len(d.Files) == 0 {
+		return nil, fmt.Errorf("no files")
+	}
+	out := &nzb.NZB{}
+	for i, f := range d.Files {
+		if f.Name == "" || len(f.Segments) == 0 {
+			return nil, fmt.Errorf("file %d needs a name and segments", i+1)
+		}
+		file := nzb.File{
+			Subject: fmt.Sprintf("\"%s\" yEnc (1/%d)", f.Name, len(f.Segments)),
+			Groups:  f.Groups,
+		}
+		for n, seg := range f.Segments {
+			if seg.ID == "" {
+				return nil, fmt.Errorf("file %s: segment %d has no message ID", f.Name, n+1)
+			}
+			file.Segments = append(file.Segments, nzb.Segment{
+				ID:     strings.Trim(seg.ID, "<>"),
+				Bytes:  seg.Bytes,
+				Number: n + 1,
+			})
+		}
+		out.Files = append(out.Files, file)
+	}
+	return out, nil
+}
+
+// readDebugNZB parses the body of a debug play POST: a JSON segment list (debugSegments)
+// or a raw NZB document.
+func readDebugNZB(r *http.Request) (*nzb.NZB, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxDebugNZBBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+	if data[0] == '{' {
+		var segs debugSegments
+		if err := json.Unmarshal(data, &segs); err != nil {
+			return nil, fmt.Errorf("invalid segment list: %w", err)
+		}
+		return segs.toNZB()
+	}
+	parsed, err := nzb.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NZB: %w", err)
+	}
+	if len(parsed.Files) == 0 {
+		return nil, fmt.Errorf("no files in NZB")
+	}
+	return parsed, nil
+}
@@ -0,0 +1,35 @@
+package stremio
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadDebugNZB(t *testing.T) {
+	body := `{"files":[{"name":"Movie.2024.1080p.mkv","groups":["alt.binaries.test"],
+		"segments":[{"id":"<part1@example>","bytes":768000},{"id":"part2@example","bytes":512}]}]}`
+	n, err := readDebugNZB(httptest.NewRequest("POST", "/debug/play-segments", strings.NewReader(body)))
+	if err != nil {
+		t.Fatalf("segment list: %v", err)
+	}
+	info := n.GetFileInfo()
+	if len(info) != 1 || info[0].Filename != "Movie.2024.1080p.mkv" || info[0].Size != 768512 {
+		t.Fatalf("unexpected file info: %+v", info[0])
+	}
+	if id := n.Files[0].Segments[0].ID; id != "part1@example" {
+		t.Errorf("message ID = %q, want brackets stripped", id)
+	}
+
+	raw := `<?xml version="1.0"?><nzb><file subject="&quot;a.mkv&quot; yEnc (1/1)"><groups><group>a.b</group></groups>
+		<segments><segment bytes="10" number="1">x@y</segment></segments></file></nzb>`
+	if n, err = readDebugNZB(httptest.NewRequest("POST", "/debug/play", strings.NewReader(raw))); err != nil || len(n.Files) != 1 {
+		t.Fatalf("raw NZB: %v", err)
+	}
+
+	for _, bad := range []string{"", `{"files":[]}`, `{"files":[{"name":"a.mkv","segments":[{"id":""}]}]}`, "<html></html>"} {
+		if _, err := readDebugNZB(httptest.NewRequest("POST", "/debug/play", strings.NewReader(bad))); err == nil {
+			t.Errorf("expected an error for body %q", bad)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package stremio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/search/triage"
+	"streamnzb/pkg/session"
+)
+
+// recentErrorsSize is how many failed /stream and /play requests RecentErrors keeps.
+const recentErrorsSize = 50
+
+// errNoStreams marks a /stream request that completed without any stream.
+var errNoStreams = errors.New("no streams found")
+
+// FailedRequest is a /stream or /play request that returned no streams or an error,
+// kept in memory so transient failures can be looked at after the fact.
+type FailedRequest struct {
+	Time        time.Time          `json:"time"`
+	Endpoint    string             `json:"endpoint"` // "stream" or "play"
+	Device      string             `json:"device"`
+	ContentType string             `json:"content_type,omitempty"`
+	ContentID   string             `json:"content_id,omitempty"`
+	SessionID   string             `json:"session_id,omitempty"`
+	Release     string             `json:"release,omitempty"`
+	Provider    string             `json:"provider,omitempty"` // playback provider of the session
+	Status      int                `json:"status"`             // HTTP status sent (307 = error video)
+	Error       string             `json:"error"`
+	Timing      string             `json:"timing,omitempty"` // as in the X-StreamNZB-Timing header
+	Candidates  []CandidateOutcome `json:"candidates,omitempty"`
+}
+
+// CandidateOutcome is the validation result of one candidate of a /stream request.
+type CandidateOutcome struct {
+	Title   string `json:"title"`
+	Indexer string `json:"indexer,omitempty"`
+	Score   int    `json:"score"`
+	Streams int    `json:"streams"`
+	Error   string `json:"error,omitempty"`
+}
+
+// requestTrace collects the candidate outcomes of a /stream request. A nil *requestTrace
+// records nothing, like searchTimings.
+type requestTrace struct {
+	mu         sync.Mutex
+	candidates []CandidateOutcome
+}
+
+type requestTraceKey struct{}
+
+func withRequestTrace(ctx context.Context, t *requestTrace) context.Context {
+	return context.WithValue(ctx, requestTraceKey{}, t)
+}
+
+func requestTraceFrom(ctx context.Context) *requestTrace {
+	t, _ := ctx.Value(requestTraceKey{}).(*requestTrace)
+	return t
+}
+
+// candidate records the outcome of validating cand.
+func (t *requestTrace) candidate(cand triage.Candidate, streams int, err error) {
+	if t == nil || cand.Release == nil {
+		return
+	}
+	o := CandidateOutcome{Title: cand.Release.Title, Indexer: cand.Release.Indexer, Score: cand.Score, Streams: streams}
+	if err != nil {
+		o.Error = err.Error()
+	}
+	t.mu.Lock()
+	t.candidates = append(t.candidates, o)
+	t.mu.Unlock()
+}
+
+func (t *requestTrace) outcomes() []CandidateOutcome {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]CandidateOutcome(nil), t.candidates...)
+}
+
+// failureLog is a ring of the most recent failed requests. A nil *failureLog keeps nothing.
+type failureLog struct {
+	mu      sync.Mutex
+	size    int
+	entries []FailedRequest // oldest first
+}
+
+func newFailureLog(size int) *failureLog {
+	return &failureLog{size: size}
+}
+
+func (l *failureLog) add(f FailedRequest) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) >= l.size {
+		l.entries = append(l.entries[:0], l.entries[len(l.entries)-l.size+1:]...)
+	}
+	l.entries = append(l.entries, f)
+}
+
+// list returns the entries, newest first.
+func (l *failureLog) list() []FailedRequest {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]FailedRequest, len(l.entries))
+	for i, f := range l.entries {
+		out[len(out)-1-i] = f
+	}
+	return out
+}
+
+// RecentErrors returns the last failed /stream and /play requests, newest first.
+func (s *Server) RecentErrors() []FailedRequest {
+	return s.recentErrors.list()
+}
+
+func deviceName(device *auth.Device) string {
+	if device != nil {
+		return device.Username
+	}
+	return "legacy"
+}
+
+// recordStreamFailure keeps a /stream request that failed or found no streams.
+func (s *Server) recordStreamFailure(device *auth.Device, contentType, id string, trace *requestTrace, timing string, err error) {
+	if err == nil {
+		err = errNoStreams
+	}
+	s.recentErrors.add(FailedRequest{
+		Time:        time.Now(),
+		Endpoint:    "stream",
+		Device:      deviceName(device),
+		ContentType: contentType,
+		ContentID:   id,
+		Status:      200, // Stremio always gets a stream list, possibly empty
+		Error:       err.Error(),
+		Timing:      timing,
+		Candidates:  trace.outcomes(),
+	})
+}
+
+// recordPlayFailure keeps a /play request that could not be served; sess is nil when
+// the session was not found.
+func (s *Server) recordPlayFailure(device *auth.Device, sessionID string, sess *session.Session, status int, err error) {
+	f := FailedRequest{
+		Time:      time.Now(),
+		Endpoint:  "play",
+		Device:    deviceName(device),
+		SessionID: sessionID,
+		Status:    status,
+		Error:     err.Error(),
+	}
+	if sess != nil {
+		f.Provider = sess.Provider
+		if sess.Release != nil {
+			f.Release = sess.Release.Title
+		}
+		if ids := sess.ContentIDs; ids != nil {
+			f.ContentID = ids.ImdbID
+			if f.ContentID == "" && ids.TvdbID != "" {
+				f.ContentID = "tvdb:" + ids.TvdbID
+			}
+			if f.ContentID != "" && ids.Episode > 0 {
+				f.ContentID += fmt.Sprintf(":%d:%d", ids.Season, ids.Episode)
+			}
+		}
+	}
+	s.recentErrors.add(f)
+}
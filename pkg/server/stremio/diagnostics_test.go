@@ -0,0 +1,49 @@
+package stremio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/search/triage"
+	"streamnzb/pkg/session"
+)
+
+func TestRecentErrors(t *testing.T) {
+	s := &Server{recentErrors: newFailureLog(3)}
+	for i := 0; i < 5; i++ {
+		s.recordStreamFailure(nil, "movie", fmt.Sprintf("tt%d", i), nil, "", nil)
+	}
+	recent := s.RecentErrors()
+	if len(recent) != 3 || recent[0].ContentID != "tt4" || recent[2].ContentID != "tt2" {
+		t.Fatalf("RecentErrors = %+v, want tt4..tt2 newest first", recent)
+	}
+	if recent[0].Error != errNoStreams.Error() || recent[0].Device != "legacy" {
+		t.Errorf("empty result recorded as %+v", recent[0])
+	}
+
+	// Candidate outcomes travel with the request context
+	trace := &requestTrace{}
+	ctx := withRequestTrace(context.Background(), trace)
+	requestTraceFrom(ctx).candidate(triage.Candidate{Release: &release.Release{Title: "A", Indexer: "idx"}, Score: 7}, 0, errors.New("no valid providers"))
+	requestTraceFrom(ctx).candidate(triage.Candidate{Release: &release.Release{Title: "B"}}, 1, nil)
+	requestTraceFrom(context.Background()).candidate(triage.Candidate{Release: &release.Release{Title: "C"}}, 1, nil) // no trace: ignored
+	s.recordStreamFailure(nil, "movie", "tt9", trace, "total=10ms", errors.New("search failed"))
+	got := s.RecentErrors()[0].Candidates
+	if len(got) != 2 || got[0].Error != "no valid providers" || got[0].Score != 7 || got[1].Streams != 1 {
+		t.Errorf("candidates = %+v", got)
+	}
+
+	sess := &session.Session{
+		Release:    &release.Release{Title: "Show.S01E02"},
+		ContentIDs: &session.AvailReportMeta{TvdbID: "123", Season: 1, Episode: 2},
+		Provider:   "news.example",
+	}
+	s.recordPlayFailure(nil, "abc", sess, 307, errors.New("no files in session"))
+	play := s.RecentErrors()[0]
+	if play.Endpoint != "play" || play.ContentID != "tvdb:123:1:2" || play.Release != "Show.S01E02" || play.Provider != "news.example" {
+		t.Errorf("play failure recorded as %+v", play)
+	}
+}
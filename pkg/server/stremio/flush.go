@@ -0,0 +1,38 @@
+package stremio
+
+import "streamnzb/pkg/search"
+
+// FlushCaches drops the stream results, refresh attempt history, TMDB metadata
+// lookups, indexer search results and TVDB lookups. Returns the number of entries cleared per cache.
+func (s *Server) FlushCaches() map[string]int {
+	s.mu.RLock()
+	tvdbClient := s.tvdbClient
+	s.mu.RUnlock()
+
+	cleared := map[string]int{
+		"streams":      s.streamCache.Clear(),
+		"attempts":     s.attempts.clear(),
+		"content_info": s.contentInfo.clear(),
+		"searches":     search.ClearSearchCache(),
+	}
+	if tvdbClient != nil {
+		cleared["tvdb"] = tvdbClient.ClearCache()
+	}
+	return cleared
+}
+
+func (t *attemptTracker) clear() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(t.entries)
+	t.entries = make(map[streamCacheKey]*attemptEntry)
+	return n
+}
+
+func (c *contentInfoCache) clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.entries)
+	c.entries = make(map[string]contentInfoEntry)
+	return n
+}
@@ -11,17 +11,18 @@ import (
 	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"streamnzb/pkg/auth"
 	"streamnzb/pkg/core/config"
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/media/decode"
 	"streamnzb/pkg/media/loader"
 	"streamnzb/pkg/media/nzb"
 	"streamnzb/pkg/media/unpack"
@@ -42,6 +43,7 @@ type Server struct {
 	version              string // raw version for API/frontend (e.g. dev-9a3e479)
 	baseURL              string
 	config               *config.Config
+	trustedProxies       []*net.IPNet // config TrustedProxies, parsed when the config is set
 	indexer              indexer.Indexer
 	validator            *validation.Checker
 	sessionManager       *session.Manager
@@ -54,6 +56,16 @@ type Server struct {
 	deviceManager        *auth.DeviceManager
 	webHandler           http.Handler
 	apiHandler           http.Handler
+	streamCache          *streamCache
+	attempts             *attemptTracker
+	health               healthCache
+	contentInfo          *contentInfoCache
+	dav                  *davTree
+	blacklist            *triage.Blacklist // releases hidden by users (see SetBlacklist)
+	recentErrors         *failureLog       // last failed /stream and /play requests
+	// Lifetime indexer-candidate validation results (cancelled validations are not counted)
+	validationsOK     atomic.Int64
+	validationsFailed atomic.Int64
 }
 
 // NewServer creates a new Stremio addon server.
@@ -75,6 +87,7 @@ func NewServer(cfg *config.Config, baseURL string, port int, indexer indexer.Ind
 		version:              version,
 		baseURL:              baseURL,
 		config:               cfg,
+		trustedProxies:       parseTrustedProxies(cfg.TrustedProxies),
 		indexer:              indexer,
 		validator:            validator,
 		sessionManager:       sessionMgr,
@@ -85,6 +98,11 @@ func NewServer(cfg *config.Config, baseURL string, port int, indexer indexer.Ind
 		tmdbClient:           tmdbClient,
 		tvdbClient:           tvdbClient,
 		deviceManager:        deviceManager,
+		streamCache:          newStreamCache(cfg.StreamRateLimitPerMinute),
+		attempts:             newAttemptTracker(),
+		contentInfo:          newContentInfoCache(),
+		dav:                  newDavTree(),
+		recentErrors:         newFailureLog(recentErrorsSize),
 	}
 
 	if err := s.CheckPort(port); err != nil {
@@ -94,6 +112,19 @@ func NewServer(cfg *config.Config, baseURL string, port int, indexer indexer.Ind
 	return s, nil
 }
 
+// Close stops background work owned by the server (AvailNZB report workers). Call on shutdown.
+func (s *Server) Close() {
+	s.mu.RLock()
+	avail := s.availClient
+	s.mu.RUnlock()
+	avail.Close()
+}
+
+// ValidationCounts returns how many indexer candidates passed and failed validation since startup.
+func (s *Server) ValidationCounts() (ok, failed int64) {
+	return s.validationsOK.Load(), s.validationsFailed.Load()
+}
+
 // CheckPort verifies if the specified port is available for the addon
 func (s *Server) CheckPort(port int) error {
 	address := fmt.Sprintf(":%d", port)
@@ -146,7 +177,7 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 		}
 
 		// Determine if this is a Stremio route that requires device token
-		isStremioRoute := path == "/manifest.json" || strings.HasPrefix(path, "/stream/") || strings.HasPrefix(path, "/play/") || strings.HasPrefix(path, "/debug/play")
+		isStremioRoute := path == "/manifest.json" || strings.HasPrefix(path, "/stream/") || strings.HasPrefix(path, "/meta/") || strings.HasPrefix(path, "/stream-refresh/") || strings.HasPrefix(path, "/play/") || strings.HasPrefix(path, "/nfo/") || strings.HasPrefix(path, "/debug/play") || path == "/dav" || strings.HasPrefix(path, "/dav/")
 
 		// Root path "/" and web UI routes are always accessible (no token required)
 		// Only Stremio routes require device tokens in the path
@@ -160,7 +191,8 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 
 			// Try to authenticate as a device token
 			if deviceManager != nil {
-				device, err := deviceManager.AuthenticateToken(token, s.config.GetAdminUsername(), s.config.AdminToken)
+				adminUsername, adminToken := s.adminCredentials()
+				device, err := deviceManager.AuthenticateToken(token, adminUsername, adminToken)
 				if err == nil && device != nil {
 					authenticatedDevice = device
 					// Strip token from path for internal routing
@@ -193,10 +225,20 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 			s.handleManifest(w, r)
 		} else if strings.HasPrefix(path, "/stream/") {
 			s.handleStream(w, r, authenticatedDevice)
+		} else if strings.HasPrefix(path, "/meta/") {
+			s.handleMeta(w, r)
+		} else if strings.HasPrefix(path, "/stream-refresh/") {
+			s.handleStreamRefresh(w, r, authenticatedDevice)
+		} else if path == "/play/upload" {
+			s.handlePlayUpload(w, r, authenticatedDevice)
 		} else if strings.HasPrefix(path, "/play/") {
 			s.handlePlay(w, r, authenticatedDevice)
+		} else if strings.HasPrefix(path, "/nfo/") {
+			s.handleNFO(w, r)
 		} else if strings.HasPrefix(path, "/debug/play") {
 			s.handleDebugPlay(w, r, authenticatedDevice)
+		} else if path == "/dav" || strings.HasPrefix(path, "/dav/") {
+			s.handleDAV(w, r, authenticatedDevice)
 		} else if path == "/health" {
 			s.handleHealth(w, r)
 		} else if strings.HasPrefix(path, "/api/") {
@@ -228,12 +270,15 @@ func (s *Server) handleManifest(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	s.mu.RLock()
-	manifest := s.manifest
+	manifest := s.manifest.WithBranding(s.config.AddonName, s.config.AddonLogoURL)
 	s.mu.RUnlock()
+	if s.metaEnabled() {
+		manifest = manifest.WithResource("meta")
+	}
 
 	// Configure button (behaviorHints.configurable) only for admin users
 	device, _ := auth.DeviceFromContext(r)
-	isAdmin := device != nil && device.Username == s.config.GetAdminUsername()
+	isAdmin := device.IsAdmin()
 
 	data, err := manifest.ToJSONForDevice(isAdmin)
 	if err != nil {
@@ -246,34 +291,42 @@ func (s *Server) handleManifest(w http.ResponseWriter, r *http.Request) {
 
 // handleStream handles stream requests
 func (s *Server) handleStream(w http.ResponseWriter, r *http.Request, device *auth.Device) {
-	// Parse URL: /stream/{type}/{id}.json
-	path := strings.TrimPrefix(r.URL.Path, "/stream/")
-	path = strings.TrimSuffix(path, ".json")
-
-	parts := strings.Split(path, "/")
-	if len(parts) < 2 {
+	// Parse URL: /stream/{type}/{id}.json or /stream/{type}/{id}/{extra}.json
+	// contentType is "movie" or "series"; id is an IMDb ID (tt1234567[:s:e]) or tmdb:/tvdb: ID
+	contentType, id, extra, ok := parseResourcePath(r.URL.Path, "/stream/")
+	if !ok {
 		http.Error(w, "Invalid stream URL", http.StatusBadRequest)
 		return
 	}
 
-	contentType := parts[0] // "movie" or "series"
-	id := parts[1]          // IMDb ID (tt1234567) or TMDB ID
-
 	logger.Info("Stream request", "type", contentType, "id", id, "device", func() string {
 		if device != nil {
 			return device.Username
 		}
 		return "legacy"
 	}())
+	if len(extra) > 0 {
+		logger.Debug("Stream request extras", "id", id, "extra", extra.Encode())
+	}
 
-	// Allow time for indexer search plus NNTP validation across providers.
-	// 5s was too short: slow indexers + validation often exceeded it and returned 0 streams.
-	const streamRequestTimeout = 30 * time.Second
 	ctx, cancel := context.WithTimeout(r.Context(), streamRequestTimeout)
 	defer cancel()
+	timings := newSearchTimings()
+	ctx = withSearchTimings(ctx, timings)
+	trace := &requestTrace{}
+	ctx = withRequestTrace(ctx, trace)
 
 	logger.Trace("stream request start", "type", contentType, "id", id)
-	streams, err := s.searchAndValidate(ctx, contentType, id, device)
+	// ?refresh=1 skips the cached result and validates the next batch of candidates
+	refresh := r.URL.Query().Get("refresh") == "1"
+	key := newStreamCacheKey(device, contentType, id)
+	if refresh {
+		s.streamCache.Invalidate(key)
+	}
+	ttl := time.Duration(s.config.StreamCacheTTLSeconds) * time.Second
+	streams, err := s.streamCache.Do(ctx, key, ttl, func() ([]Stream, error) {
+		return s.searchAndValidate(ctx, contentType, id, device, refresh)
+	})
 	logger.Trace("stream request searchAndValidate returned", "count", len(streams), "err", err)
 	if err != nil {
 		logger.Error("Error searching for streams", "err", err)
@@ -281,6 +334,11 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request, device *au
 	if streams == nil {
 		streams = []Stream{}
 	}
+	timing := timings.String()
+	logger.Trace("stream request timing", "type", contentType, "id", id, "timing", timing)
+	if err != nil || len(streams) == 0 {
+		s.recordStreamFailure(device, contentType, id, trace, timing, err)
+	}
 
 	response := StreamResponse{
 		Streams: streams,
@@ -292,6 +350,7 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request, device *au
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set(timingHeader, timing)
 
 	json.NewEncoder(w).Encode(response)
 }
@@ -331,59 +390,219 @@ func addAPIKeyToDownloadURL(downloadURL string, indexers []config.IndexerConfig)
 	return downloadURL
 }
 
-// triageCandidates returns filtered+sorted candidates. Devices use their own filters and sorting;
-// admin and unauthenticated requests use global config.
+// filterConfig returns the filters that apply to a request: the device's own for regular
+// devices, the global config for admin and unauthenticated requests.
+func (s *Server) filterConfig(device *auth.Device) *config.FilterConfig {
+	if device != nil && device.Username != s.config.GetAdminUsername() {
+		return &device.Filters
+	}
+	return &s.config.Filters
+}
+
+// triageCandidates returns filtered+sorted candidates without blacklisted releases. Devices use
+// their own filters and sorting; admin and unauthenticated requests use global config.
 func (s *Server) triageCandidates(device *auth.Device, releases []*release.Release) []triage.Candidate {
+	releases = s.Blacklist().Filter(releases)
 	if device != nil && device.Username != s.config.GetAdminUsername() {
 		ts := triage.NewService(&device.Filters, device.Sorting)
+		ts.AllowUnknownSize = s.config.ZeroSizePolicy != "skip"
+		ts.SkipObfuscated = s.config.ObfuscatedPolicy == "skip"
 		return ts.Filter(releases)
 	}
 	return s.triageService.Filter(releases)
 }
 
-func (s *Server) searchAndValidate(ctx context.Context, contentType, id string, device *auth.Device) ([]Stream, error) {
+// recentlyUnhealthy reports whether an AvailNZB unhealthy report from lastUpdated should
+// still skip the release: it must be younger than grace (0 = never skip) and not from the
+// first half of the release's life, when articles may still have been propagating.
+func recentlyUnhealthy(lastUpdated time.Time, pubDate string, grace time.Duration) bool {
+	since := time.Since(lastUpdated)
+	if grace <= 0 || since > grace {
+		return false
+	}
+	if pub, ok := parsePubDate(pubDate); ok {
+		if age := time.Since(pub); age > 0 && since > age/2 {
+			return false
+		}
+	}
+	return true
+}
+
+// isArchiveCompression reports whether an NZB/AvailNZB compression type needs archive streaming.
+func isArchiveCompression(compressionType string) bool {
+	return compressionType == "rar" || compressionType == "7z"
+}
+
+// A search validates for at most validationTimeout; once it stops, in-flight validations
+// get validationDrainTimeout to unwind before the response is sent without them.
+const (
+	validationTimeout      = 60 * time.Second
+	validationDrainTimeout = time.Second
+)
+
+// validationAttempts limits how many indexer candidates one search validates (maxStreams *
+// ValidationAttemptMultiplier, at least MinValidationAttempts) to avoid excessive downloads.
+func validationAttempts(cfg *config.Config, maxStreams, candidates int) int {
+	multiplier, floor := 2, 6
+	if cfg != nil && cfg.ValidationAttemptMultiplier > 0 {
+		multiplier = cfg.ValidationAttemptMultiplier
+	}
+	if cfg != nil && cfg.MinValidationAttempts > 0 {
+		floor = cfg.MinValidationAttempts
+	}
+	return min(max(maxStreams*multiplier, floor), candidates)
+}
+
+// streamDedupe tracks the streams one search keeps. Streams match by normalized release
+// title; with byContent, streams whose NZB content is known are matched by content instead:
+// same content under another title is a duplicate, a same-titled stream with different
+// content is not.
+type streamDedupe struct {
+	byContent bool
+	titles    map[string][]string // content hashes of kept streams per normalized title ("" = not known, e.g. AvailNZB-verified)
+	hashes    map[string]bool
+	features  map[string]bool // kept feature files per release (see keepFeature)
+}
+
+func newStreamDedupe(byContent bool) *streamDedupe {
+	return &streamDedupe{byContent: byContent, titles: make(map[string][]string), hashes: make(map[string]bool), features: make(map[string]bool)}
+}
+
+// keep reports whether stream duplicates no kept stream, recording it if so.
+func (d *streamDedupe) keep(stream Stream) bool {
+	hash := ""
+	if d.byContent {
+		hash = stream.ContentHash
+	}
+	if hash != "" && d.hashes[hash] {
+		return false
+	}
+	norm := release.NormalizeTitle(stream.Release.Title)
+	if kept, ok := d.titles[norm]; ok {
+		if hash == "" {
+			return false
+		}
+		for _, h := range kept {
+			if h == "" {
+				return false
+			}
+		}
+	}
+	d.titles[norm] = append(d.titles[norm], hash)
+	if hash != "" {
+		d.hashes[hash] = true
+	}
+	return true
+}
+
+// keepFeature reports whether stream, a further feature file of the release lead was kept
+// for, duplicates no kept stream, recording it if so. Feature files of one release share
+// its title, so they are matched by release and file name instead.
+func (d *streamDedupe) keepFeature(lead, stream Stream) bool {
+	if stream.BehaviorHints == nil || stream.BehaviorHints.Filename == "" {
+		return false
+	}
+	key := release.NormalizeTitle(lead.Release.Title) + "\x00" + lead.ContentHash + "\x00" + strings.ToLower(stream.BehaviorHints.Filename)
+	if d.features[key] {
+		return false
+	}
+	d.features[key] = true
+	return true
+}
+
+// validationConcurrency is how many candidates one search validates at once:
+// ValidationConcurrency (default 6), but no more than the connections validation may use
+// across the enabled providers, so parallel validations don't just queue on the pools.
+func validationConcurrency(cfg *config.Config) int {
+	n := 6
+	if cfg == nil {
+		return n
+	}
+	if cfg.ValidationConcurrency > 0 {
+		n = cfg.ValidationConcurrency
+	}
+	conns := 0
+	for _, p := range cfg.Providers {
+		if p.Enabled != nil && *p.Enabled && p.UsedForValidation() {
+			conns += max(p.Connections-p.PlaybackReservedConnections, 1)
+		}
+	}
+	if conns > 0 {
+		n = min(n, conns)
+	}
+	return n
+}
+
+// streamID is a Stremio content ID split into its external ID and episode.
+type streamID struct {
+	IMDbID, TMDBID, TVDBID string
+	Season, Episode        string
+}
+
+// seriesKey identifies the show regardless of the episode, e.g. "tt0903747" or "tvdb:81189".
+func (sid streamID) seriesKey() string {
+	switch {
+	case sid.IMDbID != "":
+		return sid.IMDbID
+	case sid.TMDBID != "":
+		return "tmdb:" + sid.TMDBID
+	case sid.TVDBID != "":
+		return "tvdb:" + sid.TVDBID
+	}
+	return ""
+}
+
+// parseStreamID parses tt123, tmdb:123 and tvdb:123 IDs; series IDs carry
+// :SEASON:EPISODE (tt123:1:2, tmdb:123:1:2, tvdb:123:1:2). Bare numbers are TMDB IDs.
+func parseStreamID(contentType, id string) streamID {
+	var sid streamID
+	parts := strings.Split(id, ":")
+	prefix := ""
+	if parts[0] == "tmdb" || parts[0] == "tvdb" {
+		prefix, parts = parts[0], parts[1:]
+	}
+	if len(parts) == 0 {
+		return sid
+	}
+	if contentType == "series" && len(parts) >= 3 {
+		sid.Season, sid.Episode = parts[1], parts[2]
+	}
+	switch {
+	case prefix == "tvdb":
+		sid.TVDBID = parts[0]
+	case prefix == "" && strings.HasPrefix(parts[0], "tt"):
+		sid.IMDbID = parts[0]
+	default:
+		sid.TMDBID = parts[0]
+	}
+	return sid
+}
+
+// With refresh, indexer candidates already validated for this device and content are skipped
+// so the next batch is tried.
+func (s *Server) searchAndValidate(ctx context.Context, contentType, id string, device *auth.Device, refresh bool) ([]Stream, error) {
 	maxStreams := s.config.MaxStreams
 	if maxStreams <= 0 {
 		maxStreams = 6
 	}
+	timings := searchTimingsFrom(ctx)
+	trace := requestTraceFrom(ctx)
+	phaseStart := time.Now()
 
 	// 1. Build search request and content IDs
 	req := indexer.SearchRequest{
 		Limit: 1000,
 	}
 
-	searchID := id
-	if contentType == "series" && strings.Contains(id, ":") {
-		parts := strings.Split(id, ":")
-		if parts[0] == "tmdb" && len(parts) >= 4 {
-			searchID = parts[1]
-			req.Season, req.Episode = parts[2], parts[3]
-		} else if len(parts) >= 3 {
-			searchID = parts[0]
-			req.Season, req.Episode = parts[1], parts[2]
-		} else if len(parts) > 0 {
-			searchID = parts[0]
-		}
-	} else if strings.HasPrefix(id, "tmdb:") {
-		searchID = strings.TrimPrefix(id, "tmdb:")
-	}
-	if strings.HasPrefix(searchID, "tt") {
-		req.IMDbID = searchID
-	} else {
-		req.TMDBID = searchID
-	}
+	sid := parseStreamID(contentType, id)
+	req.IMDbID, req.TMDBID, req.TVDBID = sid.IMDbID, sid.TMDBID, sid.TVDBID
+	req.Season, req.Episode = sid.Season, sid.Episode
 	imdbForText := req.IMDbID
 	tmdbForText := req.TMDBID
-	if contentType == "series" && strings.Contains(id, ":") {
-		parts := strings.Split(id, ":")
-		if parts[0] == "tmdb" && len(parts) >= 2 {
-			tmdbForText = parts[1]
-		}
-	}
 	if contentType == "movie" {
-		req.Cat = "2000"
+		req.Cat = indexer.CategoryMovie
 	} else {
-		req.Cat = "5000"
+		req.Cat = indexer.CategorySeries
 		if req.IMDbID != "" && req.TVDBID == "" {
 			if s.tvdbClient != nil {
 				if tvdbID, err := s.tvdbClient.ResolveTVDBID(req.IMDbID); err == nil && tvdbID != "" {
@@ -400,6 +619,9 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 	seasonNum, _ := strconv.Atoi(req.Season)
 	episodeNum, _ := strconv.Atoi(req.Episode)
 	contentIDs := &session.AvailReportMeta{ImdbID: req.IMDbID, TvdbID: req.TVDBID, Season: seasonNum, Episode: episodeNum}
+	if contentType == "series" && req.TVDBID != "" && seasonNum > 0 && episodeNum > 0 {
+		req.AbsoluteEpisode = s.absoluteEpisode(req.TVDBID, seasonNum, episodeNum)
+	}
 	if contentType == "movie" && contentIDs.ImdbID == "" && req.TMDBID != "" && s.tmdbClient != nil {
 		if tmdbIDNum, err := strconv.Atoi(req.TMDBID); err == nil {
 			if extIDs, err := s.tmdbClient.GetExternalIDs(tmdbIDNum, "movie"); err == nil && extIDs.IMDbID != "" {
@@ -407,24 +629,46 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 			}
 		}
 	}
+	content := s.resolveContentInfo(contentType, imdbForText, tmdbForText)
+	if contentType == "series" {
+		content.SeriesID = sid.seriesKey()
+	}
+	timings.since("metadata", phaseStart)
 	// AvailNZB indexer filter: use underlying hostnames so GetReleases returns matches
 	availIndexers := s.availNZBIndexerHosts
 	logger.Debug("searchAndValidate", "imdb", req.IMDbID, "tvdb", req.TVDBID, "season", req.Season, "ep", req.Episode, "maxStreams", maxStreams)
 
 	var streams []Stream
-	seenReleaseTitles := make(map[string]bool)
+	dedupe := newStreamDedupe(s.config.DedupeByContentHash)
+	filters := s.filterConfig(device)
 
-	// addStream adds a stream if not already present (by normalized release title).
-	addStream := func(stream Stream) {
+	// allowed reports whether a stream may be listed at all.
+	allowed := func(stream Stream) bool {
 		if stream.Release == nil || stream.Release.Title == "" {
-			return
+			return false
 		}
-		norm := release.NormalizeTitle(stream.Release.Title)
-		if seenReleaseTitles[norm] {
+		// Probed metadata can move a release out of the groups triage let through
+		if group := stream.ParsedMetadata.ResolutionGroup(); !triage.AllowsResolutionGroup(filters, group) {
+			logger.Debug("Dropping stream outside allowed resolution groups", "title", stream.Release.Title, "resolution", group)
+			return false
+		}
+		return true
+	}
+	// addRelease adds the streams of one validated release (several for multi-feature
+	// releases) that are allowed and not already present (see streamDedupe). The first
+	// stream decides whether the release is a duplicate; the other feature files are
+	// then deduplicated per file.
+	addRelease := func(group []Stream) {
+		if len(group) == 0 || !allowed(group[0]) || !dedupe.keep(group[0]) {
 			return
 		}
-		seenReleaseTitles[norm] = true
-		streams = append(streams, stream)
+		streams = append(streams, group[0])
+		dedupe.keepFeature(group[0], group[0])
+		for _, stream := range group[1:] {
+			if allowed(stream) && dedupe.keepFeature(group[0], stream) {
+				streams = append(streams, stream)
+			}
+		}
 	}
 
 	// Helper function to check if we have enough streams
@@ -453,6 +697,7 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 		return true
 	}
 
+	phaseStart = time.Now()
 	var availResult *availnzb.ReleasesResult
 	if s.availClient != nil && s.availClient.BaseURL != "" && (contentIDs.ImdbID != "" || contentIDs.TvdbID != "") {
 		availResult, _ = s.availClient.GetReleases(contentIDs.ImdbID, contentIDs.TvdbID, contentIDs.Season, contentIDs.Episode, availIndexers, "")
@@ -472,6 +717,9 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 			if ct != "" && ct != "direct" && ct != "7z" && ct != "rar" {
 				continue
 			}
+			if isArchiveCompression(ct) && !s.config.AllowArchiveStreaming {
+				continue
+			}
 			availReleases = append(availReleases, rws.Release)
 		}
 		if len(availReleases) > 0 {
@@ -483,9 +731,24 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 					continue
 				}
 				rel := cand.Release
+				if ok, err := deferZeroSize(s.config.ZeroSizePolicy, rel.Size); !ok {
+					if err != nil {
+						logger.Debug("AvailNZB release without size skipped", "title", rel.Title, "policy", s.config.ZeroSizePolicy)
+						continue
+					}
+					// "resolve": download and validate the NZB like an indexer candidate to learn the size
+					group, err := s.validateCandidate(ctx, cand, device, contentIDs, content, nil, false)
+					requestTraceFrom(ctx).candidate(cand, len(group), err)
+					if err != nil {
+						logger.Debug("AvailNZB release without size failed to resolve", "title", rel.Title, "err", err)
+						continue
+					}
+					addRelease(group)
+					continue
+				}
 				downloadURL := addAPIKeyToDownloadURL(rel.Link, s.config.Indexers)
 				sessionID := fmt.Sprintf("%x", md5.Sum([]byte(rel.DetailsURL)))
-				_, err := s.sessionManager.CreateDeferredSession(
+				sess, err := s.sessionManager.CreateDeferredSession(
 					sessionID,
 					downloadURL,
 					rel,
@@ -496,14 +759,15 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 					logger.Debug("AvailNZB deferred session failed", "title", rel.Title, "err", err)
 					continue
 				}
+				offerSession(sess, device)
 				var streamURL string
 				if device != nil {
 					streamURL = fmt.Sprintf("%s/%s/play/%s", s.baseURL, device.Token, sessionID)
 				}
 				sizeGB := float64(rel.Size) / (1024 * 1024 * 1024)
 				displayTitle := rel.Title + "\n[AvailNZB]"
-				stream := buildStreamMetadata(streamURL, displayTitle, cand, sizeGB, rel.Size, rel)
-				addStream(stream)
+				stream := buildStreamMetadata(streamURL, displayTitle, cand, sizeGB, rel.Size, rel, 0, content, s.config.StreamTitleTemplate)
+				addRelease([]Stream{stream})
 			}
 			logger.Debug("AvailNZB phase done", "streams", len(streams))
 
@@ -518,19 +782,40 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 						knownURLs[rel.DetailsURL] = true
 					}
 				}
-				go s.warmAvailNZBCache(context.Background(), req, contentIDs, knownURLs)
+				// Warming only exists to report results, so skip it when reporting is off
+				if s.availReportEnabled(device) {
+					go s.warmAvailNZBCache(context.Background(), req, contentIDs, knownURLs)
+				}
 			}
 		}
 	}
 
+	timings.since("avail", phaseStart)
+
 	// 3. Indexers: search, triage, validate until we have enough streams
-	var indexerCandidatesCount, indexerAttempted int
+	var indexerCandidatesCount, indexerAttempted, indexerSkipped int
 	if !hasEnoughStreams(streams) {
+		phaseStart = time.Now()
 		indexerReleases, err := search.RunIndexerSearches(s.indexer, s.tmdbClient, req, contentType, contentIDs, imdbForText, tmdbForText)
+		timings.since("search", phaseStart)
 		if err != nil {
-			return nil, err
+			// Every indexer failed; AvailNZB streams found above are still worth returning
+			if len(streams) == 0 {
+				return nil, err
+			}
+			logger.Warn("Indexer search failed, returning AvailNZB streams only", "err", err)
 		}
+		phaseStart = time.Now()
 		candidates := s.triageCandidates(device, indexerReleases)
+		attemptKey := newStreamCacheKey(device, contentType, id)
+		if refresh {
+			candidates, indexerSkipped = s.attempts.skipAttempted(attemptKey, candidates)
+			logger.Debug("Refresh: skipping previously validated candidates", "skipped", indexerSkipped)
+		}
+		if n := s.config.MaxCandidatesAfterTriage; n > 0 && len(candidates) > n {
+			logger.Debug("Capping indexer candidates after triage", "candidates", len(candidates), "max", n)
+			candidates = candidates[:n]
+		}
 		indexerCandidatesCount = len(candidates)
 		logger.Debug("Indexer candidates after triage", "count", indexerCandidatesCount)
 
@@ -540,40 +825,56 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 				ourProviders[strings.ToLower(h)] = true
 			}
 			cachedAvailable := make(map[string]bool)
-			cachedUnhealthyForUs := make(map[string]bool)
+			cachedSkip := make(map[string]bool)
+			grace := time.Duration(s.config.UnhealthyGracePeriodHours) * time.Hour
+			// AvailNZB releases carry no pubDate; the indexer's copy says how old the release is
+			pubDates := make(map[string]string, len(candidates))
+			for _, c := range candidates {
+				if c.Release != nil {
+					pubDates[c.Release.DetailsURL] = c.Release.PubDate
+				}
+			}
 			for _, rws := range availResult.Releases {
 				if rws == nil || rws.Release == nil {
 					continue
 				}
 				detailsURL := rws.Release.DetailsURL
-				if rws.Available {
+				if isArchiveCompression(strings.ToLower(rws.CompressionType)) && !s.config.AllowArchiveStreaming {
+					// Known archive: skip before downloading the NZB
+					cachedSkip[detailsURL] = true
+				} else if rws.Available {
 					cachedAvailable[detailsURL] = true
 				} else if len(ourProviders) > 0 && len(rws.Summary) > 0 {
 					ourReported, ourHealthy := 0, 0
+					var lastReport time.Time
 					for host, status := range rws.Summary {
 						if ourProviders[strings.ToLower(host)] {
 							ourReported++
 							if status.Healthy {
 								ourHealthy++
 							}
+							if status.LastUpdated.After(lastReport) {
+								lastReport = status.LastUpdated
+							}
 						}
 					}
-					if ourReported > 0 && ourHealthy == 0 {
-						cachedUnhealthyForUs[detailsURL] = true
+					if ourReported > 0 && ourHealthy == 0 && recentlyUnhealthy(lastReport, pubDates[detailsURL], grace) {
+						cachedSkip[detailsURL] = true
 					}
 				}
 			}
-			// Filter out indexer candidates that AvailNZB marks as unhealthy for our providers
-			if len(cachedUnhealthyForUs) > 0 {
+			// Filter out indexer candidates that AvailNZB marks as unhealthy for our providers (or as
+			// archives when archive streaming is disabled)
+			if len(cachedSkip) > 0 {
 				before := len(candidates)
 				filtered := candidates[:0]
 				for _, c := range candidates {
-					if c.Release == nil || !cachedUnhealthyForUs[c.Release.DetailsURL] {
+					if c.Release == nil || !cachedSkip[c.Release.DetailsURL] {
 						filtered = append(filtered, c)
 					}
 				}
 				candidates = filtered
-				logger.Debug("Filtered candidates by AvailNZB unhealthy (our providers)", "removed", before-len(candidates), "remaining", len(candidates))
+				logger.Debug("Filtered candidates by AvailNZB (unhealthy for our providers or archive)", "removed", before-len(candidates), "remaining", len(candidates))
 			}
 			if len(cachedAvailable) > 0 {
 				sort.SliceStable(candidates, func(i, j int) bool {
@@ -584,125 +885,134 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 			}
 		}
 
-		// Validate candidates in parallel until we have enough streams
-		// Limit validation attempts to maxStreams * 2 to avoid excessive downloads
-		maxAttempts := maxStreams * 2
-		if maxAttempts < 6 {
-			maxAttempts = 6
-		}
-		if maxAttempts > len(candidates) {
-			maxAttempts = len(candidates)
-		}
-
-		sem := make(chan struct{}, 6)
-		resultChan := make(chan Stream, maxAttempts)
-		var mu sync.Mutex
-		attempted := 0
-		validationCtx, cancel := context.WithCancel(ctx)
-		defer cancel()
-		var wg sync.WaitGroup
-
-		for _, candidate := range candidates {
-			mu.Lock()
-			if attempted >= maxAttempts {
-				mu.Unlock()
-				break
-			}
-			attempted++
-			mu.Unlock()
-
-			wg.Add(1)
-			go func(cand triage.Candidate) {
-				defer wg.Done()
-				select {
-				case sem <- struct{}{}:
-					defer func() { <-sem }()
-				case <-validationCtx.Done():
-					return
-				}
-
-				stream, err := s.validateCandidate(validationCtx, cand, device, contentIDs)
-				if err != nil {
-					logger.Trace("validateCandidate failed", "title", cand.Release.Title, "err", err)
-					return
+		timings.since("triage", phaseStart)
+		phaseStart = time.Now()
+
+		// validateBatch validates candidates in parallel until there are enough streams, the
+		// time runs out or maxAttempts were launched, and returns the launched candidates.
+		// cachedOnly accepts only candidates AvailNZB confirms healthy, without downloading.
+		var confirmedMu sync.Mutex
+		confirmed := make(map[*release.Release]bool)
+		checks := newAvailChecks(availResult)
+		validateBatch := func(candidates []triage.Candidate, maxAttempts int, cachedOnly bool) []triage.Candidate {
+			sem := make(chan struct{}, validationConcurrency(s.config))
+			resultChan := make(chan []Stream, maxAttempts)
+			validationCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			var wg sync.WaitGroup
+			var launched []triage.Candidate
+
+			for _, candidate := range candidates {
+				if len(launched) >= maxAttempts {
+					break
 				}
-				resultChan <- stream
-			}(candidate)
-		}
+				launched = append(launched, candidate)
 
-		done := make(chan struct{})
-		go func() {
-			wg.Wait()
-			close(resultChan)
-			close(done)
-		}()
+				wg.Add(1)
+				go func(cand triage.Candidate) {
+					defer wg.Done()
+					select {
+					case sem <- struct{}{}:
+						defer func() { <-sem }()
+					case <-validationCtx.Done():
+						return
+					}
 
-		timeout := time.After(60 * time.Second)
-		for {
-			select {
-			case stream, ok := <-resultChan:
-				if !ok {
-					goto doneCollect
-				}
-				addStream(stream)
-				// Sort streams by triage score before checking (limitStreamsPerResolution expects sorted input)
-				sort.Slice(streams, func(i, j int) bool {
-					return streamScore(streams[i]) > streamScore(streams[j])
-				})
-				// Check if we have enough streams after each addition
-				if hasEnoughStreams(streams) {
-					cancel() // Stop validating more, but collect what's already validated
-					// Drain remaining streams
-					for {
-						select {
-						case stream, ok := <-resultChan:
-							if !ok {
-								goto doneCollect
-							}
-							streams = append(streams, stream)
-						default:
-							goto doneCollect
+					releaseStreams, err := s.validateCandidate(validationCtx, cand, device, contentIDs, content, checks, cachedOnly)
+					trace.candidate(cand, len(releaseStreams), err)
+					if err != nil {
+						logger.Trace("validateCandidate failed", "title", cand.Release.Title, "err", err)
+						if validationCtx.Err() == nil && !errors.Is(err, errNotCached) {
+							s.validationsFailed.Add(1)
 						}
+						return
 					}
-				}
-			case <-timeout:
-				cancel()
-				// Drain remaining streams
-				for {
-					select {
-					case stream, ok := <-resultChan:
-						if !ok {
-							goto doneCollect
-						}
-						streams = append(streams, stream)
-					default:
-						goto doneCollect
+					s.validationsOK.Add(1)
+					if cachedOnly {
+						confirmedMu.Lock()
+						confirmed[cand.Release] = true
+						confirmedMu.Unlock()
+					}
+					resultChan <- releaseStreams
+				}(candidate)
+			}
+
+			// Each worker sends at most once and resultChan holds maxAttempts results, so no
+			// worker blocks on its send after collection stops; resultChan closes once all
+			// workers have returned.
+			go func() {
+				wg.Wait()
+				close(resultChan)
+			}()
+
+			timeout := time.NewTimer(validationTimeout)
+			defer timeout.Stop()
+		collect:
+			for {
+				select {
+				case releaseStreams, ok := <-resultChan:
+					if !ok {
+						return launched
+					}
+					addRelease(releaseStreams)
+					// Sort streams by triage score before checking (limitStreamsPerResolution expects sorted input)
+					sort.Slice(streams, func(i, j int) bool {
+						return streamScore(streams[i]) > streamScore(streams[j])
+					})
+					if hasEnoughStreams(streams) {
+						break collect
 					}
+				case <-timeout.C:
+					break collect
+				case <-validationCtx.Done():
+					// Client disconnected or the request timed out
+					break collect
 				}
-			case <-validationCtx.Done():
-				// Drain remaining streams
-				for {
-					select {
-					case stream, ok := <-resultChan:
-						if !ok {
-							goto doneCollect
-						}
-						streams = append(streams, stream)
-					default:
-						goto doneCollect
+			}
+
+			// Stop the remaining validations and wait for them to unwind: they return promptly
+			// once validationCtx is cancelled. Results that complete meanwhile are kept.
+			cancel()
+			drain := time.NewTimer(validationDrainTimeout)
+			defer drain.Stop()
+			for {
+				select {
+				case releaseStreams, ok := <-resultChan:
+					if !ok {
+						return launched
 					}
+					addRelease(releaseStreams)
+				case <-drain.C:
+					logger.Warn("Some validation goroutines are still running after cancellation")
+					return launched
 				}
 			}
 		}
-	doneCollect:
 
-		select {
-		case <-done:
-		case <-time.After(1 * time.Second):
-			logger.Warn("Some validation goroutines may still be running")
+		// Validate candidates in parallel until we have enough streams
+		var launched []triage.Candidate
+		if s.config.PreferCachedOnly {
+			// Only streams AvailNZB already confirms; nothing is downloaded in this pass
+			checked := validateBatch(candidates, validationAttempts(s.config, maxStreams, len(candidates)), true)
+			confirmedMu.Lock()
+			logger.Debug("Prefer cached: AvailNZB-confirmed indexer candidates", "checked", len(checked), "confirmed", len(confirmed), "streams", len(streams))
+			var remaining []triage.Candidate
+			for _, c := range candidates {
+				if !confirmed[c.Release] {
+					remaining = append(remaining, c)
+				}
+			}
+			confirmedMu.Unlock()
+			if !s.config.PreferCachedStrict && !hasEnoughStreams(streams) {
+				launched = validateBatch(remaining, validationAttempts(s.config, maxStreams, len(remaining)), false)
+			}
+		} else {
+			launched = validateBatch(candidates, validationAttempts(s.config, maxStreams, len(candidates)), false)
 		}
 
-		indexerAttempted = attempted
+		timings.since("validation", phaseStart)
+		indexerAttempted = len(launched)
+		s.attempts.record(attemptKey, launched, !refresh)
 	}
 
 	// Final sort all streams by triage score (respects user's priority config)
@@ -725,16 +1035,21 @@ func (s *Server) searchAndValidate(ctx context.Context, contentType, id string,
 		logger.Debug("After maxStreams capping (per-resolution disabled)", "count", len(streams))
 	}
 
-	// Placeholder when we have 0 streams but validated only a subset of candidates (e.g. 12/193)
+	// Placeholder when we have 0 streams but validated only a subset of candidates (e.g. 12/193).
+	// Playing it validates the next batch (/stream-refresh) and starts the first stream found.
 	if len(streams) == 0 && indexerAttempted > 0 && indexerCandidatesCount > indexerAttempted {
-		errorVideoURL := strings.TrimSuffix(s.baseURL, "/") + "/error/failure.mp4"
+		placeholderURL := s.errorVideoURL()
+		if device != nil {
+			placeholderURL = fmt.Sprintf("%s/%s/stream-refresh/%s/%s", strings.TrimSuffix(s.baseURL, "/"), device.Token, contentType, id)
+		}
+		tried := indexerSkipped + indexerAttempted
 		placeholder := Stream{
-			URL:   errorVideoURL,
+			URL:   placeholderURL,
 			Name:  "StreamNZB⚡",
-			Title: fmt.Sprintf("0/%d OK\n%d total\nNo streams from first batch.\nRetry to validate more.", indexerAttempted, indexerCandidatesCount),
+			Title: fmt.Sprintf("0/%d OK\n%d total\nNo streams yet.\nPlay to validate the next batch.", tried, indexerSkipped+indexerCandidatesCount),
 		}
 		streams = []Stream{placeholder}
-		logger.Info("Adding placeholder stream", "attempted", indexerAttempted, "candidates", indexerCandidatesCount)
+		logger.Info("Adding placeholder stream", "attempted", tried, "candidates", indexerSkipped+indexerCandidatesCount)
 	}
 
 	logger.Info("Returning validated streams", "count", len(streams))
@@ -751,7 +1066,8 @@ func (s *Server) warmAvailNZBCache(ctx context.Context, req indexer.SearchReques
 	if len(providerHosts) == 0 {
 		return
 	}
-	searchResp, err := s.indexer.Search(req)
+	// Usually answered from the search cache when the indexers were searched for this request
+	searchResp, err := search.CachedSearch(s.indexer, req)
 	if err != nil {
 		logger.Debug("AvailNZB cache warm: search failed", "err", err)
 		return
@@ -787,7 +1103,7 @@ func (s *Server) warmAvailNZBCache(ctx context.Context, req indexer.SearchReques
 		if err != nil {
 			continue
 		}
-		if len(nzbParsed.GetContentFiles()) == 0 {
+		if len(nzbParsed.GetContentFiles()) == 0 || nzbParsed.BrokenReason() != "" {
 			streamSize := nzbParsed.TotalSize()
 			meta := availnzb.ReportMeta{ReleaseName: rel.Title, Size: streamSize, CompressionType: nzbParsed.CompressionType()}
 			meta.ImdbID = contentIDs.ImdbID
@@ -795,9 +1111,9 @@ func (s *Server) warmAvailNZBCache(ctx context.Context, req indexer.SearchReques
 			meta.Season = contentIDs.Season
 			meta.Episode = contentIDs.Episode
 			for _, providerHost := range providerHosts {
-				_ = s.availClient.ReportAvailability(detailsURL, providerHost, false, meta)
+				s.availClient.QueueReport(detailsURL, providerHost, false, meta)
 			}
-			logger.Debug("AvailNZB cache warm: no content files, reported unavailable", "title", rel.Title)
+			logger.Debug("AvailNZB cache warm: no usable content, reported unavailable", "title", rel.Title)
 			continue
 		}
 		streamSize := nzbParsed.TotalSize()
@@ -809,23 +1125,108 @@ func (s *Server) warmAvailNZBCache(ctx context.Context, req indexer.SearchReques
 		// Check each provider and report all results to AvailNZB
 		for _, providerHost := range providerHosts {
 			result := s.validator.ValidateNZBSingleProviderExtended(ctx, nzbParsed, providerHost)
-			available := result.Error == nil && result.Available
-			if err := s.availClient.ReportAvailability(detailsURL, result.Host, available, meta); err != nil {
-				logger.Debug("AvailNZB cache warm: report failed", "title", rel.Title, "provider", providerHost, "err", err)
-				continue
-			}
-			logger.Debug("AvailNZB cache warm: reported", "title", rel.Title, "provider", providerHost, "available", available)
+			available := result.IsComplete()
+			s.availClient.QueueReport(detailsURL, result.Host, available, meta)
+			logger.Debug("AvailNZB cache warm: report queued", "title", rel.Title, "provider", providerHost, "available", available)
 		}
 		return
 	}
 	logger.Debug("AvailNZB cache warm: no new candidate to validate")
 }
 
-// validateCandidate validates a single candidate and returns a stream
-func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, device *auth.Device, contentIDs *session.AvailReportMeta) (Stream, error) {
+// availabilityTitle marks the title of a stream accepted via MinAvailabilityRatio with
+// its completion: some sampled articles are missing, so expect glitches.
+func availabilityTitle(title string, completion float64) string {
+	if completion >= 1.0 {
+		return title
+	}
+	return fmt.Sprintf("⚠️ %.0f%% available", completion*100) + "\n" + title
+}
+
+// errNotCached rejects candidates in the cached-only pass that AvailNZB does not confirm.
+var errNotCached = errors.New("not confirmed available by AvailNZB")
+
+// availChecks is what one search learned from AvailNZB, shared by its validation passes:
+// the details URLs AvailNZB lists as direct (non-archive) releases, and the status lookups
+// already made, so a candidate is looked up at most once per search.
+type availChecks struct {
+	direct map[string]bool
+
+	mu     sync.Mutex
+	status map[string]availStatus
+}
+
+// availStatus is the outcome of one AvailNZB status lookup.
+type availStatus struct {
+	healthy     bool
+	lastUpdated time.Time
+	err         error
+}
+
+func newAvailChecks(result *availnzb.ReleasesResult) *availChecks {
+	c := &availChecks{direct: make(map[string]bool), status: make(map[string]availStatus)}
+	if result != nil {
+		for _, rws := range result.Releases {
+			if rws != nil && rws.Release != nil && strings.EqualFold(rws.CompressionType, "direct") {
+				c.direct[rws.Release.DetailsURL] = true
+			}
+		}
+	}
+	return c
+}
+
+// isDirect reports whether AvailNZB lists detailsURL as a release without archives.
+func (c *availChecks) isDirect(detailsURL string) bool {
+	return c != nil && c.direct[detailsURL]
+}
+
+// checkPreDownload asks AvailNZB whether detailsURL is healthy for providerHosts, reusing
+// the answer checks already holds. checks may be nil.
+func (s *Server) checkPreDownload(checks *availChecks, detailsURL string, providerHosts []string) (bool, time.Time, error) {
+	if checks != nil {
+		checks.mu.Lock()
+		st, ok := checks.status[detailsURL]
+		checks.mu.Unlock()
+		if ok {
+			return st.healthy, st.lastUpdated, st.err
+		}
+	}
+	healthy, lastUpdated, _, err := s.availClient.CheckPreDownload(detailsURL, providerHosts)
+	if checks != nil {
+		checks.mu.Lock()
+		checks.status[detailsURL] = availStatus{healthy: healthy, lastUpdated: lastUpdated, err: err}
+		checks.mu.Unlock()
+	}
+	return healthy, lastUpdated, err
+}
+
+// errZeroSize rejects releases listed without a size under ZeroSizePolicy "skip".
+var errZeroSize = errors.New("indexer did not provide a size")
+
+// deferZeroSize reports whether a release of size bytes may still become a deferred
+// session under policy: "defer" (the default) keeps it, "resolve" downloads the NZB
+// first to learn the real size, and "skip" drops it with errZeroSize.
+func deferZeroSize(policy string, size int64) (bool, error) {
+	if size > 0 {
+		return true, nil
+	}
+	switch policy {
+	case "skip":
+		return false, errZeroSize
+	case "resolve":
+		return false, nil
+	}
+	return true, nil
+}
+
+// validateCandidate validates a single candidate and returns its streams. checks (may be
+// nil) shares AvailNZB lookups between the passes of one search. With cachedOnly, only
+// candidates AvailNZB reports healthy are accepted (as lazy sessions); others fail with
+// errNotCached instead of being downloaded.
+func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, device *auth.Device, contentIDs *session.AvailReportMeta, content contentInfo, checks *availChecks, cachedOnly bool) ([]Stream, error) {
 	rel := cand.Release
 	if rel == nil {
-		return Stream{}, fmt.Errorf("candidate has no release")
+		return nil, fmt.Errorf("candidate has no release")
 	}
 	logger.Trace("validateCandidate start", "title", rel.Title)
 
@@ -843,21 +1244,37 @@ func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, d
 	releaseDetailsURL := rel.DetailsURL
 	if releaseDetailsURL != "" && len(providerHosts) > 0 && s.availClient != nil && s.availClient.BaseURL != "" {
 		logger.Trace("validateCandidate: CheckPreDownload start", "title", rel.Title)
-		isHealthy, lastUpdated, _, err := s.availClient.CheckPreDownload(releaseDetailsURL, providerHosts)
+		isHealthy, lastUpdated, err := s.checkPreDownload(checks, releaseDetailsURL, providerHosts)
 		logger.Trace("validateCandidate: CheckPreDownload done", "title", rel.Title, "skipValidation", err == nil && isHealthy, "err", err)
 		if err == nil {
 			if isHealthy {
-				skipValidation = true
-			} else {
-				if time.Since(lastUpdated) <= 24*time.Hour {
-					return Stream{}, fmt.Errorf("recently reported unhealthy")
-				}
+				// Without archive streaming the NZB must be inspected, so no lazy session,
+				// unless AvailNZB already knows the release has no archives
+				skipValidation = s.config.AllowArchiveStreaming || checks.isDirect(releaseDetailsURL)
+			} else if recentlyUnhealthy(lastUpdated, rel.PubDate, time.Duration(s.config.UnhealthyGracePeriodHours)*time.Hour) {
+				return nil, fmt.Errorf("recently reported unhealthy")
 			}
 		}
 	}
 
-	var sessionID string
+	if skipValidation {
+		// Players need a Content-Length, which a deferred session only has from the indexer
+		var err error
+		if skipValidation, err = deferZeroSize(s.config.ZeroSizePolicy, rel.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	if cachedOnly && !skipValidation {
+		return nil, errNotCached
+	}
+
+	report := s.availReportEnabled(device)
+	var sessionID, contentHash string
 	var streamSize int64
+	var providers int
+	var features []*nzb.FileInfo
+	completion := 1.0
 
 	if skipValidation {
 		sessionID = fmt.Sprintf("%x", md5.Sum([]byte(rel.GUID)))
@@ -876,7 +1293,7 @@ func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, d
 				idx = i
 			}
 		}
-		_, err := s.sessionManager.CreateDeferredSession(
+		sess, err := s.sessionManager.CreateDeferredSession(
 			sessionID,
 			rel.Link, // NZB download URL (indexer results typically have apikey)
 			rel,
@@ -885,8 +1302,14 @@ func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, d
 		)
 		logger.Trace("validateCandidate: CreateDeferredSession done", "title", rel.Title, "err", err)
 		if err != nil {
-			return Stream{}, fmt.Errorf("failed to create deferred session: %w", err)
+			return nil, fmt.Errorf("failed to create deferred session: %w", err)
 		}
+		for _, alt := range rel.Alternates {
+			if altIdx, ok := alt.SourceIndexer.(indexer.Indexer); ok {
+				sess.AddAlternateDownload(alt.Link, altIdx, alt.Indexer)
+			}
+		}
+		offerSession(sess, device)
 	} else {
 		// IMMEDIATE - Download and validate (30s)
 		logger.Debug("Downloading NZB for validation", "title", rel.Title)
@@ -906,15 +1329,26 @@ func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, d
 		cancel()
 
 		if err != nil {
-			return Stream{}, fmt.Errorf("failed to download NZB: %w", err)
+			var notNZB *indexer.NotNZBError
+			if errors.As(err, &notNZB) {
+				logger.Warn("Indexer returned a non-NZB response, skipping candidate", "title", rel.Title, "indexer", notNZB.Indexer, "status", notNZB.StatusCode, "body", notNZB.Snippet)
+			}
+			return nil, fmt.Errorf("failed to download NZB: %w", err)
 		}
 		// Parse NZB
 		nzbParsed, err := nzb.Parse(bytes.NewReader(nzbData))
 		if err != nil {
-			return Stream{}, fmt.Errorf("failed to parse NZB: %w", err)
+			return nil, fmt.Errorf("failed to parse NZB: %w", err)
 		}
 
+		var unusable error
 		if len(nzbParsed.GetContentFiles()) == 0 {
+			unusable = errors.New("no content files found in NZB")
+		} else if reason := nzbParsed.BrokenReason(); reason != "" {
+			unusable = fmt.Errorf("%w: %s", nzb.ErrBrokenRelease, reason)
+			logger.Info("Skipping broken release", "title", rel.Title, "reason", reason)
+		}
+		if unusable != nil {
 			compressionType := nzbParsed.CompressionType()
 			reportMeta := availnzb.ReportMeta{ReleaseName: rel.Title, Size: nzbParsed.TotalSize(), CompressionType: compressionType}
 			if contentIDs != nil {
@@ -923,25 +1357,29 @@ func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, d
 				reportMeta.Season = contentIDs.Season
 				reportMeta.Episode = contentIDs.Episode
 			}
-			if (reportMeta.ImdbID != "" || reportMeta.TvdbID != "") && !release.IsPrivateReleaseURL(rel.DetailsURL) && s.availClient != nil {
+			if report && (reportMeta.ImdbID != "" || reportMeta.TvdbID != "") && !release.IsPrivateReleaseURL(rel.DetailsURL) && s.availClient != nil {
 				for _, providerHost := range s.validator.GetProviderHosts() {
-					host := providerHost
-					go func() {
-						_ = s.availClient.ReportAvailability(rel.DetailsURL, host, false, reportMeta)
-					}()
+					s.availClient.QueueReport(rel.DetailsURL, providerHost, false, reportMeta)
 				}
 			}
-			return Stream{}, fmt.Errorf("no content files found in NZB")
+			return nil, unusable
+		}
+
+		if !s.config.AllowArchiveStreaming {
+			if ct := nzbParsed.CompressionType(); isArchiveCompression(ct) {
+				return nil, fmt.Errorf("archive streaming disabled (%s release)", ct)
+			}
+		}
+		obfuscated := nzbParsed.IsObfuscated()
+		if obfuscated && s.config.ObfuscatedPolicy == "skip" {
+			return nil, fmt.Errorf("obfuscated release skipped")
 		}
 
 		streamSize = nzbParsed.TotalSize()
 		sessionID = nzbParsed.Hash()
+		contentHash = nzbParsed.CalculateID()
 
 		// Validate availability
-		logger.Trace("validateCandidate: ValidateNZB start", "title", rel.Title)
-		validationResults := s.validator.ValidateNZB(ctx, nzbParsed)
-		logger.Trace("validateCandidate: ValidateNZB done", "title", rel.Title, "results", len(validationResults))
-
 		compressionType := nzbParsed.CompressionType()
 		reportMeta := availnzb.ReportMeta{ReleaseName: rel.Title, Size: streamSize, CompressionType: compressionType}
 		if contentIDs != nil {
@@ -950,64 +1388,217 @@ func (s *Server) validateCandidate(ctx context.Context, cand triage.Candidate, d
 			reportMeta.Season = contentIDs.Season
 			reportMeta.Episode = contentIDs.Episode
 		}
-		shouldReport := (reportMeta.ImdbID != "" || reportMeta.TvdbID != "") && !release.IsPrivateReleaseURL(rel.DetailsURL)
+		shouldReport := report && (reportMeta.ImdbID != "" || reportMeta.TvdbID != "") && !release.IsPrivateReleaseURL(rel.DetailsURL)
+
+		// Stop at the first provider with the whole release; the others still finish in the
+		// background when their results are reported to AvailNZB
+		var reportRest func(map[string]*validation.ValidationResult)
+		if availClient := s.availClient; shouldReport && availClient != nil {
+			detailsURL := rel.DetailsURL
+			reportRest = func(results map[string]*validation.ValidationResult) {
+				for _, result := range results {
+					if !errors.Is(result.Error, context.DeadlineExceeded) {
+						availClient.QueueReport(detailsURL, result.Host, result.IsComplete(), reportMeta)
+					}
+				}
+			}
+		}
+		logger.Trace("validateCandidate: ValidateNZB start", "title", rel.Title)
+		validationResults := s.validator.ValidateNZBEarly(ctx, nzbParsed, reportRest)
+		logger.Trace("validateCandidate: ValidateNZB done", "title", rel.Title, "results", len(validationResults))
 
 		if len(validationResults) == 0 {
 			if shouldReport && s.availClient != nil {
 				for _, providerHost := range s.validator.GetProviderHosts() {
-					host := providerHost
-					go func() {
-						_ = s.availClient.ReportAvailability(rel.DetailsURL, host, false, reportMeta)
-					}()
+					s.availClient.QueueReport(rel.DetailsURL, providerHost, false, reportMeta)
 				}
 			}
-			return Stream{}, fmt.Errorf("no valid providers")
+			return nil, fmt.Errorf("no valid providers")
+		}
+
+		for _, result := range validationResults {
+			if result.Corrupt {
+				logger.Info("Provider serves corrupt data for release (yEnc CRC mismatch)", "title", rel.Title, "provider", result.Provider, "err", result.Error)
+			}
 		}
 
 		// Report each provider's result to AvailNZB (available=true when that provider has content, false otherwise)
 		if shouldReport && s.availClient != nil {
-			go func() {
-				for _, result := range validationResults {
-					available := result.Error == nil && result.Available
-					_ = s.availClient.ReportAvailability(rel.DetailsURL, result.Host, available, reportMeta)
-				}
-			}()
+			for _, result := range validationResults {
+				s.availClient.QueueReport(rel.DetailsURL, result.Host, result.IsComplete(), reportMeta)
+			}
 		}
 
 		bestResult := validation.GetBestProvider(validationResults)
 		if bestResult == nil {
-			return Stream{}, fmt.Errorf("no best provider")
+			for _, result := range validationResults {
+				if errors.Is(result.Error, unpack.ErrEncryptedArchive) {
+					return nil, result.Error
+				}
+			}
+			return nil, fmt.Errorf("no best provider")
+		}
+		completion = bestResult.Completion()
+		for _, result := range validationResults {
+			if result.Available {
+				providers++
+			}
+		}
+
+		// Movies bundling several features get a session per file (not for episodes:
+		// there several video files are usually other episodes of a pack)
+		if contentIDs == nil || contentIDs.Episode == 0 {
+			features = featureFiles(nzbParsed)
 		}
 
 		// Store NZB in session manager
 		logger.Trace("validateCandidate: CreateSession start", "title", rel.Title)
-		_, err = s.sessionManager.CreateSession(sessionID, nzbParsed, rel, contentIDs)
+		if len(features) == 0 {
+			var sess *session.Session
+			sess, err = s.sessionManager.CreateSessionWithProvider(sessionID, nzbParsed, rel, contentIDs, bestResult.Host)
+			if err == nil && s.config.VerifyMediaMetadata && nzbParsed.CompressionType() == "direct" {
+				cand = verifyMediaMetadata(sess.Files, cand)
+			}
+			if err == nil && obfuscated && s.config.ObfuscatedPolicy == "probe" {
+				kind, name, ok := unpack.ProbeObfuscated(sess.Files)
+				if !ok {
+					s.sessionManager.DeleteSession(sessionID)
+					return nil, fmt.Errorf("obfuscated release: no media or archive signature in %s", name)
+				}
+				logger.Debug("Obfuscated release probed", "title", rel.Title, "file", name, "kind", kind)
+			}
+			if err == nil {
+				offerSession(sess, device)
+			}
+		}
+		for i, info := range features {
+			var sess *session.Session
+			if sess, err = s.sessionManager.CreateSessionWithProvider(featureSessionID(sessionID, i), singleFileNZB(nzbParsed, info), rel, contentIDs, bestResult.Host); err != nil {
+				break
+			}
+			offerSession(sess, device)
+		}
 		logger.Trace("validateCandidate: CreateSession done", "title", rel.Title, "err", err)
 		if err != nil {
-			return Stream{}, fmt.Errorf("failed to create session: %w", err)
+			return nil, fmt.Errorf("failed to create session: %w", err)
 		}
 	}
 
 	// Create stream URL (always include device token if device is present)
 	// Admin and all devices need token in URL for proper routing
-	var streamURL string
 	token := ""
 	if device != nil {
 		token = device.Token
-		// Include device token in URL path: /{token}/play/{sessionID}
-		streamURL = fmt.Sprintf("%s/%s/play/%s", s.baseURL, token, sessionID)
 	}
-	sizeGB := float64(streamSize) / (1024 * 1024 * 1024)
+	newStream := func(sessionID, filename string, size int64) Stream {
+		var streamURL string
+		if device != nil {
+			// Include device token in URL path: /{token}/play/{sessionID}
+			streamURL = fmt.Sprintf("%s/%s/play/%s", s.baseURL, token, sessionID)
+		}
+		sizeGB := float64(size) / (1024 * 1024 * 1024)
+
+		// Build stream metadata
+		stream := buildStreamMetadata(streamURL, filename, cand, sizeGB, size, rel, providers, content, s.config.StreamTitleTemplate)
+		stream.Title = availabilityTitle(stream.Title, completion)
+		if link := s.nfoLink(token, sessionID, rel); link != "" {
+			stream.Title += "\n📄 NFO: " + link
+		}
+		return stream
+	}
+
+	if len(features) == 0 {
+		stream := newStream(sessionID, rel.Title, streamSize)
+		stream.ContentHash = contentHash
+		logger.Debug("Created stream", "name", stream.Name, "url", stream.URL)
+		return []Stream{stream}, nil
+	}
+
+	// One entry per feature file, largest first and kept next to each other
+	streams := make([]Stream, 0, len(features))
+	for i, info := range features {
+		stream := newStream(featureSessionID(sessionID, i), info.Filename, info.Size)
+		stream.Title = featureTitleLine(info, i, len(features)) + "\n" + stream.Title
+		stream.Score -= i
+		if i == 0 {
+			stream.ContentHash = contentHash
+		}
+		streams = append(streams, stream)
+	}
+	logger.Debug("Created per-file streams", "title", rel.Title, "files", len(streams))
+	return streams, nil
+}
+
+// errInvalidFileSelection rejects a ?file= or ?name= that names no file of the session.
+var errInvalidFileSelection = errors.New("invalid file selection")
 
-	// Build stream metadata
-	stream := buildStreamMetadata(streamURL, rel.Title, cand, sizeGB, streamSize, rel)
+// selectPlayFile returns the index in files of the content file requested with
+// ?file={index} or ?name={filename}, or -1 when the request lets GetMediaStream choose.
+func selectPlayFile(query url.Values, files []*loader.File) (int, error) {
+	if v := query.Get("file"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(files) {
+			return -1, fmt.Errorf("%w: file index %q, session has %d files", errInvalidFileSelection, v, len(files))
+		}
+		return i, nil
+	}
+	if name := query.Get("name"); name != "" {
+		for i, f := range files {
+			if strings.EqualFold(unpack.ExtractFilename(f.Name()), name) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("%w: no file named %q", errInvalidFileSelection, name)
+	}
+	return -1, nil
+}
 
-	logger.Debug("Created stream", "name", stream.Name, "url", stream.URL)
-	return stream, nil
+// openPlayStream opens the media stream of files, the session's content or only file
+// selected (see selectPlayFile), from the cached blueprint when there is one, and caches
+// the blueprint of a fresh scan. A cached blueprint whose stream starts corrupt (stale,
+// e.g. from a partial scan) is discarded and the files are scanned again, once per
+// session and selection. Direct files with their seek index at the end get the tail
+// prefetched (see unpack.PrewarmTrailingIndex).
+func openPlayStream(ctx context.Context, sess *session.Session, files []*loader.File, selected int) (unpack.ReadSeekCloser, string, int64, error) {
+	bp := sess.Blueprint
+	if selected >= 0 {
+		bp = sess.FileBlueprint(selected, files[0])
+	}
+	stream, name, size, newBP, err := unpack.GetMediaStream(ctx, files, bp)
+	if err == nil && bp != nil {
+		if headErr := unpack.CheckStreamHead(stream, name); headErr != nil {
+			stream.Close()
+			if !sess.DiscardBlueprint(selected) {
+				return nil, "", 0, headErr
+			}
+			logger.Warn("Cached blueprint produced a corrupt stream, rescanning", "session", sess.ID, "err", headErr)
+			bp = nil
+			stream, name, size, newBP, err = unpack.GetMediaStream(ctx, files, nil)
+			if err == nil {
+				if headErr = unpack.CheckStreamHead(stream, name); headErr != nil {
+					stream.Close()
+					return nil, "", 0, headErr
+				}
+			}
+		}
+	}
+	if newBP != nil && bp == nil {
+		if selected >= 0 {
+			sess.SetFileBlueprint(selected, files[0], newBP)
+		} else {
+			sess.SetBlueprint(newBP)
+		}
+	}
+	if err == nil {
+		unpack.PrewarmTrailingIndex(files, newBP)
+	}
+	return stream, name, size, err
 }
 
 // handlePlay serves video content for a session.
 // Each request creates its own stream from the cached blueprint.
+// ?file={index} or ?name={filename} plays that file of the session instead of the
+// auto-selected content; its blueprint is cached separately.
 // No stream sharing, no mutexes, no caching -- the shared segment
 // cache in loader.File handles deduplication automatically.
 func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, device *auth.Device) {
@@ -1016,28 +1607,65 @@ func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, device *auth
 
 	sess, err := s.sessionManager.GetSession(sessionID)
 	if err != nil {
+		s.recordPlayFailure(device, sessionID, nil, http.StatusNotFound, err)
 		http.Error(w, "Session expired or not found", http.StatusNotFound)
 		return
 	}
+	offerSession(sess, device)
+
+	if device != nil && s.deviceManager != nil && s.deviceManager.QuotaExceeded(device) {
+		logger.Warn("Monthly quota exceeded for device", "device", device.Username, "quota_gb", device.MonthlyQuotaGB, "session", sessionID)
+		s.recordPlayFailure(device, sessionID, sess, http.StatusTemporaryRedirect, errors.New("monthly quota exceeded"))
+		forceDisconnect(w, s.errorVideoURL())
+		return
+	}
+
+	// Enforce per-device concurrent playback limit (device override, else global default)
+	deviceKey := "ip:" + s.clientIP(r)
+	limit := s.config.MaxConcurrentPlaybacks
+	if device != nil {
+		deviceKey = "device:" + device.Token
+		if device.MaxConcurrentPlaybacks > 0 {
+			limit = device.MaxConcurrentPlaybacks
+		}
+	}
+	if !s.sessionManager.TryStartDevicePlayback(deviceKey, sessionID, limit) {
+		logger.Warn("Too many streams for device", "session", sessionID, "limit", limit)
+		s.recordPlayFailure(device, sessionID, sess, http.StatusTooManyRequests, fmt.Errorf("concurrent playback limit of %d reached", limit))
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, fmt.Sprintf("Too many streams: this device is limited to %d concurrent playbacks", limit), http.StatusTooManyRequests)
+		return
+	}
+	defer s.sessionManager.EndDevicePlayback(deviceKey, sessionID)
 
 	if _, err = sess.GetOrDownloadNZB(s.sessionManager); err != nil {
 		logger.Error("Failed to lazy load NZB", "id", sessionID, "err", err)
-		forceDisconnect(w, s.baseURL)
+		s.recordPlayFailure(device, sessionID, sess, http.StatusTemporaryRedirect, err)
+		s.reportBadRelease(sess, device, err)
+		forceDisconnect(w, s.errorVideoURL())
 		return
 	}
 
-	files := sess.Files
+	files := sessionFiles(sess)
 	if len(files) == 0 {
-		if sess.File != nil {
-			files = []*loader.File{sess.File}
-		} else {
-			logger.Error("No files in session", "id", sessionID)
-			if sess.NZB != nil {
-				s.validator.InvalidateCache(sess.NZB.Hash())
-			}
-			forceDisconnect(w, s.baseURL)
-			return
+		logger.Error("No files in session", "id", sessionID)
+		s.recordPlayFailure(device, sessionID, sess, http.StatusTemporaryRedirect, errors.New("no files in session"))
+		if sess.NZB != nil {
+			s.validator.InvalidateCache(sess.NZB.Hash())
 		}
+		forceDisconnect(w, s.errorVideoURL())
+		return
+	}
+
+	selected, err := selectPlayFile(r.URL.Query(), files)
+	if err != nil {
+		logger.Warn("Invalid play file selection", "session", sessionID, "err", err)
+		s.recordPlayFailure(device, sessionID, sess, http.StatusBadRequest, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if selected >= 0 {
+		files = files[selected : selected+1]
 	}
 
 	// If any file has exceeded its failure threshold, redirect immediately
@@ -1045,11 +1673,12 @@ func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, device *auth
 	for _, f := range files {
 		if f.IsFailed() {
 			logger.Error("Session file has too many failures, redirecting to error", "session", sessionID, "file", f.Name())
-			s.reportBadRelease(sess, loader.ErrTooManyZeroFills)
+			s.recordPlayFailure(device, sessionID, sess, http.StatusTemporaryRedirect, fmt.Errorf("%s: %w", f.Name(), loader.ErrTooManyZeroFills))
+			s.reportBadRelease(sess, device, loader.ErrTooManyZeroFills)
 			if sess.NZB != nil {
 				s.validator.InvalidateCache(sess.NZB.Hash())
 			}
-			forceDisconnect(w, s.baseURL)
+			forceDisconnect(w, s.errorVideoURL())
 			return
 		}
 	}
@@ -1057,30 +1686,25 @@ func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, device *auth
 	// Each request gets its own stream, scoped to the HTTP request context.
 	// When the client disconnects, r.Context() is cancelled, which propagates
 	// down through VirtualStream -> SegmentReader -> DownloadSegment.
-	stream, name, size, bp, err := unpack.GetMediaStream(r.Context(), files, sess.Blueprint)
-	if bp != nil && sess.Blueprint == nil {
-		sess.SetBlueprint(bp)
-	}
+	stream, name, size, err := openPlayStream(r.Context(), sess, files, selected)
 	if err != nil {
 		logger.Error("Failed to open media stream", "id", sessionID, "err", err)
-		s.reportBadRelease(sess, err)
+		s.recordPlayFailure(device, sessionID, sess, http.StatusTemporaryRedirect, err)
+		s.reportBadRelease(sess, device, err)
 		if sess.NZB != nil {
 			s.validator.InvalidateCache(sess.NZB.Hash())
 		}
-		forceDisconnect(w, s.baseURL)
+		forceDisconnect(w, s.errorVideoURL())
 		return
 	}
 	defer stream.Close()
 
 	// Report successful fetch/stream to AvailNZB (lazy sessions weren't reported at catalog time)
-	if s.availReporter != nil {
+	if s.availReporter != nil && s.availReportEnabled(device) {
 		s.availReporter.ReportGood(sess)
 	}
 
-	clientIP, _, _ := net.SplitHostPort(r.RemoteAddr)
-	if clientIP == "" {
-		clientIP = r.RemoteAddr
-	}
+	clientIP := s.clientIP(r)
 	s.sessionManager.StartPlayback(sessionID, clientIP)
 	defer s.sessionManager.EndPlayback(sessionID, clientIP)
 
@@ -1091,6 +1715,10 @@ func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, device *auth
 		manager:        s.sessionManager,
 		lastUpdate:     time.Now(),
 	}
+	if device != nil && s.deviceManager != nil {
+		monitoredStream.usage, monitoredStream.username = s.deviceManager, device.Username
+	}
+	defer monitoredStream.flushUsage()
 
 	logger.Info("Serving media", "name", name, "size", size, "session", sessionID)
 
@@ -1104,84 +1732,71 @@ func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request, device *auth
 }
 
 // reportBadRelease reports unstreamable releases to AvailNZB in the background.
-func (s *Server) reportBadRelease(sess *session.Session, streamErr error) {
+func (s *Server) reportBadRelease(sess *session.Session, device *auth.Device, streamErr error) {
 	errMsg := streamErr.Error()
 	if !strings.Contains(errMsg, "compressed") && !strings.Contains(errMsg, "encrypted") &&
-		!strings.Contains(errMsg, "EOF") && !errors.Is(streamErr, loader.ErrTooManyZeroFills) {
+		!strings.Contains(errMsg, "EOF") && !errors.Is(streamErr, loader.ErrTooManyZeroFills) &&
+		!errors.Is(streamErr, unpack.ErrEncryptedArchive) && !errors.Is(streamErr, decode.ErrCRCMismatch) &&
+		!errors.Is(streamErr, nzb.ErrBrokenRelease) {
 		return
 	}
-	if s.availReporter != nil {
+	if s.availReporter != nil && s.availReportEnabled(device) {
 		s.availReporter.ReportBad(sess, errMsg)
 	}
 }
 
-// handleDebugPlay allows playing directly from an NZB URL or local file for debugging
-func (s *Server) handleDebugPlay(w http.ResponseWriter, r *http.Request, device *auth.Device) {
-	nzbPath := r.URL.Query().Get("nzb")
-	if nzbPath == "" {
-		http.Error(w, "Missing 'nzb' query parameter (URL or file path)", http.StatusBadRequest)
-		return
+// availReportEnabled reports whether results may be sent to AvailNZB for this device:
+// the device override when set, otherwise the global AvailNZBReportEnabled setting.
+// Reads (status and release lookups) are not affected.
+func (s *Server) availReportEnabled(device *auth.Device) bool {
+	if device != nil && device.AvailNZBReport != nil {
+		return *device.AvailNZBReport
 	}
+	return s.config.AvailNZBReportEnabled
+}
 
-	logger.Info("Debug Play request", "nzb", nzbPath)
-
-	var nzbData []byte
-	var err error
-
-	// Check if it's a local file path (starts with / or drive letter on Windows)
-	if strings.HasPrefix(nzbPath, "/") || (len(nzbPath) > 2 && nzbPath[1] == ':') {
-		// Local file path
-		logger.Debug("Reading NZB from local file", "path", nzbPath)
-		nzbData, err = os.ReadFile(nzbPath)
+// handleDebugPlay allows playing directly from an NZB URL or local file for debugging.
+// POST takes the NZB in the body instead (raw NZB or a JSON segment list, see
+// debugSegments), e.g. /debug/play-segments, bypassing any indexer download.
+func (s *Server) handleDebugPlay(w http.ResponseWriter, r *http.Request, device *auth.Device) {
+	var nzbParsed *nzb.NZB
+	var sessionID string
+	if r.Method == http.MethodPost {
+		parsed, err := readDebugNZB(r)
 		if err != nil {
-			logger.Error("Failed to read local NZB file", "path", nzbPath, "err", err)
-			http.Error(w, "Failed to read local NZB file: "+err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		nzbParsed = parsed
+		sessionID = "debug-" + nzbParsed.CalculateID()
+		logger.Info("Debug Play request", "files", len(nzbParsed.Files), "session", sessionID)
 	} else {
-		// URL - try indexer download first (60s for debug play)
-		dlCtx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
-		nzbData, err = s.indexer.DownloadNZB(dlCtx, nzbPath)
-		cancel()
-		if err != nil {
-			// Fallback to HTTP GET with timeout to avoid hanging on slow/broken URLs
-			httpClient := &http.Client{Timeout: 60 * time.Second}
-			resp, httpErr := httpClient.Get(nzbPath)
-			if httpErr != nil {
-				logger.Error("Failed to download NZB", "url", nzbPath, "err", err, "httpErr", httpErr)
-				http.Error(w, "Failed to download NZB: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-			defer resp.Body.Close()
+		nzbPath := r.URL.Query().Get("nzb")
+		if nzbPath == "" {
+			http.Error(w, "Missing 'nzb' query parameter (URL or file path)", http.StatusBadRequest)
+			return
+		}
 
-			if resp.StatusCode != 200 {
-				msg := fmt.Sprintf("Failed to download NZB (HTTP %d)", resp.StatusCode)
-				logger.Error(msg, "url", nzbPath)
-				http.Error(w, msg, http.StatusInternalServerError)
-				return
-			}
+		logger.Info("Debug Play request", "nzb", nzbPath)
 
-			nzbData, err = io.ReadAll(resp.Body)
-			if err != nil {
-				http.Error(w, "Failed to read NZB body", http.StatusInternalServerError)
-				return
-			}
+		nzbData, err := s.fetchNZB(r.Context(), nzbPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-	}
 
-	// Parse NZB
-	nzbParsed, err := nzb.Parse(bytes.NewReader(nzbData))
-	if err != nil {
-		logger.Error("Failed to parse NZB", "err", err)
-		http.Error(w, "Failed to parse NZB", http.StatusInternalServerError)
-		return
-	}
+		// Parse NZB
+		nzbParsed, err = nzb.Parse(bytes.NewReader(nzbData))
+		if err != nil {
+			logger.Error("Failed to parse NZB", "err", err)
+			http.Error(w, "Failed to parse NZB", http.StatusInternalServerError)
+			return
+		}
 
-	// Create Session
-	// Use hash of path as ID to allow repeating same path
-	sessionID := fmt.Sprintf("debug-%x", nzbPath)
-	// Or use NZB hash
-	// sessionID := nzbParsed.Hash()
+		// Create Session
+		// Use hash of path as ID to allow repeating same path
+		sessionID = fmt.Sprintf("debug-%x", nzbPath)
+	}
 
 	// Create/Get Session (no release metadata for debug path - no AvailNZB reporting)
 	sess, err := s.sessionManager.CreateSession(sessionID, nzbParsed, nil, nil)
@@ -1209,10 +1824,7 @@ func (s *Server) handleDebugPlay(w http.ResponseWriter, r *http.Request, device
 	}
 	defer stream.Close()
 
-	clientIP, _, _ := net.SplitHostPort(r.RemoteAddr)
-	if clientIP == "" {
-		clientIP = r.RemoteAddr
-	}
+	clientIP := s.clientIP(r)
 
 	s.sessionManager.StartPlayback(sessionID, clientIP)
 	defer s.sessionManager.EndPlayback(sessionID, clientIP)
@@ -1236,15 +1848,6 @@ func (s *Server) handleDebugPlay(w http.ResponseWriter, r *http.Request, device
 	logger.Debug("Finished serving debug media")
 }
 
-// handleHealth serves health check endpoint
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "ok",
-		"addon":  "streamnzb",
-	})
-}
-
 // streamScore returns the triage score for sorting (higher = better). Uses the score from
 // triage which respects the user's priority configuration (resolution, codec, etc.).
 func streamScore(s Stream) int {
@@ -1344,10 +1947,19 @@ func limitStreamsPerResolution(streams []Stream, maxPerResolution int, maxTotal
 	return result
 }
 
-// forceDisconnect redirects to the embedded failure video when streaming is unavailable.
-// The video is packaged with the binary and served from /error/failure.mp4.
-func forceDisconnect(w http.ResponseWriter, baseURL string) {
-	errorVideoURL := strings.TrimSuffix(baseURL, "/") + "/error/failure.mp4"
+// errorVideoURL returns CustomErrorVideoURL when set, otherwise the embedded failure video
+// (packaged with the binary and served from /error/failure.mp4).
+func (s *Server) errorVideoURL() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.config.CustomErrorVideoURL != "" {
+		return s.config.CustomErrorVideoURL
+	}
+	return strings.TrimSuffix(s.baseURL, "/") + "/error/failure.mp4"
+}
+
+// forceDisconnect redirects to the error video when streaming is unavailable.
+func forceDisconnect(w http.ResponseWriter, errorVideoURL string) {
 	logger.Info("Redirecting to error video", "url", errorVideoURL)
 
 	w.Header().Set("Connection", "close")
@@ -1355,6 +1967,22 @@ func forceDisconnect(w http.ResponseWriter, baseURL string) {
 	http.Redirect(w, &http.Request{Method: "GET"}, errorVideoURL, http.StatusTemporaryRedirect)
 }
 
+// SetConfig swaps in cfg without reloading components, for changes that only affect
+// request handling, such as a rotated admin token.
+func (s *Server) SetConfig(cfg *config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = cfg
+	s.trustedProxies = parseTrustedProxies(cfg.TrustedProxies)
+}
+
+// adminCredentials returns the config admin's username and token.
+func (s *Server) adminCredentials() (string, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config.GetAdminUsername(), s.config.AdminToken
+}
+
 // Reload updates the server components at runtime
 func (s *Server) Reload(cfg *config.Config, baseURL string, indexer indexer.Indexer, validator *validation.Checker,
 	triage *triage.Service, avail *availnzb.Client, availNZBIndexerHosts []string,
@@ -1363,10 +1991,14 @@ func (s *Server) Reload(cfg *config.Config, baseURL string, indexer indexer.Inde
 	defer s.mu.Unlock()
 
 	s.config = cfg // Update config so MaxStreamsPerResolution and other settings are hot-reloaded
+	s.trustedProxies = parseTrustedProxies(cfg.TrustedProxies)
 	s.baseURL = baseURL
 	s.indexer = indexer
 	s.validator = validator
 	s.triageService = triage
+	if old := s.availClient; old != nil && old != avail {
+		old.Close() // stop the replaced client's report workers
+	}
 	s.availClient = avail
 	if avail != nil {
 		s.availReporter = availnzb.NewReporter(avail, validator)
@@ -1377,6 +2009,10 @@ func (s *Server) Reload(cfg *config.Config, baseURL string, indexer indexer.Inde
 	s.tmdbClient = tmdbClient
 	s.tvdbClient = tvdbClient
 	s.deviceManager = deviceManager
+	// Indexers, filters or sorting may have changed
+	s.streamCache.Clear()
+	s.streamCache.SetRateLimit(cfg.StreamRateLimitPerMinute)
+	search.ClearSearchCache()
 }
 
 type writeTimeoutResponseWriter struct {
@@ -1408,10 +2044,16 @@ type StreamMonitor struct {
 	manager    *session.Manager
 	lastUpdate time.Time
 	mu         sync.Mutex // Protect lastUpdate to be safe, though Read is usually serial
+
+	// Bytes served are added to the device's monthly usage every update interval
+	usage    *auth.DeviceManager
+	username string
+	pending  atomic.Int64
 }
 
 func (s *StreamMonitor) Read(p []byte) (n int, err error) {
 	n, err = s.ReadSeekCloser.Read(p)
+	s.pending.Add(int64(n))
 
 	// Non-blocking update check
 	// We don't want to lock on every read, so just check time occasionally
@@ -1419,6 +2061,7 @@ func (s *StreamMonitor) Read(p []byte) (n int, err error) {
 		s.mu.Lock()
 		if time.Since(s.lastUpdate) > 10*time.Second {
 			s.manager.KeepAlive(s.sessionID, s.clientIP)
+			s.flushUsage()
 			s.lastUpdate = time.Now()
 		}
 		s.mu.Unlock()
@@ -1427,6 +2070,13 @@ func (s *StreamMonitor) Read(p []byte) (n int, err error) {
 	return n, err
 }
 
+// flushUsage adds the bytes read since the last flush to the device's usage.
+func (s *StreamMonitor) flushUsage() {
+	if s.usage != nil {
+		s.usage.AddBytesServed(s.username, s.pending.Swap(0))
+	}
+}
+
 func (s *StreamMonitor) Close() error {
 	if s.ReadSeekCloser != nil {
 		return s.ReadSeekCloser.Close()
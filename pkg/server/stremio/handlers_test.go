@@ -0,0 +1,697 @@
+package stremio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/media/unpack"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/search"
+	"streamnzb/pkg/search/parser"
+	"streamnzb/pkg/search/triage"
+	"streamnzb/pkg/services/availnzb"
+	"streamnzb/pkg/services/metadata/tmdb"
+	"streamnzb/pkg/session"
+	"streamnzb/pkg/usenet/nntp"
+	"streamnzb/pkg/usenet/validation"
+)
+
+func TestValidationAttempts(t *testing.T) {
+	tests := []struct {
+		cfg                    *config.Config
+		maxStreams, candidates int
+		want                   int
+	}{
+		{&config.Config{}, 6, 100, 12},
+		{&config.Config{}, 2, 100, 6},
+		{&config.Config{}, 6, 5, 5},
+		{&config.Config{ValidationAttemptMultiplier: 5}, 6, 100, 30},
+		{&config.Config{MinValidationAttempts: 20}, 2, 100, 20},
+		{nil, 6, 100, 12},
+	}
+	for i, tt := range tests {
+		if got := validationAttempts(tt.cfg, tt.maxStreams, tt.candidates); got != tt.want {
+			t.Errorf("case %d: validationAttempts(maxStreams=%d, candidates=%d) = %d, want %d", i, tt.maxStreams, tt.candidates, got, tt.want)
+		}
+	}
+}
+
+func TestValidationConcurrency(t *testing.T) {
+	on := true
+	provider := func(conns, reserved int, role string) config.Provider {
+		return config.Provider{Connections: conns, PlaybackReservedConnections: reserved, Role: role, Enabled: &on}
+	}
+	tests := []struct {
+		cfg  *config.Config
+		want int
+	}{
+		{nil, 6},
+		{&config.Config{}, 6},
+		{&config.Config{ValidationConcurrency: 12, Providers: []config.Provider{provider(30, 0, "")}}, 12},
+		{&config.Config{ValidationConcurrency: 12, Providers: []config.Provider{provider(8, 4, ""), provider(20, 0, "download")}}, 4},
+		{&config.Config{ValidationConcurrency: 12, Providers: []config.Provider{provider(4, 0, "validate"), provider(5, 0, "")}}, 9},
+		{&config.Config{ValidationConcurrency: 2, Providers: []config.Provider{provider(50, 0, "")}}, 2},
+	}
+	for i, tt := range tests {
+		if got := validationConcurrency(tt.cfg); got != tt.want {
+			t.Errorf("case %d: validationConcurrency = %d, want %d", i, got, tt.want)
+		}
+	}
+}
+
+func TestRecentlyUnhealthy(t *testing.T) {
+	now := time.Now()
+	pub := func(age time.Duration) string { return now.Add(-age).Format(time.RFC1123Z) }
+	tests := []struct {
+		reportAge time.Duration
+		pubDate   string
+		grace     time.Duration
+		want      bool
+	}{
+		{2 * time.Hour, "", 24 * time.Hour, true},
+		{30 * time.Hour, "", 24 * time.Hour, false}, // past the grace period
+		{2 * time.Hour, "", 0, false},               // grace disabled
+		{2 * time.Hour, pub(10 * 24 * time.Hour), 24 * time.Hour, true},
+		{8 * time.Hour, pub(12 * time.Hour), 24 * time.Hour, false}, // reported while propagating
+		{2 * time.Hour, pub(12 * time.Hour), 24 * time.Hour, true},
+		{2 * time.Hour, "not a date", 24 * time.Hour, true},
+	}
+	for i, tt := range tests {
+		if got := recentlyUnhealthy(now.Add(-tt.reportAge), tt.pubDate, tt.grace); got != tt.want {
+			t.Errorf("case %d: recentlyUnhealthy(report %s ago, pubDate %q, grace %s) = %v, want %v", i, tt.reportAge, tt.pubDate, tt.grace, got, tt.want)
+		}
+	}
+}
+
+func TestDeferZeroSize(t *testing.T) {
+	tests := []struct {
+		policy string
+		size   int64
+		lazy   bool
+		err    error
+	}{
+		{"defer", 0, true, nil},
+		{"", 0, true, nil},
+		{"resolve", 0, false, nil},
+		{"skip", 0, false, errZeroSize},
+		{"skip", 1 << 30, true, nil},
+		{"resolve", 1 << 30, true, nil},
+	}
+	for _, tt := range tests {
+		got, err := deferZeroSize(tt.policy, tt.size)
+		if got != tt.lazy || !errors.Is(err, tt.err) {
+			t.Errorf("deferZeroSize(%q, %d) = %v, %v; want %v, %v", tt.policy, tt.size, got, err, tt.lazy, tt.err)
+		}
+	}
+}
+
+// availRelease is a release served by fakeAvailNZB, reported healthy on "news.test".
+type availRelease struct {
+	Title, DetailsURL, Link, Compression string
+	Size                                 int64
+}
+
+// fakeAvailNZB serves /releases with rels and /status as healthy for each of them.
+// statusCalls counts status lookups.
+func fakeAvailNZB(t *testing.T, rels []availRelease, statusCalls *atomic.Int32) *availnzb.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		summary := map[string]any{"news.test": map[string]any{"healthy": true, "last_updated": time.Now()}}
+		switch r.URL.Path {
+		case "/api/v1/releases":
+			var items []map[string]any
+			for _, rel := range rels {
+				items = append(items, map[string]any{
+					"url": rel.DetailsURL, "release_name": rel.Title, "download_link": rel.Link,
+					"size": rel.Size, "compression_type": rel.Compression, "available": true, "summary": summary,
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"count": len(items), "releases": items})
+		case "/api/v1/status":
+			if statusCalls != nil {
+				statusCalls.Add(1)
+			}
+			for _, rel := range rels {
+				if rel.DetailsURL == r.URL.Query().Get("url") {
+					json.NewEncoder(w).Encode(map[string]any{"url": rel.DetailsURL, "available": true, "summary": summary})
+					return
+				}
+			}
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return availnzb.NewClient(srv.URL, "")
+}
+
+// recordingIndexer returns items from every search and fails NZB downloads, recording their URLs.
+type recordingIndexer struct {
+	items []indexer.Item
+
+	mu        sync.Mutex
+	downloads []string
+}
+
+func (x *recordingIndexer) Search(req indexer.SearchRequest) (*indexer.SearchResponse, error) {
+	resp := &indexer.SearchResponse{}
+	resp.Channel.Items = append(resp.Channel.Items, x.items...)
+	return resp, nil
+}
+
+func (x *recordingIndexer) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error) {
+	x.mu.Lock()
+	x.downloads = append(x.downloads, nzbURL)
+	x.mu.Unlock()
+	return nil, errors.New("nzb unavailable")
+}
+
+func (x *recordingIndexer) Ping() error             { return nil }
+func (x *recordingIndexer) Name() string            { return "recording" }
+func (x *recordingIndexer) GetUsage() indexer.Usage { return indexer.Usage{} }
+
+func (x *recordingIndexer) downloaded() []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return append([]string(nil), x.downloads...)
+}
+
+// availTestServer is a Server whose AvailNZB knows rels and whose validator has one
+// provider, "news.test", without connections.
+func availTestServer(t *testing.T, cfg *config.Config, rels []availRelease, statusCalls *atomic.Int32) (*Server, *recordingIndexer) {
+	idx := &recordingIndexer{}
+	return &Server{
+		config:         cfg,
+		indexer:        idx,
+		validator:      validation.NewChecker(map[string]*nntp.ClientPool{"news.test": nil}, []string{"news.test"}, time.Minute, 1, 1, 1),
+		sessionManager: session.NewManager(nil, time.Minute),
+		triageService:  triage.NewService(&cfg.Filters, cfg.Sorting),
+		tmdbClient:     tmdb.NewClient(""),
+		availClient:    fakeAvailNZB(t, rels, statusCalls),
+		attempts:       newAttemptTracker(),
+		contentInfo:    newContentInfoCache(),
+	}, idx
+}
+
+func TestZeroSizePolicyAvailNZBPhase(t *testing.T) {
+	logger.Init("DEBUG")
+	rels := []availRelease{{
+		Title: "Movie.2001.1080p.BluRay.x264-GRP", DetailsURL: "https://indexer.test/details/1",
+		Link: "https://indexer.test/getnzb/1", Compression: "direct",
+	}}
+	device := &auth.Device{Username: "tv", Token: "tok"}
+	tests := []struct {
+		policy    string
+		streams   int
+		downloads int
+	}{
+		{"defer", 1, 0},
+		{"skip", 0, 0},
+		{"resolve", 0, 1}, // downloaded to learn the size (the download fails here)
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{MaxStreams: 1, AllowArchiveStreaming: true, ZeroSizePolicy: tt.policy}
+		s, idx := availTestServer(t, cfg, rels, nil)
+		s.triageService.AllowUnknownSize = tt.policy != "skip"
+		streams, err := s.searchAndValidate(context.Background(), "movie", "tt7100002", device, false)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.policy, err)
+		}
+		var avail int
+		for _, st := range streams {
+			if st.Release != nil {
+				avail++
+			}
+		}
+		if avail != tt.streams {
+			t.Errorf("%s: %d streams; want %d", tt.policy, avail, tt.streams)
+		}
+		if got := idx.downloaded(); len(got) != tt.downloads {
+			t.Errorf("%s: NZB downloads %v; want %d", tt.policy, got, tt.downloads)
+		}
+	}
+}
+
+func TestArchiveStreamingAvailNZBPhase(t *testing.T) {
+	logger.Init("DEBUG")
+	rels := []availRelease{
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", DetailsURL: "https://indexer.test/details/3", Link: "https://indexer.test/getnzb/3", Compression: "rar", Size: 8 << 30},
+		{Title: "Movie.2001.720p.BluRay.x264-GRP", DetailsURL: "https://indexer.test/details/4", Link: "https://indexer.test/getnzb/4", Compression: "direct", Size: 4 << 30},
+	}
+	device := &auth.Device{Username: "tv", Token: "tok"}
+	for _, tt := range []struct {
+		allow bool
+		want  []string
+	}{
+		{true, []string{rels[0].Title, rels[1].Title}},
+		{false, []string{rels[1].Title}}, // the RAR release is skipped without a download
+	} {
+		cfg := &config.Config{MaxStreams: 2, AllowArchiveStreaming: tt.allow}
+		s, idx := availTestServer(t, cfg, rels, nil)
+		streams, err := s.searchAndValidate(context.Background(), "movie", "tt7100003", device, false)
+		if err != nil {
+			t.Fatalf("allow %v: %v", tt.allow, err)
+		}
+		var got []string
+		for _, st := range streams {
+			if st.Release != nil {
+				got = append(got, st.Release.Title)
+			}
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("allow %v: streams %v; want %v", tt.allow, got, tt.want)
+		}
+		if dl := idx.downloaded(); len(dl) != 0 {
+			t.Errorf("allow %v: NZB downloads %v; want none", tt.allow, dl)
+		}
+	}
+}
+
+func TestZeroSizePolicyValidateCandidate(t *testing.T) {
+	logger.Init("DEBUG")
+	rels := []availRelease{{Title: "Movie.2001.1080p.BluRay.x264-GRP", DetailsURL: "https://indexer.test/details/2", Compression: "direct"}}
+	cand := triage.Candidate{Release: &release.Release{
+		Title: rels[0].Title, DetailsURL: rels[0].DetailsURL, Link: "https://indexer.test/getnzb/2", GUID: "guid-2",
+	}, Metadata: parser.ParseReleaseTitle(rels[0].Title)}
+	device := &auth.Device{Username: "tv", Token: "tok"}
+	for _, tt := range []struct {
+		policy    string
+		lazy      bool
+		err       error
+		downloads int
+	}{
+		{"defer", true, nil, 0},
+		{"skip", false, errZeroSize, 0},
+		{"resolve", false, nil, 1},
+	} {
+		cfg := &config.Config{AllowArchiveStreaming: true, ZeroSizePolicy: tt.policy}
+		s, idx := availTestServer(t, cfg, rels, nil)
+		streams, err := s.validateCandidate(context.Background(), cand, device, &session.AvailReportMeta{ImdbID: "tt7100002"}, contentInfo{}, nil, false)
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v; want %v", tt.policy, err, tt.err)
+		}
+		if lazy := err == nil && len(streams) == 1; lazy != tt.lazy {
+			t.Errorf("%s: deferred stream = %v (err %v); want %v", tt.policy, lazy, err, tt.lazy)
+		}
+		if got := idx.downloaded(); len(got) != tt.downloads {
+			t.Errorf("%s: NZB downloads %v; want %d", tt.policy, got, tt.downloads)
+		}
+	}
+}
+
+func TestPreferCachedOnly(t *testing.T) {
+	logger.Init("DEBUG")
+	// AvailNZB lists "cached" as a direct release without a download link, so only the
+	// indexer phase can stream it; "unknown" is not known to AvailNZB at all.
+	rels := []availRelease{{Title: "Movie.2001.1080p.BluRay.x264-GRP", DetailsURL: "https://indexer.test/details/cached", Compression: "direct"}}
+	items := []indexer.Item{
+		{Title: "Movie.2001.1080p.BluRay.x264-GRP", GUID: "https://indexer.test/details/cached", Link: "https://indexer.test/getnzb/cached", Size: 8 << 30},
+		{Title: "Movie.2001.720p.WEB-DL.x264-OTHER", GUID: "https://indexer.test/details/unknown", Link: "https://indexer.test/getnzb/unknown", Size: 4 << 30},
+	}
+	device := &auth.Device{Username: "tv", Token: "tok"}
+	tests := []struct {
+		name      string
+		imdb      string
+		cfg       config.Config
+		downloads []string
+	}{
+		// Confirmed direct releases stream lazily even without archive streaming
+		{"strict", "tt7100010", config.Config{MaxStreams: 2, PreferCachedOnly: true, PreferCachedStrict: true}, nil},
+		// The fallback validates only what the cached pass did not confirm
+		{"fallback", "tt7100011", config.Config{MaxStreams: 2, PreferCachedOnly: true, AllowArchiveStreaming: true}, []string{"https://indexer.test/getnzb/unknown"}},
+	}
+	for _, tt := range tests {
+		var statusCalls atomic.Int32
+		cfg := tt.cfg
+		s, idx := availTestServer(t, &cfg, rels, &statusCalls)
+		idx.items = items
+		streams, err := s.searchAndValidate(context.Background(), "movie", tt.imdb, device, false)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var cached int
+		for _, st := range streams {
+			if st.Release != nil && st.Release.DetailsURL == rels[0].DetailsURL {
+				cached++
+			}
+		}
+		if cached != 1 {
+			t.Errorf("%s: %d streams for the cached release; want 1", tt.name, cached)
+		}
+		if got := idx.downloaded(); !reflect.DeepEqual(got, tt.downloads) {
+			t.Errorf("%s: NZB downloads %v; want %v", tt.name, got, tt.downloads)
+		}
+		// One status lookup per candidate, shared by both passes
+		if n := statusCalls.Load(); n != int32(len(items)) {
+			t.Errorf("%s: %d AvailNZB status lookups; want %d", tt.name, n, len(items))
+		}
+	}
+}
+
+func TestAvailabilityTitle(t *testing.T) {
+	if got := availabilityTitle("💾 1.00 GB", 1); got != "💾 1.00 GB" {
+		t.Errorf("complete release: %q", got)
+	}
+	if got := availabilityTitle("💾 1.00 GB", 0.97); got != "⚠️ 97% available\n💾 1.00 GB" {
+		t.Errorf("partial release: %q", got)
+	}
+}
+
+func TestBranding(t *testing.T) {
+	base := NewManifest("1.0.0")
+	if m := base.WithBranding("", ""); m.Name != "StreamNZB" || m.Logo != base.Logo {
+		t.Errorf("empty branding changed the manifest: %q %q", m.Name, m.Logo)
+	}
+	m := base.WithBranding("My Usenet", "https://example.com/logo.png")
+	if m.Name != "My Usenet" || m.Logo != "https://example.com/logo.png" || base.Name != "StreamNZB" {
+		t.Errorf("branding: got %q %q, base %q", m.Name, m.Logo, base.Name)
+	}
+
+	s := &Server{config: &config.Config{}, baseURL: "http://localhost:7000/"}
+	if got := s.errorVideoURL(); got != "http://localhost:7000/error/failure.mp4" {
+		t.Errorf("default error video = %q", got)
+	}
+	s.config.CustomErrorVideoURL = "https://cdn.example.com/sorry.mp4"
+	if got := s.errorVideoURL(); got != "https://cdn.example.com/sorry.mp4" {
+		t.Errorf("custom error video = %q", got)
+	}
+
+	for _, bad := range []string{"/relative.mp4", "ftp://host/x.mp4", "https://"} {
+		if ValidateBrandingURL(bad) == nil {
+			t.Errorf("ValidateBrandingURL(%q) accepted", bad)
+		}
+	}
+}
+
+func TestParseStreamID(t *testing.T) {
+	tests := []struct {
+		contentType, id string
+		want            streamID
+	}{
+		{"movie", "tt0133093", streamID{IMDbID: "tt0133093"}},
+		{"movie", "tmdb:603", streamID{TMDBID: "603"}},
+		{"movie", "tvdb:169", streamID{TVDBID: "169"}},
+		{"movie", "603", streamID{TMDBID: "603"}},
+		{"series", "tt0903747:1:2", streamID{IMDbID: "tt0903747", Season: "1", Episode: "2"}},
+		{"series", "tmdb:1396:1:2", streamID{TMDBID: "1396", Season: "1", Episode: "2"}},
+		{"series", "tvdb:81189:1:2", streamID{TVDBID: "81189", Season: "1", Episode: "2"}},
+		{"series", "tvdb:81189", streamID{TVDBID: "81189"}},
+	}
+	for _, tt := range tests {
+		if got := parseStreamID(tt.contentType, tt.id); got != tt.want {
+			t.Errorf("parseStreamID(%q, %q) = %+v, want %+v", tt.contentType, tt.id, got, tt.want)
+		}
+	}
+}
+
+// blockingIndexer returns search results whose NZB downloads block until cancelled.
+type blockingIndexer struct {
+	downloads atomic.Int32
+}
+
+func (b *blockingIndexer) Search(req indexer.SearchRequest) (*indexer.SearchResponse, error) {
+	resp := &indexer.SearchResponse{}
+	for i := range 20 {
+		resp.Channel.Items = append(resp.Channel.Items, indexer.Item{
+			Title: fmt.Sprintf("Movie.2001.1080p.BluRay.x264-GRP%d", i),
+			Link:  fmt.Sprintf("https://indexer.test/getnzb/%d", i),
+			GUID:  fmt.Sprintf("guid-%d", i),
+			Size:  8 << 30,
+		})
+	}
+	return resp, nil
+}
+
+func (b *blockingIndexer) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error) {
+	b.downloads.Add(1)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (b *blockingIndexer) Ping() error             { return nil }
+func (b *blockingIndexer) Name() string            { return "blocking" }
+func (b *blockingIndexer) GetUsage() indexer.Usage { return indexer.Usage{} }
+
+func TestSearchCancellationStopsValidation(t *testing.T) {
+	logger.Init("DEBUG")
+	cfg := &config.Config{MaxStreams: 2, ValidationConcurrency: 4}
+	idx := &blockingIndexer{}
+	s := &Server{
+		config:         cfg,
+		indexer:        idx,
+		validator:      validation.NewChecker(nil, nil, time.Minute, 1, 1, 1),
+		sessionManager: session.NewManager(nil, time.Minute),
+		triageService:  triage.NewService(&cfg.Filters, cfg.Sorting),
+		tmdbClient:     tmdb.NewClient(""),
+		attempts:       newAttemptTracker(),
+		contentInfo:    newContentInfoCache(),
+	}
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.searchAndValidate(ctx, "movie", "tt7100001", nil, false)
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for idx.downloads.Load() < 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("validations never started (downloads: %d)", idx.downloads.Load())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel() // client disconnected
+	select {
+	case <-done:
+	case <-time.After(validationDrainTimeout + time.Second):
+		t.Fatal("searchAndValidate did not return after cancellation")
+	}
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("goroutines leaked: %d > baseline %d\n%s", runtime.NumGoroutine(), baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := idx.downloads.Load(); n != 4 {
+		t.Errorf("%d NZB downloads started; want 4 (validation concurrency)", n)
+	}
+}
+
+func TestSelectPlayFile(t *testing.T) {
+	logger.Init("DEBUG")
+	mgr := session.NewManager(nil, time.Hour)
+	sess, err := mgr.CreateSession("fedcba9876543210", testNZB(map[string]int64{
+		"Movie.2001.1080p.part1.rar": 50 << 20,
+		"Movie.2001.1080p.part2.rar": 50 << 20,
+	}), &release.Release{Title: "Movie 2001"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := sessionFiles(sess)
+	if len(files) != 2 {
+		t.Fatalf("session has %d files, want 2", len(files))
+	}
+
+	tests := []struct {
+		query   string
+		want    int
+		invalid bool
+	}{
+		{"", -1, false},
+		{"file=1", 1, false},
+		{"file=2", -1, true},
+		{"file=-1", -1, true},
+		{"file=x", -1, true},
+		{"name=Movie.2001.1080p.part3.rar", -1, true},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		got, err := selectPlayFile(query, files)
+		if got != tt.want || errors.Is(err, errInvalidFileSelection) != tt.invalid {
+			t.Errorf("%q: selectPlayFile = %d, %v; want %d, invalid %v", tt.query, got, err, tt.want, tt.invalid)
+		}
+	}
+
+	for i, f := range files {
+		name := unpack.ExtractFilename(f.Name())
+		query := url.Values{"name": {strings.ToLower(name)}}
+		if got, err := selectPlayFile(query, files); got != i || err != nil {
+			t.Errorf("name=%s: selectPlayFile = %d, %v; want %d", name, got, err, i)
+		}
+	}
+}
+
+func TestReloadClosesReplacedAvailClient(t *testing.T) {
+	logger.Init("DEBUG")
+	var reports atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reports.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	old := availnzb.NewClient(srv.URL, "key")
+	s := &Server{config: cfg, availClient: old, streamCache: newStreamCache(0)}
+	s.Reload(cfg, "", nil, nil, nil, old, nil, nil, nil, nil) // same client: kept open
+	meta := availnzb.ReportMeta{ReleaseName: "Movie.2001.1080p", Size: 1, ImdbID: "tt0000001"}
+	old.QueueReport("https://indexer.test/details/1", "news.test", true, meta)
+	deadline := time.Now().Add(2 * time.Second)
+	for reports.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if reports.Load() != 1 {
+		t.Fatal("report not sent by the current client")
+	}
+
+	replacement := availnzb.NewClient(srv.URL, "key")
+	s.Reload(cfg, "", nil, nil, nil, replacement, nil, nil, nil, nil)
+	old.QueueReport("https://indexer.test/details/2", "news.test", true, meta)
+	time.Sleep(50 * time.Millisecond)
+	if n := reports.Load(); n != 1 {
+		t.Errorf("replaced client still reporting: %d reports", n)
+	}
+	s.Close()
+	replacement.QueueReport("https://indexer.test/details/3", "news.test", true, meta)
+	time.Sleep(50 * time.Millisecond)
+	if n := reports.Load(); n != 1 {
+		t.Errorf("client still reporting after Close: %d reports", n)
+	}
+}
+
+func TestStreamDedupe(t *testing.T) {
+	stream := func(title, hash string) Stream {
+		return Stream{Release: &release.Release{Title: title}, ContentHash: hash}
+	}
+	tests := []struct {
+		name      string
+		byContent bool
+		streams   []Stream
+		want      []bool
+	}{
+		{"by title", false, []Stream{
+			stream("Movie.2001.1080p-GRP", "h1"),
+			stream("Movie.2001.1080p-GRP", "h2"), // same title, content ignored
+			stream("Movie.2001.1080p-OTHER", "h1"),
+		}, []bool{true, false, true}},
+		{"by content", true, []Stream{
+			stream("Movie.2001.1080p-GRP", "h1"),
+			stream("Movie.2001.1080p-GRP", "h2"),    // distinct upload sharing a title
+			stream("Movie.2001.1080p-REPOST", "h1"), // re-upload under another name
+			stream("Movie.2001.1080p-GRP", ""),      // content unknown: matched by title
+		}, []bool{true, true, false, false}},
+		{"unknown content kept first", true, []Stream{
+			stream("Movie.2001.1080p-GRP", ""), // e.g. AvailNZB-verified
+			stream("Movie.2001.1080p-GRP", "h1"),
+			stream("Movie.2001.1080p-OTHER", "h1"),
+			stream("Movie.2001.1080p-THIRD", "h1"),
+		}, []bool{true, false, true, false}},
+	}
+	for _, tt := range tests {
+		d := newStreamDedupe(tt.byContent)
+		for i, st := range tt.streams {
+			if got := d.keep(st); got != tt.want[i] {
+				t.Errorf("%s: keep(%s, %q) = %v; want %v", tt.name, st.Release.Title, st.ContentHash, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestStreamDedupeFeatures(t *testing.T) {
+	feature := func(title, hash, file string) Stream {
+		return Stream{Release: &release.Release{Title: title}, ContentHash: hash, BehaviorHints: &BehaviorHints{Filename: file}}
+	}
+	d := newStreamDedupe(true)
+	lead := feature("Movie.2001.1080p-GRP", "h1", "Movie.Theatrical.mkv")
+	if !d.keep(lead) || !d.keepFeature(lead, lead) {
+		t.Fatal("lead stream not kept")
+	}
+	steps := []struct {
+		lead, stream Stream
+		want         bool
+	}{
+		{lead, feature("Movie.2001.1080p-GRP", "", "Movie.Extended.mkv"), true},
+		{lead, feature("Movie.2001.1080p-GRP", "", "Movie.Extended.mkv"), false},   // listed twice
+		{lead, feature("Movie.2001.1080p-GRP", "", "movie.theatrical.MKV"), false}, // the lead's own file
+		{lead, Stream{Release: lead.Release}, false},                               // no file to tell it apart
+		// another upload of the same title keeps its own feature files
+		{feature("Movie.2001.1080p-GRP", "h2", "Movie.Theatrical.mkv"), feature("Movie.2001.1080p-GRP", "", "Movie.Extended.mkv"), true},
+	}
+	for i, st := range steps {
+		if got := d.keepFeature(st.lead, st.stream); got != st.want {
+			t.Errorf("step %d: keepFeature = %v; want %v", i, got, st.want)
+		}
+	}
+}
+
+func TestAvailReportEnabled(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		global bool
+		device *auth.Device
+		want   bool
+	}{
+		{true, nil, true},
+		{false, nil, false},
+		{true, &auth.Device{Username: "tv"}, true}, // no override: global setting
+		{true, &auth.Device{Username: "tv", AvailNZBReport: &off}, false},
+		{false, &auth.Device{Username: "tv", AvailNZBReport: &on}, true},
+	}
+	for _, tt := range tests {
+		s := &Server{config: &config.Config{AvailNZBReportEnabled: tt.global}}
+		if got := s.availReportEnabled(tt.device); got != tt.want {
+			t.Errorf("global %v, device %+v: availReportEnabled = %v; want %v", tt.global, tt.device, got, tt.want)
+		}
+	}
+}
+
+func TestMaxCandidatesAfterTriage(t *testing.T) {
+	logger.Init("DEBUG")
+	device := &auth.Device{Username: "tv", Token: "tok"}
+	for _, tt := range []struct {
+		max       int
+		downloads int
+	}{
+		{2, 2},
+		{0, 4}, // no cap
+	} {
+		search.ClearSearchCache()
+		cfg := &config.Config{MaxStreams: 10, MaxCandidatesAfterTriage: tt.max}
+		s, idx := availTestServer(t, cfg, nil, nil)
+		for i, res := range []string{"2160p", "1080p", "720p", "480p"} {
+			idx.items = append(idx.items, indexer.Item{
+				Title: "Movie.2001." + res + ".BluRay.x264-GRP", GUID: strconv.Itoa(i),
+				Link: "https://indexer.test/getnzb/" + strconv.Itoa(i), Size: 4 << 30,
+			})
+		}
+		if _, err := s.searchAndValidate(context.Background(), "movie", "tt7100005", device, false); err != nil {
+			t.Fatalf("max %d: %v", tt.max, err)
+		}
+		if dl := idx.downloaded(); len(dl) != tt.downloads {
+			t.Errorf("max %d: %d candidates downloaded; want %d", tt.max, len(dl), tt.downloads)
+		}
+	}
+}
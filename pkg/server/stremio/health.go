@@ -0,0 +1,235 @@
+package stremio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/services/availnzb"
+)
+
+// healthCacheTTL bounds how often deep checks hit providers, indexers and AvailNZB.
+// Orchestrator readiness probes typically fire every few seconds per replica.
+const healthCacheTTL = 10 * time.Second
+
+// healthCheckTimeout bounds one deep check; dependencies still unanswered are reported down.
+const healthCheckTimeout = 15 * time.Second
+
+// Dependency status values reported by the deep health check.
+const (
+	healthOK            = "ok"
+	healthDown          = "down"
+	healthNotConfigured = "not_configured"
+)
+
+// DependencyHealth is the status of one dependency in the deep health check.
+type DependencyHealth struct {
+	Status  string            `json:"status"`
+	Error   string            `json:"error,omitempty"`
+	Details map[string]string `json:"details,omitempty"` // per provider status for "providers"
+}
+
+// HealthReport is the /health?deep=1 response.
+type HealthReport struct {
+	Status    string                      `json:"status"` // "ok" or "degraded"
+	Addon     string                      `json:"addon"`
+	CheckedAt time.Time                   `json:"checked_at"`
+	Checks    map[string]DependencyHealth `json:"checks"`
+}
+
+// healthCache holds the last deep check. At most one check runs at a time; concurrent
+// probes wait for the run in flight (or their own context) instead of starting their own.
+type healthCache struct {
+	mu      sync.Mutex
+	report  *HealthReport
+	running chan struct{} // closed when the check in flight finishes; nil when idle
+
+	// Pings that take no context keep running after a timeout; these are set while one
+	// is still running so repeated probes do not pile up goroutines behind a hung backend.
+	indexerPing atomic.Bool
+	availPing   atomic.Bool
+}
+
+// handleHealth serves the health check endpoint. The default check is a cheap
+// liveness probe; ?deep=1 checks dependencies for readiness and returns 503
+// when a configured dependency is down.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if deep := r.URL.Query().Get("deep"); deep == "" || deep == "0" || deep == "false" {
+		json.NewEncoder(w).Encode(map[string]string{
+			"status": "ok",
+			"addon":  "streamnzb",
+		})
+		return
+	}
+
+	report := s.deepHealth(r.Context())
+	if report.Status != healthOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// deepHealth returns the cached deep check, or waits for a fresh one when the cache is
+// stale. When ctx ends first, the last report (possibly stale) is returned.
+func (s *Server) deepHealth(ctx context.Context) *HealthReport {
+	s.health.mu.Lock()
+	if s.health.report != nil && time.Since(s.health.report.CheckedAt) < healthCacheTTL {
+		report := s.health.report
+		s.health.mu.Unlock()
+		return report
+	}
+	if s.health.running == nil {
+		s.health.running = make(chan struct{})
+		go s.runDeepHealth(s.health.running)
+	}
+	done := s.health.running
+	s.health.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+	if s.health.report == nil {
+		return &HealthReport{Status: "degraded", Addon: "streamnzb", CheckedAt: time.Now(), Checks: map[string]DependencyHealth{}}
+	}
+	return s.health.report
+}
+
+// runDeepHealth checks all dependencies, stores the report and closes done. It runs
+// detached from any request so one impatient probe does not poison the cache.
+func (s *Server) runDeepHealth(done chan struct{}) {
+	checkCtx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	checks := map[string]DependencyHealth{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	run := func(name string, check func(context.Context) DependencyHealth) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := check(checkCtx)
+			mu.Lock()
+			checks[name] = res
+			mu.Unlock()
+		}()
+	}
+	run("providers", s.checkProvidersHealth)
+	run("indexers", s.checkIndexersHealth)
+	run("availnzb", s.checkAvailNZBHealth)
+	wg.Wait()
+
+	report := &HealthReport{Status: healthOK, Addon: "streamnzb", CheckedAt: time.Now(), Checks: checks}
+	for name, c := range checks {
+		if c.Status == healthDown {
+			report.Status = "degraded"
+			logger.Warn("Health check dependency down", "dependency", name, "err", c.Error)
+		}
+	}
+
+	s.health.mu.Lock()
+	s.health.report = report
+	s.health.running = nil
+	s.health.mu.Unlock()
+	close(done)
+}
+
+// checkProvidersHealth passes when at least one provider pool has, or can open,
+// a connection. Pools with live connections are not redialed.
+func (s *Server) checkProvidersHealth(ctx context.Context) DependencyHealth {
+	if s.validator == nil {
+		return DependencyHealth{Status: healthNotConfigured}
+	}
+	pools := s.validator.GetProviderPools()
+	if len(pools) == 0 {
+		return DependencyHealth{Status: healthNotConfigured}
+	}
+
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := DependencyHealth{Status: healthDown, Details: make(map[string]string, len(pools))}
+	for _, name := range names {
+		pool := pools[name]
+		if pool.TotalConnections() > 0 {
+			res.Details[name] = healthOK
+			res.Status = healthOK
+			continue
+		}
+		c, ok := pool.TryGet(ctx)
+		if !ok {
+			res.Details[name] = healthDown
+			continue
+		}
+		pool.Put(c)
+		res.Details[name] = healthOK
+		res.Status = healthOK
+	}
+	if res.Status == healthDown {
+		res.Error = "no provider connection could be established"
+	}
+	return res
+}
+
+func (s *Server) checkIndexersHealth(ctx context.Context) DependencyHealth {
+	if s.indexer == nil {
+		return DependencyHealth{Status: healthNotConfigured}
+	}
+	return dependencyHealth(ctx, &s.health.indexerPing, s.indexer.Ping)
+}
+
+func (s *Server) checkAvailNZBHealth(ctx context.Context) DependencyHealth {
+	if s.availClient == nil || s.availClient.BaseURL == "" {
+		return DependencyHealth{Status: healthNotConfigured}
+	}
+	return dependencyHealth(ctx, &s.health.availPing, func() error { return s.availClient.Ping(ctx) })
+}
+
+// dependencyHealth runs check, giving up when ctx expires (indexer pings take no context).
+// inflight is set while check runs; a check still running from an earlier timeout is
+// reported down instead of being started again.
+func dependencyHealth(ctx context.Context, inflight *atomic.Bool, check func() error) DependencyHealth {
+	if !inflight.CompareAndSwap(false, true) {
+		return DependencyHealth{Status: healthDown, Error: "previous check still running"}
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		err := check()
+		inflight.Store(false)
+		errCh <- err
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = errors.New("timed out")
+	}
+	if err != nil {
+		return DependencyHealth{Status: healthDown, Error: err.Error()}
+	}
+	return DependencyHealth{Status: healthOK}
+}
+
+// AvailNZBBreaker returns the circuit breaker state of the AvailNZB client; ok is false
+// when AvailNZB is not configured.
+func (s *Server) AvailNZBBreaker() (st availnzb.BreakerStatus, ok bool) {
+	s.mu.RLock()
+	client := s.availClient
+	s.mu.RUnlock()
+	if client == nil || client.BaseURL == "" {
+		return availnzb.BreakerStatus{}, false
+	}
+	return client.Breaker(), true
+}
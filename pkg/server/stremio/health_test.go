@@ -0,0 +1,94 @@
+package stremio
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestDependencyHealthTimeout(t *testing.T) {
+	var inflight atomic.Bool
+	var calls atomic.Int32
+	release := make(chan struct{})
+	hung := func() error {
+		calls.Add(1)
+		<-release
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if res := dependencyHealth(ctx, &inflight, hung); res.Status != healthDown || res.Error != "timed out" {
+		t.Fatalf("hung check: %+v", res)
+	}
+	// The hung check is not started again while it is still running
+	if res := dependencyHealth(context.Background(), &inflight, hung); res.Status != healthDown {
+		t.Fatalf("second check while hung: %+v", res)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("%d checks started; want 1", n)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for inflight.Load() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if res := dependencyHealth(context.Background(), &inflight, hung); res.Status != healthOK {
+		t.Errorf("check after the hung one returned: %+v", res)
+	}
+	failing := func() error { return errors.New("connection refused") }
+	if res := dependencyHealth(context.Background(), &inflight, failing); res.Status != healthDown || res.Error != "connection refused" {
+		t.Errorf("failing check: %+v", res)
+	}
+}
+
+// pingIndexer is an indexer whose Ping blocks until release is closed.
+type pingIndexer struct {
+	recordingIndexer
+	pings   atomic.Int32
+	release chan struct{}
+}
+
+func (x *pingIndexer) Ping() error {
+	x.pings.Add(1)
+	<-x.release
+	return nil
+}
+
+func TestDeepHealthSharesRunWithoutBlocking(t *testing.T) {
+	logger.Init("DEBUG")
+	idx := &pingIndexer{release: make(chan struct{})}
+	s := &Server{indexer: idx}
+
+	first := make(chan *HealthReport)
+	go func() { first <- s.deepHealth(context.Background()) }()
+
+	// A probe that gives up returns while the check is still running
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if r := s.deepHealth(ctx); r.Status == healthOK {
+		t.Errorf("probe without a finished check reported %q", r.Status)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("impatient probe waited %v", waited)
+	}
+
+	close(idx.release)
+	r := <-first
+	if r.Status != healthOK || r.Checks["indexers"].Status != healthOK || r.Checks["providers"].Status != healthNotConfigured {
+		t.Errorf("deep check: %+v", r)
+	}
+	if n := idx.pings.Load(); n != 1 {
+		t.Errorf("%d indexer pings; want 1 shared by both probes", n)
+	}
+	// Served from the cache
+	if again := s.deepHealth(context.Background()); again != r || idx.pings.Load() != 1 {
+		t.Error("fresh report was not reused")
+	}
+}
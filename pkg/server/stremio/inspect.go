@@ -0,0 +1,168 @@
+package stremio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/nzb"
+)
+
+// NZBInspection is a non-streaming diagnostic of an NZB: what it contains and how
+// it would be played.
+type NZBInspection struct {
+	Hash            string                 `json:"hash"`
+	Title           string                 `json:"title,omitempty"`
+	CompressionType string                 `json:"compression_type"` // "rar", "7z" or "direct"
+	IsRARRelease    bool                   `json:"is_rar_release"`
+	TotalSize       int64                  `json:"total_size"`
+	FileCount       int                    `json:"file_count"`
+	SegmentCount    int                    `json:"segment_count"`
+	ContentFiles    []InspectedFile        `json:"content_files"`
+	Files           []InspectedFile        `json:"files"`
+	Providers       []ProviderAvailability `json:"providers,omitempty"`
+}
+
+// InspectedFile describes one file of the NZB.
+type InspectedFile struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	Segments int    `json:"segments"`
+	IsVideo  bool   `json:"is_video"`
+	IsSample bool   `json:"is_sample,omitempty"`
+	IsExtra  bool   `json:"is_extra,omitempty"`
+}
+
+// ProviderAvailability is the per-provider article check of an inspected NZB.
+type ProviderAvailability struct {
+	Provider        string  `json:"provider"`
+	Host            string  `json:"host"`
+	Available       bool    `json:"available"`
+	CheckedArticles int     `json:"checked_articles"`
+	MissingArticles int     `json:"missing_articles"`
+	Completion      float64 `json:"completion"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// fetchNZB reads an NZB from a local path or downloads it from a URL, trying the
+// indexers first and falling back to a plain HTTP GET.
+func (s *Server) fetchNZB(ctx context.Context, nzbPath string) ([]byte, error) {
+	// Check if it's a local file path (starts with / or drive letter on Windows)
+	if strings.HasPrefix(nzbPath, "/") || (len(nzbPath) > 2 && nzbPath[1] == ':') {
+		logger.Debug("Reading NZB from local file", "path", nzbPath)
+		data, err := os.ReadFile(nzbPath)
+		if err != nil {
+			logger.Error("Failed to read local NZB file", "path", nzbPath, "err", err)
+			return nil, fmt.Errorf("failed to read local NZB file: %w", err)
+		}
+		return data, nil
+	}
+
+	// URL - try indexer download first (60s for debug requests)
+	dlCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	data, err := s.indexer.DownloadNZB(dlCtx, nzbPath)
+	cancel()
+	if err == nil {
+		return data, nil
+	}
+
+	// Fallback to HTTP GET with timeout to avoid hanging on slow/broken URLs
+	httpClient := &http.Client{Timeout: 60 * time.Second}
+	resp, httpErr := httpClient.Get(nzbPath)
+	if httpErr != nil {
+		logger.Error("Failed to download NZB", "url", nzbPath, "err", err, "httpErr", httpErr)
+		return nil, fmt.Errorf("failed to download NZB: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		logger.Error("Failed to download NZB", "url", nzbPath, "status", resp.StatusCode)
+		return nil, fmt.Errorf("failed to download NZB (HTTP %d)", resp.StatusCode)
+	}
+
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.New("failed to read NZB body")
+	}
+	return data, nil
+}
+
+// InspectNZB downloads and parses an NZB and reports its structure. When validate
+// is set, article availability is checked on every provider.
+func (s *Server) InspectNZB(ctx context.Context, nzbPath string, validate bool) (*NZBInspection, error) {
+	data, err := s.fetchNZB(ctx, nzbPath)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := nzb.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse NZB: %w", err)
+	}
+	if len(parsed.Files) == 0 {
+		return nil, errors.New("no files in NZB")
+	}
+
+	res := &NZBInspection{
+		Hash:            parsed.Hash(),
+		CompressionType: parsed.CompressionType(),
+		IsRARRelease:    parsed.IsRARRelease(),
+		TotalSize:       parsed.TotalSize(),
+		FileCount:       len(parsed.Files),
+		ContentFiles:    []InspectedFile{},
+	}
+	for _, m := range parsed.Head.Meta {
+		if m.Type == "title" {
+			res.Title = m.Value
+			break
+		}
+	}
+	for _, info := range parsed.GetFileInfo() {
+		res.SegmentCount += len(info.File.Segments)
+		res.Files = append(res.Files, inspectedFile(info))
+	}
+	for _, info := range parsed.GetContentFiles() {
+		res.ContentFiles = append(res.ContentFiles, inspectedFile(info))
+	}
+
+	if validate {
+		if s.validator != nil {
+			for name, r := range s.validator.ValidateNZB(ctx, parsed) {
+				pa := ProviderAvailability{
+					Provider:        name,
+					Host:            r.Host,
+					Available:       r.Available,
+					CheckedArticles: r.CheckedArticles,
+					MissingArticles: r.MissingArticles,
+					Completion:      r.Completion(),
+				}
+				if r.Error != nil {
+					pa.Error = r.Error.Error()
+				}
+				res.Providers = append(res.Providers, pa)
+			}
+			sort.Slice(res.Providers, func(i, j int) bool { return res.Providers[i].Provider < res.Providers[j].Provider })
+		}
+	}
+
+	logger.Info("Inspected NZB", "nzb", nzbPath, "type", res.CompressionType, "files", res.FileCount, "size", res.TotalSize)
+	return res, nil
+}
+
+func inspectedFile(info *nzb.FileInfo) InspectedFile {
+	return InspectedFile{
+		Name:     info.Filename,
+		Size:     info.Size,
+		Segments: len(info.File.Segments),
+		IsVideo:  info.IsVideo,
+		IsSample: info.IsSample,
+		IsExtra:  info.IsExtra,
+	}
+}
@@ -2,6 +2,8 @@ package stremio
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -60,11 +62,46 @@ func NewManifest(version string) *Manifest {
 		Resources:   []string{"stream"},
 		Types:       []string{"movie", "series"},
 		Catalogs:    []Catalog{},
-		IDPrefixes:  []string{"tt", "tmdb"},
+		IDPrefixes:  []string{"tt", "tmdb", "tvdb"},
 		Logo:        "https://cdn.discordapp.com/icons/1470288400157380710/6f397b4a2e9561dc7ad43526588cfd67.png",
 	}
 }
 
+// WithResource returns a copy of the manifest that also advertises resource.
+func (m *Manifest) WithResource(resource string) *Manifest {
+	out := *m
+	out.Resources = append(append([]string{}, m.Resources...), resource)
+	return &out
+}
+
+// WithBranding returns a copy of the manifest with the configured addon name and logo;
+// empty values keep the defaults.
+func (m *Manifest) WithBranding(name, logo string) *Manifest {
+	if name == "" && logo == "" {
+		return m
+	}
+	out := *m
+	if name != "" {
+		out.Name = name
+	}
+	if logo != "" {
+		out.Logo = logo
+	}
+	return &out
+}
+
+// ValidateBrandingURL checks a configured logo or error video URL (empty = default).
+func ValidateBrandingURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL")
+	}
+	return nil
+}
+
 // ToJSONForDevice returns manifest JSON with behaviorHints set for the given device.
 // Configurable is true only for admin users (shows configure button in Stremio).
 func (m *Manifest) ToJSONForDevice(isAdmin bool) ([]byte, error) {
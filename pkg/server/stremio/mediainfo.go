@@ -0,0 +1,51 @@
+package stremio
+
+import (
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/loader"
+	"streamnzb/pkg/media/unpack"
+	"streamnzb/pkg/search/parser"
+	"streamnzb/pkg/search/triage"
+)
+
+// verifyMediaMetadata reads the header of a direct release's main video file
+// (VerifyMediaMetadata) and corrects the candidate's title-derived resolution and codec.
+// The header segment stays in the segment cache for playback.
+func verifyMediaMetadata(files []*loader.File, cand triage.Candidate) triage.Candidate {
+	info, name, ok := unpack.ProbeDirectVideo(files)
+	if !ok {
+		logger.Debug("Media metadata probe: no Matroska video track found", "title", cand.Release.Title, "file", name)
+		return cand
+	}
+	return correctMediaMetadata(cand, info)
+}
+
+// correctMediaMetadata overrides the resolution (group) and codec of cand where the
+// probed video track disagrees with the release title.
+func correctMediaMetadata(cand triage.Candidate, info unpack.VideoInfo) triage.Candidate {
+	var meta parser.ParsedRelease
+	if cand.Metadata != nil {
+		meta = *cand.Metadata
+	}
+	changed := false
+	if res := info.Resolution(); (&parser.ParsedRelease{Resolution: res}).ResolutionGroup() != cand.Group {
+		meta.Resolution = res
+		changed = true
+	}
+	if info.Codec != "" && normalizeCodec(meta.Codec) != info.Codec {
+		meta.Codec = info.Codec
+		changed = true
+	}
+	if !changed {
+		return cand
+	}
+	title := ""
+	if cand.Release != nil {
+		title = cand.Release.Title
+	}
+	logger.Info("Release title metadata corrected from the file header", "title", title,
+		"resolution", meta.Resolution, "codec", meta.Codec, "width", info.Width, "height", info.Height)
+	cand.Metadata = &meta
+	cand.Group = meta.ResolutionGroup()
+	return cand
+}
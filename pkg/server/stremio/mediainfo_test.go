@@ -0,0 +1,42 @@
+package stremio
+
+import (
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/unpack"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/search/parser"
+	"streamnzb/pkg/search/triage"
+)
+
+func TestCorrectMediaMetadata(t *testing.T) {
+	logger.Init("DEBUG")
+	candidate := func(title string) triage.Candidate {
+		meta := parser.ParseReleaseTitle(title)
+		return triage.Candidate{Release: &release.Release{Title: title}, Metadata: meta, Group: meta.ResolutionGroup()}
+	}
+
+	// Title claims 2160p HEVC, the file is 1080p AVC
+	cand := candidate("Movie.2020.2160p.WEB-DL.x265-GRP")
+	got := correctMediaMetadata(cand, unpack.VideoInfo{Width: 1920, Height: 1080, Codec: "AVC"})
+	if got.Group != "1080p" || got.Metadata.Codec != "AVC" {
+		t.Errorf("expected 1080p AVC, got %s %s", got.Group, got.Metadata.Codec)
+	}
+	if cand.Metadata.Codec == "AVC" || cand.Group != "4k" {
+		t.Error("the original candidate metadata was modified")
+	}
+
+	// Matching header: unchanged
+	cand = candidate("Movie.2020.1080p.BluRay.x264-GRP")
+	if got := correctMediaMetadata(cand, unpack.VideoInfo{Width: 1920, Height: 800, Codec: "AVC"}); got.Metadata != cand.Metadata {
+		t.Error("metadata replaced although the header agrees with the title")
+	}
+
+	// Unknown codec keeps the title codec
+	cand = candidate("Movie.2020.720p.HDTV.x264-GRP")
+	got = correctMediaMetadata(cand, unpack.VideoInfo{Width: 1280, Height: 720})
+	if got.Metadata != cand.Metadata {
+		t.Error("metadata replaced for an unknown codec")
+	}
+}
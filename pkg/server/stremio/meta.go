@@ -0,0 +1,130 @@
+package stremio
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"streamnzb/pkg/services/metadata/tmdb"
+)
+
+// MetaResponse is the response to a meta request (/meta/{type}/{id}.json)
+type MetaResponse struct {
+	Meta *Meta `json:"meta"`
+}
+
+// Meta is the Stremio meta object of a movie or show
+type Meta struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Poster      string `json:"poster,omitempty"`
+	Background  string `json:"background,omitempty"`
+	Description string `json:"description,omitempty"`
+	ReleaseInfo string `json:"releaseInfo,omitempty"`
+	// Videos lists the episodes of a series
+	Videos []Video `json:"videos,omitempty"`
+}
+
+// Video is one episode in a series meta object
+type Video struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Season   int    `json:"season"`
+	Episode  int    `json:"episode"`
+	Released string `json:"released,omitempty"`
+}
+
+// metaEnabled reports whether the meta resource is served (requires a TMDB API key).
+func (s *Server) metaEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tmdbClient.Configured()
+}
+
+// parseMetaID splits a meta content ID (tt123, tmdb:123; episode suffixes are
+// ignored) into IMDb and TMDB IDs.
+func parseMetaID(id string) (imdbID, tmdbID string) {
+	parts := strings.Split(id, ":")
+	if parts[0] == "tmdb" && len(parts) >= 2 {
+		return "", parts[1]
+	}
+	if strings.HasPrefix(parts[0], "tt") {
+		return parts[0], ""
+	}
+	return "", ""
+}
+
+// handleMeta serves TMDB-sourced metadata (name, poster, background, description, and
+// the episodes of a series) from the contentInfo cache shared with /stream.
+func (s *Server) handleMeta(w http.ResponseWriter, r *http.Request) {
+	// Parse URL: /meta/{type}/{id}.json
+	contentType, id, _, ok := parseResourcePath(r.URL.Path, "/meta/")
+	if !ok || (contentType != "movie" && contentType != "series") {
+		http.Error(w, "Invalid meta URL", http.StatusBadRequest)
+		return
+	}
+	imdbID, tmdbID := parseMetaID(id)
+	if imdbID == "" && tmdbID == "" {
+		http.Error(w, "Unsupported ID", http.StatusNotFound)
+		return
+	}
+
+	s.mu.RLock()
+	tmdbClient := s.tmdbClient
+	s.mu.RUnlock()
+	if !tmdbClient.Configured() {
+		http.Error(w, "Metadata not available", http.StatusNotFound)
+		return
+	}
+
+	info := s.resolveContentInfo(contentType, imdbID, tmdbID)
+	if info.Title == "" {
+		http.Error(w, "Metadata not found", http.StatusNotFound)
+		return
+	}
+
+	resp := MetaResponse{Meta: &Meta{
+		ID:          id,
+		Type:        contentType,
+		Name:        info.Title,
+		Poster:      info.Poster,
+		Background:  info.Background,
+		Description: info.Overview,
+		ReleaseInfo: info.Year,
+	}}
+	if contentType == "series" {
+		resp.Meta.Videos = seriesVideos(imdbID, tmdbID, s.resolveEpisodes(imdbID, tmdbID, info))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// seriesVideos builds the Stremio videos of a show; episode IDs extend the show's ID
+// (tt123:1:2 or tmdb:456:1:2) so they match the stream requests Stremio sends.
+func seriesVideos(imdbID, tmdbID string, episodes []tmdb.Episode) []Video {
+	base := imdbID
+	if base == "" {
+		base = "tmdb:" + tmdbID
+	}
+	videos := make([]Video, 0, len(episodes))
+	for _, ep := range episodes {
+		v := Video{
+			ID:      fmt.Sprintf("%s:%d:%d", base, ep.Season, ep.Number),
+			Title:   ep.Name,
+			Season:  ep.Season,
+			Episode: ep.Number,
+		}
+		if t, err := time.Parse("2006-01-02", ep.AirDate); err == nil {
+			v.Released = t.Format("2006-01-02T15:04:05.000Z")
+		}
+		if v.Title == "" {
+			v.Title = fmt.Sprintf("Episode %d", ep.Number)
+		}
+		videos = append(videos, v)
+	}
+	return videos
+}
@@ -0,0 +1,105 @@
+package stremio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/services/metadata/tmdb"
+)
+
+func TestParseMetaID(t *testing.T) {
+	tests := []struct {
+		id, imdb, tmdb string
+	}{
+		{"tt0111161", "tt0111161", ""},
+		{"tt0944947:1:2", "tt0944947", ""},
+		{"tmdb:1399", "", "1399"},
+		{"kitsu:123", "", ""},
+	}
+	for _, tt := range tests {
+		imdb, tmdbID := parseMetaID(tt.id)
+		if imdb != tt.imdb || tmdbID != tt.tmdb {
+			t.Errorf("parseMetaID(%q) = %q, %q; want %q, %q", tt.id, imdb, tmdbID, tt.imdb, tt.tmdb)
+		}
+	}
+}
+
+func TestMetaRequiresTMDB(t *testing.T) {
+	s := &Server{manifest: NewManifest("1.0.0"), tmdbClient: tmdb.NewClient(""), contentInfo: newContentInfoCache()}
+	if s.metaEnabled() {
+		t.Fatal("meta enabled without a TMDB API key")
+	}
+
+	w := httptest.NewRecorder()
+	s.handleMeta(w, httptest.NewRequest(http.MethodGet, "/meta/movie/tt0111161.json", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+
+	m := s.manifest.WithResource("meta")
+	if !slices.Contains(m.Resources, "meta") || slices.Contains(s.manifest.Resources, "meta") {
+		t.Errorf("WithResource: got %v, base %v", m.Resources, s.manifest.Resources)
+	}
+}
+
+func TestSeriesVideos(t *testing.T) {
+	episodes := []tmdb.Episode{
+		{Season: 1, Number: 1, Name: "Pilot", AirDate: "2011-04-17"},
+		{Season: 1, Number: 2, AirDate: "TBA"},
+	}
+	want := []Video{
+		{ID: "tt0944947:1:1", Title: "Pilot", Season: 1, Episode: 1, Released: "2011-04-17T00:00:00.000Z"},
+		{ID: "tt0944947:1:2", Title: "Episode 2", Season: 1, Episode: 2},
+	}
+	if got := seriesVideos("tt0944947", "", episodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("seriesVideos(imdb) = %+v; want %+v", got, want)
+	}
+	if got := seriesVideos("", "1399", episodes[:1]); len(got) != 1 || got[0].ID != "tmdb:1399:1:1" {
+		t.Errorf("seriesVideos(tmdb) = %+v", got)
+	}
+}
+
+func TestMetaUsesContentInfoCache(t *testing.T) {
+	s := &Server{tmdbClient: tmdb.NewClient("key"), contentInfo: newContentInfoCache()}
+	// Cached entries, as left by an earlier /stream or /meta request: no TMDB call is made
+	s.contentInfo.entries[contentInfoKey("series", "tt0944947", "")] = contentInfoEntry{
+		info:           contentInfo{Title: "Game of Thrones", Year: "2011", TMDBID: 1399, Poster: "https://image.tmdb.org/t/p/w500/p.jpg"},
+		expires:        time.Now().Add(time.Hour),
+		episodes:       []tmdb.Episode{{Season: 1, Number: 1, Name: "Winter Is Coming", AirDate: "2011-04-17"}},
+		episodesLoaded: true,
+	}
+	s.contentInfo.entries[contentInfoKey("movie", "tt0111161", "")] = contentInfoEntry{
+		info:    contentInfo{Title: "The Shawshank Redemption", Year: "1994", TMDBID: 278},
+		expires: time.Now().Add(time.Hour),
+	}
+
+	get := func(path string) MetaResponse {
+		w := httptest.NewRecorder()
+		s.handleMeta(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d", path, w.Code)
+		}
+		var resp MetaResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	series := get("/meta/series/tt0944947.json").Meta
+	if series.Name != "Game of Thrones" || series.ReleaseInfo != "2011" || series.Poster == "" {
+		t.Errorf("series meta = %+v", series)
+	}
+	if len(series.Videos) != 1 || series.Videos[0].ID != "tt0944947:1:1" || series.Videos[0].Title != "Winter Is Coming" {
+		t.Errorf("series videos = %+v", series.Videos)
+	}
+	movie := get("/meta/movie/tt0111161.json").Meta
+	if movie.Name != "The Shawshank Redemption" || movie.Videos != nil {
+		t.Errorf("movie meta = %+v", movie)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/release"
 	"streamnzb/pkg/search/parser"
 	"streamnzb/pkg/search/triage"
@@ -11,22 +12,33 @@ import (
 
 // buildStreamMetadata creates a rich Stream object with PTT metadata.
 // rel is the canonical release for deduplication; may be nil for legacy paths.
-func buildStreamMetadata(url, filename string, cand triage.Candidate, sizeGB float64, totalBytes int64, rel *release.Release) Stream {
+// The title is rendered from titleTemplate (StreamTitleTemplate; empty = default).
+// providers is the number of providers known to have the release (0 = not validated here).
+func buildStreamMetadata(url, filename string, cand triage.Candidate, sizeGB float64, totalBytes int64, rel *release.Release, providers int, content contentInfo, titleTemplate string) Stream {
 	meta := cand.Metadata
 
 	name := buildStreamName(meta, cand.Group)
-	description := buildDetailedDescription(meta, sizeGB, filename)
+	data := newStreamTemplateData(filename, cand, sizeGB, totalBytes, rel, providers)
+	data.Title, data.Year = content.Title, content.Year
+	title, err := renderStreamTemplate(streamTitleTemplate(titleTemplate), data)
+	if err != nil {
+		logger.Debug("Stream title template failed, using default", "err", err)
+		title, _ = renderStreamTemplate(streamTitleTemplate(""), data)
+	}
 	hints := &BehaviorHints{
 		NotWebReady: false,
-		BingeGroup:  fmt.Sprintf("streamnzb|%s", cand.Group),
 		VideoSize:   totalBytes,
 		Filename:    filename,
 	}
+	if content.SeriesID != "" {
+		// Same show and resolution: Stremio auto-plays the next episode with this stream
+		hints.BingeGroup = fmt.Sprintf("streamnzb-%s-%s", content.SeriesID, cand.Group)
+	}
 
 	return Stream{
 		URL:            url,
 		Name:           name,
-		Description:    description,
+		Title:          title,
 		BehaviorHints:  hints,
 		StreamType:     "usenet",
 		Score:          cand.Score,
@@ -53,96 +65,3 @@ func buildStreamName(meta *parser.ParsedRelease, group string) string {
 
 	return strings.Join(parts, " ")
 }
-
-// buildDetailedDescription creates the right-side technical details
-func buildDetailedDescription(meta *parser.ParsedRelease, sizeGB float64, filename string) string {
-	lines := []string{}
-
-	// Line 1: Source + Codec + Quality
-	line1 := []string{}
-	if meta.Quality != "" {
-		line1 = append(line1, fmt.Sprintf("📡 %s", meta.Quality))
-	}
-	if meta.Codec != "" {
-		codec := strings.ToUpper(meta.Codec)
-		codec = strings.ReplaceAll(codec, "H.265", "HEVC")
-		codec = strings.ReplaceAll(codec, "H.264", "AVC")
-		codec = strings.ReplaceAll(codec, "X265", "HEVC")
-		codec = strings.ReplaceAll(codec, "X264", "AVC")
-		line1 = append(line1, fmt.Sprintf("🎞️ %s", codec))
-	}
-	if meta.Container != "" {
-		line1 = append(line1, fmt.Sprintf("📦 %s", strings.ToUpper(meta.Container)))
-	}
-	if len(line1) > 0 {
-		lines = append(lines, strings.Join(line1, " "))
-	}
-
-	// Line 2: Visual Tags (HDR/3D) + Audio
-	// PTT ThreeD formats: "3D", "3D HSBS", "3D SBS", "3D HOU", "3D OU"
-	line2 := []string{}
-	visualTags := make([]string, 0)
-	visualTags = append(visualTags, meta.HDR...)
-	if meta.ThreeD != "" {
-		// Preserve the actual 3D format from PTT
-		visualTags = append(visualTags, meta.ThreeD)
-	}
-	if len(visualTags) > 0 {
-		tags := strings.Join(visualTags, "|")
-		line2 = append(line2, fmt.Sprintf("📺 %s", tags))
-	}
-	if len(meta.Audio) > 0 {
-		audio := meta.Audio[0]
-		if len(meta.Channels) > 0 {
-			audio = fmt.Sprintf("%s %s", audio, meta.Channels[0])
-		}
-		line2 = append(line2, fmt.Sprintf("🎧 %s", audio))
-	}
-	if len(line2) > 0 {
-		lines = append(lines, strings.Join(line2, " • "))
-	}
-
-	// Line 3: Special flags
-	flags := []string{}
-	if meta.Proper {
-		flags = append(flags, "⚡ PROPER")
-	}
-	if meta.Repack {
-		flags = append(flags, "🔄 REPACK")
-	}
-	if meta.Extended {
-		flags = append(flags, "⏱️ EXTENDED")
-	}
-	if meta.Unrated {
-		flags = append(flags, "🔞 UNRATED")
-	}
-	if meta.ThreeD != "" {
-		flags = append(flags, "🕶️ 3D")
-	}
-	if len(flags) > 0 {
-		lines = append(lines, strings.Join(flags, " "))
-	}
-
-	// Line 4: Size + Release Group
-	line4 := []string{}
-	if sizeGB > 0 {
-		line4 = append(line4, fmt.Sprintf("💾 %.2f GB", sizeGB))
-	} else {
-		line4 = append(line4, "💾 Size Unknown")
-	}
-	if meta.Group != "" {
-		line4 = append(line4, fmt.Sprintf("👥 %s", meta.Group))
-	}
-	lines = append(lines, strings.Join(line4, " • "))
-
-	// Line 5: Languages
-	if len(meta.Languages) > 0 {
-		langs := strings.Join(meta.Languages, " | ")
-		lines = append(lines, fmt.Sprintf("🌍 %s", langs))
-	}
-
-	// Line 6: Filename
-	lines = append(lines, fmt.Sprintf("📄 %s", filename))
-
-	return strings.Join(lines, "\n")
-}
@@ -30,6 +30,9 @@ type Stream struct {
 	// Release is the canonical release for deduplication and identity; not sent to client
 	Release *release.Release `json:"-"`
 
+	// ContentHash identifies the NZB content (first article Message-ID hash); set for validated streams only
+	ContentHash string `json:"-"`
+
 	// Optional metadata (shown in Stremio UI)
 	Title         string         `json:"title,omitempty"`
 	Description   string         `json:"description,omitempty"`
@@ -0,0 +1,81 @@
+package stremio
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"streamnzb/pkg/media/nzb"
+)
+
+// Some direct releases carry several features (theatrical + extended cut, a movie plus a
+// bonus film). Each one gets its own session and stream entry instead of only the largest.
+const (
+	maxFeatureFiles     = 4
+	featureFileMinRatio = 0.4       // of the largest video file; smaller ones are extras
+	featureFileMinBytes = 300 << 20 // below this a video file is never a feature
+)
+
+// featureFiles returns the main video files of a direct (non-archive) release, largest
+// first, when it has more than one; nil otherwise.
+func featureFiles(n *nzb.NZB) []*nzb.FileInfo {
+	if n.CompressionType() != "direct" {
+		return nil
+	}
+	var videos []*nzb.FileInfo
+	var largest int64
+	for _, info := range n.GetFileInfo() {
+		if !info.IsVideo || info.IsSample || info.IsExtra {
+			continue
+		}
+		videos = append(videos, info)
+		largest = max(largest, info.Size)
+	}
+	minSize := max(int64(float64(largest)*featureFileMinRatio), featureFileMinBytes)
+	var features []*nzb.FileInfo
+	for _, info := range videos {
+		if info.Size >= minSize {
+			features = append(features, info)
+		}
+	}
+	if len(features) < 2 {
+		return nil
+	}
+	sort.SliceStable(features, func(i, j int) bool { return features[i].Size > features[j].Size })
+	if len(features) > maxFeatureFiles {
+		features = features[:maxFeatureFiles]
+	}
+	return features
+}
+
+// featureSessionID is the session of the i-th feature file of a release.
+func featureSessionID(sessionID string, i int) string {
+	return fmt.Sprintf("%s-f%d", sessionID, i+1)
+}
+
+// singleFileNZB is n restricted to one file, so the session plays exactly that file.
+func singleFileNZB(n *nzb.NZB, info *nzb.FileInfo) *nzb.NZB {
+	return &nzb.NZB{Head: n.Head, Files: []nzb.File{*info.File}}
+}
+
+// featureLabel names a feature file for the stream list: its edition when the file name
+// has one (e.g. "Extended Edition", "Theatrical"), otherwise the file name.
+func featureLabel(info *nzb.FileInfo) string {
+	if p := info.ParsedInfo; p != nil {
+		switch {
+		case p.Edition != "":
+			return p.Edition
+		case p.Extended:
+			return "Extended"
+		case p.Unrated:
+			return "Unrated"
+		}
+	}
+	return strings.TrimSuffix(info.Filename, filepath.Ext(info.Filename))
+}
+
+// featureTitleLine is the first title line of a per-file stream.
+func featureTitleLine(info *nzb.FileInfo, index, total int) string {
+	return fmt.Sprintf("🎞️ %s • %.2f GB (file %d of %d)", featureLabel(info), float64(info.Size)/(1<<30), index+1, total)
+}
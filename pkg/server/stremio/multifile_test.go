@@ -0,0 +1,58 @@
+package stremio
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/nzb"
+)
+
+func testNZB(files map[string]int64) *nzb.NZB {
+	n := &nzb.NZB{}
+	for name, size := range files {
+		n.Files = append(n.Files, nzb.File{
+			Subject:  fmt.Sprintf("%q yEnc (1/1)", name),
+			Segments: []nzb.Segment{{ID: name + "@test", Bytes: size, Number: 1}},
+		})
+	}
+	return n
+}
+
+func TestFeatureFiles(t *testing.T) {
+	logger.Init("DEBUG")
+	const gb = 1 << 30
+	n := testNZB(map[string]int64{
+		"Movie.2001.Theatrical.1080p.BluRay.x264-GRP.mkv":       9 * gb,
+		"Movie.2001.Extended.Edition.1080p.BluRay.x264-GRP.mkv": 12 * gb,
+		"Movie.2001.Behind.The.Scenes.1080p.mkv":                gb,
+		"Movie.2001.1080p-sample.mkv":                           100 << 20,
+		"Movie.2001.nfo":                                        4096,
+	})
+	features := featureFiles(n)
+	if len(features) != 2 {
+		t.Fatalf("expected 2 feature files, got %d", len(features))
+	}
+	if got := featureLabel(features[0]); got != "Extended Edition" {
+		t.Errorf("largest feature label = %q, want Extended Edition", got)
+	}
+	if got := featureLabel(features[1]); got != "Theatrical" {
+		t.Errorf("second feature label = %q, want Theatrical", got)
+	}
+	single := singleFileNZB(n, features[1])
+	if len(single.Files) != 1 || !strings.Contains(single.Files[0].Subject, "Theatrical") {
+		t.Errorf("single-file NZB has the wrong files: %+v", single.Files)
+	}
+	if id := featureSessionID("abc", 1); id != "abc-f2" {
+		t.Errorf("featureSessionID = %q", id)
+	}
+
+	// One feature plus extras, and archives, keep a single stream
+	if f := featureFiles(testNZB(map[string]int64{"Movie.mkv": 9 * gb, "Featurette.mkv": gb})); f != nil {
+		t.Errorf("expected no split for a single feature, got %d files", len(f))
+	}
+	if f := featureFiles(testNZB(map[string]int64{"Movie.part1.rar": 9 * gb, "Movie.part2.rar": 9 * gb})); f != nil {
+		t.Errorf("expected no split for an archive release, got %d files", len(f))
+	}
+}
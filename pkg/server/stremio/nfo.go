@@ -0,0 +1,61 @@
+package stremio
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/release"
+)
+
+// nfoLink returns the /nfo URL for a stream when NFO fetching is enabled and the
+// release's indexer supports it (Easynews and AvailNZB releases don't).
+func (s *Server) nfoLink(token, sessionID string, rel *release.Release) string {
+	if !s.config.FetchNFO || token == "" || rel == nil || rel.SourceIndexer == nil {
+		return ""
+	}
+	if _, ok := rel.SourceIndexer.(indexer.IndexerWithNFO); !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/nfo/%s", s.baseURL, token, sessionID)
+}
+
+// handleNFO serves the release NFO of a session as plain text. The NFO is fetched
+// from the indexer on the first request and kept on the session.
+func (s *Server) handleNFO(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.TrimPrefix(r.URL.Path, "/nfo/")
+	sess, err := s.sessionManager.GetSession(sessionID)
+	if err != nil {
+		http.Error(w, "Session expired or not found", http.StatusNotFound)
+		return
+	}
+
+	nfo := sess.NFO()
+	if nfo == "" {
+		var src indexer.IndexerWithNFO
+		if sess.Release != nil {
+			src, _ = sess.Release.SourceIndexer.(indexer.IndexerWithNFO)
+		}
+		if src == nil {
+			http.Error(w, "NFO not available for this release", http.StatusNotFound)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		nfo, err = src.GetNFO(ctx, sess.Release)
+		cancel()
+		if err != nil {
+			logger.Debug("NFO fetch failed", "session", sessionID, "err", err)
+			http.Error(w, "NFO not available: "+err.Error(), http.StatusNotFound)
+			return
+		}
+		sess.SetNFO(nfo)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write([]byte(nfo))
+}
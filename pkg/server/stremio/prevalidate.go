@@ -0,0 +1,181 @@
+package stremio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+const (
+	// Episodes validated at once; each episode already validates candidates in parallel
+	prevalidateConcurrency = 2
+	// Per-episode budget for search + validation
+	prevalidateEpisodeTimeout = 60 * time.Second
+	maxPrevalidateEpisodes    = 100
+)
+
+// prevalidateEpisodeDelay staggers episode starts so a season run does not hammer
+// indexers/providers. A var so tests can shorten it.
+var prevalidateEpisodeDelay = 3 * time.Second
+
+// EpisodeStatus reports the pre-validation outcome of a single episode.
+type EpisodeStatus struct {
+	Season  int    `json:"season"`
+	Episode int    `json:"episode"`
+	Ready   bool   `json:"ready"`
+	Streams int    `json:"streams"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PrevalidateSeason runs the stream pipeline (search, triage, validation and AvailNZB
+// reporting) for every episode of a season so later stream requests hit warm caches.
+// seriesID is an IMDb ID (tt123), "tvdb:<id>" or "tmdb:<id>". When episodes is 0 the
+// episode count is looked up on TMDB. At most prevalidateConcurrency episodes run at
+// once; progress, if non-nil, is called as each finishes (not necessarily in order).
+// The returned statuses are in episode order.
+func (s *Server) PrevalidateSeason(ctx context.Context, seriesID string, season, episodes int, progress func(EpisodeStatus)) ([]EpisodeStatus, error) {
+	if season < 0 {
+		return nil, errors.New("invalid season")
+	}
+	streamID, tmdbID, err := s.resolvePrevalidateID(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if episodes <= 0 {
+		if tmdbID == 0 {
+			return nil, errors.New("episode count unknown: pass episodes or configure TMDB")
+		}
+		d, err := s.tmdbClient.GetSeasonDetails(tmdbID, season)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch season episodes: %w", err)
+		}
+		today := time.Now().Format("2006-01-02")
+		for _, ep := range d.Episodes {
+			// Skip episodes that have not aired yet
+			if ep.AirDate != "" && ep.AirDate > today {
+				continue
+			}
+			if ep.EpisodeNumber > episodes {
+				episodes = ep.EpisodeNumber
+			}
+		}
+		if episodes == 0 {
+			return nil, errors.New("season has no aired episodes")
+		}
+	}
+	if episodes > maxPrevalidateEpisodes {
+		episodes = maxPrevalidateEpisodes
+	}
+
+	logger.Info("Prevalidating season", "id", seriesID, "season", season, "episodes", episodes)
+	statuses := make([]*EpisodeStatus, episodes)
+	sem := make(chan struct{}, prevalidateConcurrency)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+launch:
+	for ep := 1; ep <= episodes; ep++ {
+		if ep > 1 {
+			select {
+			case <-ctx.Done():
+				break launch
+			case <-time.After(prevalidateEpisodeDelay):
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break launch
+		}
+
+		wg.Add(1)
+		go func(ep int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			status := s.prevalidateEpisode(ctx, streamID, season, ep)
+			logger.Debug("Prevalidated episode", "id", seriesID, "season", season, "episode", ep, "ready", status.Ready, "streams", status.Streams)
+
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[ep-1] = &status
+			if progress != nil {
+				progress(status)
+			}
+		}(ep)
+	}
+	wg.Wait()
+
+	results := make([]EpisodeStatus, 0, episodes)
+	for _, st := range statuses {
+		if st != nil {
+			results = append(results, *st)
+		}
+	}
+	return results, ctx.Err()
+}
+
+// prevalidateEpisode runs the stream pipeline for one episode within prevalidateEpisodeTimeout.
+func (s *Server) prevalidateEpisode(ctx context.Context, streamID string, season, ep int) EpisodeStatus {
+	status := EpisodeStatus{Season: season, Episode: ep}
+	epCtx, cancel := context.WithTimeout(ctx, prevalidateEpisodeTimeout)
+	defer cancel()
+	streams, err := s.searchAndValidate(epCtx, "series", fmt.Sprintf("%s:%d:%d", streamID, season, ep), nil, false)
+	if err != nil {
+		status.Error = err.Error()
+	}
+	for _, st := range streams {
+		// The "retry to validate more" placeholder carries no release
+		if st.Release != nil {
+			status.Streams++
+		}
+	}
+	status.Ready = status.Streams > 0
+	return status
+}
+
+// resolvePrevalidateID maps a series ID to the form searchAndValidate expects and,
+// when TMDB is available, the TMDB ID used for the episode list.
+func (s *Server) resolvePrevalidateID(seriesID string) (string, int, error) {
+	seriesID = strings.TrimSpace(seriesID)
+	haveTMDB := s.tmdbClient != nil
+	switch {
+	case strings.HasPrefix(seriesID, "tt"):
+		if !haveTMDB {
+			return seriesID, 0, nil
+		}
+		if find, err := s.tmdbClient.Find(seriesID, "imdb_id"); err == nil && len(find.TVResults) > 0 {
+			return seriesID, find.TVResults[0].ID, nil
+		}
+		return seriesID, 0, nil
+	case strings.HasPrefix(seriesID, "tmdb:"):
+		id, err := strconv.Atoi(strings.TrimPrefix(seriesID, "tmdb:"))
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid TMDB ID %q", seriesID)
+		}
+		return seriesID, id, nil
+	case strings.HasPrefix(seriesID, "tvdb:"):
+		tvdbID := strings.TrimPrefix(seriesID, "tvdb:")
+		if _, err := strconv.Atoi(tvdbID); err != nil {
+			return "", 0, fmt.Errorf("invalid TVDB ID %q", seriesID)
+		}
+		if !haveTMDB {
+			return "", 0, errors.New("TVDB IDs require TMDB to resolve the series")
+		}
+		find, err := s.tmdbClient.Find(tvdbID, "tvdb_id")
+		if err != nil || len(find.TVResults) == 0 {
+			return "", 0, fmt.Errorf("no series found for %s", seriesID)
+		}
+		tmdbID := find.TVResults[0].ID
+		// Prefer IMDb so the pipeline can resolve the TVDB ID and query AvailNZB
+		if ext, err := s.tmdbClient.GetExternalIDs(tmdbID, "tv"); err == nil && ext.IMDbID != "" {
+			return ext.IMDbID, tmdbID, nil
+		}
+		return fmt.Sprintf("tmdb:%d", tmdbID), tmdbID, nil
+	}
+	return "", 0, fmt.Errorf("unsupported series ID %q (use tt..., tvdb:... or tmdb:...)", seriesID)
+}
@@ -0,0 +1,126 @@
+package stremio
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/search"
+	"streamnzb/pkg/search/triage"
+	"streamnzb/pkg/services/metadata/tmdb"
+	"streamnzb/pkg/session"
+	"streamnzb/pkg/usenet/validation"
+)
+
+// slowIndexer holds every search for a while and records how many episodes were searched at once.
+type slowIndexer struct {
+	recordingIndexer
+
+	mu       sync.Mutex
+	inFlight map[string]int
+	peak     int
+	searched map[string]bool
+}
+
+func (x *slowIndexer) Search(req indexer.SearchRequest) (*indexer.SearchResponse, error) {
+	x.mu.Lock()
+	x.inFlight[req.Episode]++
+	x.searched[req.Episode] = true
+	if len(x.inFlight) > x.peak {
+		x.peak = len(x.inFlight)
+	}
+	x.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	x.mu.Lock()
+	if x.inFlight[req.Episode]--; x.inFlight[req.Episode] == 0 {
+		delete(x.inFlight, req.Episode)
+	}
+	x.mu.Unlock()
+	return &indexer.SearchResponse{}, nil
+}
+
+func prevalidateTestServer(idx indexer.Indexer) *Server {
+	cfg := &config.Config{MaxStreams: 1}
+	return &Server{
+		config:         cfg,
+		indexer:        idx,
+		validator:      validation.NewChecker(nil, nil, time.Minute, 1, 1, 1),
+		sessionManager: session.NewManager(nil, time.Minute),
+		triageService:  triage.NewService(&cfg.Filters, cfg.Sorting),
+		attempts:       newAttemptTracker(),
+		tmdbClient:     tmdb.NewClient(""),
+		contentInfo:    newContentInfoCache(),
+	}
+}
+
+func TestPrevalidateSeasonBoundsConcurrency(t *testing.T) {
+	logger.Init("DEBUG")
+	delay := prevalidateEpisodeDelay
+	prevalidateEpisodeDelay = 0
+	t.Cleanup(func() { prevalidateEpisodeDelay = delay })
+	search.ClearSearchCache() // a cached search would never reach the indexer
+
+	idx := &slowIndexer{inFlight: make(map[string]int), searched: make(map[string]bool)}
+	s := prevalidateTestServer(idx)
+	var progressed []int
+	results, err := s.PrevalidateSeason(context.Background(), "tt7200001", 1, 6, func(st EpisodeStatus) {
+		progressed = append(progressed, st.Episode)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 6 || len(progressed) != 6 {
+		t.Fatalf("got %d results and %d progress calls; want 6", len(results), len(progressed))
+	}
+	for i, st := range results {
+		if st.Episode != i+1 || st.Season != 1 || st.Ready {
+			t.Errorf("result %d = %+v", i, st)
+		}
+		if !idx.searched[strconv.Itoa(i+1)] {
+			t.Errorf("episode %d was never searched", i+1)
+		}
+	}
+	if idx.peak > prevalidateConcurrency {
+		t.Errorf("%d episodes searched at once; limit is %d", idx.peak, prevalidateConcurrency)
+	}
+	if idx.peak < 2 {
+		t.Errorf("episodes ran one at a time (peak %d)", idx.peak)
+	}
+}
+
+func TestPrevalidateSeasonCancel(t *testing.T) {
+	logger.Init("DEBUG")
+	delay := prevalidateEpisodeDelay
+	prevalidateEpisodeDelay = time.Hour
+	t.Cleanup(func() { prevalidateEpisodeDelay = delay })
+
+	s := prevalidateTestServer(&slowIndexer{inFlight: make(map[string]int), searched: make(map[string]bool)})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	var results []EpisodeStatus
+	var err error
+	go func() {
+		results, err = s.PrevalidateSeason(ctx, "tt7200002", 1, 5, nil)
+		close(done)
+	}()
+	time.Sleep(200 * time.Millisecond) // first episode done, waiting to start the second
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("PrevalidateSeason did not return after cancellation")
+	}
+	if err != context.Canceled {
+		t.Errorf("err = %v; want context.Canceled", err)
+	}
+	if len(results) != 1 || results[0].Episode != 1 {
+		t.Errorf("results after cancel = %+v; want episode 1 only", results)
+	}
+}
@@ -0,0 +1,94 @@
+package stremio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/session"
+)
+
+var (
+	quotaDevicesOnce sync.Once
+	quotaDevices     *auth.DeviceManager
+)
+
+// quotaDeviceManager returns the process-wide device manager, backed by a temp dir.
+func quotaDeviceManager(t *testing.T) *auth.DeviceManager {
+	t.Helper()
+	quotaDevicesOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "streamnzb-stremio-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if quotaDevices, err = auth.GetDeviceManager(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return quotaDevices
+}
+
+type nopSeekCloser struct{ io.ReadSeeker }
+
+func (nopSeekCloser) Close() error { return nil }
+
+func TestStreamMonitorEnforcesQuota(t *testing.T) {
+	logger.Init("DEBUG")
+	dm := quotaDeviceManager(t)
+	username := fmt.Sprintf("quota-tv-%d", time.Now().UnixNano()) // usage outlives the test
+	if _, err := dm.CreateDevice(username, "", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := dm.UpdateDeviceQuota(username, 2000.0/(1<<30)); err != nil {
+		t.Fatal(err)
+	}
+	device, err := dm.GetDevice(username, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dm.QuotaExceeded(device) {
+		t.Fatal("quota exceeded before anything was served")
+	}
+
+	read := func(n int) {
+		m := &StreamMonitor{
+			ReadSeekCloser: nopSeekCloser{bytes.NewReader(make([]byte, n))},
+			sessionID:      "quota-session",
+			manager:        session.NewManager(nil, time.Minute),
+			lastUpdate:     time.Now(),
+			usage:          dm,
+			username:       device.Username,
+		}
+		if _, err := io.Copy(io.Discard, m); err != nil {
+			t.Fatal(err)
+		}
+		m.flushUsage()
+	}
+	read(1500)
+	if got := dm.MonthlyBytesServed(device.Username); got != 1500 {
+		t.Fatalf("MonthlyBytesServed = %d; want 1500", got)
+	}
+	if dm.QuotaExceeded(device) {
+		t.Error("quota exceeded below the limit")
+	}
+	read(500)
+	if !dm.QuotaExceeded(device) {
+		t.Errorf("quota not exceeded after %d bytes", dm.MonthlyBytesServed(device.Username))
+	}
+
+	if err := dm.UpdateDeviceQuota(username, 0); err != nil {
+		t.Fatal(err)
+	}
+	if device, _ = dm.GetDevice(username, "admin"); dm.QuotaExceeded(device) {
+		t.Error("quota 0 should be unlimited")
+	}
+	if dm.QuotaExceeded(nil) {
+		t.Error("nil device has no quota")
+	}
+}
@@ -0,0 +1,149 @@
+package stremio
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/search/triage"
+)
+
+// Allow time for indexer search plus NNTP validation across providers.
+// 5s was too short: slow indexers + validation often exceeded it and returned 0 streams.
+const streamRequestTimeout = 30 * time.Second
+
+const (
+	attemptTTL = time.Hour // how long validated candidates are remembered per content
+	attemptMax = 1000      // tracked device/content pairs
+
+	// refreshReuseWindow is how long a refresh result with a stream is reused by the next refresh
+	refreshReuseWindow = 15 * time.Second
+)
+
+// attemptTracker remembers which indexer candidates were already validated per
+// device and content, so refreshes continue with the next batch instead of
+// validating the same failures again.
+type attemptTracker struct {
+	mu      sync.Mutex
+	entries map[streamCacheKey]*attemptEntry
+}
+
+type attemptEntry struct {
+	keys    map[string]bool
+	expires time.Time
+}
+
+func newAttemptTracker() *attemptTracker {
+	return &attemptTracker{entries: make(map[streamCacheKey]*attemptEntry)}
+}
+
+// attemptKey identifies a candidate across searches (indexer details page, else GUID or link).
+func attemptKey(rel *release.Release) string {
+	if rel == nil {
+		return ""
+	}
+	if rel.DetailsURL != "" {
+		return rel.DetailsURL
+	}
+	if rel.GUID != "" {
+		return rel.GUID
+	}
+	return rel.Link
+}
+
+// skipAttempted returns the candidates not validated before for key, and how many were skipped.
+func (t *attemptTracker) skipAttempted(key streamCacheKey, candidates []triage.Candidate) ([]triage.Candidate, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return candidates, 0
+	}
+	out := make([]triage.Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		if k := attemptKey(c.Release); k != "" && e.keys[k] {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, len(candidates) - len(out)
+}
+
+// record adds the validated candidates for key. reset starts a new history
+// (a regular /stream request), otherwise the batch is appended (a refresh).
+func (t *attemptTracker) record(key streamCacheKey, candidates []triage.Candidate, reset bool) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.entries[key]
+	if !ok || reset || now.After(e.expires) {
+		if len(t.entries) >= attemptMax {
+			for k, old := range t.entries {
+				if now.After(old.expires) {
+					delete(t.entries, k)
+				}
+			}
+			if len(t.entries) >= attemptMax {
+				t.entries = make(map[streamCacheKey]*attemptEntry)
+			}
+		}
+		e = &attemptEntry{keys: make(map[string]bool)}
+		t.entries[key] = e
+	}
+	for _, c := range candidates {
+		if k := attemptKey(c.Release); k != "" {
+			e.keys[k] = true
+		}
+	}
+	e.expires = now.Add(attemptTTL)
+}
+
+// handleStreamRefresh is the URL of the "0/N OK" placeholder stream:
+// /stream-refresh/{type}/{id}. Playing it validates the next batch of candidates,
+// caches the result for the stream list, and redirects the player to the first
+// stream found (or the error video when the batch had none).
+func (s *Server) handleStreamRefresh(w http.ResponseWriter, r *http.Request, device *auth.Device) {
+	contentType, id, _, ok := parseResourcePath(r.URL.Path, "/stream-refresh/")
+	if !ok {
+		http.Error(w, "Invalid refresh URL", http.StatusBadRequest)
+		return
+	}
+	logger.Info("Stream refresh request", "type", contentType, "id", id)
+
+	ctx, cancel := context.WithTimeout(r.Context(), streamRequestTimeout)
+	defer cancel()
+
+	key := newStreamCacheKey(device, contentType, id)
+	// Players often probe the URL (HEAD) before the GET: reuse a batch validated moments ago
+	// instead of burning the next one, so both requests land on the same stream
+	s.streamCache.InvalidateUnlessRecent(key, refreshReuseWindow, func(streams []Stream) bool {
+		return firstPlayable(streams) != ""
+	})
+	ttl := time.Duration(s.config.StreamCacheTTLSeconds) * time.Second
+	streams, err := s.streamCache.Do(ctx, key, ttl, func() ([]Stream, error) {
+		return s.searchAndValidate(ctx, contentType, id, device, true)
+	})
+	if err != nil {
+		logger.Error("Error refreshing streams", "err", err)
+	}
+	if url := firstPlayable(streams); url != "" {
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+	http.Redirect(w, r, s.errorVideoURL(), http.StatusFound)
+}
+
+// firstPlayable returns the URL of the first stream with a release; the placeholder
+// itself has none.
+func firstPlayable(streams []Stream) string {
+	for _, stream := range streams {
+		if stream.Release != nil && stream.URL != "" {
+			return stream.URL
+		}
+	}
+	return ""
+}
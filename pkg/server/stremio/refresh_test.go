@@ -0,0 +1,88 @@
+package stremio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/config"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/search/triage"
+)
+
+func TestAttemptTrackerAdvancesBatches(t *testing.T) {
+	cands := func(urls ...string) []triage.Candidate {
+		out := make([]triage.Candidate, 0, len(urls))
+		for _, u := range urls {
+			out = append(out, triage.Candidate{Release: &release.Release{DetailsURL: u}})
+		}
+		return out
+	}
+	all := cands("a", "b", "c", "d")
+	key := streamCacheKey{device: "tok", contentType: "movie", id: "tt1"}
+	tr := newAttemptTracker()
+
+	tr.record(key, all[:2], true)
+	rest, skipped := tr.skipAttempted(key, all)
+	if skipped != 2 || len(rest) != 2 || rest[0].Release.DetailsURL != "c" {
+		t.Fatalf("first refresh: skipped=%d rest=%d", skipped, len(rest))
+	}
+
+	tr.record(key, rest[:1], false)
+	if rest, skipped = tr.skipAttempted(key, all); skipped != 3 || rest[0].Release.DetailsURL != "d" {
+		t.Fatalf("second refresh: skipped=%d", skipped)
+	}
+
+	// A regular request starts over
+	tr.record(key, all[:1], true)
+	if _, skipped = tr.skipAttempted(key, all); skipped != 1 {
+		t.Fatalf("after reset: skipped=%d", skipped)
+	}
+}
+
+func TestStreamRefreshReusesRecentResult(t *testing.T) {
+	logger.Init("DEBUG")
+	device := &auth.Device{Username: "tv", Token: "tok"}
+	s := &Server{config: &config.Config{StreamCacheTTLSeconds: 30}, streamCache: newStreamCache(0)}
+	key := newStreamCacheKey(device, "movie", "tt1")
+	playable := []Stream{{URL: "http://host/tok/play/abc", Release: &release.Release{Title: "Movie"}}}
+	s.streamCache.Do(context.Background(), key, time.Minute, func() ([]Stream, error) { return playable, nil })
+
+	// HEAD probe then GET: both land on the stream validated moments ago
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		w := httptest.NewRecorder()
+		s.handleStreamRefresh(w, httptest.NewRequest(method, "/stream-refresh/movie/tt1.json", nil), device)
+		if got := w.Header().Get("Location"); got != playable[0].URL {
+			t.Errorf("%s redirected to %q; want %q", method, got, playable[0].URL)
+		}
+	}
+}
+
+func TestStreamCacheInvalidateUnlessRecent(t *testing.T) {
+	c := newStreamCache(0)
+	key := streamCacheKey{device: "tok", contentType: "movie", id: "tt1"}
+	usable := func(streams []Stream) bool { return firstPlayable(streams) != "" }
+	placeholder := []Stream{{URL: "http://host/tok/stream-refresh/movie/tt1"}}
+
+	c.Do(context.Background(), key, time.Minute, func() ([]Stream, error) { return placeholder, nil })
+	c.InvalidateUnlessRecent(key, time.Minute, usable)
+	if _, ok := c.entries[key]; ok {
+		t.Error("recent result without a playable stream was kept")
+	}
+
+	c.Do(context.Background(), key, time.Minute, func() ([]Stream, error) {
+		return []Stream{{URL: "http://host/play", Release: &release.Release{}}}, nil
+	})
+	c.InvalidateUnlessRecent(key, time.Minute, usable)
+	if _, ok := c.entries[key]; !ok {
+		t.Error("recent playable result was dropped")
+	}
+	c.InvalidateUnlessRecent(key, 0, usable)
+	if _, ok := c.entries[key]; ok {
+		t.Error("old result was kept")
+	}
+}
@@ -0,0 +1,49 @@
+package stremio
+
+import (
+	"net/url"
+	"strings"
+)
+
+// parseResourcePath splits a Stremio resource path (/{resource}/{type}/{id}.json, or
+// /{resource}/{type}/{id}/{extra}.json when the client sends extras such as "skip=0")
+// into the content type, the content ID and the extra arguments. IDs arriving
+// percent-encoded ("tt0944947%3A1%3A2") are decoded. ok is false when the type or ID
+// is missing.
+func parseResourcePath(path, prefix string) (contentType, id string, extra url.Values, ok bool) {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, prefix), "/")
+	path = strings.TrimSuffix(path, ".json")
+	parts := strings.SplitN(path, "/", 3)
+	if len(parts) < 2 {
+		return "", "", nil, false
+	}
+	contentType, id = parts[0], unescapeSegment(parts[1])
+	// Some clients keep .json on the ID even when extras follow it
+	id = strings.TrimSuffix(id, ".json")
+	if contentType == "" || id == "" || strings.Contains(id, "/") {
+		return "", "", nil, false
+	}
+	extra = url.Values{}
+	if len(parts) == 3 && parts[2] != "" {
+		if parsed, err := url.ParseQuery(parts[2]); err == nil {
+			extra = parsed
+		}
+	}
+	return contentType, id, extra, true
+}
+
+// unescapeSegment decodes a path segment that was percent-encoded (possibly twice);
+// segments that fail to decode are returned unchanged.
+func unescapeSegment(s string) string {
+	for range 2 {
+		if !strings.Contains(s, "%") {
+			break
+		}
+		decoded, err := url.PathUnescape(s)
+		if err != nil {
+			break
+		}
+		s = decoded
+	}
+	return s
+}
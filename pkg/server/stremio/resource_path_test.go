@@ -0,0 +1,33 @@
+package stremio
+
+import "testing"
+
+func TestParseResourcePath(t *testing.T) {
+	tests := []struct {
+		path, contentType, id, extra string
+		ok                           bool
+	}{
+		{"/stream/movie/tt123.json", "movie", "tt123", "", true},
+		{"/stream/movie/tmdb:123.json", "movie", "tmdb:123", "", true},
+		{"/stream/series/tt123:1:2.json", "series", "tt123:1:2", "", true},
+		{"/stream/series/tt123:1:2", "series", "tt123:1:2", "", true},
+		{"/stream/series/tt123%3A1%3A2.json", "series", "tt123:1:2", "", true},
+		{"/stream/movie/tmdb%253A123.json", "movie", "tmdb:123", "", true},
+		{"/stream/series/tt123:1:2/skip=0&lang=en.json", "series", "tt123:1:2", "lang=en&skip=0", true},
+		{"/stream/series/tt123:1:2.json/skip=0.json", "series", "tt123:1:2", "skip=0", true},
+		{"/stream/movie/tt123/.json", "movie", "tt123", "", true},
+		{"/stream/movie.json", "", "", "", false},
+		{"/stream/movie/", "", "", "", false},
+		{"/stream//tt123.json", "", "", "", false},
+	}
+	for _, tt := range tests {
+		contentType, id, extra, ok := parseResourcePath(tt.path, "/stream/")
+		if ok != tt.ok || contentType != tt.contentType || id != tt.id {
+			t.Errorf("parseResourcePath(%q) = %q, %q, ok=%v; want %q, %q, ok=%v", tt.path, contentType, id, ok, tt.contentType, tt.id, tt.ok)
+			continue
+		}
+		if ok && extra.Encode() != tt.extra {
+			t.Errorf("parseResourcePath(%q) extra = %q; want %q", tt.path, extra.Encode(), tt.extra)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package stremio
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+)
+
+// streamCacheKey identifies a /stream request: results differ per device
+// (filters, sorting) and per content.
+type streamCacheKey struct {
+	device      string
+	contentType string
+	id          string
+}
+
+func newStreamCacheKey(device *auth.Device, contentType, id string) streamCacheKey {
+	deviceKey := "legacy"
+	if device != nil {
+		deviceKey = device.Token
+	}
+	return streamCacheKey{device: deviceKey, contentType: contentType, id: id}
+}
+
+type streamCacheEntry struct {
+	done     chan struct{} // closed when streams/err are set
+	streams  []Stream
+	err      error
+	finished time.Time
+	expires  time.Time
+}
+
+// streamCacheStaleFor is how long an expired result is kept to answer requests of a
+// device that ran out of rate-limit tokens.
+const streamCacheStaleFor = 10 * time.Minute
+
+// errStreamRateLimited is returned by Do when the device has no token left and no
+// previous result for the content.
+var errStreamRateLimited = errors.New("too many stream requests, try again shortly")
+
+// tokenBucket allows perMinute runs per minute, with bursts of up to perMinute.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take refills the bucket for the time since the last call and takes a token if one is left.
+func (b *tokenBucket) take(now time.Time, perMinute int) bool {
+	b.tokens += now.Sub(b.last).Minutes() * float64(perMinute)
+	if b.tokens > float64(perMinute) {
+		b.tokens = float64(perMinute)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// streamCache limits /stream to one search+validate run per key and TTL window.
+// Stremio re-requests streams on focus and retry; repeated requests reuse the
+// previous result, and concurrent duplicates wait for the run in flight. Runs are
+// further limited per device by a token bucket: a device out of tokens gets its
+// previous (possibly expired) result for the content.
+type streamCache struct {
+	mu        sync.Mutex
+	entries   map[streamCacheKey]*streamCacheEntry
+	buckets   map[string]*tokenBucket // by device key
+	perMinute int                     // <= 0 disables the rate limit
+}
+
+func newStreamCache(perMinute int) *streamCache {
+	return &streamCache{
+		entries:   make(map[streamCacheKey]*streamCacheEntry),
+		buckets:   make(map[string]*tokenBucket),
+		perMinute: perMinute,
+	}
+}
+
+// SetRateLimit changes the per-device limit of runs per minute (<= 0 disables it).
+func (c *streamCache) SetRateLimit(perMinute int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.perMinute = perMinute
+	c.buckets = make(map[string]*tokenBucket)
+}
+
+// allowLocked takes a rate-limit token for device.
+func (c *streamCache) allowLocked(device string, now time.Time) bool {
+	if c.perMinute <= 0 {
+		return true
+	}
+	b, ok := c.buckets[device]
+	if !ok {
+		b = &tokenBucket{tokens: float64(c.perMinute), last: now}
+		c.buckets[device] = b
+	}
+	return b.take(now, c.perMinute)
+}
+
+// Do returns the cached result for key, or runs fn and caches its result for ttl.
+// Failed runs are not cached. ttl <= 0 disables caching and rate limiting.
+// Callers waiting for another caller's run stop waiting when ctx is done; when that
+// run was canceled or timed out, they run fn themselves instead of sharing its error.
+func (c *streamCache) Do(ctx context.Context, key streamCacheKey, ttl time.Duration, fn func() ([]Stream, error)) ([]Stream, error) {
+	if ttl <= 0 {
+		return fn()
+	}
+
+	for {
+		now := time.Now()
+		c.mu.Lock()
+		e, ok := c.entries[key]
+		if ok {
+			select {
+			case <-e.done:
+				if now.Before(e.expires) {
+					c.mu.Unlock()
+					logger.Debug("Serving cached stream result", "type", key.contentType, "id", key.id, "streams", len(e.streams))
+					return e.streams, nil
+				}
+			default:
+				c.mu.Unlock()
+				logger.Debug("Waiting for in-flight stream request", "type", key.contentType, "id", key.id)
+				select {
+				case <-e.done:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				if errors.Is(e.err, context.Canceled) || errors.Is(e.err, context.DeadlineExceeded) {
+					continue // the other request gave up; run it for this one
+				}
+				return e.streams, e.err
+			}
+		}
+		if !c.allowLocked(key.device, now) {
+			c.mu.Unlock()
+			if ok {
+				logger.Debug("Stream requests rate limited, serving previous result", "type", key.contentType, "id", key.id, "streams", len(e.streams))
+				return e.streams, nil
+			}
+			return nil, errStreamRateLimited
+		}
+		e = &streamCacheEntry{done: make(chan struct{})}
+		c.entries[key] = e
+		c.pruneLocked(now)
+		c.mu.Unlock()
+
+		streams, err := fn()
+
+		c.mu.Lock()
+		e.streams, e.err = streams, err
+		e.finished = time.Now()
+		e.expires = e.finished.Add(ttl)
+		if err != nil && c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		close(e.done)
+		c.mu.Unlock()
+		return streams, err
+	}
+}
+
+// Invalidate drops the finished entry for key so the next Do runs again.
+// An in-flight run is left alone; callers wait for it instead.
+func (c *streamCache) Invalidate(key streamCacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			delete(c.entries, key)
+		default:
+		}
+	}
+}
+
+// InvalidateUnlessRecent is Invalidate, except that a result that finished less than
+// age ago and is usable is kept for the next Do to reuse.
+func (c *streamCache) InvalidateUnlessRecent(key streamCacheKey, age time.Duration, usable func([]Stream) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if time.Since(e.finished) > age || !usable(e.streams) {
+				delete(c.entries, key)
+			}
+		default:
+		}
+	}
+}
+
+// Clear drops all finished entries and returns how many. In-flight runs complete normally.
+func (c *streamCache) Clear() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for key, e := range c.entries {
+		select {
+		case <-e.done:
+			delete(c.entries, key)
+			n++
+		default:
+		}
+	}
+	return n
+}
+
+func (c *streamCache) pruneLocked(now time.Time) {
+	for key, e := range c.entries {
+		select {
+		case <-e.done:
+			if now.Sub(e.expires) > streamCacheStaleFor {
+				delete(c.entries, key)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package stremio
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestStreamCacheReusesResult(t *testing.T) {
+	logger.Init("DEBUG")
+	c := newStreamCache(0)
+	key := streamCacheKey{device: "tok", contentType: "movie", id: "tt1"}
+	var runs atomic.Int32
+	fn := func() ([]Stream, error) {
+		runs.Add(1)
+		return []Stream{{Name: "a"}}, nil
+	}
+	for i := 0; i < 3; i++ {
+		if streams, err := c.Do(context.Background(), key, time.Minute, fn); err != nil || len(streams) != 1 {
+			t.Fatalf("Do = %v, %v", streams, err)
+		}
+	}
+	if n := runs.Load(); n != 1 {
+		t.Errorf("fn ran %d times; want 1", n)
+	}
+	c.Invalidate(key)
+	c.Do(context.Background(), key, time.Minute, fn)
+	if n := runs.Load(); n != 2 {
+		t.Errorf("fn ran %d times after Invalidate; want 2", n)
+	}
+}
+
+func TestStreamCacheWaiterContext(t *testing.T) {
+	logger.Init("DEBUG")
+	c := newStreamCache(0)
+	key := streamCacheKey{device: "tok", contentType: "movie", id: "tt1"}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		c.Do(context.Background(), key, time.Minute, func() ([]Stream, error) {
+			close(started)
+			<-release
+			return nil, context.Canceled // the first request gave up
+		})
+	}()
+	<-started
+
+	// A waiter whose own context ends stops waiting
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Do(ctx, key, time.Minute, func() ([]Stream, error) { return nil, nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiter with an expired context: err = %v; want DeadlineExceeded", err)
+	}
+
+	// A waiter does not inherit the first request's cancellation; it runs fn itself
+	got := make(chan error, 1)
+	go func() {
+		streams, err := c.Do(context.Background(), key, time.Minute, func() ([]Stream, error) {
+			return []Stream{{Name: "b"}}, nil
+		})
+		if err == nil && len(streams) != 1 {
+			err = errors.New("no streams")
+		}
+		got <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	<-firstDone
+	if err := <-got; err != nil {
+		t.Errorf("waiter after a canceled run: %v", err)
+	}
+}
+
+func TestStreamCacheRateLimit(t *testing.T) {
+	logger.Init("DEBUG")
+	c := newStreamCache(2)
+	ttl := time.Millisecond
+	fn := func() ([]Stream, error) { return []Stream{{Name: "a"}}, nil }
+	key := func(id string) streamCacheKey { return streamCacheKey{device: "tok", contentType: "movie", id: id} }
+
+	for _, id := range []string{"tt1", "tt2"} {
+		if _, err := c.Do(context.Background(), key(id), ttl, fn); err != nil {
+			t.Fatalf("Do(%s) within the burst: %v", id, err)
+		}
+	}
+	time.Sleep(2 * ttl)
+	// Out of tokens: the expired result is served, unknown content is refused
+	if streams, err := c.Do(context.Background(), key("tt1"), ttl, func() ([]Stream, error) {
+		t.Error("fn ran although the device is out of tokens")
+		return nil, nil
+	}); err != nil || len(streams) != 1 {
+		t.Errorf("rate limited with a previous result: %v, %v", streams, err)
+	}
+	if _, err := c.Do(context.Background(), key("tt3"), ttl, fn); !errors.Is(err, errStreamRateLimited) {
+		t.Errorf("rate limited without a previous result: err = %v; want errStreamRateLimited", err)
+	}
+	// Other devices have their own bucket
+	if _, err := c.Do(context.Background(), streamCacheKey{device: "other", contentType: "movie", id: "tt3"}, ttl, fn); err != nil {
+		t.Errorf("other device limited: %v", err)
+	}
+
+	c.SetRateLimit(0)
+	if _, err := c.Do(context.Background(), key("tt3"), ttl, fn); err != nil {
+		t.Errorf("limit disabled: %v", err)
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	now := time.Now()
+	b := &tokenBucket{tokens: 0, last: now}
+	if b.take(now, 60) {
+		t.Error("empty bucket gave a token")
+	}
+	if !b.take(now.Add(time.Second), 60) {
+		t.Error("bucket not refilled after a second at 60/min")
+	}
+	if b.take(now.Add(time.Second), 60) {
+		t.Error("bucket gave a second token without refilling")
+	}
+}
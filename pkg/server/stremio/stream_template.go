@@ -0,0 +1,206 @@
+package stremio
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/search/parser"
+	"streamnzb/pkg/search/triage"
+)
+
+// DefaultStreamTitleTemplate renders the stream title shown in Stremio.
+// Used when StreamTitleTemplate is empty. Each output line is trimmed and empty
+// lines are dropped, so conditional lines can simply render nothing.
+const DefaultStreamTitleTemplate = `{{if .Title}}🎬 {{.Title}}{{if .Year}} ({{.Year}}){{end}}{{end}}
+{{if .Quality}}📡 {{.Quality}} {{end}}{{if .Codec}}🎞️ {{.Codec}} {{end}}{{if .Container}}📦 {{.Container}}{{end}}
+{{if .VisualTags}}📺 {{join .VisualTags "|"}}{{end}}{{if and .VisualTags .Audio}} • {{end}}{{if .Audio}}🎧 {{.Audio}}{{end}}
+{{if .Proper}}⚡ PROPER {{end}}{{if .Repack}}🔄 REPACK {{end}}{{if .Extended}}⏱️ EXTENDED {{end}}{{if .Unrated}}🔞 UNRATED {{end}}{{if .ThreeD}}🕶️ 3D{{end}}
+💾 {{if gt .SizeGB 0.0}}{{printf "%.2f GB" .SizeGB}}{{else}}Size Unknown{{end}}{{if .ReleaseGroup}} • 👥 {{.ReleaseGroup}}{{end}}
+{{if .Languages}}🌍 {{join .Languages " | "}}{{end}}
+📄 {{.Filename}}`
+
+// StreamTemplateData is the data available to StreamTitleTemplate.
+type StreamTemplateData struct {
+	Title        string   // Movie/show title from TMDB; empty when TMDB is unavailable
+	Year         string   // Release (movie) or first air (show) year from TMDB
+	Filename     string   // Release title / file name
+	Resolution   string   // Triage group: 4K, 1080p, 720p, SD
+	Quality      string   // Source, e.g. BluRay, WEB-DL
+	Codec        string   // Normalized: HEVC, AVC, ...
+	Container    string   // Upper-case, e.g. MKV
+	HDR          []string // HDR formats, e.g. DV, HDR10
+	ThreeD       string   // 3D format, if any
+	VisualTags   []string // HDR formats plus 3D format
+	Audio        string   // First audio track with channels, e.g. "DTS 5.1"
+	AudioTracks  []string
+	Channels     []string
+	Languages    []string
+	ReleaseGroup string
+	BitDepth     string
+	Proper       bool
+	Repack       bool
+	Extended     bool
+	Unrated      bool
+	Size         int64   // Bytes
+	SizeGB       float64 // GiB
+	Indexer      string
+	Age          string // e.g. "5h", "12d"; empty when unknown
+	Providers    int    // Providers that have the release; 0 when not validated here
+	Score        int
+}
+
+var streamTemplateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseStreamTitleTemplate compiles a stream title template; empty text uses the default.
+func ParseStreamTitleTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultStreamTitleTemplate
+	}
+	return template.New("stream_title").Funcs(streamTemplateFuncs).Parse(text)
+}
+
+// ValidateStreamTitleTemplate checks that text parses and renders against sample data.
+func ValidateStreamTitleTemplate(text string) error {
+	tmpl, err := ParseStreamTitleTemplate(text)
+	if err != nil {
+		return err
+	}
+	sample := StreamTemplateData{
+		Title: "Movie", Year: "2024",
+		Filename: "Movie.2024.2160p.BluRay.x265-GROUP", Resolution: "4K", Quality: "BluRay",
+		Codec: "HEVC", Container: "MKV", HDR: []string{"DV"}, VisualTags: []string{"DV"},
+		Audio: "DTS 5.1", Languages: []string{"English"}, ReleaseGroup: "GROUP",
+		Size: 1 << 30, SizeGB: 1, Age: "2d", Providers: 1,
+	}
+	_, err = renderStreamTemplate(tmpl, sample)
+	return err
+}
+
+func renderStreamTemplate(tmpl *template.Template, data StreamTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// streamTemplateCache keeps the compiled template for the current config text.
+var streamTemplateCache struct {
+	sync.Mutex
+	text string
+	tmpl *template.Template
+}
+
+// streamTitleTemplate returns the compiled template for text, falling back to the
+// default when text does not parse (it is validated on save, but config files can be edited by hand).
+func streamTitleTemplate(text string) *template.Template {
+	streamTemplateCache.Lock()
+	defer streamTemplateCache.Unlock()
+	if streamTemplateCache.tmpl != nil && streamTemplateCache.text == text {
+		return streamTemplateCache.tmpl
+	}
+	tmpl, err := ParseStreamTitleTemplate(text)
+	if err != nil {
+		tmpl, _ = ParseStreamTitleTemplate("")
+	}
+	streamTemplateCache.text, streamTemplateCache.tmpl = text, tmpl
+	return tmpl
+}
+
+func newStreamTemplateData(filename string, cand triage.Candidate, sizeGB float64, totalBytes int64, rel *release.Release, providers int) StreamTemplateData {
+	meta := cand.Metadata
+	if meta == nil {
+		meta = &parser.ParsedRelease{}
+	}
+	d := StreamTemplateData{
+		Filename:     filename,
+		Resolution:   cand.Group,
+		Quality:      meta.Quality,
+		Codec:        normalizeCodec(meta.Codec),
+		Container:    strings.ToUpper(meta.Container),
+		HDR:          meta.HDR,
+		ThreeD:       meta.ThreeD,
+		AudioTracks:  meta.Audio,
+		Channels:     meta.Channels,
+		Languages:    meta.Languages,
+		ReleaseGroup: meta.Group,
+		BitDepth:     meta.BitDepth,
+		Proper:       meta.Proper,
+		Repack:       meta.Repack,
+		Extended:     meta.Extended,
+		Unrated:      meta.Unrated,
+		Size:         totalBytes,
+		SizeGB:       sizeGB,
+		Providers:    providers,
+		Score:        cand.Score,
+	}
+	d.VisualTags = append(d.VisualTags, meta.HDR...)
+	if meta.ThreeD != "" {
+		d.VisualTags = append(d.VisualTags, meta.ThreeD)
+	}
+	if len(meta.Audio) > 0 {
+		d.Audio = meta.Audio[0]
+		if len(meta.Channels) > 0 {
+			d.Audio = fmt.Sprintf("%s %s", d.Audio, meta.Channels[0])
+		}
+	}
+	if rel != nil {
+		d.Indexer = rel.Indexer
+		d.Age = releaseAge(rel.PubDate)
+	}
+	return d
+}
+
+func normalizeCodec(codec string) string {
+	codec = strings.ToUpper(codec)
+	codec = strings.ReplaceAll(codec, "H.265", "HEVC")
+	codec = strings.ReplaceAll(codec, "H.264", "AVC")
+	codec = strings.ReplaceAll(codec, "X265", "HEVC")
+	codec = strings.ReplaceAll(codec, "X264", "AVC")
+	return codec
+}
+
+// parsePubDate parses a release pubDate (RFC1123Z or RFC1123).
+func parsePubDate(pubDate string) (time.Time, bool) {
+	if pubDate == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC1123Z, pubDate)
+	if err != nil {
+		if t, err = time.Parse(time.RFC1123, pubDate); err != nil {
+			return time.Time{}, false
+		}
+	}
+	return t, true
+}
+
+// releaseAge formats the time since pubDate (RFC1123/RFC1123Z) as hours or days.
+func releaseAge(pubDate string) string {
+	t, ok := parsePubDate(pubDate)
+	if !ok {
+		return ""
+	}
+	age := time.Since(t)
+	if age < 0 {
+		return ""
+	}
+	if age < 48*time.Hour {
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
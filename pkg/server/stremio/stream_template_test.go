@@ -0,0 +1,74 @@
+package stremio
+
+import (
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/search/parser"
+	"streamnzb/pkg/search/triage"
+)
+
+func TestDefaultStreamTitleTemplate(t *testing.T) {
+	title := "Movie.2024.2160p.BluRay.x265.DV.DTS-HD.MA.7.1-GROUP"
+	cand := triage.Candidate{Metadata: parser.ParseReleaseTitle(title), Group: "4K"}
+
+	s := buildStreamMetadata("http://x/play/1", title, cand, 0, 0, nil, 0, contentInfo{}, "")
+	want := "📡 BluRay 🎞️ HEVC\n📺 DV • 🎧 DTS Lossless 7.1\n💾 Size Unknown • 👥 GROUP\n📄 " + title
+	if s.Title != want {
+		t.Errorf("default title:\ngot  %q\nwant %q", s.Title, want)
+	}
+
+	// TMDB title and year lead the stream title when resolved
+	s = buildStreamMetadata("http://x/play/1", title, cand, 0, 0, nil, 0, contentInfo{Title: "Movie", Year: "2024"}, "")
+	if want = "🎬 Movie (2024)\n" + want; s.Title != want {
+		t.Errorf("title with content info:\ngot  %q\nwant %q", s.Title, want)
+	}
+}
+
+func TestCustomStreamTitleTemplate(t *testing.T) {
+	logger.Init("DEBUG")
+	title := "Show.S01E01.1080p.WEB-DL.H.264-NTb"
+	cand := triage.Candidate{Metadata: parser.ParseReleaseTitle(title), Group: "1080p"}
+	rel := &release.Release{Title: title, Indexer: "Geek"}
+
+	tmpl := `{{.Resolution}} {{.Codec}} from {{.Indexer}}{{if .Providers}} ({{.Providers}} providers){{end}}
+
+{{printf "%.1f" .SizeGB}} GB`
+	if err := ValidateStreamTitleTemplate(tmpl); err != nil {
+		t.Fatalf("valid template rejected: %v", err)
+	}
+	s := buildStreamMetadata("", title, cand, 1.5, 0, rel, 2, contentInfo{}, tmpl)
+	if want := "1080p H264 from Geek (2 providers)\n1.5 GB"; s.Title != want {
+		t.Errorf("got %q, want %q", s.Title, want)
+	}
+
+	if err := ValidateStreamTitleTemplate("{{.Nope}}"); err == nil {
+		t.Error("expected unknown field to fail validation")
+	}
+	if err := ValidateStreamTitleTemplate("{{if}}"); err == nil {
+		t.Error("expected parse error")
+	}
+	// Invalid templates in a hand-edited config fall back to the default
+	if s := buildStreamMetadata("", title, cand, 0, 0, rel, 0, contentInfo{}, "{{.Nope}}"); s.Title == "" {
+		t.Error("expected default title on template error")
+	}
+}
+
+func TestBingeGroup(t *testing.T) {
+	title := "Show.S01E01.1080p.WEB-DL.H.264-NTb"
+	cand := triage.Candidate{Metadata: parser.ParseReleaseTitle(title), Group: "1080p"}
+
+	s := buildStreamMetadata("", title, cand, 0, 0, nil, 0, contentInfo{SeriesID: parseStreamID("series", "tt0903747:1:2").seriesKey()}, "")
+	if want := "streamnzb-tt0903747-1080p"; s.BehaviorHints.BingeGroup != want {
+		t.Errorf("series bingeGroup = %q, want %q", s.BehaviorHints.BingeGroup, want)
+	}
+	s = buildStreamMetadata("", title, cand, 0, 0, nil, 0, contentInfo{SeriesID: parseStreamID("series", "tvdb:81189:1:2").seriesKey()}, "")
+	if want := "streamnzb-tvdb:81189-1080p"; s.BehaviorHints.BingeGroup != want {
+		t.Errorf("tvdb series bingeGroup = %q, want %q", s.BehaviorHints.BingeGroup, want)
+	}
+	// Movies have no next episode
+	if s := buildStreamMetadata("", title, cand, 0, 0, nil, 0, contentInfo{}, ""); s.BehaviorHints.BingeGroup != "" {
+		t.Errorf("movie bingeGroup = %q, want empty", s.BehaviorHints.BingeGroup)
+	}
+}
@@ -0,0 +1,75 @@
+package stremio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timingHeader carries the per-phase durations of a /stream request.
+const timingHeader = "X-StreamNZB-Timing"
+
+// searchTimings records how long each phase of searchAndValidate took. A nil
+// *searchTimings is valid and records nothing, so callers without a context
+// value (refresh, prevalidation) need no special handling.
+type searchTimings struct {
+	mu     sync.Mutex
+	start  time.Time
+	phases []phaseTiming
+}
+
+type phaseTiming struct {
+	name string
+	d    time.Duration
+}
+
+type searchTimingsKey struct{}
+
+func newSearchTimings() *searchTimings {
+	return &searchTimings{start: time.Now()}
+}
+
+func withSearchTimings(ctx context.Context, t *searchTimings) context.Context {
+	return context.WithValue(ctx, searchTimingsKey{}, t)
+}
+
+func searchTimingsFrom(ctx context.Context) *searchTimings {
+	t, _ := ctx.Value(searchTimingsKey{}).(*searchTimings)
+	return t
+}
+
+// since records the time elapsed since start under name; repeated names accumulate.
+func (t *searchTimings) since(name string, start time.Time) {
+	if t == nil {
+		return
+	}
+	d := time.Since(start)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.phases {
+		if t.phases[i].name == name {
+			t.phases[i].d += d
+			return
+		}
+	}
+	t.phases = append(t.phases, phaseTiming{name: name, d: d})
+}
+
+// String formats the phases in recording order followed by the total,
+// e.g. "metadata=120ms, search=2300ms, total=2450ms". Without recorded phases
+// (the result came from the stream cache) only the total is reported.
+func (t *searchTimings) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parts := make([]string, 0, len(t.phases)+2)
+	for _, p := range t.phases {
+		parts = append(parts, fmt.Sprintf("%s=%dms", p.name, p.d.Milliseconds()))
+	}
+	if len(t.phases) == 0 {
+		parts = append(parts, "cache=hit")
+	}
+	parts = append(parts, fmt.Sprintf("total=%dms", time.Since(t.start).Milliseconds()))
+	return strings.Join(parts, ", ")
+}
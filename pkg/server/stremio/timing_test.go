@@ -0,0 +1,35 @@
+package stremio
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchTimings(t *testing.T) {
+	timings := newSearchTimings()
+	ctx := withSearchTimings(context.Background(), timings)
+
+	got := searchTimingsFrom(ctx)
+	start := time.Now().Add(-20 * time.Millisecond)
+	got.since("search", start)
+	got.since("validation", start)
+	got.since("search", start)
+
+	s := timings.String()
+	if !strings.HasPrefix(s, "search=") || !strings.Contains(s, ", validation=") || !strings.Contains(s, ", total=") {
+		t.Errorf("unexpected timing string %q", s)
+	}
+	if timings.phases[0].d < 40*time.Millisecond {
+		t.Errorf("repeated phase not accumulated: %v", timings.phases[0].d)
+	}
+
+	// Without a context value nothing is recorded and nothing panics
+	none := searchTimingsFrom(context.Background())
+	none.since("search", start)
+
+	if s := newSearchTimings().String(); !strings.HasPrefix(s, "cache=hit, total=") {
+		t.Errorf("cache hit timing = %q", s)
+	}
+}
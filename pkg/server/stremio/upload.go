@@ -0,0 +1,193 @@
+package stremio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"streamnzb/pkg/auth"
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/nzb"
+	"streamnzb/pkg/media/unpack"
+	"streamnzb/pkg/release"
+	"streamnzb/pkg/services/availnzb"
+	"streamnzb/pkg/session"
+	"streamnzb/pkg/usenet/validation"
+)
+
+const maxUploadNZBBytes = 64 << 20
+
+// UploadResponse is the body of a successful POST /play/upload.
+type UploadResponse struct {
+	SessionID string `json:"session_id"`
+	URL       string `json:"url"`
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	Providers int    `json:"providers"` // providers that have the articles
+}
+
+// handlePlayUpload creates a playable session from an uploaded NZB (multipart field
+// "nzb"), so releases found outside the configured indexers can be streamed through the
+// providers. Optional form fields: title, release_url (the indexer details page, needed
+// for AvailNZB reports), imdb_id or tvdb_id with season/episode.
+func (s *Server) handlePlayUpload(w http.ResponseWriter, r *http.Request, device *auth.Device) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if device == nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadNZBBytes)
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	file, header, err := r.FormFile("nzb")
+	if err != nil {
+		http.Error(w, "Missing 'nzb' file field", http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+	nzbParsed, err := nzb.Parse(bytes.NewReader(data))
+	if err != nil {
+		http.Error(w, "Invalid NZB: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(nzbParsed.GetContentFiles()) == 0 {
+		http.Error(w, "No content files found in NZB", http.StatusUnprocessableEntity)
+		return
+	}
+	if reason := nzbParsed.BrokenReason(); reason != "" {
+		http.Error(w, "Broken release: "+reason, http.StatusUnprocessableEntity)
+		return
+	}
+	if ct := nzbParsed.CompressionType(); !s.config.AllowArchiveStreaming && isArchiveCompression(ct) {
+		http.Error(w, fmt.Sprintf("Archive streaming disabled (%s release)", ct), http.StatusUnprocessableEntity)
+		return
+	}
+
+	contentIDs, err := uploadContentIDs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rel := &release.Release{
+		Title:      uploadTitle(r.FormValue("title"), nzbParsed, header.Filename),
+		DetailsURL: strings.TrimSpace(r.FormValue("release_url")),
+		Size:       nzbParsed.TotalSize(),
+	}
+	logger.Info("NZB upload", "title", rel.Title, "files", len(nzbParsed.Files), "device", device.Username)
+
+	results := s.validator.ValidateNZB(r.Context(), nzbParsed)
+	reportMeta := availnzb.ReportMeta{ReleaseName: rel.Title, Size: rel.Size, CompressionType: nzbParsed.CompressionType()}
+	if contentIDs != nil {
+		reportMeta.ImdbID = contentIDs.ImdbID
+		reportMeta.TvdbID = contentIDs.TvdbID
+		reportMeta.Season = contentIDs.Season
+		reportMeta.Episode = contentIDs.Episode
+	}
+	if s.availClient != nil && s.availReportEnabled(device) && contentIDs != nil &&
+		rel.DetailsURL != "" && !release.IsPrivateReleaseURL(rel.DetailsURL) {
+		for _, result := range results {
+			s.availClient.QueueReport(rel.DetailsURL, result.Host, result.IsComplete(), reportMeta)
+		}
+	}
+	best := validation.GetBestProvider(results)
+	if best == nil {
+		http.Error(w, "Articles not available on any provider", http.StatusUnprocessableEntity)
+		return
+	}
+	providers := 0
+	for _, result := range results {
+		if result.Available {
+			providers++
+		}
+	}
+
+	sessionID := nzbParsed.Hash()
+	_, lookupErr := s.sessionManager.GetSession(sessionID)
+	isNew := lookupErr != nil
+	sess, err := s.sessionManager.CreateSessionWithProvider(sessionID, nzbParsed, rel, contentIDs, best.Host)
+	if err != nil {
+		logger.Error("Failed to create upload session", "err", err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	offerSession(sess, device)
+
+	// Open the media once: archives are scanned now, so a compressed or encrypted RAR/7z
+	// fails the upload instead of the first play, and the blueprint is ready for playback
+	stream, name, size, bp, err := unpack.GetMediaStream(r.Context(), sessionFiles(sess), sess.Blueprint)
+	if bp != nil && sess.Blueprint == nil {
+		sess.SetBlueprint(bp)
+	}
+	if err != nil {
+		logger.Error("Uploaded NZB is not streamable", "title", rel.Title, "err", err)
+		s.reportBadRelease(sess, device, err)
+		if isNew {
+			s.sessionManager.DeleteSession(sessionID)
+		}
+		http.Error(w, "Not streamable: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	stream.Close()
+
+	resp := UploadResponse{
+		SessionID: sessionID,
+		URL:       fmt.Sprintf("%s/%s/play/%s", s.baseURL, device.Token, sessionID),
+		Name:      name,
+		Size:      size,
+		Providers: providers,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// uploadContentIDs reads the optional content IDs of an upload for AvailNZB reporting.
+func uploadContentIDs(r *http.Request) (*session.AvailReportMeta, error) {
+	ids := &session.AvailReportMeta{
+		ImdbID: strings.TrimSpace(r.FormValue("imdb_id")),
+		TvdbID: strings.TrimSpace(r.FormValue("tvdb_id")),
+	}
+	for field, dst := range map[string]*int{"season": &ids.Season, "episode": &ids.Episode} {
+		if v := strings.TrimSpace(r.FormValue(field)); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s %q", field, v)
+			}
+			*dst = n
+		}
+	}
+	if ids.ImdbID == "" && ids.TvdbID == "" {
+		return nil, nil
+	}
+	return ids, nil
+}
+
+// uploadTitle names an uploaded release: the title field, else the NZB's title meta,
+// else the uploaded file name without .nzb.
+func uploadTitle(title string, n *nzb.NZB, filename string) string {
+	if t := strings.TrimSpace(title); t != "" {
+		return t
+	}
+	for _, m := range n.Head.Meta {
+		if m.Type == "title" && m.Value != "" {
+			return m.Value
+		}
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(filename, ".nzb"), ".NZB")
+}
@@ -0,0 +1,48 @@
+package stremio
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"streamnzb/pkg/media/nzb"
+)
+
+func TestUploadContentIDs(t *testing.T) {
+	form := func(v url.Values) *http.Request {
+		r := httptest.NewRequest("POST", "/play/upload", strings.NewReader(v.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return r
+	}
+	ids, err := uploadContentIDs(form(url.Values{"tvdb_id": {"81189"}, "season": {"2"}, "episode": {"5"}}))
+	if err != nil || ids == nil || ids.TvdbID != "81189" || ids.Season != 2 || ids.Episode != 5 {
+		t.Fatalf("tvdb ids = %+v, %v", ids, err)
+	}
+	if ids, err := uploadContentIDs(form(url.Values{"title": {"x"}})); err != nil || ids != nil {
+		t.Errorf("no ids = %+v, %v; want nil", ids, err)
+	}
+	if _, err := uploadContentIDs(form(url.Values{"imdb_id": {"tt1"}, "season": {"two"}})); err == nil {
+		t.Error("expected an error for a non-numeric season")
+	}
+}
+
+func TestUploadTitle(t *testing.T) {
+	withMeta := &nzb.NZB{}
+	withMeta.Head.Meta = append(withMeta.Head.Meta, nzb.Meta{Type: "title", Value: "Movie.2024.1080p-GRP"})
+	tests := []struct {
+		title, filename string
+		n               *nzb.NZB
+		want            string
+	}{
+		{" Given ", "a.nzb", withMeta, "Given"},
+		{"", "a.nzb", withMeta, "Movie.2024.1080p-GRP"},
+		{"", "Show.S01E01.nzb", &nzb.NZB{}, "Show.S01E01"},
+	}
+	for _, tt := range tests {
+		if got := uploadTitle(tt.title, tt.n, tt.filename); got != tt.want {
+			t.Errorf("uploadTitle(%q, %q) = %q, want %q", tt.title, tt.filename, got, tt.want)
+		}
+	}
+}
@@ -2,24 +2,41 @@ package availnzb
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/release"
 )
 
-const apiPath = "/api/v1"
+// log tags this package's messages; set "availnzb" in log_levels to change its level.
+var log = logger.For("availnzb")
+
+const (
+	apiPath = "/api/v1"
+
+	// DefaultTimeout bounds each AvailNZB request unless SetTimeout changes it.
+	DefaultTimeout = 5 * time.Second
+)
 
 type Client struct {
 	BaseURL string
 	APIKey  string
 	HTTP    *http.Client
+
+	queue   reportQueue  // background reports (QueueReport)
+	breaker breaker      // fails calls fast while AvailNZB is unreachable
+	timeout atomic.Int64 // per-request timeout in nanoseconds (see SetTimeout); 0 = DefaultTimeout
 }
 
 // ReportRequest is the body for POST /api/v1/report (authenticated).
@@ -102,24 +119,140 @@ func NewClient(baseURL, apiKey string) *Client {
 		BaseURL: baseURL,
 		APIKey:  apiKey,
 		HTTP: &http.Client{
-			Timeout: 10 * time.Second,
+			Transport: newTransport(),
 		},
 	}
 }
 
+// newTransport keeps connections to the AvailNZB host open between lookups; a search
+// makes one releases call and a status call per candidate.
+func newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          32,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// SetTimeout sets the per-request timeout (<= 0 restores DefaultTimeout). Safe to call
+// while requests are in flight; it applies to requests started afterwards.
+func (c *Client) SetTimeout(d time.Duration) {
+	if d <= 0 {
+		d = DefaultTimeout
+	}
+	c.timeout.Store(int64(d))
+}
+
+func (c *Client) requestTimeout() time.Duration {
+	if d := time.Duration(c.timeout.Load()); d > 0 {
+		return d
+	}
+	return DefaultTimeout
+}
+
+// Breaker returns the state of the circuit breaker that skips calls while AvailNZB is down.
+func (c *Client) Breaker() BreakerStatus {
+	return c.breaker.status()
+}
+
+// do sends an API request through the circuit breaker with the request timeout. Transport
+// errors and 5xx responses count as failures; while recovering from failures a short
+// timeout applies.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	timeout := c.requestTimeout()
+	if c.breaker.recovering() {
+		timeout = min(timeout, probeTimeout)
+	}
+	resp, err := c.send(req, timeout)
+	return resp, c.recordResponse(resp, err)
+}
+
+// send runs req bounded by timeout, which covers reading the response body.
+func (c *Client) send(req *http.Request, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := c.HTTP.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// recordResponse feeds the outcome of a request to the breaker and returns err unchanged.
+func (c *Client) recordResponse(resp *http.Response, err error) error {
+	switch {
+	case err != nil:
+		c.breaker.record(err)
+	case resp.StatusCode >= 500:
+		c.breaker.record(fmt.Errorf("status %d", resp.StatusCode))
+	default:
+		c.breaker.record(nil)
+	}
+	return err
+}
+
+// cancelBody releases the request timeout once the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// Ping checks that the AvailNZB server is reachable. Any non-5xx response counts,
+// since the root path is not part of the API and may return 404.
+func (c *Client) Ping(ctx context.Context) error {
+	if c.BaseURL == "" {
+		return fmt.Errorf("AvailNZB URL not configured")
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req, c.requestTimeout())
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("AvailNZB returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // ReportAvailability submits an availability report for a release (POST /api/v1/report).
 // releaseURL is the indexer details URL. meta.ReleaseName is required; meta must have either ImdbID (movie) or TvdbID+Season+Episode (TV).
 func (c *Client) ReportAvailability(releaseURL string, providerURL string, status bool, meta ReportMeta) error {
+	return c.reportAvailability(context.Background(), releaseURL, providerURL, status, meta)
+}
+
+func (c *Client) reportAvailability(ctx context.Context, releaseURL string, providerURL string, status bool, meta ReportMeta) error {
 	if c.BaseURL == "" {
-		logger.Debug("AvailNZB report skipped", "reason", "no base URL configured")
+		log.Debug("AvailNZB report skipped", "reason", "no base URL configured")
 		return nil
 	}
 	if c.APIKey == "" {
-		logger.Debug("AvailNZB report skipped", "reason", "no API key configured")
+		log.Debug("AvailNZB report skipped", "reason", "no API key configured")
 		return nil
 	}
 	if meta.ReleaseName == "" {
-		logger.Debug("AvailNZB report skipped", "reason", "no release_name in meta", "url", releaseURL)
+		log.Debug("AvailNZB report skipped", "reason", "no release_name in meta", "url", releaseURL)
 		return nil
 	}
 
@@ -139,19 +272,19 @@ func (c *Client) ReportAvailability(releaseURL string, providerURL string, statu
 		body.Episode = meta.Episode
 	}
 	if body.ImdbID == "" && body.TvdbID == "" {
-		logger.Debug("AvailNZB report skipped", "reason", "no imdb_id or tvdb_id in meta", "url", releaseURL)
+		log.Debug("AvailNZB report skipped", "reason", "no imdb_id or tvdb_id in meta", "url", releaseURL)
 		return nil
 	}
 
-	logger.Debug("AvailNZB report", "url", releaseURL, "release_name", body.ReleaseName, "provider", providerURL, "status", status, "imdb_id", body.ImdbID, "tvdb_id", body.TvdbID, "season", body.Season, "episode", body.Episode)
+	log.Debug("AvailNZB report", "url", releaseURL, "release_name", body.ReleaseName, "provider", providerURL, "status", status, "imdb_id", body.ImdbID, "tvdb_id", body.TvdbID, "season", body.Season, "episode", body.Episode)
 
 	reqBody, err := json.Marshal(body)
 	if err != nil {
-		logger.Error("AvailNZB report marshal failed", "err", err)
+		log.Error("AvailNZB report marshal failed", "err", err)
 		return err
 	}
 
-	req, err := http.NewRequest("POST", c.BaseURL+apiPath+"/report", bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+apiPath+"/report", bytes.NewBuffer(reqBody))
 	if err != nil {
 		return err
 	}
@@ -159,19 +292,21 @@ func (c *Client) ReportAvailability(releaseURL string, providerURL string, statu
 	req.Header.Set("X-API-Key", c.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.HTTP.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		logger.Error("AvailNZB report request failed", "err", err, "url", releaseURL)
+		if !errors.Is(err, ErrUnavailable) {
+			log.Error("AvailNZB report request failed", "err", err, "url", releaseURL)
+		}
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
-		logger.Error("AvailNZB report unexpected status", "status", resp.StatusCode, "url", releaseURL)
+		log.Error("AvailNZB report unexpected status", "status", resp.StatusCode, "url", releaseURL)
 		return fmt.Errorf("availnzb report: unexpected status code: %d", resp.StatusCode)
 	}
 
-	logger.Debug("AvailNZB report success", "url", releaseURL, "status_code", resp.StatusCode)
+	log.Debug("AvailNZB report success", "url", releaseURL, "status_code", resp.StatusCode)
 	return nil
 }
 
@@ -179,7 +314,7 @@ func (c *Client) ReportAvailability(releaseURL string, providerURL string, statu
 // provider is optional; if non-empty, filters by that provider.
 func (c *Client) GetStatus(releaseURL string, provider string) (*StatusResponse, error) {
 	if c.BaseURL == "" {
-		logger.Trace("AvailNZB GetStatus skipped", "reason", "no base URL")
+		log.Trace("AvailNZB GetStatus skipped", "reason", "no base URL")
 		return nil, nil
 	}
 
@@ -190,32 +325,38 @@ func (c *Client) GetStatus(releaseURL string, provider string) (*StatusResponse,
 	}
 	reqURL := c.BaseURL + apiPath + "/status?" + params.Encode()
 
-	logger.Debug("AvailNZB GetStatus", "url", releaseURL, "provider", provider)
+	log.Debug("AvailNZB GetStatus", "url", releaseURL, "provider", provider)
 
-	resp, err := c.HTTP.Get(reqURL)
+	req, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
-		logger.Error("AvailNZB GetStatus request failed", "err", err, "url", releaseURL)
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		if !errors.Is(err, ErrUnavailable) {
+			log.Error("AvailNZB GetStatus request failed", "err", err, "url", releaseURL)
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		logger.Debug("AvailNZB GetStatus", "result", "not_found", "url", releaseURL)
+		log.Debug("AvailNZB GetStatus", "result", "not_found", "url", releaseURL)
 		return nil, nil // No reports yet
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		logger.Error("AvailNZB GetStatus unexpected status", "status", resp.StatusCode, "url", releaseURL)
+		log.Error("AvailNZB GetStatus unexpected status", "status", resp.StatusCode, "url", releaseURL)
 		return nil, fmt.Errorf("availnzb status: unexpected status code: %d", resp.StatusCode)
 	}
 
 	var status StatusResponse
 	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
-		logger.Error("AvailNZB GetStatus decode failed", "err", err)
+		log.Error("AvailNZB GetStatus decode failed", "err", err)
 		return nil, err
 	}
 
-	logger.Debug("AvailNZB GetStatus", "url", releaseURL, "available", status.Available, "providers", len(status.Summary))
+	log.Debug("AvailNZB GetStatus", "url", releaseURL, "available", status.Available, "providers", len(status.Summary))
 	return &status, nil
 }
 
@@ -230,7 +371,7 @@ type releasesResponseJSON struct {
 // Pass empty compressionTypes to get all; filter/split streamable vs RAR client-side.
 func (c *Client) GetReleases(imdbID string, tvdbID string, season, episode int, indexers []string, compressionTypes string) (*ReleasesResult, error) {
 	if c.BaseURL == "" {
-		logger.Trace("AvailNZB GetReleases skipped", "reason", "no base URL")
+		log.Trace("AvailNZB GetReleases skipped", "reason", "no base URL")
 		return nil, nil
 	}
 
@@ -252,23 +393,29 @@ func (c *Client) GetReleases(imdbID string, tvdbID string, season, episode int,
 	}
 	reqURL := c.BaseURL + apiPath + "/releases?" + params.Encode()
 
-	logger.Debug("AvailNZB GetReleases", "imdb_id", imdbID, "tvdb_id", tvdbID, "season", season, "episode", episode, "indexer_filter", len(indexers))
+	log.Debug("AvailNZB GetReleases", "imdb_id", imdbID, "tvdb_id", tvdbID, "season", season, "episode", episode, "indexer_filter", len(indexers))
 
-	resp, err := c.HTTP.Get(reqURL)
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
 	if err != nil {
-		logger.Error("AvailNZB GetReleases request failed", "err", err, "imdb_id", imdbID, "tvdb_id", tvdbID)
+		if !errors.Is(err, ErrUnavailable) {
+			log.Error("AvailNZB GetReleases request failed", "err", err, "imdb_id", imdbID, "tvdb_id", tvdbID)
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		logger.Error("AvailNZB GetReleases unexpected status", "status", resp.StatusCode, "imdb_id", imdbID, "tvdb_id", tvdbID)
+		log.Error("AvailNZB GetReleases unexpected status", "status", resp.StatusCode, "imdb_id", imdbID, "tvdb_id", tvdbID)
 		return nil, fmt.Errorf("availnzb releases: unexpected status code: %d", resp.StatusCode)
 	}
 
 	var raw releasesResponseJSON
 	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
-		logger.Error("AvailNZB GetReleases decode failed", "err", err)
+		log.Error("AvailNZB GetReleases decode failed", "err", err)
 		return nil, err
 	}
 
@@ -297,7 +444,7 @@ func (c *Client) GetReleases(imdbID string, tvdbID string, season, episode int,
 			availableCount++
 		}
 	}
-	logger.Debug("AvailNZB GetReleases", "count", raw.Count, "available", availableCount, "imdb_id", imdbID, "tvdb_id", tvdbID)
+	log.Debug("AvailNZB GetReleases", "count", raw.Count, "available", availableCount, "imdb_id", imdbID, "tvdb_id", tvdbID)
 	return &ReleasesResult{ImdbID: raw.ImdbID, Count: raw.Count, Releases: releases}, nil
 }
 
@@ -305,19 +452,19 @@ func (c *Client) GetReleases(imdbID string, tvdbID string, season, episode int,
 // Returns: available (can skip validation), last updated time, capable provider, error.
 // Use releaseURL (indexer release URL, e.g. item.Link) to query GET /api/v1/status.
 func (c *Client) CheckPreDownload(releaseURL string, validProviders []string) (available bool, lastUpdated time.Time, capableProvider string, err error) {
-	logger.Debug("AvailNZB CheckPreDownload", "url", releaseURL, "our_providers", len(validProviders))
+	log.Debug("AvailNZB CheckPreDownload", "url", releaseURL, "our_providers", len(validProviders))
 	if c.BaseURL == "" || releaseURL == "" {
-		logger.Trace("AvailNZB CheckPreDownload skipped", "reason", "no base URL or empty release URL")
+		log.Trace("AvailNZB CheckPreDownload skipped", "reason", "no base URL or empty release URL")
 		return false, time.Time{}, "", nil
 	}
 
 	status, err := c.GetStatus(releaseURL, "")
 	if err != nil {
-		logger.Debug("AvailNZB CheckPreDownload GetStatus failed", "url", releaseURL, "err", err)
+		log.Debug("AvailNZB CheckPreDownload GetStatus failed", "url", releaseURL, "err", err)
 		return false, time.Time{}, "", err
 	}
 	if status == nil {
-		logger.Debug("AvailNZB CheckPreDownload", "result", "not_found", "url", releaseURL)
+		log.Debug("AvailNZB CheckPreDownload", "result", "not_found", "url", releaseURL)
 		return false, time.Time{}, "", nil
 	}
 
@@ -346,6 +493,6 @@ func (c *Client) CheckPreDownload(releaseURL string, validProviders []string) (a
 		available = status.Available
 	}
 
-	logger.Debug("AvailNZB CheckPreDownload", "result", "found", "available", available, "capable_provider", capableProvider, "url", releaseURL)
+	log.Debug("AvailNZB CheckPreDownload", "result", "found", "available", available, "capable_provider", capableProvider, "url", releaseURL)
 	return available, lastUpdated, capableProvider, nil
 }
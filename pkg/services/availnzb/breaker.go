@@ -0,0 +1,89 @@
+package availnzb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// When AvailNZB is down every lookup would wait for the full timeout on the /stream path.
+// After breakerThreshold consecutive failures the breaker opens and calls fail immediately
+// for a cooldown (30s, doubling up to 5 minutes); afterwards calls use probeTimeout until
+// one succeeds.
+const (
+	breakerThreshold = 3
+	minCooldown      = 30 * time.Second
+	maxCooldown      = 5 * time.Minute
+	probeTimeout     = 2 * time.Second
+)
+
+// ErrUnavailable is returned without contacting AvailNZB while the breaker is open.
+var ErrUnavailable = errors.New("availnzb: temporarily unavailable")
+
+// BreakerStatus is a snapshot of the breaker for the stats API.
+type BreakerStatus struct {
+	Open     bool       `json:"open"`                 // calls are currently skipped
+	Until    *time.Time `json:"until,omitempty"`      // when calls resume
+	Failures int        `json:"failures"`             // consecutive failed calls
+	Trips    int        `json:"trips_since_start"`    // times the breaker opened
+	LastErr  string     `json:"last_error,omitempty"` // most recent failure
+}
+
+type breaker struct {
+	mu       sync.Mutex
+	failures int
+	cooldown time.Duration
+	until    time.Time
+	trips    int
+	lastErr  string
+}
+
+// allow returns ErrUnavailable while the breaker is open.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if time.Now().Before(b.until) {
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// recovering reports whether recent calls failed, so the next one should use probeTimeout.
+func (b *breaker) recovering() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= breakerThreshold
+}
+
+// record notes the outcome of a call; err is nil for a successful one.
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.failures >= breakerThreshold {
+			log.Info("AvailNZB reachable again")
+		}
+		b.failures, b.cooldown, b.lastErr = 0, 0, ""
+		return
+	}
+	b.failures++
+	b.lastErr = err.Error()
+	if b.failures < breakerThreshold {
+		return
+	}
+	b.cooldown = min(max(b.cooldown*2, minCooldown), maxCooldown)
+	b.until = time.Now().Add(b.cooldown)
+	b.trips++
+	log.Warn("AvailNZB unreachable, skipping lookups", "for", b.cooldown.String(), "err", err)
+}
+
+func (b *breaker) status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := BreakerStatus{Failures: b.failures, Trips: b.trips, LastErr: b.lastErr}
+	if time.Now().Before(b.until) {
+		until := b.until
+		st.Open, st.Until = true, &until
+	}
+	return st
+}
@@ -0,0 +1,88 @@
+package availnzb
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestBreakerFailsFastWhileDown(t *testing.T) {
+	logger.Init("DEBUG")
+	var requests atomic.Int32
+	var down atomic.Bool
+	down.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key")
+	for i := 0; i < breakerThreshold; i++ {
+		if _, err := c.GetStatus("https://indexer/details/1", ""); err == nil {
+			t.Fatalf("call %d: expected error for status 502", i+1)
+		}
+	}
+	if st := c.Breaker(); !st.Open || st.Trips != 1 || st.Failures != breakerThreshold {
+		t.Fatalf("breaker after %d failures = %+v, want open with one trip", breakerThreshold, st)
+	}
+
+	if _, err := c.GetStatus("https://indexer/details/1", ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("open breaker: err = %v, want ErrUnavailable", err)
+	}
+	if n := requests.Load(); n != breakerThreshold {
+		t.Fatalf("open breaker sent a request: %d requests, want %d", n, breakerThreshold)
+	}
+
+	// Cooldown over and AvailNZB back: the next call goes through and closes the breaker
+	down.Store(false)
+	c.breaker.mu.Lock()
+	c.breaker.until = time.Now().Add(-time.Second)
+	c.breaker.mu.Unlock()
+	if status, err := c.GetStatus("https://indexer/details/1", ""); err != nil || status != nil {
+		t.Fatalf("after recovery: status=%v err=%v, want not found", status, err)
+	}
+	if st := c.Breaker(); st.Open || st.Failures != 0 {
+		t.Fatalf("breaker after success = %+v, want closed", st)
+	}
+}
+
+func TestSetTimeoutAppliesPerRequest(t *testing.T) {
+	logger.Init("DEBUG")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key")
+	httpClient := c.HTTP
+	c.SetTimeout(50 * time.Millisecond)
+	if _, err := c.GetStatus("https://indexer/details/1", ""); err == nil {
+		t.Fatal("expected a timeout with a 50ms request timeout")
+	}
+
+	c.SetTimeout(2 * time.Second)
+	if _, err := c.GetStatus("https://indexer/details/1", ""); err != nil {
+		t.Fatalf("with a 2s request timeout: %v", err)
+	}
+	if c.HTTP != httpClient || c.HTTP.Timeout != 0 {
+		t.Error("SetTimeout modified the shared http.Client")
+	}
+	c.SetTimeout(0)
+	if got := c.requestTimeout(); got != DefaultTimeout {
+		t.Errorf("SetTimeout(0): timeout %v; want %v", got, DefaultTimeout)
+	}
+}
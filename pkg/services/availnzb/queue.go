@@ -0,0 +1,131 @@
+package availnzb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	reportWorkers     = 4
+	reportQueueSize   = 256
+	reportDedupWindow = 5 * time.Minute
+)
+
+type reportKey struct {
+	url, provider string
+	status        bool
+}
+
+type queuedReport struct {
+	key  reportKey
+	meta ReportMeta
+}
+
+// reportQueue sends reports from a fixed number of workers so bursts of validations
+// don't turn into one goroutine and HTTP request per provider. Identical reports
+// (url, provider, status) within reportDedupWindow are sent once.
+type reportQueue struct {
+	mu     sync.Mutex
+	jobs   chan queuedReport // nil until the first report starts the workers
+	ctx    context.Context   // cancelled by Close: stops workers and in-flight reports
+	cancel context.CancelFunc
+	closed bool
+	sent   map[reportKey]time.Time
+}
+
+// QueueReport submits an availability report in the background (see ReportAvailability).
+// It never blocks: duplicates of a recent report are dropped, and so are reports
+// arriving while the queue is full or after Close.
+func (c *Client) QueueReport(releaseURL string, providerURL string, status bool, meta ReportMeta) {
+	if c == nil || c.BaseURL == "" || c.APIKey == "" {
+		return
+	}
+	jobs := c.queue.start(c)
+	if jobs == nil {
+		return
+	}
+
+	q := &c.queue
+	key := reportKey{url: releaseURL, provider: providerURL, status: status}
+	if !q.claim(key, time.Now()) {
+		log.Trace("AvailNZB report deduplicated", "url", releaseURL, "provider", providerURL, "status", status)
+		return
+	}
+	select {
+	case jobs <- queuedReport{key: key, meta: meta}:
+	default:
+		q.release(key)
+		log.Debug("AvailNZB report queue full, dropping report", "url", releaseURL, "provider", providerURL)
+	}
+}
+
+// Close stops the report workers and cancels reports in flight; queued reports are
+// dropped. Call when the client is replaced (config reload) or on shutdown.
+func (c *Client) Close() {
+	if c == nil {
+		return
+	}
+	q := &c.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	if q.cancel != nil {
+		q.cancel()
+	}
+}
+
+// start launches the workers on first use and returns the job channel, or nil after Close.
+func (q *reportQueue) start(c *Client) chan queuedReport {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	if q.jobs == nil {
+		q.jobs = make(chan queuedReport, reportQueueSize)
+		q.sent = make(map[reportKey]time.Time)
+		q.ctx, q.cancel = context.WithCancel(context.Background())
+		for i := 0; i < reportWorkers; i++ {
+			go c.reportWorker(q.ctx, q.jobs)
+		}
+	}
+	return q.jobs
+}
+
+func (c *Client) reportWorker(ctx context.Context, jobs <-chan queuedReport) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-jobs:
+			if err := c.reportAvailability(ctx, job.key.url, job.key.provider, job.key.status, job.meta); err != nil {
+				c.queue.release(job.key) // allow a retry by a later report
+			}
+		}
+	}
+}
+
+// claim records key as sent unless it was sent within reportDedupWindow.
+func (q *reportQueue) claim(key reportKey, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if at, ok := q.sent[key]; ok && now.Sub(at) < reportDedupWindow {
+		return false
+	}
+	if len(q.sent) >= 4*reportQueueSize {
+		for k, at := range q.sent {
+			if now.Sub(at) >= reportDedupWindow {
+				delete(q.sent, k)
+			}
+		}
+	}
+	q.sent[key] = now
+	return true
+}
+
+func (q *reportQueue) release(key reportKey) {
+	q.mu.Lock()
+	delete(q.sent, key)
+	q.mu.Unlock()
+}
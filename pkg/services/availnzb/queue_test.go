@@ -0,0 +1,79 @@
+package availnzb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestQueueReportDeduplicates(t *testing.T) {
+	logger.Init("DEBUG")
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "key")
+	meta := ReportMeta{ReleaseName: "Movie.2020.1080p", Size: 1, ImdbID: "tt1234567"}
+	for i := 0; i < 10; i++ {
+		c.QueueReport("https://indexer/details/1", "news.example.com", true, meta)
+	}
+	c.QueueReport("https://indexer/details/1", "news.example.com", false, meta)
+	c.QueueReport("https://indexer/details/1", "other.example.com", true, meta)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for requests.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := requests.Load(); n != 3 {
+		t.Errorf("sent %d reports, want 3 (duplicates dropped)", n)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestQueueCloseStopsReports(t *testing.T) {
+	logger.Init("DEBUG")
+	var requests atomic.Int32
+	started := make(chan struct{}, 1)
+	cancelled := make(chan struct{})
+	c := NewClient("http://availnzb.test", "key")
+	c.SetTimeout(time.Minute)
+	c.HTTP.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		started <- struct{}{}
+		<-r.Context().Done() // hang until the report is cancelled
+		close(cancelled)
+		return nil, r.Context().Err()
+	})
+
+	meta := ReportMeta{ReleaseName: "Movie.2020.1080p", Size: 1, ImdbID: "tt1234567"}
+	c.QueueReport("https://indexer/details/1", "news.example.com", true, meta)
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("report was not sent")
+	}
+
+	c.Close()
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("in-flight report not cancelled by Close")
+	}
+	c.Close() // idempotent
+	c.QueueReport("https://indexer/details/2", "news.example.com", true, meta)
+	time.Sleep(50 * time.Millisecond)
+	if n := requests.Load(); n != 1 {
+		t.Errorf("sent %d reports, want 1 (nothing after Close)", n)
+	}
+}
@@ -2,7 +2,6 @@ package availnzb
 
 import (
 	"strings"
-	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/release"
 	"streamnzb/pkg/session"
 	"sync"
@@ -36,14 +35,14 @@ func (r *Reporter) ReportGood(sess *session.Session) {
 // ReportBad reports bad/unstreamable release to AvailNZB as unavailable.
 func (r *Reporter) ReportBad(sess *session.Session, reason string) {
 	if reason != "" {
-		logger.Info("Reporting bad/unstreamable release to AvailNZB", "session", sess.ID, "reason", reason)
+		log.Info("Reporting bad/unstreamable release to AvailNZB", "session", sess.ID, "reason", reason)
 	}
 	r.report(sess, false)
 }
 
 // ReportRAR reports RAR releases to AvailNZB as available with compression_type=rar.
 func (r *Reporter) ReportRAR(sess *session.Session) {
-	logger.Info("Reporting RAR release to AvailNZB (compression_type)", "session", sess.ID)
+	log.Info("Reporting RAR release to AvailNZB (compression_type)", "session", sess.ID)
 	r.report(sess, true)
 }
 
@@ -51,42 +50,40 @@ func (r *Reporter) report(sess *session.Session, available bool) {
 	if r.client == nil || r.client.BaseURL == "" {
 		return
 	}
-	go func() {
-		releaseURL := sess.ReleaseURL()
-		if releaseURL == "" {
-			return
-		}
-		if release.IsPrivateReleaseURL(releaseURL) {
-			logger.Debug("Skipping AvailNZB report: release URL is private", "url", releaseURL)
-			return
-		}
-		meta := ReportMeta{ReleaseName: sess.ReportReleaseName(), Size: sess.ReportSize()}
-		if ids := sess.ContentIDs; ids != nil {
-			if ids.ImdbID != "" {
-				meta.ImdbID = ids.ImdbID
-			} else if ids.TvdbID != "" {
-				meta.TvdbID = ids.TvdbID
-				meta.Season = ids.Season
-				meta.Episode = ids.Episode
-			}
-		}
-		if meta.ImdbID == "" && meta.TvdbID == "" {
-			return
-		}
-		if meta.ReleaseName == "" {
-			return
-		}
-		if sess.NZB != nil {
-			meta.CompressionType = sess.NZB.CompressionType()
-		}
-		hosts := r.providerSrc.GetProviderHosts()
-		if len(hosts) == 0 {
-			return
-		}
-		if !available {
-			_ = r.client.ReportAvailability(releaseURL, strings.Join(hosts, ","), false, meta)
-		} else {
-			_ = r.client.ReportAvailability(releaseURL, hosts[0], true, meta)
+	releaseURL := sess.ReleaseURL()
+	if releaseURL == "" {
+		return
+	}
+	if release.IsPrivateReleaseURL(releaseURL) {
+		log.Debug("Skipping AvailNZB report: release URL is private", "url", releaseURL)
+		return
+	}
+	meta := ReportMeta{ReleaseName: sess.ReportReleaseName(), Size: sess.ReportSize()}
+	if ids := sess.ContentIDs; ids != nil {
+		if ids.ImdbID != "" {
+			meta.ImdbID = ids.ImdbID
+		} else if ids.TvdbID != "" {
+			meta.TvdbID = ids.TvdbID
+			meta.Season = ids.Season
+			meta.Episode = ids.Episode
 		}
-	}()
+	}
+	if meta.ImdbID == "" && meta.TvdbID == "" {
+		return
+	}
+	if meta.ReleaseName == "" {
+		return
+	}
+	if sess.NZB != nil {
+		meta.CompressionType = sess.NZB.CompressionType()
+	}
+	hosts := r.providerSrc.GetProviderHosts()
+	if len(hosts) == 0 {
+		return
+	}
+	if !available {
+		r.client.QueueReport(releaseURL, strings.Join(hosts, ","), false, meta)
+	} else {
+		r.client.QueueReport(releaseURL, hosts[0], true, meta)
+	}
 }
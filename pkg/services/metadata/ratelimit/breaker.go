@@ -0,0 +1,103 @@
+// Package ratelimit pauses calls to the metadata APIs (TMDB, TVDB) after they answer
+// 429 Too Many Requests, so lookups on the /stream path fail fast instead of piling up.
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+const (
+	minBackoff = 30 * time.Second
+	maxBackoff = 10 * time.Minute
+)
+
+// ErrRateLimited is returned while the breaker is open.
+var ErrRateLimited = errors.New("rate limited")
+
+// Status is a snapshot of a breaker for the stats API.
+type Status struct {
+	RateLimited bool      // calls are currently skipped
+	Until       time.Time // when calls resume (zero when not rate limited)
+	Trips       int       // 429 responses since start
+}
+
+// Breaker opens on a 429 response for the Retry-After duration, or a backoff that doubles
+// on consecutive 429s (30s up to 10 minutes). A successful response resets the backoff.
+type Breaker struct {
+	name string
+
+	mu      sync.Mutex
+	until   time.Time
+	backoff time.Duration
+	trips   int
+}
+
+// NewBreaker creates a breaker; name is used in log messages (e.g. "TMDB").
+func NewBreaker(name string) *Breaker {
+	return &Breaker{name: name}
+}
+
+// Allow returns ErrRateLimited while the breaker is open.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if time.Now().Before(b.until) {
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// Check records the outcome of a response and reports whether it was a 429, in which
+// case the breaker is now open and the caller should treat the call as failed.
+func (b *Breaker) Check(resp *http.Response) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		if resp.StatusCode < 400 {
+			b.backoff = 0
+		}
+		return false
+	}
+
+	b.backoff = min(max(b.backoff*2, minBackoff), maxBackoff)
+	wait := b.backoff
+	if d := retryAfter(resp.Header.Get("Retry-After")); d > wait {
+		wait = min(d, maxBackoff)
+	}
+	b.until = time.Now().Add(wait)
+	b.trips++
+	logger.Warn("Metadata API rate limit hit, skipping lookups", "api", b.name, "for", wait.String())
+	return true
+}
+
+// Status returns the current state of the breaker.
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := Status{Trips: b.trips}
+	if time.Now().Before(b.until) {
+		st.RateLimited = true
+		st.Until = b.until
+	}
+	return st
+}
+
+// retryAfter parses a Retry-After header (seconds or an HTTP date); 0 when absent or invalid.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
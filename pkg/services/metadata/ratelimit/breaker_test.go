@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestBreaker(t *testing.T) {
+	logger.Init("DEBUG")
+	b := NewBreaker("TEST")
+	if err := b.Allow(); err != nil {
+		t.Fatalf("new breaker should allow calls: %v", err)
+	}
+
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	if b.Check(ok) {
+		t.Fatal("200 must not trip the breaker")
+	}
+
+	limited := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"120"}}}
+	if !b.Check(limited) {
+		t.Fatal("429 should trip the breaker")
+	}
+	if err := b.Allow(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	st := b.Status()
+	if !st.RateLimited || st.Trips != 1 {
+		t.Fatalf("unexpected status %+v", st)
+	}
+	if wait := time.Until(st.Until); wait < 110*time.Second || wait > 120*time.Second {
+		t.Errorf("Retry-After not honored, open for %s", wait)
+	}
+
+	// Consecutive 429s double the backoff
+	limited.Header.Del("Retry-After")
+	b.Check(limited)
+	b.Check(limited)
+	if b.backoff != 4*minBackoff { // 30s, 1m, 2m
+		t.Errorf("backoff = %s, want %s", b.backoff, 4*minBackoff)
+	}
+	b.Check(ok)
+	if b.backoff != 0 {
+		t.Errorf("success should reset the backoff, got %s", b.backoff)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d := retryAfter("30"); d != 30*time.Second {
+		t.Errorf("retryAfter(30) = %s", d)
+	}
+	if d := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)); d < 50*time.Second || d > time.Minute {
+		t.Errorf("retryAfter(date) = %s", d)
+	}
+	if d := retryAfter("soon"); d != 0 {
+		t.Errorf("retryAfter(soon) = %s", d)
+	}
+}
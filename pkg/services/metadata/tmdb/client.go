@@ -7,13 +7,15 @@ import (
 	"net/url"
 	"strconv"
 	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/services/metadata/ratelimit"
 	"time"
 )
 
 // Client for TheMovieDB API
 type Client struct {
-	apiKey string
-	client *http.Client
+	apiKey  string
+	client  *http.Client
+	breaker *ratelimit.Breaker // open after a 429; lookups fail fast until it closes
 }
 
 // NewClient creates a new TMDB client
@@ -23,9 +25,20 @@ func NewClient(apiKey string) *Client {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		breaker: ratelimit.NewBreaker("TMDB"),
 	}
 }
 
+// Configured reports whether an API key is set, so callers can skip optional TMDB features.
+func (c *Client) Configured() bool {
+	return c != nil && c.apiKey != ""
+}
+
+// RateLimit returns the state of the client's 429 circuit breaker.
+func (c *Client) RateLimit() ratelimit.Status {
+	return c.breaker.Status()
+}
+
 // FindResponse represents the response from /find/{id}
 type FindResponse struct {
 	MovieResults     []Result `json:"movie_results"`
@@ -44,6 +57,10 @@ type Result struct {
 	OriginalTitle string `json:"original_title"` // Movie
 	MediaType     string `json:"media_type"`
 	Overview      string `json:"overview"`
+	ReleaseDate   string `json:"release_date"`   // Movie
+	FirstAirDate  string `json:"first_air_date"` // TV
+	PosterPath    string `json:"poster_path"`
+	BackdropPath  string `json:"backdrop_path"`
 }
 
 // ExternalIDsResponse represents the response from /{type}/{id}/external_ids
@@ -59,6 +76,9 @@ type ExternalIDsResponse struct {
 }
 
 func (c *Client) doRequest(endpoint string, params url.Values) (*http.Response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
 	reqURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
 
 	req, err := http.NewRequest("GET", reqURL, nil)
@@ -69,7 +89,15 @@ func (c *Client) doRequest(endpoint string, params url.Values) (*http.Response,
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("accept", "application/json")
 
-	return c.client.Do(req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.breaker.Check(resp) {
+		resp.Body.Close()
+		return nil, ratelimit.ErrRateLimited
+	}
+	return resp, nil
 }
 
 // Find searches for objects by external ID (IMDb ID)
@@ -135,12 +163,34 @@ type MovieDetails struct {
 	Title         string `json:"title"`
 	ReleaseDate   string `json:"release_date"`
 	OriginalTitle string `json:"original_title"`
+	Overview      string `json:"overview"`
+	PosterPath    string `json:"poster_path"`
+	BackdropPath  string `json:"backdrop_path"`
 }
 
 // TVDetails is the response from GET /tv/{id}
 type TVDetails struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	FirstAirDate string `json:"first_air_date"`
+	Overview     string `json:"overview"`
+	PosterPath   string `json:"poster_path"`
+	BackdropPath string `json:"backdrop_path"`
+	Seasons      []struct {
+		SeasonNumber int `json:"season_number"`
+		EpisodeCount int `json:"episode_count"`
+	} `json:"seasons"`
+}
+
+// SeasonDetails is the response from GET /tv/{id}/season/{season_number}
+type SeasonDetails struct {
+	ID           int `json:"id"`
+	SeasonNumber int `json:"season_number"`
+	Episodes     []struct {
+		EpisodeNumber int    `json:"episode_number"`
+		Name          string `json:"name"`
+		AirDate       string `json:"air_date"`
+	} `json:"episodes"`
 }
 
 // GetMovieTitle returns the movie title for text-based search.
@@ -191,6 +241,109 @@ func (c *Client) GetTVShowName(tmdbID string, imdbID string) (string, error) {
 	return "", fmt.Errorf("could not resolve TV show name")
 }
 
+// GetTitleAndYear returns the display title and release year (first air year for TV)
+// of a movie or show. mediaType is "movie" or "series". Supports TMDB ID or IMDb ID (tt123).
+func (c *Client) GetTitleAndYear(mediaType, imdbID, tmdbID string) (string, string, error) {
+	if id, err := strconv.Atoi(tmdbID); err == nil {
+		if mediaType == "movie" {
+			d, err := c.GetMovieDetails(id)
+			if err != nil {
+				return "", "", err
+			}
+			return d.Title, yearOf(d.ReleaseDate), nil
+		}
+		d, err := c.GetTVDetails(id)
+		if err != nil {
+			return "", "", err
+		}
+		return d.Name, yearOf(d.FirstAirDate), nil
+	}
+	if imdbID != "" {
+		find, err := c.Find(imdbID, "imdb_id")
+		if err != nil {
+			return "", "", err
+		}
+		if mediaType == "movie" && len(find.MovieResults) > 0 {
+			return find.MovieResults[0].Title, yearOf(find.MovieResults[0].ReleaseDate), nil
+		}
+		if mediaType != "movie" && len(find.TVResults) > 0 {
+			return find.TVResults[0].Name, yearOf(find.TVResults[0].FirstAirDate), nil
+		}
+	}
+	return "", "", fmt.Errorf("could not resolve title")
+}
+
+// Summary is the display metadata of a movie or show (image paths are absolute URLs).
+type Summary struct {
+	TMDBID     int
+	Name       string
+	Year       string
+	Overview   string
+	Poster     string
+	Background string
+}
+
+// ImageURL returns the TMDB CDN URL of an image path at the given size (e.g. "w500",
+// "original"), or "" when the path is empty.
+func ImageURL(path, size string) string {
+	if path == "" {
+		return ""
+	}
+	return "https://image.tmdb.org/t/p/" + size + path
+}
+
+// GetSummary returns name, year, overview and artwork of a movie or show.
+// mediaType is "movie" or "series". Supports TMDB ID or IMDb ID (tt123).
+func (c *Client) GetSummary(mediaType, imdbID, tmdbID string) (*Summary, error) {
+	summary := func(id int, name, date, overview, poster, backdrop string) *Summary {
+		return &Summary{
+			TMDBID:     id,
+			Name:       name,
+			Year:       yearOf(date),
+			Overview:   overview,
+			Poster:     ImageURL(poster, "w500"),
+			Background: ImageURL(backdrop, "original"),
+		}
+	}
+	if id, err := strconv.Atoi(tmdbID); err == nil {
+		if mediaType == "movie" {
+			d, err := c.GetMovieDetails(id)
+			if err != nil {
+				return nil, err
+			}
+			return summary(d.ID, d.Title, d.ReleaseDate, d.Overview, d.PosterPath, d.BackdropPath), nil
+		}
+		d, err := c.GetTVDetails(id)
+		if err != nil {
+			return nil, err
+		}
+		return summary(d.ID, d.Name, d.FirstAirDate, d.Overview, d.PosterPath, d.BackdropPath), nil
+	}
+	if imdbID != "" {
+		find, err := c.Find(imdbID, "imdb_id")
+		if err != nil {
+			return nil, err
+		}
+		if mediaType == "movie" && len(find.MovieResults) > 0 {
+			r := find.MovieResults[0]
+			return summary(r.ID, r.Title, r.ReleaseDate, r.Overview, r.PosterPath, r.BackdropPath), nil
+		}
+		if mediaType != "movie" && len(find.TVResults) > 0 {
+			r := find.TVResults[0]
+			return summary(r.ID, r.Name, r.FirstAirDate, r.Overview, r.PosterPath, r.BackdropPath), nil
+		}
+	}
+	return nil, fmt.Errorf("could not resolve metadata")
+}
+
+// yearOf returns the year of a TMDB date (YYYY-MM-DD), or "" when missing.
+func yearOf(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
+
 // GetMovieDetails fetches movie title for text-based search.
 func (c *Client) GetMovieDetails(tmdbID int) (*MovieDetails, error) {
 	if c.apiKey == "" {
@@ -233,6 +386,57 @@ func (c *Client) GetTVDetails(tmdbID int) (*TVDetails, error) {
 	return &d, nil
 }
 
+// GetSeasonDetails fetches the episode list of a TV season.
+func (c *Client) GetSeasonDetails(tmdbID, season int) (*SeasonDetails, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("TMDB API key not configured")
+	}
+	endpoint := fmt.Sprintf("https://api.themoviedb.org/3/tv/%d/season/%d", tmdbID, season)
+	resp, err := c.doRequest(endpoint, url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("TMDB season details: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB returned status: %d", resp.StatusCode)
+	}
+	var d SeasonDetails
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("TMDB season decode: %w", err)
+	}
+	return &d, nil
+}
+
+// Episode is one episode of a show, as listed by GetEpisodes.
+type Episode struct {
+	Season  int
+	Number  int
+	Name    string
+	AirDate string
+}
+
+// GetEpisodes lists the episodes of every season of a show (specials are season 0).
+func (c *Client) GetEpisodes(tmdbID int) ([]Episode, error) {
+	show, err := c.GetTVDetails(tmdbID)
+	if err != nil {
+		return nil, err
+	}
+	var episodes []Episode
+	for _, season := range show.Seasons {
+		if season.EpisodeCount == 0 {
+			continue
+		}
+		d, err := c.GetSeasonDetails(tmdbID, season.SeasonNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, ep := range d.Episodes {
+			episodes = append(episodes, Episode{Season: season.SeasonNumber, Number: ep.EpisodeNumber, Name: ep.Name, AirDate: ep.AirDate})
+		}
+	}
+	return episodes, nil
+}
+
 // ResolveTVDBID tries to find the TVDB ID for a given IMDb string (e.g. tt123456)
 func (c *Client) ResolveTVDBID(imdbID string) (string, error) {
 	// 1. Find the TMDB ID from IMDb ID
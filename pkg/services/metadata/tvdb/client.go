@@ -8,6 +8,9 @@ import (
 	"strconv"
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/core/persistence"
+	"streamnzb/pkg/services/metadata/ratelimit"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,6 +30,12 @@ type Client struct {
 	dataDir    string
 	client     *http.Client
 	tokenCache string // in-memory cache, refreshed from state if needed
+	breaker    *ratelimit.Breaker
+
+	mu          sync.Mutex
+	animeCache  map[string]bool   // series ID -> has the Anime genre
+	absoluteMap map[string]int    // "series:season:episode" -> absolute episode number
+	remoteIDs   map[string]string // IMDb/TMDB ID -> TVDB series ID
 }
 
 // NewClient creates a new TVDB client
@@ -37,9 +46,18 @@ func NewClient(apiKey, dataDir string) *Client {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		breaker:     ratelimit.NewBreaker("TVDB"),
+		animeCache:  make(map[string]bool),
+		absoluteMap: make(map[string]int),
+		remoteIDs:   make(map[string]string),
 	}
 }
 
+// RateLimit returns the state of the client's 429 circuit breaker.
+func (c *Client) RateLimit() ratelimit.Status {
+	return c.breaker.Status()
+}
+
 // loginResponse matches the response from POST /login
 type loginResponse struct {
 	Status string `json:"status"`
@@ -64,6 +82,28 @@ type searchRemoteIDResponse struct {
 	} `json:"data"`
 }
 
+// seriesExtendedResponse matches the response from GET /series/{id}/extended
+type seriesExtendedResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Genres []struct {
+			Name string `json:"name"`
+		} `json:"genres"`
+	} `json:"data"`
+}
+
+// seriesEpisodesResponse matches the response from GET /series/{id}/episodes/default
+type seriesEpisodesResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Episodes []struct {
+			SeasonNumber   int `json:"seasonNumber"`
+			Number         int `json:"number"`
+			AbsoluteNumber int `json:"absoluteNumber"`
+		} `json:"episodes"`
+	} `json:"data"`
+}
+
 // tokenState is stored in state.json
 type tokenState struct {
 	Token     string `json:"token"`
@@ -128,6 +168,9 @@ func (c *Client) login() (string, error) {
 		return "", fmt.Errorf("TVDB login request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	if c.breaker.Check(resp) {
+		return "", ratelimit.ErrRateLimited
+	}
 
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("TVDB login returned status: %d", resp.StatusCode)
@@ -151,6 +194,9 @@ func (c *Client) invalidateToken() {
 
 // doRequest performs a request with Bearer auth, refreshing token if needed
 func (c *Client) doRequest(method, path string, body []byte) (*http.Response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
 	token, err := c.ensureToken()
 	if err != nil {
 		return nil, err
@@ -173,6 +219,10 @@ func (c *Client) doRequest(method, path string, body []byte) (*http.Response, er
 	if err != nil {
 		return nil, err
 	}
+	if c.breaker.Check(resp) {
+		resp.Body.Close()
+		return nil, ratelimit.ErrRateLimited
+	}
 	if resp.StatusCode == http.StatusUnauthorized {
 		c.invalidateToken()
 		// Could retry once with new token; for simplicity we return error
@@ -183,11 +233,29 @@ func (c *Client) doRequest(method, path string, body []byte) (*http.Response, er
 }
 
 // ResolveTVDBID looks up the TVDB series ID by IMDb ID (e.g. tt4283088) or TMDB ID.
-// Uses GET /search/remoteid/{remoteId}.
+// Uses GET /search/remoteid/{remoteId}; resolved IDs are cached for the lifetime of the
+// client, so they keep resolving while the API is rate limited.
 func (c *Client) ResolveTVDBID(remoteID string) (string, error) {
 	if c.apiKey == "" {
 		return "", fmt.Errorf("TVDB API key not configured")
 	}
+	c.mu.Lock()
+	cached, ok := c.remoteIDs[remoteID]
+	c.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+	tvdbID, err := c.resolveRemoteID(remoteID)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.remoteIDs[remoteID] = tvdbID
+	c.mu.Unlock()
+	return tvdbID, nil
+}
+
+func (c *Client) resolveRemoteID(remoteID string) (string, error) {
 	resp, err := c.doRequest("GET", "/search/remoteid/"+remoteID, nil)
 	if err != nil {
 		return "", err
@@ -226,3 +294,98 @@ func (c *Client) ResolveTVDBID(remoteID string) (string, error) {
 	}
 	return "", fmt.Errorf("no TVDB ID found for remote ID: %s", remoteID)
 }
+
+// IsAnime reports whether the TVDB series is tagged with the Anime genre. Results are
+// cached for the lifetime of the client.
+func (c *Client) IsAnime(seriesID string) (bool, error) {
+	c.mu.Lock()
+	anime, ok := c.animeCache[seriesID]
+	c.mu.Unlock()
+	if ok {
+		return anime, nil
+	}
+
+	resp, err := c.doRequest("GET", "/series/"+seriesID+"/extended?short=true", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("TVDB series/extended returned status: %d", resp.StatusCode)
+	}
+
+	var out seriesExtendedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, fmt.Errorf("failed to decode TVDB response: %w", err)
+	}
+	if out.Status != successVal {
+		return false, fmt.Errorf("TVDB series lookup failed: status=%s", out.Status)
+	}
+	for _, g := range out.Data.Genres {
+		if strings.EqualFold(g.Name, "anime") {
+			anime = true
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.animeCache[seriesID] = anime
+	c.mu.Unlock()
+	return anime, nil
+}
+
+// ClearCache drops the cached anime flags, absolute episode mappings and resolved IDs.
+// Returns the number of entries removed.
+func (c *Client) ClearCache() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.animeCache) + len(c.absoluteMap) + len(c.remoteIDs)
+	c.animeCache = make(map[string]bool)
+	c.absoluteMap = make(map[string]int)
+	c.remoteIDs = make(map[string]string)
+	return n
+}
+
+// GetAbsoluteEpisode maps a season/episode in TVDB's default (aired) order to the
+// absolute episode number. Returns 0 without error when TVDB has no absolute number.
+func (c *Client) GetAbsoluteEpisode(seriesID string, season, episode int) (int, error) {
+	key := fmt.Sprintf("%s:%d:%d", seriesID, season, episode)
+	c.mu.Lock()
+	abs, ok := c.absoluteMap[key]
+	c.mu.Unlock()
+	if ok {
+		return abs, nil
+	}
+
+	path := fmt.Sprintf("/series/%s/episodes/default?page=0&season=%d&episodeNumber=%d", seriesID, season, episode)
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("TVDB series/episodes returned status: %d", resp.StatusCode)
+	}
+
+	var out seriesEpisodesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("failed to decode TVDB response: %w", err)
+	}
+	if out.Status != successVal {
+		return 0, fmt.Errorf("TVDB episode lookup failed: status=%s", out.Status)
+	}
+	for _, ep := range out.Data.Episodes {
+		if ep.SeasonNumber == season && ep.Number == episode {
+			abs = ep.AbsoluteNumber
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.absoluteMap[key] = abs
+	c.mu.Unlock()
+	logger.Debug("Resolved TVDB absolute episode", "series", seriesID, "season", season, "episode", episode, "absolute", abs)
+	return abs, nil
+}
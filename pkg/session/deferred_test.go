@@ -0,0 +1,101 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/indexer"
+	"streamnzb/pkg/release"
+)
+
+const testNZB = `<?xml version="1.0" encoding="UTF-8"?>
+<nzb xmlns="http://www.newzbin.com/DTD/2003/nzb">
+<file poster="p" date="1" subject="&quot;Movie.2001.1080p.mkv&quot; yEnc (1/1)"><groups><group>alt.binaries.test</group></groups>
+<segments><segment bytes="734003200" number="1">movie-1@test</segment></segments></file>
+</nzb>`
+
+// downloadIndexer serves testNZB unless fail is set, recording the URLs it was asked for.
+type downloadIndexer struct {
+	fail bool
+
+	mu   sync.Mutex
+	urls []string
+}
+
+func (x *downloadIndexer) Search(req indexer.SearchRequest) (*indexer.SearchResponse, error) {
+	return &indexer.SearchResponse{}, nil
+}
+
+func (x *downloadIndexer) DownloadNZB(ctx context.Context, nzbURL string) ([]byte, error) {
+	x.mu.Lock()
+	x.urls = append(x.urls, nzbURL)
+	x.mu.Unlock()
+	if x.fail {
+		return nil, errors.New("nzb gone")
+	}
+	return []byte(testNZB), nil
+}
+
+func (x *downloadIndexer) Ping() error             { return nil }
+func (x *downloadIndexer) Name() string            { return "download" }
+func (x *downloadIndexer) GetUsage() indexer.Usage { return indexer.Usage{} }
+
+func (x *downloadIndexer) requested() []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return append([]string(nil), x.urls...)
+}
+
+func TestDeferredDownloadFallsBackToAlternate(t *testing.T) {
+	logger.Init("DEBUG")
+	m := NewManager(nil, time.Minute)
+	primary, alt, spare := &downloadIndexer{fail: true}, &downloadIndexer{}, &downloadIndexer{}
+	rel := &release.Release{Title: "Movie.2001.1080p", Indexer: "primary"}
+	s, err := m.CreateDeferredSession("deferred-alt", "https://primary.test/nzb/1?apikey=k", rel, primary, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddAlternateDownload("https://primary.test/nzb/1?apikey=k", alt, "alt") // the primary itself
+	s.AddAlternateDownload("https://alt.test/nzb/9?apikey=k", nil, "alt")     // no indexer
+	s.AddAlternateDownload("https://alt.test/nzb/9?apikey=k", alt, "alt")
+	s.AddAlternateDownload("https://alt.test/nzb/9?apikey=k", alt, "alt") // duplicate
+	s.AddAlternateDownload("https://spare.test/nzb/3?apikey=k", spare, "spare")
+
+	got, err := s.GetOrDownloadNZB(m)
+	if err != nil {
+		t.Fatalf("GetOrDownloadNZB: %v", err)
+	}
+	if got == nil || s.File == nil {
+		t.Fatal("session has no NZB or file after the fallback")
+	}
+	if urls := primary.requested(); len(urls) != 1 {
+		t.Errorf("primary asked for %v; want one download", urls)
+	}
+	if urls := alt.requested(); len(urls) != 1 || urls[0] != "https://alt.test/nzb/9?apikey=k" {
+		t.Errorf("alternate asked for %v", urls)
+	}
+	if urls := spare.requested(); len(urls) != 0 {
+		t.Errorf("later alternate tried after a success: %v", urls)
+	}
+}
+
+func TestDeferredDownloadAllSourcesFail(t *testing.T) {
+	logger.Init("DEBUG")
+	m := NewManager(nil, time.Minute)
+	primary, alt := &downloadIndexer{fail: true}, &downloadIndexer{fail: true}
+	s, err := m.CreateDeferredSession("deferred-fail", "https://primary.test/nzb/1?apikey=k", &release.Release{Title: "Movie"}, primary, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddAlternateDownload("https://alt.test/nzb/9?apikey=k", alt, "alt")
+	if _, err := s.GetOrDownloadNZB(m); err == nil {
+		t.Fatal("expected an error when every source fails")
+	}
+	if len(primary.requested()) != 1 || len(alt.requested()) != 1 {
+		t.Errorf("primary %v, alternate %v; want one attempt each", primary.requested(), alt.requested())
+	}
+}
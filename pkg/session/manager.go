@@ -5,14 +5,17 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/indexer"
 	"streamnzb/pkg/media/loader"
 	"streamnzb/pkg/media/nzb"
+	"streamnzb/pkg/media/unpack"
 	"streamnzb/pkg/release"
 	"streamnzb/pkg/usenet/nntp"
 )
@@ -24,12 +27,21 @@ type Session struct {
 	Files []*loader.File // All files related to the content (e.g. RAR volumes)
 	File  *loader.File   // Helper for single-file content, or first file of archive
 	// Cache for archive structure
-	Blueprint   interface{} // type *unpack.ArchiveBlueprint (interface to avoid strict cycle, though safe)
+	Blueprint interface{} // type *unpack.ArchiveBlueprint (interface to avoid strict cycle, though safe)
+	// Provider is the host of the provider validation picked for playback (GetBestProvider);
+	// its pool is tried first for every segment. "" keeps the priority order.
+	Provider string
+	// Blueprints of single content files played with ?file= or ?name=, by file index
+	fileBlueprints map[int]interface{}
+	// Blueprints discarded after a corrupt stream, by file index (-1: session blueprint)
+	discarded   map[int]bool
 	CreatedAt   time.Time
 	LastAccess  time.Time
 	ActivePlays int32
 	Clients     map[string]time.Time // IP -> Connected time
 	mu          sync.Mutex
+	// played is set by the first StartPlayback; only played sessions are swept as idle
+	played bool
 
 	// Lifecycle
 	ctx    context.Context
@@ -44,6 +56,44 @@ type Session struct {
 	// Deferred download: URL to fetch NZB (may have apikey added by caller); indexer for DownloadNZB
 	downloadURL string
 	indexer     indexer.Indexer
+	// Same release on other indexers, tried in order when the primary download fails
+	alternates []deferredDownload
+
+	// Optional on-disk blueprint persistence (shared with the manager)
+	blueprints *unpack.BlueprintCache
+
+	// Release NFO, fetched on first /nfo request
+	nfo string
+
+	// Devices (by username) the session was offered to or played by; see AddDevice
+	devices map[string]bool
+}
+
+// deferredDownload is one source a deferred session's NZB can be fetched from.
+type deferredDownload struct {
+	url         string
+	indexer     indexer.Indexer
+	indexerName string
+}
+
+// AddAlternateDownload registers another indexer's copy of the same release.
+// GetOrDownloadNZB tries alternates in the order they were added when the
+// primary download fails. Duplicate URLs are ignored.
+func (s *Session) AddAlternateDownload(downloadURL string, idx indexer.Indexer, indexerName string) {
+	if downloadURL == "" || idx == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if downloadURL == s.downloadURL {
+		return
+	}
+	for _, alt := range s.alternates {
+		if alt.url == downloadURL {
+			return
+		}
+	}
+	s.alternates = append(s.alternates, deferredDownload{url: downloadURL, indexer: idx, indexerName: indexerName})
 }
 
 // ReleaseURL returns the indexer details URL for AvailNZB reporting
@@ -80,21 +130,203 @@ type Manager struct {
 	estimator *loader.SegmentSizeEstimator
 	ttl       time.Duration
 	mu        sync.RWMutex
+
+	// Sweeping: interval changes are handed to cleanupLoop; idleTimeout (0 = disabled) closes
+	// played sessions without active playback sooner than ttl
+	sweepInterval chan time.Duration
+	idleTimeout   time.Duration
+	sweptExpired  atomic.Int64
+	sweptIdle     atomic.Int64
+
+	// Optional on-disk blueprint cache; nil disables persistence
+	blueprints *unpack.BlueprintCache
+
+	// Segments streams prefetch ahead of the read position; 0 = one per connection
+	readAhead int
+	// Per-provider segment download deadline for playback; 0 = NNTP default
+	segmentTimeout time.Duration
+	// Readers idle this long (player paused) cancel their prefetches; 0 = never
+	pauseRelease time.Duration
+
+	// Per-device playback tracking: device key -> session ID -> open play requests
+	devicePlays   map[string]map[string]int
+	devicePlaysMu sync.Mutex
 }
 
-// SetBlueprint caches the archive blueprint
+// SetBlueprint caches the archive blueprint, persisting it to disk when a blueprint cache is configured
 func (s *Session) SetBlueprint(bp interface{}) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.Blueprint = bp
+	cache, nzbData, files := s.blueprints, s.NZB, s.Files
+	s.mu.Unlock()
+
+	if cache != nil && nzbData != nil {
+		cache.Store(nzbData.Hash(), files, bp)
+	}
+}
+
+// FileBlueprint returns the blueprint of content file i played on its own, loading it from
+// the blueprint cache on first use; nil when it has not been built yet.
+func (s *Session) FileBlueprint(i int, f *loader.File) interface{} {
+	s.mu.Lock()
+	bp, ok := s.fileBlueprints[i]
+	cache, nzbData := s.blueprints, s.NZB
+	s.mu.Unlock()
+	if ok || cache == nil || nzbData == nil {
+		return bp
+	}
+	if bp = cache.Load(fileBlueprintKey(nzbData.Hash(), i), []*loader.File{f}); bp != nil {
+		s.mu.Lock()
+		if s.fileBlueprints == nil {
+			s.fileBlueprints = make(map[int]interface{})
+		}
+		s.fileBlueprints[i] = bp
+		s.mu.Unlock()
+	}
+	return bp
+}
+
+// SetFileBlueprint caches the blueprint of content file i played on its own. It is kept
+// apart from the session blueprint, which covers the auto-selected content.
+func (s *Session) SetFileBlueprint(i int, f *loader.File, bp interface{}) {
+	s.mu.Lock()
+	if s.fileBlueprints == nil {
+		s.fileBlueprints = make(map[int]interface{})
+	}
+	s.fileBlueprints[i] = bp
+	cache, nzbData := s.blueprints, s.NZB
+	s.mu.Unlock()
+
+	if cache != nil && nzbData != nil {
+		cache.Store(fileBlueprintKey(nzbData.Hash(), i), []*loader.File{f}, bp)
+	}
+}
+
+// DiscardBlueprint drops the cached blueprint of content file i, or of the session when
+// i < 0, from memory and disk, so the next GetMediaStream scans again. It reports false,
+// leaving the blueprint in place, when it was already discarded once: a fresh scan that
+// is broken as well would otherwise be redone on every play request.
+func (s *Session) DiscardBlueprint(i int) bool {
+	s.mu.Lock()
+	if s.discarded[i] {
+		s.mu.Unlock()
+		return false
+	}
+	if s.discarded == nil {
+		s.discarded = make(map[int]bool)
+	}
+	s.discarded[i] = true
+	key := ""
+	if s.NZB != nil {
+		key = s.NZB.Hash()
+	}
+	if i < 0 {
+		s.Blueprint = nil
+	} else {
+		delete(s.fileBlueprints, i)
+		if key != "" {
+			key = fileBlueprintKey(key, i)
+		}
+	}
+	cache := s.blueprints
+	s.mu.Unlock()
+
+	if cache != nil && key != "" {
+		cache.Remove(key)
+	}
+	return true
+}
+
+// fileBlueprintKey is the blueprint cache key of content file i of the NZB with this hash.
+func fileBlueprintKey(hash string, i int) string {
+	return fmt.Sprintf("%s-file%d", hash, i)
+}
+
+// NFO returns the cached release NFO, or "" when it has not been fetched.
+func (s *Session) NFO() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nfo
+}
+
+// SetNFO stores the release NFO on the session.
+func (s *Session) SetNFO(nfo string) {
+	s.mu.Lock()
+	s.nfo = nfo
+	s.mu.Unlock()
+}
+
+// AddDevice records that the session was offered to or played by the device. Sessions
+// are shared between devices that find the same release, so a session can have several.
+func (s *Session) AddDevice(username string) {
+	if username == "" {
+		return
+	}
+	s.mu.Lock()
+	if s.devices == nil {
+		s.devices = make(map[string]bool)
+	}
+	s.devices[username] = true
+	s.mu.Unlock()
+}
+
+// HasDevice reports whether AddDevice recorded the device.
+func (s *Session) HasDevice(username string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.devices[username]
+}
+
+// SetBlueprintCache enables on-disk blueprint persistence for sessions created afterwards.
+func (m *Manager) SetBlueprintCache(c *unpack.BlueprintCache) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blueprints = c
+}
+
+// ClearBlueprintCache removes all persisted blueprints; returns how many were removed.
+// Sessions keep the blueprints they already hold.
+func (m *Manager) ClearBlueprintCache() int {
+	m.mu.RLock()
+	c := m.blueprints
+	m.mu.RUnlock()
+	if c == nil {
+		return 0
+	}
+	return c.Clear()
+}
+
+// SetReadAheadSegments sets the prefetch depth for files of new sessions.
+func (m *Manager) SetReadAheadSegments(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readAhead = n
+}
+
+// SetPlaybackSegmentTimeout sets how long a provider may take to deliver a segment
+// for files of new sessions before the next provider is tried (0 = NNTP default).
+func (m *Manager) SetPlaybackSegmentTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.segmentTimeout = d
+}
+
+// SetPauseRelease sets how long a stream of a new session may go without reads before it
+// stops prefetching and leaves the provider connections to other streams (0 = never).
+func (m *Manager) SetPauseRelease(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pauseRelease = d
 }
 
 func NewManager(pools []*nntp.ClientPool, ttl time.Duration) *Manager {
 	m := &Manager{
-		sessions:  make(map[string]*Session),
-		pools:     pools,
-		estimator: loader.NewSegmentSizeEstimator(),
-		ttl:       ttl,
+		sessions:      make(map[string]*Session),
+		pools:         pools,
+		estimator:     loader.NewSegmentSizeEstimator(),
+		ttl:           ttl,
+		sweepInterval: make(chan time.Duration),
+		devicePlays:   make(map[string]map[string]int),
 	}
 
 	// Start cleanup goroutine
@@ -117,10 +349,10 @@ func catFromReportMeta(m *AvailReportMeta) string {
 		return ""
 	}
 	if m.Season > 0 || m.Episode > 0 || m.TvdbID != "" {
-		return "5000"
+		return indexer.CategorySeries
 	}
 	if m.ImdbID != "" {
-		return "2000"
+		return indexer.CategoryMovie
 	}
 	return ""
 }
@@ -129,6 +361,14 @@ func catFromReportMeta(m *AvailReportMeta) string {
 // rel provides release metadata for AvailNZB; contentIDs holds catalog context (ImdbID, TvdbID, etc.).
 // Heavy work (GetContentFiles, NewFile) is done outside the manager lock.
 func (m *Manager) CreateSession(sessionID string, nzbData *nzb.NZB, rel *release.Release, contentIDs *AvailReportMeta) (*Session, error) {
+	return m.CreateSessionWithProvider(sessionID, nzbData, rel, contentIDs, "")
+}
+
+// CreateSessionWithProvider is CreateSession with the playback provider picked by
+// validation: the pool of providerHost is tried first, so every play of the session is
+// served by the same provider while it has the segments. An existing session is returned
+// unchanged.
+func (m *Manager) CreateSessionWithProvider(sessionID string, nzbData *nzb.NZB, rel *release.Release, contentIDs *AvailReportMeta, providerHost string) (*Session, error) {
 	logger.Trace("session CreateSession start", "id", sessionID)
 	m.mu.Lock()
 	if existing, ok := m.sessions[sessionID]; ok {
@@ -149,20 +389,36 @@ func (m *Manager) CreateSession(sessionID string, nzbData *nzb.NZB, rel *release
 	m.mu.RLock()
 	pools := m.pools
 	estimator := m.estimator
+	blueprints := m.blueprints
+	readAhead := m.readAhead
+	segmentTimeout := m.segmentTimeout
+	pauseRelease := m.pauseRelease
 	m.mu.RUnlock()
 
+	pools = preferPool(pools, providerHost)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	var loaderFiles []*loader.File
 	for _, info := range contentFiles {
 		lf := loader.NewFile(ctx, info.File, pools, estimator)
+		lf.SetReadAhead(readAhead)
+		lf.SetSegmentTimeout(segmentTimeout)
+		lf.SetPauseRelease(pauseRelease)
 		loaderFiles = append(loaderFiles, lf)
 	}
 
+	var bp interface{}
+	if blueprints != nil {
+		bp = blueprints.Load(nzbData.Hash(), loaderFiles)
+	}
+
 	session := &Session{
 		ID:         sessionID,
 		NZB:        nzbData,
 		Files:      loaderFiles,
 		File:       loaderFiles[0],
+		Blueprint:  bp,
+		Provider:   providerHost,
 		Release:    rel,
 		ContentIDs: contentIDs,
 		CreatedAt:  time.Now(),
@@ -170,6 +426,7 @@ func (m *Manager) CreateSession(sessionID string, nzbData *nzb.NZB, rel *release
 		Clients:    make(map[string]time.Time),
 		ctx:        ctx,
 		cancel:     cancel,
+		blueprints: blueprints,
 	}
 
 	logger.Trace("session CreateSession insert", "id", sessionID)
@@ -186,6 +443,27 @@ func (m *Manager) CreateSession(sessionID string, nzbData *nzb.NZB, rel *release
 	return session, nil
 }
 
+// preferPool returns pools with the pool of host moved to the front, keeping the order of
+// the others. pools is returned as is when host is "" or not among them.
+func preferPool(pools []*nntp.ClientPool, host string) []*nntp.ClientPool {
+	if host == "" {
+		return pools
+	}
+	for i, p := range pools {
+		if p.Host() != host {
+			continue
+		}
+		if i == 0 {
+			return pools
+		}
+		ordered := make([]*nntp.ClientPool, 0, len(pools))
+		ordered = append(ordered, p)
+		ordered = append(ordered, pools[:i]...)
+		return append(ordered, pools[i+1:]...)
+	}
+	return pools
+}
+
 // CreateDeferredSession creates a session placeholder without downloading the NZB yet.
 // downloadURL is the NZB fetch URL (caller adds apikey if needed). rel provides metadata; idx is used for DownloadNZB.
 func (m *Manager) CreateDeferredSession(sessionID, downloadURL string, rel *release.Release, idx indexer.Indexer, contentIDs *AvailReportMeta) (*Session, error) {
@@ -214,6 +492,7 @@ func (m *Manager) CreateDeferredSession(sessionID, downloadURL string, rel *rele
 		Clients:     make(map[string]time.Time),
 		ctx:         ctx,
 		cancel:      cancel,
+		blueprints:  m.blueprints,
 	}
 	m.sessions[sessionID] = session
 	logger.Trace("session CreateDeferredSession done", "id", sessionID)
@@ -233,8 +512,7 @@ func (s *Session) GetOrDownloadNZB(manager *Manager) (*nzb.NZB, error) {
 		s.mu.Unlock()
 		return nil, fmt.Errorf("session has no NZB and no deferred download info")
 	}
-	nzbURL := s.downloadURL
-	idx := s.indexer
+	sources := append([]deferredDownload{{url: s.downloadURL, indexer: s.indexer}}, s.alternates...)
 	itemTitle := ""
 	indexerName := ""
 	reportSize := int64(0)
@@ -248,8 +526,86 @@ func (s *Session) GetOrDownloadNZB(manager *Manager) (*nzb.NZB, error) {
 	ctx := s.ctx
 	s.mu.Unlock()
 
+	// Try the primary indexer, then the same release on other indexers
+	var parsedNZB *nzb.NZB
+	var err error
+	for i, src := range sources {
+		name := indexerName
+		if i > 0 {
+			name = src.indexerName
+		}
+		parsedNZB, err = downloadDeferredNZB(ctx, src, itemTitle, name, reportSize, reportCat)
+		if err == nil {
+			if i > 0 {
+				logger.Info("Downloaded NZB from alternate indexer", "title", itemTitle, "indexer", name)
+			}
+			break
+		}
+		if i < len(sources)-1 {
+			logger.Warn("Deferred NZB download failed, trying alternate indexer", "title", itemTitle, "indexer", name, "err", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	contentFiles := parsedNZB.GetContentFiles()
+	if len(contentFiles) == 0 {
+		logger.Error("Lazy load: no content files in NZB",
+			"title", itemTitle,
+			"indexer", indexerName,
+			"nzb_files", len(parsedNZB.Files),
+			"details", "see DEBUG log GetContentFiles returned empty for file list")
+		return nil, fmt.Errorf("no content files found in lazy NZB")
+	}
+	if reason := parsedNZB.BrokenReason(); reason != "" {
+		logger.Error("Lazy load: broken release", "title", itemTitle, "indexer", indexerName, "reason", reason)
+		return nil, fmt.Errorf("%w: %s", nzb.ErrBrokenRelease, reason)
+	}
+
+	manager.mu.RLock()
+	pools := manager.pools
+	estimator := manager.estimator
+	readAhead := manager.readAhead
+	segmentTimeout := manager.segmentTimeout
+	pauseRelease := manager.pauseRelease
+	manager.mu.RUnlock()
+
+	var loaderFiles []*loader.File
+	for _, info := range contentFiles {
+		lf := loader.NewFile(ctx, info.File, pools, estimator)
+		lf.SetReadAhead(readAhead)
+		lf.SetSegmentTimeout(segmentTimeout)
+		lf.SetPauseRelease(pauseRelease)
+		loaderFiles = append(loaderFiles, lf)
+	}
+
+	var bp interface{}
+	if s.blueprints != nil {
+		bp = s.blueprints.Load(parsedNZB.Hash(), loaderFiles)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.NZB != nil {
+		return s.NZB, nil
+	}
+	s.NZB = parsedNZB
+	s.Files = loaderFiles
+	s.File = loaderFiles[0]
+	if s.Blueprint == nil {
+		s.Blueprint = bp
+	}
+	return s.NZB, nil
+}
+
+// downloadDeferredNZB fetches and parses the NZB from one deferred download source.
+func downloadDeferredNZB(ctx context.Context, src deferredDownload, itemTitle, indexerName string, reportSize int64, reportCat string) (*nzb.NZB, error) {
+	if src.url == "" || src.indexer == nil {
+		return nil, fmt.Errorf("no deferred download info")
+	}
 	var data []byte
 	var err error
+	nzbURL, idx := src.url, src.indexer
 	downloadCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 
@@ -290,36 +646,7 @@ func (s *Session) GetOrDownloadNZB(manager *Manager) (*nzb.NZB, error) {
 		logger.Debug("Failed to parse NZB", "indexer", indexerName, "title", itemTitle, "url", nzbURL, "len", len(data), "snippet", snippet, "err", err)
 		return nil, fmt.Errorf("failed to parse lazy downloaded NZB: %w", err)
 	}
-	contentFiles := parsedNZB.GetContentFiles()
-	if len(contentFiles) == 0 {
-		logger.Error("Lazy load: no content files in NZB",
-			"title", itemTitle,
-			"indexer", indexerName,
-			"nzb_files", len(parsedNZB.Files),
-			"details", "see DEBUG log GetContentFiles returned empty for file list")
-		return nil, fmt.Errorf("no content files found in lazy NZB")
-	}
-
-	manager.mu.RLock()
-	pools := manager.pools
-	estimator := manager.estimator
-	manager.mu.RUnlock()
-
-	var loaderFiles []*loader.File
-	for _, info := range contentFiles {
-		lf := loader.NewFile(ctx, info.File, pools, estimator)
-		loaderFiles = append(loaderFiles, lf)
-	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.NZB != nil {
-		return s.NZB, nil
-	}
-	s.NZB = parsedNZB
-	s.Files = loaderFiles
-	s.File = loaderFiles[0]
-	return s.NZB, nil
+	return parsedNZB, nil
 }
 
 // GetSession retrieves an existing session
@@ -340,6 +667,18 @@ func (m *Manager) GetSession(sessionID string) (*Session, error) {
 	return session, nil
 }
 
+// Sessions returns a snapshot of all sessions, oldest first.
+func (m *Manager) Sessions() []*Session {
+	m.mu.RLock()
+	out := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		out = append(out, s)
+	}
+	m.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
 // DeleteSession removes a session
 func (m *Manager) DeleteSession(sessionID string) {
 	m.mu.Lock()
@@ -360,17 +699,55 @@ func (s *Session) Close() {
 	}
 }
 
-// cleanupLoop periodically removes expired sessions
+// DefaultSweepInterval is how often expired and idle sessions are removed unless
+// SetSweepInterval changes it.
+const DefaultSweepInterval = 5 * time.Minute
+
+// SweepStats counts sessions removed by the background sweep since start.
+type SweepStats struct {
+	Expired int64 `json:"expired"` // unused for longer than the session TTL
+	Idle    int64 `json:"idle"`    // played, then idle for longer than the idle timeout
+}
+
+// SetSweepInterval changes how often sessions are swept (<= 0 restores DefaultSweepInterval).
+func (m *Manager) SetSweepInterval(d time.Duration) {
+	if d <= 0 {
+		d = DefaultSweepInterval
+	}
+	m.sweepInterval <- d
+}
+
+// SetIdleTimeout closes played sessions once they have had no playback and no access for d,
+// before the absolute TTL; 0 disables it.
+func (m *Manager) SetIdleTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.idleTimeout = max(d, 0)
+}
+
+// SweepStats returns how many sessions the background sweep has removed.
+func (m *Manager) SweepStats() SweepStats {
+	return SweepStats{Expired: m.sweptExpired.Load(), Idle: m.sweptIdle.Load()}
+}
+
+// cleanupLoop periodically removes expired and idle sessions
 func (m *Manager) cleanupLoop() {
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(DefaultSweepInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		m.cleanup()
+	for {
+		select {
+		case <-ticker.C:
+			m.cleanup()
+		case d := <-m.sweepInterval:
+			ticker.Reset(d)
+		}
 	}
 }
 
-// cleanup removes sessions that haven't been accessed within TTL.
+// cleanup removes sessions that haven't been accessed within TTL, and played sessions
+// that have been idle for longer than the idle timeout. Sessions with an active playback
+// are never removed.
 // We must not call session.Close() while holding session.mu: Close() locks the same
 // mutex, causing deadlock (sync.Mutex is not reentrant). So we remove from map
 // under lock, then unlock, then Close().
@@ -380,10 +757,17 @@ func (m *Manager) cleanup() {
 
 	now := time.Now()
 	var toClose []*Session
+	var expired, idle int64
 	for id, session := range m.sessions {
 		session.mu.Lock()
-		hasActivePlayback := session.ActivePlays > 0 || len(session.Clients) > 0
-		if !hasActivePlayback && now.Sub(session.LastAccess) > m.ttl {
+		switch {
+		case session.ActivePlays > 0:
+		case len(session.Clients) == 0 && now.Sub(session.LastAccess) > m.ttl:
+			expired++
+			delete(m.sessions, id)
+			toClose = append(toClose, session)
+		case session.idleLocked(now, m.idleTimeout):
+			idle++
 			delete(m.sessions, id)
 			toClose = append(toClose, session)
 		}
@@ -392,6 +776,25 @@ func (m *Manager) cleanup() {
 	for _, s := range toClose {
 		s.Close()
 	}
+	m.sweptExpired.Add(expired)
+	m.sweptIdle.Add(idle)
+	if expired+idle > 0 {
+		logger.Debug("Swept sessions", "expired", expired, "idle", idle, "remaining", len(m.sessions))
+	}
+}
+
+// idleLocked reports whether a played session without active playback has been unused
+// (no access, no client seen) for longer than timeout. Caller holds s.mu.
+func (s *Session) idleLocked(now time.Time, timeout time.Duration) bool {
+	if timeout <= 0 || !s.played || s.ActivePlays > 0 || now.Sub(s.LastAccess) <= timeout {
+		return false
+	}
+	for _, lastSeen := range s.Clients {
+		if now.Sub(lastSeen) <= timeout {
+			return false
+		}
+	}
+	return true
 }
 
 // StartPlayback increments the active play count for a session and tracks IP
@@ -400,6 +803,7 @@ func (m *Manager) StartPlayback(id, ip string) {
 	if err == nil {
 		s.mu.Lock()
 		s.ActivePlays++
+		s.played = true
 		s.Clients[ip] = time.Now()
 		s.mu.Unlock()
 	}
@@ -430,12 +834,60 @@ func (m *Manager) KeepAlive(id, ip string) {
 	}
 }
 
+// TryStartDevicePlayback registers a play request for sessionID on behalf of deviceKey
+// (device token or client IP). It returns false when the device is already playing
+// limit distinct sessions; limit <= 0 means unlimited. Further requests for a session
+// the device is already playing (range requests, seeks) are always allowed.
+// Every successful call must be paired with EndDevicePlayback.
+func (m *Manager) TryStartDevicePlayback(deviceKey, sessionID string, limit int) bool {
+	m.devicePlaysMu.Lock()
+	defer m.devicePlaysMu.Unlock()
+
+	plays := m.devicePlays[deviceKey]
+	if plays == nil {
+		plays = make(map[string]int)
+		m.devicePlays[deviceKey] = plays
+	}
+	if _, playing := plays[sessionID]; !playing && limit > 0 && len(plays) >= limit {
+		return false
+	}
+	plays[sessionID]++
+	return true
+}
+
+// EndDevicePlayback releases a play request registered with TryStartDevicePlayback.
+func (m *Manager) EndDevicePlayback(deviceKey, sessionID string) {
+	m.devicePlaysMu.Lock()
+	defer m.devicePlaysMu.Unlock()
+
+	plays := m.devicePlays[deviceKey]
+	if plays == nil {
+		return
+	}
+	if plays[sessionID] <= 1 {
+		delete(plays, sessionID)
+	} else {
+		plays[sessionID]--
+	}
+	if len(plays) == 0 {
+		delete(m.devicePlays, deviceKey)
+	}
+}
+
+// DevicePlaybackCount returns the number of distinct sessions deviceKey is currently playing.
+func (m *Manager) DevicePlaybackCount(deviceKey string) int {
+	m.devicePlaysMu.Lock()
+	defer m.devicePlaysMu.Unlock()
+	return len(m.devicePlays[deviceKey])
+}
+
 // ActiveSessionInfo provides details about a currently playing session
 type ActiveSessionInfo struct {
 	ID        string   `json:"id"`
 	Title     string   `json:"title"`
 	Clients   []string `json:"clients"`
 	StartTime string   `json:"start_time"`
+	Provider  string   `json:"provider,omitempty"` // host serving playback first (Session.Provider)
 }
 
 // GetActiveSessions returns a list of sessions that are currently playing.
@@ -485,6 +937,7 @@ func (m *Manager) GetActiveSessions() []ActiveSessionInfo {
 				Title:     title,
 				Clients:   clients,
 				StartTime: s.CreatedAt.Format(time.Kitchen),
+				Provider:  s.Provider,
 			})
 		}
 		s.mu.Unlock()
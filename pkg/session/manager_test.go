@@ -0,0 +1,143 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/release"
+)
+
+func TestDevicePlaybackLimit(t *testing.T) {
+	m := NewManager(nil, time.Minute)
+
+	if !m.TryStartDevicePlayback("dev", "s1", 2) || !m.TryStartDevicePlayback("dev", "s2", 2) {
+		t.Fatal("playbacks within the limit were refused")
+	}
+	if m.TryStartDevicePlayback("dev", "s3", 2) {
+		t.Error("third session allowed with a limit of 2")
+	}
+	// Range requests and seeks of a session already playing are always allowed
+	if !m.TryStartDevicePlayback("dev", "s1", 2) {
+		t.Error("another request for a playing session was refused")
+	}
+	if n := m.DevicePlaybackCount("dev"); n != 2 {
+		t.Errorf("DevicePlaybackCount = %d; want 2 distinct sessions", n)
+	}
+	// Devices are counted separately; limit <= 0 is unlimited
+	if !m.TryStartDevicePlayback("other", "s3", 2) {
+		t.Error("another device was limited by dev's playbacks")
+	}
+	for i := 0; i < 5; i++ {
+		if !m.TryStartDevicePlayback("unlimited", string(rune('a'+i)), 0) {
+			t.Fatal("limit 0 refused a playback")
+		}
+	}
+}
+
+func TestEndDevicePlaybackReleases(t *testing.T) {
+	m := NewManager(nil, time.Minute)
+	m.TryStartDevicePlayback("dev", "s1", 1)
+	m.TryStartDevicePlayback("dev", "s1", 1) // second request of the same session
+
+	m.EndDevicePlayback("dev", "s1")
+	if m.TryStartDevicePlayback("dev", "s2", 1) {
+		t.Error("slot released while a request of s1 was still playing")
+	}
+	m.EndDevicePlayback("dev", "s1")
+	if n := m.DevicePlaybackCount("dev"); n != 0 {
+		t.Fatalf("DevicePlaybackCount = %d after all requests ended; want 0", n)
+	}
+	if !m.TryStartDevicePlayback("dev", "s2", 1) {
+		t.Error("slot not released after the last request ended")
+	}
+
+	// Ending unknown playbacks is a no-op and doesn't leak entries
+	m.EndDevicePlayback("dev", "s2")
+	m.EndDevicePlayback("dev", "s2")
+	m.EndDevicePlayback("nobody", "s1")
+	m.devicePlaysMu.Lock()
+	n := len(m.devicePlays)
+	m.devicePlaysMu.Unlock()
+	if n != 0 {
+		t.Errorf("%d device entries left after every playback ended", n)
+	}
+}
+
+// ageSession backdates a session's last access and every client's last-seen time by d.
+func ageSession(t *testing.T, m *Manager, id string, d time.Duration) {
+	t.Helper()
+	m.mu.RLock()
+	s := m.sessions[id]
+	m.mu.RUnlock()
+	s.mu.Lock()
+	s.LastAccess = time.Now().Add(-d)
+	for ip := range s.Clients {
+		s.Clients[ip] = time.Now().Add(-d)
+	}
+	s.mu.Unlock()
+}
+
+func TestCleanupSweepsExpiredAndIdle(t *testing.T) {
+	logger.Init("DEBUG")
+	m := NewManager(nil, time.Minute)
+	m.SetIdleTimeout(10 * time.Second)
+	for _, id := range []string{"expired", "idle", "playing", "client-seen", "fresh"} {
+		if _, err := m.CreateDeferredSession(id, "https://indexer.test/nzb/"+id, &release.Release{Title: id}, &downloadIndexer{}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ageSession(t, m, "expired", 2*time.Minute) // never played, past the TTL
+	m.StartPlayback("idle", "10.0.0.1")
+	m.EndPlayback("idle", "10.0.0.1")
+	ageSession(t, m, "idle", 20*time.Second) // played, idle past the idle timeout
+	m.StartPlayback("playing", "10.0.0.2")
+	ageSession(t, m, "playing", 2*time.Minute) // active playback is never swept
+	m.StartPlayback("client-seen", "10.0.0.3")
+	m.EndPlayback("client-seen", "10.0.0.3")
+	ageSession(t, m, "client-seen", 20*time.Second)
+	m.KeepAlive("client-seen", "10.0.0.4")    // a client was seen just now
+	ageSession(t, m, "fresh", 20*time.Second) // idle but never played
+
+	m.cleanup()
+	for id, want := range map[string]bool{"expired": false, "idle": false, "playing": true, "client-seen": true, "fresh": true} {
+		m.mu.RLock()
+		_, ok := m.sessions[id]
+		m.mu.RUnlock()
+		if ok != want {
+			t.Errorf("session %s kept = %v; want %v", id, ok, want)
+		}
+	}
+	if got := m.SweepStats(); got != (SweepStats{Expired: 1, Idle: 1}) {
+		t.Errorf("SweepStats = %+v; want 1 expired, 1 idle", got)
+	}
+
+	// With the idle timeout disabled only the TTL applies
+	m.SetIdleTimeout(0)
+	m.StartPlayback("fresh", "10.0.0.5")
+	m.EndPlayback("fresh", "10.0.0.5")
+	ageSession(t, m, "fresh", 20*time.Second)
+	m.cleanup()
+	if _, err := m.GetSession("fresh"); err != nil {
+		t.Error("played session swept as idle with the idle timeout disabled")
+	}
+}
+
+func TestSetSweepInterval(t *testing.T) {
+	logger.Init("DEBUG")
+	m := NewManager(nil, time.Minute)
+	if _, err := m.CreateDeferredSession("old", "https://indexer.test/nzb/old", &release.Release{Title: "old"}, &downloadIndexer{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	ageSession(t, m, "old", 2*time.Minute)
+	m.SetSweepInterval(20 * time.Millisecond)
+	t.Cleanup(func() { m.SetSweepInterval(0) })
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.SweepStats().Expired == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expired session not swept on the shortened interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -23,8 +23,14 @@ type Client struct {
 	user    string
 	pass    string
 
-	LastUsed time.Time
-	pool     *ClientPool // Reference to parent pool for metrics
+	LastUsed   time.Time
+	lastPing   time.Time   // last keepalive while idle
+	pool       *ClientPool // Reference to parent pool for metrics
+	background bool        // Checked out via GetBackground
+
+	// COMPRESS DEFLATE: wantCompress is re-negotiated on Reconnect
+	wantCompress bool
+	compressed   bool
 }
 
 func NewClient(address string, port int, ssl bool) (*Client, error) {
@@ -102,6 +108,13 @@ func (c *Client) Authenticate(user, pass string) error {
 }
 
 func (c *Client) Group(group string) error {
+	_, err := c.GroupInfo(group)
+	return err
+}
+
+// GroupInfo selects group and returns the article count the server reports for it
+// ("211 count low high group").
+func (c *Client) GroupInfo(group string) (int64, error) {
 	const maxRetries = 2
 
 	for i := 0; i <= maxRetries; i++ {
@@ -113,15 +126,19 @@ func (c *Client) Group(group string) error {
 					continue
 				}
 			}
-			return err
+			return 0, err
 		}
 
 		c.conn.StartResponse(id)
-		code, _, err := c.conn.ReadCodeLine(211)
+		code, line, err := c.conn.ReadCodeLine(211)
 		c.conn.EndResponse(id)
 
 		if err == nil {
-			return nil
+			var count int64
+			if fields := strings.Fields(line); len(fields) > 0 {
+				count, _ = strconv.ParseInt(fields[0], 10, 64)
+			}
+			return count, nil
 		}
 
 		if c.shouldRetry(code, err) {
@@ -129,11 +146,11 @@ func (c *Client) Group(group string) error {
 				continue
 			}
 		} else {
-			return err
+			return 0, err
 		}
 	}
 	// Return generic or last error
-	return errors.New("group command failed after retries")
+	return 0, errors.New("group command failed after retries")
 }
 
 // bodyReader calls EndResponse when the body is fully read (on EOF), so the
@@ -162,15 +179,29 @@ func formatMessageID(messageID string) string {
 	return "<" + s + ">"
 }
 
+// defaultBodyTimeout bounds reading an article body when no timeout is given.
+const defaultBodyTimeout = 5 * time.Minute
+
 // Body returns a Reader for the body of the article.
 // Caller is responsible for reading until EOF (dot). EndResponse is called only after EOF.
 func (c *Client) Body(messageID string) (io.Reader, error) {
+	return c.BodyTimeout(messageID, 0)
+}
+
+// BodyTimeout is Body with a deadline of timeout for the command and the whole body
+// read (0 = 60s for the command, 5 minutes for the body). A timed out read returns
+// an error for which IsTimeout reports true; the client should then be discarded.
+func (c *Client) BodyTimeout(messageID string, timeout time.Duration) (io.Reader, error) {
 	const maxRetries = 2
 	var lastErr error
 
 	for i := 0; i <= maxRetries; i++ {
 		// 1. Send Command (message-id must be in angle brackets)
-		c.setDeadline()
+		if timeout > 0 {
+			c.setTimeout(timeout)
+		} else {
+			c.setDeadline()
+		}
 		bodyArg := formatMessageID(messageID)
 		id, err := c.conn.Cmd("BODY %s", bodyArg)
 		if err != nil {
@@ -201,10 +232,10 @@ func (c *Client) Body(messageID string) (io.Reader, error) {
 		}
 
 		// Set deadline for body read to prevent indefinite blocking
-		c.setDeadline()
-		if c.netConn != nil {
-			c.netConn.SetDeadline(time.Now().Add(5 * time.Minute))
+		if timeout <= 0 {
+			timeout = defaultBodyTimeout
 		}
+		c.setTimeout(timeout)
 		metricR := &metricReader{r: c.conn.DotReader(), client: c}
 		// Defer EndResponse until caller reads to EOF so pipeline matches actual consumption
 		return &bodyReader{
@@ -273,24 +304,56 @@ func (c *Client) Reconnect() error {
 
 	c.conn = tp
 	c.netConn = conn
+	c.compressed = false
 
 	// Re-authenticate
 	if c.user != "" {
-		return c.Authenticate(c.user, c.pass)
+		if err := c.Authenticate(c.user, c.pass); err != nil {
+			return err
+		}
+	}
+	if c.wantCompress {
+		if _, err := c.enableCompression(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// Ping sends DATE to keep an idle connection open and check that it still works.
+func (c *Client) Ping(timeout time.Duration) error {
+	c.setTimeout(timeout)
+	id, err := c.conn.Cmd("DATE")
+	if err != nil {
+		return err
+	}
+	c.conn.StartResponse(id)
+	_, _, err = c.conn.ReadCodeLine(111) // 111 server date and time
+	c.conn.EndResponse(id)
+	return err
+}
+
 func (c *Client) Quit() error {
 	return c.conn.Close()
 }
 
 func (c *Client) setDeadline() {
+	c.setTimeout(60 * time.Second)
+}
+
+func (c *Client) setTimeout(d time.Duration) {
 	if c.netConn != nil {
-		c.netConn.SetDeadline(time.Now().Add(60 * time.Second))
+		c.netConn.SetDeadline(time.Now().Add(d))
 	}
 }
 
+// IsTimeout reports whether err is a network timeout (e.g. a BodyTimeout deadline),
+// as opposed to the server rejecting the request.
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 func (c *Client) setShortDeadline() {
 	if c.netConn != nil {
 		// Aggressive 2s timeout for STAT checks to ensure responsiveness during triage
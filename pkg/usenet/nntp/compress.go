@@ -0,0 +1,135 @@
+package nntp
+
+import (
+	"compress/flate"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+)
+
+// deflateConn wraps a connection after a successful RFC 8054 COMPRESS DEFLATE.
+// Both directions are raw deflate streams; every write is sync-flushed so each
+// command reaches the server immediately.
+type deflateConn struct {
+	net.Conn
+	r io.ReadCloser
+	w *flate.Writer
+}
+
+func newDeflateConn(conn net.Conn) (*deflateConn, error) {
+	w, err := flate.NewWriter(conn, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &deflateConn{Conn: conn, r: flate.NewReader(conn), w: w}, nil
+}
+
+func (d *deflateConn) Read(p []byte) (int, error) {
+	return d.r.Read(p)
+}
+
+func (d *deflateConn) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, d.w.Flush()
+}
+
+func (d *deflateConn) Close() error {
+	d.r.Close()
+	return d.Conn.Close()
+}
+
+// capabilities returns the server's capability lines (upper-cased). It tries
+// CAPABILITIES (RFC 3977) first and falls back to LIST EXTENSIONS for older servers.
+func (c *Client) capabilities() ([]string, error) {
+	c.setDeadline()
+	lines, err := c.listCommand("CAPABILITIES", 101)
+	if err == nil {
+		return lines, nil
+	}
+	return c.listCommand("LIST EXTENSIONS", 202)
+}
+
+func (c *Client) listCommand(cmd string, expectCode int) ([]string, error) {
+	id, err := c.conn.Cmd("%s", cmd)
+	if err != nil {
+		return nil, err
+	}
+	c.conn.StartResponse(id)
+	defer c.conn.EndResponse(id)
+	if _, _, err := c.conn.ReadCodeLine(expectCode); err != nil {
+		return nil, err
+	}
+	lines, err := c.conn.ReadDotLines()
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range lines {
+		lines[i] = strings.ToUpper(strings.TrimSpace(line))
+	}
+	return lines, nil
+}
+
+func supportsDeflate(caps []string) bool {
+	for _, line := range caps {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "COMPRESS" {
+			continue
+		}
+		for _, alg := range fields[1:] {
+			if alg == "DEFLATE" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enableCompression negotiates COMPRESS DEFLATE when the server advertises it.
+// It returns false without error when the server does not support it; the
+// connection stays usable uncompressed in that case.
+func (c *Client) enableCompression() (bool, error) {
+	if c.compressed {
+		return true, nil
+	}
+	caps, err := c.capabilities()
+	if err != nil {
+		// Servers without capability listing reply 500; that is not a connection error
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !supportsDeflate(caps) {
+		return false, nil
+	}
+
+	c.setDeadline()
+	id, err := c.conn.Cmd("COMPRESS DEFLATE")
+	if err != nil {
+		return false, err
+	}
+	c.conn.StartResponse(id)
+	code, _, err := c.conn.ReadCodeLine(206)
+	c.conn.EndResponse(id)
+	if err != nil {
+		if code != 0 {
+			return false, nil
+		}
+		return false, err
+	}
+
+	dc, err := newDeflateConn(c.netConn)
+	if err != nil {
+		return false, err
+	}
+	c.conn = textproto.NewConn(dc)
+	c.compressed = true
+	log.Debug("NNTP compression enabled", "host", c.host)
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package nntp
+
+import (
+	"bufio"
+	"compress/flate"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+)
+
+// fakeCompressServer speaks just enough NNTP to negotiate COMPRESS DEFLATE and
+// answer STAT over the compressed stream.
+func fakeCompressServer(conn net.Conn, advertise bool) {
+	defer conn.Close()
+	r := textproto.NewReader(bufio.NewReader(conn))
+	var w io.Writer = conn
+	var fw *flate.Writer
+	send := func(s string) {
+		io.WriteString(w, s+"\r\n")
+		if fw != nil {
+			fw.Flush()
+		}
+	}
+	send("200 ready")
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+		switch {
+		case line == "CAPABILITIES":
+			send("101 Capability list:")
+			send("VERSION 2")
+			if advertise {
+				send("COMPRESS DEFLATE")
+			}
+			send(".")
+		case line == "COMPRESS DEFLATE":
+			send("206 Compression active")
+			fw, _ = flate.NewWriter(conn, flate.DefaultCompression)
+			w = fw
+			r = textproto.NewReader(bufio.NewReader(flate.NewReader(conn)))
+		case strings.HasPrefix(line, "STAT"):
+			send("223 0 " + strings.TrimPrefix(line, "STAT "))
+		default:
+			send("500 unknown command")
+		}
+	}
+}
+
+func TestEnableCompression(t *testing.T) {
+	logger.Init("DEBUG")
+	for _, advertise := range []bool{true, false} {
+		clientConn, serverConn := net.Pipe()
+		go fakeCompressServer(serverConn, advertise)
+
+		tp := textproto.NewConn(clientConn)
+		if _, _, err := tp.ReadResponse(200); err != nil {
+			t.Fatalf("greeting: %v", err)
+		}
+		c := &Client{conn: tp, netConn: clientConn, host: "test"}
+
+		ok, err := c.enableCompression()
+		if err != nil {
+			t.Fatalf("enableCompression(advertise=%v): %v", advertise, err)
+		}
+		if ok != advertise {
+			t.Fatalf("enableCompression(advertise=%v) = %v", advertise, ok)
+		}
+		found, err := c.CheckArticle("<a@b>")
+		if err != nil || !found {
+			t.Fatalf("STAT after negotiation (advertise=%v): found=%v err=%v", advertise, found, err)
+		}
+		c.Quit()
+	}
+}
@@ -2,12 +2,19 @@ package nntp
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"streamnzb/pkg/core/logger"
 )
 
+// log tags this package's messages; set "nntp" in log_levels to change its level.
+var log = logger.For("nntp")
+
 type ClientPool struct {
 	host    string
 	port    int
@@ -29,6 +36,24 @@ type ClientPool struct {
 	providerName string
 	usageManager *ProviderUsageManager
 
+	// Optional NNTP COMPRESS DEFLATE; compressUnsupported remembers a server
+	// that does not advertise it so new connections skip the negotiation
+	compress            bool
+	compressUnsupported bool
+
+	// Connections background work (validation, cache warming) may never take,
+	// so playback always has them; bgActive counts background checkouts
+	playbackReserve int
+	bgActive        atomic.Int32
+
+	// Idle connections the reaper leaves open (startup warmup)
+	keepWarm int
+
+	// Idle connections are closed after idleTimeout; the ones kept open are sent a
+	// DATE every keepalive (0 = off) so providers don't drop them silently
+	idleTimeout time.Duration
+	keepalive   time.Duration
+
 	mu     sync.Mutex
 	closed bool
 }
@@ -44,6 +69,7 @@ func NewClientPool(host string, port int, ssl bool, user, pass string, maxConn i
 		idleClients: make(chan *Client, maxConn),
 		slots:       make(chan struct{}, maxConn),
 		lastCheck:   time.Now(),
+		idleTimeout: defaultIdleTimeout,
 	}
 
 	// Fill slots with permits
@@ -63,6 +89,129 @@ func (p *ClientPool) SetUsageManager(name string, mgr *ProviderUsageManager) {
 	p.usageManager = mgr
 }
 
+// ErrReservedForPlayback is returned by GetBackground when only connections
+// reserved for playback are left.
+var ErrReservedForPlayback = errors.New("remaining connections reserved for playback")
+
+// SetPlaybackReserve keeps n connections free of background work (GetBackground).
+// At least one connection stays available to background work.
+func (p *ClientPool) SetPlaybackReserve(n int) {
+	if n > p.maxConn-1 {
+		n = p.maxConn - 1
+	}
+	if n < 0 {
+		n = 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.playbackReserve = n
+}
+
+// SetKeepWarm keeps up to n idle connections open instead of reaping them after
+// the idle timeout, so the first request does not pay dial, TLS and auth latency.
+func (p *ClientPool) SetKeepWarm(n int) {
+	if n > p.maxConn {
+		n = p.maxConn
+	}
+	if n < 0 {
+		n = 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keepWarm = n
+}
+
+const (
+	defaultIdleTimeout = 30 * time.Second
+	reaperInterval     = 15 * time.Second
+	keepaliveTimeout   = 5 * time.Second
+)
+
+// SetIdleTimeout sets how long a connection may sit idle before it is closed
+// (keep-warm connections excepted). d <= 0 restores the default.
+func (p *ClientPool) SetIdleTimeout(d time.Duration) {
+	if d <= 0 {
+		d = defaultIdleTimeout
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idleTimeout = d
+}
+
+// SetKeepalive pings idle connections every d so the provider does not drop them;
+// a connection that fails the ping is closed instead of being handed out. 0 disables it.
+func (p *ClientPool) SetKeepalive(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keepalive = d
+}
+
+// GetBackground gets a client for background work such as validation or cache
+// warming. It tries an idle or new connection first and then waits on ctx, but
+// never uses the connections reserved for playback.
+func (p *ClientPool) GetBackground(ctx context.Context) (*Client, error) {
+	p.mu.Lock()
+	limit := int32(p.maxConn - p.playbackReserve)
+	p.mu.Unlock()
+	if p.bgActive.Add(1) > limit {
+		p.bgActive.Add(-1)
+		return nil, ErrReservedForPlayback
+	}
+	c, ok := p.TryGet(ctx)
+	if !ok {
+		var err error
+		if c, err = p.Get(ctx); err != nil {
+			p.bgActive.Add(-1)
+			return nil, err
+		}
+	}
+	c.background = true
+	return c, nil
+}
+
+// releaseBackground returns the background token held by c, if any.
+func (p *ClientPool) releaseBackground(c *Client) {
+	if c.background {
+		c.background = false
+		p.bgActive.Add(-1)
+	}
+}
+
+// SetCompression enables negotiating COMPRESS DEFLATE (RFC 8054) on new connections.
+// Servers that do not support it are used uncompressed.
+func (p *ClientPool) SetCompression(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.compress = enabled
+	p.compressUnsupported = false
+}
+
+// negotiateCompression enables compression on a freshly authenticated client
+// when configured. Only connection errors are returned.
+func (p *ClientPool) negotiateCompression(c *Client) error {
+	p.mu.Lock()
+	try := p.compress && !p.compressUnsupported
+	p.mu.Unlock()
+	if !try {
+		return nil
+	}
+	ok, err := c.enableCompression()
+	if err != nil {
+		return err
+	}
+	c.wantCompress = ok
+	if !ok {
+		log.Debug("NNTP compression not supported by provider", "host", p.host)
+		p.mu.Lock()
+		p.compressUnsupported = true
+		p.mu.Unlock()
+	}
+	return nil
+}
+
 // RestoreTotalBytes allows persisted counters to be injected on startup
 func (p *ClientPool) RestoreTotalBytes(total int64) {
 	p.mu.Lock()
@@ -142,14 +291,14 @@ func (p *ClientPool) TotalMegabytes() float64 {
 }
 
 func (p *ClientPool) Get(ctx context.Context) (*Client, error) {
-	logger.Trace("pool.Get start", "host", p.host)
+	log.Trace("pool.Get start", "host", p.host)
 	// 1. Prefer Idle Client
 	select {
 	case <-ctx.Done():
-		logger.Trace("pool.Get ctx.Done (idle check)", "host", p.host)
+		log.Trace("pool.Get ctx.Done (idle check)", "host", p.host)
 		return nil, ctx.Err()
 	case c := <-p.idleClients:
-		logger.Trace("pool.Get from idle", "host", p.host)
+		log.Trace("pool.Get from idle", "host", p.host)
 		return c, nil
 	default:
 	}
@@ -157,7 +306,7 @@ func (p *ClientPool) Get(ctx context.Context) (*Client, error) {
 	// 2. Try to create new connection (check slots)
 	select {
 	case <-ctx.Done():
-		logger.Trace("pool.Get ctx.Done (slot check)", "host", p.host)
+		log.Trace("pool.Get ctx.Done (slot check)", "host", p.host)
 		return nil, ctx.Err()
 	case <-p.slots:
 		// Got permit, dial
@@ -172,19 +321,24 @@ func (p *ClientPool) Get(ctx context.Context) (*Client, error) {
 			p.slots <- struct{}{}
 			return nil, err
 		}
-		logger.Trace("pool.Get new client", "host", p.host)
+		if err := p.negotiateCompression(c); err != nil {
+			c.Quit()
+			p.slots <- struct{}{}
+			return nil, err
+		}
+		log.Trace("pool.Get new client", "host", p.host)
 		return c, nil
 	default:
 	}
 
 	// 3. Block and wait for resource
-	logger.Trace("pool.Get blocking", "host", p.host)
+	log.Trace("pool.Get blocking", "host", p.host)
 	select {
 	case <-ctx.Done():
-		logger.Trace("pool.Get ctx.Done (blocking)", "host", p.host)
+		log.Trace("pool.Get ctx.Done (blocking)", "host", p.host)
 		return nil, ctx.Err()
 	case c := <-p.idleClients:
-		logger.Trace("pool.Get from idle (after block)", "host", p.host)
+		log.Trace("pool.Get from idle (after block)", "host", p.host)
 		return c, nil
 	case <-p.slots:
 		// Got permit, dial
@@ -199,7 +353,12 @@ func (p *ClientPool) Get(ctx context.Context) (*Client, error) {
 			p.slots <- struct{}{}
 			return nil, err
 		}
-		logger.Trace("pool.Get new client (after block)", "host", p.host)
+		if err := p.negotiateCompression(c); err != nil {
+			c.Quit()
+			p.slots <- struct{}{}
+			return nil, err
+		}
+		log.Trace("pool.Get new client (after block)", "host", p.host)
 		return c, nil
 	}
 }
@@ -231,6 +390,11 @@ func (p *ClientPool) TryGet(ctx context.Context) (*Client, bool) {
 			p.slots <- struct{}{}
 			return nil, false
 		}
+		if err := p.negotiateCompression(c); err != nil {
+			c.Quit()
+			p.slots <- struct{}{}
+			return nil, false
+		}
 		return c, true
 	default:
 		return nil, false
@@ -241,6 +405,7 @@ func (p *ClientPool) Put(c *Client) {
 	if c == nil {
 		return
 	}
+	p.releaseBackground(c)
 	p.mu.Lock()
 	closed := p.closed
 	p.mu.Unlock()
@@ -251,7 +416,7 @@ func (p *ClientPool) Put(c *Client) {
 		return
 	}
 	c.LastUsed = time.Now()
-	logger.Trace("pool.Put", "host", p.host)
+	log.Trace("pool.Put", "host", p.host)
 
 	select {
 	case p.idleClients <- c:
@@ -269,45 +434,75 @@ func (p *ClientPool) Discard(c *Client) {
 	if c == nil {
 		return
 	}
-	logger.Trace("pool.Discard", "host", p.host)
+	log.Trace("pool.Discard", "host", p.host)
+	p.releaseBackground(c)
 	c.Quit()
 	p.slots <- struct{}{}
 }
 
 func (p *ClientPool) reaperLoop() {
-	ticker := time.NewTicker(15 * time.Second) // Check every 15 seconds
-	timeout := 30 * time.Second                // Close connections idle >30s
+	ticker := time.NewTicker(reaperInterval)
+	defer ticker.Stop()
 
 	for range ticker.C {
-		p.mu.Lock()
-		if p.closed {
-			p.mu.Unlock()
+		if !p.reapIdle() {
 			return
 		}
+	}
+}
+
+// reapIdle closes idle connections past the idle timeout and pings the ones due a
+// keepalive. It returns false once the pool is shut down.
+func (p *ClientPool) reapIdle() bool {
+	p.mu.Lock()
+	if p.closed {
 		p.mu.Unlock()
-		// Scan idle clients
-		// We want to check ALL currently idle clients.
-		// However, channel is FIFO/random.
-		// Strategy: Iterate 'count' times equal to current length.
-		// If used recently, put back. If old, close.
-
-		count := len(p.idleClients)
-		for i := 0; i < count; i++ {
-			select {
-			case c := <-p.idleClients:
-				if time.Since(c.LastUsed) > timeout {
-					// Idle timeout
+		return false
+	}
+	keepWarm := p.keepWarm
+	timeout := p.idleTimeout
+	keepalive := p.keepalive
+	p.mu.Unlock()
+	// Scan idle clients
+	// We want to check ALL currently idle clients.
+	// However, channel is FIFO/random.
+	// Strategy: Iterate 'count' times equal to current length.
+	// If used recently, put back. If old, close.
+
+	count := len(p.idleClients)
+	for i := 0; i < count; i++ {
+		select {
+		case c := <-p.idleClients:
+			// len+1: idle clients including c
+			if time.Since(c.LastUsed) > timeout && len(p.idleClients)+1 > keepWarm {
+				// Idle timeout
+				c.Quit()
+				p.slots <- struct{}{} // Release permit
+				continue
+			}
+			if keepalive > 0 && time.Since(c.LastUsed) >= keepalive && time.Since(c.lastPing) >= keepalive {
+				if err := c.Ping(keepaliveTimeout); err != nil {
+					log.Debug("NNTP keepalive failed, closing idle connection", "host", p.host, "err", err)
+					c.Quit()
+					p.slots <- struct{}{}
+					continue
+				}
+				c.lastPing = time.Now()
+				p.mu.Lock()
+				closed := p.closed
+				p.mu.Unlock()
+				if closed { // shut down during the ping
 					c.Quit()
-					p.slots <- struct{}{} // Release permit
-				} else {
-					// Still fresh, keep
-					p.idleClients <- c
+					continue
 				}
-			default:
-				// Empty
 			}
+			// Still fresh, keep
+			p.idleClients <- c
+		default:
+			// Empty
 		}
 	}
+	return true
 }
 
 // Validate checks if the pool can successfully connect and authenticate.
@@ -323,6 +518,29 @@ func (p *ClientPool) Validate() error {
 	return nil
 }
 
+// ProbeGroup selects group on a pooled connection and returns the article count the
+// provider reports, confirming the account actually serves that (binary) group.
+func (p *ClientPool) ProbeGroup(group string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	c, err := p.Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, err := c.GroupInfo(group)
+	if err != nil {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) {
+			p.Put(c) // e.g. 411 no such group: the connection itself is fine
+			return 0, fmt.Errorf("provider does not serve %s: %w", group, err)
+		}
+		p.Discard(c)
+		return 0, err
+	}
+	p.Put(c)
+	return count, nil
+}
+
 func (p *ClientPool) Host() string {
 	return p.host
 }
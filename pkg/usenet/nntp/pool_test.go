@@ -0,0 +1,167 @@
+package nntp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+)
+
+func TestGetBackgroundRespectsPlaybackReserve(t *testing.T) {
+	logger.Init("DEBUG")
+	p := NewClientPool("news.example.com", 563, true, "", "", 3)
+	defer p.Shutdown()
+	p.SetPlaybackReserve(1)
+
+	// Pre-open all connections so no dialing happens
+	for i := 0; i < 3; i++ {
+		<-p.slots
+		conn, _ := net.Pipe()
+		p.idleClients <- &Client{conn: textproto.NewConn(conn), netConn: conn}
+	}
+	ctx := context.Background()
+
+	var bg []*Client
+	for i := 0; i < 2; i++ {
+		c, err := p.GetBackground(ctx)
+		if err != nil {
+			t.Fatalf("background get %d: %v", i, err)
+		}
+		bg = append(bg, c)
+	}
+	if _, err := p.GetBackground(ctx); !errors.Is(err, ErrReservedForPlayback) {
+		t.Fatalf("expected ErrReservedForPlayback, got %v", err)
+	}
+
+	// Playback can use the reserved connection
+	play, ok := p.TryGet(ctx)
+	if !ok {
+		t.Fatal("playback could not use the reserved connection")
+	}
+	p.Put(play)
+
+	// Returning a background client frees a background slot
+	p.Put(bg[0])
+	c, err := p.GetBackground(ctx)
+	if err != nil {
+		t.Fatalf("background get after release: %v", err)
+	}
+	p.Put(c)
+	p.Put(bg[1])
+	if n := p.bgActive.Load(); n != 0 {
+		t.Errorf("expected no background checkouts, got %d", n)
+	}
+}
+
+func TestReapIdleKeepalive(t *testing.T) {
+	logger.Init("DEBUG")
+	p := NewClientPool("news.example.com", 563, true, "", "", 2)
+	defer p.Shutdown()
+	p.SetKeepWarm(2)
+	p.SetKeepalive(time.Minute)
+
+	// healthy answers DATE, dead has its server side closed
+	healthy, server := net.Pipe()
+	go func() {
+		tp := textproto.NewConn(server)
+		if line, err := tp.ReadLine(); err == nil && line == "DATE" {
+			tp.PrintfLine("111 20260101000000")
+		}
+	}()
+	dead, deadServer := net.Pipe()
+	deadServer.Close()
+
+	idle := time.Now().Add(-2 * time.Minute)
+	for _, conn := range []net.Conn{healthy, dead} {
+		<-p.slots
+		p.idleClients <- &Client{conn: textproto.NewConn(conn), netConn: conn, LastUsed: idle}
+	}
+
+	if !p.reapIdle() {
+		t.Fatal("reapIdle reported a closed pool")
+	}
+	if n := p.IdleConnections(); n != 1 {
+		t.Fatalf("expected 1 idle connection after keepalive, got %d", n)
+	}
+	if n := p.TotalConnections(); n != 1 {
+		t.Errorf("expected the failed connection's slot to be released, got %d open", n)
+	}
+	c := <-p.idleClients
+	if c.netConn != healthy || c.lastPing.IsZero() {
+		t.Errorf("expected the pinged healthy connection to stay idle")
+	}
+}
+
+func TestReapIdleKeepWarm(t *testing.T) {
+	logger.Init("DEBUG")
+	p := NewClientPool("news.example.com", 563, true, "", "", 3)
+	defer p.Shutdown()
+	if p.SetKeepWarm(10); p.keepWarm != 3 {
+		t.Errorf("SetKeepWarm(10) with 3 connections = %d; want 3", p.keepWarm)
+	}
+	if p.SetKeepWarm(-1); p.keepWarm != 0 {
+		t.Errorf("SetKeepWarm(-1) = %d; want 0", p.keepWarm)
+	}
+
+	stale := func(n int) {
+		for i := 0; i < n; i++ {
+			<-p.slots
+			conn, _ := net.Pipe()
+			p.idleClients <- &Client{conn: textproto.NewConn(conn), netConn: conn, LastUsed: time.Now().Add(-2 * p.idleTimeout)}
+		}
+	}
+	stale(3)
+	p.SetKeepWarm(1)
+	p.reapIdle()
+	if n := p.IdleConnections(); n != 1 {
+		t.Errorf("expected 1 warm connection to survive the idle timeout, got %d", n)
+	}
+	if n := p.TotalConnections(); n != 1 {
+		t.Errorf("expected the other slots to be released, got %d open", n)
+	}
+
+	p.SetKeepWarm(0)
+	p.reapIdle()
+	if n := p.TotalConnections(); n != 0 {
+		t.Errorf("without warm connections %d stale connections were kept", n)
+	}
+}
+
+func TestProbeGroup(t *testing.T) {
+	logger.Init("DEBUG")
+	p := NewClientPool("news.example.com", 563, true, "", "", 1)
+	defer p.Shutdown()
+
+	conn, server := net.Pipe()
+	go func() {
+		tp := textproto.NewConn(server)
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			if line == "GROUP alt.binaries.test" {
+				tp.PrintfLine("211 123456 1000 124455 alt.binaries.test")
+			} else {
+				tp.PrintfLine("411 No such group")
+			}
+		}
+	}()
+	<-p.slots
+	p.idleClients <- &Client{conn: textproto.NewConn(conn), netConn: conn, LastUsed: time.Now()}
+
+	count, err := p.ProbeGroup("alt.binaries.test")
+	if err != nil || count != 123456 {
+		t.Fatalf("ProbeGroup = %d, %v; want 123456", count, err)
+	}
+	if _, err := p.ProbeGroup("alt.text.only"); err == nil {
+		t.Fatal("expected an error for a group the provider does not serve")
+	}
+	if n := p.IdleConnections(); n != 1 {
+		t.Errorf("expected the connection back in the pool after 411, got %d idle", n)
+	}
+}
@@ -0,0 +1,49 @@
+package nntp
+
+import (
+	"fmt"
+	"io"
+)
+
+// Post uploads an article (header lines, empty line, body lines; not dot-stuffed)
+// with POST. A rejection is returned as a *textproto.Error carrying the server's code.
+func (c *Client) Post(article []string) error {
+	return c.sendArticle("POST", 340, 240, article)
+}
+
+// IHave offers an article with IHAVE (transit). A rejection (435 not wanted,
+// 436 try later, 437 rejected) is returned as a *textproto.Error.
+func (c *Client) IHave(messageID string, article []string) error {
+	return c.sendArticle(fmt.Sprintf("IHAVE %s", messageID), 335, 235, article)
+}
+
+// sendArticle runs cmd, waits for sendCode, transmits the article and waits for doneCode.
+func (c *Client) sendArticle(cmd string, sendCode, doneCode int, article []string) error {
+	c.setDeadline()
+	id, err := c.conn.Cmd("%s", cmd)
+	if err != nil {
+		return err
+	}
+
+	c.conn.StartResponse(id)
+	defer c.conn.EndResponse(id)
+
+	if _, _, err := c.conn.ReadCodeLine(sendCode); err != nil {
+		return err
+	}
+
+	// DotWriter dot-stuffs, converts line endings and writes the terminating "."
+	w := c.conn.DotWriter()
+	for _, line := range article {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	_, _, err = c.conn.ReadCodeLine(doneCode)
+	return err
+}
@@ -55,10 +55,11 @@ func (s *Session) handleCapabilities(args []string) error {
 		"101 Capability list:",
 		"VERSION 2",
 		"READER",
-		"POST",
-		"IHAVE",
 		"STREAMING",
 	}
+	if s.postPool != nil {
+		capabilities = append(capabilities, "POST", "IHAVE")
+	}
 
 	if s.authUser != "" {
 		capabilities = append(capabilities, "AUTHINFO USER")
@@ -85,11 +86,20 @@ func (s *Session) handleAuthInfo(args []string) error {
 		}
 
 		if value == s.authUser {
+			s.userAccepted = true
 			return s.WriteLine("381 Password required")
 		}
+		s.userAccepted = false
 		return s.WriteLine("481 Authentication failed")
 
 	case "PASS":
+		if s.authUser == "" {
+			s.authenticated = true
+			return s.WriteLine("281 Authentication accepted")
+		}
+		if !s.userAccepted {
+			return s.WriteLine("482 Authentication commands issued out of sequence")
+		}
 		if value == s.authPass {
 			s.authenticated = true
 			return s.WriteLine("281 Authentication accepted")
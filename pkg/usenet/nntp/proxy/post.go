@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/usenet/nntp"
+)
+
+// maxPostSize limits the article size accepted for POST/IHAVE (articles are buffered in memory).
+const maxPostSize = 16 << 20
+
+var errArticleTooLarge = errors.New("article too large")
+
+// readArticle reads a dot-terminated article from the client and undoes dot-stuffing.
+// An oversized article is still read to the end so the session stays in sync.
+func (s *Session) readArticle() ([]string, error) {
+	var lines []string
+	size := 0
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if line == "." {
+			if size > maxPostSize {
+				return nil, errArticleTooLarge
+			}
+			return lines, nil
+		}
+		line = strings.TrimPrefix(line, ".")
+		size += len(line) + 2
+		if size <= maxPostSize {
+			lines = append(lines, line)
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("connection closed during article transfer")
+}
+
+// upload runs send on a connection of the upload provider.
+func (s *Session) upload(send func(client *nntp.Client) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), poolGetTimeout)
+	defer cancel()
+	client, err := s.postPool.Get(ctx)
+	if err != nil {
+		return err
+	}
+	err = send(client)
+	s.postPool.Put(client)
+	return err
+}
+
+// handlePost handles the POST command: the article is read from the client, then
+// posted through the upload provider.
+func (s *Session) handlePost(args []string) error {
+	if s.postPool == nil {
+		return s.WriteLine("440 Posting not permitted")
+	}
+	if err := s.WriteLine("340 Send article to be posted"); err != nil {
+		return err
+	}
+	article, err := s.readArticle()
+	if errors.Is(err, errArticleTooLarge) {
+		return s.WriteLine("441 Article too large")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.upload(func(client *nntp.Client) error { return client.Post(article) }); err != nil {
+		logger.Info("NNTP proxy: POST failed", "err", err)
+		var tpErr *textproto.Error
+		if errors.As(err, &tpErr) {
+			return s.WriteLine(fmt.Sprintf("441 %s", tpErr.Msg))
+		}
+		return s.WriteLine("441 Posting failed")
+	}
+	logger.Debug("NNTP proxy: article posted", "remote", s.conn.RemoteAddr())
+	return s.WriteLine("240 Article received OK")
+}
+
+// handleIHave handles the IHAVE command: the article is read from the client, then
+// offered to the upload provider, relaying its verdict.
+func (s *Session) handleIHave(args []string) error {
+	if s.postPool == nil {
+		return s.WriteLine("502 Transfer not permitted")
+	}
+	if len(args) < 1 {
+		return s.WriteLine("501 Syntax error")
+	}
+	messageID := normalizeMessageID(args[0])
+
+	if err := s.WriteLine("335 Send it"); err != nil {
+		return err
+	}
+	article, err := s.readArticle()
+	if errors.Is(err, errArticleTooLarge) {
+		return s.WriteLine("437 Article too large")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.upload(func(client *nntp.Client) error { return client.IHave(messageID, article) }); err != nil {
+		logger.Info("NNTP proxy: IHAVE failed", "messageID", messageID, "err", err)
+		var tpErr *textproto.Error
+		if errors.As(err, &tpErr) && tpErr.Code == 437 {
+			return s.WriteLine(fmt.Sprintf("437 %s", tpErr.Msg))
+		}
+		if errors.As(err, &tpErr) && tpErr.Code == 435 {
+			return s.WriteLine("437 Article not wanted")
+		}
+		return s.WriteLine("436 Transfer not possible; try again later")
+	}
+	return s.WriteLine("235 Article transferred OK")
+}
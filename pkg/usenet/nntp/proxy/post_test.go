@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/usenet/nntp"
+)
+
+// fakeUploadServer accepts any login and POST, sending every received article on the returned channel.
+func fakeUploadServer(t *testing.T) (string, int, <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	articles := make(chan []string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				tp := textproto.NewConn(conn)
+				tp.PrintfLine("200 ready")
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					if strings.HasPrefix(line, "AUTHINFO") {
+						tp.PrintfLine("281 accepted")
+						continue
+					}
+					if line != "POST" {
+						tp.PrintfLine("500 unsupported")
+						continue
+					}
+					tp.PrintfLine("340 send article")
+					lines, err := tp.ReadDotLines()
+					if err != nil {
+						return
+					}
+					articles <- lines
+					tp.PrintfLine("240 article posted")
+				}
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, articles
+}
+
+func TestPostRequiresAuthAndForwards(t *testing.T) {
+	logger.Init("DEBUG")
+	host, port, articles := fakeUploadServer(t)
+	pool := nntp.NewClientPool(host, port, false, "", "", 1)
+	defer pool.Shutdown()
+
+	s := &Server{authUser: "user", authPass: "secret", sessions: make(map[string]*Session)}
+	s.SetPostPool(pool)
+
+	serverConn, clientConn := net.Pipe()
+	go s.handleConnection(serverConn)
+	c := textproto.NewConn(clientConn)
+	defer c.Close()
+
+	expect := func(cmd string, code int) {
+		t.Helper()
+		if cmd != "" {
+			if err := c.PrintfLine("%s", cmd); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, msg, err := c.ReadCodeLine(code); err != nil {
+			t.Fatalf("%q: expected %d, got %v (%s)", cmd, code, err, msg)
+		}
+	}
+
+	expect("", 200)
+	expect("POST", 480)
+	expect("AUTHINFO PASS secret", 482) // PASS before USER
+	expect("AUTHINFO USER user", 381)
+	expect("AUTHINFO PASS secret", 281)
+
+	expect("POST", 340)
+	w := c.DotWriter()
+	w.Write([]byte("Subject: test\n\n.leading dot\nbody\n"))
+	w.Close()
+	expect("", 240)
+
+	got := strings.Join(<-articles, "\n")
+	if want := "Subject: test\n\n.leading dot\nbody"; got != want {
+		t.Errorf("forwarded article = %q, want %q", got, want)
+	}
+	expect("QUIT", 205)
+}
+
+func TestSetPostPoolRequiresCredentials(t *testing.T) {
+	logger.Init("DEBUG")
+	pool := nntp.NewClientPool("127.0.0.1", 1, false, "", "", 1)
+	defer pool.Shutdown()
+	s := &Server{}
+	s.SetPostPool(pool)
+	if s.postPool != nil {
+		t.Fatal("posting enabled without proxy credentials")
+	}
+}
@@ -1,7 +1,6 @@
 package proxy
 
 import (
-	"bufio"
 	"fmt"
 	"net"
 	"streamnzb/pkg/core/logger"
@@ -16,6 +15,7 @@ type Server struct {
 	host     string
 	port     int
 	pools    []*nntp.ClientPool
+	postPool *nntp.ClientPool
 	authUser string
 	authPass string
 
@@ -42,6 +42,17 @@ func NewServer(host string, port int, pools []*nntp.ClientPool, authUser, authPa
 	return s, nil
 }
 
+// SetPostPool enables POST/IHAVE, forwarding uploaded articles to pool. Posting
+// requires proxy credentials so the proxy never becomes an open relay; without
+// them the call is ignored. Call before Start.
+func (s *Server) SetPostPool(pool *nntp.ClientPool) {
+	if pool != nil && s.authUser == "" {
+		logger.Warn("NNTP proxy posting requires proxy_auth_user/proxy_auth_pass; staying read-only")
+		return
+	}
+	s.postPool = pool
+}
+
 // Validate checks if the proxy server can be started (port free, security checks)
 func (s *Server) Validate() error {
 	addr := fmt.Sprintf("%s:%d", s.host, s.port)
@@ -95,7 +106,7 @@ func (s *Server) handleConnection(conn net.Conn) {
 	defer conn.Close()
 
 	// Create session
-	session := NewSession(conn, s.pools, s.authUser, s.authPass)
+	session := NewSession(conn, s.pools, s.postPool, s.authUser, s.authPass)
 
 	// Store session
 	s.mu.Lock()
@@ -110,10 +121,14 @@ func (s *Server) handleConnection(conn net.Conn) {
 	}()
 
 	// Send welcome banner
-	session.WriteLine("200 StreamNZB NNTP Proxy ready (posting prohibited)")
+	if s.postPool != nil {
+		session.WriteLine("200 StreamNZB NNTP Proxy ready (posting allowed)")
+	} else {
+		session.WriteLine("200 StreamNZB NNTP Proxy ready (posting prohibited)")
+	}
 
-	// Read and process commands
-	scanner := bufio.NewScanner(conn)
+	// Read and process commands (POST/IHAVE read the article from the same scanner)
+	scanner := session.scanner
 	for scanner.Scan() {
 		line := scanner.Text()
 		line = strings.TrimSpace(line)
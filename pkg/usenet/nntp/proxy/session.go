@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"bufio"
 	"fmt"
 	"net"
 	"strings"
@@ -11,20 +12,26 @@ import (
 // Session represents a single NNTP client session
 type Session struct {
 	conn     net.Conn
+	scanner  *bufio.Scanner
 	pools    []*nntp.ClientPool
+	postPool *nntp.ClientPool // nil = posting prohibited
 	authUser string
 	authPass string
 
+	userAccepted  bool // AUTHINFO USER matched; PASS is only checked after it
 	authenticated bool
 	currentGroup  string
 	shouldQuit    bool
 }
 
-// NewSession creates a new NNTP session
-func NewSession(conn net.Conn, pools []*nntp.ClientPool, authUser, authPass string) *Session {
+// NewSession creates a new NNTP session. postPool is the upload provider for
+// POST/IHAVE (nil = read-only).
+func NewSession(conn net.Conn, pools []*nntp.ClientPool, postPool *nntp.ClientPool, authUser, authPass string) *Session {
 	return &Session{
 		conn:          conn,
+		scanner:       bufio.NewScanner(conn),
 		pools:         pools,
+		postPool:      postPool,
 		authUser:      authUser,
 		authPass:      authPass,
 		authenticated: authUser == "", // Auto-auth if no credentials required
@@ -91,6 +98,10 @@ func (s *Session) HandleCommand(cmd string, args []string) error {
 		return s.handleHead(args)
 	case "STAT":
 		return s.handleStat(args)
+	case "POST":
+		return s.handlePost(args)
+	case "IHAVE":
+		return s.handleIHave(args)
 	case "LIST":
 		return s.handleList(args)
 	case "DATE":
@@ -98,6 +109,9 @@ func (s *Session) HandleCommand(cmd string, args []string) error {
 	case "MODE":
 		// MODE READER - we are already in reader mode
 		if len(args) >= 1 && strings.ToUpper(args[0]) == "READER" {
+			if s.postPool != nil {
+				return s.WriteLine("200 StreamNZB proxy (reader mode, posting allowed)")
+			}
 			return s.WriteLine("201 StreamNZB proxy (reader mode)")
 		}
 		return s.WriteLine(fmt.Sprintf("500 Unknown command: %s", cmd))
@@ -1,7 +1,6 @@
 package nntp
 
 import (
-	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/core/persistence"
 	"sync"
 )
@@ -108,7 +107,7 @@ func (m *ProviderUsageManager) AddBytes(name string, delta int64) {
 // persistAndUpdateLast writes state and updates lastPersisted for all providers.
 func (m *ProviderUsageManager) persistAndUpdateLast() {
 	if err := m.save(); err != nil {
-		logger.Error("Failed to save provider usage data", "err", err)
+		log.Error("Failed to save provider usage data", "err", err)
 		return
 	}
 	m.mu.Lock()
@@ -149,7 +148,7 @@ func (m *ProviderUsageManager) SyncUsage(activeNames []string) {
 	changed := false
 	for name := range m.data {
 		if !activeMap[name] {
-			logger.Info("Removing orphaned usage data for provider", "name", name)
+			log.Info("Removing orphaned usage data for provider", "name", name)
 			delete(m.data, name)
 			delete(m.lastPersisted, name)
 			changed = true
@@ -159,7 +158,7 @@ func (m *ProviderUsageManager) SyncUsage(activeNames []string) {
 
 	if changed {
 		if err := m.save(); err != nil {
-			logger.Error("Failed to save provider usage data after sync", "err", err)
+			log.Error("Failed to save provider usage data after sync", "err", err)
 		}
 	}
 }
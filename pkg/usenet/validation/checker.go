@@ -3,6 +3,7 @@ package validation
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -11,12 +12,15 @@ import (
 	"streamnzb/pkg/core/logger"
 	"streamnzb/pkg/media/decode"
 	"streamnzb/pkg/media/nzb"
+	"streamnzb/pkg/media/unpack"
 	"streamnzb/pkg/usenet/nntp"
 
 	"github.com/javi11/rardecode/v2"
 	"github.com/javi11/sevenzip"
 )
 
+// log tags this package's messages; set "validation" in log_levels to change its level.
+var log = logger.For("validation")
 
 // Checker validates article availability across providers
 type Checker struct {
@@ -25,20 +29,74 @@ type Checker struct {
 	providerOrder []string // Provider names in priority order (for single-provider validation)
 	sampleSize    int
 	maxConcurrent int
+	minRatio      float64 // Minimum fraction of sampled articles that must exist (1.0 = all)
+	tailDepth     float64 // Percent of trailing segments checked separately (0 = disabled)
+	bodyTimeout   time.Duration
+	verifyCRC     bool
+	weights       map[string]int // Playback preference by provider name (SetProviderWeights)
 }
 
+// maxTailArticles caps the tail check so huge files don't STAT hundreds of articles.
+const maxTailArticles = 20
+
 // NewChecker creates a new article availability checker.
 // providerOrder is the list of provider names in priority order (used for cache warming).
 // cacheTTL is ignored (validation cache removed to avoid stale results).
-func NewChecker(providers map[string]*nntp.ClientPool, providerOrder []string, cacheTTL time.Duration, sampleSize, maxConcurrent int) *Checker {
+// minRatio is the minimum sampled-article completion for a provider to count as available;
+// values outside (0, 1] are treated as 1.0.
+func NewChecker(providers map[string]*nntp.ClientPool, providerOrder []string, cacheTTL time.Duration, sampleSize, maxConcurrent int, minRatio float64) *Checker {
+	if minRatio <= 0 || minRatio > 1 {
+		minRatio = 1.0
+	}
 	return &Checker{
 		providers:     providers,
 		providerOrder: providerOrder,
 		sampleSize:    sampleSize,
 		maxConcurrent: maxConcurrent,
+		minRatio:      minRatio,
 	}
 }
 
+// SetTailDepth makes validation also check the last pct percent of segments (0 = off).
+// Partial retention usually loses the tail first, while players need it for the
+// index (MP4 moov, MKV Cues); results report it as TailChecked/TailMissing.
+func (c *Checker) SetTailDepth(pct float64) {
+	if pct < 0 {
+		pct = 0
+	}
+	if pct > 100 {
+		pct = 100
+	}
+	c.mu.Lock()
+	c.tailDepth = pct
+	c.mu.Unlock()
+}
+
+// SetSegmentTimeout bounds each segment download (BODY probe) during validation so a
+// slow provider fails fast instead of holding up the search (0 = NNTP default).
+func (c *Checker) SetSegmentTimeout(d time.Duration) {
+	c.mu.Lock()
+	c.bodyTimeout = d
+	c.mu.Unlock()
+}
+
+// SetVerifyCRC makes the BODY probe reject segments whose yEnc CRC32 does not match
+// the trailer (result.Corrupt). When off, such segments are accepted on size alone.
+func (c *Checker) SetVerifyCRC(verify bool) {
+	c.mu.Lock()
+	c.verifyCRC = verify
+	c.mu.Unlock()
+}
+
+// SetProviderWeights sets the playback preference of providers by name (config
+// Provider.Weight). GetBestProvider picks the highest weight among providers that
+// validated equally well, and ValidateNZBEarly waits for heavier providers still running.
+func (c *Checker) SetProviderWeights(weights map[string]int) {
+	c.mu.Lock()
+	c.weights = weights
+	c.mu.Unlock()
+}
+
 // ValidationResult represents the result of article validation
 type ValidationResult struct {
 	Provider        string
@@ -47,7 +105,34 @@ type ValidationResult struct {
 	TotalArticles   int
 	CheckedArticles int
 	MissingArticles int
-	Error           error
+	// Tail check (SetTailDepth); not included in the counts above
+	TailChecked int
+	TailMissing int
+	// Corrupt is set when a probed article decoded with a CRC32 mismatch: the provider
+	// has the article but serves damaged data (as opposed to missing articles)
+	Corrupt bool
+	// Weight is the provider's configured playback preference (SetProviderWeights)
+	Weight int
+	Error  error
+}
+
+// Completion returns the fraction of checked articles that exist (1.0 when nothing was checked).
+func (r *ValidationResult) Completion() float64 {
+	if r == nil || r.CheckedArticles == 0 {
+		return 1.0
+	}
+	return float64(r.CheckedArticles-r.MissingArticles) / float64(r.CheckedArticles)
+}
+
+// TailComplete reports whether every checked tail article exists (true when the tail wasn't checked).
+func (r *ValidationResult) TailComplete() bool {
+	return r == nil || r.TailMissing == 0
+}
+
+// IsComplete reports whether the provider has every sampled article.
+// Used for AvailNZB reporting, which should not advertise partial releases as healthy.
+func (r *ValidationResult) IsComplete() bool {
+	return r != nil && r.Error == nil && r.Available && r.MissingArticles == 0
 }
 
 // GetProviderHosts returns a list of all configured provider hostnames
@@ -62,6 +147,18 @@ func (c *Checker) GetProviderHosts() []string {
 	return hosts
 }
 
+// GetProviderPools returns a snapshot of the provider pools keyed by provider name.
+func (c *Checker) GetProviderPools() map[string]*nntp.ClientPool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	pools := make(map[string]*nntp.ClientPool, len(c.providers))
+	for name, pool := range c.providers {
+		pools[name] = pool
+	}
+	return pools
+}
+
 // GetPrimaryProviderHost returns the highest-priority provider name for single-provider validation (e.g. cache warming).
 func (c *Checker) GetPrimaryProviderHost() string {
 	c.mu.RLock()
@@ -102,58 +199,130 @@ func (c *Checker) ValidateNZBSingleProviderExtended(ctx context.Context, nzbData
 	return c.validateProviderExtended(ctx, nzbData, providerName, pool)
 }
 
+// validationTimeout bounds a multi-provider validation so a hanging provider cannot
+// hold up the search.
+const validationTimeout = 30 * time.Second
+
 // ValidateNZB checks article availability for an NZB across all providers.
 // Each provider runs STAT + BODY/yEnc probe in parallel.
 func (c *Checker) ValidateNZB(ctx context.Context, nzbData *nzb.NZB) map[string]*ValidationResult {
-	results := make(map[string]*ValidationResult)
-	var mu sync.Mutex
-	var wg sync.WaitGroup
+	return c.validateAll(ctx, nzbData, false, nil)
+}
 
-	logger.Trace("ValidateNZB start", "hash", nzbData.Hash())
+// ValidateNZBEarly is ValidateNZB that returns as soon as one provider has every checked
+// article, head and tail, instead of waiting for slower providers. With rest nil the
+// remaining validations are cancelled; otherwise they finish in the background and rest
+// receives their results (e.g. for AvailNZB reports). rest is only called after an
+// early return.
+func (c *Checker) ValidateNZBEarly(ctx context.Context, nzbData *nzb.NZB, rest func(map[string]*ValidationResult)) map[string]*ValidationResult {
+	return c.validateAll(ctx, nzbData, true, rest)
+}
+
+func (c *Checker) validateAll(ctx context.Context, nzbData *nzb.NZB, earlyExit bool, rest func(map[string]*ValidationResult)) map[string]*ValidationResult {
+	log.Trace("ValidateNZB start", "hash", nzbData.Hash())
 
-	// Validate across all providers in parallel
 	c.mu.RLock()
-	providers := c.providers
+	providers, weights := c.providers, c.weights
 	c.mu.RUnlock()
 
-	for providerName, pool := range providers {
-		wg.Add(1)
-		go func(name string, p *nntp.ClientPool) {
-			defer wg.Done()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	return collectResults(ctx, names, weights, func(ctx context.Context, name string) *ValidationResult {
+		result := c.validateProviderExtended(ctx, nzbData, name, providers[name])
+		result.Weight = weights[name]
+		return result
+	}, earlyExit, rest)
+}
 
-			result := c.validateProviderExtended(ctx, nzbData, name, p)
+type providerResult struct {
+	name   string
+	result *ValidationResult
+}
 
-			mu.Lock()
-			results[name] = result
-			mu.Unlock()
-		}(providerName, pool)
+// collectResults runs validate for every provider in parallel and gathers the results,
+// returning partial results when ctx ends or validationTimeout passes. With earlyExit it
+// returns once a provider is complete (see ValidateNZBEarly) and no provider with a
+// higher weight is still running.
+func collectResults(ctx context.Context, names []string, weights map[string]int, validate func(context.Context, string) *ValidationResult,
+	earlyExit bool, rest func(map[string]*ValidationResult)) map[string]*ValidationResult {
+	// Validations that outlive an early return for rest must not end with the caller's ctx
+	runCtx, cancel := context.WithCancel(ctx)
+	if earlyExit && rest != nil {
+		runCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), validationTimeout)
 	}
 
-	// Wait for all validations with timeout to prevent hanging
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	select {
-	case <-done:
-		logger.Trace("ValidateNZB: all providers done", "results", len(results))
-	case <-ctx.Done():
-		// Context cancelled, return partial results
-		logger.Debug("Validation cancelled, returning partial results")
-		logger.Trace("ValidateNZB: ctx.Done", "partial_results", len(results))
-		return results
-	case <-time.After(30 * time.Second):
-		// Timeout after 30 seconds to prevent hanging
-		logger.Warn("Validation timeout, returning partial results", "providers", len(providers))
-		logger.Trace("ValidateNZB: 30s timeout", "partial_results", len(results))
-		return results
+	resultCh := make(chan providerResult, len(names))
+	for _, name := range names {
+		go func() {
+			resultCh <- providerResult{name, validate(runCtx, name)}
+		}()
 	}
 
+	results := make(map[string]*ValidationResult, len(names))
+	timeout := time.NewTimer(validationTimeout)
+	defer timeout.Stop()
+	for len(results) < len(names) {
+		select {
+		case r := <-resultCh:
+			results[r.name] = r.result
+			if pending := len(names) - len(results); earlyExit && pending > 0 && r.result.IsComplete() && r.result.TailComplete() &&
+				!heavierPending(names, results, weights, weights[r.name]) {
+				log.Debug("Provider has the release, not waiting for the others", "provider", r.name, "pending", pending)
+				if rest == nil {
+					cancel()
+				} else {
+					go finishResults(runCtx, cancel, resultCh, pending, rest)
+				}
+				return results
+			}
+		case <-ctx.Done():
+			cancel()
+			// Context cancelled, return partial results
+			log.Debug("Validation cancelled, returning partial results")
+			log.Trace("ValidateNZB: ctx.Done", "partial_results", len(results))
+			return results
+		case <-timeout.C:
+			cancel()
+			log.Warn("Validation timeout, returning partial results", "providers", len(names))
+			log.Trace("ValidateNZB: timeout", "partial_results", len(results))
+			return results
+		}
+	}
+	cancel()
+	log.Trace("ValidateNZB: all providers done", "results", len(results))
 	return results
 }
 
+// heavierPending reports whether a provider without a result yet has a weight above weight.
+func heavierPending(names []string, results map[string]*ValidationResult, weights map[string]int, weight int) bool {
+	for _, name := range names {
+		if _, done := results[name]; !done && weights[name] > weight {
+			return true
+		}
+	}
+	return false
+}
+
+// finishResults waits for the validations still running after an early return and hands
+// their results to rest.
+func finishResults(ctx context.Context, cancel context.CancelFunc, resultCh <-chan providerResult, pending int, rest func(map[string]*ValidationResult)) {
+	defer cancel()
+	results := make(map[string]*ValidationResult, pending)
+	for len(results) < pending {
+		select {
+		case r := <-resultCh:
+			results[r.name] = r.result
+		case <-ctx.Done():
+			log.Debug("Background validation timeout", "finished", len(results), "pending", pending)
+			rest(results)
+			return
+		}
+	}
+	rest(results)
+}
+
 // InvalidateCache is a no-op (validation cache removed)
 func (c *Checker) InvalidateCache(hash string) {}
 
@@ -174,17 +343,16 @@ func (c *Checker) validateProvider(ctx context.Context, nzbData *nzb.NZB, provid
 	articles := c.getSampleArticles(nzbData)
 	result.TotalArticles = len(nzbData.Files[0].Segments)
 	result.CheckedArticles = len(articles)
+	tail := c.getTailArticles(nzbData, articles)
+	result.TailChecked = len(tail)
 
-	client, ok := pool.TryGet(ctx)
-	if !ok {
-		var err error
-		waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-		defer cancel()
-		client, err = pool.Get(waitCtx)
-		if err != nil {
-			result.Error = fmt.Errorf("pool busy: %w", err)
-			return result
-		}
+	// Background work: never take the connections reserved for playback
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	client, err := pool.GetBackground(waitCtx)
+	if err != nil {
+		result.Error = fmt.Errorf("pool busy: %w", err)
+		return result
 	}
 
 	releaseAsOk := false
@@ -199,12 +367,19 @@ func (c *Checker) validateProvider(ctx context.Context, nzbData *nzb.NZB, provid
 	// Stat articles in parallel (with concurrency limit)
 	type statResult struct {
 		exists bool
+		tail   bool
 		err    error
 	}
-	statChan := make(chan statResult, len(articles))
+	total := len(articles) + len(tail)
+	statChan := make(chan statResult, total)
 	sem := make(chan struct{}, maxOr(c.maxConcurrent, 5))
-	for _, articleID := range articles {
-		articleID := articleID
+	for i := 0; i < total; i++ {
+		articleID, isTail := "", i >= len(articles)
+		if isTail {
+			articleID = tail[i-len(articles)]
+		} else {
+			articleID = articles[i]
+		}
 		select {
 		case <-ctx.Done():
 			result.Error = ctx.Err()
@@ -215,11 +390,11 @@ func (c *Checker) validateProvider(ctx context.Context, nzbData *nzb.NZB, provid
 			sem <- struct{}{}
 			defer func() { <-sem }()
 			exists, err := client.StatArticle(articleID)
-			statChan <- statResult{exists, err}
+			statChan <- statResult{exists, isTail, err}
 		}()
 	}
 	missing := 0
-	for range articles {
+	for i := 0; i < total; i++ {
 		select {
 		case <-ctx.Done():
 			result.Error = ctx.Err()
@@ -229,7 +404,9 @@ func (c *Checker) validateProvider(ctx context.Context, nzbData *nzb.NZB, provid
 				result.Error = res.err
 				return result
 			}
-			if !res.exists {
+			if !res.exists && res.tail {
+				result.TailMissing++
+			} else if !res.exists {
 				missing++
 			}
 		}
@@ -237,9 +414,9 @@ func (c *Checker) validateProvider(ctx context.Context, nzbData *nzb.NZB, provid
 
 	releaseAsOk = true
 	result.MissingArticles = missing
-	result.Available = missing == 0
+	result.Available = missing == 0 || result.Completion() >= c.minRatio
 
-	logger.Debug("Provider check", "provider", providerName, "available", result.CheckedArticles-missing, "total", result.CheckedArticles)
+	log.Debug("Provider check", "provider", providerName, "available", result.CheckedArticles-missing, "total", result.CheckedArticles, "accepted", result.Available, "tail_missing", result.TailMissing, "tail_checked", result.TailChecked)
 
 	return result
 }
@@ -261,16 +438,16 @@ func (c *Checker) validateProviderExtended(ctx context.Context, nzbData *nzb.NZB
 
 	// Pick probe indices: first, last, middle -- deduplicated for small files.
 	probeIndices := probeSegmentIndices(len(segments))
+	c.mu.RLock()
+	bodyTimeout := c.bodyTimeout
+	verifyCRC := c.verifyCRC
+	c.mu.RUnlock()
 
-	client, ok := pool.TryGet(ctx)
-	if !ok {
-		waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-		defer cancel()
-		var err error
-		client, err = pool.Get(waitCtx)
-		if err != nil {
-			return result
-		}
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	client, err := pool.GetBackground(waitCtx)
+	if err != nil {
+		return result
 	}
 
 	releaseOk := false
@@ -291,25 +468,37 @@ func (c *Checker) validateProviderExtended(ctx context.Context, nzbData *nzb.NZB
 	var lastSegData []byte
 
 	for _, idx := range probeIndices {
-		body, err := client.Body(segments[idx].ID)
+		body, err := client.BodyTimeout(segments[idx].ID, bodyTimeout)
 		if err != nil {
 			result.Available = false
 			result.Error = fmt.Errorf("body probe segment %d: %w", idx, err)
-			logger.Debug("Extended check BODY failed", "provider", providerName, "segment", idx, "err", err)
+			log.Debug("Extended check BODY failed", "provider", providerName, "segment", idx, "err", err)
 			return result
 		}
 		frame, err := decode.DecodeToBytes(body)
+		if errors.Is(err, decode.ErrCRCMismatch) {
+			if verifyCRC {
+				releaseOk = true // article was read completely; the connection is fine
+				result.Available = false
+				result.Corrupt = true
+				result.Error = fmt.Errorf("probe segment %d: %w", idx, err)
+				log.Debug("Extended check CRC mismatch", "provider", providerName, "segment", idx, "err", err)
+				return result
+			}
+			log.Trace("Extended check ignoring CRC mismatch", "provider", providerName, "segment", idx)
+			err = nil
+		}
 		if err != nil {
 			_, _ = io.Copy(io.Discard, body)
 			result.Available = false
 			result.Error = fmt.Errorf("decode probe segment %d: %w", idx, err)
-			logger.Debug("Extended check decode failed", "provider", providerName, "segment", idx, "err", err)
+			log.Debug("Extended check decode failed", "provider", providerName, "segment", idx, "err", err)
 			return result
 		}
 		if len(frame.Data) == 0 {
 			result.Available = false
 			result.Error = fmt.Errorf("probe segment %d decoded to empty data", idx)
-			logger.Debug("Extended check empty segment", "provider", providerName, "segment", idx)
+			log.Debug("Extended check empty segment", "provider", providerName, "segment", idx)
 			return result
 		}
 		if idx == 0 {
@@ -331,13 +520,13 @@ func (c *Checker) validateProviderExtended(ctx context.Context, nzbData *nzb.NZB
 		if err := verifyArchiveHeader(ct, firstSegData, lastSegData, info); err != nil {
 			result.Available = false
 			result.Error = err
-			logger.Debug("Extended check archive header failed", "provider", providerName, "compression", ct, "err", err)
+			log.Debug("Extended check archive header failed", "provider", providerName, "compression", ct, "err", err)
 			return result
 		}
 	}
 
 	releaseOk = true
-	logger.Debug("Extended check passed", "provider", providerName, "probed", len(probeIndices), "compression", ct)
+	log.Debug("Extended check passed", "provider", providerName, "probed", len(probeIndices), "compression", ct)
 	return result
 }
 
@@ -419,10 +608,55 @@ func (c *Checker) getSampleArticles(nzbData *nzb.NZB) []string {
 	return articles
 }
 
+// getTailArticles returns up to maxTailArticles segments spread over the last
+// tailDepth percent of the playback file, skipping ones already in sampled.
+func (c *Checker) getTailArticles(nzbData *nzb.NZB, sampled []string) []string {
+	c.mu.RLock()
+	depth := c.tailDepth
+	c.mu.RUnlock()
+	if depth <= 0 || len(nzbData.Files) == 0 {
+		return nil
+	}
+
+	var file *nzb.File
+	if info := nzbData.GetPlaybackFile(); info != nil {
+		file = info.File
+	} else {
+		file = &nzbData.Files[0]
+	}
+	segments := file.Segments
+	span := int(float64(len(segments)) * depth / 100)
+	if span < 1 {
+		span = 1
+	}
+	start := len(segments) - span
+	if start < 0 {
+		start = 0
+	}
+
+	seen := make(map[string]bool, len(sampled))
+	for _, id := range sampled {
+		seen[id] = true
+	}
+	step := 1.0
+	if span > maxTailArticles {
+		step = float64(span) / float64(maxTailArticles)
+	}
+	var articles []string
+	for f := float64(start); int(f) < len(segments) && len(articles) < maxTailArticles; f += step {
+		id := segments[int(f)].ID
+		if !seen[id] {
+			seen[id] = true
+			articles = append(articles, id)
+		}
+	}
+	return articles
+}
+
 // verifyArchiveHeader parses downloaded segment data to confirm the archive
 // is valid and uses STORE mode (required for streaming).
 //
-// For RAR: feeds the first segment to rardecode and checks the Stored flag.
+// For RAR: feeds the first segment to rardecode and checks the Stored and Encrypted flags.
 // For 7z: builds a sparse ReaderAt from first + last segment data and uses
 // sevenzip.NewReader to parse the encoded header (which lives at the archive tail).
 func verifyArchiveHeader(ct string, firstSeg, lastSeg []byte, info *nzb.FileInfo) error {
@@ -433,9 +667,15 @@ func verifyArchiveHeader(ct string, firstSeg, lastSeg []byte, info *nzb.FileInfo
 			return fmt.Errorf("invalid RAR archive: %w", err)
 		}
 		hdr, err := r.Next()
+		if errors.Is(err, rardecode.ErrArchiveEncrypted) {
+			return fmt.Errorf("%w (RAR headers)", unpack.ErrEncryptedArchive)
+		}
 		if err != nil {
 			return fmt.Errorf("cannot read RAR file entry: %w", err)
 		}
+		if hdr.Encrypted {
+			return fmt.Errorf("%w (file: %s)", unpack.ErrEncryptedArchive, hdr.Name)
+		}
 		if !hdr.Stored {
 			return fmt.Errorf("RAR archive uses compression (STORE mode required for streaming)")
 		}
@@ -484,13 +724,22 @@ func verify7zHeader(headData, tailData []byte, info *nzb.FileInfo) error {
 func parse7z(ra io.ReaderAt, size int64) error {
 	r, err := sevenzip.NewReader(ra, size)
 	if err != nil {
+		if unpack.Is7zEncryptedError(err) {
+			return fmt.Errorf("%w (7z headers)", unpack.ErrEncryptedArchive)
+		}
 		return fmt.Errorf("invalid 7z archive: %w", err)
 	}
 	infos, err := r.ListFilesWithOffsets()
 	if err != nil {
+		if unpack.Is7zEncryptedError(err) {
+			return fmt.Errorf("%w (7z headers)", unpack.ErrEncryptedArchive)
+		}
 		return fmt.Errorf("cannot list 7z contents: %w", err)
 	}
 	for _, fi := range infos {
+		if fi.Encrypted {
+			return fmt.Errorf("%w (file: %s)", unpack.ErrEncryptedArchive, fi.Name)
+		}
 		if fi.Size > 50*1024*1024 && fi.Compressed {
 			return fmt.Errorf("7z archive uses compression (STORE mode required for streaming)")
 		}
@@ -553,31 +802,33 @@ func (s *sparse7zReader) ReadAt(p []byte, off int64) (int, error) {
 	return n, nil
 }
 
-// GetBestProvider returns the provider with highest availability
+// GetBestProvider returns the provider with highest availability, preferring
+// providers whose tail check passed (the file index lives at the end). Among equally
+// available providers the highest Weight wins, then the provider name.
 func GetBestProvider(results map[string]*ValidationResult) *ValidationResult {
 	var bestResult *ValidationResult
-	var bestScore float64
-
 	for _, result := range results {
 		if result.Error != nil || !result.Available {
 			continue
 		}
-
-		// Calculate completion percentage
-		var score float64
-		if result.CheckedArticles == 0 {
-			// Special case for skipped validation (trusted availability)
-			// If Available is true (checked above), treat as 100%
-			score = 1.0
-		} else {
-			score = float64(result.CheckedArticles-result.MissingArticles) / float64(result.CheckedArticles)
-		}
-
-		if score >= bestScore { // Use >= to pick the first one even if score is 0 or equal
-			bestScore = score
+		if bestResult == nil || betterProvider(result, bestResult) {
 			bestResult = result
 		}
 	}
-
 	return bestResult
 }
+
+// betterProvider reports whether a is preferred over b for playback.
+func betterProvider(a, b *ValidationResult) bool {
+	if aTail, bTail := a.TailComplete(), b.TailComplete(); aTail != bTail {
+		return aTail
+	}
+	// Skipped validation counts as 100%
+	if aScore, bScore := a.Completion(), b.Completion(); aScore != bScore {
+		return aScore > bScore
+	}
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	return a.Provider < b.Provider
+}
@@ -0,0 +1,126 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"streamnzb/pkg/core/logger"
+	"streamnzb/pkg/media/nzb"
+)
+
+func TestGetTailArticles(t *testing.T) {
+	logger.Init("DEBUG")
+	file := nzb.File{Subject: `"movie.mkv" yEnc (1/1000)`}
+	for i := 0; i < 1000; i++ {
+		file.Segments = append(file.Segments, nzb.Segment{Number: i + 1, ID: fmt.Sprintf("seg%d@x", i)})
+	}
+	n := &nzb.NZB{Files: []nzb.File{file}}
+
+	c := NewChecker(nil, nil, 0, 5, 5, 1)
+	if got := c.getTailArticles(n, nil); got != nil {
+		t.Fatalf("tail check disabled, got %d articles", len(got))
+	}
+
+	c.SetTailDepth(5) // last 50 segments, capped at maxTailArticles
+	sampled := c.getSampleArticles(n)
+	tail := c.getTailArticles(n, sampled)
+	if len(tail) == 0 || len(tail) > maxTailArticles {
+		t.Fatalf("expected 1..%d tail articles, got %d", maxTailArticles, len(tail))
+	}
+	for _, id := range tail {
+		var idx int
+		fmt.Sscanf(id, "seg%d@x", &idx)
+		if idx < 950 {
+			t.Errorf("tail article %s outside the last 5%%", id)
+		}
+		if id == "seg999@x" {
+			t.Errorf("last segment is already sampled and must not be repeated")
+		}
+	}
+}
+
+func TestGetBestProviderPrefersIntactTail(t *testing.T) {
+	results := map[string]*ValidationResult{
+		"a": {Provider: "a", Available: true, CheckedArticles: 10, TailChecked: 5, TailMissing: 2},
+		"b": {Provider: "b", Available: true, CheckedArticles: 10, MissingArticles: 1, TailChecked: 5},
+	}
+	if best := GetBestProvider(results); best == nil || best.Provider != "b" {
+		t.Fatalf("expected provider with intact tail, got %+v", best)
+	}
+}
+
+func TestGetBestProviderPrefersWeight(t *testing.T) {
+	results := map[string]*ValidationResult{
+		"a": {Provider: "a", Available: true, CheckedArticles: 10},
+		"b": {Provider: "b", Available: true, CheckedArticles: 10, Weight: 5},
+		"c": {Provider: "c", Available: true, CheckedArticles: 10, MissingArticles: 1, Weight: 10},
+	}
+	if best := GetBestProvider(results); best == nil || best.Provider != "b" {
+		t.Fatalf("expected the heavier of the complete providers, got %+v", best)
+	}
+	results["b"].Weight = 0
+	if best := GetBestProvider(results); best == nil || best.Provider != "a" {
+		t.Fatalf("expected ties broken by name, got %+v", best)
+	}
+}
+
+func TestCollectResultsEarlyExit(t *testing.T) {
+	logger.Init("DEBUG")
+	names := []string{"fast", "slow"}
+	validate := func(ctx context.Context, name string) *ValidationResult {
+		if name == "slow" {
+			select {
+			case <-ctx.Done():
+				return &ValidationResult{Provider: name, Error: ctx.Err()}
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		return &ValidationResult{Provider: name, Available: true, CheckedArticles: 10}
+	}
+
+	start := time.Now()
+	results := collectResults(context.Background(), names, nil, validate, true, nil)
+	if len(results) != 1 || results["fast"] == nil || time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("early exit returned %v after %v, want only the fast provider", results, time.Since(start))
+	}
+
+	// With rest the slow provider finishes in the background, even after the caller's ctx ends
+	ctx, cancel := context.WithCancel(context.Background())
+	restCh := make(chan map[string]*ValidationResult, 1)
+	results = collectResults(ctx, names, nil, validate, true, func(r map[string]*ValidationResult) { restCh <- r })
+	cancel()
+	if len(results) != 1 {
+		t.Fatalf("early exit returned %d results, want 1", len(results))
+	}
+	select {
+	case rest := <-restCh:
+		if r := rest["slow"]; r == nil || !r.IsComplete() {
+			t.Errorf("background result = %+v, want the slow provider complete", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("rest was not called")
+	}
+
+	// Without early exit every provider is waited for
+	if results := collectResults(context.Background(), names, nil, validate, false, nil); len(results) != 2 {
+		t.Errorf("full validation returned %d results, want 2", len(results))
+	}
+
+	// An incomplete provider does not end validation early
+	partial := func(ctx context.Context, name string) *ValidationResult {
+		if name == "fast" {
+			return &ValidationResult{Provider: name, Available: true, CheckedArticles: 10, MissingArticles: 1}
+		}
+		return validate(ctx, name)
+	}
+	if results := collectResults(context.Background(), names, nil, partial, true, nil); len(results) != 2 {
+		t.Errorf("validation with an incomplete provider returned %d results, want 2", len(results))
+	}
+
+	// A complete provider does not end validation early while a preferred one is running
+	if results := collectResults(context.Background(), names, map[string]int{"slow": 10}, validate, true, nil); len(results) != 2 {
+		t.Errorf("validation with a heavier provider pending returned %d results, want 2", len(results))
+	}
+}